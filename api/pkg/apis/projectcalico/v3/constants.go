@@ -40,6 +40,20 @@ const (
 	// orchestrator.
 	LabelOrchestrator = "projectcalico.org/orchestrator"
 
+	// Label used to scope a GlobalNetworkSet to one node.  Felix ignores a GlobalNetworkSet with
+	// this label unless the label's value is its own node name.
+	LabelNodeScope = "projectcalico.org/node-scope"
+
+	// Label set to "true" on the GlobalNetworkSets of sources that Felix found exceeding the
+	// automatic blocklist connection rate.  Untracked deny policies can select it to drop their
+	// traffic with XDP.
+	LabelAutoBlocklist = "projectcalico.org/auto-blocklist"
+
+	// Annotation that Felix sets on its Node to export the sources that exceeded the automatic
+	// blocklist connection rate.  The value is a JSON object that maps each source IP to the time
+	// it was last recorded.
+	AnnotationAutoBlocklistSources = "projectcalico.org/auto-blocklist-sources"
+
 	// Known orchestrators.  Orchestrators are not limited to this list.
 	OrchestratorKubernetes = "k8s"
 	OrchestratorCNI        = "cni"
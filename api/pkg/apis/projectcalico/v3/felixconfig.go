@@ -353,6 +353,42 @@ type FelixConfigurationSpec struct {
 	// iptables. [Default: false]
 	GenericXDPEnabled *bool `json:"genericXDPEnabled,omitempty" confignamev1:"GenericXDPEnabled"`
 
+	// XDPAutoBlocklistConnRate, if non-zero, makes Felix record the sources that open new TCP
+	// connections to a host endpoint faster than this many per second, and export them in the
+	// projectcalico.org/auto-blocklist-sources annotation of its Node. While an XDPAutoBlocklist
+	// resource exists, the auto-blocklist controller in calico-kube-controllers adds them to the
+	// GlobalNetworkSet auto-blocklist.<node name>, which is labelled
+	// projectcalico.org/auto-blocklist=true and only applies on that node, so that an untracked
+	// deny policy that selects it drops their traffic with XDP. Connections to the inbound
+	// failsafe ports, and from Calico hosts, are not counted. [Default: 0]
+	XDPAutoBlocklistConnRate *int `json:"xdpAutoBlocklistConnRate,omitempty" validate:"omitempty,gte=0"`
+
+	// XDPUpdateDebounce is how long Felix holds back changes to IP sets that are used by XDP
+	// policy, counted from the first change, so that a burst of changes is written to the XDP
+	// maps in one batch. Set to 0 to write changes as soon as possible. [Default: 0s]
+	XDPUpdateDebounce *metav1.Duration `json:"xdpUpdateDebounce,omitempty" configv1timescale:"milliseconds"`
+
+	// XDPEgressBlocklistEnabled, if enabled, attaches a TC egress program alongside each XDP
+	// program so that the host also can't send packets to the addresses on the XDP blocklist.
+	// Replies from failsafe inbound ports are still allowed. [Default: false]
+	XDPEgressBlocklistEnabled *bool `json:"xdpEgressBlocklistEnabled,omitempty"`
+
+	// XDPMaxBlocklistEntries is the most CIDRs that Felix puts in the XDP blocklist of each
+	// interface. When the blocklists of an interface's untracked deny policies add up to more
+	// than that, Felix leaves out the extra CIDRs, logs a warning and reports how many it left
+	// out in the felix_xdp_blocklist_skipped_entries metric; their traffic is still denied by
+	// iptables. The extra CIDRs are added when there is room for them. [Default: 10240]
+	XDPMaxBlocklistEntries *int `json:"xdpMaxBlocklistEntries,omitempty" validate:"omitempty,gte=1,lte=10240"`
+
+	// XDPMinInterfaceSpeed is the slowest link speed, in Mbit/s, of an interface that Felix
+	// attaches XDP programs to. When a host endpoint resolves to an interface that reports a
+	// slower speed, Felix logs that it is skipping XDP on the interface and leaves the
+	// interface's untracked policy to iptables. Felix checks the speed again whenever the
+	// interface's state or addresses change, so an interface whose link slows down or speeds up
+	// moves between the two. Interfaces that don't report a speed are not skipped. Set to 0 to
+	// use XDP whatever the speed. [Default: 0]
+	XDPMinInterfaceSpeed *int `json:"xdpMinInterfaceSpeed,omitempty" validate:"omitempty,gte=0"`
+
 	// BPFEnabled, if enabled Felix will use the BPF dataplane. [Default: false]
 	BPFEnabled *bool `json:"bpfEnabled,omitempty" validate:"omitempty"`
 	// BPFDisableUnprivileged, if enabled, Felix sets the kernel.unprivileged_bpf_disabled sysctl to disable
@@ -364,6 +400,12 @@ type FelixConfigurationSpec struct {
 	// [Default: Off].
 	// +optional
 	BPFLogLevel string `json:"bpfLogLevel" validate:"omitempty,bpfLogLevel"`
+	// XDPLogLevel controls which packets the XDP programs report to their events ring buffer when in
+	// BPF dataplane mode.  One of "Off", "Error" (dropped packets only), or "Debug" (all packets that
+	// go through XDP policy).  Only the debug build of the programs has the ring buffer, so the events
+	// also need BPFLogLevel to be "Debug". [Default: Off].
+	// +optional
+	XDPLogLevel string `json:"xdpLogLevel,omitempty" validate:"omitempty,xdpLogLevel"`
 	// BPFDataIfacePattern is a regular expression that controls which interfaces Felix should attach BPF programs to
 	// in order to catch traffic to/from the network.  This needs to match the interfaces that Calico workload traffic
 	// flows over as well as any interfaces that handle incoming traffic to nodeports and services from outside the
@@ -16,6 +16,8 @@ package v3
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/projectcalico/api/pkg/lib/numorstring"
 )
 
 const (
@@ -49,6 +51,36 @@ type GlobalNetworkSet struct {
 type GlobalNetworkSetSpec struct {
 	// The list of IP networks that belong to this set.
 	Nets []string `json:"nets,omitempty" validate:"omitempty,dive,cidr"`
+	// Optional expiry times for entries in Nets, keyed on the entry as it appears in Nets.  Once an
+	// entry's expiry time has passed, Felix stops treating it as a member of the set, without the
+	// GlobalNetworkSet needing to be updated.  This allows blocklists that are fed from external
+	// sources to give their entries a TTL.
+	NetExpiries map[string]metav1.Time `json:"netExpiries,omitempty" validate:"omitempty,dive,keys,cidr,endkeys"`
+	// Optional grace periods for entries in Nets, keyed on the entry as it appears in Nets; each
+	// value is the time at which the entry's grace period ends.  Until then, Felix doesn't treat the
+	// entry as a member of the set but, where the set feeds an XDP blocklist, it counts the packets
+	// from the entry's addresses and lets them through.  This allows the impact of a new blocklist
+	// entry to be checked before it's enforced.
+	NetGraceUntil map[string]metav1.Time `json:"netGraceUntil,omitempty" validate:"omitempty,dive,keys,cidr,endkeys"`
+	// Optional reason codes for entries in Nets, keyed on the entry as it appears in Nets, for
+	// auditing. Felix records the reason for each entry in a BPF map alongside the XDP blocklist so
+	// that it's possible to find out why an address is blocked; for example, which threat feed it
+	// came from. Reasons may be up to 32 characters long.
+	NetReasons map[string]string `json:"netReasons,omitempty" validate:"omitempty,dive,keys,cidr,endkeys,max=32"`
+	// Optional URL of an external feed of IP networks, for example a threat feed.  The URL must use
+	// the http or https scheme, its host must be listed in the FEED_ALLOWED_HOSTS environment
+	// variable of calico-kube-controllers, and it should return one IP address or CIDR per line;
+	// blank lines and lines starting with "#" are ignored.  When set, the networksetfeed controller
+	// in calico-kube-controllers periodically fetches the feed and replaces Nets with its contents.
+	// A feed with no valid entries is treated as an error and leaves Nets unchanged.
+	FeedURL string `json:"feedURL,omitempty" validate:"omitempty,url"`
+	// How often to fetch the feed given by FeedURL.  Defaults to 5m.
+	FeedRefresh *metav1.Duration `json:"feedRefresh,omitempty"`
+	// Optional autonomous system numbers whose networks belong to this set.  When set, the
+	// networksetasn controller in calico-kube-controllers expands them into CIDRs, using the
+	// IP-to-ASN dataset given by its ASN_DATASET_FILE environment variable, and replaces Nets with
+	// the result.  Can't be combined with FeedURL.
+	ASNumbers []numorstring.ASNumber `json:"asNumbers,omitempty"`
 }
 
 // NewGlobalNetworkSet creates a new (zeroed) NetworkSet struct with the TypeMetadata initialised to the current
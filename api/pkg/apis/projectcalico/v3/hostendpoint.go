@@ -82,8 +82,20 @@ type HostEndpointSpec struct {
 	Profiles []string `json:"profiles,omitempty" validate:"omitempty,dive,name"`
 	// Ports contains the endpoint's named ports, which may be referenced in security policy rules.
 	Ports []EndpointPort `json:"ports,omitempty" validate:"dive"`
+	// XDPMode overrides the mode in which Felix attaches its XDP program to this endpoint's
+	// interface: "Native" (in the driver) or "Generic" (in the kernel's network stack, which is
+	// slower but works with any driver).  When not set, Felix uses the most efficient mode that
+	// the driver supports, falling back to generic mode if GenericXDPEnabled is true.
+	XDPMode XDPMode `json:"xdpMode,omitempty" validate:"omitempty,oneof=Native Generic"`
 }
 
+type XDPMode string
+
+const (
+	XDPModeNative  XDPMode = "Native"
+	XDPModeGeneric XDPMode = "Generic"
+)
+
 type EndpointPort struct {
 	Name     string               `json:"name" validate:"portName"`
 	Protocol numorstring.Protocol `json:"protocol"`
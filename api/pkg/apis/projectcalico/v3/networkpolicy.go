@@ -100,6 +100,14 @@ type NetworkPolicySpec struct {
 
 	// ServiceAccountSelector is an optional field for an expression used to select a pod based on service accounts.
 	ServiceAccountSelector string `json:"serviceAccountSelector,omitempty" validate:"selector"`
+
+	// DoNotTrack indicates whether packets matched by the rules in this policy should go through
+	// the data plane's connection tracking, such as Linux conntrack.  If True, the rules in
+	// this policy are applied before any data plane connection tracking, and packets allowed by
+	// this policy are marked as not to be tracked.  Untracked policy only applies to host
+	// endpoints, so an untracked NetworkPolicy only applies to the host endpoints that are
+	// associated with its namespace by the label "projectcalico.org/namespace: <namespace>".
+	DoNotTrack bool `json:"doNotTrack,omitempty"`
 }
 
 // NewNetworkPolicy creates a new (zeroed) NetworkPolicy struct with the TypeMetadata initialised to the current
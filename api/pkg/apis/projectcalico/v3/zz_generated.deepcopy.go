@@ -1286,6 +1286,31 @@ func (in *FelixConfigurationSpec) DeepCopyInto(out *FelixConfigurationSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.XDPAutoBlocklistConnRate != nil {
+		in, out := &in.XDPAutoBlocklistConnRate, &out.XDPAutoBlocklistConnRate
+		*out = new(int)
+		**out = **in
+	}
+	if in.XDPUpdateDebounce != nil {
+		in, out := &in.XDPUpdateDebounce, &out.XDPUpdateDebounce
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.XDPEgressBlocklistEnabled != nil {
+		in, out := &in.XDPEgressBlocklistEnabled, &out.XDPEgressBlocklistEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.XDPMaxBlocklistEntries != nil {
+		in, out := &in.XDPMaxBlocklistEntries, &out.XDPMaxBlocklistEntries
+		*out = new(int)
+		**out = **in
+	}
+	if in.XDPMinInterfaceSpeed != nil {
+		in, out := &in.XDPMinInterfaceSpeed, &out.XDPMinInterfaceSpeed
+		*out = new(int)
+		**out = **in
+	}
 	if in.BPFEnabled != nil {
 		in, out := &in.BPFEnabled, &out.BPFEnabled
 		*out = new(bool)
@@ -1635,6 +1660,37 @@ func (in *GlobalNetworkSetSpec) DeepCopyInto(out *GlobalNetworkSetSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NetExpiries != nil {
+		in, out := &in.NetExpiries, &out.NetExpiries
+		*out = make(map[string]v1.Time, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.NetGraceUntil != nil {
+		in, out := &in.NetGraceUntil, &out.NetGraceUntil
+		*out = make(map[string]v1.Time, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.NetReasons != nil {
+		in, out := &in.NetReasons, &out.NetReasons
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FeedRefresh != nil {
+		in, out := &in.FeedRefresh, &out.FeedRefresh
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ASNumbers != nil {
+		in, out := &in.ASNumbers, &out.ASNumbers
+		*out = make([]numorstring.ASNumber, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -2753,6 +2753,40 @@ func schema_pkg_apis_projectcalico_v3_FelixConfigurationSpec(ref common.Referenc
 							Format:      "",
 						},
 					},
+					"xdpAutoBlocklistConnRate": {
+						SchemaProps: spec.SchemaProps{
+							Description: "XDPAutoBlocklistConnRate, if non-zero, makes Felix record the sources that open new TCP connections to a host endpoint faster than this many per second, and export them in the projectcalico.org/auto-blocklist-sources annotation of its Node. While an XDPAutoBlocklist resource exists, the auto-blocklist controller in calico-kube-controllers adds them to the GlobalNetworkSet auto-blocklist.<node name>, which is labelled projectcalico.org/auto-blocklist=true and only applies on that node, so that an untracked deny policy that selects it drops their traffic with XDP. Connections to the inbound failsafe ports, and from Calico hosts, are not counted. [Default: 0]",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"xdpUpdateDebounce": {
+						SchemaProps: spec.SchemaProps{
+							Description: "XDPUpdateDebounce is how long Felix holds back changes to IP sets that are used by XDP policy, counted from the first change, so that a burst of changes is written to the XDP maps in one batch. Set to 0 to write changes as soon as possible. [Default: 0s]",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+					"xdpEgressBlocklistEnabled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "XDPEgressBlocklistEnabled, if enabled, attaches a TC egress program alongside each XDP program so that the host also can't send packets to the addresses on the XDP blocklist. Replies from failsafe inbound ports are still allowed. [Default: false]",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"xdpMaxBlocklistEntries": {
+						SchemaProps: spec.SchemaProps{
+							Description: "XDPMaxBlocklistEntries is the most CIDRs that Felix puts in the XDP blocklist of each interface. When the blocklists of an interface's untracked deny policies add up to more than that, Felix leaves out the extra CIDRs, logs a warning and reports how many it left out in the felix_xdp_blocklist_skipped_entries metric; their traffic is still denied by iptables. The extra CIDRs are added when there is room for them. [Default: 10240]",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"xdpMinInterfaceSpeed": {
+						SchemaProps: spec.SchemaProps{
+							Description: "XDPMinInterfaceSpeed is the slowest link speed, in Mbit/s, of an interface that Felix attaches XDP programs to. When a host endpoint resolves to an interface that reports a slower speed, Felix logs that it is skipping XDP on the interface and leaves the interface's untracked policy to iptables. Felix checks the speed again whenever the interface's state or addresses change, so an interface whose link slows down or speeds up moves between the two. Interfaces that don't report a speed are not skipped. Set to 0 to use XDP whatever the speed. [Default: 0]",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
 					"bpfEnabled": {
 						SchemaProps: spec.SchemaProps{
 							Description: "BPFEnabled, if enabled Felix will use the BPF dataplane. [Default: false]",
@@ -2775,6 +2809,13 @@ func schema_pkg_apis_projectcalico_v3_FelixConfigurationSpec(ref common.Referenc
 							Format:      "",
 						},
 					},
+					"xdpLogLevel": {
+						SchemaProps: spec.SchemaProps{
+							Description: "XDPLogLevel controls which packets the XDP programs report to their events ring buffer when in BPF dataplane mode.  One of \"Off\", \"Error\" (dropped packets only), or \"Debug\" (all packets that go through XDP policy).  Only the debug build of the programs has the ring buffer, so the events also need BPFLogLevel to be \"Debug\". [Default: Off].",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"bpfDataIfacePattern": {
 						SchemaProps: spec.SchemaProps{
 							Description: "BPFDataIfacePattern is a regular expression that controls which interfaces Felix should attach BPF programs to in order to catch traffic to/from the network.  This needs to match the interfaces that Calico workload traffic flows over as well as any interfaces that handle incoming traffic to nodeports and services from outside the cluster.  It should not match the workload interfaces (usually named cali...).",
@@ -3379,9 +3420,85 @@ func schema_pkg_apis_projectcalico_v3_GlobalNetworkSetSpec(ref common.ReferenceC
 							},
 						},
 					},
+					"netExpiries": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Optional expiry times for entries in Nets, keyed on the entry as it appears in Nets.  Once an entry's expiry time has passed, Felix stops treating it as a member of the set, without the GlobalNetworkSet needing to be updated.  This allows blocklists that are fed from external sources to give their entries a TTL.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+									},
+								},
+							},
+						},
+					},
+					"netGraceUntil": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Optional grace periods for entries in Nets, keyed on the entry as it appears in Nets; each value is the time at which the entry's grace period ends.  Until then, Felix doesn't treat the entry as a member of the set but, where the set feeds an XDP blocklist, it counts the packets from the entry's addresses and lets them through.  This allows the impact of a new blocklist entry to be checked before it's enforced.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+									},
+								},
+							},
+						},
+					},
+					"netReasons": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Optional reason codes for entries in Nets, keyed on the entry as it appears in Nets, for auditing. Felix records the reason for each entry in a BPF map alongside the XDP blocklist so that it's possible to find out why an address is blocked; for example, which threat feed it came from. Reasons may be up to 32 characters long.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"feedURL": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Optional URL of an external feed of IP networks, for example a threat feed.  The URL must use the http or https scheme, its host must be listed in the FEED_ALLOWED_HOSTS environment variable of calico-kube-controllers, and it should return one IP address or CIDR per line; blank lines and lines starting with \"#\" are ignored.  When set, the networksetfeed controller in calico-kube-controllers periodically fetches the feed and replaces Nets with its contents.  A feed with no valid entries is treated as an error and leaves Nets unchanged.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"feedRefresh": {
+						SchemaProps: spec.SchemaProps{
+							Description: "How often to fetch the feed given by FeedURL.  Defaults to 5m.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+					"asNumbers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Optional autonomous system numbers whose networks belong to this set.  When set, the networksetasn controller in calico-kube-controllers expands them into CIDRs, using the IP-to-ASN dataset given by its ASN_DATASET_FILE environment variable, and replaces Nets with the result.  Can't be combined with FeedURL.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: 0,
+										Type:    []string{"integer"},
+										Format:  "int64",
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Duration", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -3636,6 +3753,13 @@ func schema_pkg_apis_projectcalico_v3_HostEndpointSpec(ref common.ReferenceCallb
 							},
 						},
 					},
+					"xdpMode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "XDPMode overrides the mode in which Felix attaches its XDP program to this endpoint's interface: \"Native\" (in the driver) or \"Generic\" (in the kernel's network stack, which is slower but works with any driver).  When not set, Felix uses the most efficient mode that the driver supports, falling back to generic mode if GenericXDPEnabled is true.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
@@ -4494,6 +4618,13 @@ func schema_pkg_apis_projectcalico_v3_NetworkPolicySpec(ref common.ReferenceCall
 							Format:      "",
 						},
 					},
+					"doNotTrack": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DoNotTrack indicates whether packets matched by the rules in this policy should go through the data plane's connection tracking, such as Linux conntrack.  If True, the rules in this policy are applied before any data plane connection tracking, and packets allowed by this policy are marked as not to be tracked.  Untracked policy only applies to host endpoints, so an untracked NetworkPolicy only applies to the host endpoints that are associated with its namespace by the label \"projectcalico.org/namespace: <namespace>\".",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
@@ -144,5 +144,19 @@ func CalicoCRDs() ([]*v1.CustomResourceDefinition, error) {
 	}
 	crds = append(crds, &netset)
 
+	xdpBlockList := v1.CustomResourceDefinition{}
+	err = yaml.Unmarshal([]byte(xdpblocklists), &xdpBlockList)
+	if err != nil {
+		return crds, err
+	}
+	crds = append(crds, &xdpBlockList)
+
+	xdpAutoBlocklist := v1.CustomResourceDefinition{}
+	err = yaml.Unmarshal([]byte(xdpautoblocklists), &xdpAutoBlocklist)
+	if err != nil {
+		return crds, err
+	}
+	crds = append(crds, &xdpAutoBlocklist)
+
 	return crds, nil
 }
@@ -0,0 +1,254 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package autoblocklist exports the sources of excessive connection rates.  The dataplane
+// records over-rate sources in an xt_recent list (see rules.AutoBlocklistName); the Blocklister
+// periodically moves the members of that list into the AnnotationAutoBlocklistSources
+// annotation of this host's Node, along with when each was last recorded.  The auto-blocklist
+// controller in kube-controllers turns those into a GlobalNetworkSet, with an expiry, so that
+// an untracked deny policy selecting the LabelAutoBlocklist label can drop their traffic in XDP.
+package autoblocklist
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/projectcalico/calico/felix/jitter"
+	"github.com/projectcalico/calico/felix/rules"
+	"github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+const (
+	// SourceRetention is how long a source stays in the annotation after it was last
+	// recorded.  By then the controller has long since added it to the network set, which
+	// has its own expiry.
+	SourceRetention = 10 * time.Minute
+
+	// MaxSources is the most sources that the annotation holds; when there are more, the
+	// ones recorded longest ago are left out.
+	MaxSources = 1000
+
+	// refreshInterval is how old a source's time must be before it's moved forward when the
+	// source is recorded again, so that a continuing attack doesn't rewrite the Node on every
+	// poll.
+	refreshInterval = 30 * time.Second
+
+	defaultPollInterval = 2 * time.Second
+)
+
+var DefaultRecentListPath = path.Join("/proc/net/xt_recent", rules.AutoBlocklistName)
+
+type Blocklister struct {
+	hostname string
+	client   clientv3.NodeInterface
+
+	// RecentListPath is the xt_recent list to read; exposed for testing.
+	RecentListPath string
+	// PollInterval is how often the list is read.
+	PollInterval time.Duration
+
+	// sources maps each recorded source IP to when it was last recorded.
+	sources map[string]time.Time
+	// written is the annotation value that was last written to (or found on) the Node;
+	// inSync is set once there is one.
+	written string
+	inSync  bool
+
+	now func() time.Time
+}
+
+func New(hostname string, client clientv3.NodeInterface) *Blocklister {
+	return &Blocklister{
+		hostname:       hostname,
+		client:         client,
+		RecentListPath: DefaultRecentListPath,
+		PollInterval:   defaultPollInterval,
+		sources:        map[string]time.Time{},
+		now:            time.Now,
+	}
+}
+
+func (b *Blocklister) Start(ctx context.Context) {
+	go b.loop(ctx)
+}
+
+func (b *Blocklister) loop(ctx context.Context) {
+	log.WithField("node", b.hostname).Info(
+		"Automatic blocklisting of high connection rate sources enabled.")
+	ticker := jitter.NewTicker(b.PollInterval, b.PollInterval/10)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := b.Poll(ctx); err != nil {
+			log.WithError(err).Warn("Failed to export the automatic blocklist sources, will retry.")
+		}
+	}
+}
+
+// Poll reads the sources recorded by the dataplane since the last call and exports them,
+// along with the sources recorded within the SourceRetention, in the Node's annotation.  The
+// Node is only written if that changes the annotation, and the recorded sources are only
+// cleared once it has been written.
+func (b *Blocklister) Poll(ctx context.Context) error {
+	ips, err := readRecentList(b.RecentListPath)
+	if err != nil {
+		return err
+	}
+	now := b.now()
+	if len(ips) > 0 {
+		log.WithField("sources", ips).Info("Recording high connection rate sources.")
+	}
+	for _, ip := range ips {
+		if lastSeen, ok := b.sources[ip]; !ok || now.Sub(lastSeen) >= refreshInterval {
+			b.sources[ip] = now
+		}
+	}
+	b.prune(now)
+
+	value, err := b.annotationValue()
+	if err != nil {
+		return err
+	}
+	if !b.inSync || value != b.written {
+		if err := b.writeAnnotation(ctx, value); err != nil {
+			return err
+		}
+	}
+	if len(ips) == 0 {
+		return nil
+	}
+
+	// Any source recorded since we read the list is lost, but if it's still exceeding the
+	// rate it will be recorded again straight away.
+	return flushRecentList(b.RecentListPath)
+}
+
+// prune removes the sources that were last recorded before the SourceRetention and, if there
+// are still more than MaxSources, the ones recorded longest ago.
+func (b *Blocklister) prune(now time.Time) {
+	var ips []string
+	for ip, lastSeen := range b.sources {
+		if now.Sub(lastSeen) >= SourceRetention {
+			delete(b.sources, ip)
+			continue
+		}
+		ips = append(ips, ip)
+	}
+	if len(ips) <= MaxSources {
+		return
+	}
+	sort.Slice(ips, func(i, j int) bool {
+		return b.sources[ips[i]].After(b.sources[ips[j]])
+	})
+	log.WithField("numDropped", len(ips)-MaxSources).Warn(
+		"Too many high connection rate sources, dropping the ones recorded longest ago.")
+	for _, ip := range ips[MaxSources:] {
+		delete(b.sources, ip)
+	}
+}
+
+// annotationValue returns the JSON encoding of the sources, or "" if there are none.
+func (b *Blocklister) annotationValue() (string, error) {
+	if len(b.sources) == 0 {
+		return "", nil
+	}
+	sources := make(map[string]metav1.Time, len(b.sources))
+	for ip, lastSeen := range b.sources {
+		sources[ip] = metav1.NewTime(lastSeen)
+	}
+	value, err := json.Marshal(sources)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// writeAnnotation sets (or, if value is "", removes) the annotation on the Node, unless it
+// already has that value.
+func (b *Blocklister) writeAnnotation(ctx context.Context, value string) error {
+	node, err := b.client.Get(ctx, b.hostname, options.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if node.Annotations[apiv3.AnnotationAutoBlocklistSources] != value {
+		if value == "" {
+			delete(node.Annotations, apiv3.AnnotationAutoBlocklistSources)
+		} else {
+			if node.Annotations == nil {
+				node.Annotations = map[string]string{}
+			}
+			node.Annotations[apiv3.AnnotationAutoBlocklistSources] = value
+		}
+		if _, err := b.client.Update(ctx, node, options.SetOptions{}); err != nil {
+			return err
+		}
+	}
+	b.written = value
+	b.inSync = true
+	return nil
+}
+
+// readRecentList returns the source IPs in an xt_recent list.  Each line of the list looks
+// like "src=10.0.0.1 ttl: 64 last_seen: 4295239314 oldest_pkt: 1 4295239314".
+func readRecentList(listPath string) ([]string, error) {
+	f, err := os.Open(listPath)
+	if os.IsNotExist(err) {
+		// The list is created along with the first iptables rule that uses it.
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ips []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "src=") {
+			continue
+		}
+		ip := net.ParseIP(strings.TrimPrefix(fields[0], "src=")).To4()
+		if ip == nil {
+			log.WithField("line", scanner.Text()).Warn("Ignoring bad entry in xt_recent list.")
+			continue
+		}
+		ips = append(ips, ip.String())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", listPath, err)
+	}
+	return ips, nil
+}
+
+// flushRecentList removes all the entries from an xt_recent list.
+func flushRecentList(listPath string) error {
+	return os.WriteFile(listPath, []byte("/\n"), 0)
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoblocklist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	libapiv3 "github.com/projectcalico/calico/libcalico-go/lib/apis/v3"
+	"github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+var _ = Describe("Blocklister", func() {
+	var (
+		client   *mockNodeClient
+		b        *Blocklister
+		tmpDir   string
+		listPath string
+		now      time.Time
+	)
+
+	writeList := func(lines ...string) {
+		content := ""
+		for _, l := range lines {
+			content += l + "\n"
+		}
+		Expect(os.WriteFile(listPath, []byte(content), 0644)).To(Succeed())
+	}
+
+	sources := func() map[string]time.Time {
+		value, ok := client.node.Annotations[apiv3.AnnotationAutoBlocklistSources]
+		if !ok {
+			return nil
+		}
+		var s map[string]metav1.Time
+		Expect(json.Unmarshal([]byte(value), &s)).To(Succeed())
+		lastSeen := map[string]time.Time{}
+		for ip, t := range s {
+			lastSeen[ip] = t.UTC()
+		}
+		return lastSeen
+	}
+
+	BeforeEach(func() {
+		client = &mockNodeClient{node: libapiv3.NewNode()}
+		client.node.Name = "node1"
+		var err error
+		tmpDir, err = os.MkdirTemp("", "autoblocklist")
+		Expect(err).NotTo(HaveOccurred())
+		listPath = filepath.Join(tmpDir, "cali-autobl")
+		now = time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+		b = New("node1", client)
+		b.RecentListPath = listPath
+		b.now = func() time.Time { return now }
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	It("should not write the node if the list doesn't exist", func() {
+		Expect(b.Poll(context.Background())).To(Succeed())
+		Expect(client.numGets).To(Equal(1))
+		Expect(client.numUpdates).To(Equal(0))
+
+		Expect(b.Poll(context.Background())).To(Succeed())
+		Expect(client.numGets).To(Equal(1))
+	})
+
+	It("should not write the node if the list is empty", func() {
+		writeList()
+		Expect(b.Poll(context.Background())).To(Succeed())
+		Expect(client.numUpdates).To(Equal(0))
+		Expect(sources()).To(BeNil())
+	})
+
+	It("should export the sources and flush the list", func() {
+		writeList(
+			"src=10.0.0.2 ttl: 64 last_seen: 4295239314 oldest_pkt: 1 4295239314",
+			"src=10.0.0.1 ttl: 63 last_seen: 4295239315 oldest_pkt: 1 4295239315",
+		)
+		Expect(b.Poll(context.Background())).To(Succeed())
+
+		Expect(client.numUpdates).To(Equal(1))
+		Expect(sources()).To(Equal(map[string]time.Time{
+			"10.0.0.1": now,
+			"10.0.0.2": now,
+		}))
+
+		// Writing "/" to an xt_recent list flushes it.
+		Expect(os.ReadFile(listPath)).To(Equal([]byte("/\n")))
+	})
+
+	It("should ignore bad entries", func() {
+		writeList("src=10.0.0.1 ttl: 64", "garbage", "src=foo ttl: 64")
+		Expect(b.Poll(context.Background())).To(Succeed())
+		Expect(sources()).To(Equal(map[string]time.Time{"10.0.0.1": now}))
+	})
+
+	It("should only move a source's time forward after the refresh interval", func() {
+		writeList("src=10.0.0.1 ttl: 64")
+		Expect(b.Poll(context.Background())).To(Succeed())
+		first := now
+
+		now = now.Add(refreshInterval / 2)
+		writeList("src=10.0.0.1 ttl: 64")
+		Expect(b.Poll(context.Background())).To(Succeed())
+		Expect(client.numUpdates).To(Equal(1))
+		Expect(sources()).To(Equal(map[string]time.Time{"10.0.0.1": first}))
+
+		now = now.Add(refreshInterval)
+		writeList("src=10.0.0.1 ttl: 64")
+		Expect(b.Poll(context.Background())).To(Succeed())
+		Expect(client.numUpdates).To(Equal(2))
+		Expect(sources()).To(Equal(map[string]time.Time{"10.0.0.1": now}))
+	})
+
+	It("should remove sources after the retention period", func() {
+		writeList("src=10.0.0.1 ttl: 64")
+		Expect(b.Poll(context.Background())).To(Succeed())
+		now = now.Add(time.Minute)
+		writeList("src=10.0.0.2 ttl: 64")
+		Expect(b.Poll(context.Background())).To(Succeed())
+
+		now = now.Add(SourceRetention - time.Minute)
+		Expect(b.Poll(context.Background())).To(Succeed())
+		Expect(sources()).To(Equal(map[string]time.Time{
+			"10.0.0.2": now.Add(-SourceRetention + time.Minute),
+		}))
+
+		now = now.Add(time.Minute)
+		Expect(b.Poll(context.Background())).To(Succeed())
+		Expect(client.node.Annotations).NotTo(HaveKey(apiv3.AnnotationAutoBlocklistSources))
+	})
+
+	It("should keep at most MaxSources sources, dropping the oldest", func() {
+		var lines []string
+		for i := 0; i < MaxSources; i++ {
+			lines = append(lines, fmt.Sprintf("src=10.0.%d.%d ttl: 64", i/256, i%256))
+		}
+		writeList(lines...)
+		Expect(b.Poll(context.Background())).To(Succeed())
+
+		now = now.Add(time.Second)
+		writeList("src=10.1.0.1 ttl: 64")
+		Expect(b.Poll(context.Background())).To(Succeed())
+		s := sources()
+		Expect(s).To(HaveLen(MaxSources))
+		Expect(s).To(HaveKey("10.1.0.1"))
+	})
+
+	It("should remove a stale annotation when it starts", func() {
+		client.node.Annotations = map[string]string{
+			apiv3.AnnotationAutoBlocklistSources: `{"10.0.0.1":"2022-06-01T11:00:00Z"}`,
+			"other":                              "value",
+		}
+		Expect(b.Poll(context.Background())).To(Succeed())
+		Expect(client.numUpdates).To(Equal(1))
+		Expect(client.node.Annotations).To(Equal(map[string]string{"other": "value"}))
+	})
+
+	It("should keep the list if the update fails", func() {
+		client.updateErr = errors.New("datastore unavailable")
+		writeList("src=10.0.0.1 ttl: 64")
+		Expect(b.Poll(context.Background())).NotTo(Succeed())
+		Expect(os.ReadFile(listPath)).To(Equal([]byte("src=10.0.0.1 ttl: 64\n")))
+
+		// The next poll retries the write.
+		client.updateErr = nil
+		Expect(b.Poll(context.Background())).To(Succeed())
+		Expect(sources()).To(HaveKey("10.0.0.1"))
+	})
+})
+
+type mockNodeClient struct {
+	clientv3.NodeInterface
+
+	node       *libapiv3.Node
+	numGets    int
+	numUpdates int
+	updateErr  error
+}
+
+func (c *mockNodeClient) Get(ctx context.Context, name string, opts options.GetOptions) (*libapiv3.Node, error) {
+	c.numGets++
+	if c.node == nil || c.node.Name != name {
+		return nil, cerrors.ErrorResourceDoesNotExist{Identifier: name}
+	}
+	return c.node.DeepCopy(), nil
+}
+
+func (c *mockNodeClient) Update(ctx context.Context, res *libapiv3.Node, opts options.SetOptions) (*libapiv3.Node, error) {
+	if c.updateErr != nil {
+		return nil, c.updateErr
+	}
+	c.numUpdates++
+	c.node = res.DeepCopy()
+	return res, nil
+}
@@ -63,6 +63,8 @@ MAKEFUNC(int, msg_redirect_hash,
 MAKEFUNC(int, sock_hash_update,
 	struct bpf_sock_ops*, struct bpf_map_def*, void*, __u64)
 MAKEFUNC(void*, map_lookup_elem, void*, const void*)
+MAKEFUNC(int, map_update_elem, void*, const void*, const void*, __u64)
+MAKEFUNC(int, get_numa_node_id, void)
 
 /*
  * Data types, structs, and unions
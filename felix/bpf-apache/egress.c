@@ -0,0 +1,92 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// TC egress counterpart of the XDP prefilter: drops packets that the host
+// sends to an address on the interface's blocklist.  It reads the same pinned
+// maps as the XDP program, so the two always agree.
+
+#include <linux/types.h>
+#include <linux/bpf.h>
+#include <linux/pkt_cls.h>
+#include <linux/if_ether.h>
+#include <linux/ip.h>
+#include <linux/in.h>
+#include <linux/tcp.h>
+#include <linux/udp.h>
+#include "filter.h"
+
+// Returns the source port of a TCP or UDP packet, or 0 if it has neither.
+CALI_BPF_INLINE static __u16 source_port(struct __sk_buff *skb, struct iphdr *h)
+{
+	struct tcphdr *thdr;
+	struct udphdr *uhdr;
+
+	switch (h->protocol) {
+		case IPPROTO_TCP:
+			thdr = (void*)((__u64)(h) + sizeof(*h));
+			if ((void*)(thdr + 1) > (void*)(long)skb->data_end) {
+				return 0;
+			}
+			return port_to_host(thdr->source);
+		case IPPROTO_UDP:
+			uhdr = (void*)((__u64)(h) + sizeof(*h));
+			if ((void*)(uhdr + 1) > (void*)(long)skb->data_end) {
+				return 0;
+			}
+			return port_to_host(uhdr->source);
+		default:
+			return 0;
+	}
+}
+
+__attribute__((section("classifier")))
+int egress_filter(struct __sk_buff *skb)
+{
+	struct ethhdr * ehdr;
+	struct iphdr  * ihdr;
+	struct protoport sport = {0,0};
+	union ip4_bpf_lpm_trie_key dip;
+
+	ehdr = (void*)(long)skb->data;
+	ihdr = (void*)((__u64)(ehdr) + sizeof(*ehdr));
+	if ((void*)(ihdr + 1) > (void*)(long)skb->data_end) {
+		// Too small to be an IP packet; leave it to the rest of the stack.
+		return TC_ACT_UNSPEC;
+	}
+	if (be16_to_host(ETH_P_IP) != ehdr->h_proto) {
+		return TC_ACT_UNSPEC;
+	}
+
+	// Let through the replies to connections to failsafe ports, mirroring the
+	// XDP program's check on the destination port of incoming packets.
+	sport.proto = ihdr->protocol;
+	sport.port = source_port(skb, ihdr);
+	if (sport.port != 0 &&
+		NULL != bpf_map_lookup_elem(&calico_failsafe_ports, &sport)) {
+		return TC_ACT_UNSPEC;
+	}
+
+	ip4val_to_lpm(&dip, 32, ihdr->daddr);
+	if (NULL != bpf_map_lookup_elem(&calico_prefilter_allow_v4, &dip)) {
+		// The host's own addresses are never blocklisted.
+		return TC_ACT_UNSPEC;
+	}
+	if (NULL != lookup_blocklist(&dip)) {
+		return TC_ACT_SHOT;
+	}
+
+	return TC_ACT_UNSPEC;
+}
+
+char ____license[] __attribute__((section("license")))  = "Apache-2.0";
@@ -52,6 +52,35 @@ CALI_BPF_INLINE static int extract_ports(__u32 len, struct iphdr * h,
 	return 1;
 }
 
+CALI_BPF_INLINE static void count_src(void *counts, __u32 saddr)
+{
+	// The map is per-CPU, so this CPU's count needs no atomic add.
+	__u64 *count = bpf_map_lookup_elem(counts, &saddr);
+	if (count) {
+		(*count)++;
+		return;
+	}
+	// First packet from this source.  If the map is full, this evicts the
+	// least recently counted source.
+	__u64 one = 1;
+	bpf_map_update_elem(counts, &saddr, &one, BPF_NOEXIST);
+}
+
+CALI_BPF_INLINE static void count_src_drop(__u32 saddr)
+{
+	count_src(&calico_drops_v4, saddr);
+}
+
+CALI_BPF_INLINE static void count_failsafe_bypass(struct protoport *dport)
+{
+	__u64 *count = bpf_map_lookup_elem(&calico_fsbyp_v4, dport);
+	if (count) {
+		__sync_fetch_and_add(count, 1);
+		return;
+	}
+	__u64 one = 1;
+	bpf_map_update_elem(&calico_fsbyp_v4, dport, &one, BPF_NOEXIST);
+}
 
 __attribute__((section("prefilter_func")))
 enum xdp_action prefilter(struct xdp_md* xdp)
@@ -59,7 +88,7 @@ enum xdp_action prefilter(struct xdp_md* xdp)
 	struct ethhdr * ehdr;
 	struct iphdr  * ihdr;
 	struct protoport dport = {0,0};
-	union ip4_bpf_lpm_trie_key sip;
+	union ip4_bpf_lpm_trie_key sip, dip;
 
 	// You must be at least 'UDP header' tall to take this ride.
 	if (xdp->data + sizeof(*ehdr) + sizeof(*ihdr) + sizeof(struct udphdr)
@@ -68,33 +97,74 @@ enum xdp_action prefilter(struct xdp_md* xdp)
 		return XDP_DROP;
 	}
 
-	// Make sure it's an IP packet
-	// NOTE that this is a straightforward implementation that
-	// does not handle e.g. V[X]LAN encapsulation.
+	// Make sure it's an IP packet, looking past a single VLAN tag so that
+	// frames on trunk interfaces are filtered on their inner source.
+	// NOTE that this is a straightforward implementation that does not
+	// handle stacked VLAN tags or VXLAN encapsulation; such frames are
+	// passed.
 	ehdr = (void*)(long)xdp->data;
-	if (be16_to_host(ETH_P_IP) != ehdr->h_proto) {
+	__be16 h_proto = ehdr->h_proto;
+	__u32 vlan_len = 0;
+	if (be16_to_host(ETH_P_8021Q) == h_proto || be16_to_host(ETH_P_8021AD) == h_proto) {
+		struct vlan_hdr *vhdr = (void*)((__u64)(ehdr) + sizeof(*ehdr));
+		if (xdp->data + sizeof(*ehdr) + sizeof(*vhdr) + sizeof(*ihdr) +
+			sizeof(struct udphdr) > xdp->data_end) {
+			// Too small to contain the tag, ip, and UDP headers. Drop.
+			return XDP_DROP;
+		}
+		h_proto = vhdr->h_vlan_encapsulated_proto;
+		vlan_len = sizeof(*vhdr);
+	}
+	// IPv6 isn't handled here: ip6tables applies the IPv6 failsafe ports and
+	// untracked policy, so the failsafe map and blocklists are IPv4 only.
+	if (be16_to_host(ETH_P_IP) != h_proto) {
 		return XDP_PASS;
 	}
 
 	// Parse l4 protocols and ports.
 	// NOTE that this is a straightforward implementation that
 	// does not handle e.g. IPIP encapsulation.
-	ihdr = (void*)((__u64)(ehdr) + sizeof(*ehdr));
-	if (extract_ports(xdp->data_end - xdp->data, ihdr, &dport)) {
+	ihdr = (void*)((__u64)(ehdr) + sizeof(*ehdr) + vlan_len);
+	ip4val_to_lpm(&sip, 32, ihdr->saddr);
+
+	if (extract_ports(xdp->data_end - xdp->data - vlan_len, ihdr, &dport)) {
 		// Check failsafe ports and XDP_PASS early
 		if (NULL != bpf_map_lookup_elem(&calico_failsafe_ports, &dport)) {
+			// Record which failsafe port let a blocklisted source through.
+			if (NULL == bpf_map_lookup_elem(&calico_prefilter_allow_v4, &sip) &&
+				NULL != lookup_blocklist(&sip)) {
+				count_failsafe_bypass(&dport);
+			}
 			return XDP_PASS;
 		}
 	}
 
-	ip4val_to_lpm(&sip, 32, ihdr->saddr);
+	// Pass the host's own traffic early, whatever the blocklist says.
+	if (NULL != bpf_map_lookup_elem(&calico_prefilter_allow_v4, &sip)) {
+		return XDP_PASS;
+	}
 
 	// Drop the packet if source IP matches a blocklist entry.
-	if (NULL != bpf_map_lookup_elem(&calico_prefilter_v4, &sip)) {
+	if (NULL != lookup_blocklist(&sip)) {
 		// In blocklist - "thou shall not XDP_PASS!"
+		count_src_drop(ihdr->saddr);
 		return XDP_DROP;
 	}
 
+	ip4val_to_lpm(&dip, 32, ihdr->daddr);
+
+	// Drop the packet if its destination is denied to all sources.
+	if (NULL != bpf_map_lookup_elem(&calico_prefilter_dst_v4, &dip)) {
+		count_src_drop(ihdr->saddr);
+		return XDP_DROP;
+	}
+
+	// Count, but pass, the packet if its source is in a blocklist entry
+	// that is still in its grace period.
+	if (NULL != bpf_map_lookup_elem(&calico_prefilter_grace_v4, &sip)) {
+		count_src(&calico_gpass_v4, ihdr->saddr);
+	}
+
 	// Not in blocklist - pass.
 	return XDP_PASS;
 }
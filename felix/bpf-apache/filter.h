@@ -15,12 +15,76 @@
 #include <linux/bpf.h>
 #include "bpf.h"
 
+// The 802.1Q tag that sits between the ethernet header and the payload of a frame
+// on a trunk interface.  (struct vlan_hdr isn't part of the UAPI headers.)
+struct vlan_hdr {
+	__be16 h_vlan_TCI;
+	__be16 h_vlan_encapsulated_proto;
+};
+
 struct protoport {
 	__u16 proto;
 	__u16 port;
 };
 
-struct bpf_map_def __attribute__((section("maps"))) calico_prefilter_v4 = {
+// The blocklist, with a copy for each of up to four NUMA nodes so that each node
+// looks up its own copy.  Felix creates copy i with its memory on NUMA node i, keeps the
+// copies in sync and loads the program with the copies that a host doesn't need pointing at
+// calico_prefilter_v4.
+#define PREFILTER_V4_SHARD(name) \
+struct bpf_map_def __attribute__((section("maps"))) name = { \
+	.type           = BPF_MAP_TYPE_LPM_TRIE, \
+	.key_size       = sizeof(union ip4_bpf_lpm_trie_key), \
+	.value_size     = sizeof(__u32), \
+	.max_entries    = 10240, \
+	.map_flags      = BPF_F_NO_PREALLOC, \
+};
+
+PREFILTER_V4_SHARD(calico_prefilter_v4)
+PREFILTER_V4_SHARD(calico_prefilter_v4_1)
+PREFILTER_V4_SHARD(calico_prefilter_v4_2)
+PREFILTER_V4_SHARD(calico_prefilter_v4_3)
+
+// Looks an address up in the local NUMA node's copy of the blocklist.
+CALI_BPF_INLINE static void *lookup_blocklist(union ip4_bpf_lpm_trie_key *key)
+{
+	switch (bpf_get_numa_node_id()) {
+		case 1:
+			return bpf_map_lookup_elem(&calico_prefilter_v4_1, key);
+		case 2:
+			return bpf_map_lookup_elem(&calico_prefilter_v4_2, key);
+		case 3:
+			return bpf_map_lookup_elem(&calico_prefilter_v4_3, key);
+		default:
+			return bpf_map_lookup_elem(&calico_prefilter_v4, key);
+	}
+}
+
+// Destinations that the untracked policy denies ingress to, whatever the source.  There's
+// only one copy: destinations are the host's own addresses, so there are few of them.
+struct bpf_map_def __attribute__((section("maps"))) calico_prefilter_dst_v4 = {
+	.type           = BPF_MAP_TYPE_LPM_TRIE,
+	.key_size       = sizeof(union ip4_bpf_lpm_trie_key),
+	.value_size     = sizeof(__u32),
+	.max_entries    = 1024,
+	.map_flags      = BPF_F_NO_PREALLOC,
+};
+
+// Sources that are never blocklisted: the host's own addresses and those of its workloads.
+// Felix fills it in so that a blocklist CIDR that contains the node's pod CIDR can't cut the
+// node off from its own pods.  There's one copy for the whole host.
+struct bpf_map_def __attribute__((section("maps"))) calico_prefilter_allow_v4 = {
+	.type           = BPF_MAP_TYPE_LPM_TRIE,
+	.key_size       = sizeof(union ip4_bpf_lpm_trie_key),
+	.value_size     = sizeof(__u32),
+	.max_entries    = 1024,
+	.map_flags      = BPF_F_NO_PREALLOC,
+};
+
+// Blocklist CIDRs that are still in their grace period.  Felix holds them back from the
+// blocklist until the grace period ends; meanwhile the program counts the packets from them
+// but lets them through.  There's one copy for the whole host.
+struct bpf_map_def __attribute__((section("maps"))) calico_prefilter_grace_v4 = {
 	.type           = BPF_MAP_TYPE_LPM_TRIE,
 	.key_size       = sizeof(union ip4_bpf_lpm_trie_key),
 	.value_size     = sizeof(__u32),
@@ -35,3 +99,35 @@ struct bpf_map_def __attribute__((section("maps"))) calico_failsafe_ports = {
 	.max_entries    = 65535,
 	.map_flags      = BPF_F_NO_PREALLOC,
 };
+
+// Number of packets dropped by the blocklist, keyed by source IP (in network order), with a
+// count per CPU.  When the map is full, counting a new source evicts the least recently
+// counted one, so a flood from many sources can't stop the map from counting the current
+// ones.  The map isn't pinned, so it lives and dies with the program.
+struct bpf_map_def __attribute__((section("maps"))) calico_drops_v4 = {
+	.type           = BPF_MAP_TYPE_LRU_PERCPU_HASH,
+	.key_size       = sizeof(__u32),
+	.value_size     = sizeof(__u64),
+	.max_entries    = 10240,
+};
+
+// Number of packets passed, rather than dropped, because their source is in a blocklist
+// CIDR's grace period, keyed by source IP (in network order).  Like calico_drops_v4, it has a
+// count per CPU, evicts the least recently counted source when full and isn't pinned.
+struct bpf_map_def __attribute__((section("maps"))) calico_gpass_v4 = {
+	.type           = BPF_MAP_TYPE_LRU_PERCPU_HASH,
+	.key_size       = sizeof(__u32),
+	.value_size     = sizeof(__u64),
+	.max_entries    = 10240,
+};
+
+// Number of packets from blocklisted sources that were passed because of a failsafe port,
+// keyed by the failsafe port's entry in calico_failsafe_ports.  Like calico_drops_v4, the
+// map isn't pinned.
+struct bpf_map_def __attribute__((section("maps"))) calico_fsbyp_v4 = {
+	.type           = BPF_MAP_TYPE_HASH,
+	.key_size       = sizeof(struct protoport),
+	.value_size     = sizeof(__u64),
+	.max_entries    = 1024,
+	.map_flags      = BPF_F_NO_PREALLOC,
+};
@@ -38,8 +38,18 @@ struct cali_ctlb_globals {
 	bool exclude_udp;
 };
 
+enum cali_xdp_log_level {
+	/* CALI_XDP_LOG_LEVEL_OFF disables the XDP events. */
+	CALI_XDP_LOG_LEVEL_OFF			= 0,
+	/* CALI_XDP_LOG_LEVEL_ERROR only reports dropped packets. */
+	CALI_XDP_LOG_LEVEL_ERROR		= 1,
+	/* CALI_XDP_LOG_LEVEL_DEBUG reports every verdict. */
+	CALI_XDP_LOG_LEVEL_DEBUG		= 2,
+};
+
 struct cali_xdp_globals {
 	__u8 iface_name[16];
+	__u32 log_level;
 };
 
 #endif /* __CALI_GLOBALS_H__ */
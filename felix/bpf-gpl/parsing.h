@@ -28,6 +28,20 @@ static CALI_BPF_INLINE int parse_packet_ip(struct cali_tc_ctx *ctx) {
 			goto deny;
 		}
 		protocol = bpf_ntohs(eth_hdr(ctx)->h_proto);
+		if (protocol == ETH_P_8021Q || protocol == ETH_P_8021AD) {
+			/* Skip a single VLAN tag so that the blocklist matches the inner
+			 * IP header of frames on trunk interfaces.  Frames with stacked
+			 * tags fall through to the unknown ethertype case below and are
+			 * passed without policy. */
+			ctx->vlan_len = VLAN_SIZE;
+			if (skb_refresh_validate_ptrs(ctx, UDP_SIZE)) {
+				deny_reason(ctx, CALI_REASON_SHORT);
+				CALI_DEBUG("Too short for VLAN tag\n");
+				goto deny;
+			}
+			protocol = bpf_ntohs(vlan_hdr(ctx)->encap_proto);
+			CALI_DEBUG("VLAN tagged frame, inner ethertype %x\n", protocol);
+		}
 	} else {
 		protocol = bpf_ntohs(ctx->skb->protocol);
 	}
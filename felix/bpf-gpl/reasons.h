@@ -22,6 +22,9 @@ enum calico_reason {
 	CALI_REASON_DECAP_FAIL,
 	CALI_REASON_UNAUTH_SOURCE,
 	CALI_REASON_RT_UNKNOWN,
+	CALI_REASON_NOT_MATCHED_BY_XDP,
+	// The packets that the XDP program handed to the kernel stack with XDP_PASS.
+	COUNTER_XDP_PASS,
 	CALI_REASON_ACCEPTED_BY_XDP, // Not used by countres map
 	CALI_REASON_WEP_NOT_READY,
 	CALI_REASON_NATIFACE,
@@ -75,6 +75,10 @@ static CALI_BPF_INLINE long skb_iphdr_offset(struct cali_tc_ctx *ctx)
 		// Egress on an IPIP tunnel, or any other l3 devices (wireguard) both directions:
 		// skb is [inner IP|payload]
 		return 0;
+	} else if (CALI_F_XDP) {
+		// XDP sees frames before the kernel strips any VLAN tag: [ether|VLAN|IP|payload]
+		// for a tagged frame, otherwise [ether|IP|payload].
+		return sizeof(struct ethhdr) + ctx->vlan_len;
 	} else {
 		// Normal L2 interface: skb is [ether|IP|payload]
 		return sizeof(struct ethhdr);
@@ -85,6 +89,7 @@ static CALI_BPF_INLINE long skb_iphdr_offset(struct cali_tc_ctx *ctx)
 #define ETH_IPV4_UDP_SIZE	(sizeof(struct ethhdr) + IPV4_UDP_SIZE)
 
 #define ETH_SIZE (sizeof(struct ethhdr))
+#define VLAN_SIZE (sizeof(struct cali_vlan_hdr))
 #define IP_SIZE (sizeof(struct iphdr))
 #define IPv6_SIZE (sizeof(struct ipv6hdr))
 #define UDP_SIZE (sizeof(struct udphdr))
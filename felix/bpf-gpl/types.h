@@ -144,6 +144,10 @@ struct cali_tc_ctx {
   void *ip_header;
   void *nh;
   long ipheader_len;
+  /* Length of the VLAN tag between the ethernet and IP headers.  Only XDP
+   * programs see tagged frames; TC gets them after the kernel has untagged
+   * them. */
+  long vlan_len;
 
   struct cali_tc_state *state;
   struct calico_nat_dest *nat_dest;
@@ -152,6 +156,13 @@ struct cali_tc_ctx {
   void *counters;
 };
 
+/* The 802.1Q tag of a frame on a trunk interface.  (struct vlan_hdr isn't part
+ * of the UAPI headers.) */
+struct cali_vlan_hdr {
+	__be16 tci;
+	__be16 encap_proto;
+};
+
 static CALI_BPF_INLINE struct iphdr* ip_hdr(struct cali_tc_ctx *ctx)
 {
 	return (struct iphdr *)ctx->ip_header;
@@ -167,6 +178,11 @@ static CALI_BPF_INLINE struct ethhdr* eth_hdr(struct cali_tc_ctx *ctx)
 	return (struct ethhdr *)ctx->data_start;
 }
 
+static CALI_BPF_INLINE struct cali_vlan_hdr* vlan_hdr(struct cali_tc_ctx *ctx)
+{
+	return (struct cali_vlan_hdr *)(ctx->data_start + sizeof(struct ethhdr));
+}
+
 static CALI_BPF_INLINE struct tcphdr* tcp_hdr(struct cali_tc_ctx *ctx)
 {
 	return (struct tcphdr *)ctx->nh;
@@ -26,9 +26,18 @@
 #include "jump.h"
 #include "metadata.h"
 #include "globals.h"
+#include "rule_counters.h"
+#include "xdp_events.h"
 
 const volatile struct cali_xdp_globals __globals;
 
+/* xdp_pass counts the packet as handed to the kernel stack and returns XDP_PASS. */
+static CALI_BPF_INLINE int xdp_pass(struct cali_tc_ctx *ctx)
+{
+	counter_inc(ctx, COUNTER_XDP_PASS);
+	return XDP_PASS;
+}
+
 /* calico_xdp is the main function used in all of the xdp programs */
 static CALI_BPF_INLINE int calico_xdp(struct xdp_md *xdp)
 {
@@ -102,7 +111,7 @@ static CALI_BPF_INLINE int calico_xdp(struct xdp_md *xdp)
 	CALI_JUMP_TO(xdp, PROG_INDEX_POLICY);
 
 allow:
-	return XDP_PASS;
+	return xdp_pass(&ctx);
 
 deny:
 	return XDP_DROP;
@@ -115,8 +124,13 @@ deny:
 SEC("xdp/policy")
 int calico_xdp_norm_pol_tail(struct xdp_md *xdp)
 {
+	struct cali_tc_ctx ctx = {
+		.xdp = xdp,
+	};
+
 	CALI_DEBUG("Entering normal policy tail call: PASS\n");
-	return XDP_PASS;
+	counter_inc(&ctx, CALI_REASON_NOT_MATCHED_BY_XDP);
+	return xdp_pass(&ctx);
 }
 
 SEC("xdp/accept")
@@ -124,6 +138,7 @@ int calico_xdp_accepted_entrypoint(struct xdp_md *xdp)
 {
 	CALI_DEBUG("Entering calico_xdp_accepted_entrypoint\n");
 	struct cali_tc_ctx ctx = {
+		.state = state_get(),
 		.counters = counters_get(xdp->ingress_ifindex),
 		.fwd = {
 			.res = XDP_PASS,
@@ -137,13 +152,27 @@ int calico_xdp_accepted_entrypoint(struct xdp_md *xdp)
 		return XDP_DROP;
 	}
 
+	// The policy program also comes here when no untracked policy matched the
+	// packet.  Let it through without marking it as accepted so that TC still
+	// applies the normal policy.
+	if (ctx.state && ctx.state->pol_rc == CALI_POL_NO_MATCH) {
+		CALI_DEBUG("No XDP policy matched: PASS\n");
+		counter_inc(&ctx, CALI_REASON_NOT_MATCHED_BY_XDP);
+		return xdp_pass(&ctx);
+	}
+
+	if (ctx.state) {
+		update_rule_counters(ctx.state);
+		xdp_event_emit(ctx.state, XDP_PASS);
+	}
+
 	// Share with TC the packet is already accepted and accept it there too.
 	if (xdp2tc_set_metadata(xdp, CALI_META_ACCEPTED_BY_XDP)) {
 		CALI_DEBUG("Failed to set metadata for TC\n");
 	}
 	counter_inc(&ctx, CALI_REASON_ACCEPTED_BY_POLICY);
 
-	return XDP_PASS;
+	return xdp_pass(&ctx);
 }
 
 SEC("xdp/drop")
@@ -165,6 +194,9 @@ int calico_xdp_drop(struct xdp_md *xdp)
 		CALI_DEBUG("Counters map lookup failed: DROP\n");
 		return XDP_DROP;
 	}
+
+	update_rule_counters(ctx.state);
+	xdp_event_emit(ctx.state, XDP_DROP);
 	counter_inc(&ctx, CALI_REASON_DROPPED_BY_POLICY);
 
 	CALI_DEBUG("proto=%d\n", ctx.state->ip_proto);
@@ -0,0 +1,59 @@
+// Project Calico BPF dataplane programs.
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0 OR GPL-2.0-or-later
+
+#ifndef __CALI_XDP_EVENTS_H__
+#define __CALI_XDP_EVENTS_H__
+
+#include "bpf.h"
+#include "log.h"
+#include "types.h"
+
+/* The XDP programs report the verdict for each packet that goes through policy
+ * to a ring buffer, so that tests (and operators) can see exactly which packets
+ * were dropped.  Ring buffers need kernel 5.8+ so we only create the map in
+ * the debug build of the programs.  Even then, Felix's XDPLogLevel setting,
+ * which it passes in the log_level global, decides which verdicts are reported
+ * so that the events can be turned off without reloading a different build.
+ */
+#if CALI_LOG_LEVEL >= CALI_LOG_LEVEL_DEBUG
+
+/* Must match bpf.XDPEvent on the Go side. */
+struct cali_xdp_event {
+	__be32 ip_src;
+	__u32 verdict;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_RINGBUF);
+	__uint(max_entries, 256 * 1024);
+} map_symbol(cali_xdp_evts, 1) SEC(".maps");
+
+static CALI_BPF_INLINE void xdp_event_emit(struct cali_tc_state *state, __u32 verdict)
+{
+	struct cali_xdp_event *ev;
+	__u32 min_level = verdict == XDP_DROP ? CALI_XDP_LOG_LEVEL_ERROR : CALI_XDP_LOG_LEVEL_DEBUG;
+
+	if (__globals.log_level < min_level) {
+		return;
+	}
+
+	ev = bpf_ringbuf_reserve(&map_symbol(cali_xdp_evts, 1), sizeof(*ev), 0);
+	if (!ev) {
+		CALI_DEBUG("XDP event ring buffer full\n");
+		return;
+	}
+	ev->ip_src = state->ip_src;
+	ev->verdict = verdict;
+	bpf_ringbuf_submit(ev, 0);
+}
+
+#else
+
+static CALI_BPF_INLINE void xdp_event_emit(struct cali_tc_state *state, __u32 verdict)
+{
+}
+
+#endif
+
+#endif /* __CALI_XDP_EVENTS_H__ */
@@ -22,6 +22,7 @@ package bpf
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
@@ -36,6 +37,8 @@ import (
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
 	"golang.org/x/sys/unix"
 
 	"github.com/projectcalico/calico/felix/bpf/bpfdefs"
@@ -43,6 +46,7 @@ import (
 	"github.com/projectcalico/calico/felix/bpf/utils"
 	"github.com/projectcalico/calico/felix/environment"
 	"github.com/projectcalico/calico/felix/labelindex"
+	"github.com/projectcalico/calico/felix/netlinkshim"
 	"github.com/projectcalico/calico/felix/proto"
 	"github.com/projectcalico/calico/libcalico-go/lib/set"
 )
@@ -96,6 +100,14 @@ const (
 	XDPGeneric XDPMode = unix.XDP_FLAGS_SKB_MODE
 )
 
+// AttachFlags returns the XDP_FLAGS_* that Felix attaches its XDP program with in this mode.
+// As well as the mode's flag, "ip link set" asks for XDP_FLAGS_UPDATE_IF_NOEXIST unless it's
+// forced, which Felix never does, so that it fails rather than replacing a program that
+// something else has attached.
+func (m XDPMode) AttachFlags() uint32 {
+	return uint32(m) | unix.XDP_FLAGS_UPDATE_IF_NOEXIST
+}
+
 type FindObjectMode uint32
 
 const (
@@ -103,6 +115,10 @@ const (
 	FindByID
 )
 
+// CIDRMapMaxEntries is the capacity of each shard of an interface's XDP blocklist map; it
+// matches max_entries in filter.h.
+const CIDRMapMaxEntries = 10240
+
 const (
 	// XDP
 	cidrMapVersion        = "v1"
@@ -110,6 +126,10 @@ const (
 	xdpProgVersion        = "v1"
 	failsafeMapName       = "calico_failsafe_ports_" + failsafeMapVersion
 	failsafeSymbolMapName = "calico_failsafe_ports" // no need to version the symbol name
+	tcEgressProgVersion   = "v1"
+	// tcEgressFilterPref is the priority of the TC egress filter that mirrors the
+	// XDP blocklist; the filter is found and removed by it.
+	tcEgressFilterPref = "49152"
 
 	// sockmap
 	sockopsProgVersion         = "v1"
@@ -123,9 +143,10 @@ const (
 )
 
 var (
-	xdpFilename     = "filter.o"
-	sockopsFilename = "sockops.o"
-	redirFilename   = "redir.o"
+	xdpFilename      = "filter.o"
+	tcEgressFilename = "egress.o"
+	sockopsFilename  = "sockops.o"
+	redirFilename    = "redir.o"
 
 	bpfCalicoSubdir = "calico"
 	ifaceRegexp     = regexp.MustCompile(`(?m)^[0-9]+:\s+(?P<name>.+):`)
@@ -203,6 +224,10 @@ type BPFLib struct {
 	sockmapDir  string
 	cgroupV2Dir string
 	xdpDir      string
+
+	// blocklistShards is the number of copies of each blocklist map; see
+	// numBlocklistShards.
+	blocklistShards int
 }
 
 func NewBPFLib(binDir string) (*BPFLib, error) {
@@ -218,7 +243,10 @@ func NewBPFLib(binDir string) (*BPFLib, error) {
 
 	cgroupV2Dir, err := utils.MaybeMountCgroupV2()
 	if err != nil {
-		return nil, err
+		// Only the sockmap programs need cgroup v2, so don't let this stop XDP from working;
+		// for example, when Felix doesn't have CAP_SYS_ADMIN to mount it.
+		log.WithError(err).Warn("Failed to mount cgroup v2, sidecar acceleration won't be available.")
+		cgroupV2Dir = ""
 	}
 
 	calicoDir := filepath.Join(bpfDir, bpfCalicoSubdir)
@@ -232,11 +260,14 @@ func NewBPFLib(binDir string) (*BPFLib, error) {
 		sockmapDir:  sockmapDir,
 		cgroupV2Dir: cgroupV2Dir,
 		xdpDir:      xdpDir,
+
+		blocklistShards: numBlocklistShards(),
 	}, nil
 }
 
 type BPFDataplane interface {
 	DumpCIDRMap(ifName string, family IPFamily) (map[CIDRMapKey]uint32, error)
+	DumpDstCIDRMap(ifName string, family IPFamily) (map[CIDRMapKey]uint32, error)
 	DumpFailsafeMap() ([]ProtoPort, error)
 	GetCIDRMapID(ifName string, family IPFamily) (int, error)
 	GetFailsafeMapID() (int, error)
@@ -247,6 +278,8 @@ type BPFDataplane interface {
 	GetXDPObjTag(objPath string) (string, error)
 	GetXDPObjTagAuto() (string, error)
 	GetXDPTag(ifName string) (string, error)
+	SupportsXDPOnInterface(ifName string, mode XDPMode) error
+	InterfaceSpeed(ifName string) (int, error)
 	IsValidMap(ifName string, family IPFamily) (bool, error)
 	ListCIDRMaps(family IPFamily) ([]string, error)
 	LoadXDP(objPath, ifName string, mode XDPMode) error
@@ -258,10 +291,28 @@ type BPFDataplane interface {
 	RemoveCIDRMap(ifName string, family IPFamily) error
 	RemoveFailsafeMap() error
 	RemoveItemCIDRMap(ifName string, family IPFamily, ip net.IP, mask int) error
+	RemoveItemDstCIDRMap(ifName string, family IPFamily, ip net.IP, mask int) error
 	RemoveItemFailsafeMap(proto uint8, port uint16) error
 	RemoveXDP(ifName string, mode XDPMode) error
+	LoadTCEgress(objPath, ifName string) error
+	LoadTCEgressAuto(ifName string) error
+	RemoveTCEgress(ifName string) error
+	HasTCEgress(ifName string) (bool, error)
 	UpdateCIDRMap(ifName string, family IPFamily, ip net.IP, mask int, refCount uint32) error
+	UpdateDstCIDRMap(ifName string, family IPFamily, ip net.IP, mask int) error
 	UpdateFailsafeMap(proto uint8, port uint16) error
+	UpdateBlocklistReasonsMap(ip net.IP, mask int, reason string) error
+	RemoveItemBlocklistReasonsMap(ip net.IP, mask int) error
+	DumpBlocklistReasonsMap() (map[CIDRMapKey]string, error)
+	RemoveBlocklistReasonsMap() error
+	UpdateXDPAllowMap(ip net.IP, mask int) error
+	RemoveItemXDPAllowMap(ip net.IP, mask int) error
+	DumpXDPAllowMap() (map[CIDRMapKey]uint32, error)
+	RemoveXDPAllowMap() error
+	UpdateXDPGraceMap(ip net.IP, mask int) error
+	RemoveItemXDPGraceMap(ip net.IP, mask int) error
+	DumpXDPGraceMap() (map[CIDRMapKey]uint32, error)
+	RemoveXDPGraceMap() error
 	loadXDPRaw(objPath, ifName string, mode XDPMode, mapArgs []string) error
 	GetBPFCalicoDir() string
 	AttachToSockmap() error
@@ -293,6 +344,10 @@ func getProgName(ifName string) string {
 	return fmt.Sprintf("prefilter_%s_%s", xdpProgVersion, ifName)
 }
 
+func getTCEgressProgName(ifName string) string {
+	return fmt.Sprintf("egress_filter_%s_%s", tcEgressProgVersion, ifName)
+}
+
 func newMap(name, path, kind string, entries, keySize, valueSize, flags int) (string, error) {
 	// FIXME: for some reason this function was called several times for a
 	// particular map, just assume it's created if the pinned file is there for
@@ -364,23 +419,37 @@ func (b *BPFLib) NewCIDRMap(ifName string, family IPFamily) (string, error) {
 		return "", errors.New("IPv6 not supported")
 	}
 
-	keySize := 8
-	valueSize := 4
+	for shard := 1; shard < b.blocklistShards; shard++ {
+		if err := b.newCIDRMapShard(ifName, family, shard); err != nil {
+			return "", err
+		}
+	}
 
-	return newMap(mapName,
-		mapPath,
-		"lpm_trie",
-		10240,
-		keySize,
-		valueSize,
-		1, // BPF_F_NO_PREALLOC
-	)
+	if _, err := b.newDstCIDRMap(ifName, family); err != nil {
+		return "", err
+	}
+
+	if _, err := b.newXDPAllowMap(); err != nil {
+		return "", err
+	}
+
+	if _, err := b.newXDPGraceMap(); err != nil {
+		return "", err
+	}
+
+	if err := b.newCIDRMapShard(ifName, family, 0); err != nil {
+		return "", err
+	}
+	return mapPath, nil
 }
 
 func (b *BPFLib) ListCIDRMaps(family IPFamily) ([]string, error) {
 	var ifNames []string
 	maps, err := os.ReadDir(b.xdpDir)
-	if err != nil {
+	if os.IsNotExist(err) {
+		// Created along with the first map; or bpffs has been remounted, losing our pins.
+		return nil, nil
+	} else if err != nil {
 		return nil, err
 	}
 
@@ -404,14 +473,37 @@ func (b *BPFLib) RemoveFailsafeMap() error {
 }
 
 func (b *BPFLib) RemoveCIDRMap(ifName string, family IPFamily) error {
+	// Remove all the shards that there could be, in case the map was created with more.
+	for shard := 1; shard < maxBlocklistShards; shard++ {
+		shardPath := filepath.Join(b.xdpDir, getCIDRMapShardName(ifName, family, shard))
+		if err := os.Remove(shardPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if err := b.removeDstCIDRMap(ifName, family); err != nil {
+		return err
+	}
+
 	mapName := getCIDRMapName(ifName, family)
 	mapPath := filepath.Join(b.xdpDir, mapName)
 
 	return os.Remove(mapPath)
 }
 
+// cidrMapShardPaths returns the paths of all the shards of the blocklist map of an interface,
+// starting with shard 0.
+func (b *BPFLib) cidrMapShardPaths(ifName string, family IPFamily) []string {
+	paths := make([]string, b.blocklistShards)
+	for shard := range paths {
+		paths[shard] = filepath.Join(b.xdpDir, getCIDRMapShardName(ifName, family, shard))
+	}
+	return paths
+}
+
 type mapInfo struct {
 	Id        int    `json:"id"`
+	Name      string `json:"name"`
 	Type      string `json:"type"`
 	KeySize   int    `json:"bytes_key"`
 	ValueSize int    `json:"bytes_value"`
@@ -547,27 +639,61 @@ func (b *BPFLib) GetCIDRMapID(ifName string, family IPFamily) (int, error) {
 	return m.Id, nil
 }
 
+// IsValidMap returns whether the blocklist map of an interface has the expected type and
+// layout.  A sharded map is only valid if all its shards exist and have that type and layout;
+// their contents aren't checked here (see CIDRMapContentsEqual).  The map is also invalid if
+// the interface's destination map, or the host's allow-list or grace list, is missing, since
+// the program can't be loaded without them.
 func (b *BPFLib) IsValidMap(ifName string, family IPFamily) (bool, error) {
-	mapName := getCIDRMapName(ifName, family)
-	mapPath := filepath.Join(b.xdpDir, mapName)
-
-	m, err := getMapStruct(mapPath)
-	if err != nil {
-		return false, err
+	if family == IPFamilyV4 {
+		if ok, err := b.isValidDstCIDRMap(ifName, family); err != nil || !ok {
+			return false, err
+		}
+		if ok, err := b.isValidXDPAllowMap(); err != nil || !ok {
+			return false, err
+		}
+		if ok, err := b.isValidXDPGraceMap(); err != nil || !ok {
+			return false, err
+		}
 	}
-	switch family {
-	case IPFamilyV4:
-		if m.Type != "lpm_trie" || m.KeySize != 8 || m.ValueSize != 4 {
-			return false, nil
+	for shard, mapPath := range b.cidrMapShardPaths(ifName, family) {
+		if shard > 0 {
+			if _, err := os.Stat(mapPath); os.IsNotExist(err) {
+				return false, nil
+			}
+		}
+		m, err := getMapStruct(mapPath)
+		if err != nil {
+			return false, err
+		}
+		switch family {
+		case IPFamilyV4:
+			if m.Type != "lpm_trie" || m.KeySize != 8 || m.ValueSize != 4 {
+				return false, nil
+			}
+		case IPFamilyV6:
+			return false, fmt.Errorf("IPv6 not implemented yet")
+		default:
+			return false, fmt.Errorf("unknown IP family %d", family)
 		}
-	case IPFamilyV6:
-		return false, fmt.Errorf("IPv6 not implemented yet")
-	default:
-		return false, fmt.Errorf("unknown IP family %d", family)
 	}
 	return true, nil
 }
 
+// CIDRMapContentsEqual returns whether two dumps of CIDR maps have the same CIDRs with the
+// same reference counts.
+func CIDRMapContentsEqual(a, b map[CIDRMapKey]uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
 func (b *BPFLib) LookupFailsafeMap(proto uint8, port uint16) (bool, error) {
 	mapName := failsafeMapName
 	mapPath := filepath.Join(b.calicoDir, mapName)
@@ -708,6 +834,12 @@ func (b *BPFLib) DumpCIDRMap(ifName string, family IPFamily) (map[CIDRMapKey]uin
 		return nil, err
 	}
 
+	return dumpCIDRMap(mapPath, family)
+}
+
+func dumpCIDRMap(mapPath string, family IPFamily) (map[CIDRMapKey]uint32, error) {
+	mapName := filepath.Base(mapPath)
+
 	prog := "bpftool"
 	args := []string{
 		"--json",
@@ -723,8 +855,14 @@ func (b *BPFLib) DumpCIDRMap(ifName string, family IPFamily) (map[CIDRMapKey]uin
 		return nil, fmt.Errorf("failed to dump in map (%s): %s\n%s", mapName, err, output)
 	}
 
+	return ParseCIDRMapDump(output, family)
+}
+
+// ParseCIDRMapDump parses the output of "bpftool --json map dump" for a CIDR map into the
+// map's entries, keyed by CIDR, with their reference counts.
+func ParseCIDRMapDump(output []byte, family IPFamily) (map[CIDRMapKey]uint32, error) {
 	var al []mapEntry
-	err = json.Unmarshal(output, &al)
+	err := json.Unmarshal(output, &al)
 	if err != nil {
 		return nil, fmt.Errorf("cannot parse json output: %v\n%s", err, output)
 	}
@@ -779,9 +917,6 @@ func (b *BPFLib) RemoveItemFailsafeMap(proto uint8, port uint16) error {
 }
 
 func (b *BPFLib) RemoveItemCIDRMap(ifName string, family IPFamily, ip net.IP, mask int) error {
-	mapName := getCIDRMapName(ifName, family)
-	mapPath := filepath.Join(b.xdpDir, mapName)
-
 	if err := os.MkdirAll(b.xdpDir, 0700); err != nil {
 		return err
 	}
@@ -793,21 +928,29 @@ func (b *BPFLib) RemoveItemCIDRMap(ifName string, family IPFamily, ip net.IP, ma
 		return err
 	}
 
-	prog := "bpftool"
-	args := []string{
-		"map",
-		"delete",
-		"pinned",
-		mapPath,
-		"key",
-		"hex"}
+	if SyscallSupport() {
+		return b.deleteFromCIDRMapShards(ifName, family, hexKey)
+	}
 
-	args = append(args, hexKey...)
+	for _, mapPath := range b.cidrMapShardPaths(ifName, family) {
+		mapName := filepath.Base(mapPath)
 
-	printCommand(prog, args...)
-	output, err := exec.Command(prog, args...).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to delete item (%v/%d) from map (%s): %s\n%s", ip, mask, mapName, err, output)
+		prog := "bpftool"
+		args := []string{
+			"map",
+			"delete",
+			"pinned",
+			mapPath,
+			"key",
+			"hex"}
+
+		args = append(args, hexKey...)
+
+		printCommand(prog, args...)
+		output, err := exec.Command(prog, args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to delete item (%v/%d) from map (%s): %s\n%s", ip, mask, mapName, err, output)
+		}
 	}
 
 	return nil
@@ -850,9 +993,6 @@ func (b *BPFLib) UpdateFailsafeMap(proto uint8, port uint16) error {
 }
 
 func (b *BPFLib) UpdateCIDRMap(ifName string, family IPFamily, ip net.IP, mask int, refCount uint32) error {
-	mapName := getCIDRMapName(ifName, family)
-	mapPath := filepath.Join(b.xdpDir, mapName)
-
 	if err := os.MkdirAll(b.xdpDir, 0700); err != nil {
 		return err
 	}
@@ -865,22 +1005,30 @@ func (b *BPFLib) UpdateCIDRMap(ifName string, family IPFamily, ip net.IP, mask i
 	}
 	hexValue := cidrMapValueToHex(refCount)
 
-	prog := "bpftool"
-	args := []string{
-		"map",
-		"update",
-		"pinned",
-		mapPath,
-		"key",
-		"hex"}
-	args = append(args, hexKey...)
-	args = append(args, "value", "hex")
-	args = append(args, hexValue...)
+	if SyscallSupport() {
+		return b.updateCIDRMapShards(ifName, family, hexKey, hexValue)
+	}
 
-	printCommand(prog, args...)
-	output, err := exec.Command(prog, args...).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to update map (%s) with (%v/%d): %s\n%s", mapName, ip, mask, err, output)
+	for _, mapPath := range b.cidrMapShardPaths(ifName, family) {
+		mapName := filepath.Base(mapPath)
+
+		prog := "bpftool"
+		args := []string{
+			"map",
+			"update",
+			"pinned",
+			mapPath,
+			"key",
+			"hex"}
+		args = append(args, hexKey...)
+		args = append(args, "value", "hex")
+		args = append(args, hexValue...)
+
+		printCommand(prog, args...)
+		output, err := exec.Command(prog, args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to update map (%s) with (%v/%d): %s\n%s", mapName, ip, mask, err, output)
+		}
 	}
 
 	return nil
@@ -927,16 +1075,25 @@ func (b *BPFLib) loadXDPRaw(objPath, ifName string, mode XDPMode, mapArgs []stri
 
 func (b *BPFLib) getMapArgs(ifName string) ([]string, error) {
 	// FIXME hardcoded ipv4, do we need both?
-	mapName := getCIDRMapName(ifName, IPFamilyV4)
-	mapPath := filepath.Join(b.xdpDir, mapName)
+	shardPaths := b.cidrMapShardPaths(ifName, IPFamilyV4)
 
 	failsafeMapPath := filepath.Join(b.calicoDir, failsafeMapName)
 
 	// key: symbol of the map definition in the XDP program
 	// value: path where the map is pinned
 	maps := map[string]string{
-		"calico_prefilter_v4": mapPath,
 		failsafeSymbolMapName: failsafeMapPath,
+		dstCIDRMapSymbol:      b.dstCIDRMapPath(ifName, IPFamilyV4),
+		xdpAllowMapSymbol:     b.xdpAllowMapPath(),
+		xdpGraceMapSymbol:     b.xdpGraceMapPath(),
+	}
+	for shard := 0; shard < maxBlocklistShards; shard++ {
+		// Shards that this host doesn't have share shard 0's map.
+		mapPath := shardPaths[0]
+		if shard < len(shardPaths) {
+			mapPath = shardPaths[shard]
+		}
+		maps[getCIDRMapShardSymbol(shard)] = mapPath
 	}
 
 	var mapArgs []string
@@ -969,6 +1126,44 @@ func (b *BPFLib) RemoveXDP(ifName string, mode XDPMode) error {
 	progName := getProgName(ifName)
 	progPath := filepath.Join(b.xdpDir, progName)
 
+	if _, err := netlink.LinkByName(ifName); netlinkshim.IsNotExist(err) {
+		// The interface has gone, taking the attachment with it, or it has been renamed,
+		// in which case the program is still attached under the new name.  Detach it from
+		// there, so that the interface can get a program of its own, with maps pinned
+		// under its new name, and then unpin the program so that it's freed.
+		renamed, renamedMode, err := linkWithPinnedXDPProg(progPath)
+		if err != nil {
+			return err
+		}
+		if renamed != "" {
+			log.WithFields(log.Fields{
+				"oldName": ifName,
+				"newName": renamed,
+			}).Info("Interface was renamed, detaching XDP program from it under its new name.")
+			if err := detachXDP(renamed, renamedMode); err != nil {
+				return err
+			}
+		} else {
+			log.WithField("iface", ifName).Debug("Interface no longer exists, only unpinning XDP program.")
+		}
+		if err := os.Remove(progPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := detachXDP(ifName, mode); err != nil {
+		return err
+	}
+
+	if err := os.Remove(progPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// detachXDP detaches the XDP program attached to the interface in the given mode, if any.
+func detachXDP(ifName string, mode XDPMode) error {
 	prog := "ip"
 	args := []string{
 		"link",
@@ -981,10 +1176,188 @@ func (b *BPFLib) RemoveXDP(ifName string, mode XDPMode) error {
 	printCommand(prog, args...)
 	output, err := exec.Command(prog, args...).CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("failed to detach XDP program (%s) from %s: %s\n%s", progPath, ifName, err, output)
+		return fmt.Errorf("failed to detach XDP program from %s: %s\n%s", ifName, err, output)
 	}
+	return nil
+}
 
-	return os.Remove(progPath)
+// linkWithPinnedXDPProg returns the name of the interface that the XDP program pinned at the
+// given path is attached to, and the mode that it's attached in, or "" if the program isn't
+// pinned or isn't attached to any interface in this network namespace.
+func linkWithPinnedXDPProg(progPath string) (string, XDPMode, error) {
+	if _, err := os.Stat(progPath); os.IsNotExist(err) {
+		return "", 0, nil
+	}
+
+	prog := "bpftool"
+	args := []string{
+		"--json",
+		"--pretty",
+		"prog",
+		"show",
+		"pinned",
+		progPath}
+
+	printCommand(prog, args...)
+	output, err := exec.Command(prog, args...).CombinedOutput()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to show XDP program (%s): %s\n%s", progPath, err, output)
+	}
+	p := ProgInfo{}
+	if err := json.Unmarshal(output, &p); err != nil {
+		return "", 0, fmt.Errorf("cannot parse json output: %v\n%s", err, output)
+	}
+	if p.Err != "" {
+		return "", 0, fmt.Errorf("%s", p.Err)
+	}
+
+	links, err := netlink.LinkList()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to list interfaces: %w", err)
+	}
+	for _, link := range links {
+		xdp := link.Attrs().Xdp
+		if xdp == nil || !xdp.Attached || int(xdp.ProgId) != p.Id {
+			continue
+		}
+		switch xdp.AttachMode {
+		case nl.XDP_ATTACHED_DRV:
+			return link.Attrs().Name, XDPDriver, nil
+		case nl.XDP_ATTACHED_HW:
+			return link.Attrs().Name, XDPOffload, nil
+		default:
+			return link.Attrs().Name, XDPGeneric, nil
+		}
+	}
+	return "", 0, nil
+}
+
+// LoadTCEgress attaches a TC egress program to the interface that drops the packets that the
+// host sends to addresses on the interface's blocklist.  The program reads the same maps as the
+// XDP program, so those must exist first.
+func (b *BPFLib) LoadTCEgress(objPath, ifName string) error {
+	objPath = path.Join(b.binDir, objPath)
+
+	if _, err := os.Stat(objPath); os.IsNotExist(err) {
+		return fmt.Errorf("cannot find TC egress object %q", objPath)
+	}
+
+	mapArgs, err := b.getMapArgs(ifName)
+	if err != nil {
+		return err
+	}
+
+	progPath := filepath.Join(b.xdpDir, getTCEgressProgName(ifName))
+	if err := b.loadBPF(objPath, progPath, "classifier", mapArgs); err != nil {
+		return err
+	}
+
+	prog := "tc"
+	args := []string{
+		"qdisc",
+		"add",
+		"dev",
+		ifName,
+		"clsact"}
+
+	printCommand(prog, args...)
+	output, err := exec.Command(prog, args...).CombinedOutput()
+	if err != nil && !strings.Contains(string(output), "File exists") {
+		return fmt.Errorf("failed to add clsact qdisc to %s: %s\n%s", ifName, err, output)
+	}
+
+	args = []string{
+		"filter",
+		"replace",
+		"dev",
+		ifName,
+		"egress",
+		"pref",
+		tcEgressFilterPref,
+		"handle",
+		"1",
+		"bpf",
+		"direct-action",
+		"pinned",
+		progPath}
+
+	printCommand(prog, args...)
+	output, err = exec.Command(prog, args...).CombinedOutput()
+	if err != nil {
+		if removeErr := os.Remove(progPath); removeErr != nil {
+			return fmt.Errorf("failed to attach TC egress program (%s) to %s: %s (also failed to remove the pinned program: %s)\n%s", progPath, ifName, err, removeErr, output)
+		}
+		return fmt.Errorf("failed to attach TC egress program (%s) to %s: %s\n%s", progPath, ifName, err, output)
+	}
+
+	return nil
+}
+
+func (b *BPFLib) LoadTCEgressAuto(ifName string) error {
+	return b.LoadTCEgress(tcEgressFilename, ifName)
+}
+
+// RemoveTCEgress detaches and unpins the interface's TC egress program.  It succeeds if there's
+// no program to remove.
+func (b *BPFLib) RemoveTCEgress(ifName string) error {
+	progPath := filepath.Join(b.xdpDir, getTCEgressProgName(ifName))
+
+	hasProg, err := b.HasTCEgress(ifName)
+	if err != nil {
+		return err
+	}
+	if hasProg {
+		prog := "tc"
+		args := []string{
+			"filter",
+			"del",
+			"dev",
+			ifName,
+			"egress",
+			"pref",
+			tcEgressFilterPref}
+
+		printCommand(prog, args...)
+		output, err := exec.Command(prog, args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to detach TC egress program (%s) from %s: %s\n%s", progPath, ifName, err, output)
+		}
+	}
+
+	if err := os.Remove(progPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// HasTCEgress returns true if the interface has the TC egress program attached.
+func (b *BPFLib) HasTCEgress(ifName string) (bool, error) {
+	if _, err := netlink.LinkByName(ifName); netlinkshim.IsNotExist(err) {
+		return false, nil
+	}
+
+	prog := "tc"
+	args := []string{
+		"filter",
+		"show",
+		"dev",
+		ifName,
+		"egress",
+		"pref",
+		tcEgressFilterPref}
+
+	printCommand(prog, args...)
+	output, err := exec.Command(prog, args...).CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(output), "Cannot find specified qdisc") {
+			// No clsact qdisc, so no filter either.
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to show TC egress filters of %s: %s\n%s", ifName, err, output)
+	}
+
+	// Nothing else uses our filter priority, so any filter there is ours.
+	return len(strings.TrimSpace(string(output))) > 0, nil
 }
 
 func (b *BPFLib) GetXDPTag(ifName string) (string, error) {
@@ -1122,7 +1495,12 @@ func (b *BPFLib) GetXDPID(ifName string) (int, error) {
 		return -1, fmt.Errorf("failed to show interface information (%s): %s\n%s", ifName, err, output)
 	}
 
-	s := strings.Fields(string(output))
+	return xdpIDFromLinkShow(string(output))
+}
+
+// xdpIDFromLinkShow extracts the ID of the attached XDP program from the output of "ip link show".
+func xdpIDFromLinkShow(output string) (int, error) {
+	s := strings.Fields(output)
 	for i := range s {
 		// Example of output:
 		//
@@ -1141,6 +1519,40 @@ func (b *BPFLib) GetXDPID(ifName string) (int, error) {
 	return -1, errors.New("ID not found")
 }
 
+// xlatedMapRefRegexp matches the references to maps in the output of "bpftool prog dump xlated".
+// The kernel substitutes the IDs of the maps, which depend on the order in which the maps were
+// created, so they have to be masked out to compare programs.
+var xlatedMapRefRegexp = regexp.MustCompile(`map\[id:\d+\]`)
+
+// XDPProgramSHA returns a SHA-256 hash of the instructions of the XDP program that is attached
+// to the given interface in the given Felix.  The hash is the same for any load of the
+// same program build, whichever maps it uses, so it can be compared with the hash from another
+// node, or from before an upgrade, to check whether the same program is loaded.
+//
+// In BPF mode, the XDP program attached to the interface is the entrypoint; the policy
+// program that it tail-calls is not included.
+func XDPProgramSHA(felix CommandRunner, iface string) (string, error) {
+	out, err := felix.ExecOutput("ip", "link", "show", "dev", iface)
+	if err != nil {
+		return "", fmt.Errorf("failed to show interface information (%s): %w\n%s", iface, err, out)
+	}
+	id, err := xdpIDFromLinkShow(out)
+	if err != nil {
+		return "", fmt.Errorf("no XDP program attached to %s: %w", iface, err)
+	}
+	out, err = felix.ExecOutput("bpftool", "prog", "dump", "xlated", "id", strconv.Itoa(id))
+	if err != nil {
+		return "", fmt.Errorf("failed to dump XDP program %d (%s): %w\n%s", id, iface, err, out)
+	}
+	return xlatedProgramSHA(out), nil
+}
+
+// xlatedProgramSHA hashes the output of "bpftool prog dump xlated", with the map IDs masked.
+func xlatedProgramSHA(xlated string) string {
+	masked := xlatedMapRefRegexp.ReplaceAllString(xlated, "map[id:*]")
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(masked)))
+}
+
 func (b *BPFLib) GetXDPMode(ifName string) (XDPMode, error) {
 	prog := "ip"
 	args := []string{
@@ -1155,7 +1567,13 @@ func (b *BPFLib) GetXDPMode(ifName string) (XDPMode, error) {
 		return XDPGeneric, fmt.Errorf("failed to show interface information (%s): %s\n%s", ifName, err, output)
 	}
 
-	s := strings.Fields(string(output))
+	return xdpModeFromLinkShow(string(output))
+}
+
+// xdpModeFromLinkShow extracts the mode of the attached XDP program from the output of "ip link
+// show".
+func xdpModeFromLinkShow(output string) (XDPMode, error) {
+	s := strings.Fields(output)
 	// Note: using a slice (rather than a map[string]XDPMode) here to ensure deterministic ordering.
 	for _, modeMapping := range []struct {
 		String string
@@ -1415,6 +1833,9 @@ func maybeDeleteIface(name string) error {
 	return nil
 }
 
+// SupportsXDP returns an error if the kernel can't run Felix's XDP programs.  Both programs look
+// past a single 802.1Q/802.1ad tag, so the blocklist applies to frames on trunk interfaces too;
+// frames with stacked tags are passed without being matched.
 func SupportsXDP() error {
 	if err := isAtLeastKernel(v4Dot16Dot0); err != nil {
 		return err
@@ -1428,6 +1849,88 @@ func SupportsXDP() error {
 	return nil
 }
 
+// sysClassNetDir is where the kernel lists network interfaces; a variable so that tests can fake
+// it.
+var sysClassNetDir = "/sys/class/net"
+
+// nativeXDPVFDrivers are the drivers of SR-IOV virtual functions that are known to implement
+// native XDP.  Other VF drivers may reject a native XDP program or, worse, reset the VF while
+// trying to load it, so Felix uses generic mode on them.
+var nativeXDPVFDrivers = set.From("mlx5_core", "ixgbevf")
+
+// IsSRIOVVirtualFunction returns true if the given interface is an SR-IOV virtual function: its
+// PCI device has a physical function.
+func IsSRIOVVirtualFunction(ifName string) bool {
+	_, err := os.Lstat(filepath.Join(sysClassNetDir, ifName, "device", "physfn"))
+	return err == nil
+}
+
+// InterfaceDriver returns the name of the kernel driver behind the given interface, or "" if it
+// has no device, as for virtual interfaces such as veths.
+func InterfaceDriver(ifName string) string {
+	target, err := os.Readlink(filepath.Join(sysClassNetDir, ifName, "device", "driver"))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
+
+// VFDriverSupportsNativeXDP returns true if the given driver of an SR-IOV virtual function is
+// known to implement native XDP.
+func VFDriverSupportsNativeXDP(driver string) bool {
+	return nativeXDPVFDrivers.Contains(driver)
+}
+
+// SupportsXDPOnInterface returns an error if Felix shouldn't try to attach an XDP program to the
+// given interface in the given mode.  Apart from the kernel checks of SupportsXDP, it only rules
+// out modes that SR-IOV virtual functions can't support: offload, which only a physical
+// function can do, and native mode on VFs whose drivers don't implement it.  For other
+// interfaces, trying to attach is the only way to find out.
+func SupportsXDPOnInterface(ifName string, mode XDPMode) error {
+	if err := SupportsXDP(); err != nil {
+		return err
+	}
+	if !IsSRIOVVirtualFunction(ifName) {
+		return nil
+	}
+	switch mode {
+	case XDPOffload:
+		return fmt.Errorf("%s is an SR-IOV virtual function, which can't offload XDP", ifName)
+	case XDPDriver:
+		if driver := InterfaceDriver(ifName); !VFDriverSupportsNativeXDP(driver) {
+			return fmt.Errorf("%s is an SR-IOV virtual function and its driver, %q, doesn't support native XDP",
+				ifName, driver)
+		}
+	}
+	return nil
+}
+
+func (b *BPFLib) SupportsXDPOnInterface(ifName string, mode XDPMode) error {
+	return SupportsXDPOnInterface(ifName, mode)
+}
+
+// InterfaceSpeed returns the link speed of the given interface in Mbit/s, as ethtool reports
+// it.  It returns an error if the interface doesn't report a speed: many virtual interfaces
+// don't, and the kernel reports an unknown speed for an interface that is down.
+func InterfaceSpeed(ifName string) (int, error) {
+	out, err := os.ReadFile(filepath.Join(sysClassNetDir, ifName, "speed"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the link speed of %s: %w", ifName, err)
+	}
+	speed, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse the link speed of %s: %w", ifName, err)
+	}
+	if speed <= 0 {
+		return 0, fmt.Errorf("%s doesn't know its link speed", ifName)
+	}
+	return speed, nil
+}
+
+func (b *BPFLib) InterfaceSpeed(ifName string) (int, error) {
+	return InterfaceSpeed(ifName)
+}
+
 func (b *BPFLib) AttachToSockmap() error {
 	mapPath := filepath.Join(b.sockmapDir, sockMapName)
 	progPath := filepath.Join(b.sockmapDir, skMsgProgName)
@@ -157,6 +157,55 @@ func RunBPFProgram(fd ProgFD, dataIn []byte, repeat int) (pr ProgResult, err err
 	return
 }
 
+// GetProgFDByID returns a new file descriptor for the loaded program with the given ID.
+func GetProgFDByID(progID int) (ProgFD, error) {
+	log.Debugf("GetProgFDByID(%v)", progID)
+	bpfAttr := C.bpf_attr_alloc()
+	defer C.free(unsafe.Pointer(bpfAttr))
+
+	// prog_id shares its place in the union with map_id.
+	C.bpf_attr_setup_obj_get_id(bpfAttr, C.uint(progID), 0)
+	fd, _, errno := unix.Syscall(unix.SYS_BPF, unix.BPF_PROG_GET_FD_BY_ID, uintptr(unsafe.Pointer(bpfAttr)), C.sizeof_union_bpf_attr)
+	if errno != 0 {
+		return 0, errno
+	}
+
+	return ProgFD(fd), nil
+}
+
+// CreatePinnedMapOnNUMANode creates a BPF map whose memory is allocated on the given NUMA node
+// and pins it at the given path.  The name is truncated to the length that the kernel allows.
+func CreatePinnedMapOnNUMANode(filename, name string, mapType, keySize, valueSize, maxEntries, flags uint32, numaNode int) error {
+	bpfAttr := C.bpf_attr_alloc()
+	defer C.free(unsafe.Pointer(bpfAttr))
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	C.bpf_attr_setup_map_create_numa(bpfAttr, cName, C.uint(mapType), C.uint(keySize), C.uint(valueSize),
+		C.uint(maxEntries), C.uint(flags), C.uint(numaNode))
+	fd, _, errno := unix.Syscall(unix.SYS_BPF, unix.BPF_MAP_CREATE, uintptr(unsafe.Pointer(bpfAttr)), C.sizeof_union_bpf_attr)
+	if errno != 0 {
+		return errno
+	}
+	defer unix.Close(int(fd))
+
+	// The kernel rejects a BPF_OBJ_PIN whose unused fields aren't zero, so start afresh.
+	pinAttr := C.bpf_attr_alloc()
+	defer C.free(unsafe.Pointer(pinAttr))
+
+	cFilename := C.CString(filename)
+	defer C.free(unsafe.Pointer(cFilename))
+
+	C.bpf_attr_setup_obj_pin(pinAttr, cFilename, C.uint(fd), 0)
+	_, _, errno = unix.Syscall(unix.SYS_BPF, unix.BPF_OBJ_PIN, uintptr(unsafe.Pointer(pinAttr)), C.sizeof_union_bpf_attr)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
 func PinBPFProgram(fd ProgFD, filename string) error {
 	bpfAttr := C.bpf_attr_alloc()
 	defer C.free(unsafe.Pointer(bpfAttr))
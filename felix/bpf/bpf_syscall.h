@@ -47,6 +47,42 @@ void bpf_attr_setup_obj_pin(union bpf_attr *attr, char *path, __u32 fd, __u32 fl
    attr->file_flags = flags;
 }
 
+// bpf_attr_setup_map_create_numa sets up the bpf_attr union for use with BPF_MAP_CREATE, for a
+// map whose memory is allocated on the given NUMA node.
+// A C function makes this easier because unions aren't easy to access from Go.
+void bpf_attr_setup_map_create_numa(union bpf_attr *attr,
+				    const char *name,
+				    __u32 map_type,
+				    __u32 key_size,
+				    __u32 value_size,
+				    __u32 max_entries,
+				    __u32 map_flags,
+				    __u32 numa_node)
+{
+   attr->map_type = map_type;
+   attr->key_size = key_size;
+   attr->value_size = value_size;
+   attr->max_entries = max_entries;
+   attr->map_flags = map_flags | BPF_F_NUMA_NODE;
+   attr->numa_node = numa_node;
+
+   if (name) {
+	   int sz = sizeof(attr->map_name);
+
+	   int i;
+	   for (i = 0; i < sz; i++) {
+		   attr->map_name[i] = name[i];
+		   if (name[i] == '\0') {
+			   break;
+		   }
+	   }
+
+	   if (i == sz) {
+		   attr->map_name[sz - 1] = '\0';
+	   }
+   }
+}
+
 // bpf_attr_setup_load_prog sets up the bpf_attr union for use with BPF_PROG_LOAD.
 // A C function makes this easier because unions aren't easy to access from Go.
 void bpf_attr_setup_load_prog(union bpf_attr *attr,
@@ -34,6 +34,14 @@ func RunBPFProgram(fd ProgFD, dataIn []byte, repeat int) (pr ProgResult, err err
 	panic("BPF syscall stub")
 }
 
+func GetProgFDByID(progID int) (ProgFD, error) {
+	panic("BPF syscall stub")
+}
+
 func PinBPFProgram(fd ProgFD, filename string) error {
 	panic("BPF syscall stub")
 }
+
+func CreatePinnedMapOnNUMANode(filename, name string, mapType, keySize, valueSize, maxEntries, flags uint32, numaNode int) error {
+	panic("BPF syscall stub")
+}
@@ -23,9 +23,15 @@ import (
 	"net"
 	"os"
 	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
 	. "github.com/onsi/gomega"
+	"golang.org/x/sys/unix"
 
 	"github.com/projectcalico/calico/felix/environment"
 	"github.com/projectcalico/calico/felix/logutils"
@@ -745,6 +751,52 @@ func TestMemberToIPMask(t *testing.T) {
 	}
 }
 
+func TestCidrToHexLPMKey(t *testing.T) {
+	RegisterTestingT(t)
+	t.Log("CidrToHex should put a little endian prefix length before the address in network order")
+	for cidr, expected := range map[string][]string{
+		"10.65.0.2/32": {"20", "00", "00", "00", "0a", "41", "00", "02"},
+		"10.66.3.0/24": {"18", "00", "00", "00", "0a", "42", "03", "00"},
+		"11.0.0.0/8":   {"08", "00", "00", "00", "0b", "00", "00", "00"},
+		"0.0.0.0/0":    {"00", "00", "00", "00", "00", "00", "00", "00"},
+	} {
+		hexCIDR, err := CidrToHex(cidr)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hexCIDR).To(Equal(expected), "wrong key for %s", cidr)
+
+		ipNet, err := hexToIPNet(hexCIDR, IPFamilyV4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ipNet.String()).To(Equal(cidr))
+	}
+}
+
+func TestParseCIDRMapDump(t *testing.T) {
+	RegisterTestingT(t)
+	t.Log("ParseCIDRMapDump should keep overlapping CIDRs as separate entries")
+	output := []byte(`[{
+		"key": ["0x20","0x00","0x00","0x00","0x0a","0x00","0x00","0x05"],
+		"value": ["0x01","0x00","0x00","0x00"]
+	},{
+		"key": ["0x18","0x00","0x00","0x00","0x0a","0x00","0x00","0x00"],
+		"value": ["0x02","0x00","0x00","0x00"]
+	}]`)
+
+	m, err := ParseCIDRMapDump(output, IPFamilyV4)
+	Expect(err).NotTo(HaveOccurred())
+
+	contents := map[string]uint32{}
+	for k, v := range m {
+		contents[k.ToIPNet().String()] = v
+	}
+	Expect(contents).To(Equal(map[string]uint32{
+		"10.0.0.5/32": 1,
+		"10.0.0.0/24": 2,
+	}))
+
+	_, err = ParseCIDRMapDump([]byte("not json"), IPFamilyV4)
+	Expect(err).To(HaveOccurred())
+}
+
 func TestIPv6NotSupported(t *testing.T) {
 	t.Log("Creating an IPv6 CIDR map should fail for now")
 	_, err := bpfDP.NewCIDRMap("myiface2", IPFamilyV6)
@@ -794,3 +846,625 @@ func TestVersionParse(t *testing.T) {
 		Expect(ver1.Compare(ver2)).To(Equal(test.expected))
 	}
 }
+
+func TestXDPEventParsing(t *testing.T) {
+	RegisterTestingT(t)
+
+	e, err := XDPEventFromBytes([]byte{10, 65, 0, 2, 1, 0, 0, 0})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(e.SrcIP.String()).To(Equal("10.65.0.2"))
+	Expect(e.Verdict).To(Equal(XDPVerdictDrop))
+	Expect(e.String()).To(Equal("src=10.65.0.2 verdict=DROP"))
+
+	parsed, err := ParseXDPEvent(e.String())
+	Expect(err).NotTo(HaveOccurred())
+	Expect(parsed.SrcIP.Equal(e.SrcIP)).To(BeTrue())
+	Expect(parsed.Verdict).To(Equal(XDPVerdictDrop))
+
+	_, err = XDPEventFromBytes([]byte{10, 65, 0, 2})
+	Expect(err).To(HaveOccurred())
+	_, err = ParseXDPEvent("src=10.65.0.2 verdict=MAYBE")
+	Expect(err).To(HaveOccurred())
+}
+
+func TestXlatedProgramSHA(t *testing.T) {
+	RegisterTestingT(t)
+
+	xlated := func(mapID int) string {
+		return fmt.Sprintf(`   0: (bf) r6 = r1
+   1: (18) r2 = map[id:%d]
+   2: (b7) r3 = 2
+   3: (85) call bpf_tail_call#12
+   4: (b7) r0 = 2
+   5: (95) exit
+`, mapID)
+	}
+
+	// The map IDs depend on the order in which maps were created so they should be ignored.
+	Expect(xlatedProgramSHA(xlated(21))).To(Equal(xlatedProgramSHA(xlated(1234))))
+
+	changed := strings.Replace(xlated(21), "r0 = 2", "r0 = 1", 1)
+	Expect(xlatedProgramSHA(changed)).NotTo(Equal(xlatedProgramSHA(xlated(21))))
+}
+
+func TestXDPIDFromLinkShow(t *testing.T) {
+	RegisterTestingT(t)
+
+	id, err := xdpIDFromLinkShow(`196: test_A@test_B: <BROADCAST,MULTICAST> mtu 1500 xdpgeneric qdisc noop state DOWN mode DEFAULT group default qlen 1000
+    link/ether 1a:d0:df:a5:12:59 brd ff:ff:ff:ff:ff:ff
+    prog/xdp id 175 tag 5199fa060702bbff jited`)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(id).To(Equal(175))
+
+	_, err = xdpIDFromLinkShow(`2: eth0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc noqueue state UP mode DEFAULT group default`)
+	Expect(err).To(HaveOccurred())
+}
+
+// fakeBPFMapRunner emulates the bpftool map commands that SnapshotMap and RestoreMap run
+// against a single map.
+type fakeBPFMapRunner struct {
+	entries  map[string]string
+	commands []string
+
+	// progMapNames maps the ID of each map used by the program to the map's name.
+	progMapNames map[int]string
+	// mapType is the type reported for the pinned map.
+	mapType string
+	// pinned lists the names of the pinned XDP maps.
+	pinned []string
+	// link is the output of "ip link show".
+	link string
+	// status is Felix's XDP status, as served at XDPStatusPath.
+	status string
+	// verdicts maps source IP to the output of "calico-bpf xdp test-lookup".
+	verdicts map[string]string
+	// perCPU dumps the entries as a per-CPU map, with the same value on two CPUs.
+	perCPU bool
+}
+
+func (r *fakeBPFMapRunner) ExecOutput(args ...string) (string, error) {
+	r.commands = append(r.commands, strings.Join(args, " "))
+	switch {
+	case args[0] == "ls", args[0] == "find":
+		return strings.Join(r.pinned, "\n"), nil
+	case args[0] == "ip":
+		return r.link, nil
+	case args[0] == "wget":
+		return r.status, nil
+	case args[0] == "calico-bpf" && args[1] == "xdp":
+		return r.verdicts[args[4]], nil
+	case args[0] == "calico-bpf":
+		return "", nil
+	case args[1] == "--json" && args[2] == "prog" && args[3] == "show":
+		var ids []string
+		for id := range r.progMapNames {
+			ids = append(ids, strconv.Itoa(id))
+		}
+		sort.Strings(ids)
+		return fmt.Sprintf(`{"id":1,"type":"xdp","map_ids":[%s]}`, strings.Join(ids, ",")), nil
+	case args[1] == "--json" && args[2] == "map" && args[3] == "show" && args[4] == "pinned":
+		return fmt.Sprintf(`{"id":1,"type":%q}`, r.mapType), nil
+	case args[1] == "--json" && args[2] == "map" && args[3] == "show":
+		id, _ := strconv.Atoi(args[5])
+		return fmt.Sprintf(`{"id":%d,"name":%q}`, id, r.progMapNames[id]), nil
+	case args[1] == "--json" && args[2] == "map" && args[3] == "lookup":
+		k := strings.Join(args[8:], " ")
+		v, ok := r.entries[k]
+		if !ok {
+			return `{"error":"key not found"}`, fmt.Errorf("exit status 255")
+		}
+		return fmt.Sprintf(`{"key":%s,"value":%s}`, hexJSON(k), hexJSON(v)), nil
+	case args[1] == "--json":
+		var dump []string
+		for k, v := range r.entries {
+			if r.perCPU {
+				dump = append(dump, fmt.Sprintf(`{"key":%s,"values":[{"cpu":0,"value":%s},{"cpu":1,"value":%s}]}`,
+					hexJSON(k), hexJSON(v), hexJSON(v)))
+				continue
+			}
+			dump = append(dump, fmt.Sprintf(`{"key":%s,"value":%s}`, hexJSON(k), hexJSON(v)))
+		}
+		return "[" + strings.Join(dump, ",") + "]", nil
+	case args[2] == "delete":
+		delete(r.entries, strings.Join(args[7:], " "))
+		return "", nil
+	case args[2] == "update":
+		for i, a := range args {
+			if a == "value" {
+				r.entries[strings.Join(args[7:i], " ")] = strings.Join(args[i+2:], " ")
+			}
+		}
+		return "", nil
+	}
+	return "", fmt.Errorf("unexpected command %v", args)
+}
+
+func hexJSON(hex string) string {
+	var quoted []string
+	for _, h := range strings.Fields(hex) {
+		quoted = append(quoted, `"0x`+h+`"`)
+	}
+	return "[" + strings.Join(quoted, ",") + "]"
+}
+
+func TestXDPAttachFlags(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(XDPDriver.AttachFlags()).To(Equal(uint32(unix.XDP_FLAGS_DRV_MODE | unix.XDP_FLAGS_UPDATE_IF_NOEXIST)))
+	Expect(XDPGeneric.AttachFlags()).To(Equal(uint32(unix.XDP_FLAGS_SKB_MODE | unix.XDP_FLAGS_UPDATE_IF_NOEXIST)))
+
+	runner := &fakeBPFMapRunner{
+		status: fmt.Sprintf(`{"eth0":{"attached":true,"mode":"xdpgeneric","attachFlags":%d},"eth1":{"desired":true}}`,
+			XDPGeneric.AttachFlags()),
+		link: `2: eth0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 xdpgeneric qdisc noqueue state UP mode DEFAULT group default
+    link/ether 1a:d0:df:a5:12:59 brd ff:ff:ff:ff:ff:ff
+    prog/xdp id 175 tag 5199fa060702bbff jited`,
+	}
+	flags, err := XDPAttachFlags(runner, "eth0")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(flags & unix.XDP_FLAGS_UPDATE_IF_NOEXIST).NotTo(BeZero())
+	Expect(flags & unix.XDP_FLAGS_SKB_MODE).NotTo(BeZero())
+
+	_, err = XDPAttachFlags(runner, "eth1")
+	Expect(err).To(MatchError(ContainSubstring("no XDP program attached")))
+
+	// Felix and the kernel disagree about the mode.
+	runner.link = strings.Replace(runner.link, "xdpgeneric", "xdp", 1)
+	_, err = XDPAttachFlags(runner, "eth0")
+	Expect(err).To(MatchError(ContainSubstring("the kernel reports mode xdpdrv")))
+}
+
+func TestSnapshotAndRestoreMap(t *testing.T) {
+	RegisterTestingT(t)
+
+	const mapPath = "/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist"
+	runner := &fakeBPFMapRunner{entries: map[string]string{
+		"20 00 00 00 0a 41 00 02": "01 00 00 00",
+		"18 00 00 00 0a 42 00 00": "02 00 00 00",
+	}}
+
+	snapshot, err := SnapshotMap(runner, mapPath)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(snapshot).To(Equal(Snapshot{
+		string([]byte{0x20, 0, 0, 0, 10, 65, 0, 2}): {1, 0, 0, 0},
+		string([]byte{0x18, 0, 0, 0, 10, 66, 0, 0}): {2, 0, 0, 0},
+	}))
+
+	cidrs, err := snapshot.CIDRs()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cidrs).To(Equal([]string{"10.65.0.2/32", "10.66.0.0/24"}))
+
+	t.Log("Restoring an unchanged map should only dump it")
+	runner.commands = nil
+	Expect(RestoreMap(runner, mapPath, snapshot)).To(Succeed())
+	Expect(runner.commands).To(HaveLen(1), "only the dump should have been run")
+
+	t.Log("Restoring a map that was tampered with should undo the changes")
+	delete(runner.entries, "20 00 00 00 0a 41 00 02")
+	runner.entries["18 00 00 00 0a 42 00 00"] = "05 00 00 00"
+	runner.entries["20 00 00 00 01 02 03 04"] = "01 00 00 00"
+	Expect(RestoreMap(runner, mapPath, snapshot)).To(Succeed())
+	Expect(runner.entries).To(Equal(map[string]string{
+		"20 00 00 00 0a 41 00 02": "01 00 00 00",
+		"18 00 00 00 0a 42 00 00": "02 00 00 00",
+	}))
+
+	_, err = parseMapDump(`[{"key":["0x01"],"value":["0x02"]},{"error":"bad entry"}]`)
+	Expect(err).To(HaveOccurred())
+}
+
+func TestVerifyAllProgrammed(t *testing.T) {
+	RegisterTestingT(t)
+
+	const mapPath = "/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist"
+	runner := &fakeBPFMapRunner{entries: map[string]string{
+		"20 00 00 00 0a 41 00 02": "01 00 00 00",
+		"18 00 00 00 0a 42 00 00": "01 00 00 00",
+	}}
+
+	Expect(VerifyAllProgrammed(runner, mapPath, []string{"10.65.0.2", "10.66.0.0/24"})).To(Succeed())
+
+	err := VerifyAllProgrammed(runner, mapPath, []string{"10.65.0.2/32", "10.66.0.0/24", "10.67.0.1/32"})
+	Expect(err).To(MatchError(ContainSubstring("has 2 of 3 expected CIDRs; missing 1 (10.67.0.1/32), unexpected 0")))
+
+	err = VerifyAllProgrammed(runner, mapPath, []string{"10.65.0.2/32"})
+	Expect(err).To(MatchError(ContainSubstring("missing 0 (), unexpected 1 (10.66.0.0/24)")))
+}
+
+func TestParseNUMANodeList(t *testing.T) {
+	RegisterTestingT(t)
+
+	for s, expected := range map[string]int{
+		"0\n":     1,
+		"0-1\n":   2,
+		"0,2-3\n": 4,
+		"1\n":     2,
+	} {
+		n, err := parseNUMANodeList(s)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(expected), s)
+	}
+
+	_, err := parseNUMANodeList("")
+	Expect(err).To(HaveOccurred())
+}
+
+func TestXDPInterfacePins(t *testing.T) {
+	RegisterTestingT(t)
+
+	xdpDir := "/sys/fs/bpf/" + bpfCalicoSubdir + "/xdp"
+	runner := &fakeBPFMapRunner{
+		pinned: []string{
+			"/sys/fs/bpf/" + bpfCalicoSubdir,
+			xdpDir,
+			xdpDir + "/calico_failsafe_ports_v1",
+			xdpDir + "/eth0_ipv4_v1_blacklist",
+			xdpDir + "/eth0_ipv4_v1_blacklist_shard1",
+			xdpDir + "/prefilter_v1_eth0",
+			"/sys/fs/bpf/tc",
+			"/sys/fs/bpf/tc/globals",
+			"/sys/fs/bpf/tc/globals/cali_v4_state",
+			"/sys/fs/bpf/tc/eth1_igr",
+			"/sys/fs/bpf/tc/eth1_xdp",
+			"/sys/fs/bpf/tc/eth1_xdp/cali_jump2",
+		},
+	}
+	pins, err := XDPInterfacePins(runner)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(pins).To(Equal([]string{
+		xdpDir + "/eth0_ipv4_v1_blacklist",
+		xdpDir + "/eth0_ipv4_v1_blacklist_shard1",
+		xdpDir + "/prefilter_v1_eth0",
+		"/sys/fs/bpf/tc/eth1_xdp",
+	}))
+}
+
+func TestMapType(t *testing.T) {
+	RegisterTestingT(t)
+
+	runner := &fakeBPFMapRunner{mapType: "lpm_trie"}
+	mapType, err := MapType(runner, "/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(mapType).To(Equal("lpm_trie"))
+	Expect(runner.commands).To(Equal([]string{
+		"bpftool --json map show pinned /sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist",
+	}))
+}
+
+func TestPerSourceDropCounts(t *testing.T) {
+	RegisterTestingT(t)
+
+	runner := &fakeBPFMapRunner{
+		entries: map[string]string{
+			"0a 41 00 02": "05 00 00 00 00 00 00 00",
+			"0a 41 00 03": "00 01 00 00 00 00 00 00",
+		},
+		progMapNames: map[int]string{
+			3: "calico_prefilt",
+			4: "calico_failsaf",
+			5: xdpDropCountsMapName,
+		},
+	}
+	counts, err := PerSourceDropCounts(runner, "eth0")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(counts).To(Equal(map[string]uint64{
+		"10.65.0.2": 5,
+		"10.65.0.3": 256,
+	}))
+	Expect(runner.commands[0]).To(HavePrefix("bpftool --json prog show pinned /sys/fs/bpf/"))
+	Expect(runner.commands[0]).To(HaveSuffix("/xdp/prefilter_v1_eth0"))
+	Expect(runner.commands[len(runner.commands)-1]).To(Equal("bpftool --json map dump id 5"))
+
+	delete(runner.progMapNames, 5)
+	_, err = PerSourceDropCounts(runner, "eth0")
+	Expect(err).To(HaveOccurred())
+}
+
+func TestPerSourceDropCountsPerCPU(t *testing.T) {
+	RegisterTestingT(t)
+
+	runner := &fakeBPFMapRunner{
+		entries: map[string]string{
+			"0a 41 00 02": "05 00 00 00 00 00 00 00",
+		},
+		progMapNames: map[int]string{
+			5: xdpDropCountsMapName,
+		},
+		perCPU: true,
+	}
+	counts, err := PerSourceDropCounts(runner, "eth0")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(counts).To(Equal(map[string]uint64{"10.65.0.2": 10}), "the per-CPU counts should be summed")
+}
+
+func TestPerSourceGraceCounts(t *testing.T) {
+	RegisterTestingT(t)
+
+	runner := &fakeBPFMapRunner{
+		entries: map[string]string{
+			"0a 41 00 02": "07 00 00 00 00 00 00 00",
+		},
+		progMapNames: map[int]string{
+			3: "calico_prefilt",
+			5: xdpDropCountsMapName,
+			6: xdpGraceCountsMapName,
+		},
+	}
+	counts, err := PerSourceGraceCounts(runner, "eth0")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(counts).To(Equal(map[string]uint64{"10.65.0.2": 7}))
+	Expect(runner.commands[len(runner.commands)-1]).To(Equal("bpftool --json map dump id 6"))
+
+	delete(runner.progMapNames, 6)
+	_, err = PerSourceGraceCounts(runner, "eth0")
+	Expect(err).To(HaveOccurred())
+}
+
+func TestFailsafeBypassEvents(t *testing.T) {
+	RegisterTestingT(t)
+
+	runner := &fakeBPFMapRunner{
+		entries: map[string]string{
+			"11 00 d2 04": "02 00 00 00 00 00 00 00",
+			"06 00 16 00": "03 00 00 00 00 00 00 00",
+		},
+		progMapNames: map[int]string{
+			3: "calico_prefilt",
+			5: xdpDropCountsMapName,
+			7: xdpFailsafeBypassCountsMapName,
+		},
+	}
+	events, err := FailsafeBypassEvents(runner, "eth0")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(events).To(Equal([]FailsafeBypassEvent{
+		{ProtoPort: ProtoPort{Proto: labelindex.ProtocolTCP, Port: 22}, Packets: 3},
+		{ProtoPort: ProtoPort{Proto: labelindex.ProtocolUDP, Port: 1234}, Packets: 2},
+	}))
+	Expect(events[0].String()).To(Equal("inbound tcp:22: 3 packets"))
+	Expect(runner.commands[len(runner.commands)-1]).To(Equal("bpftool --json map dump id 7"))
+
+	delete(runner.progMapNames, 7)
+	_, err = FailsafeBypassEvents(runner, "eth0")
+	Expect(err).To(HaveOccurred())
+}
+
+func TestResetXDPCounters(t *testing.T) {
+	RegisterTestingT(t)
+
+	runner := &fakeBPFMapRunner{
+		entries: map[string]string{
+			"0a 41 00 02": "05 00 00 00 00 00 00 00",
+			"0a 41 00 03": "00 01 00 00 00 00 00 00",
+		},
+		progMapNames: map[int]string{
+			3: "calico_prefilt",
+			5: xdpDropCountsMapName,
+			6: xdpGraceCountsMapName,
+			7: xdpFailsafeBypassCountsMapName,
+		},
+	}
+	Expect(ResetXDPCounters(runner, "eth0")).To(Succeed())
+	Expect(runner.commands[0]).To(Equal("calico-bpf counters flush --iface=eth0"))
+	Expect(runner.commands).To(ContainElements(
+		"bpftool map delete id 5 key hex 0a 41 00 02",
+		"bpftool map delete id 5 key hex 0a 41 00 03",
+	))
+
+	counts, err := PerSourceDropCounts(runner, "eth0")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(counts).To(BeEmpty())
+}
+
+func TestSupportsXDPOnInterface(t *testing.T) {
+	RegisterTestingT(t)
+	if err := SupportsXDP(); err != nil {
+		t.Skipf("XDP not supported: %v", err)
+	}
+
+	// Fake the sysfs entries of a physical function and two virtual functions, one with a
+	// driver that implements native XDP and one without.
+	dir := t.TempDir()
+	defer func(orig string) { sysClassNetDir = orig }(sysClassNetDir)
+	sysClassNetDir = dir
+	addDevice := func(ifName, driver string, vf bool) {
+		device := dir + "/" + ifName + "/device"
+		Expect(os.MkdirAll(device, 0o755)).To(Succeed())
+		Expect(os.Symlink("../../../bus/pci/drivers/"+driver, device+"/driver")).To(Succeed())
+		if vf {
+			Expect(os.Symlink("../0000:3b:00.0", device+"/physfn")).To(Succeed())
+		}
+	}
+	addDevice("pf0", "iavf", false)
+	addDevice("vf0", "mlx5_core", true)
+	addDevice("vf1", "iavf", true)
+
+	Expect(IsSRIOVVirtualFunction("pf0")).To(BeFalse())
+	Expect(IsSRIOVVirtualFunction("vf0")).To(BeTrue())
+	Expect(IsSRIOVVirtualFunction("veth0")).To(BeFalse())
+	Expect(InterfaceDriver("vf1")).To(Equal("iavf"))
+	Expect(InterfaceDriver("veth0")).To(Equal(""))
+
+	for _, mode := range []XDPMode{XDPOffload, XDPDriver, XDPGeneric} {
+		Expect(SupportsXDPOnInterface("pf0", mode)).To(Succeed(), "physical function, mode %v", mode)
+		Expect(SupportsXDPOnInterface("veth0", mode)).To(Succeed(), "virtual interface, mode %v", mode)
+	}
+	Expect(SupportsXDPOnInterface("vf0", XDPOffload)).NotTo(Succeed())
+	Expect(SupportsXDPOnInterface("vf0", XDPDriver)).To(Succeed())
+	Expect(SupportsXDPOnInterface("vf0", XDPGeneric)).To(Succeed())
+	Expect(SupportsXDPOnInterface("vf1", XDPOffload)).NotTo(Succeed())
+	Expect(SupportsXDPOnInterface("vf1", XDPDriver)).To(MatchError(ContainSubstring(`"iavf"`)))
+	Expect(SupportsXDPOnInterface("vf1", XDPGeneric)).To(Succeed())
+}
+
+func TestInterfaceSpeed(t *testing.T) {
+	RegisterTestingT(t)
+
+	// Fake the sysfs speed entries of a fast link, a link that is down, which reports -1, and
+	// a virtual interface, whose speed can't be read at all.
+	dir := t.TempDir()
+	defer func(orig string) { sysClassNetDir = orig }(sysClassNetDir)
+	sysClassNetDir = dir
+	addSpeed := func(ifName, speed string) {
+		Expect(os.MkdirAll(dir+"/"+ifName, 0o755)).To(Succeed())
+		Expect(os.WriteFile(dir+"/"+ifName+"/speed", []byte(speed), 0o644)).To(Succeed())
+	}
+	addSpeed("eth0", "25000\n")
+	addSpeed("eth1", "-1\n")
+	Expect(os.MkdirAll(dir+"/lo", 0o755)).To(Succeed())
+
+	Expect(InterfaceSpeed("eth0")).To(Equal(25000))
+	_, err := InterfaceSpeed("eth1")
+	Expect(err).To(HaveOccurred())
+	_, err = InterfaceSpeed("lo")
+	Expect(err).To(HaveOccurred())
+}
+
+func TestBlocklistEntryReason(t *testing.T) {
+	RegisterTestingT(t)
+
+	runner := &fakeBPFMapRunner{entries: map[string]string{
+		"20 00 00 00 0a 41 00 02": strings.Join(blocklistReasonToHex("threat-feed-x"), " "),
+		"18 00 00 00 0a 42 00 00": strings.Join(blocklistReasonToHex("abuse-list"), " "),
+	}}
+
+	reason, err := BlocklistEntryReason(runner, "10.65.0.2/32")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(reason).To(Equal("threat-feed-x"))
+	Expect(runner.commands).To(ConsistOf(
+		"bpftool --json map lookup pinned /sys/fs/bpf/" + bpfCalicoSubdir + "/xdp/calico_reasons key hex 20 00 00 00 0a 41 00 02"))
+
+	t.Log("A plain IP should be looked up as a /32")
+	reason, err = BlocklistEntryReason(runner, "10.65.0.2")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(reason).To(Equal("threat-feed-x"))
+
+	reason, err = BlocklistEntryReason(runner, "10.66.0.0/24")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(reason).To(Equal("abuse-list"))
+
+	_, err = BlocklistEntryReason(runner, "10.67.0.1/32")
+	Expect(err).To(HaveOccurred())
+
+	t.Log("Reasons should be padded to the size of the map's values")
+	Expect(blocklistReasonToHex("x")).To(HaveLen(BlocklistReasonMaxLen))
+	_, err = hexToBlocklistReason([]string{"0x78"})
+	Expect(err).To(HaveOccurred())
+}
+
+func TestParseBlocklistReasonsDump(t *testing.T) {
+	RegisterTestingT(t)
+
+	runner := &fakeBPFMapRunner{entries: map[string]string{
+		"20 00 00 00 0a 41 00 02": strings.Join(blocklistReasonToHex("threat-feed-x"), " "),
+		"18 00 00 00 0a 42 00 00": strings.Join(blocklistReasonToHex("abuse-list"), " "),
+	}}
+	out, err := runner.ExecOutput("bpftool", "--json", "map", "dump", "pinned", "calico_reasons")
+	Expect(err).NotTo(HaveOccurred())
+
+	reasons, err := parseBlocklistReasonsDump([]byte(out))
+	Expect(err).NotTo(HaveOccurred())
+	_, cidr1, _ := net.ParseCIDR("10.65.0.2/32")
+	_, cidr2, _ := net.ParseCIDR("10.66.0.0/24")
+	Expect(reasons).To(Equal(map[CIDRMapKey]string{
+		NewCIDRMapKey(cidr1): "threat-feed-x",
+		NewCIDRMapKey(cidr2): "abuse-list",
+	}))
+}
+
+func TestXDPLookupPacket(t *testing.T) {
+	RegisterTestingT(t)
+
+	pkt, err := XDPLookupPacket(net.ParseIP("10.65.0.2"), net.ParseIP("10.65.1.3"))
+	Expect(err).NotTo(HaveOccurred())
+	p := gopacket.NewPacket(pkt, layers.LayerTypeEthernet, gopacket.Default)
+	Expect(p.ErrorLayer()).To(BeNil())
+	ip := p.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+	Expect(ip.SrcIP.String()).To(Equal("10.65.0.2"))
+	Expect(ip.DstIP.String()).To(Equal("10.65.1.3"))
+	Expect(p.Layer(layers.LayerTypeUDP)).NotTo(BeNil())
+
+	_, err = XDPLookupPacket(net.ParseIP("fd00::2"), net.ParseIP("10.65.1.3"))
+	Expect(err).To(HaveOccurred())
+}
+
+func TestXDPTestLookup(t *testing.T) {
+	RegisterTestingT(t)
+
+	runner := &fakeBPFMapRunner{verdicts: map[string]string{
+		"10.65.0.2": "DROP\n",
+		"10.65.0.3": "PASS\n",
+		"10.65.0.4": "XDPVerdict(3)\n",
+	}}
+	v, err := XDPTestLookup(runner, "eth0", "10.65.0.2")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(v).To(Equal(XDPVerdictDrop))
+	Expect(runner.commands).To(ConsistOf("calico-bpf xdp test-lookup eth0 10.65.0.2"))
+
+	v, err = XDPTestLookup(runner, "eth0", "10.65.0.3")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(v).To(Equal(XDPVerdictPass))
+
+	_, err = XDPTestLookup(runner, "eth0", "10.65.0.4")
+	Expect(err).To(HaveOccurred())
+}
+
+// fakeXDPReloadRunner emulates the commands that AssertProgramReusesPinnedMaps runs; the test's
+// reload function changes the program and maps that it reports.
+type fakeXDPReloadRunner struct {
+	progID   int
+	progMaps []int
+	// pins maps the ID of each pinned map to the path it's pinned at.
+	pins map[int]string
+}
+
+func (r *fakeXDPReloadRunner) ExecOutput(args ...string) (string, error) {
+	switch {
+	case args[0] == "ip":
+		return fmt.Sprintf(`2: eth0@if5: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 xdp qdisc noqueue state UP `+
+			`\    prog/xdp id %d tag 1234`, r.progID), nil
+	case args[2] == "prog":
+		var ids []string
+		for _, id := range r.progMaps {
+			ids = append(ids, strconv.Itoa(id))
+		}
+		return fmt.Sprintf(`{"id":%d,"type":"xdp","map_ids":[%s]}`, r.progID, strings.Join(ids, ",")), nil
+	case args[3] == "map":
+		var maps []string
+		for id, pin := range r.pins {
+			maps = append(maps, fmt.Sprintf(`{"id":%d,"name":"eth0_ipv4_v1_bl","pinned":[%q]}`, id, pin))
+		}
+		return "[" + strings.Join(maps, ",") + "]", nil
+	}
+	return "", fmt.Errorf("unexpected command %v", args)
+}
+
+func TestAssertProgramReusesPinnedMaps(t *testing.T) {
+	RegisterTestingT(t)
+
+	const pin = "/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist"
+	runner := &fakeXDPReloadRunner{progID: 7, progMaps: []int{3, 4}, pins: map[int]string{3: pin}}
+
+	Expect(AssertProgramReusesPinnedMaps(runner, "eth0", func() error {
+		runner.progID = 8
+		return nil
+	})).To(Succeed())
+
+	err := AssertProgramReusesPinnedMaps(runner, "eth0", func() error {
+		runner.progID = 9
+		runner.progMaps = []int{5, 4}
+		runner.pins = map[int]string{5: pin}
+		return nil
+	})
+	Expect(err).To(MatchError(ContainSubstring("map pinned at " + pin + " was replaced")))
+
+	err = AssertProgramReusesPinnedMaps(runner, "eth0", func() error {
+		runner.progID = 10
+		runner.progMaps = []int{6}
+		return nil
+	})
+	Expect(err).To(MatchError(ContainSubstring("doesn't use the map pinned at " + pin + " (map 5)")))
+
+	runner.pins = map[int]string{}
+	err = AssertProgramReusesPinnedMaps(runner, "eth0", func() error { return nil })
+	Expect(err).To(MatchError(ContainSubstring("doesn't use any pinned maps")))
+}
@@ -15,11 +15,15 @@
 package bpf
 
 import (
+	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/bpf/bpfdefs"
 )
 
 func CleanUpCalicoPins(dir string) {
@@ -41,3 +45,33 @@ func CleanUpCalicoPins(dir string) {
 		log.WithError(err).Warn("Failed to remove pinned BPF progs/maps. Ignoring.")
 	}
 }
+
+// XDPInterfacePins returns the paths of the pinned BPF objects that the given Felix has for the
+// XDP programs of particular interfaces: the programs, blocklist maps and destination maps that
+// the iptables dataplane pins in calico/xdp, and the directories of jump maps that the BPF
+// dataplane pins in tc/<iface>_xdp.  Felix should remove them when it removes the XDP program
+// from an interface.
+func XDPInterfacePins(felix CommandRunner) ([]string, error) {
+	out, err := felix.ExecOutput("find", bpfdefs.DefaultBPFfsPath, "-mindepth", "1", "-maxdepth", "3")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pinned BPF objects: %w\n%s", err, out)
+	}
+	xdpDir := path.Join(bpfdefs.DefaultBPFfsPath, bpfCalicoSubdir, "xdp")
+	tcDir := path.Join(bpfdefs.DefaultBPFfsPath, "tc")
+	var pins []string
+	for _, p := range strings.Fields(out) {
+		dir, name := path.Split(p)
+		switch path.Clean(dir) {
+		case xdpDir:
+			if strings.HasPrefix(name, "prefilter_") || strings.Contains(name, "_blacklist") ||
+				strings.Contains(name, "_dstlist") {
+				pins = append(pins, p)
+			}
+		case tcDir:
+			if strings.HasSuffix(name, "_xdp") {
+				pins = append(pins, p)
+			}
+		}
+	}
+	return pins, nil
+}
@@ -18,6 +18,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
 	"github.com/projectcalico/calico/felix/timeshim/mocktime"
@@ -300,3 +301,42 @@ var _ = Describe("BPF Conntrack upgrade entries", func() {
 		),
 	)
 })
+
+type bpftoolRunner struct {
+	output string
+	args   []string
+}
+
+func (r *bpftoolRunner) ExecOutput(args ...string) (string, error) {
+	r.args = args
+	return r.output, nil
+}
+
+func bpftoolHex(b []byte) string {
+	s := make([]string, len(b))
+	for i, x := range b {
+		s[i] = fmt.Sprintf("%q", fmt.Sprintf("0x%02x", x))
+	}
+	return "[" + strings.Join(s, ",") + "]"
+}
+
+var _ = Describe("BPF Conntrack Dump", func() {
+	It("should decode the entries in bpftool's output", func() {
+		tcpVal := makeValue(now-1, now-1, conntrack.Leg{SynSeen: true, AckSeen: true}, conntrack.Leg{SynSeen: true, AckSeen: true})
+		udpVal := makeValue(now-2, now-1, conntrack.Leg{}, conntrack.Leg{})
+		runner := &bpftoolRunner{
+			output: fmt.Sprintf(`[{"key":%s,"value":%s},{"key":%s,"value":%s}]`,
+				bpftoolHex(tcpKey.AsBytes()), bpftoolHex(tcpVal.AsBytes()),
+				bpftoolHex(udpKey.AsBytes()), bpftoolHex(udpVal.AsBytes())),
+		}
+
+		entries, err := conntrack.Dump(runner)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runner.args).To(ContainElement(conntrack.Map().(*maps.PinnedMap).VersionedFilename()))
+		Expect(entries).To(Equal([]conntrack.Entry{
+			{Key: tcpKey, Value: tcpVal},
+			{Key: udpKey, Value: udpVal},
+		}))
+		Expect(entries[0].Value.Data().Established()).To(BeTrue())
+	})
+})
@@ -0,0 +1,58 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conntrack
+
+import (
+	"fmt"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/bpf/maps"
+)
+
+// Entry is a decoded entry of the BPF conntrack map.
+type Entry struct {
+	Key   Key
+	Value Value
+}
+
+func (e Entry) String() string {
+	return fmt.Sprintf("%s -> %s", e.Key, e.Value)
+}
+
+// Dump reads all the entries of the BPF conntrack map using bpftool, run via the
+// given runner; for example, in a Felix container.  In BPF mode, this is the
+// equivalent of conntrack -L.
+func Dump(runner bpf.CommandRunner) ([]Entry, error) {
+	cmd, err := maps.DumpMapCmd(Map())
+	if err != nil {
+		return nil, err
+	}
+	out, err := runner.ExecOutput(cmd...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump the conntrack map: %w: %s", err, out)
+	}
+
+	var entries []Entry
+	err = bpf.IterMapCmdOutput([]byte(out), func(k, v []byte) {
+		entries = append(entries, Entry{
+			Key:   KeyFromBytes(k),
+			Value: ValueFromBytes(v),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
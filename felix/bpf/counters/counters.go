@@ -27,7 +27,7 @@ import (
 )
 
 const (
-	MaxCounterNumber    int = 14
+	MaxCounterNumber    int = 16
 	counterMapKeySize   int = 8
 	counterMapValueSize int = 8
 )
@@ -72,6 +72,8 @@ const (
 	DroppedFailedDecap
 	DroppedUnauthSource
 	DroppedUnknownRoute
+	NotMatchedByXDP
+	XDPPassed
 )
 
 type Description struct {
@@ -150,6 +152,14 @@ var descriptions DescList = DescList{
 		Counter:  DroppedUnknownRoute,
 		Category: "Dropped", Caption: "packets with unknown route",
 	},
+	{
+		Counter:  NotMatchedByXDP,
+		Category: "Passed", Caption: "by XDP, no policy match",
+	},
+	{
+		Counter:  XDPPassed,
+		Category: "XDP verdict", Caption: "XDP_PASS",
+	},
 }
 
 func Descriptions() DescList {
@@ -174,6 +184,51 @@ func Read(m maps.Map, ifindex int, hook bpf.Hook) ([]uint64, error) {
 	return bpfCounters, nil
 }
 
+// XDPVerdicts breaks down the packets that the XDP program on an interface let through by the
+// reason that it let them through, and counts some of the reasons that it dropped packets.
+type XDPVerdicts struct {
+	// Pass counts packets that were explicitly allowed by untracked policy.
+	Pass uint64
+	// Failsafe counts packets that hit a failsafe port and so skipped policy.
+	Failsafe uint64
+	// NotMatched counts packets that didn't match any untracked policy, or that arrived
+	// before the policy was programmed; they are left to the normal policy in TC.
+	NotMatched uint64
+
+	// StackPassed counts packets that the program handed to the kernel stack with XDP_PASS,
+	// whatever the reason.
+	StackPassed uint64
+
+	// DroppedByPolicy counts packets that untracked policy, such as a blocklist, dropped;
+	// DroppedShort counts packets that were too short to hold the headers that the program
+	// needs, which it drops whatever their source.
+	DroppedByPolicy uint64
+	DroppedShort    uint64
+}
+
+// XDPVerdictsFromCounters extracts the XDP verdicts from the counters that Read returns for
+// the XDP hook.
+func XDPVerdictsFromCounters(values []uint64) XDPVerdicts {
+	return XDPVerdicts{
+		Pass:        values[AcceptedByPolicy],
+		Failsafe:    values[AcceptedByFailsafe],
+		NotMatched:  values[NotMatchedByXDP],
+		StackPassed: values[XDPPassed],
+
+		DroppedByPolicy: values[DroppedByPolicy],
+		DroppedShort:    values[DroppedShortPacket],
+	}
+}
+
+// ReadXDPVerdicts reads the XDP verdict counters for the given interface.
+func ReadXDPVerdicts(m maps.Map, ifindex int) (XDPVerdicts, error) {
+	values, err := Read(m, ifindex, bpf.HookXDP)
+	if err != nil {
+		return XDPVerdicts{}, err
+	}
+	return XDPVerdictsFromCounters(values), nil
+}
+
 func Flush(m maps.Map, ifindex int, hook bpf.Hook) error {
 	if err := m.(maps.MapWithUpdateWithFlags).
 		UpdateWithFlags(NewKey(ifindex, hook).AsBytes(), zeroVal, unix.BPF_EXIST); err != nil {
@@ -29,3 +29,26 @@ func TestCounterMapSize(t *testing.T) {
 	noOfDescriptions := len(Descriptions())
 	Expect(MaxCounterNumber).Should(Equal(noOfDescriptions + noOfDescriptions%2))
 }
+
+func TestXDPVerdictsFromCounters(t *testing.T) {
+	RegisterTestingT(t)
+
+	values := make([]uint64, MaxCounterNumber)
+	values[TotalPackets] = 10
+	values[AcceptedByPolicy] = 1
+	values[AcceptedByFailsafe] = 2
+	values[NotMatchedByXDP] = 3
+	values[DroppedByPolicy] = 4
+	values[DroppedShortPacket] = 5
+	values[XDPPassed] = 6
+
+	Expect(XDPVerdictsFromCounters(values)).To(Equal(XDPVerdicts{
+		Pass:        1,
+		Failsafe:    2,
+		NotMatched:  3,
+		StackPassed: 6,
+
+		DroppedByPolicy: 4,
+		DroppedShort:    5,
+	}))
+}
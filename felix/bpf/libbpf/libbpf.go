@@ -396,7 +396,7 @@ func XDPSetGlobals(
 	cName := C.CString(globalData.IfaceName)
 	defer C.free(unsafe.Pointer(cName))
 
-	_, err := C.bpf_xdp_set_globals(m.bpfMap, cName)
+	_, err := C.bpf_xdp_set_globals(m.bpfMap, cName, C.uint(globalData.LogLevel))
 
 	return err
 }
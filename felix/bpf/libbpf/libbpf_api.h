@@ -235,9 +235,10 @@ void bpf_ctlb_set_globals(struct bpf_map *map, uint udp_not_seen_timeo, bool exc
 	set_errno(bpf_map__set_initial_value(map, (void*)(&data), sizeof(data)));
 }
 
-void bpf_xdp_set_globals(struct bpf_map *map, char *iface_name)
+void bpf_xdp_set_globals(struct bpf_map *map, char *iface_name, uint log_level)
 {
 	struct cali_xdp_globals data = {
+		.log_level = log_level,
 	};
 
 	strncpy(data.iface_name, iface_name, sizeof(data.iface_name));
@@ -32,4 +32,5 @@ type TcGlobalData struct {
 
 type XDPGlobalData struct {
 	IfaceName string
+	LogLevel  uint32
 }
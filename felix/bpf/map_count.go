@@ -0,0 +1,90 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpf
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// bpfObjNameLen is the number of characters of a map's name that the kernel keeps.
+const bpfObjNameLen = 15
+
+type pinnedMapInfo struct {
+	mapInfo
+	Pinned []string `json:"pinned"`
+}
+
+// CountCalicoMaps returns the number of distinct BPF maps, with names that start with prefix,
+// that the given Felix holds: the maps pinned in its BPF filesystem and the maps of the XDP
+// programs attached to its interfaces.  Maps are counted by ID, so a map that Felix replaced
+// with a new one instead of reusing it counts twice for as long as a pin or a program still
+// refers to it.  The kernel keeps only the first 15 characters of a map's name, so the prefix
+// is cut to that length; for example, "eth0_" matches the per-interface blocklist maps of eth0
+// and "calico_" matches the maps that all the interfaces share.
+//
+// The maps belong to the kernel rather than to the container, so the maps of other Felixes on
+// the same kernel only count if this Felix's programs use them.
+func CountCalicoMaps(felix CommandRunner, prefix string) (int, error) {
+	if len(prefix) > bpfObjNameLen {
+		prefix = prefix[:bpfObjNameLen]
+	}
+
+	// --bpffs fills in the paths that each map is pinned at in this container's BPF
+	// filesystem.
+	out, err := felix.ExecOutput("bpftool", "--json", "--bpffs", "map", "list")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list maps: %w\n%s", err, out)
+	}
+	var maps []pinnedMapInfo
+	if err := json.Unmarshal([]byte(out), &maps); err != nil {
+		return 0, fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+	}
+	names := map[int]string{}
+	held := map[int]bool{}
+	for _, m := range maps {
+		names[m.Id] = m.Name
+		if len(m.Pinned) > 0 {
+			held[m.Id] = true
+		}
+	}
+
+	out, err = felix.ExecOutput("ip", "-o", "link", "show")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list interfaces: %w\n%s", err, out)
+	}
+	for _, m := range attachedXDPProgIDRegexp.FindAllStringSubmatch(out, -1) {
+		out, err := felix.ExecOutput("bpftool", "--json", "prog", "show", "id", m[1])
+		if err != nil {
+			return 0, fmt.Errorf("failed to show XDP program %s: %w\n%s", m[1], err, out)
+		}
+		prog := ProgInfo{}
+		if err := json.Unmarshal([]byte(out), &prog); err != nil {
+			return 0, fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+		}
+		for _, id := range prog.MapIds {
+			held[id] = true
+		}
+	}
+
+	count := 0
+	for id := range held {
+		if strings.HasPrefix(names[id], prefix) {
+			count++
+		}
+	}
+	return count, nil
+}
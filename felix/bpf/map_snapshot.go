@@ -0,0 +1,196 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// Snapshot holds the entries of a BPF map, as read by SnapshotMap.  It maps the raw bytes
+// of each key, as a string, to the raw bytes of its value.
+type Snapshot map[string][]byte
+
+// SnapshotMap reads all the entries of the BPF map pinned at the given path in the given
+// Felix.  Per-CPU maps aren't supported.
+func SnapshotMap(felix CommandRunner, path string) (Snapshot, error) {
+	out, err := felix.ExecOutput("bpftool", "--json", "map", "dump", "pinned", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump map (%s): %w\n%s", path, err, out)
+	}
+	return parseMapDump(out)
+}
+
+// MapType returns the type of the BPF map pinned at the given path in the given Felix, as
+// bpftool names it; for example, "hash" or "lpm_trie".
+func MapType(felix CommandRunner, path string) (string, error) {
+	out, err := felix.ExecOutput("bpftool", "--json", "map", "show", "pinned", path)
+	if err != nil {
+		return "", fmt.Errorf("failed to show map (%s): %w\n%s", path, err, out)
+	}
+	m := mapInfo{}
+	if err := json.Unmarshal([]byte(out), &m); err != nil {
+		return "", fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+	}
+	if m.Err != "" {
+		return "", fmt.Errorf("%s", m.Err)
+	}
+	return m.Type, nil
+}
+
+// RestoreMap makes the BPF map pinned at the given path in the given Felix hold exactly the
+// entries of the snapshot: entries that aren't in the snapshot are deleted and entries that
+// are missing or have a different value are written.  Entries that already match aren't
+// touched, so restoring an unchanged map is a no-op.
+func RestoreMap(felix CommandRunner, path string, snapshot Snapshot) error {
+	current, err := SnapshotMap(felix, path)
+	if err != nil {
+		return err
+	}
+
+	for k := range current {
+		if _, ok := snapshot[k]; ok {
+			continue
+		}
+		args := append([]string{"bpftool", "map", "delete", "pinned", path, "key", "hex"}, bytesToHex([]byte(k))...)
+		if out, err := felix.ExecOutput(args...); err != nil {
+			return fmt.Errorf("failed to delete map entry (%s): %w\n%s", path, err, out)
+		}
+	}
+
+	for k, v := range snapshot {
+		if cur, ok := current[k]; ok && bytes.Equal(cur, v) {
+			continue
+		}
+		args := append([]string{"bpftool", "map", "update", "pinned", path, "key", "hex"}, bytesToHex([]byte(k))...)
+		args = append(append(args, "value", "hex"), bytesToHex(v)...)
+		if out, err := felix.ExecOutput(args...); err != nil {
+			return fmt.Errorf("failed to update map entry (%s): %w\n%s", path, err, out)
+		}
+	}
+	return nil
+}
+
+// parseMapDump parses the output of "bpftool --json map dump".
+func parseMapDump(out string) (Snapshot, error) {
+	var entries []mapEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		return nil, fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+	}
+
+	snapshot := make(Snapshot, len(entries))
+	for _, e := range entries {
+		if e.Err != "" {
+			return nil, fmt.Errorf("%s", e.Err)
+		}
+		k, err := hexStringsToBytes(e.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bpf map key (%v): %w", e.Key, err)
+		}
+		v, err := hexStringsToBytes(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bpf map value (%v): %w", e.Value, err)
+		}
+		snapshot[string(k)] = v
+	}
+	return snapshot, nil
+}
+
+// bytesToHex returns the bytes in the form that bpftool takes after "hex".
+func bytesToHex(b []byte) []string {
+	hex := make([]string, len(b))
+	for i, x := range b {
+		hex[i] = fmt.Sprintf("%02x", x)
+	}
+	return hex
+}
+
+// CIDRs returns the CIDRs that are the keys of a snapshot of an XDP blocklist map, sorted.
+func (s Snapshot) CIDRs() ([]string, error) {
+	cidrs := make([]string, 0, len(s))
+	for k := range s {
+		if len(k) != 8 {
+			return nil, fmt.Errorf("unexpected CIDR map key length %d", len(k))
+		}
+		prefixLen := int(binary.LittleEndian.Uint32([]byte(k[:4])))
+		ipNet := net.IPNet{IP: net.IP(k[4:]), Mask: net.CIDRMask(prefixLen, 32)}
+		cidrs = append(cidrs, ipNet.String())
+	}
+	sort.Strings(cidrs)
+	return cidrs, nil
+}
+
+// VerifyAllProgrammed checks that the XDP blocklist map pinned at the given path in the given
+// Felix holds exactly the expected CIDRs; an address without a prefix length is a /32.  The
+// error says how many CIDRs are missing and how many are unexpected, with the first few of
+// each, so that it shows whether a large set was truncated.
+func VerifyAllProgrammed(felix CommandRunner, path string, expected []string) error {
+	snapshot, err := SnapshotMap(felix, path)
+	if err != nil {
+		return err
+	}
+	cidrs, err := snapshot.CIDRs()
+	if err != nil {
+		return err
+	}
+	actual := make(map[string]bool, len(cidrs))
+	for _, cidr := range cidrs {
+		actual[cidr] = true
+	}
+
+	var missing []string
+	wanted := make(map[string]bool, len(expected))
+	for _, e := range expected {
+		if !strings.Contains(e, "/") {
+			e += "/32"
+		}
+		_, ipNet, err := net.ParseCIDR(e)
+		if err != nil {
+			return fmt.Errorf("bad expected CIDR %q: %w", e, err)
+		}
+		cidr := ipNet.String()
+		wanted[cidr] = true
+		if !actual[cidr] {
+			missing = append(missing, cidr)
+		}
+	}
+	var unexpected []string
+	for _, cidr := range cidrs {
+		if !wanted[cidr] {
+			unexpected = append(unexpected, cidr)
+		}
+	}
+	if len(missing) == 0 && len(unexpected) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("map %s has %d of %d expected CIDRs; missing %d (%s), unexpected %d (%s)",
+		path, len(wanted)-len(missing), len(wanted),
+		len(missing), firstFew(missing), len(unexpected), firstFew(unexpected))
+}
+
+// firstFew returns the first few of the given strings, for an error message.
+func firstFew(s []string) string {
+	const few = 5
+	if len(s) > few {
+		return strings.Join(s[:few], ", ") + ", ..."
+	}
+	return strings.Join(s, ", ")
+}
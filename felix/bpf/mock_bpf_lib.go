@@ -97,12 +97,35 @@ type MockBPFLib struct {
 	binDir              string
 	XDPProgs            map[string]XDPInfo      // iface -> []maps
 	CIDRMaps            map[CIDRMapsKey]CIDRMap // iface -> map[ip]refCount
+	DstCIDRMaps         map[CIDRMapsKey]CIDRMap // iface -> set of destinations
 	SockopsProg         *SockopsInfo
 	SockMap             *SockMap
 	SkMsgProg           *SkMsgInfo
 	SockmapEndpointsMap *CIDRMap
 	FailsafeMap         FailsafeMap
 	CgroupV2Dir         string
+	// UnsupportedXDPModes simulates a driver that rejects XDP programs in these modes.
+	UnsupportedXDPModes map[XDPMode]bool
+	// TCEgressIfaces is the set of interfaces with the TC egress program attached.
+	TCEgressIfaces map[string]bool
+	// UnsupportedIfaceXDPModes simulates interfaces, such as SR-IOV virtual functions, that
+	// SupportsXDPOnInterface rules out some modes for.
+	UnsupportedIfaceXDPModes map[string]map[XDPMode]bool
+	// InterfaceSpeeds holds the link speed, in Mbit/s, that InterfaceSpeed reports for each
+	// interface; other interfaces don't report a speed.
+	InterfaceSpeeds map[string]int
+	// AttemptedXDPModes records, per interface, the modes that Felix tried to load an XDP
+	// program in.
+	AttemptedXDPModes map[string][]XDPMode
+	// BlocklistReasons holds the reason for each CIDR in the blocklist reasons map, or is nil
+	// if the map doesn't exist.
+	BlocklistReasons map[IPv4Mask]string
+	// XDPAllowList holds the CIDRs in the host's XDP allow-list, or is nil if the allow-list
+	// doesn't exist.
+	XDPAllowList map[IPv4Mask]struct{}
+	// XDPGraceList holds the CIDRs in the host's XDP grace list, or is nil if the grace list
+	// doesn't exist.
+	XDPGraceList map[IPv4Mask]struct{}
 }
 
 func NewMockBPFLib(binDir string) *MockBPFLib {
@@ -110,6 +133,7 @@ func NewMockBPFLib(binDir string) *MockBPFLib {
 		binDir:      binDir,
 		XDPProgs:    make(map[string]XDPInfo),
 		CIDRMaps:    make(map[CIDRMapsKey]CIDRMap),
+		DstCIDRMaps: make(map[CIDRMapsKey]CIDRMap),
 		CgroupV2Dir: "/sys/fs/cgroup/unified",
 	}
 }
@@ -129,7 +153,8 @@ func (b *MockBPFLib) NewCIDRMap(ifName string, family IPFamily) (string, error)
 	}
 
 	b.CIDRMaps[key] = NewMockCIDRMap(id)
-
+	id += 1
+	b.DstCIDRMaps[key] = NewMockCIDRMap(id)
 	id += 1
 
 	return fmt.Sprintf("/sys/fs/bpf/calico/xdp/%s_ipv4_v1_blacklist", ifName), nil
@@ -168,6 +193,24 @@ func (b *MockBPFLib) DumpCIDRMap(ifName string, family IPFamily) (map[CIDRMapKey
 	return ret, nil
 }
 
+func (b *MockBPFLib) DumpDstCIDRMap(ifName string, family IPFamily) (map[CIDRMapKey]uint32, error) {
+	m, ok := b.DstCIDRMaps[CIDRMapsKey{IfName: ifName, Family: family}]
+	if !ok {
+		return nil, fmt.Errorf("destination map %q not found", ifName)
+	}
+
+	ret := make(map[CIDRMapKey]uint32)
+	for k, v := range m.M {
+		ipnet := net.IPNet{
+			IP:   net.IPv4(k.Ip[0], k.Ip[1], k.Ip[2], k.Ip[3]),
+			Mask: net.CIDRMask(k.Mask, 32),
+		}
+		ret[NewCIDRMapKey(&ipnet)] = v
+	}
+
+	return ret, nil
+}
+
 func (b *MockBPFLib) DumpFailsafeMap() ([]ProtoPort, error) {
 	var ret []ProtoPort
 
@@ -310,10 +353,28 @@ func (b *MockBPFLib) LoadXDP(objPath, ifName string, mode XDPMode) error {
 	}
 
 	mapArgs = append(mapArgs, strconv.Itoa(cmap.Info.Id))
+	if dmap, ok := b.DstCIDRMaps[key]; ok {
+		mapArgs = append(mapArgs, strconv.Itoa(dmap.Info.Id))
+	}
 
 	return b.loadXDPRaw(objPath, ifName, mode, mapArgs)
 }
 
+func (b *MockBPFLib) SupportsXDPOnInterface(ifName string, mode XDPMode) error {
+	if b.UnsupportedIfaceXDPModes[ifName][mode] {
+		return fmt.Errorf("XDP mode %v not supported on %s", mode, ifName)
+	}
+	return nil
+}
+
+func (b *MockBPFLib) InterfaceSpeed(ifName string) (int, error) {
+	speed, ok := b.InterfaceSpeeds[ifName]
+	if !ok {
+		return 0, fmt.Errorf("%s doesn't know its link speed", ifName)
+	}
+	return speed, nil
+}
+
 func (b *MockBPFLib) LoadXDPAuto(ifName string, mode XDPMode) error {
 	return b.LoadXDP(xdpFilename, ifName, mode)
 }
@@ -369,6 +430,7 @@ func (b *MockBPFLib) RemoveCIDRMap(ifName string, family IPFamily) error {
 	}
 
 	delete(b.CIDRMaps, CIDRMapsKey{ifName, family})
+	delete(b.DstCIDRMaps, CIDRMapsKey{ifName, family})
 	return nil
 }
 
@@ -407,6 +469,25 @@ func (b *MockBPFLib) RemoveItemCIDRMap(ifName string, family IPFamily, ip net.IP
 	return nil
 }
 
+func (b *MockBPFLib) RemoveItemDstCIDRMap(ifName string, family IPFamily, ip net.IP, mask int) error {
+	m, ok := b.DstCIDRMaps[CIDRMapsKey{IfName: ifName, Family: family}]
+	if !ok {
+		return fmt.Errorf("destination map %q not found", ifName)
+	}
+
+	l := len(ip)
+	ipm := IPv4Mask{
+		Ip:   [4]byte{ip[l-4], ip[l-3], ip[l-2], ip[l-1]},
+		Mask: mask,
+	}
+	if _, ok := m.M[ipm]; !ok {
+		return errors.New("CIDR not found")
+	}
+
+	delete(m.M, ipm)
+	return nil
+}
+
 func (b *MockBPFLib) RemoveItemFailsafeMap(proto uint8, port uint16) error {
 	if b.FailsafeMap.M == nil {
 		return fmt.Errorf("failsafe map not found")
@@ -437,6 +518,35 @@ func (b *MockBPFLib) RemoveXDP(ifName string, mode XDPMode) error {
 	return nil
 }
 
+func (b *MockBPFLib) LoadTCEgress(objPath, ifName string) error {
+	key := CIDRMapsKey{
+		IfName: ifName,
+		Family: IPFamilyV4,
+	}
+	if _, ok := b.CIDRMaps[key]; !ok {
+		return fmt.Errorf("map %q needs to be loaded first", ifName)
+	}
+
+	if b.TCEgressIfaces == nil {
+		b.TCEgressIfaces = make(map[string]bool)
+	}
+	b.TCEgressIfaces[ifName] = true
+	return nil
+}
+
+func (b *MockBPFLib) LoadTCEgressAuto(ifName string) error {
+	return b.LoadTCEgress(tcEgressFilename, ifName)
+}
+
+func (b *MockBPFLib) RemoveTCEgress(ifName string) error {
+	delete(b.TCEgressIfaces, ifName)
+	return nil
+}
+
+func (b *MockBPFLib) HasTCEgress(ifName string) (bool, error) {
+	return b.TCEgressIfaces[ifName], nil
+}
+
 func (b *MockBPFLib) UpdateCIDRMap(ifName string, family IPFamily, ip net.IP, mask int, refCount uint32) error {
 	key := CIDRMapsKey{
 		IfName: ifName,
@@ -457,6 +567,21 @@ func (b *MockBPFLib) UpdateCIDRMap(ifName string, family IPFamily, ip net.IP, ma
 	return nil
 }
 
+func (b *MockBPFLib) UpdateDstCIDRMap(ifName string, family IPFamily, ip net.IP, mask int) error {
+	m, ok := b.DstCIDRMaps[CIDRMapsKey{IfName: ifName, Family: family}]
+	if !ok {
+		return fmt.Errorf("destination map %q not found", ifName)
+	}
+
+	l := len(ip)
+	ipm := IPv4Mask{
+		Ip:   [4]byte{ip[l-4], ip[l-3], ip[l-2], ip[l-1]},
+		Mask: mask,
+	}
+	m.M[ipm] = 1
+	return nil
+}
+
 func (b *MockBPFLib) UpdateFailsafeMap(proto uint8, port uint16) error {
 	if b.FailsafeMap.M == nil {
 		return fmt.Errorf("failsafe map not found")
@@ -472,7 +597,144 @@ func (b *MockBPFLib) UpdateFailsafeMap(proto uint8, port uint16) error {
 	return nil
 }
 
+func (b *MockBPFLib) UpdateBlocklistReasonsMap(ip net.IP, mask int, reason string) error {
+	ipv4 := ip.To4()
+	if ipv4 == nil {
+		return fmt.Errorf("IP %q is not IPv4", ip)
+	}
+	if b.BlocklistReasons == nil {
+		b.BlocklistReasons = make(map[IPv4Mask]string)
+	}
+	ipm := IPv4Mask{Mask: mask}
+	copy(ipm.Ip[:], ipv4)
+	if len(reason) > BlocklistReasonMaxLen {
+		reason = reason[:BlocklistReasonMaxLen]
+	}
+	b.BlocklistReasons[ipm] = reason
+	return nil
+}
+
+func (b *MockBPFLib) RemoveItemBlocklistReasonsMap(ip net.IP, mask int) error {
+	ipv4 := ip.To4()
+	if ipv4 == nil {
+		return fmt.Errorf("IP %q is not IPv4", ip)
+	}
+	ipm := IPv4Mask{Mask: mask}
+	copy(ipm.Ip[:], ipv4)
+	delete(b.BlocklistReasons, ipm)
+	return nil
+}
+
+func (b *MockBPFLib) DumpBlocklistReasonsMap() (map[CIDRMapKey]string, error) {
+	ret := make(map[CIDRMapKey]string)
+	for ipm, reason := range b.BlocklistReasons {
+		ipnet := &net.IPNet{
+			IP:   net.IPv4(ipm.Ip[0], ipm.Ip[1], ipm.Ip[2], ipm.Ip[3]),
+			Mask: net.CIDRMask(ipm.Mask, 32),
+		}
+		ret[NewCIDRMapKey(ipnet)] = reason
+	}
+	return ret, nil
+}
+
+func (b *MockBPFLib) RemoveBlocklistReasonsMap() error {
+	b.BlocklistReasons = nil
+	return nil
+}
+
+func (b *MockBPFLib) UpdateXDPAllowMap(ip net.IP, mask int) error {
+	ipv4 := ip.To4()
+	if ipv4 == nil {
+		return fmt.Errorf("IP %q is not IPv4", ip)
+	}
+	if b.XDPAllowList == nil {
+		b.XDPAllowList = make(map[IPv4Mask]struct{})
+	}
+	ipm := IPv4Mask{Mask: mask}
+	copy(ipm.Ip[:], ipv4)
+	b.XDPAllowList[ipm] = struct{}{}
+	return nil
+}
+
+func (b *MockBPFLib) RemoveItemXDPAllowMap(ip net.IP, mask int) error {
+	ipv4 := ip.To4()
+	if ipv4 == nil {
+		return fmt.Errorf("IP %q is not IPv4", ip)
+	}
+	ipm := IPv4Mask{Mask: mask}
+	copy(ipm.Ip[:], ipv4)
+	delete(b.XDPAllowList, ipm)
+	return nil
+}
+
+func (b *MockBPFLib) DumpXDPAllowMap() (map[CIDRMapKey]uint32, error) {
+	ret := make(map[CIDRMapKey]uint32)
+	for ipm := range b.XDPAllowList {
+		ipnet := &net.IPNet{
+			IP:   net.IPv4(ipm.Ip[0], ipm.Ip[1], ipm.Ip[2], ipm.Ip[3]),
+			Mask: net.CIDRMask(ipm.Mask, 32),
+		}
+		ret[NewCIDRMapKey(ipnet)] = 1
+	}
+	return ret, nil
+}
+
+func (b *MockBPFLib) RemoveXDPAllowMap() error {
+	b.XDPAllowList = nil
+	return nil
+}
+
+func (b *MockBPFLib) UpdateXDPGraceMap(ip net.IP, mask int) error {
+	ipv4 := ip.To4()
+	if ipv4 == nil {
+		return fmt.Errorf("IP %q is not IPv4", ip)
+	}
+	if b.XDPGraceList == nil {
+		b.XDPGraceList = make(map[IPv4Mask]struct{})
+	}
+	ipm := IPv4Mask{Mask: mask}
+	copy(ipm.Ip[:], ipv4)
+	b.XDPGraceList[ipm] = struct{}{}
+	return nil
+}
+
+func (b *MockBPFLib) RemoveItemXDPGraceMap(ip net.IP, mask int) error {
+	ipv4 := ip.To4()
+	if ipv4 == nil {
+		return fmt.Errorf("IP %q is not IPv4", ip)
+	}
+	ipm := IPv4Mask{Mask: mask}
+	copy(ipm.Ip[:], ipv4)
+	delete(b.XDPGraceList, ipm)
+	return nil
+}
+
+func (b *MockBPFLib) DumpXDPGraceMap() (map[CIDRMapKey]uint32, error) {
+	ret := make(map[CIDRMapKey]uint32)
+	for ipm := range b.XDPGraceList {
+		ipnet := &net.IPNet{
+			IP:   net.IPv4(ipm.Ip[0], ipm.Ip[1], ipm.Ip[2], ipm.Ip[3]),
+			Mask: net.CIDRMask(ipm.Mask, 32),
+		}
+		ret[NewCIDRMapKey(ipnet)] = 1
+	}
+	return ret, nil
+}
+
+func (b *MockBPFLib) RemoveXDPGraceMap() error {
+	b.XDPGraceList = nil
+	return nil
+}
+
 func (b *MockBPFLib) loadXDPRaw(objPath, ifName string, mode XDPMode, mapArgs []string) error {
+	if b.AttemptedXDPModes == nil {
+		b.AttemptedXDPModes = map[string][]XDPMode{}
+	}
+	b.AttemptedXDPModes[ifName] = append(b.AttemptedXDPModes[ifName], mode)
+	if b.UnsupportedXDPModes[mode] {
+		return fmt.Errorf("XDP mode %v not supported by %s", mode, ifName)
+	}
+
 	objPath = path.Join(b.binDir, objPath)
 
 	f, err := os.Open(objPath)
@@ -341,7 +341,17 @@ func (p *Builder) writeProgramFooter(forXDP bool) {
 	p.b.Exit()
 
 	if forXDP {
+		// No untracked policy matched.  Tail call the allowed program anyway so that it can
+		// count the packet as not matched; it lets the packet through to TC policy.
 		p.b.LabelNextInsn("xdp_pass")
+		p.b.MovImm32(R1, int32(state.PolicyNoMatch))
+		p.b.Store32(R9, R1, stateOffPolResult)
+		p.b.Mov64(R1, R6)                           // First arg is the context.
+		p.b.LoadMapFD(R2, uint32(p.jumpMapFD))      // Second arg is the map.
+		p.b.MovImm32(R3, p.indexOfAllowesProgram()) // Third arg is the index (rather than a pointer to the index).
+		p.b.Call(HelperTailCall)
+
+		// Fall through if tail call fails; the packet didn't match so it is still safe to pass it.
 		p.b.MovImm64(R0, 2 /* XDP_PASS */)
 		p.b.Exit()
 	}
@@ -0,0 +1,137 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+
+// Package ringbuf reads records from a BPF_MAP_TYPE_RINGBUF map.
+package ringbuf
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/projectcalico/calico/felix/bpf/maps"
+)
+
+const (
+	// Flags in the length field of each record's header; see the kernel's
+	// include/uapi/linux/bpf.h.
+	recordBusyBit    = 1 << 31
+	recordDiscardBit = 1 << 30
+
+	recordHeaderSize = 8
+)
+
+// Reader consumes the records from a ring buffer.  The kernel maps the ring
+// buffer's data area twice, back to back, so records that wrap around the end of
+// the buffer can be read as one contiguous slice.
+type Reader struct {
+	fd maps.FD
+
+	consumerPage []byte
+	producerArea []byte
+	data         []byte
+	mask         uint64
+}
+
+// OpenPinned opens the ring buffer that is pinned at the given path.
+func OpenPinned(pinPath string) (*Reader, error) {
+	fd, err := maps.GetMapFDByPin(pinPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ring buffer %s: %w", pinPath, err)
+	}
+	info, err := maps.GetMapInfo(fd)
+	if err != nil {
+		fd.Close()
+		return nil, fmt.Errorf("failed to get info for ring buffer %s: %w", pinPath, err)
+	}
+	if info.Type != unix.BPF_MAP_TYPE_RINGBUF {
+		fd.Close()
+		return nil, fmt.Errorf("map %s is not a ring buffer", pinPath)
+	}
+
+	r, err := newReader(fd, info.MaxEntries)
+	if err != nil {
+		fd.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func newReader(fd maps.FD, size int) (*Reader, error) {
+	pageSize := os.Getpagesize()
+
+	consumerPage, err := unix.Mmap(int(fd), 0, pageSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mmap ring buffer consumer page: %w", err)
+	}
+	producerArea, err := unix.Mmap(int(fd), int64(pageSize), pageSize+2*size, unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		_ = unix.Munmap(consumerPage)
+		return nil, fmt.Errorf("failed to mmap ring buffer data: %w", err)
+	}
+
+	return &Reader{
+		fd:           fd,
+		consumerPage: consumerPage,
+		producerArea: producerArea,
+		data:         producerArea[pageSize:],
+		mask:         uint64(size - 1),
+	}, nil
+}
+
+// Read returns the records that have been committed to the ring buffer since the
+// last call and marks them as consumed.  It doesn't block.
+func (r *Reader) Read() [][]byte {
+	consumerPos := (*uint64)(unsafe.Pointer(&r.consumerPage[0]))
+	producerPos := (*uint64)(unsafe.Pointer(&r.producerArea[0]))
+
+	var records [][]byte
+	cons := atomic.LoadUint64(consumerPos)
+	prod := atomic.LoadUint64(producerPos)
+	for cons < prod {
+		hdr := r.data[cons&r.mask:]
+		length := atomic.LoadUint32((*uint32)(unsafe.Pointer(&hdr[0])))
+		if length&recordBusyBit != 0 {
+			// The producer hasn't committed this record yet.
+			break
+		}
+		dataLen := uint64(length &^ (recordBusyBit | recordDiscardBit))
+		if length&recordDiscardBit == 0 {
+			record := make([]byte, dataLen)
+			copy(record, hdr[recordHeaderSize:recordHeaderSize+dataLen])
+			records = append(records, record)
+		}
+		// Records are padded to a multiple of 8 bytes.
+		cons += (recordHeaderSize + dataLen + 7) &^ 7
+		atomic.StoreUint64(consumerPos, cons)
+	}
+	return records
+}
+
+func (r *Reader) Close() error {
+	err1 := unix.Munmap(r.producerArea)
+	err2 := unix.Munmap(r.consumerPage)
+	err3 := r.fd.Close()
+	for _, err := range []error{err1, err2, err3} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -530,7 +530,7 @@ func objLoad(fname, bpfFsDir, ipFamily string, topts testOpts, polProg, hasHostC
 	for m, err := obj.FirstMap(); m != nil && err == nil; m, err = m.NextMap() {
 		if m.IsMapInternal() {
 			if forXDP {
-				if err := xdp.ConfigureProgram(m, bpfIfaceName); err != nil {
+				if err := xdp.ConfigureProgram(m, bpfIfaceName, "debug"); err != nil {
 					return nil, err
 				}
 			} else {
@@ -180,7 +180,6 @@ func TestLoadGarbageProgram(t *testing.T) {
 const (
 	RCAllowedReached = 123
 	RCDropReached    = 124
-	XDPPass          = 2
 )
 
 func packetWithPorts(proto int, src, dst string) packet {
@@ -2110,6 +2109,39 @@ var xdpPolProgramTests = []polProgramTest{
 			udpPkt("123.0.0.1:1024", "10.96.0.11:53"),
 		},
 	},
+	{
+		PolicyName: "XDP deny ICMP echo request from a source",
+		Policy: polprog.Rules{
+			ForXDP:           true,
+			ForHostInterface: true,
+			HostNormalTiers: []polprog.Tier{{
+				Name:      "default",
+				EndAction: "pass",
+				Policies: []polprog.Policy{{
+					Name: "p1",
+					Rules: []polprog.Rule{{
+						Rule: &proto.Rule{
+							Action:   "Deny",
+							SrcNet:   []string{"123.0.0.1/32"},
+							Protocol: &proto.Protocol{NumberOrName: &proto.Protocol_Name{Name: "icmp"}},
+							Icmp:     &proto.Rule_IcmpType{IcmpType: 8},
+						}},
+					},
+				}},
+			}},
+		},
+		DroppedPackets: []packet{
+			icmpPktWithTypeCode("123.0.0.1", "10.96.0.10", 8, 0),
+		},
+		UnmatchedPackets: []packet{
+			// ICMP errors from the same source, such as fragmentation needed, must
+			// still get through.
+			icmpPktWithTypeCode("123.0.0.1", "10.96.0.10", 3, 4),
+			icmpPktWithTypeCode("123.0.0.1", "10.96.0.10", 0, 0),
+			icmpPktWithTypeCode("123.0.0.2", "10.96.0.10", 8, 0),
+			udpPkt("123.0.0.1:1024", "10.96.0.10:53"),
+		},
+	},
 }
 
 func allowDestElseDeny(name, dst string) []polprog.Policy {
@@ -2464,7 +2496,7 @@ func runTest(t *testing.T, tp testPolicy) {
 	for _, tc := range tp.UnmatchedPackets() {
 		t.Run(fmt.Sprintf("should not match %s", tc), func(t *testing.T) {
 			RegisterTestingT(t)
-			runProgram(tc, testStateMap, polProgFD, XDPPass, state.PolicyNoMatch)
+			runProgram(tc, testStateMap, polProgFD, RCAllowedReached, state.PolicyNoMatch)
 		})
 	}
 }
@@ -263,3 +263,45 @@ func TestXDPPrograms(t *testing.T) {
 		}, withXDP())
 	}
 }
+
+// vlanTag inserts an 802.1Q tag with the given VLAN ID after the MAC addresses of an untagged
+// ethernet frame.
+func vlanTag(frame []byte, vlanID uint16) []byte {
+	tagged := make([]byte, 0, len(frame)+4)
+	tagged = append(tagged, frame[:12]...)
+	tagged = append(tagged, 0x81, 0x00, byte(vlanID>>8), byte(vlanID))
+	return append(tagged, frame[12:]...)
+}
+
+func TestXDPVLANTagged(t *testing.T) {
+	RegisterTestingT(t)
+
+	defer func() { bpfIfaceName = "" }()
+	bpfIfaceName = "XDP-VLAN"
+
+	for _, tc := range []struct {
+		src    net.IP
+		result string
+	}{
+		// Matches the source-only deny rule so the tag must have been skipped.
+		{src: net.IPv4(9, 8, 7, 6), result: "XDP_DROP"},
+		// Doesn't match any rule so it's left to TC.
+		{src: net.IPv4(8, 8, 8, 8), result: "XDP_PASS"},
+	} {
+		runBpfTest(t, "xdp_calico_entrypoint", &oneXDPRule, func(bpfrun bpfProgRunFn) {
+			ip := *ipv4Default
+			ip.SrcIP = tc.src
+			ip.DstIP = net.IPv4(9, 9, 9, 9)
+			_, _, _, _, pktBytes, err := testPacket(nil, &ip, &layers.UDP{SrcPort: 54321, DstPort: 8080}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			pktBytes = vlanTag(pktBytes, 100)
+
+			res, err := bpfrun(pktBytes)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.RetvalStrXDP()).To(Equal(tc.result), fmt.Sprintf("source %s", tc.src))
+			if tc.result == "XDP_PASS" {
+				Expect(res.dataOut).To(Equal(pktBytes))
+			}
+		}, withXDP())
+	}
+}
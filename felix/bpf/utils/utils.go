@@ -120,7 +120,13 @@ func isCgroupV2(path string) (bool, error) {
 }
 
 func mountBPFfs(path string) error {
-	return syscall.Mount(path, path, "bpf", 0, "")
+	if err := syscall.Mount(path, path, "bpf", 0, ""); err != nil {
+		// Mounting needs CAP_SYS_ADMIN, which a hardened deployment may not grant; it can
+		// mount the filesystem for us instead.
+		return fmt.Errorf("failed to mount the BPF filesystem at %s; mount it before starting "+
+			"Felix if Felix doesn't have CAP_SYS_ADMIN: %w", path, err)
+	}
+	return nil
 }
 
 func isMount(path string) (bool, error) {
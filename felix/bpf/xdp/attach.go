@@ -42,7 +42,10 @@ const DetachedID = 0
 type AttachPoint struct {
 	Iface    string
 	LogLevel string
-	Modes    []bpf.XDPMode
+	// EventLogLevel is the XDPLogLevel setting, which controls which verdicts the debug
+	// build of the program reports to the XDP events ring buffer.
+	EventLogLevel string
+	Modes         []bpf.XDPMode
 }
 
 func (ap *AttachPoint) IfaceName() string {
@@ -75,9 +78,10 @@ func (ap *AttachPoint) ProgramName() string {
 
 func (ap *AttachPoint) Log() *log.Entry {
 	return log.WithFields(log.Fields{
-		"iface":    ap.Iface,
-		"modes":    ap.Modes,
-		"logLevel": ap.LogLevel,
+		"iface":         ap.Iface,
+		"modes":         ap.Modes,
+		"logLevel":      ap.LogLevel,
+		"eventLogLevel": ap.EventLogLevel,
 	})
 }
 
@@ -106,12 +110,31 @@ func (ap *AttachPoint) AlreadyAttached(object string) (int, bool) {
 	return -1, false
 }
 
-func ConfigureProgram(m *libbpf.Map, iface string) error {
+// Values of the log_level field of struct cali_xdp_globals.
+const (
+	eventLogLevelOff   = 0
+	eventLogLevelError = 1
+	eventLogLevelDebug = 2
+)
+
+func eventLogLevel(level string) uint32 {
+	switch strings.ToLower(level) {
+	case "error":
+		return eventLogLevelError
+	case "debug":
+		return eventLogLevelDebug
+	default:
+		return eventLogLevelOff
+	}
+}
+
+func ConfigureProgram(m *libbpf.Map, iface, eventLogLvl string) error {
 	var globalData libbpf.XDPGlobalData
 
 	in := []byte("---------------")
 	copy(in, iface)
 	globalData.IfaceName = string(in)
+	globalData.LogLevel = eventLogLevel(eventLogLvl)
 
 	if err := libbpf.XDPSetGlobals(m, &globalData); err != nil {
 		return fmt.Errorf("failed to configure xdp: %w", err)
@@ -140,7 +163,7 @@ func (ap *AttachPoint) AttachProgram() (int, error) {
 
 	for m, err := obj.FirstMap(); m != nil && err == nil; m, err = m.NextMap() {
 		if m.IsMapInternal() {
-			if err := ConfigureProgram(m, ap.Iface); err != nil {
+			if err := ConfigureProgram(m, ap.Iface, ap.EventLogLevel); err != nil {
 				return -1, err
 			}
 			continue
@@ -178,13 +201,16 @@ func (ap *AttachPoint) AttachProgram() (int, error) {
 	}
 
 	attachmentSucceeded := false
-	for _, mode := range ap.Modes {
+	for i, mode := range ap.Modes {
 		ap.Log().Debugf("Trying to attach XDP program in mode %v - old id: %v", mode, oldID)
 		// Force attach the program. If there is already a program attached, the replacement only
 		// succeed in the same mode of the current program.
 		progID, err = obj.AttachXDP(ap.Iface, ap.ProgramName(), oldID, unix.XDP_FLAGS_REPLACE|uint(mode))
 		if err != nil || progID == DetachedID || progID == oldID {
 			ap.Log().WithError(err).Warnf("Failed to attach to XDP program %s mode %v", ap.ProgramName(), mode)
+			if i+1 < len(ap.Modes) {
+				ap.Log().Infof("Failed to load XDP program, falling back to %v mode.", ap.Modes[i+1])
+			}
 		} else {
 			ap.Log().Debugf("Successfully attached XDP program in mode %v. ID: %v", mode, progID)
 			attachmentSucceeded = true
@@ -247,6 +273,12 @@ func (ap *AttachPoint) DetachProgram() error {
 
 	ap.Log().Infof("XDP program detached. program ID: %v", progID)
 
+	// The pinned jump map holds the policy program, which would otherwise stay loaded.
+	jumpMapDir := bpf.MapPinDir(unix.BPF_MAP_TYPE_PROG_ARRAY, maps.JumpMapName(), ap.Iface, bpf.HookXDP)
+	if err := os.RemoveAll(jumpMapDir); err != nil {
+		ap.Log().WithError(err).Warn("Failed to remove the pinned XDP jump map.")
+	}
+
 	// Program is detached, now remove the json file we saved for it
 	if err = bpf.ForgetAttachedProg(ap.IfaceName(), bpf.HookXDP); err != nil {
 		return fmt.Errorf("failed to delete hash of BPF program from disk: %w", err)
@@ -0,0 +1,154 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpf
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// The allow-list holds the sources that the XDP program passes without looking at the
+// blocklist: the host's own addresses and those of its workloads.  It stops a blocklist CIDR
+// that happens to contain the node's pod CIDR from cutting the node off from its own pods.
+// There's one allow-list for the whole host, shared by the programs on all interfaces; it's
+// created along with the first blocklist map, and it's small because it only holds the
+// host's addresses.
+const (
+	xdpAllowMapName       = "calico_allow_v4"
+	xdpAllowMapSymbol     = "calico_prefilter_allow_v4"
+	xdpAllowMapMaxEntries = 1024
+)
+
+func (b *BPFLib) xdpAllowMapPath() string {
+	return filepath.Join(b.xdpDir, xdpAllowMapName)
+}
+
+func (b *BPFLib) newXDPAllowMap() (string, error) {
+	return newMap(xdpAllowMapName,
+		b.xdpAllowMapPath(),
+		"lpm_trie",
+		xdpAllowMapMaxEntries,
+		8, // key size
+		4, // value size
+		1, // BPF_F_NO_PREALLOC
+	)
+}
+
+// isValidXDPAllowMap returns whether the allow-list exists and has the expected type and
+// layout.
+func (b *BPFLib) isValidXDPAllowMap() (bool, error) {
+	mapPath := b.xdpAllowMapPath()
+	if _, err := os.Stat(mapPath); os.IsNotExist(err) {
+		return false, nil
+	}
+	m, err := getMapStruct(mapPath)
+	if err != nil {
+		return false, err
+	}
+	return m.Type == "lpm_trie" && m.KeySize == 8 && m.ValueSize == 4, nil
+}
+
+// DumpXDPAllowMap returns the CIDRs in the allow-list.  The allow-list is empty if it doesn't
+// exist.
+func (b *BPFLib) DumpXDPAllowMap() (map[CIDRMapKey]uint32, error) {
+	mapPath := b.xdpAllowMapPath()
+	if _, err := os.Stat(mapPath); os.IsNotExist(err) {
+		return map[CIDRMapKey]uint32{}, nil
+	}
+
+	return dumpCIDRMap(mapPath, IPFamilyV4)
+}
+
+// UpdateXDPAllowMap adds the given CIDR to the allow-list, creating the allow-list if it
+// doesn't exist yet.
+func (b *BPFLib) UpdateXDPAllowMap(ip net.IP, mask int) error {
+	mapPath, err := b.newXDPAllowMap()
+	if err != nil {
+		return err
+	}
+
+	hexKey, err := CidrToHex(fmt.Sprintf("%s/%d", ip.String(), mask))
+	if err != nil {
+		return err
+	}
+
+	prog := "bpftool"
+	args := []string{
+		"map",
+		"update",
+		"pinned",
+		mapPath,
+		"key",
+		"hex"}
+	args = append(args, hexKey...)
+	// It's just a set, so use 1 as value.
+	args = append(args, "value", "hex")
+	args = append(args, cidrMapValueToHex(1)...)
+
+	printCommand(prog, args...)
+	output, err := exec.Command(prog, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to update map (%s) with (%v/%d): %s\n%s", xdpAllowMapName, ip, mask, err, output)
+	}
+
+	return nil
+}
+
+// RemoveItemXDPAllowMap removes the given CIDR from the allow-list.  It's not an error if the
+// CIDR isn't there.
+func (b *BPFLib) RemoveItemXDPAllowMap(ip net.IP, mask int) error {
+	mapPath := b.xdpAllowMapPath()
+	if _, err := os.Stat(mapPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	hexKey, err := CidrToHex(fmt.Sprintf("%s/%d", ip.String(), mask))
+	if err != nil {
+		return err
+	}
+
+	prog := "bpftool"
+	args := []string{
+		"map",
+		"delete",
+		"pinned",
+		mapPath,
+		"key",
+		"hex"}
+	args = append(args, hexKey...)
+
+	printCommand(prog, args...)
+	output, err := exec.Command(prog, args...).CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(output), "No such file or directory") {
+			return nil
+		}
+		return fmt.Errorf("failed to delete item (%v/%d) from map (%s): %s\n%s", ip, mask, xdpAllowMapName, err, output)
+	}
+
+	return nil
+}
+
+func (b *BPFLib) RemoveXDPAllowMap() error {
+	err := os.Remove(b.xdpAllowMapPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,235 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/projectcalico/calico/felix/bpf/bpfdefs"
+)
+
+// The reasons that GlobalNetworkSets give for their entries are kept, for auditing, in a map
+// alongside the XDP blocklists.  The map is shared by all interfaces; it's keyed on the CIDR,
+// in the same form as the blocklists, and its values are the reasons, padded with NULs.  The
+// XDP program doesn't use it.
+const (
+	blocklistReasonsMapName = "calico_reasons"
+
+	// BlocklistReasonMaxLen is the length of the longest reason that the map can hold.
+	BlocklistReasonMaxLen = 32
+)
+
+func (b *BPFLib) blocklistReasonsMapPath() string {
+	return filepath.Join(b.xdpDir, blocklistReasonsMapName)
+}
+
+func (b *BPFLib) newBlocklistReasonsMap() (string, error) {
+	keySize := 8
+	valueSize := BlocklistReasonMaxLen
+
+	return newMap(blocklistReasonsMapName,
+		b.blocklistReasonsMapPath(),
+		"lpm_trie",
+		CIDRMapMaxEntries,
+		keySize,
+		valueSize,
+		1, // BPF_F_NO_PREALLOC
+	)
+}
+
+// UpdateBlocklistReasonsMap records the reason for the given CIDR, creating the map if it
+// doesn't exist yet.  Reasons longer than BlocklistReasonMaxLen are truncated.
+func (b *BPFLib) UpdateBlocklistReasonsMap(ip net.IP, mask int, reason string) error {
+	mapPath, err := b.newBlocklistReasonsMap()
+	if err != nil {
+		return err
+	}
+
+	cidr := fmt.Sprintf("%s/%d", ip.String(), mask)
+
+	hexKey, err := CidrToHex(cidr)
+	if err != nil {
+		return err
+	}
+
+	prog := "bpftool"
+	args := []string{
+		"map",
+		"update",
+		"pinned",
+		mapPath,
+		"key",
+		"hex"}
+	args = append(args, hexKey...)
+	args = append(args, "value", "hex")
+	args = append(args, blocklistReasonToHex(reason)...)
+
+	printCommand(prog, args...)
+	output, err := exec.Command(prog, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to update map (%s) with (%v/%d): %s\n%s", blocklistReasonsMapName, ip, mask, err, output)
+	}
+
+	return nil
+}
+
+// RemoveItemBlocklistReasonsMap removes the reason for the given CIDR.  It's not an error if
+// the CIDR has no reason.
+func (b *BPFLib) RemoveItemBlocklistReasonsMap(ip net.IP, mask int) error {
+	mapPath := b.blocklistReasonsMapPath()
+	if _, err := os.Stat(mapPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	cidr := fmt.Sprintf("%s/%d", ip.String(), mask)
+
+	hexKey, err := CidrToHex(cidr)
+	if err != nil {
+		return err
+	}
+
+	prog := "bpftool"
+	args := []string{
+		"map",
+		"delete",
+		"pinned",
+		mapPath,
+		"key",
+		"hex"}
+	args = append(args, hexKey...)
+
+	printCommand(prog, args...)
+	output, err := exec.Command(prog, args...).CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(output), "No such file or directory") {
+			return nil
+		}
+		return fmt.Errorf("failed to delete (%v/%d) from map (%s): %s\n%s", ip, mask, blocklistReasonsMapName, err, output)
+	}
+
+	return nil
+}
+
+// DumpBlocklistReasonsMap returns the reasons in the map, keyed on CIDR.  The map is empty if
+// it doesn't exist.
+func (b *BPFLib) DumpBlocklistReasonsMap() (map[CIDRMapKey]string, error) {
+	mapPath := b.blocklistReasonsMapPath()
+	if _, err := os.Stat(mapPath); os.IsNotExist(err) {
+		return map[CIDRMapKey]string{}, nil
+	}
+
+	prog := "bpftool"
+	args := []string{
+		"--json",
+		"--pretty",
+		"map",
+		"dump",
+		"pinned",
+		mapPath}
+
+	printCommand(prog, args...)
+	output, err := exec.Command(prog, args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump map (%s): %s\n%s", blocklistReasonsMapName, err, output)
+	}
+
+	return parseBlocklistReasonsDump(output)
+}
+
+func parseBlocklistReasonsDump(output []byte) (map[CIDRMapKey]string, error) {
+	var entries []mapEntry
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("cannot parse json output: %v\n%s", err, output)
+	}
+
+	reasons := make(map[CIDRMapKey]string, len(entries))
+	for _, e := range entries {
+		ipnet, err := hexToIPNet(e.Key, IPFamilyV4)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bpf map key (%v) to ip and mask: %v", e.Key, err)
+		}
+		reason, err := hexToBlocklistReason(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bpf map value (%v): %v", e.Value, err)
+		}
+		reasons[NewCIDRMapKey(ipnet)] = reason
+	}
+
+	return reasons, nil
+}
+
+func (b *BPFLib) RemoveBlocklistReasonsMap() error {
+	err := os.Remove(b.blocklistReasonsMapPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// BlocklistEntryReason returns the reason recorded for the given CIDR in the given Felix.  The
+// lookup matches the longest prefix in the map, so the reason for an address that's blocked by
+// a wider CIDR is that CIDR's reason.
+func BlocklistEntryReason(felix CommandRunner, cidr string) (string, error) {
+	if !strings.Contains(cidr, "/") {
+		cidr += "/32"
+	}
+	hexKey, err := CidrToHex(cidr)
+	if err != nil {
+		return "", err
+	}
+
+	mapPath := path.Join(bpfdefs.DefaultBPFfsPath, bpfCalicoSubdir, "xdp", blocklistReasonsMapName)
+	args := append([]string{"bpftool", "--json", "map", "lookup", "pinned", mapPath, "key", "hex"}, hexKey...)
+	out, err := felix.ExecOutput(args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up %s in map (%s): %w\n%s", cidr, mapPath, err, out)
+	}
+
+	e := mapEntry{}
+	if err := json.Unmarshal([]byte(out), &e); err != nil {
+		return "", fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+	}
+	if e.Err != "" {
+		return "", fmt.Errorf("%s", e.Err)
+	}
+	return hexToBlocklistReason(e.Value)
+}
+
+// blocklistReasonToHex returns the map value for the given reason in the form that bpftool
+// takes after "hex".
+func blocklistReasonToHex(reason string) []string {
+	value := make([]byte, BlocklistReasonMaxLen)
+	copy(value, reason)
+	return bytesToHex(value)
+}
+
+func hexToBlocklistReason(hexStrings []string) (string, error) {
+	value, err := hexStringsToBytes(hexStrings)
+	if err != nil {
+		return "", err
+	}
+	if len(value) != BlocklistReasonMaxLen {
+		return "", fmt.Errorf("wrong size of hex in %q", hexStrings)
+	}
+	return string(bytes.TrimRight(value, "\x00")), nil
+}
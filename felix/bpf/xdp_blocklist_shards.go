@@ -0,0 +1,179 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/projectcalico/calico/felix/bpf/maps"
+)
+
+// The blocklist XDP program keeps one copy ("shard") of the blocklist map per NUMA node and
+// looks up the copy of the node that it's running on, so that the nodes of a multi-socket
+// host don't all share one map.  Shard i is created with its memory on NUMA node i, and Felix
+// writes every change to all the shards.
+//
+// Shard 0 is the map that was always there, so that tools that look at it keep working; the
+// other shards are pinned alongside it with a suffix.  The program has a map for each of up
+// to maxBlocklistShards shards; NUMA nodes beyond that, and shards that a host doesn't need,
+// use shard 0.
+const (
+	maxBlocklistShards = 4
+
+	numaNodesOnlinePath = "/sys/devices/system/node/online"
+)
+
+// getCIDRMapShardName returns the name of the given shard of the blocklist map of an
+// interface.
+func getCIDRMapShardName(ifName string, family IPFamily, shard int) string {
+	name := getCIDRMapName(ifName, family)
+	if shard == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s_shard%d", name, shard)
+}
+
+// getCIDRMapShardSymbol returns the name of the map definition of the given shard in the
+// blocklist XDP program.
+func getCIDRMapShardSymbol(shard int) string {
+	if shard == 0 {
+		return "calico_prefilter_v4"
+	}
+	return fmt.Sprintf("calico_prefilter_v4_%d", shard)
+}
+
+// newCIDRMapShard creates the given shard of the blocklist map of an interface, unless it's
+// already pinned.  When there's more than one shard, shard i is allocated on NUMA node i; if
+// that fails, for example because the node is offline, the shard is created without a node.
+func (b *BPFLib) newCIDRMapShard(ifName string, family IPFamily, shard int) error {
+	name := getCIDRMapShardName(ifName, family, shard)
+	mapPath := filepath.Join(b.xdpDir, name)
+
+	if b.blocklistShards > 1 && SyscallSupport() {
+		if _, err := os.Stat(mapPath); err == nil {
+			return nil
+		}
+		if err := os.MkdirAll(b.xdpDir, 0700); err != nil {
+			return err
+		}
+		err := CreatePinnedMapOnNUMANode(mapPath, name, unix.BPF_MAP_TYPE_LPM_TRIE,
+			8, 4, CIDRMapMaxEntries, unix.BPF_F_NO_PREALLOC, shard)
+		if err == nil {
+			return nil
+		}
+		log.WithError(err).WithFields(log.Fields{
+			"iface": ifName,
+			"shard": shard,
+		}).Warn("Failed to create blocklist map shard on its NUMA node, creating it without one.")
+	}
+
+	_, err := newMap(name,
+		mapPath,
+		"lpm_trie",
+		CIDRMapMaxEntries,
+		8, // key size
+		4, // value size
+		1, // BPF_F_NO_PREALLOC
+	)
+	return err
+}
+
+// updateCIDRMapShards writes an entry, given as bpftool hex, to every shard of the blocklist
+// map of an interface with the bpf() syscall, so that a change doesn't cost a bpftool process
+// per shard.
+func (b *BPFLib) updateCIDRMapShards(ifName string, family IPFamily, hexKey, hexValue []string) error {
+	k, err := hexStringsToBytes(hexKey)
+	if err != nil {
+		return err
+	}
+	v, err := hexStringsToBytes(hexValue)
+	if err != nil {
+		return err
+	}
+	return b.forEachCIDRMapShard(ifName, family, func(fd maps.FD) error {
+		return maps.UpdateMapEntry(fd, k, v)
+	})
+}
+
+// deleteFromCIDRMapShards removes an entry, given as bpftool hex, from every shard of the
+// blocklist map of an interface with the bpf() syscall.  Like bpftool, it fails if a shard
+// doesn't have the entry.
+func (b *BPFLib) deleteFromCIDRMapShards(ifName string, family IPFamily, hexKey []string) error {
+	k, err := hexStringsToBytes(hexKey)
+	if err != nil {
+		return err
+	}
+	return b.forEachCIDRMapShard(ifName, family, func(fd maps.FD) error {
+		return maps.DeleteMapEntry(fd, k, 4)
+	})
+}
+
+func (b *BPFLib) forEachCIDRMapShard(ifName string, family IPFamily, f func(fd maps.FD) error) error {
+	for _, mapPath := range b.cidrMapShardPaths(ifName, family) {
+		fd, err := maps.GetMapFDByPin(mapPath)
+		if err != nil {
+			return fmt.Errorf("failed to open map (%s): %w", filepath.Base(mapPath), err)
+		}
+		err = f(fd)
+		_ = fd.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write map (%s): %w", filepath.Base(mapPath), err)
+		}
+	}
+	return nil
+}
+
+// numBlocklistShards returns the number of blocklist map shards to use on this host: one for
+// each NUMA node ID up to the highest that's online, capped at maxBlocklistShards.
+func numBlocklistShards() int {
+	nodes, err := os.ReadFile(numaNodesOnlinePath)
+	if err != nil {
+		log.WithError(err).Debug("Couldn't read NUMA nodes, using a single blocklist map.")
+		return 1
+	}
+	n, err := parseNUMANodeList(string(nodes))
+	if err != nil {
+		log.WithError(err).Warn("Couldn't parse NUMA nodes, using a single blocklist map.")
+		return 1
+	}
+	if n > maxBlocklistShards {
+		n = maxBlocklistShards
+	}
+	return n
+}
+
+// parseNUMANodeList parses a kernel node list, such as "0" or "0,2-3", and returns one more
+// than the highest node ID in it.
+func parseNUMANodeList(s string) (int, error) {
+	highest := -1
+	for _, r := range strings.Split(strings.TrimSpace(s), ",") {
+		parts := strings.SplitN(r, "-", 2)
+		id, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			return 0, fmt.Errorf("bad NUMA node list %q: %w", s, err)
+		}
+		if id > highest {
+			highest = id
+		}
+	}
+	return highest + 1, nil
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+
+	"github.com/projectcalico/calico/felix/bpf/bpfdefs"
+)
+
+const (
+	// xdpDropCountsMapName is the name of the map in which the blocklist XDP program counts
+	// the packets that it drops from each source IP.
+	xdpDropCountsMapName = "calico_drops_v4"
+	// xdpGraceCountsMapName is the name of the map in which the blocklist XDP program counts
+	// the packets that it lets through, from each source IP, because the source is in a
+	// blocklist entry's grace period.
+	xdpGraceCountsMapName = "calico_gpass_v4"
+)
+
+// PerSourceDropCounts returns the number of packets that the blocklist XDP program
+// attached to the given interface has dropped from each source IP, keyed by the IP.  The
+// counts start from zero whenever the program is (re)loaded, or the counters are reset
+// with ResetXDPCounters.  The program counts up to 10240 sources; once it has that many,
+// counting a new source evicts the source that was least recently counted, so the counts
+// of sources that have stopped sending can disappear.
+func PerSourceDropCounts(felix CommandRunner, iface string) (map[string]uint64, error) {
+	return perSourceCounts(felix, iface, xdpDropCountsMapName)
+}
+
+// PerSourceGraceCounts returns the number of packets that the blocklist XDP program attached
+// to the given interface has let through from each source IP, keyed by the IP, because the
+// source is in a blocklist entry that is still in its grace period.  Like the drop counts,
+// the counts start from zero whenever the program is (re)loaded, or the counters are reset
+// with ResetXDPCounters.
+func PerSourceGraceCounts(felix CommandRunner, iface string) (map[string]uint64, error) {
+	return perSourceCounts(felix, iface, xdpGraceCountsMapName)
+}
+
+func perSourceCounts(felix CommandRunner, iface, mapName string) (map[string]uint64, error) {
+	id, err := xdpProgMapID(felix, iface, mapName)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := dumpDropCounts(felix, id)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]uint64, len(snapshot))
+	for k, v := range snapshot {
+		if len(k) != 4 || len(v) != 8 {
+			return nil, fmt.Errorf("unexpected count entry in %s %x: %x", mapName, k, v)
+		}
+		counts[net.IP(k).String()] = nativeEndian.Uint64(v)
+	}
+	return counts, nil
+}
+
+// ResetXDPCounters zeroes the XDP counters of the given interface: the BPF-mode verdict
+// counters and, if the blocklist XDP program is attached, its per-source drop and grace counts and
+// its failsafe bypass counts.  Tests can use it to check the exact number of drops since the reset.
+func ResetXDPCounters(felix CommandRunner, iface string) error {
+	out, err := felix.ExecOutput("calico-bpf", "counters", "flush", "--iface="+iface)
+	if err != nil {
+		return fmt.Errorf("failed to flush counters of %s: %w\n%s", iface, err, out)
+	}
+
+	if _, err := felix.ExecOutput("ls", xdpProgPinPath(iface)); err != nil {
+		// No blocklist program so there are no per-source counts.
+		return nil
+	}
+	for _, mapName := range []string{xdpDropCountsMapName, xdpGraceCountsMapName, xdpFailsafeBypassCountsMapName} {
+		id, err := xdpProgMapID(felix, iface, mapName)
+		if err != nil {
+			return err
+		}
+		snapshot, err := dumpDropCounts(felix, id)
+		if err != nil {
+			return err
+		}
+		for k := range snapshot {
+			args := append([]string{"bpftool", "map", "delete", "id", id, "key", "hex"}, bytesToHex([]byte(k))...)
+			if out, err := felix.ExecOutput(args...); err != nil {
+				return fmt.Errorf("failed to delete count %x from %s: %w\n%s", k, mapName, err, out)
+			}
+		}
+	}
+	return nil
+}
+
+func xdpProgPinPath(iface string) string {
+	return path.Join(bpfdefs.DefaultBPFfsPath, bpfCalicoSubdir, "xdp", getProgName(iface))
+}
+
+// xdpProgMapID returns the ID of the map with the given name that the blocklist XDP program
+// attached to the given interface uses.
+func xdpProgMapID(felix CommandRunner, iface, mapName string) (string, error) {
+	progPath := xdpProgPinPath(iface)
+	out, err := felix.ExecOutput("bpftool", "--json", "prog", "show", "pinned", progPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to show XDP program (%s): %w\n%s", progPath, err, out)
+	}
+	p := ProgInfo{}
+	if err := json.Unmarshal([]byte(out), &p); err != nil {
+		return "", fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+	}
+
+	for _, mapID := range p.MapIds {
+		id := strconv.Itoa(mapID)
+		out, err := felix.ExecOutput("bpftool", "--json", "map", "show", "id", id)
+		if err != nil {
+			return "", fmt.Errorf("failed to show map %s: %w\n%s", id, err, out)
+		}
+		m := mapInfo{}
+		if err := json.Unmarshal([]byte(out), &m); err != nil {
+			return "", fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+		}
+		if m.Name == mapName {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("XDP program on %s has no %s map", iface, mapName)
+}
+
+// countEntry is an entry of a counts map as dumped by "bpftool --json map dump".  A per-CPU
+// map has a value per CPU instead of a single value.
+type countEntry struct {
+	Key    []string `json:"key"`
+	Value  []string `json:"value"`
+	Values []struct {
+		CPU   int      `json:"cpu"`
+		Value []string `json:"value"`
+	} `json:"values"`
+	Err string `json:"error"`
+}
+
+// dumpDropCounts reads a map of 64-bit counts.  For a per-CPU map, the value of each entry is
+// the sum of its per-CPU counts.
+func dumpDropCounts(felix CommandRunner, id string) (Snapshot, error) {
+	out, err := felix.ExecOutput("bpftool", "--json", "map", "dump", "id", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump map %s: %w\n%s", id, err, out)
+	}
+	var entries []countEntry
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		return nil, fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+	}
+
+	snapshot := make(Snapshot, len(entries))
+	for _, e := range entries {
+		if e.Err != "" {
+			return nil, fmt.Errorf("%s", e.Err)
+		}
+		k, err := hexStringsToBytes(e.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bpf map key (%v): %w", e.Key, err)
+		}
+		if e.Values == nil {
+			v, err := hexStringsToBytes(e.Value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse bpf map value (%v): %w", e.Value, err)
+			}
+			snapshot[string(k)] = v
+			continue
+		}
+		var sum uint64
+		for _, cv := range e.Values {
+			v, err := hexStringsToBytes(cv.Value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse bpf map value (%v): %w", cv.Value, err)
+			}
+			if len(v) != 8 {
+				return nil, fmt.Errorf("unexpected count in map %s %x: %x", id, k, v)
+			}
+			sum += nativeEndian.Uint64(v)
+		}
+		v := make([]byte, 8)
+		nativeEndian.PutUint64(v, sum)
+		snapshot[string(k)] = v
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpf
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Alongside the blocklist map, which holds the sources that an interface's untracked policy
+// denies, each interface has a destination map, which holds the destinations that the policy
+// denies to all sources.  The XDP program drops a packet if its source is in the blocklist
+// or its destination is in the destination map.  The destination map is created and removed
+// with the blocklist map, and isn't sharded: its entries are the host's own addresses, so
+// it's small and rarely changes.
+const (
+	dstCIDRMapSymbol     = "calico_prefilter_dst_v4"
+	dstCIDRMapMaxEntries = 1024
+)
+
+// getDstCIDRMapName returns the name of the destination map of an interface.
+func getDstCIDRMapName(ifName string, family IPFamily) string {
+	return fmt.Sprintf("%s_%s_%s_dstlist", ifName, family, cidrMapVersion)
+}
+
+func (b *BPFLib) dstCIDRMapPath(ifName string, family IPFamily) string {
+	return filepath.Join(b.xdpDir, getDstCIDRMapName(ifName, family))
+}
+
+func (b *BPFLib) newDstCIDRMap(ifName string, family IPFamily) (string, error) {
+	mapName := getDstCIDRMapName(ifName, family)
+	return newMap(mapName,
+		b.dstCIDRMapPath(ifName, family),
+		"lpm_trie",
+		dstCIDRMapMaxEntries,
+		8, // key size
+		4, // value size
+		1, // BPF_F_NO_PREALLOC
+	)
+}
+
+func (b *BPFLib) removeDstCIDRMap(ifName string, family IPFamily) error {
+	if err := os.Remove(b.dstCIDRMapPath(ifName, family)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// isValidDstCIDRMap returns whether the destination map of an interface exists and has the
+// expected type and layout.
+func (b *BPFLib) isValidDstCIDRMap(ifName string, family IPFamily) (bool, error) {
+	mapPath := b.dstCIDRMapPath(ifName, family)
+	if _, err := os.Stat(mapPath); os.IsNotExist(err) {
+		return false, nil
+	}
+	m, err := getMapStruct(mapPath)
+	if err != nil {
+		return false, err
+	}
+	return m.Type == "lpm_trie" && m.KeySize == 8 && m.ValueSize == 4, nil
+}
+
+func (b *BPFLib) DumpDstCIDRMap(ifName string, family IPFamily) (map[CIDRMapKey]uint32, error) {
+	if err := os.MkdirAll(b.xdpDir, 0700); err != nil {
+		return nil, err
+	}
+
+	return dumpCIDRMap(b.dstCIDRMapPath(ifName, family), family)
+}
+
+func (b *BPFLib) UpdateDstCIDRMap(ifName string, family IPFamily, ip net.IP, mask int) error {
+	mapPath := b.dstCIDRMapPath(ifName, family)
+
+	hexKey, err := CidrToHex(fmt.Sprintf("%s/%d", ip.String(), mask))
+	if err != nil {
+		return err
+	}
+
+	prog := "bpftool"
+	args := []string{
+		"map",
+		"update",
+		"pinned",
+		mapPath,
+		"key",
+		"hex"}
+	args = append(args, hexKey...)
+	// It's just a set, so use 1 as value.
+	args = append(args, "value", "hex")
+	args = append(args, cidrMapValueToHex(1)...)
+
+	printCommand(prog, args...)
+	output, err := exec.Command(prog, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to update map (%s) with (%v/%d): %s\n%s", filepath.Base(mapPath), ip, mask, err, output)
+	}
+
+	return nil
+}
+
+func (b *BPFLib) RemoveItemDstCIDRMap(ifName string, family IPFamily, ip net.IP, mask int) error {
+	mapPath := b.dstCIDRMapPath(ifName, family)
+
+	hexKey, err := CidrToHex(fmt.Sprintf("%s/%d", ip.String(), mask))
+	if err != nil {
+		return err
+	}
+
+	prog := "bpftool"
+	args := []string{
+		"map",
+		"delete",
+		"pinned",
+		mapPath,
+		"key",
+		"hex"}
+	args = append(args, hexKey...)
+
+	printCommand(prog, args...)
+	output, err := exec.Command(prog, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to delete item (%v/%d) from map (%s): %s\n%s", ip, mask, filepath.Base(mapPath), err, output)
+	}
+
+	return nil
+}
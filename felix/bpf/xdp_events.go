@@ -0,0 +1,137 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/projectcalico/calico/felix/bpf/bpfdefs"
+)
+
+// XDPEventsMapName is the name of the ring buffer that the debug build of the XDP
+// programs reports the verdict for each packet to.
+const XDPEventsMapName = "cali_xdp_evts1"
+
+// XDPEventsPinPath is where the XDP events ring buffer is pinned.
+var XDPEventsPinPath = path.Join(bpfdefs.DefaultBPFfsPath, "tc", "globals", XDPEventsMapName)
+
+// XDPVerdict is the XDP action that the program returned for a packet.
+type XDPVerdict uint32
+
+// Values from enum xdp_action.
+const (
+	XDPVerdictDrop XDPVerdict = 1
+	XDPVerdictPass XDPVerdict = 2
+)
+
+func (v XDPVerdict) String() string {
+	switch v {
+	case XDPVerdictDrop:
+		return "DROP"
+	case XDPVerdictPass:
+		return "PASS"
+	default:
+		return fmt.Sprintf("XDPVerdict(%d)", uint32(v))
+	}
+}
+
+// XDPEvent mirrors struct cali_xdp_event in bpf-gpl/xdp_events.h.
+type XDPEvent struct {
+	SrcIP   net.IP
+	Verdict XDPVerdict
+}
+
+const xdpEventSize = 8
+
+// XDPEventFromBytes decodes an event record read from the ring buffer.
+func XDPEventFromBytes(b []byte) (XDPEvent, error) {
+	if len(b) < xdpEventSize {
+		return XDPEvent{}, fmt.Errorf("XDP event too short: %d bytes", len(b))
+	}
+	return XDPEvent{
+		SrcIP:   net.IP(append([]byte(nil), b[0:4]...)),
+		Verdict: XDPVerdict(binary.LittleEndian.Uint32(b[4:8])),
+	}, nil
+}
+
+func (e XDPEvent) String() string {
+	return fmt.Sprintf("src=%s verdict=%s", e.SrcIP, e.Verdict)
+}
+
+// ParseXDPEvent parses the output of XDPEvent.String().
+func ParseXDPEvent(s string) (XDPEvent, error) {
+	var src, verdict string
+	if _, err := fmt.Sscanf(s, "src=%s verdict=%s", &src, &verdict); err != nil {
+		return XDPEvent{}, fmt.Errorf("failed to parse XDP event %q: %w", s, err)
+	}
+	e := XDPEvent{SrcIP: net.ParseIP(src).To4()}
+	if e.SrcIP == nil {
+		return XDPEvent{}, fmt.Errorf("bad source IP in XDP event %q", s)
+	}
+	v, err := ParseXDPVerdict(verdict)
+	if err != nil {
+		return XDPEvent{}, fmt.Errorf("bad verdict in XDP event %q", s)
+	}
+	e.Verdict = v
+	return e, nil
+}
+
+// ParseXDPVerdict parses the output of XDPVerdict.String() for the drop and pass verdicts.
+func ParseXDPVerdict(s string) (XDPVerdict, error) {
+	switch s {
+	case XDPVerdictDrop.String():
+		return XDPVerdictDrop, nil
+	case XDPVerdictPass.String():
+		return XDPVerdictPass, nil
+	default:
+		return 0, fmt.Errorf("unknown XDP verdict %q", s)
+	}
+}
+
+// CommandRunner runs a command, for example in a Felix container, and returns its
+// output.
+type CommandRunner interface {
+	ExecOutput(args ...string) (string, error)
+}
+
+// ReadXDPRingbuf uses calico-bpf to collect the events from the XDP events ring
+// buffer for the given time.  The events are consumed, so each event is only
+// returned once.  Felix must be running the debug build of the BPF programs
+// (BPFLogLevel=Debug).
+func ReadXDPRingbuf(felix CommandRunner, timeout time.Duration) ([]XDPEvent, error) {
+	out, err := felix.ExecOutput("calico-bpf", "xdp", "events", "--timeout", timeout.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read XDP events: %w: %s", err, out)
+	}
+
+	var events []XDPEvent
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		e, err := ParseXDPEvent(line)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
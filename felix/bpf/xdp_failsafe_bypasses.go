@@ -0,0 +1,84 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpf
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/projectcalico/calico/felix/labelindex"
+)
+
+// xdpFailsafeBypassCountsMapName is the name of the map in which the blocklist XDP program
+// counts, for each failsafe port, the packets from blocklisted sources that it let through
+// because of the failsafe port.
+const xdpFailsafeBypassCountsMapName = "calico_fsbyp_v4"
+
+// FailsafeBypassEvent is the number of packets from blocklisted sources that one failsafe
+// rule let through the blocklist.
+type FailsafeBypassEvent struct {
+	// Outbound is true for an outbound failsafe rule.  The blocklist XDP program only sees
+	// ingress traffic so it only applies the inbound rules.
+	Outbound bool
+	ProtoPort
+	Packets uint64
+}
+
+func (e FailsafeBypassEvent) String() string {
+	dir := "inbound"
+	if e.Outbound {
+		dir = "outbound"
+	}
+	return fmt.Sprintf("%s %s:%d: %d packets", dir, e.Proto, e.Port, e.Packets)
+}
+
+// FailsafeBypassEvents returns, for each failsafe rule that has let packets from blocklisted
+// sources through the blocklist XDP program attached to the given interface, how many packets
+// it let through.  The events are sorted by protocol and port.  Rules that haven't let any
+// packets through aren't listed, nor are packets from sources that aren't blocklisted, which
+// would have got through anyway.  Like the per-source drop counts, the counts start from zero
+// whenever the program is (re)loaded, or the counters are reset with ResetXDPCounters.  Not
+// available in BPF mode.
+func FailsafeBypassEvents(felix CommandRunner, iface string) ([]FailsafeBypassEvent, error) {
+	id, err := xdpProgMapID(felix, iface, xdpFailsafeBypassCountsMapName)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := dumpDropCounts(felix, id)
+	if err != nil {
+		return nil, err
+	}
+	var events []FailsafeBypassEvent
+	for k, v := range snapshot {
+		if len(k) != 4 || len(v) != 8 {
+			return nil, fmt.Errorf("unexpected count entry in %s %x: %x", xdpFailsafeBypassCountsMapName, k, v)
+		}
+		kb := []byte(k)
+		events = append(events, FailsafeBypassEvent{
+			ProtoPort: ProtoPort{
+				Proto: labelindex.IPSetPortProtocol(nativeEndian.Uint16(kb[0:2])),
+				Port:  nativeEndian.Uint16(kb[2:4]),
+			},
+			Packets: nativeEndian.Uint64(v),
+		})
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Proto != events[j].Proto {
+			return events[i].Proto < events[j].Proto
+		}
+		return events[i].Port < events[j].Port
+	})
+	return events, nil
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpf
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// The grace list holds the blocklist CIDRs that are still in their grace period: the XDP
+// program counts the packets from them, per source, but lets them through, so that the impact
+// of a new blocklist entry can be checked before it's enforced.  Like the allow-list, there's
+// one grace list for the whole host, shared by the programs on all interfaces, and it's created
+// along with the first blocklist map.
+const (
+	xdpGraceMapName       = "calico_grace_v4"
+	xdpGraceMapSymbol     = "calico_prefilter_grace_v4"
+	xdpGraceMapMaxEntries = 10240
+)
+
+func (b *BPFLib) xdpGraceMapPath() string {
+	return filepath.Join(b.xdpDir, xdpGraceMapName)
+}
+
+func (b *BPFLib) newXDPGraceMap() (string, error) {
+	return newMap(xdpGraceMapName,
+		b.xdpGraceMapPath(),
+		"lpm_trie",
+		xdpGraceMapMaxEntries,
+		8, // key size
+		4, // value size
+		1, // BPF_F_NO_PREALLOC
+	)
+}
+
+// isValidXDPGraceMap returns whether the grace list exists and has the expected type and
+// layout.
+func (b *BPFLib) isValidXDPGraceMap() (bool, error) {
+	mapPath := b.xdpGraceMapPath()
+	if _, err := os.Stat(mapPath); os.IsNotExist(err) {
+		return false, nil
+	}
+	m, err := getMapStruct(mapPath)
+	if err != nil {
+		return false, err
+	}
+	return m.Type == "lpm_trie" && m.KeySize == 8 && m.ValueSize == 4, nil
+}
+
+// DumpXDPGraceMap returns the CIDRs in the grace list.  The grace list is empty if it doesn't
+// exist.
+func (b *BPFLib) DumpXDPGraceMap() (map[CIDRMapKey]uint32, error) {
+	mapPath := b.xdpGraceMapPath()
+	if _, err := os.Stat(mapPath); os.IsNotExist(err) {
+		return map[CIDRMapKey]uint32{}, nil
+	}
+
+	return dumpCIDRMap(mapPath, IPFamilyV4)
+}
+
+// UpdateXDPGraceMap adds the given CIDR to the grace list, creating the grace list if it
+// doesn't exist yet.
+func (b *BPFLib) UpdateXDPGraceMap(ip net.IP, mask int) error {
+	mapPath, err := b.newXDPGraceMap()
+	if err != nil {
+		return err
+	}
+
+	hexKey, err := CidrToHex(fmt.Sprintf("%s/%d", ip.String(), mask))
+	if err != nil {
+		return err
+	}
+
+	prog := "bpftool"
+	args := []string{
+		"map",
+		"update",
+		"pinned",
+		mapPath,
+		"key",
+		"hex"}
+	args = append(args, hexKey...)
+	// It's just a set, so use 1 as value.
+	args = append(args, "value", "hex")
+	args = append(args, cidrMapValueToHex(1)...)
+
+	printCommand(prog, args...)
+	output, err := exec.Command(prog, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to update map (%s) with (%v/%d): %s\n%s", xdpGraceMapName, ip, mask, err, output)
+	}
+
+	return nil
+}
+
+// RemoveItemXDPGraceMap removes the given CIDR from the grace list.  It's not an error if the
+// CIDR isn't there.
+func (b *BPFLib) RemoveItemXDPGraceMap(ip net.IP, mask int) error {
+	mapPath := b.xdpGraceMapPath()
+	if _, err := os.Stat(mapPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	hexKey, err := CidrToHex(fmt.Sprintf("%s/%d", ip.String(), mask))
+	if err != nil {
+		return err
+	}
+
+	prog := "bpftool"
+	args := []string{
+		"map",
+		"delete",
+		"pinned",
+		mapPath,
+		"key",
+		"hex"}
+	args = append(args, hexKey...)
+
+	printCommand(prog, args...)
+	output, err := exec.Command(prog, args...).CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(output), "No such file or directory") {
+			return nil
+		}
+		return fmt.Errorf("failed to delete item (%v/%d) from map (%s): %s\n%s", ip, mask, xdpGraceMapName, err, output)
+	}
+
+	return nil
+}
+
+func (b *BPFLib) RemoveXDPGraceMap() error {
+	err := os.Remove(b.xdpGraceMapPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpf
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// XDPLookupPacket builds the ethernet frame that "calico-bpf xdp test-lookup" runs through
+// an XDP program: a small UDP datagram from src to dst.  Only IPv4 is supported, like the
+// XDP blocklist.
+func XDPLookupPacket(src, dst net.IP) ([]byte, error) {
+	src4, dst4 := src.To4(), dst.To4()
+	if src4 == nil || dst4 == nil {
+		return nil, fmt.Errorf("XDP lookups need IPv4 addresses, not %s -> %s", src, dst)
+	}
+
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0x02, 0, 0, 0, 0, 0x01},
+		DstMAC:       net.HardwareAddr{0x02, 0, 0, 0, 0, 0x02},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip := &layers.IPv4{
+		Version:  4,
+		IHL:      5,
+		TTL:      64,
+		Flags:    layers.IPv4DontFragment,
+		SrcIP:    src4,
+		DstIP:    dst4,
+		Protocol: layers.IPProtocolUDP,
+	}
+	udp := &layers.UDP{SrcPort: 54321, DstPort: 8055}
+	if err := udp.SetNetworkLayerForChecksum(ip); err != nil {
+		return nil, err
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	err := gopacket.SerializeLayers(buf, opts, eth, ip, udp, gopacket.Payload("xdp-test-lookup"))
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// XDPTestLookup uses calico-bpf to run a packet from the given source IP through the XDP
+// program attached to iface, and returns the program's verdict.  No packet is sent so the
+// result doesn't depend on the network.
+func XDPTestLookup(felix CommandRunner, iface, src string) (XDPVerdict, error) {
+	out, err := felix.ExecOutput("calico-bpf", "xdp", "test-lookup", iface, src)
+	if err != nil {
+		return 0, fmt.Errorf("failed to run XDP test lookup of %s on %s: %w: %s", src, iface, err, out)
+	}
+	return ParseXDPVerdict(strings.TrimSpace(out))
+}
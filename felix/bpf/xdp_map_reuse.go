@@ -0,0 +1,138 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpf
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// xdpReloadTimeout is how long AssertProgramReusesPinnedMaps waits for Felix to reload the
+// XDP program.
+const xdpReloadTimeout = 10 * time.Second
+
+// AssertProgramReusesPinnedMaps checks that, when Felix reloads the XDP program on the given
+// interface in the given Felix, the new program refers to the same pinned maps as the old one
+// rather than to new maps of its own; a program that came with fresh maps would start with an
+// empty blocklist.  It records the maps that the attached program uses and where they're
+// pinned, calls reload, which should make Felix reload the program, and waits for a new
+// program to be attached.  It returns an error if no new program is attached in time, if a
+// pin now refers to a different map, or if the new program doesn't use a map that the old one
+// did.
+func AssertProgramReusesPinnedMaps(felix CommandRunner, iface string, reload func() error) error {
+	before, err := attachedXDPProgram(felix, iface)
+	if err != nil {
+		return err
+	}
+	pins, err := pinnedMapIDs(felix)
+	if err != nil {
+		return err
+	}
+	pinnedBefore := map[string]int{}
+	for _, id := range before.MapIds {
+		for _, pin := range pins[id] {
+			pinnedBefore[pin] = id
+		}
+	}
+	if len(pinnedBefore) == 0 {
+		return fmt.Errorf("XDP program %d on %s doesn't use any pinned maps", before.Id, iface)
+	}
+
+	if err := reload(); err != nil {
+		return fmt.Errorf("failed to trigger reload of XDP program on %s: %w", iface, err)
+	}
+	var after ProgInfo
+	for start := time.Now(); ; time.Sleep(100 * time.Millisecond) {
+		after, err = attachedXDPProgram(felix, iface)
+		if err == nil && after.Id != before.Id {
+			break
+		}
+		if time.Since(start) > xdpReloadTimeout {
+			return fmt.Errorf("XDP program %d on %s wasn't reloaded within %v", before.Id, iface, xdpReloadTimeout)
+		}
+	}
+
+	pins, err = pinnedMapIDs(felix)
+	if err != nil {
+		return err
+	}
+	pinnedAfter := map[string]int{}
+	for id, paths := range pins {
+		for _, pin := range paths {
+			pinnedAfter[pin] = id
+		}
+	}
+	usedAfter := map[int]bool{}
+	for _, id := range after.MapIds {
+		usedAfter[id] = true
+	}
+	for pin, id := range pinnedBefore {
+		if pinnedAfter[pin] != id {
+			return fmt.Errorf("map pinned at %s was replaced when the XDP program on %s was reloaded: map %d before, %d after",
+				pin, iface, id, pinnedAfter[pin])
+		}
+		if !usedAfter[id] {
+			return fmt.Errorf("reloaded XDP program %d on %s doesn't use the map pinned at %s (map %d)",
+				after.Id, iface, pin, id)
+		}
+	}
+	return nil
+}
+
+var attachedXDPProgIDRegexp = regexp.MustCompile(`prog/xdp id (\d+)`)
+
+// attachedXDPProgram returns the details of the XDP program that is attached to the given
+// interface in the given Felix.
+func attachedXDPProgram(felix CommandRunner, iface string) (ProgInfo, error) {
+	prog := ProgInfo{}
+	out, err := felix.ExecOutput("ip", "-o", "link", "show", "dev", iface)
+	if err != nil {
+		return prog, fmt.Errorf("failed to show %s: %w\n%s", iface, err, out)
+	}
+	m := attachedXDPProgIDRegexp.FindStringSubmatch(out)
+	if m == nil {
+		return prog, fmt.Errorf("no XDP program attached to %s", iface)
+	}
+	out, err = felix.ExecOutput("bpftool", "--json", "prog", "show", "id", m[1])
+	if err != nil {
+		return prog, fmt.Errorf("failed to show XDP program %s: %w\n%s", m[1], err, out)
+	}
+	if err := json.Unmarshal([]byte(out), &prog); err != nil {
+		return prog, fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+	}
+	return prog, nil
+}
+
+// pinnedMapIDs returns the paths that each map is pinned at in the given Felix's BPF
+// filesystem, keyed by map ID.  Maps that aren't pinned are left out.
+func pinnedMapIDs(felix CommandRunner) (map[int][]string, error) {
+	out, err := felix.ExecOutput("bpftool", "--json", "--bpffs", "map", "list")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maps: %w\n%s", err, out)
+	}
+	var maps []pinnedMapInfo
+	if err := json.Unmarshal([]byte(out), &maps); err != nil {
+		return nil, fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+	}
+	pins := map[int][]string{}
+	for _, m := range maps {
+		if len(m.Pinned) > 0 {
+			pins[m.Id] = m.Pinned
+		}
+	}
+	return pins, nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpf
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// XDPStatusPath is the path, on the Prometheus metrics port, at which Felix reports the
+// XDP status of each interface as a JSON object keyed by interface name.
+const XDPStatusPath = "/xdp-status"
+
+// xdpStatusURL is where XDPAttachFlags fetches the XDP status from, inside Felix's container;
+// 9091 is Felix's default Prometheus metrics port.
+const xdpStatusURL = "http://127.0.0.1:9091" + XDPStatusPath
+
+// XDPStatus is Felix's view of XDP enforcement on one interface, as of the last time that
+// it reconciled the XDP programs and maps with its desired state.
+type XDPStatus struct {
+	// Desired is true if the interface has untracked policy that XDP can implement.
+	Desired bool `json:"desired"`
+	// Attached is true if Felix has attached its XDP program to the interface.
+	Attached bool `json:"attached"`
+	// Mode is the mode that the XDP program is attached in; for example, "xdpdrv".  Empty
+	// if the program isn't attached.
+	Mode string `json:"mode,omitempty"`
+	// AttachFlags are the XDP_FLAGS_* that the program was attached with.  Zero if the
+	// program isn't attached.
+	AttachFlags uint32 `json:"attachFlags,omitempty"`
+	// BlocklistEntries is the number of CIDRs in the interface's blocklist map.
+	BlocklistEntries int `json:"blocklistEntries"`
+	// LastReconcile is when Felix last brought the interface's XDP state up to date.
+	LastReconcile time.Time `json:"lastReconcile"`
+}
+
+// XDPAttachFlags returns the XDP_FLAGS_* that the given Felix attached its XDP program to the
+// given interface with.  The kernel only reports the mode that a program is attached in, not
+// flags such as XDP_FLAGS_UPDATE_IF_NOEXIST, so the flags come from Felix's XDP status; the
+// mode flag among them is checked against the mode that the kernel reports.
+func XDPAttachFlags(felix CommandRunner, iface string) (flags uint32, err error) {
+	out, err := felix.ExecOutput("wget", "-q", "-T", "2", "-O", "-", xdpStatusURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch XDP status: %w\n%s", err, out)
+	}
+	var status map[string]XDPStatus
+	if err := json.Unmarshal([]byte(out), &status); err != nil {
+		return 0, fmt.Errorf("failed to decode XDP status: %w\n%s", err, out)
+	}
+	st := status[iface]
+	if !st.Attached {
+		return 0, fmt.Errorf("felix reports no XDP program attached to %s", iface)
+	}
+
+	out, err = felix.ExecOutput("ip", "link", "show", "dev", iface)
+	if err != nil {
+		return 0, fmt.Errorf("failed to show interface information (%s): %w\n%s", iface, err, out)
+	}
+	mode, err := xdpModeFromLinkShow(out)
+	if err != nil {
+		return 0, fmt.Errorf("no XDP program attached to %s: %w", iface, err)
+	}
+	if st.AttachFlags&uint32(mode) == 0 {
+		return 0, fmt.Errorf("felix reports attach flags %#x on %s but the kernel reports mode %v",
+			st.AttachFlags, iface, mode)
+	}
+	return st.AttachFlags, nil
+}
@@ -16,6 +16,7 @@ package calc
 
 import (
 	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -60,6 +61,10 @@ var (
 		Name: "felix_calc_graph_update_time_seconds",
 		Help: "Seconds to update calculation graph for each datastore OnUpdate call.",
 	})
+	gaugeProcessedRevision = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "felix_calc_graph_processed_revision",
+		Help: "Highest datastore revision that the calculation graph has processed and flushed to the dataplane.",
+	})
 )
 
 func init() {
@@ -68,6 +73,7 @@ func init() {
 	prometheus.MustRegister(countUpdatesProcessed)
 	prometheus.MustRegister(countOutputEvents)
 	prometheus.MustRegister(summaryUpdateTime)
+	prometheus.MustRegister(gaugeProcessedRevision)
 }
 
 type AsyncCalcGraph struct {
@@ -80,6 +86,10 @@ type AsyncCalcGraph struct {
 	syncStatusNow    api.SyncStatus
 	healthAggregator *health.HealthAggregator
 
+	// highestRevision is the highest numeric datastore revision seen so far.  It is
+	// published once the updates that it covers have been flushed.
+	highestRevision uint64
+
 	flushTicks       <-chan time.Time
 	healthTicks      <-chan time.Time
 	flushLeakyBucket int
@@ -154,6 +164,7 @@ func (acg *AsyncCalcGraph) loop() {
 					typeName := reflect.TypeOf(upd.Key).Name()
 					count := countUpdatesProcessed.WithLabelValues(typeName)
 					count.Inc()
+					acg.recordRevision(upd.Revision)
 					acg.reportHealth()
 				}
 			case api.SyncStatus:
@@ -193,6 +204,18 @@ func (acg *AsyncCalcGraph) loop() {
 	}
 }
 
+// recordRevision notes the revision of a datastore update.  Revisions that aren't plain numbers
+// (such as the composite revisions of some Kubernetes-backed resources) are ignored.
+func (acg *AsyncCalcGraph) recordRevision(revision string) {
+	rev, err := strconv.ParseUint(revision, 10, 64)
+	if err != nil {
+		return
+	}
+	if rev > acg.highestRevision {
+		acg.highestRevision = rev
+	}
+}
+
 func (acg *AsyncCalcGraph) reportHealth() {
 	if acg.healthAggregator != nil {
 		acg.healthAggregator.Report(healthName, &health.HealthReport{
@@ -221,6 +244,7 @@ func (acg *AsyncCalcGraph) maybeFlush() {
 			acg.onEvent(&proto.InSync{})
 			acg.needToSendInSync = false
 		}
+		gaugeProcessedRevision.Set(float64(acg.highestRevision))
 		acg.dirty = false
 	} else {
 		log.Debug("Throttled: not flushing event buffer")
@@ -0,0 +1,30 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calc
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AsyncCalcGraph processed revision", func() {
+	It("should track the highest numeric revision", func() {
+		acg := &AsyncCalcGraph{}
+		for _, rev := range []string{"10", "12", "11", "", "5/7"} {
+			acg.recordRevision(rev)
+		}
+		Expect(acg.highestRevision).To(Equal(uint64(12)))
+	})
+})
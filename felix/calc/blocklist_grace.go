@@ -0,0 +1,97 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calc
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/dispatcher"
+	"github.com/projectcalico/calico/felix/proto"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
+)
+
+// BlocklistGraceIndex tracks the GlobalNetworkSet entries that are in their grace period and
+// sends the dataplane each CIDR that is in a grace period in at least one network set, so that
+// the XDP program can count the packets from it.  It relies on the NetworkSetExpiryFilter,
+// which holds such entries back from Nets, to leave only the entries that are still in their
+// grace period in NetGraceUntil, and to re-send the network set when a grace period ends.
+type BlocklistGraceIndex struct {
+	callbacks blocklistGraceCallbacks
+
+	// netSetsByCIDR maps each CIDR to the names of the network sets that have it in its grace
+	// period.
+	netSetsByCIDR map[string]set.Set[string]
+	// cidrsByNetSet maps each network set name to the CIDRs that it has in their grace period.
+	cidrsByNetSet map[string][]string
+}
+
+func NewBlocklistGraceIndex(callbacks blocklistGraceCallbacks) *BlocklistGraceIndex {
+	return &BlocklistGraceIndex{
+		callbacks:     callbacks,
+		netSetsByCIDR: map[string]set.Set[string]{},
+		cidrsByNetSet: map[string][]string{},
+	}
+}
+
+func (idx *BlocklistGraceIndex) RegisterWith(d *dispatcher.Dispatcher) {
+	d.Register(model.NetworkSetKey{}, idx.OnUpdate)
+}
+
+func (idx *BlocklistGraceIndex) OnUpdate(update api.Update) (filterOut bool) {
+	// This type assertion is safe because we only registered for NetworkSet updates.
+	name := update.Key.(model.NetworkSetKey).Name
+
+	var cidrs []string
+	if netSet, ok := update.Value.(*model.NetworkSet); ok {
+		for cidr := range netSet.NetGraceUntil {
+			cidrs = append(cidrs, cidr)
+		}
+	}
+
+	// Add the new CIDRs before removing the old ones so that a CIDR that stays in its grace
+	// period isn't removed and re-added.
+	for _, cidr := range cidrs {
+		netSets := idx.netSetsByCIDR[cidr]
+		if netSets == nil {
+			netSets = set.New[string]()
+			idx.netSetsByCIDR[cidr] = netSets
+			log.WithField("cidr", cidr).Debug("Blocklist entry entered its grace period")
+			idx.callbacks.OnBlocklistGraceUpdate(&proto.BlocklistGraceUpdate{Cidr: cidr})
+		}
+		netSets.Add(name)
+	}
+	newCIDRs := set.FromArray(cidrs)
+	for _, cidr := range idx.cidrsByNetSet[name] {
+		if newCIDRs.Contains(cidr) {
+			continue
+		}
+		netSets := idx.netSetsByCIDR[cidr]
+		netSets.Discard(name)
+		if netSets.Len() == 0 {
+			delete(idx.netSetsByCIDR, cidr)
+			log.WithField("cidr", cidr).Debug("Blocklist entry no longer in its grace period")
+			idx.callbacks.OnBlocklistGraceRemove(cidr)
+		}
+	}
+
+	if len(cidrs) > 0 {
+		idx.cidrsByNetSet[name] = cidrs
+	} else {
+		delete(idx.cidrsByNetSet, name)
+	}
+	return
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calc_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/calc"
+	"github.com/projectcalico/calico/felix/proto"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
+)
+
+type blocklistGraceRecorder struct {
+	cidrs   set.Set[string]
+	numMsgs int
+}
+
+func (r *blocklistGraceRecorder) OnBlocklistGraceUpdate(update *proto.BlocklistGraceUpdate) {
+	Expect(r.cidrs.Contains(update.Cidr)).To(BeFalse(), "duplicate update for CIDR in grace period")
+	r.cidrs.Add(update.Cidr)
+	r.numMsgs++
+}
+
+func (r *blocklistGraceRecorder) OnBlocklistGraceRemove(cidr string) {
+	Expect(r.cidrs.Contains(cidr)).To(BeTrue(), "remove for CIDR not in grace period")
+	r.cidrs.Discard(cidr)
+	r.numMsgs++
+}
+
+var _ = Describe("BlocklistGraceIndex", func() {
+	var (
+		recorder *blocklistGraceRecorder
+		idx      *calc.BlocklistGraceIndex
+	)
+
+	graceUntil := time.Now().Add(time.Hour)
+
+	sendNetSet := func(name string, cidrs ...string) {
+		grace := map[string]time.Time{}
+		for _, cidr := range cidrs {
+			grace[cidr] = graceUntil
+		}
+		idx.OnUpdate(api.Update{KVPair: model.KVPair{
+			Key:   model.NetworkSetKey{Name: name},
+			Value: &model.NetworkSet{NetGraceUntil: grace},
+		}})
+	}
+	deleteNetSet := func(name string) {
+		idx.OnUpdate(api.Update{KVPair: model.KVPair{Key: model.NetworkSetKey{Name: name}}})
+	}
+
+	BeforeEach(func() {
+		recorder = &blocklistGraceRecorder{cidrs: set.New[string]()}
+		idx = calc.NewBlocklistGraceIndex(recorder)
+	})
+
+	It("should send the CIDRs that are in their grace period", func() {
+		sendNetSet("feed", "10.0.0.1/32", "10.0.1.0/24")
+		Expect(recorder.cidrs).To(Equal(set.From("10.0.0.1/32", "10.0.1.0/24")))
+
+		sendNetSet("feed", "10.0.1.0/24")
+		Expect(recorder.cidrs).To(Equal(set.From("10.0.1.0/24")))
+		Expect(recorder.numMsgs).To(Equal(3))
+
+		deleteNetSet("feed")
+		Expect(recorder.cidrs.Len()).To(BeZero())
+	})
+
+	It("should keep a CIDR until no network set has it in its grace period", func() {
+		sendNetSet("feed-a", "10.0.0.1/32")
+		sendNetSet("feed-b", "10.0.0.1/32")
+		Expect(recorder.numMsgs).To(Equal(1))
+
+		deleteNetSet("feed-a")
+		Expect(recorder.cidrs).To(Equal(set.From("10.0.0.1/32")))
+
+		sendNetSet("feed-b")
+		Expect(recorder.cidrs.Len()).To(BeZero())
+		Expect(recorder.numMsgs).To(Equal(2))
+	})
+})
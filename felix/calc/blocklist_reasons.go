@@ -0,0 +1,126 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calc
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/dispatcher"
+	"github.com/projectcalico/calico/felix/proto"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
+)
+
+// BlocklistReasonIndex tracks the reasons that GlobalNetworkSets give for their entries and
+// sends the dataplane the reason for each CIDR, so that it can be recorded alongside the XDP
+// blocklist for auditing.  Only the entries that are in the network set's Nets count; when an
+// entry expires its reason goes with it.  If more than one network set gives a reason for the
+// same CIDR, the reason given by the network set with the lowest name is used.
+type BlocklistReasonIndex struct {
+	callbacks blocklistReasonCallbacks
+
+	// reasonsByCIDR maps each CIDR to the reasons given for it, keyed by network set name.
+	reasonsByCIDR map[string]map[string]string
+	// cidrsByNetSet maps each network set name to the CIDRs that it gives reasons for.
+	cidrsByNetSet map[string][]string
+	// sentReasons holds the reason that was last sent for each CIDR.
+	sentReasons map[string]string
+}
+
+func NewBlocklistReasonIndex(callbacks blocklistReasonCallbacks) *BlocklistReasonIndex {
+	return &BlocklistReasonIndex{
+		callbacks:     callbacks,
+		reasonsByCIDR: map[string]map[string]string{},
+		cidrsByNetSet: map[string][]string{},
+		sentReasons:   map[string]string{},
+	}
+}
+
+func (idx *BlocklistReasonIndex) RegisterWith(d *dispatcher.Dispatcher) {
+	d.Register(model.NetworkSetKey{}, idx.OnUpdate)
+}
+
+func (idx *BlocklistReasonIndex) OnUpdate(update api.Update) (filterOut bool) {
+	// This type assertion is safe because we only registered for NetworkSet updates.
+	name := update.Key.(model.NetworkSetKey).Name
+
+	dirtyCIDRs := set.New[string]()
+	for _, cidr := range idx.cidrsByNetSet[name] {
+		delete(idx.reasonsByCIDR[cidr], name)
+		if len(idx.reasonsByCIDR[cidr]) == 0 {
+			delete(idx.reasonsByCIDR, cidr)
+		}
+		dirtyCIDRs.Add(cidr)
+	}
+	delete(idx.cidrsByNetSet, name)
+
+	if netSet, ok := update.Value.(*model.NetworkSet); ok && len(netSet.NetReasons) > 0 {
+		var cidrs []string
+		for _, n := range netSet.Nets {
+			cidr := n.String()
+			reason, ok := netSet.NetReasons[cidr]
+			if !ok {
+				continue
+			}
+			if idx.reasonsByCIDR[cidr] == nil {
+				idx.reasonsByCIDR[cidr] = map[string]string{}
+			}
+			idx.reasonsByCIDR[cidr][name] = reason
+			cidrs = append(cidrs, cidr)
+			dirtyCIDRs.Add(cidr)
+		}
+		if len(cidrs) > 0 {
+			idx.cidrsByNetSet[name] = cidrs
+		}
+	}
+
+	dirtyCIDRs.Iter(func(cidr string) error {
+		idx.sendReason(cidr)
+		return nil
+	})
+	return
+}
+
+// sendReason sends the current reason for the given CIDR, or its removal, if it differs from
+// what was sent before.
+func (idx *BlocklistReasonIndex) sendReason(cidr string) {
+	reason, ok := idx.reasonFor(cidr)
+	sentReason, sent := idx.sentReasons[cidr]
+	if !ok {
+		if sent {
+			log.WithField("cidr", cidr).Debug("Blocklist entry no longer has a reason")
+			delete(idx.sentReasons, cidr)
+			idx.callbacks.OnBlocklistReasonRemove(cidr)
+		}
+		return
+	}
+	if sent && sentReason == reason {
+		return
+	}
+	log.WithFields(log.Fields{"cidr": cidr, "reason": reason}).Debug("Blocklist entry reason updated")
+	idx.sentReasons[cidr] = reason
+	idx.callbacks.OnBlocklistReasonUpdate(&proto.BlocklistReasonUpdate{Cidr: cidr, Reason: reason})
+}
+
+func (idx *BlocklistReasonIndex) reasonFor(cidr string) (reason string, ok bool) {
+	var bestName string
+	for name, r := range idx.reasonsByCIDR[cidr] {
+		if !ok || name < bestName {
+			bestName, reason, ok = name, r, true
+		}
+	}
+	return
+}
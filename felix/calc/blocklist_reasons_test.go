@@ -0,0 +1,117 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calc_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/calc"
+	"github.com/projectcalico/calico/felix/proto"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/calico/libcalico-go/lib/net"
+)
+
+type blocklistReasonRecorder struct {
+	reasons map[string]string
+	numMsgs int
+}
+
+func (r *blocklistReasonRecorder) OnBlocklistReasonUpdate(update *proto.BlocklistReasonUpdate) {
+	r.reasons[update.Cidr] = update.Reason
+	r.numMsgs++
+}
+
+func (r *blocklistReasonRecorder) OnBlocklistReasonRemove(cidr string) {
+	Expect(r.reasons).To(HaveKey(cidr), "remove for CIDR without a reason")
+	delete(r.reasons, cidr)
+	r.numMsgs++
+}
+
+var _ = Describe("BlocklistReasonIndex", func() {
+	var (
+		recorder *blocklistReasonRecorder
+		idx      *calc.BlocklistReasonIndex
+	)
+
+	cidr1 := net.MustParseCIDR("10.0.0.1/32")
+	cidr2 := net.MustParseCIDR("10.0.1.0/24")
+
+	sendNetSet := func(name string, nets []net.IPNet, reasons map[string]string) {
+		update := api.Update{KVPair: model.KVPair{Key: model.NetworkSetKey{Name: name}}}
+		if nets != nil {
+			update.Value = &model.NetworkSet{Nets: nets, NetReasons: reasons}
+		}
+		idx.OnUpdate(update)
+	}
+
+	BeforeEach(func() {
+		recorder = &blocklistReasonRecorder{reasons: map[string]string{}}
+		idx = calc.NewBlocklistReasonIndex(recorder)
+	})
+
+	It("should send the reasons for entries in the network set", func() {
+		sendNetSet("feed", []net.IPNet{cidr1, cidr2}, map[string]string{
+			cidr1.String(): "threat-feed-x",
+		})
+		Expect(recorder.reasons).To(Equal(map[string]string{"10.0.0.1/32": "threat-feed-x"}))
+	})
+
+	It("should ignore reasons for CIDRs that aren't in Nets", func() {
+		sendNetSet("feed", []net.IPNet{cidr2}, map[string]string{
+			cidr1.String(): "threat-feed-x",
+		})
+		Expect(recorder.reasons).To(BeEmpty())
+		Expect(recorder.numMsgs).To(BeZero())
+	})
+
+	It("should remove the reasons when the network set is deleted", func() {
+		sendNetSet("feed", []net.IPNet{cidr1, cidr2}, map[string]string{
+			cidr1.String(): "threat-feed-x",
+			cidr2.String(): "threat-feed-y",
+		})
+		sendNetSet("feed", nil, nil)
+		Expect(recorder.reasons).To(BeEmpty())
+	})
+
+	It("should update a changed reason and remove one whose entry has gone", func() {
+		sendNetSet("feed", []net.IPNet{cidr1, cidr2}, map[string]string{
+			cidr1.String(): "threat-feed-x",
+			cidr2.String(): "threat-feed-y",
+		})
+		sendNetSet("feed", []net.IPNet{cidr1}, map[string]string{
+			cidr1.String(): "threat-feed-z",
+			cidr2.String(): "threat-feed-y",
+		})
+		Expect(recorder.reasons).To(Equal(map[string]string{"10.0.0.1/32": "threat-feed-z"}))
+	})
+
+	It("should not resend an unchanged reason", func() {
+		reasons := map[string]string{cidr1.String(): "threat-feed-x"}
+		sendNetSet("feed", []net.IPNet{cidr1}, reasons)
+		sendNetSet("feed", []net.IPNet{cidr1, cidr2}, reasons)
+		Expect(recorder.numMsgs).To(Equal(1))
+	})
+
+	It("should use the reason from the network set with the lowest name", func() {
+		sendNetSet("b-feed", []net.IPNet{cidr1}, map[string]string{cidr1.String(): "threat-feed-b"})
+		sendNetSet("a-feed", []net.IPNet{cidr1}, map[string]string{cidr1.String(): "threat-feed-a"})
+		Expect(recorder.reasons).To(Equal(map[string]string{"10.0.0.1/32": "threat-feed-a"}))
+
+		sendNetSet("a-feed", nil, nil)
+		Expect(recorder.reasons).To(Equal(map[string]string{"10.0.0.1/32": "threat-feed-b"}))
+	})
+})
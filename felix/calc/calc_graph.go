@@ -98,6 +98,16 @@ type vxlanCallbacks interface {
 	OnVTEPRemove(node string)
 }
 
+type blocklistReasonCallbacks interface {
+	OnBlocklistReasonUpdate(update *proto.BlocklistReasonUpdate)
+	OnBlocklistReasonRemove(cidr string)
+}
+
+type blocklistGraceCallbacks interface {
+	OnBlocklistGraceUpdate(update *proto.BlocklistGraceUpdate)
+	OnBlocklistGraceRemove(cidr string)
+}
+
 type PipelineCallbacks interface {
 	ipSetUpdateCallbacks
 	rulesUpdateCallbacks
@@ -107,6 +117,8 @@ type PipelineCallbacks interface {
 	passthruCallbacks
 	routeCallbacks
 	vxlanCallbacks
+	blocklistReasonCallbacks
+	blocklistGraceCallbacks
 }
 
 type CalcGraph struct {
@@ -422,6 +434,40 @@ func NewCalculationGraph(callbacks PipelineCallbacks, conf *config.Config, liveC
 	encapsulationResolver := NewEncapsulationResolver(conf, callbacks)
 	encapsulationResolver.RegisterWith(allUpdDispatcher)
 
+	// The blocklist reason index sends the dataplane the reasons that GlobalNetworkSets give
+	// for their entries, so that they can be recorded alongside the XDP blocklists.
+	//
+	//        ...
+	//     Dispatcher (all updates)
+	//         |
+	//         | network sets
+	//         |
+	//       blocklist reason index
+	//         |
+	//         | reason per CIDR
+	//         |
+	//      <dataplane>
+	//
+	blocklistReasonIndex := NewBlocklistReasonIndex(callbacks)
+	blocklistReasonIndex.RegisterWith(allUpdDispatcher)
+
+	// The blocklist grace index sends the dataplane the GlobalNetworkSet entries that are in
+	// their grace period, so that the XDP program can count, but pass, the packets from them.
+	//
+	//        ...
+	//     Dispatcher (all updates)
+	//         |
+	//         | network sets
+	//         |
+	//       blocklist grace index
+	//         |
+	//         | CIDRs in their grace period
+	//         |
+	//      <dataplane>
+	//
+	blocklistGraceIndex := NewBlocklistGraceIndex(callbacks)
+	blocklistGraceIndex.RegisterWith(allUpdDispatcher)
+
 	return &CalcGraph{
 		AllUpdDispatcher:      allUpdDispatcher,
 		activeRulesCalculator: activeRulesCalc,
@@ -83,6 +83,10 @@ type EventSequencer struct {
 	pendingGlobalBGPConfig       *proto.GlobalBGPConfigUpdate
 	pendingServiceUpdates        map[serviceID]*proto.ServiceUpdate
 	pendingServiceDeletes        set.Set[serviceID]
+	pendingReasonUpdates         map[string]*proto.BlocklistReasonUpdate
+	pendingReasonDeletes         set.Set[string]
+	pendingGraceUpdates          map[string]*proto.BlocklistGraceUpdate
+	pendingGraceDeletes          set.Set[string]
 
 	// Sets to record what we've sent downstream. Updated whenever we flush.
 	sentIPSets          set.Set[string]
@@ -100,6 +104,8 @@ type EventSequencer struct {
 	sentWireguard       set.Set[string]
 	sentWireguardV6     set.Set[string]
 	sentServices        set.Set[serviceID]
+	sentReasons         set.Set[string]
+	sentGrace           set.Set[string]
 
 	Callback EventHandler
 }
@@ -161,6 +167,10 @@ func NewEventSequencer(conf configInterface) *EventSequencer {
 		pendingWireguardDeletes:      set.New[string](),
 		pendingServiceUpdates:        map[serviceID]*proto.ServiceUpdate{},
 		pendingServiceDeletes:        set.New[serviceID](),
+		pendingReasonUpdates:         map[string]*proto.BlocklistReasonUpdate{},
+		pendingReasonDeletes:         set.New[string](),
+		pendingGraceUpdates:          map[string]*proto.BlocklistGraceUpdate{},
+		pendingGraceDeletes:          set.New[string](),
 
 		// Sets to record what we've sent downstream. Updated whenever we flush.
 		sentIPSets:          set.New[string](),
@@ -178,6 +188,8 @@ func NewEventSequencer(conf configInterface) *EventSequencer {
 		sentWireguard:       set.New[string](),
 		sentWireguardV6:     set.New[string](),
 		sentServices:        set.New[serviceID](),
+		sentReasons:         set.New[string](),
+		sentGrace:           set.New[string](),
 	}
 	return buf
 }
@@ -419,6 +431,7 @@ func ModelHostEndpointToProto(ep *model.HostEndpoint, tiers, untrackedTiers, pre
 		UntrackedTiers:    untrackedTiers,
 		PreDnatTiers:      preDNATTiers,
 		ForwardTiers:      forwardTiers,
+		XdpMode:           ep.XDPMode,
 	}
 }
 
@@ -846,6 +859,8 @@ func (buf *EventSequencer) Flush() {
 	}
 
 	buf.flushServices()
+	buf.flushBlocklistReasons()
+	buf.flushBlocklistGrace()
 }
 
 func (buf *EventSequencer) flushRemovedIPSets() {
@@ -1206,3 +1221,70 @@ func natsToProtoNatInfo(nats []model.IPNAT) []*proto.NatInfo {
 	}
 	return protoNats
 }
+
+func (buf *EventSequencer) OnBlocklistReasonUpdate(update *proto.BlocklistReasonUpdate) {
+	log.WithFields(log.Fields{
+		"cidr":   update.Cidr,
+		"reason": update.Reason,
+	}).Debug("Blocklist reason update")
+	buf.pendingReasonDeletes.Discard(update.Cidr)
+	buf.pendingReasonUpdates[update.Cidr] = update
+}
+
+func (buf *EventSequencer) OnBlocklistReasonRemove(cidr string) {
+	log.WithField("cidr", cidr).Debug("Blocklist reason remove")
+	delete(buf.pendingReasonUpdates, cidr)
+	if buf.sentReasons.Contains(cidr) {
+		buf.pendingReasonDeletes.Add(cidr)
+	}
+}
+
+func (buf *EventSequencer) flushBlocklistReasons() {
+	// Order doesn't matter, but send removes first to reduce max occupancy
+	buf.pendingReasonDeletes.Iter(func(cidr string) error {
+		buf.Callback(&proto.BlocklistReasonRemove{Cidr: cidr})
+		buf.sentReasons.Discard(cidr)
+		return nil
+	})
+	buf.pendingReasonDeletes.Clear()
+	for cidr, msg := range buf.pendingReasonUpdates {
+		buf.Callback(msg)
+		buf.sentReasons.Add(cidr)
+	}
+	buf.pendingReasonUpdates = make(map[string]*proto.BlocklistReasonUpdate)
+	log.Debug("Done flushing blocklist reasons")
+}
+
+func (buf *EventSequencer) OnBlocklistGraceUpdate(update *proto.BlocklistGraceUpdate) {
+	log.WithField("cidr", update.Cidr).Debug("Blocklist grace update")
+	buf.pendingGraceDeletes.Discard(update.Cidr)
+	buf.pendingGraceUpdates[update.Cidr] = update
+}
+
+func (buf *EventSequencer) OnBlocklistGraceRemove(cidr string) {
+	log.WithField("cidr", cidr).Debug("Blocklist grace remove")
+	delete(buf.pendingGraceUpdates, cidr)
+	if buf.sentGrace.Contains(cidr) {
+		buf.pendingGraceDeletes.Add(cidr)
+	}
+}
+
+func (buf *EventSequencer) flushBlocklistGrace() {
+	// Order doesn't matter, but send removes first to reduce max occupancy
+	buf.pendingGraceDeletes.Iter(func(cidr string) error {
+		buf.Callback(&proto.BlocklistGraceRemove{Cidr: cidr})
+		buf.sentGrace.Discard(cidr)
+		return nil
+	})
+	buf.pendingGraceDeletes.Clear()
+	for cidr, msg := range buf.pendingGraceUpdates {
+		if buf.sentGrace.Contains(cidr) {
+			// Grace updates carry nothing but the CIDR, so there's no need to resend them.
+			continue
+		}
+		buf.Callback(msg)
+		buf.sentGrace.Add(cidr)
+	}
+	buf.pendingGraceUpdates = make(map[string]*proto.BlocklistGraceUpdate)
+	log.Debug("Done flushing blocklist grace periods")
+}
@@ -167,6 +167,7 @@ var _ = DescribeTable("ModelHostEndpointToProto",
 				"a": "b",
 			},
 			ProfileIDs: []string{"prof1"},
+			XDPMode:    "Generic",
 		},
 		[]*proto.TierInfo{{Name: "a", IngressPolicies: []string{"b", "c"}}},
 		[]*proto.TierInfo{{Name: "d", IngressPolicies: []string{"e", "f"}}},
@@ -179,6 +180,7 @@ var _ = DescribeTable("ModelHostEndpointToProto",
 			UntrackedTiers:    []*proto.TierInfo{{Name: "d", IngressPolicies: []string{"e", "f"}}},
 			ForwardTiers:      []*proto.TierInfo{{Name: "g", IngressPolicies: []string{"h", "i"}}},
 			ProfileIds:        []string{"prof1"},
+			XdpMode:           "Generic",
 		},
 	),
 	Entry("fully loaded endpoint with policies in same tier",
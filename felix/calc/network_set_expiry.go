@@ -0,0 +1,174 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/calico/libcalico-go/lib/net"
+)
+
+// NetworkSetExpiryFilter sits between the syncer and the calculation graph.  It removes entries
+// that have passed their expiry time from NetworkSets and, when an entry expires, it re-sends
+// the NetworkSet so that the entry is removed from the IP sets (and hence the XDP blocklists)
+// without the datastore needing to change.
+//
+// Similarly, it holds back entries that are still in their grace period: they're moved from
+// Nets to NetGraceUntil (which, downstream of the filter, only contains entries that are in
+// their grace period) and the NetworkSet is re-sent when the grace period ends.
+type NetworkSetExpiryFilter struct {
+	sink api.SyncerCallbacks
+
+	// lock protects the fields below and serialises calls to the sink, which may be made
+	// from the timer's goroutine.
+	lock    sync.Mutex
+	netSets map[model.NetworkSetKey]*expiringNetworkSet
+	timer   *time.Timer
+	now     func() time.Time
+}
+
+func NewNetworkSetExpiryFilter(sink api.SyncerCallbacks) *NetworkSetExpiryFilter {
+	return &NetworkSetExpiryFilter{
+		sink:    sink,
+		netSets: map[model.NetworkSetKey]*expiringNetworkSet{},
+		now:     time.Now,
+	}
+}
+
+// expiringNetworkSet tracks a NetworkSet that has entries that are yet to expire or to come
+// out of their grace period.
+type expiringNetworkSet struct {
+	value *model.NetworkSet
+	// nextChange is the earliest expiry or grace period end that hasn't passed yet as of the
+	// last time we sent the NetworkSet downstream.
+	nextChange time.Time
+}
+
+func (f *NetworkSetExpiryFilter) OnStatusUpdated(status api.SyncStatus) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.sink.OnStatusUpdated(status)
+}
+
+func (f *NetworkSetExpiryFilter) OnUpdates(updates []api.Update) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	filteredUpdates := make([]api.Update, len(updates))
+	for i, update := range updates {
+		if key, ok := update.Key.(model.NetworkSetKey); ok {
+			delete(f.netSets, key)
+			if netSet, ok := update.Value.(*model.NetworkSet); ok && (len(netSet.NetExpiries) > 0 || len(netSet.NetGraceUntil) > 0) {
+				ens := &expiringNetworkSet{value: netSet}
+				update.Value = ens.filter(f.now())
+				if !ens.nextChange.IsZero() {
+					f.netSets[key] = ens
+				}
+			}
+		}
+		filteredUpdates[i] = update
+	}
+	f.sink.OnUpdates(filteredUpdates)
+	f.resetTimer()
+}
+
+func (f *NetworkSetExpiryFilter) onTimer() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	now := f.now()
+	var updates []api.Update
+	for key, ens := range f.netSets {
+		if ens.nextChange.After(now) {
+			continue
+		}
+		logrus.WithField("networkSet", key.Name).Info("Entries in network set expired or came out of their grace period.")
+		updates = append(updates, api.Update{
+			KVPair: model.KVPair{
+				Key:   key,
+				Value: ens.filter(now),
+			},
+			UpdateType: api.UpdateTypeKVUpdated,
+		})
+		if ens.nextChange.IsZero() {
+			delete(f.netSets, key)
+		}
+	}
+	if len(updates) > 0 {
+		f.sink.OnUpdates(updates)
+	}
+	f.resetTimer()
+}
+
+// resetTimer (re)schedules the timer for the earliest pending expiry or grace period end.  Must be called with
+// the lock held.
+func (f *NetworkSetExpiryFilter) resetTimer() {
+	if f.timer != nil {
+		// If the timer has already fired, onTimer is waiting for the lock; it does no harm
+		// since it only re-sends NetworkSets that have entries that have changed state.
+		f.timer.Stop()
+		f.timer = nil
+	}
+	var next time.Time
+	for _, ens := range f.netSets {
+		if next.IsZero() || ens.nextChange.Before(next) {
+			next = ens.nextChange
+		}
+	}
+	if next.IsZero() {
+		return
+	}
+	f.timer = time.AfterFunc(next.Sub(f.now()), f.onTimer)
+}
+
+// filter returns a copy of the NetworkSet without the entries that have expired as of now, and
+// with the entries that are in their grace period moved from Nets to NetGraceUntil.  It updates
+// nextChange to the earliest of the remaining expiry and grace period end times (or zero if
+// there are none).
+func (ens *expiringNetworkSet) filter(now time.Time) *model.NetworkSet {
+	filtered := *ens.value
+	filtered.Nets = make([]net.IPNet, 0, len(ens.value.Nets))
+	filtered.NetGraceUntil = nil
+	ens.nextChange = time.Time{}
+	noteChange := func(t time.Time) {
+		if ens.nextChange.IsZero() || t.Before(ens.nextChange) {
+			ens.nextChange = t
+		}
+	}
+	for _, n := range ens.value.Nets {
+		expiry, ok := ens.value.NetExpiries[n.String()]
+		if ok && !expiry.After(now) {
+			continue
+		}
+		if ok {
+			noteChange(expiry)
+		}
+		if graceUntil, ok := ens.value.NetGraceUntil[n.String()]; ok && graceUntil.After(now) {
+			if filtered.NetGraceUntil == nil {
+				filtered.NetGraceUntil = map[string]time.Time{}
+			}
+			filtered.NetGraceUntil[n.String()] = graceUntil
+			noteChange(graceUntil)
+			continue
+		}
+		filtered.Nets = append(filtered.Nets, n)
+	}
+	return &filtered
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calc_test
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/calc"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/calico/libcalico-go/lib/net"
+)
+
+type lockedTestSyncer struct {
+	lock     sync.Mutex
+	received []api.Update
+}
+
+func (t *lockedTestSyncer) OnStatusUpdated(status api.SyncStatus) {
+}
+
+func (t *lockedTestSyncer) OnUpdates(updates []api.Update) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.received = append(t.received, updates...)
+}
+
+// lastNets returns the Nets of the last update that was received, or nil if there
+// were no updates.
+func (t *lockedTestSyncer) lastNets() []net.IPNet {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if len(t.received) == 0 {
+		return nil
+	}
+	return t.received[len(t.received)-1].Value.(*model.NetworkSet).Nets
+}
+
+func (t *lockedTestSyncer) numReceived() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.received)
+}
+
+var _ = Describe("NetworkSetExpiryFilter", func() {
+	var (
+		sink   *lockedTestSyncer
+		filter *calc.NetworkSetExpiryFilter
+	)
+
+	key := model.NetworkSetKey{Name: "blocklist"}
+	permanent := net.MustParseCIDR("10.0.0.0/24")
+	expiring := net.MustParseCIDR("10.0.1.1/32")
+
+	sendNetSet := func(expiry time.Time) {
+		filter.OnUpdates([]api.Update{{
+			KVPair: model.KVPair{
+				Key: key,
+				Value: &model.NetworkSet{
+					Nets: []net.IPNet{permanent, expiring},
+					NetExpiries: map[string]time.Time{
+						expiring.String(): expiry,
+					},
+				},
+			},
+			UpdateType: api.UpdateTypeKVNew,
+		}})
+	}
+
+	BeforeEach(func() {
+		sink = &lockedTestSyncer{}
+		filter = calc.NewNetworkSetExpiryFilter(sink)
+	})
+
+	It("should pass through network sets without expiries", func() {
+		netSet := &model.NetworkSet{Nets: []net.IPNet{permanent}}
+		filter.OnUpdates([]api.Update{{
+			KVPair:     model.KVPair{Key: key, Value: netSet},
+			UpdateType: api.UpdateTypeKVNew,
+		}})
+		Expect(sink.received).To(HaveLen(1))
+		Expect(sink.received[0].Value).To(BeIdenticalTo(netSet))
+	})
+
+	It("should remove entries that have already expired", func() {
+		sendNetSet(time.Now().Add(-time.Second))
+		Expect(sink.lastNets()).To(Equal([]net.IPNet{permanent}))
+	})
+
+	It("should remove entries when they expire", func() {
+		sendNetSet(time.Now().Add(200 * time.Millisecond))
+		Expect(sink.lastNets()).To(Equal([]net.IPNet{permanent, expiring}))
+
+		Eventually(sink.lastNets).Should(Equal([]net.IPNet{permanent}))
+		Expect(sink.numReceived()).To(Equal(2))
+		Consistently(sink.numReceived, "300ms").Should(Equal(2))
+	})
+
+	It("should not expire entries after the network set is deleted", func() {
+		sendNetSet(time.Now().Add(200 * time.Millisecond))
+		filter.OnUpdates([]api.Update{{
+			KVPair:     model.KVPair{Key: key},
+			UpdateType: api.UpdateTypeKVDeleted,
+		}})
+		Consistently(sink.numReceived, "400ms").Should(Equal(2))
+	})
+
+	Describe("with a grace period", func() {
+		sendGraceNetSet := func(graceUntil time.Time) {
+			filter.OnUpdates([]api.Update{{
+				KVPair: model.KVPair{
+					Key: key,
+					Value: &model.NetworkSet{
+						Nets: []net.IPNet{permanent, expiring},
+						NetGraceUntil: map[string]time.Time{
+							expiring.String(): graceUntil,
+						},
+					},
+				},
+				UpdateType: api.UpdateTypeKVNew,
+			}})
+		}
+		lastGrace := func() map[string]time.Time {
+			sink.lock.Lock()
+			defer sink.lock.Unlock()
+			return sink.received[len(sink.received)-1].Value.(*model.NetworkSet).NetGraceUntil
+		}
+
+		It("should hold back entries until their grace period ends", func() {
+			graceUntil := time.Now().Add(200 * time.Millisecond)
+			sendGraceNetSet(graceUntil)
+			Expect(sink.lastNets()).To(Equal([]net.IPNet{permanent}))
+			Expect(lastGrace()).To(Equal(map[string]time.Time{expiring.String(): graceUntil}))
+
+			Eventually(sink.lastNets).Should(Equal([]net.IPNet{permanent, expiring}))
+			Expect(lastGrace()).To(BeNil())
+			Expect(sink.numReceived()).To(Equal(2))
+			Consistently(sink.numReceived, "300ms").Should(Equal(2))
+		})
+
+		It("should include entries whose grace period has already ended", func() {
+			sendGraceNetSet(time.Now().Add(-time.Second))
+			Expect(sink.lastNets()).To(Equal([]net.IPNet{permanent, expiring}))
+			Expect(lastGrace()).To(BeNil())
+			Consistently(sink.numReceived, "300ms").Should(Equal(1))
+		})
+
+		It("should remove entries that expire during their grace period", func() {
+			filter.OnUpdates([]api.Update{{
+				KVPair: model.KVPair{
+					Key: key,
+					Value: &model.NetworkSet{
+						Nets: []net.IPNet{permanent, expiring},
+						NetExpiries: map[string]time.Time{
+							expiring.String(): time.Now().Add(200 * time.Millisecond),
+						},
+						NetGraceUntil: map[string]time.Time{
+							expiring.String(): time.Now().Add(time.Hour),
+						},
+					},
+				},
+				UpdateType: api.UpdateTypeKVNew,
+			}})
+			Expect(lastGrace()).To(HaveKey(expiring.String()))
+
+			Eventually(lastGrace).Should(BeNil())
+			Expect(sink.lastNets()).To(Equal([]net.IPNet{permanent}))
+		})
+	})
+
+	It("should use the expiry from the latest update", func() {
+		sendNetSet(time.Now().Add(200 * time.Millisecond))
+		sendNetSet(time.Now().Add(time.Hour))
+		Consistently(sink.numReceived, "400ms").Should(Equal(2))
+		Expect(sink.lastNets()).To(Equal([]net.IPNet{permanent, expiring}))
+	})
+})
@@ -0,0 +1,61 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calc
+
+import (
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+)
+
+// NetworkSetNodeFilter sits between the syncer and the calculation graph.  It treats NetworkSets
+// that are labelled with another node's name in the LabelNodeScope label as missing, so that,
+// for example, the automatic blocklist of one node doesn't apply on the others.
+type NetworkSetNodeFilter struct {
+	sink     api.SyncerCallbacks
+	hostname string
+}
+
+func NewNetworkSetNodeFilter(sink api.SyncerCallbacks, hostname string) *NetworkSetNodeFilter {
+	return &NetworkSetNodeFilter{
+		sink:     sink,
+		hostname: hostname,
+	}
+}
+
+func (f *NetworkSetNodeFilter) OnStatusUpdated(status api.SyncStatus) {
+	f.sink.OnStatusUpdated(status)
+}
+
+func (f *NetworkSetNodeFilter) OnUpdates(updates []api.Update) {
+	filteredUpdates := make([]api.Update, len(updates))
+	for i, update := range updates {
+		if key, ok := update.Key.(model.NetworkSetKey); ok {
+			if netSet, ok := update.Value.(*model.NetworkSet); ok {
+				if node, ok := netSet.Labels[apiv3.LabelNodeScope]; ok && node != f.hostname {
+					logrus.WithFields(logrus.Fields{
+						"networkSet": key.Name,
+						"node":       node,
+					}).Debug("Network set is scoped to another node; treating as missing")
+					update.Value = nil
+				}
+			}
+		}
+		filteredUpdates[i] = update
+	}
+	f.sink.OnUpdates(filteredUpdates)
+}
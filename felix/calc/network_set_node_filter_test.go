@@ -0,0 +1,81 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calc_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/calico/felix/calc"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/calico/libcalico-go/lib/net"
+)
+
+var _ = Describe("NetworkSetNodeFilter", func() {
+	var (
+		sink   *lockedTestSyncer
+		filter *calc.NetworkSetNodeFilter
+	)
+
+	key := model.NetworkSetKey{Name: "auto-blocklist.node1"}
+	sendNetSet := func(labels map[string]string) {
+		filter.OnUpdates([]api.Update{{
+			KVPair: model.KVPair{
+				Key: key,
+				Value: &model.NetworkSet{
+					Nets:   []net.IPNet{net.MustParseCIDR("10.0.0.1/32")},
+					Labels: labels,
+				},
+			},
+			UpdateType: api.UpdateTypeKVNew,
+		}})
+	}
+
+	BeforeEach(func() {
+		sink = &lockedTestSyncer{}
+		filter = calc.NewNetworkSetNodeFilter(sink, "node1")
+	})
+
+	It("should pass through network sets without the label", func() {
+		sendNetSet(map[string]string{"a": "b"})
+		Expect(sink.received).To(HaveLen(1))
+		Expect(sink.received[0].Value).NotTo(BeNil())
+	})
+
+	It("should pass through network sets scoped to this node", func() {
+		sendNetSet(map[string]string{apiv3.LabelNodeScope: "node1"})
+		Expect(sink.received).To(HaveLen(1))
+		Expect(sink.received[0].Value).NotTo(BeNil())
+	})
+
+	It("should treat network sets scoped to another node as missing", func() {
+		sendNetSet(map[string]string{apiv3.LabelNodeScope: "node2"})
+		Expect(sink.received).To(HaveLen(1))
+		Expect(sink.received[0].Key).To(Equal(key))
+		Expect(sink.received[0].Value).To(BeNil())
+	})
+
+	It("should pass through other updates", func() {
+		hepKey := model.HostEndpointKey{Hostname: "node2", EndpointID: "eth0"}
+		filter.OnUpdates([]api.Update{{
+			KVPair:     model.KVPair{Key: hepKey, Value: &model.HostEndpoint{}},
+			UpdateType: api.UpdateTypeKVNew,
+		}})
+		Expect(sink.received).To(HaveLen(1))
+		Expect(sink.received[0].Value).NotTo(BeNil())
+	})
+})
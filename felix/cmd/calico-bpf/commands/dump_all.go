@@ -0,0 +1,176 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/bpf/conntrack"
+	"github.com/projectcalico/calico/felix/bpf/maps"
+	"github.com/projectcalico/calico/felix/bpf/nat"
+	"github.com/projectcalico/calico/felix/bpf/routes"
+)
+
+func init() {
+	dumpAllCmd.Flags().String("out", "", "Directory to write the JSON files to")
+	_ = dumpAllCmd.MarkFlagRequired("out")
+	rootCmd.AddCommand(dumpAllCmd)
+}
+
+var dumpAllCmd = &cobra.Command{
+	Use: "dump-all",
+	Short: "writes the contents of each of Calico's BPF maps (conntrack, NAT, routes and " +
+		"the XDP blocklists) to a JSON file in the given directory, for offline analysis",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outDir, err := cmd.Flags().GetString("out")
+		if err != nil {
+			return err
+		}
+		return dumpAll(outDir)
+	},
+}
+
+// mapEntry is the JSON representation of one entry of a map.
+type mapEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type entryDecoder func(k, v []byte) mapEntry
+
+func dumpAll(outDir string) error {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+
+	pinnedMaps := []struct {
+		file   string
+		m      maps.Map
+		decode entryDecoder
+	}{
+		{"conntrack.json", conntrack.Map(), func(k, v []byte) mapEntry {
+			return mapEntry{conntrack.KeyFromBytes(k).String(), conntrack.ValueFromBytes(v).String()}
+		}},
+		{"nat_frontends.json", nat.FrontendMap(), func(k, v []byte) mapEntry {
+			var key nat.FrontendKey
+			var value nat.FrontendValue
+			copy(key[:], k)
+			copy(value[:], v)
+			return mapEntry{key.String(), value.String()}
+		}},
+		{"nat_backends.json", nat.BackendMap(), func(k, v []byte) mapEntry {
+			var key nat.BackendKey
+			var value nat.BackendValue
+			copy(key[:], k)
+			copy(value[:], v)
+			return mapEntry{key.String(), value.String()}
+		}},
+		{"routes.json", routes.Map(), func(k, v []byte) mapEntry {
+			var key routes.Key
+			var value routes.Value
+			copy(key[:], k)
+			copy(value[:], v)
+			return mapEntry{key.Dest().String(), value.String()}
+		}},
+	}
+
+	var failed []string
+	for _, pm := range pinnedMaps {
+		entries, err := readPinnedMap(pm.m, pm.decode)
+		if os.IsNotExist(err) {
+			// Not all the maps exist in all modes; the XDP blocklists are the only
+			// maps that we use outside of BPF mode.
+			log.WithField("map", pm.m.GetName()).Info("Map doesn't exist, skipping.")
+			continue
+		}
+		if err == nil {
+			err = writeEntries(filepath.Join(outDir, pm.file), entries)
+		}
+		if err != nil {
+			log.WithError(err).WithField("map", pm.m.GetName()).Error("Failed to dump map.")
+			failed = append(failed, pm.m.GetName())
+		}
+	}
+
+	if err := dumpXDPBlocklists(outDir); err != nil {
+		log.WithError(err).Error("Failed to dump XDP blocklist maps.")
+		failed = append(failed, "XDP blocklists")
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to dump some maps: %v", failed)
+	}
+	return nil
+}
+
+func readPinnedMap(m maps.Map, decode entryDecoder) ([]mapEntry, error) {
+	if err := m.Open(); err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	entries := []mapEntry{}
+	err := m.Iter(func(k, v []byte) maps.IteratorAction {
+		entries = append(entries, decode(k, v))
+		return maps.IterNone
+	})
+	return entries, err
+}
+
+// dumpXDPBlocklists dumps the per-interface blocklist maps that the XDP programs use in
+// iptables mode.
+func dumpXDPBlocklists(outDir string) error {
+	lib, err := bpf.NewBPFLib("/usr/lib/calico/bpf/")
+	if err != nil {
+		return err
+	}
+	ifaces, err := lib.ListCIDRMaps(bpf.IPFamilyV4)
+	if err != nil {
+		return err
+	}
+	for _, iface := range ifaces {
+		contents, err := lib.DumpCIDRMap(iface, bpf.IPFamilyV4)
+		if err != nil {
+			return err
+		}
+		entries := []mapEntry{}
+		for k, refCount := range contents {
+			entries = append(entries, mapEntry{k.ToIPNet().String(), fmt.Sprint(refCount)})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Key < entries[j].Key
+		})
+		if err := writeEntries(filepath.Join(outDir, fmt.Sprintf("xdp_blocklist_%s.json", iface)), entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeEntries(path string, entries []mapEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
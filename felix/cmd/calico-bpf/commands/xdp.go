@@ -0,0 +1,145 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/bpf/libbpf"
+	"github.com/projectcalico/calico/felix/bpf/ringbuf"
+)
+
+// xdpCmd represents the xdp command
+var xdpCmd = &cobra.Command{
+	Use:   "xdp",
+	Short: "Inspect the XDP programs",
+}
+
+func init() {
+	xdpEventsCmd.Flags().Duration("timeout", 5*time.Second, "How long to collect events for")
+	xdpCmd.AddCommand(xdpEventsCmd)
+	xdpTestLookupCmd.Flags().String("dst", "", "Destination IP of the test packet; defaults to the interface's first IPv4 address")
+	xdpCmd.AddCommand(xdpTestLookupCmd)
+	rootCmd.AddCommand(xdpCmd)
+}
+
+var xdpEventsCmd = &cobra.Command{
+	Use: "events",
+	Short: "prints the per-packet verdicts that the XDP programs report; " +
+		"requires the BPF programs to be running with BPFLogLevel=Debug",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			return err
+		}
+
+		r, err := ringbuf.OpenPinned(bpf.XDPEventsPinPath)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		deadline := time.Now().Add(timeout)
+		for {
+			for _, rec := range r.Read() {
+				e, err := bpf.XDPEventFromBytes(rec)
+				if err != nil {
+					log.WithError(err).Warn("Skipping bad XDP event.")
+					continue
+				}
+				fmt.Println(e)
+			}
+			if time.Now().After(deadline) {
+				return nil
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	},
+}
+
+var xdpTestLookupCmd = &cobra.Command{
+	Use: "test-lookup <iface> <ip>",
+	Short: "runs a packet from <ip> through the XDP program attached to <iface>, without sending it, " +
+		"and prints the program's verdict",
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		iface := args[0]
+		src := net.ParseIP(args[1])
+		if src == nil {
+			return fmt.Errorf("bad IP %q", args[1])
+		}
+		dstStr, err := cmd.Flags().GetString("dst")
+		if err != nil {
+			return err
+		}
+		var dst net.IP
+		if dstStr != "" {
+			dst = net.ParseIP(dstStr)
+			if dst == nil {
+				return fmt.Errorf("bad destination IP %q", dstStr)
+			}
+		} else if dst, err = firstIPv4Addr(iface); err != nil {
+			return err
+		}
+
+		pkt, err := bpf.XDPLookupPacket(src, dst)
+		if err != nil {
+			return err
+		}
+
+		progID, err := libbpf.GetXDPProgramID(iface)
+		if err != nil {
+			return err
+		}
+		if progID == 0 {
+			return fmt.Errorf("no XDP program attached to %s", iface)
+		}
+		fd, err := bpf.GetProgFDByID(progID)
+		if err != nil {
+			return fmt.Errorf("failed to get XDP program %d: %w", progID, err)
+		}
+		defer fd.Close()
+
+		res, err := bpf.RunBPFProgram(fd, pkt, 1)
+		if err != nil {
+			return fmt.Errorf("failed to run XDP program %d: %w", progID, err)
+		}
+		fmt.Println(bpf.XDPVerdict(res.RC))
+		return nil
+	},
+}
+
+func firstIPv4Addr(iface string) (net.IP, error) {
+	intf, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := intf.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range addrs {
+		if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+			return ipNet.IP, nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no IPv4 address; use --dst", iface)
+}
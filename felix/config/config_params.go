@@ -176,6 +176,7 @@ type Config struct {
 	BPFEnabled                         bool             `config:"bool;false"`
 	BPFDisableUnprivileged             bool             `config:"bool;true"`
 	BPFLogLevel                        string           `config:"oneof(off,info,debug);off;non-zero"`
+	XDPLogLevel                        string           `config:"oneof(off,error,debug);off;non-zero"`
 	BPFDataIfacePattern                *regexp.Regexp   `config:"regexp;^((en|wl|ww|sl|ib)[Popsx].*|(eth|wlan|wwan).*|tunl0$|vxlan.calico$|wireguard.cali$|wg-v6.cali$)"`
 	BPFL3IfacePattern                  *regexp.Regexp   `config:"regexp;"`
 	BPFConnectTimeLoadBalancingEnabled bool             `config:"bool;true"`
@@ -360,6 +361,7 @@ type Config struct {
 	DebugSimulateDataplaneHangAfter time.Duration `config:"seconds;0"`
 	DebugPanicAfter                 time.Duration `config:"seconds;0"`
 	DebugSimulateDataRace           bool          `config:"bool;false"`
+	DebugSimulateXDPLoadFailure     bool          `config:"bool;false"`
 
 	// Configure where Felix gets its routing information.
 	// - workloadIPs: use workload endpoints to construct routes.
@@ -373,9 +375,16 @@ type Config struct {
 
 	IptablesNATOutgoingInterfaceFilter string `config:"iface-param;"`
 
-	SidecarAccelerationEnabled bool `config:"bool;false"`
-	XDPEnabled                 bool `config:"bool;true"`
-	GenericXDPEnabled          bool `config:"bool;false"`
+	SidecarAccelerationEnabled bool          `config:"bool;false"`
+	XDPEnabled                 bool          `config:"bool;true"`
+	GenericXDPEnabled          bool          `config:"bool;false"`
+	XDPAutoBlocklistConnRate   int           `config:"int;0"`
+	XDPEventLog                string        `config:"file;;local"`
+	XDPEventsSocket            string        `config:"file;;local"`
+	XDPUpdateDebounce          time.Duration `config:"millis;0"`
+	XDPEgressBlocklistEnabled  bool          `config:"bool;false"`
+	XDPMaxBlocklistEntries     int           `config:"int(1,10240);10240"`
+	XDPMinInterfaceSpeed       int           `config:"int;0"`
 
 	Variant string `config:"string;Calico"`
 
@@ -56,6 +56,7 @@ import (
 	"github.com/projectcalico/calico/typha/pkg/discovery"
 	"github.com/projectcalico/calico/typha/pkg/syncclient"
 
+	"github.com/projectcalico/calico/felix/autoblocklist"
 	"github.com/projectcalico/calico/felix/buildinfo"
 	"github.com/projectcalico/calico/felix/calc"
 	"github.com/projectcalico/calico/felix/config"
@@ -626,8 +627,13 @@ configRetry:
 	}
 
 	// Create the validator, which sits between the syncer and the
-	// calculation graph.
-	validator := calc.NewValidationFilter(asyncCalcGraph, configParams)
+	// calculation graph.  Validated updates pass through the network set
+	// node filter, which hides network sets that are scoped to other nodes,
+	// and the network set expiry filter, which removes expired entries from
+	// network sets.
+	netSetExpiryFilter := calc.NewNetworkSetExpiryFilter(asyncCalcGraph)
+	netSetNodeFilter := calc.NewNetworkSetNodeFilter(netSetExpiryFilter, configParams.FelixHostname)
+	validator := calc.NewValidationFilter(netSetNodeFilter, configParams)
 
 	go syncerToValidator.SendTo(validator)
 	asyncCalcGraph.Start()
@@ -649,6 +655,12 @@ configRetry:
 		dpConnector.statusReporter.Start()
 	}
 
+	if configParams.XDPAutoBlocklistConnRate > 0 && !configParams.BPFEnabled {
+		log.WithField("connRate", configParams.XDPAutoBlocklistConnRate).Info(
+			"Automatic blocklisting enabled, starting blocklister")
+		autoblocklist.New(configParams.FelixHostname, v3Client.Nodes()).Start(ctx)
+	}
+
 	// Start communicating with the dataplane driver.
 	dpConnector.Start()
 
@@ -1179,6 +1191,8 @@ var handledConfigChanges = set.From(
 	"ClusterGUID",
 	"ClusterType",
 	"HealthTimeoutOverrides",
+	// The dataplane turns XDP acceleration on and off when it gets the ConfigUpdate.
+	"XDPEnabled",
 )
 
 func (fc *DataplaneConnector) sendMessagesToDataplaneDriver() {
@@ -276,6 +276,7 @@ func StartDataplaneDriver(configParams *config.Config,
 
 				FailsafeInboundHostPorts:  configParams.FailsafeInboundHostPorts,
 				FailsafeOutboundHostPorts: configParams.FailsafeOutboundHostPorts,
+				AutoBlocklistConnRate:     configParams.XDPAutoBlocklistConnRate,
 
 				DisableConntrackInvalid: configParams.DisableConntrackInvalidCheck,
 
@@ -346,6 +347,7 @@ func StartDataplaneDriver(configParams *config.Config,
 			HealthAggregator:                   healthAggregator,
 			WatchdogTimeout:                    configParams.DataplaneWatchdogTimeout,
 			DebugSimulateDataplaneHangAfter:    configParams.DebugSimulateDataplaneHangAfter,
+			DebugSimulateXDPLoadFailure:        configParams.DebugSimulateXDPLoadFailure,
 			ExternalNodesCidrs:                 configParams.ExternalNodesCIDRList,
 			SidecarAccelerationEnabled:         configParams.SidecarAccelerationEnabled,
 			BPFEnabled:                         configParams.BPFEnabled,
@@ -371,6 +373,13 @@ func StartDataplaneDriver(configParams *config.Config,
 			BPFEnforceRPF:                      configParams.BPFEnforceRPF,
 			XDPEnabled:                         configParams.XDPEnabled,
 			XDPAllowGeneric:                    configParams.GenericXDPEnabled,
+			XDPEventLog:                        configParams.XDPEventLog,
+			XDPEventsSocket:                    configParams.XDPEventsSocket,
+			XDPLogLevel:                        configParams.XDPLogLevel,
+			XDPUpdateDebounce:                  configParams.XDPUpdateDebounce,
+			XDPEgressBlocklistEnabled:          configParams.XDPEgressBlocklistEnabled,
+			XDPMaxBlocklistEntries:             configParams.XDPMaxBlocklistEntries,
+			XDPMinInterfaceSpeed:               configParams.XDPMinInterfaceSpeed,
 			BPFConntrackTimeouts:               conntrack.DefaultTimeouts(), // FIXME make timeouts configurable
 			RouteTableManager:                  routeTableIndexAllocator,
 			MTUIfacePattern:                    configParams.MTUIfacePattern,
@@ -250,6 +250,14 @@ func WrapPayloadWithEnvelope(msg interface{}, seqNo uint64) (*proto.ToDataplane,
 		envelope.Payload = &proto.ToDataplane_ServiceUpdate{ServiceUpdate: msg}
 	case *proto.ServiceRemove:
 		envelope.Payload = &proto.ToDataplane_ServiceRemove{ServiceRemove: msg}
+	case *proto.BlocklistReasonUpdate:
+		envelope.Payload = &proto.ToDataplane_BlocklistReasonUpdate{BlocklistReasonUpdate: msg}
+	case *proto.BlocklistReasonRemove:
+		envelope.Payload = &proto.ToDataplane_BlocklistReasonRemove{BlocklistReasonRemove: msg}
+	case *proto.BlocklistGraceUpdate:
+		envelope.Payload = &proto.ToDataplane_BlocklistGraceUpdate{BlocklistGraceUpdate: msg}
+	case *proto.BlocklistGraceRemove:
+		envelope.Payload = &proto.ToDataplane_BlocklistGraceRemove{BlocklistGraceRemove: msg}
 
 	default:
 		return nil, fmt.Errorf("Unknown message type: %T", msg)
@@ -190,6 +190,7 @@ type bpfEndpointManager struct {
 	dirtyIfaceNames set.Set[string]
 
 	bpfLogLevel             string
+	xdpLogLevel             string
 	hostname                string
 	hostIP                  net.IP
 	fibLookupEnabled        bool
@@ -301,6 +302,7 @@ func newBPFEndpointManager(
 		profilesToWorkloads:     map[proto.ProfileID]set.Set[any]{},
 		dirtyIfaceNames:         set.New[string](),
 		bpfLogLevel:             config.BPFLogLevel,
+		xdpLogLevel:             config.XDPLogLevel,
 		hostname:                config.Hostname,
 		fibLookupEnabled:        fibLookupEnabled,
 		dataIfaceRegex:          config.BPFDataIfacePattern,
@@ -416,6 +418,7 @@ func newBPFEndpointManager(
 		if err != nil {
 			log.WithError(err).Warn("Failed to iterate over policy counters map")
 		}
+		bpfRuleCounters.setMap(m.bpfmaps.RuleCountersMap)
 	}
 
 	return m, nil
@@ -764,6 +767,7 @@ func (m *bpfEndpointManager) removeDirtyPolicies() {
 	m.dirtyRules.Iter(func(item polprog.RuleMatchID) error {
 		binary.LittleEndian.PutUint64(b, item)
 		log.WithField("ruleId", item).Debug("deleting entry")
+		bpfRuleCounters.removeRule(item)
 		err := m.bpfmaps.RuleCountersMap.Delete(b)
 		if err != nil && !maps.IsNotExists(err) {
 			log.WithField("ruleId", item).Info("error deleting entry")
@@ -1358,9 +1362,10 @@ func (m *bpfEndpointManager) attachDataIfaceProgram(ifaceName string, ep *proto.
 
 func (m *bpfEndpointManager) attachXDPProgram(ifaceName string, ep *proto.HostEndpoint) error {
 	ap := &xdp.AttachPoint{
-		Iface:    ifaceName,
-		LogLevel: m.bpfLogLevel,
-		Modes:    m.xdpModes,
+		Iface:         ifaceName,
+		LogLevel:      m.bpfLogLevel,
+		EventLogLevel: m.xdpLogLevel,
+		Modes:         xdpModesWithOverride(ep.GetXdpMode(), m.xdpModes),
 	}
 
 	if ep != nil && len(ep.UntrackedTiers) == 1 {
@@ -2408,6 +2413,7 @@ func (m *bpfEndpointManager) addRuleInfo(rule *proto.Rule, idx int,
 
 	matchID := m.dp.ruleMatchID(direction.RuleDir(), rule.Action, owner, polName, idx)
 	m.dirtyRules.Discard(matchID)
+	bpfRuleCounters.addRule(matchID, polName, direction, idx, rule.Action)
 
 	return matchID
 }
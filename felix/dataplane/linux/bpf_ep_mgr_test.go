@@ -27,6 +27,8 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/projectcalico/calico/felix/environment"
@@ -701,6 +703,57 @@ var _ = Describe("BPF Endpoint Manager", func() {
 
 		})
 
+		It("should track rule labels for the rule counters metric", func() {
+			denyRule := &proto.Rule{Action: "Deny", RuleId: "INGRESSDENY12345"}
+			denyRuleMatchId := bpfEpMgr.dp.ruleMatchID("Ingress", "Deny", "Policy", "denyPol", 0)
+
+			bpfEpMgr.OnUpdate(&proto.ActivePolicyUpdate{
+				Id:     &proto.PolicyID{Tier: "default", Name: "denyPol"},
+				Policy: &proto.Policy{InboundRules: []*proto.Rule{denyRule}},
+			})
+			Expect(bpfRuleCounters.rules).To(HaveKeyWithValue(denyRuleMatchId, bpfRuleInfo{
+				policy: "denyPol",
+				rule:   "ingress-0",
+				action: "deny",
+			}))
+
+			bpfEpMgr.OnUpdate(&proto.ActivePolicyRemove{Id: &proto.PolicyID{Tier: "default", Name: "denyPol"}})
+			err := bpfEpMgr.CompleteDeferredWork()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bpfRuleCounters.rules).NotTo(HaveKey(denyRuleMatchId))
+		})
+
+		It("should report the packets that each policy's deny rules dropped", func() {
+			collector := newBPFRuleCountersCollector()
+			rcMap := bpfEpMgr.bpfmaps.RuleCountersMap
+			collector.setMap(rcMap)
+			setCount := func(id polprog.RuleMatchID, action string, idx int, count uint64) {
+				collector.addRule(id, "mixedPol", PolDirnIngress, idx, action)
+				k := make([]byte, 8)
+				v := make([]byte, 8*bpfmaps.NumPossibleCPUs())
+				binary.LittleEndian.PutUint64(k, id)
+				binary.LittleEndian.PutUint64(v, count)
+				Expect(rcMap.Update(k, v)).To(Succeed())
+			}
+			setCount(bpfEpMgr.dp.ruleMatchID("Ingress", "Deny", "Policy", "mixedPol", 0), "Deny", 0, 3)
+			setCount(bpfEpMgr.dp.ruleMatchID("Ingress", "Allow", "Policy", "mixedPol", 1), "Allow", 1, 100)
+			setCount(bpfEpMgr.dp.ruleMatchID("Ingress", "Deny", "Policy", "mixedPol", 2), "Deny", 2, 4)
+
+			ch := make(chan prometheus.Metric, 10)
+			collector.Collect(ch)
+			close(ch)
+			drops := map[string]float64{}
+			for m := range ch {
+				if m.Desc() != collector.dropDesc {
+					continue
+				}
+				var pb dto.Metric
+				Expect(m.Write(&pb)).To(Succeed())
+				drops[pb.GetLabel()[0].GetValue()] = pb.GetCounter().GetValue()
+			}
+			Expect(drops).To(Equal(map[string]float64{"mixedPol": 7}))
+		})
+
 		It("should cleanup the bpf map after restart", func() {
 			ingRuleMatchId := bpfEpMgr.dp.ruleMatchID("Ingress", "Allow", "Policy", "allowPol", 0)
 			egrRuleMatchId := bpfEpMgr.dp.ruleMatchID("Egress", "Allow", "Policy", "allowPol", 0)
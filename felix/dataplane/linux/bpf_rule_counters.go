@@ -0,0 +1,140 @@
+//go:build !windows
+
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/bpf/counters"
+	"github.com/projectcalico/calico/felix/bpf/maps"
+	"github.com/projectcalico/calico/felix/bpf/polprog"
+)
+
+var bpfRuleCounters = newBPFRuleCountersCollector()
+
+func init() {
+	prometheus.MustRegister(bpfRuleCounters)
+}
+
+// bpfRuleInfo holds the labels that we report for a rule's counter.
+type bpfRuleInfo struct {
+	policy string
+	rule   string
+	action string
+}
+
+// bpfRuleCountersCollector is a prometheus collector that reports the per-rule
+// packet counters that the BPF programs (TC and XDP) maintain when
+// BPFPolicyDebugEnabled is set.  The counters map is only keyed on the rule's
+// match ID so the endpoint manager tells us which policy rule each ID belongs to.
+// It also sums the counts of each policy's deny rules, so that a drop can be
+// attributed to the policy that made it.
+type bpfRuleCountersCollector struct {
+	lock     sync.Mutex
+	ctrsMap  maps.Map
+	rules    map[polprog.RuleMatchID]bpfRuleInfo
+	desc     *prometheus.Desc
+	dropDesc *prometheus.Desc
+}
+
+func newBPFRuleCountersCollector() *bpfRuleCountersCollector {
+	return &bpfRuleCountersCollector{
+		rules: map[polprog.RuleMatchID]bpfRuleInfo{},
+		desc: prometheus.NewDesc(
+			"felix_policy_rule_packets",
+			"Number of packets that matched a policy rule in the BPF dataplane; "+
+				"only reported when BPFPolicyDebugEnabled is set.",
+			[]string{"policy", "rule", "action"},
+			nil,
+		),
+		dropDesc: prometheus.NewDesc(
+			"felix_policy_dropped_packets",
+			"Number of packets that a policy's deny rules dropped in the BPF dataplane; "+
+				"only reported when BPFPolicyDebugEnabled is set.",
+			[]string{"policy"},
+			nil,
+		),
+	}
+}
+
+func (c *bpfRuleCountersCollector) setMap(m maps.Map) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.ctrsMap = m
+}
+
+func (c *bpfRuleCountersCollector) addRule(id polprog.RuleMatchID, polName string, dir PolDirection, idx int, action string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.rules[id] = bpfRuleInfo{
+		policy: polName,
+		rule:   fmt.Sprintf("%s-%d", strings.ToLower(dir.RuleDir()), idx),
+		action: strings.ToLower(action),
+	}
+}
+
+func (c *bpfRuleCountersCollector) removeRule(id polprog.RuleMatchID) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.rules, id)
+}
+
+func (c *bpfRuleCountersCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+	ch <- c.dropDesc
+}
+
+func (c *bpfRuleCountersCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.ctrsMap == nil || len(c.rules) == 0 {
+		return
+	}
+
+	values := make(counters.PolicyMapMem)
+	iterFn := counters.PolicyMapMemIter(values)
+	err := c.ctrsMap.Iter(func(k, v []byte) maps.IteratorAction {
+		iterFn(k, v)
+		return maps.IterNone
+	})
+	if err != nil {
+		log.WithError(err).Warn("Failed to read BPF rule counters.")
+		return
+	}
+
+	drops := map[string]uint64{}
+	for id, count := range values {
+		info, ok := c.rules[id]
+		if !ok {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, float64(count),
+			info.policy, info.rule, info.action)
+		if info.action == "deny" {
+			drops[info.policy] += count
+		}
+	}
+	for policy, count := range drops {
+		ch <- prometheus.MustNewConstMetric(c.dropDesc, prometheus.CounterValue, float64(count), policy)
+	}
+}
@@ -190,6 +190,9 @@ type endpointManager struct {
 	// activeIfaceNameToHostEpID records which endpoint we resolved each host interface to.
 	activeIfaceNameToHostEpID map[string]proto.HostEndpointID
 	newIfaceNameToHostEpID    map[string]proto.HostEndpointID
+	// activeCallbackIfaceNameToHostEpID records the interfaces that we last reported to the
+	// interface callbacks; it has the all-interfaces host endpoint expanded.
+	activeCallbackIfaceNameToHostEpID map[string]proto.HostEndpointID
 
 	needToCheckDispatchChains     bool
 	needToCheckEndpointMarkChains bool
@@ -934,9 +937,12 @@ func (m *endpointManager) updateHostEndpoints() {
 		if len(ep.UntrackedTiers) > 0 {
 			if ifaceName == allInterfaces {
 				// GlobalNetworkPolicy with `doNotTrack: True` has been configured
-				// to apply to a host-* endpoint, which is not currently supported.
-				// Log and warning and ignore it.
-				logCxt.Warning("DoNotTrack policy is not supported for a HEP with `interfaceName: *`; ignoring it")
+				// to apply to a host-* endpoint, which is not currently supported in
+				// iptables.  Log a warning and ignore it; XDP still gets the
+				// interfaces (see expandAllInterfaces) so it can accelerate the
+				// policy, if possible.
+				logCxt.Warning("DoNotTrack policy is not supported in iptables for a HEP with `interfaceName: *`; " +
+					"ignoring it, apart from any rules that are accelerated by XDP")
 			} else {
 				// Optimisation: only add the endpoint chains to the raw (untracked)
 				// table if there's some untracked policy to apply.  This reduces
@@ -1063,7 +1069,11 @@ func (m *endpointManager) updateHostEndpoints() {
 			m.mangleTable.RemoveChains(chains)
 		}
 
-		m.callbacks.InvokeInterfaceCallbacks(m.activeIfaceNameToHostEpID, newIfaceNameToHostEpID)
+		// The callbacks' consumers (XDP) work per interface, so they can't do anything
+		// with the all-interfaces host endpoint's non-existent interface name.
+		newCallbackIfaceNameToHostEpID := m.expandAllInterfaces(newIfaceNameToHostEpID)
+		m.callbacks.InvokeInterfaceCallbacks(m.activeCallbackIfaceNameToHostEpID, newCallbackIfaceNameToHostEpID)
+		m.activeCallbackIfaceNameToHostEpID = newCallbackIfaceNameToHostEpID
 
 		m.activeHostIfaceToFiltChains = newHostIfaceFiltChains
 		m.activeHostIfaceToMangleEgressChains = newHostIfaceMangleEgressChains
@@ -1332,7 +1342,36 @@ func forAllInterfaces(hep *proto.HostEndpoint) bool {
 	return hep.Name == "*"
 }
 
+// expandAllInterfaces returns a copy of the given map with the all-interfaces host endpoint, if
+// any, replaced by an entry for each host interface that doesn't have its own host endpoint.
+// The loopback interface is left out since it only carries traffic from the host itself.
+func (m *endpointManager) expandAllInterfaces(ifaceNameToHostEpID map[string]proto.HostEndpointID) map[string]proto.HostEndpointID {
+	expanded := make(map[string]proto.HostEndpointID, len(ifaceNameToHostEpID))
+	for ifaceName, id := range ifaceNameToHostEpID {
+		if ifaceName != allInterfaces {
+			expanded[ifaceName] = id
+		}
+	}
+	if id, ok := ifaceNameToHostEpID[allInterfaces]; ok {
+		for ifaceName := range m.hostIfaceToAddrs {
+			if ifaceName == "lo" {
+				continue
+			}
+			if _, ok := expanded[ifaceName]; !ok {
+				expanded[ifaceName] = id
+			}
+		}
+	}
+	return expanded
+}
+
 // for implementing the endpointsSource interface
 func (m *endpointManager) GetRawHostEndpoints() map[proto.HostEndpointID]*proto.HostEndpoint {
 	return m.rawHostEndpoints
 }
+
+// GetActiveHostInterfaces returns the interfaces that were last reported to the interface
+// callbacks, with the host endpoints they resolve to.
+func (m *endpointManager) GetActiveHostInterfaces() map[string]proto.HostEndpointID {
+	return m.activeCallbackIfaceNameToHostEpID
+}
@@ -672,6 +672,8 @@ func endpointManagerTests(ipVersion uint8) func() {
 			mockProcSys     *testProcSys
 			statusReportRec *statusReportRecorder
 			hepListener     *testHEPListener
+			// ifaceCallbackState tracks the interfaces reported to the interface callbacks.
+			ifaceCallbackState map[string]proto.HostEndpointID
 		)
 
 		BeforeEach(func() {
@@ -712,6 +714,16 @@ func endpointManagerTests(ipVersion uint8) func() {
 			mockProcSys = &testProcSys{state: map[string]string{}, pathsThatExist: map[string]bool{}}
 			statusReportRec = &statusReportRecorder{currentState: map[interface{}]string{}}
 			hepListener = &testHEPListener{}
+			ifaceCallbackState = map[string]proto.HostEndpointID{}
+			callbacks := common.NewCallbacks()
+			onIface := func(ifaceName string, hostEPID proto.HostEndpointID) {
+				ifaceCallbackState[ifaceName] = hostEPID
+			}
+			callbacks.AddInterfaceV4.Append(onIface)
+			callbacks.UpdateInterfaceV4.Append(onIface)
+			callbacks.RemoveInterfaceV4.Append(func(ifaceName string) {
+				delete(ifaceCallbackState, ifaceName)
+			})
 			epMgr = newEndpointManagerWithShims(
 				rawTable,
 				mangleTable,
@@ -728,7 +740,7 @@ func endpointManagerTests(ipVersion uint8) func() {
 				"1",
 				false,
 				hepListener,
-				common.NewCallbacks(),
+				callbacks,
 				true,
 			)
 		})
@@ -893,6 +905,36 @@ func endpointManagerTests(ipVersion uint8) func() {
 						"any-interface-at-all": "profiles=,normal=I=polA,E=polA,untracked=,preDNAT=,AoF=",
 					}))
 				})
+
+				if ipVersion == 4 {
+					It("should report each non-loopback interface to the interface callbacks", func() {
+						Expect(ifaceCallbackState).To(Equal(map[string]proto.HostEndpointID{
+							"eth0": {EndpointId: "id1"},
+						}))
+					})
+
+					Context("with a specific host endpoint for eth0", func() {
+						JustBeforeEach(configureHostEp(&hostEpSpec{
+							id:      "id2",
+							name:    "eth0",
+							polName: "polB",
+						}))
+
+						It("should report eth0 with its own host endpoint", func() {
+							Expect(ifaceCallbackState).To(Equal(map[string]proto.HostEndpointID{
+								"eth0": {EndpointId: "id2"},
+							}))
+						})
+					})
+
+					Context("with the * host endpoint removed", func() {
+						JustBeforeEach(removeHostEp("id1"))
+
+						It("should remove the interfaces from the interface callbacks", func() {
+							Expect(ifaceCallbackState).To(BeEmpty())
+						})
+					})
+				}
 			})
 
 			// Configure host endpoints with tier names here, so we can check which of
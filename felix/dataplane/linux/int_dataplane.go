@@ -15,6 +15,7 @@
 package intdataplane
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -179,6 +180,7 @@ type Config struct {
 	RouteTableManager  *idalloc.IndexAllocator
 
 	DebugSimulateDataplaneHangAfter time.Duration
+	DebugSimulateXDPLoadFailure     bool
 
 	ExternalNodesCidrs []string
 
@@ -192,6 +194,13 @@ type Config struct {
 	BPFL3IfacePattern                  *regexp.Regexp
 	XDPEnabled                         bool
 	XDPAllowGeneric                    bool
+	XDPEventLog                        string
+	XDPEventsSocket                    string
+	XDPLogLevel                        string
+	XDPUpdateDebounce                  time.Duration
+	XDPEgressBlocklistEnabled          bool
+	XDPMaxBlocklistEntries             int
+	XDPMinInterfaceSpeed               int
 	BPFConntrackTimeouts               bpfconntrack.Timeouts
 	BPFCgroupV2                        string
 	BPFConnTimeLBEnabled               bool
@@ -324,7 +333,11 @@ type InternalDataplane struct {
 
 	debugHangC <-chan time.Time
 
-	xdpState          *xdpState
+	xdpState *xdpState
+	// xdpEnabled is whether XDP acceleration is turned on.  It can be turned on and off at
+	// runtime; while it's off, xdpState keeps track of the policies but not the host
+	// endpoints' interfaces, which it picks up again when XDP is turned back on.
+	xdpEnabled        bool
 	sockmapState      *sockmapState
 	endpointsSourceV4 endpointsSource
 	ipsetsSourceV4    ipsetsSource
@@ -518,28 +531,51 @@ func NewIntDataplaneDriver(config Config) *InternalDataplane {
 
 	callbacks := common.NewCallbacks()
 	dp.callbacks = callbacks
-	if config.XDPEnabled {
-		if err := bpf.SupportsXDP(); err != nil {
+	xdpConfig := XDPStateConfig{
+		AllowGenericXDP:     config.XDPAllowGeneric,
+		EventLogPath:        config.XDPEventLog,
+		UpdateDebounce:      config.XDPUpdateDebounce,
+		EgressBlocklist:     config.XDPEgressBlocklistEnabled,
+		MaxBlocklistEntries: config.XDPMaxBlocklistEntries,
+		MinInterfaceSpeed:   config.XDPMinInterfaceSpeed,
+		Hostname:            config.Hostname,
+	}
+	if err := bpf.SupportsXDP(); err != nil {
+		if config.XDPEnabled {
 			log.WithError(err).Warn("Can't enable XDP acceleration.")
 			config.XDPEnabled = false
-		} else if !config.BPFEnabled {
-			st, err := NewXDPState(config.XDPAllowGeneric)
-			if err != nil {
+		}
+	} else if !config.BPFEnabled {
+		// Create the XDP state even if XDP is disabled so that it keeps track of the
+		// policies and XDP can be turned on at runtime; see onConfigUpdate.  It only
+		// follows the host endpoints' interfaces while XDP is enabled.
+		st, err := NewXDPState(xdpConfig)
+		if err != nil {
+			if config.XDPEnabled {
 				log.WithError(err).Warn("Can't enable XDP acceleration.")
-			} else {
-				dp.xdpState = st
+			}
+		} else {
+			dp.xdpState = st
+			dp.xdpEnabled = config.XDPEnabled
+			if dp.xdpEnabled {
 				dp.xdpState.PopulateCallbacks(callbacks)
-				dp.RegisterManager(st)
-				log.Info("XDP acceleration enabled.")
 			}
+			dp.RegisterManager(st)
 		}
+	}
+	if dp.xdpEnabled {
+		log.Info("XDP acceleration enabled.")
 	} else {
 		log.Info("XDP acceleration disabled.")
 	}
 
 	// TODO Support cleaning up non-BPF XDP state from a previous Felix run, when BPF mode has just been enabled.
-	if !config.BPFEnabled && dp.xdpState == nil {
-		xdpState, err := NewXDPState(config.XDPAllowGeneric)
+	if !config.BPFEnabled && !dp.xdpEnabled {
+		xdpState := dp.xdpState
+		var err error
+		if xdpState == nil {
+			xdpState, err = NewXDPState(xdpConfig)
+		}
 		if err == nil {
 			if err := xdpState.WipeXDP(); err != nil {
 				log.WithError(err).Warn("Failed to cleanup preexisting XDP state")
@@ -787,11 +823,13 @@ func NewIntDataplaneDriver(config Config) *InternalDataplane {
 	dp.endpointsSourceV4 = epManager
 	dp.RegisterManager(newFloatingIPManager(natTableV4, ruleRenderer, 4, config.FloatingIPsEnabled))
 	dp.RegisterManager(newMasqManager(ipSetsV4, natTableV4, ruleRenderer, config.MaxIPSetSize, 4))
-	if config.RulesConfig.IPIPEnabled {
-		// Add a manager to keep the all-hosts IP set up to date.
+	if config.RulesConfig.IPIPEnabled || config.RulesConfig.AutoBlocklistConnRate > 0 {
+		// Add a manager to keep the all-hosts IP set up to date.  The automatic blocklist
+		// uses it too, to avoid blocklisting other Calico hosts.
 		dp.ipipManager = newIPIPManager(ipSetsV4, config.MaxIPSetSize, config.ExternalNodesCidrs)
 		dp.RegisterManager(dp.ipipManager) // IPv4-only
-	} else {
+	}
+	if !config.RulesConfig.IPIPEnabled {
 		// Only clean up IPIP addresses if IPIP is implicitly disabled (no IPIP pools and not explicitly set in FelixConfig)
 		if config.RulesConfig.FelixConfigIPIPEnabled == nil {
 			// Start a cleanup goroutine not to block felix if it needs to retry
@@ -1270,6 +1308,9 @@ func (d *InternalDataplane) Start() {
 	go d.loopReportingStatus()
 	go d.ifaceMonitor.MonitorInterfaces()
 	go d.monitorHostMTU()
+	if d.config.BPFEnabled && d.config.XDPEventsSocket != "" {
+		go newXDPEventForwarder(d.config.XDPEventsSocket).loop(nil)
+	}
 }
 
 // onIfaceInSync is used as a callback from the interface monitor.  We use it to send a message back to
@@ -1611,7 +1652,7 @@ func (d *InternalDataplane) setUpIptablesNormal() {
 			Action: iptables.JumpAction{Target: rules.ChainManglePostrouting},
 		}})
 	}
-	if d.xdpState != nil {
+	if d.xdpState != nil && d.xdpEnabled {
 		if err := d.setXDPFailsafePorts(); err != nil {
 			log.Warnf("failed to set XDP failsafe ports, disabling XDP: %v", err)
 			if err := d.shutdownXDPCompletely(); err != nil {
@@ -1671,6 +1712,7 @@ func (d *InternalDataplane) shutdownXDPCompletely() error {
 		err = d.xdpState.WipeXDP()
 		if err == nil {
 			d.xdpState = nil
+			d.xdpEnabled = false
 			return nil
 		}
 		log.WithError(err).WithField("try", i).Warn("failed to wipe the XDP state")
@@ -1812,12 +1854,59 @@ func (d *InternalDataplane) processMsgFromCalcGraph(msg interface{}) {
 	for _, mgr := range d.allManagers {
 		mgr.OnUpdate(msg)
 	}
-	switch msg.(type) {
+	switch msg := msg.(type) {
 	case *proto.InSync:
 		log.WithField("timeSinceStart", time.Since(processStartTime)).Info(
 			"Datastore in sync, flushing the dataplane for the first time...")
 		d.datastoreInSync = true
+	case *proto.ConfigUpdate:
+		d.onConfigUpdate(msg)
+	}
+}
+
+// onConfigUpdate applies the changes to the configuration that the dataplane can handle
+// without a restart.  Felix restarts for any other changes.
+func (d *InternalDataplane) onConfigUpdate(msg *proto.ConfigUpdate) {
+	cfg := config.New()
+	if _, err := cfg.UpdateFromConfigUpdate(msg); err != nil {
+		log.WithError(err).Warn("Failed to parse configuration update.")
+		return
 	}
+	d.setXDPEnabled(cfg.XDPEnabled)
+}
+
+// setXDPEnabled turns XDP acceleration on or off.  Turning it off removes Felix's XDP
+// programs, leaving iptables to enforce the untracked policy; turning it on again resyncs
+// the XDP programs with the current host endpoints and policies.
+func (d *InternalDataplane) setXDPEnabled(enabled bool) {
+	if enabled == d.xdpEnabled {
+		return
+	}
+	if d.xdpState == nil {
+		if enabled {
+			log.Warn("Can't enable XDP acceleration: XDP isn't supported or failed earlier.")
+		}
+		return
+	}
+	if enabled {
+		log.Info("Enabling XDP acceleration.")
+		if err := d.setXDPFailsafePorts(); err != nil {
+			log.WithError(err).Warn("Failed to set XDP failsafe ports, not enabling XDP.")
+			return
+		}
+		d.xdpState.AttachToEndpoints(d.callbacks, d.endpointsSourceV4)
+		d.xdpState.QueueResync()
+	} else {
+		log.Info("Disabling XDP acceleration.")
+		if err := d.xdpState.WipeXDP(); err != nil {
+			// Leave XDP enabled; we'll try again on the next configuration update.
+			log.WithError(err).Warn("Failed to remove XDP programs, not disabling XDP.")
+			return
+		}
+		d.xdpState.DetachFromEndpoints(d.callbacks)
+	}
+	d.xdpEnabled = enabled
+	d.dataplaneNeedsSync = true
 }
 
 // onIfaceMonitorMessage is called when we get a message from the interface monitor
@@ -1982,9 +2071,30 @@ func (d *InternalDataplane) apply() {
 		d.reportHealth()
 	}
 
-	if d.xdpState != nil {
+	var xdpDebounceDelay time.Duration
+	if d.xdpState != nil && d.xdpEnabled {
+		xdpDebounceDelay = d.xdpState.UpdateDebounceRemaining()
+	}
+	if d.xdpState != nil && !d.xdpEnabled {
+		// XDP acceleration is turned off; keep track of the policy changes so that the
+		// XDP programs can be brought up to date if it's turned on again.
+		d.xdpState.TrackPendingDiffState(d.endpointsSourceV4)
+		d.forceXDPRefresh = false
+	} else if xdpDebounceDelay > 0 {
+		// IP sets have changed recently; give any further changes a chance to arrive
+		// so that they're all written to the XDP maps together.
+		log.WithField("delay", xdpDebounceDelay).Debug("Holding back XDP update.")
+	} else if d.xdpState != nil {
 		if d.forceXDPRefresh {
 			// Refresh timer popped.
+			if _, err := d.xdpState.common.bpfLib.GetFailsafeMapID(); err != nil {
+				// The map's pin has gone, for example, because bpffs was remounted.  The
+				// resync will reload the programs, which need the map.
+				log.WithError(err).Warn("XDP failsafe ports map is missing, recreating it.")
+				if err := d.setXDPFailsafePorts(); err != nil {
+					log.WithError(err).Warn("Failed to recreate XDP failsafe ports map.")
+				}
+			}
 			d.xdpState.QueueResync()
 			d.forceXDPRefresh = false
 		}
@@ -1994,7 +2104,7 @@ func (d *InternalDataplane) apply() {
 		if err := d.applyXDPActions(); err != nil {
 			applyXDPError = err
 		} else {
-			err := d.xdpState.ProcessMemberUpdates()
+			err := d.xdpState.ProcessMemberUpdates(d.ipsetsSourceV4)
 			d.xdpState.DropPendingDiffState()
 			if err != nil {
 				log.WithError(err).Warning("Failed to process XDP member updates, will resync later...")
@@ -2006,6 +2116,10 @@ func (d *InternalDataplane) apply() {
 		}
 		if applyXDPError != nil {
 			log.WithError(applyXDPError).Info("Applying XDP actions did not succeed, disabling XDP")
+			if isPermissionError(applyXDPError) {
+				log.Warn("Felix lacks the privileges to manage XDP programs and maps; XDP " +
+					"acceleration is disabled but policy is still enforced with iptables.")
+			}
 			if err := d.shutdownXDPCompletely(); err != nil {
 				log.Warnf("failed to disable XDP: %v, will proceed anyway.", err)
 			}
@@ -2101,6 +2215,9 @@ func (d *InternalDataplane) apply() {
 		}(t)
 	}
 	iptablesWG.Wait()
+	if xdpDebounceDelay != 0 && (reschedDelay == 0 || xdpDebounceDelay < reschedDelay) {
+		reschedDelay = xdpDebounceDelay
+	}
 
 	// Now clean up any left-over IP sets.
 	for _, ipSets := range d.ipSets {
@@ -2155,6 +2272,11 @@ func (d *InternalDataplane) applyXDPActions() error {
 		if err != nil {
 			return err
 		}
+		if d.config.DebugSimulateXDPLoadFailure && d.xdpState.ipV4State.bpfActions.InstallXDP.Len() > 0 {
+			// Fail as if the kernel had rejected the program, so that we fall back to
+			// enforcing the untracked policy with iptables.
+			return errors.New("simulated XDP program load failure (DebugSimulateXDPLoadFailure)")
+		}
 		if err = d.xdpState.ApplyBPFActions(d.ipsetsSourceV4); err == nil {
 			return nil
 		} else {
@@ -0,0 +1,130 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/bpf/ringbuf"
+)
+
+// xdpEventSource is the part of ringbuf.Reader that the forwarder uses.
+type xdpEventSource interface {
+	Read() [][]byte
+	Close() error
+}
+
+func openXDPEventsRingbuf() (xdpEventSource, error) {
+	return ringbuf.OpenPinned(bpf.XDPEventsPinPath)
+}
+
+// xdpEventForwarder writes the events from the XDP events ring buffer to a unix socket, one
+// line per event in the format of bpf.XDPEvent.String(), as the XDP programs report them.
+// It's meant for a collector, such as a test, that wants to see the events as they happen.
+//
+// The forwarder only consumes the ring buffer while it's connected to the socket, so the events
+// stay available to `calico-bpf xdp events` while nothing is listening.  If the connection
+// breaks, the events that were being written are lost and the forwarder reconnects.
+type xdpEventForwarder struct {
+	socketPath string
+	openSource func() (xdpEventSource, error)
+
+	pollInterval  time.Duration
+	retryInterval time.Duration
+}
+
+func newXDPEventForwarder(socketPath string) *xdpEventForwarder {
+	return &xdpEventForwarder{
+		socketPath:    socketPath,
+		openSource:    openXDPEventsRingbuf,
+		pollInterval:  50 * time.Millisecond,
+		retryInterval: time.Second,
+	}
+}
+
+// loop forwards events until the stop channel is closed.  A nil channel never closes.
+func (f *xdpEventForwarder) loop(stop <-chan struct{}) {
+	logCxt := log.WithField("socket", f.socketPath)
+
+	// The ring buffer is created when the first XDP program is loaded.
+	var src xdpEventSource
+	for {
+		var err error
+		src, err = f.openSource()
+		if err == nil {
+			break
+		}
+		logCxt.WithError(err).Debug("XDP events ring buffer not available yet.")
+		if !f.sleep(stop, f.retryInterval) {
+			return
+		}
+	}
+	defer src.Close()
+	logCxt.Info("Forwarding XDP events.")
+
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+	for {
+		if conn == nil {
+			var err error
+			conn, err = net.Dial("unix", f.socketPath)
+			if err != nil {
+				logCxt.WithError(err).Debug("Failed to connect to XDP events socket.")
+				conn = nil
+				if !f.sleep(stop, f.retryInterval) {
+					return
+				}
+				continue
+			}
+			logCxt.Info("Connected to XDP events socket.")
+		}
+
+		for _, rec := range src.Read() {
+			e, err := bpf.XDPEventFromBytes(rec)
+			if err != nil {
+				logCxt.WithError(err).Warn("Skipping bad XDP event.")
+				continue
+			}
+			if _, err := conn.Write([]byte(e.String() + "\n")); err != nil {
+				logCxt.WithError(err).Warn("Failed to write to XDP events socket, will reconnect.")
+				conn.Close()
+				conn = nil
+				break
+			}
+		}
+
+		if !f.sleep(stop, f.pollInterval) {
+			return
+		}
+	}
+}
+
+// sleep waits for the given time, returning false if the stop channel was closed first.
+func (f *xdpEventForwarder) sleep(stop <-chan struct{}, d time.Duration) bool {
+	select {
+	case <-stop:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mockXDPEventSource struct {
+	lock    sync.Mutex
+	records [][]byte
+	closed  bool
+}
+
+func (s *mockXDPEventSource) add(records ...[]byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.records = append(s.records, records...)
+}
+
+func (s *mockXDPEventSource) pending() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.records)
+}
+
+func (s *mockXDPEventSource) Read() [][]byte {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	records := s.records
+	s.records = nil
+	return records
+}
+
+func (s *mockXDPEventSource) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	return nil
+}
+
+var _ = Describe("XDP event forwarder", func() {
+	var (
+		tmpDir     string
+		socketPath string
+		src        *mockXDPEventSource
+		fwd        *xdpEventForwarder
+		stop       chan struct{}
+		done       chan struct{}
+	)
+
+	// Records for struct cali_xdp_event: the source IP then the verdict, little-endian.
+	dropRecord := []byte{10, 65, 0, 2, 1, 0, 0, 0}
+	passRecord := []byte{10, 65, 0, 3, 2, 0, 0, 0}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "xdp-event-forwarder")
+		Expect(err).NotTo(HaveOccurred())
+		socketPath = filepath.Join(tmpDir, "events.sock")
+
+		src = &mockXDPEventSource{}
+		fwd = newXDPEventForwarder(socketPath)
+		fwd.openSource = func() (xdpEventSource, error) { return src, nil }
+		fwd.pollInterval = 10 * time.Millisecond
+		fwd.retryInterval = 10 * time.Millisecond
+
+		stop = make(chan struct{})
+		done = make(chan struct{})
+	})
+
+	startForwarder := func() {
+		go func() {
+			defer close(done)
+			fwd.loop(stop)
+		}()
+	}
+
+	AfterEach(func() {
+		close(stop)
+		Eventually(done).Should(BeClosed())
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	listen := func() net.Listener {
+		l, err := net.Listen("unix", socketPath)
+		Expect(err).NotTo(HaveOccurred())
+		return l
+	}
+
+	// readLines accepts a connection and returns a channel that gets each line from it.
+	readLines := func(l net.Listener) chan string {
+		lines := make(chan string, 10)
+		go func() {
+			defer GinkgoRecover()
+			defer close(lines)
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				lines <- scanner.Text()
+			}
+		}()
+		return lines
+	}
+
+	It("should write one line per event", func() {
+		l := listen()
+		defer l.Close()
+		lines := readLines(l)
+		startForwarder()
+
+		src.add(dropRecord, passRecord)
+		Eventually(lines).Should(Receive(Equal("src=10.65.0.2 verdict=DROP")))
+		Eventually(lines).Should(Receive(Equal("src=10.65.0.3 verdict=PASS")))
+	})
+
+	It("should skip bad records", func() {
+		l := listen()
+		defer l.Close()
+		lines := readLines(l)
+		startForwarder()
+
+		src.add([]byte{1, 2, 3}, dropRecord)
+		Eventually(lines).Should(Receive(Equal("src=10.65.0.2 verdict=DROP")))
+	})
+
+	It("should leave the events in the ring buffer while nothing is listening", func() {
+		startForwarder()
+		src.add(dropRecord)
+		Consistently(src.pending, "100ms", "10ms").Should(Equal(1))
+
+		l := listen()
+		defer l.Close()
+		lines := readLines(l)
+		Eventually(lines).Should(Receive(Equal("src=10.65.0.2 verdict=DROP")))
+	})
+
+	It("should reconnect after the collector goes away", func() {
+		l := listen()
+		conn := make(chan net.Conn, 1)
+		go func() {
+			c, err := l.Accept()
+			if err == nil {
+				conn <- c
+			}
+		}()
+		startForwarder()
+
+		var c net.Conn
+		Eventually(conn).Should(Receive(&c))
+		c.Close()
+		l.Close()
+
+		l = listen()
+		defer l.Close()
+		lines := readLines(l)
+		// Events written into the broken connection are lost, so keep sending until one
+		// arrives on the new one.
+		Eventually(func() string {
+			src.add(dropRecord)
+			select {
+			case line := <-lines:
+				return line
+			case <-time.After(50 * time.Millisecond):
+				return ""
+			}
+		}, "2s").Should(Equal("src=10.65.0.2 verdict=DROP"))
+	})
+
+	It("should retry until the ring buffer exists", func() {
+		attempts := 0
+		var lock sync.Mutex
+		fwd.openSource = func() (xdpEventSource, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			attempts++
+			if attempts < 3 {
+				return nil, errors.New("no such file or directory")
+			}
+			return src, nil
+		}
+		l := listen()
+		defer l.Close()
+		lines := readLines(l)
+		startForwarder()
+
+		src.add(dropRecord)
+		Eventually(lines).Should(Receive(Equal("src=10.65.0.2 verdict=DROP")))
+	})
+
+	It("should close the ring buffer when stopped", func() {
+		startForwarder()
+		close(stop)
+		Eventually(done).Should(BeClosed())
+		src.lock.Lock()
+		Expect(src.closed).To(BeTrue())
+		src.lock.Unlock()
+		stop = make(chan struct{})
+	})
+})
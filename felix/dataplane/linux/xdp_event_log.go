@@ -0,0 +1,88 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	xdpEventAttach = "attach"
+	xdpEventDetach = "detach"
+	xdpEventReload = "reload"
+)
+
+// xdpEventLog appends a line to a file for each XDP program that is attached to, detached
+// from or reloaded on an interface, giving operators an audit trail of the XDP programs that
+// Felix has managed.  A line looks like:
+//
+//	2022-06-01T12:00:00.123456Z attach iface=eth0 mode=xdpdrv tag=c5a4b2f4e1d0b9a8 reason="host endpoint changed"
+//
+// A nil *xdpEventLog is valid and discards the events.
+type xdpEventLog struct {
+	path string
+	now  func() time.Time
+}
+
+// newXDPEventLog returns an event log that appends to the file at the given path, or nil if
+// the path is empty.
+func newXDPEventLog(path string) *xdpEventLog {
+	if path == "" {
+		return nil
+	}
+	return &xdpEventLog{
+		path: path,
+		now:  time.Now,
+	}
+}
+
+// record appends an event to the log.  mode and tag may be empty if they aren't known.
+// Failing to write the event is logged but otherwise ignored, so that auditing can't get in
+// the way of programming the dataplane.
+func (l *xdpEventLog) record(event, iface, mode, tag, reason string) {
+	if l == nil {
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s iface=%s", l.now().UTC().Format(time.RFC3339Nano), event, iface)
+	if mode != "" {
+		fmt.Fprintf(&sb, " mode=%s", mode)
+	}
+	if tag != "" {
+		fmt.Fprintf(&sb, " tag=%s", tag)
+	}
+	fmt.Fprintf(&sb, " reason=%q\n", reason)
+
+	logCxt := log.WithFields(log.Fields{
+		"path":  l.path,
+		"event": event,
+		"iface": iface,
+	})
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logCxt.WithError(err).Warn("Failed to open XDP event log.")
+		return
+	}
+	defer f.Close()
+	if _, err := f.WriteString(sb.String()); err != nil {
+		logCxt.WithError(err).Warn("Failed to write to XDP event log.")
+	}
+}
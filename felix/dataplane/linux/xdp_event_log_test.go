@@ -0,0 +1,69 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("XDP event log", func() {
+	var (
+		tmpDir string
+		path   string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "xdp-event-log")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(tmpDir, "events.log")
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	It("should be disabled without a path", func() {
+		l := newXDPEventLog("")
+		Expect(l).To(BeNil())
+		// A nil log discards events.
+		l.record(xdpEventAttach, "eth0", "xdpdrv", "abcd", "host endpoint changed")
+	})
+
+	It("should append one line per event", func() {
+		l := newXDPEventLog(path)
+		l.now = func() time.Time {
+			return time.Date(2022, 6, 1, 12, 0, 0, 123456000, time.UTC)
+		}
+		l.record(xdpEventAttach, "eth0", "xdpdrv", "abcd", "host endpoint changed")
+		l.record(xdpEventDetach, "eth0", "", "", "interface removed")
+
+		contents, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal(
+			`2022-06-01T12:00:00.123456Z attach iface=eth0 mode=xdpdrv tag=abcd reason="host endpoint changed"` + "\n" +
+				`2022-06-01T12:00:00.123456Z detach iface=eth0 reason="interface removed"` + "\n"))
+	})
+
+	It("should not fail if the log can't be written", func() {
+		l := newXDPEventLog(filepath.Join(tmpDir, "missing", "events.log"))
+		l.record(xdpEventAttach, "eth0", "xdpdrv", "abcd", "host endpoint changed")
+	})
+})
@@ -15,11 +15,16 @@
 package intdataplane
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/projectcalico/calico/felix/bpf"
@@ -89,23 +94,138 @@ import (
 //
 // Then we need to process member updates. This consumes the
 // information we get from the ipset manager about changes within
-// ipsets. This happens in the ProcessMemberUpdates function.
+// ipsets. This happens in the ProcessMemberUpdates function, which
+// also brings the destination maps up to date. (Rules that deny
+// destinations rather than sources put the members of their ipsets
+// in a separate, unsharded destination map per interface; it's small
+// enough to diff against the desired contents rather than being
+// reference counted like the blocklist.)
+//
+// The blocklist of an interface may be limited in size. CIDRs that
+// don't fit are left out and remembered, with their reference counts,
+// and they're moved into the map when deletions make room for them.
+//
+// GlobalNetworkSets may give a reason for each of their entries. The
+// calculation graph sends the reason for each CIDR and they're kept in
+// a single map, shared by all interfaces, for auditing; the XDP
+// program doesn't look at it. Like the destination maps, it's brought
+// up to date in ProcessMemberUpdates.
+//
+// The XDP program passes packets from the host's own addresses
+// without looking at the blocklist, so that a blocklist CIDR that
+// contains the node's pod CIDR can't cut the node off from its own
+// pods. Those addresses are kept in an allow-list, shared by all
+// interfaces, which holds the host's IP, the local routes that the
+// calculation graph sends (the node's IPAM blocks, tunnel and host
+// addresses) and the addresses of the local workloads. It's small, so
+// it's diffed against the desired contents in ProcessMemberUpdates
+// whenever one of those changes.
+//
+// GlobalNetworkSet entries may have a grace period, during which the
+// calculation graph holds them back from the blocklist IP sets and sends
+// them separately. They're kept in a grace list, shared by all
+// interfaces, and the XDP program counts, but passes, the packets from
+// them. The grace list is maintained in the same way as the allow-list.
+// (In BPF mode, the entries are simply left out of the IP sets until
+// their grace period ends, so there's nothing to count them.)
 //
 // There is a special step for resynchronization - it modifies BPF
 // actions based on the actual state of XDP on the system and the
 // desired state. See the ResyncIfNeeded function.
 
+var gaugeXDPProgramMode = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "felix_xdp_program_mode",
+	Help: "Set to 1 for the mode (xdpoffload, xdpdrv or xdpgeneric) that the XDP program is attached in, per interface.",
+}, []string{"iface", "mode"})
+
+var counterXDPMemberUpdateBatches = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "felix_xdp_member_update_batches",
+	Help: "Number of batches of IP set member changes that Felix has written to the XDP maps.",
+})
+
+var counterXDPProgramOperations = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "felix_xdp_program_operations",
+	Help: "Number of times that Felix has attached, detached or reloaded an XDP program.",
+})
+
+// The operations counted by counterXDPLifecycleOperations.
+const (
+	xdpOpAttach       = "attach"
+	xdpOpDetach       = "detach"
+	xdpOpReload       = "reload"
+	xdpOpMapCreate    = "map_create"
+	xdpOpMapUpdate    = "map_update"
+	xdpOpResyncRepair = "resync_repair"
+)
+
+var counterXDPLifecycleOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "felix_xdp_lifecycle_operations",
+	Help: "Number of XDP lifecycle operations by type: program attaches, detaches and reloads, " +
+		"blocklist map creations, batches of blocklist map updates per interface, and the " +
+		"interfaces whose program or blocklist a resync found out of step and repaired.",
+}, []string{"operation"})
+
+var gaugeXDPBlocklistSkippedEntries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "felix_xdp_blocklist_skipped_entries",
+	Help: "Number of CIDRs that Felix has left out of the XDP blocklist of an interface because the blocklist is full.",
+}, []string{"iface"})
+
+func init() {
+	prometheus.MustRegister(gaugeXDPProgramMode)
+	prometheus.MustRegister(counterXDPMemberUpdateBatches)
+	prometheus.MustRegister(counterXDPProgramOperations)
+	prometheus.MustRegister(counterXDPLifecycleOperations)
+	// Export every operation from the start, so that the counts can be compared before and
+	// after an operation happens for the first time.
+	for _, op := range []string{xdpOpAttach, xdpOpDetach, xdpOpReload, xdpOpMapCreate, xdpOpMapUpdate, xdpOpResyncRepair} {
+		counterXDPLifecycleOperations.WithLabelValues(op)
+	}
+	prometheus.MustRegister(gaugeXDPBlocklistSkippedEntries)
+}
+
 type xdpState struct {
 	ipV4State *xdpIPState
 	common    xdpStateCommon
 }
 
-func NewXDPState(allowGenericXDP bool) (*xdpState, error) {
+// XDPStateConfig holds the configuration of the XDP state.
+type XDPStateConfig struct {
+	// AllowGenericXDP allows XDP programs to be attached in generic mode when the driver
+	// doesn't support native mode.
+	AllowGenericXDP bool
+	// EventLogPath, if not empty, is a file to which a line is appended for each XDP program
+	// attach, detach and reload.
+	EventLogPath string
+	// UpdateDebounce, if non-zero, holds changes to IP sets back for that long after the first
+	// of them, so that a burst of changes is written to the XDP maps in one batch.
+	UpdateDebounce time.Duration
+	// EgressBlocklist attaches a TC egress program alongside each XDP program to drop the
+	// host's packets to blocklisted addresses too.
+	EgressBlocklist bool
+	// MaxBlocklistEntries, if non-zero, limits the number of CIDRs in each interface's
+	// blocklist; any more are left out, with a warning, until there's room for them.
+	MaxBlocklistEntries int
+	// MinInterfaceSpeed, if non-zero, is the link speed, in Mbit/s, below which an interface
+	// doesn't get an XDP program.
+	MinInterfaceSpeed int
+	// Hostname is the name of this node, whose addresses are never blocklisted.
+	Hostname string
+}
+
+// NewXDPState creates the XDP state with the given configuration.
+func NewXDPState(config XDPStateConfig) (*xdpState, error) {
 	lib, err := bpf.NewBPFLib("/usr/lib/calico/bpf/")
 	if err != nil {
 		return nil, err
 	}
-	return NewXDPStateWithBPFLibrary(lib, allowGenericXDP), nil
+	st := NewXDPStateWithBPFLibrary(lib, config.AllowGenericXDP)
+	st.common.eventLog = newXDPEventLog(config.EventLogPath)
+	st.common.updateDebounce = config.UpdateDebounce
+	st.common.egressBlocklist = config.EgressBlocklist
+	st.common.maxBlocklistEntries = config.MaxBlocklistEntries
+	st.common.minInterfaceSpeed = config.MinInterfaceSpeed
+	st.common.hostname = config.Hostname
+	return st, nil
 }
 
 func NewXDPStateWithBPFLibrary(library bpf.BPFDataplane, allowGenericXDP bool) *xdpState {
@@ -113,10 +233,20 @@ func NewXDPStateWithBPFLibrary(library bpf.BPFDataplane, allowGenericXDP bool) *
 	return &xdpState{
 		ipV4State: newXDPIPState(4),
 		common: xdpStateCommon{
-			programTag: "",
-			needResync: true,
-			bpfLib:     library,
-			xdpModes:   getXDPModes(allowGenericXDP),
+			programTag:        "",
+			needResync:        true,
+			bpfLib:            library,
+			xdpModes:          getXDPModes(allowGenericXDP),
+			blocklistReasons:  map[string]string{},
+			dirtyReasons:      set.New[string](),
+			reasonsNeedResync: true,
+			localRouteCIDRs:   set.New[string](),
+			workloadCIDRs:     map[proto.WorkloadEndpointID][]string{},
+			allowListDirty:    true,
+			graceCIDRs:        set.New[string](),
+			graceListDirty:    true,
+			ifaceHostEPIDs:    map[string]proto.HostEndpointID{},
+			slowIfaces:        set.New[string](),
 		},
 	}
 }
@@ -130,6 +260,17 @@ func membersToSet(members []string) set.Set[string] {
 	return membersSet
 }
 
+// isPermissionError returns true if err is, or reports, a failure because Felix isn't allowed
+// to do something; for example, an EPERM from the bpf syscall.  Most XDP operations go through
+// bpftool so their errors often only carry its output.
+func isPermissionError(err error) bool {
+	if errors.Is(err, os.ErrPermission) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "operation not permitted") || strings.Contains(msg, "permission denied")
+}
+
 func (x *xdpState) OnUpdate(protoBufMsg interface{}) {
 	log.WithField("msg", protoBufMsg).Debug("Received message")
 	switch msg := protoBufMsg.(type) {
@@ -137,18 +278,82 @@ func (x *xdpState) OnUpdate(protoBufMsg interface{}) {
 		log.WithField("ipSetId", msg.Id).Debug("IP set delta update")
 		x.ipV4State.addMembersIPSet(msg.Id, membersToSet(msg.AddedMembers))
 		x.ipV4State.removeMembersIPSet(msg.Id, membersToSet(msg.RemovedMembers))
+		x.noteIPSetUpdate()
 	case *proto.IPSetUpdate:
 		log.WithField("ipSetId", msg.Id).Debug("IP set update")
 		x.ipV4State.replaceIPSet(msg.Id, membersToSet(msg.Members))
+		x.noteIPSetUpdate()
 	case *proto.IPSetRemove:
 		log.WithField("ipSetId", msg.Id).Debug("IP set remove")
 		x.ipV4State.removeIPSet(msg.Id)
+		x.noteIPSetUpdate()
 	case *proto.ActivePolicyUpdate:
 		log.WithField("id", msg.Id).Debug("Updating policy chains")
 		x.ipV4State.updatePolicy(*msg.Id, msg.Policy)
 	case *proto.ActivePolicyRemove:
 		log.WithField("id", msg.Id).Debug("Removing policy chains")
 		x.ipV4State.removePolicy(*msg.Id)
+	case *proto.BlocklistReasonUpdate:
+		log.WithFields(log.Fields{"cidr": msg.Cidr, "reason": msg.Reason}).Debug("Blocklist reason update")
+		x.common.blocklistReasons[msg.Cidr] = msg.Reason
+		x.common.dirtyReasons.Add(msg.Cidr)
+	case *proto.BlocklistReasonRemove:
+		log.WithField("cidr", msg.Cidr).Debug("Blocklist reason remove")
+		delete(x.common.blocklistReasons, msg.Cidr)
+		x.common.dirtyReasons.Add(msg.Cidr)
+	case *proto.BlocklistGraceUpdate:
+		log.WithField("cidr", msg.Cidr).Debug("Blocklist grace update")
+		x.common.graceCIDRs.Add(msg.Cidr)
+		x.common.graceListDirty = true
+	case *proto.BlocklistGraceRemove:
+		log.WithField("cidr", msg.Cidr).Debug("Blocklist grace remove")
+		x.common.graceCIDRs.Discard(msg.Cidr)
+		x.common.graceListDirty = true
+	case *proto.HostMetadataUpdate:
+		if msg.Hostname == x.common.hostname && msg.Ipv4Addr != x.common.hostIP {
+			log.WithField("ip", msg.Ipv4Addr).Debug("Host IP update")
+			x.common.hostIP = msg.Ipv4Addr
+			x.common.allowListDirty = true
+		}
+	case *proto.HostMetadataRemove:
+		if msg.Hostname == x.common.hostname && x.common.hostIP != "" {
+			log.Debug("Host IP remove")
+			x.common.hostIP = ""
+			x.common.allowListDirty = true
+		}
+	case *proto.RouteUpdate:
+		switch msg.Type {
+		case proto.RouteType_LOCAL_WORKLOAD, proto.RouteType_LOCAL_HOST, proto.RouteType_LOCAL_TUNNEL:
+			if !x.common.localRouteCIDRs.Contains(msg.Dst) {
+				log.WithField("dst", msg.Dst).Debug("Local route update")
+				x.common.localRouteCIDRs.Add(msg.Dst)
+				x.common.allowListDirty = true
+			}
+		default:
+			x.removeLocalRoute(msg.Dst)
+		}
+	case *proto.RouteRemove:
+		x.removeLocalRoute(msg.Dst)
+	case *proto.WorkloadEndpointUpdate:
+		log.WithField("id", msg.Id).Debug("Workload endpoint update")
+		x.common.workloadCIDRs[*msg.Id] = msg.Endpoint.Ipv4Nets
+		x.common.allowListDirty = true
+	case *proto.WorkloadEndpointRemove:
+		log.WithField("id", msg.Id).Debug("Workload endpoint remove")
+		delete(x.common.workloadCIDRs, *msg.Id)
+		x.common.allowListDirty = true
+	case *ifaceStateUpdate:
+		x.recheckInterfaceSpeed(msg.Name)
+	case *ifaceAddrsUpdate:
+		x.recheckInterfaceSpeed(msg.Name)
+	}
+}
+
+func (x *xdpState) removeLocalRoute(dst string) {
+	if x.common.localRouteCIDRs.Contains(dst) {
+		log.WithField("dst", dst).Debug("Local route remove")
+		x.common.localRouteCIDRs.Discard(dst)
+		x.common.allowListDirty = true
 	}
 }
 
@@ -156,12 +361,33 @@ func (x *xdpState) CompleteDeferredWork() error {
 	return nil
 }
 
+// noteIPSetUpdate records the time of the first IP set update since the XDP maps were last
+// updated, which starts the debounce window.
+func (x *xdpState) noteIPSetUpdate() {
+	if x.common.firstPendingIPSetUpdate.IsZero() {
+		x.common.firstPendingIPSetUpdate = time.Now()
+	}
+}
+
+// UpdateDebounceRemaining returns how much longer the pending IP set updates should be held
+// back before they're written to the XDP maps, or 0 if they should be written now.
+func (x *xdpState) UpdateDebounceRemaining() time.Duration {
+	if x.common.updateDebounce <= 0 || x.common.firstPendingIPSetUpdate.IsZero() {
+		return 0
+	}
+	remaining := x.common.updateDebounce - time.Since(x.common.firstPendingIPSetUpdate)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
 func (x *xdpState) PopulateCallbacks(cbs *common.Callbacks) {
 	if x.ipV4State != nil {
 		cbIDs := []*common.CbID{
-			cbs.AddInterfaceV4.Append(x.ipV4State.addInterface),
-			cbs.RemoveInterfaceV4.Append(x.ipV4State.removeInterface),
-			cbs.UpdateInterfaceV4.Append(x.ipV4State.updateInterface),
+			cbs.AddInterfaceV4.Append(x.addInterfaceV4),
+			cbs.RemoveInterfaceV4.Append(x.removeInterfaceV4),
+			cbs.UpdateInterfaceV4.Append(x.updateInterfaceV4),
 			cbs.UpdateHostEndpointV4.Append(x.ipV4State.updateHostEndpoint),
 			cbs.RemoveHostEndpointV4.Append(x.ipV4State.removeHostEndpoint),
 		}
@@ -169,6 +395,100 @@ func (x *xdpState) PopulateCallbacks(cbs *common.Callbacks) {
 	}
 }
 
+// addInterfaceV4, removeInterfaceV4 and updateInterfaceV4 pass on the interfaces that host
+// endpoints resolve to, leaving out those that are too slow for XDP.
+func (x *xdpState) addInterfaceV4(ifaceName string, hostEPID proto.HostEndpointID) {
+	x.common.ifaceHostEPIDs[ifaceName] = hostEPID
+	if slow, _ := x.tooSlowForXDP(ifaceName); slow {
+		x.common.slowIfaces.Add(ifaceName)
+		return
+	}
+	x.ipV4State.addInterface(ifaceName, hostEPID)
+}
+
+func (x *xdpState) removeInterfaceV4(ifaceName string) {
+	delete(x.common.ifaceHostEPIDs, ifaceName)
+	if x.common.slowIfaces.Contains(ifaceName) {
+		x.common.slowIfaces.Discard(ifaceName)
+		return
+	}
+	x.ipV4State.removeInterface(ifaceName)
+}
+
+func (x *xdpState) updateInterfaceV4(ifaceName string, newHostEPID proto.HostEndpointID) {
+	x.common.ifaceHostEPIDs[ifaceName] = newHostEPID
+	if x.common.slowIfaces.Contains(ifaceName) {
+		return
+	}
+	x.ipV4State.updateInterface(ifaceName, newHostEPID)
+}
+
+// recheckInterfaceSpeed re-reads the link speed of an interface that a host endpoint resolves
+// to, after its state or addresses changed, and moves it into or out of the XDP state if it
+// has crossed minInterfaceSpeed.  For example, a link may come up at a lower speed than it had
+// before.  An interface whose speed can't be read stays where it is.
+func (x *xdpState) recheckInterfaceSpeed(ifaceName string) {
+	hostEPID, ok := x.common.ifaceHostEPIDs[ifaceName]
+	if !ok {
+		return
+	}
+	slow, known := x.tooSlowForXDP(ifaceName)
+	if !known {
+		return
+	}
+	wasSlow := x.common.slowIfaces.Contains(ifaceName)
+	if slow == wasSlow {
+		return
+	}
+
+	pds := x.ipV4State.pendingDiffState
+	if slow {
+		x.common.slowIfaces.Add(ifaceName)
+		delete(pds.NewIfaceNameToHostEpID, ifaceName)
+		delete(pds.IfaceEpIDChange, ifaceName)
+		x.ipV4State.removeInterface(ifaceName)
+		return
+	}
+
+	log.WithField("iface", ifaceName).Info(
+		"Interface's link speed is now at least XDPMinInterfaceSpeed; using XDP on it.")
+	x.common.slowIfaces.Discard(ifaceName)
+	if _, ok := x.ipV4State.currentState.IfaceNameToData[ifaceName]; ok && pds.IfaceNamesToDrop.Contains(ifaceName) {
+		// The interface hasn't been dropped from the XDP state yet; keep it instead.
+		pds.IfaceNamesToDrop.Discard(ifaceName)
+		x.ipV4State.updateInterface(ifaceName, hostEPID)
+		return
+	}
+	x.ipV4State.addInterface(ifaceName, hostEPID)
+}
+
+// tooSlowForXDP returns whether the interface reports a link speed below minInterfaceSpeed,
+// and whether its speed is known.  Generic XDP on a slow or virtual interface costs more than
+// it saves.  An interface whose speed is unknown, which includes one that is down, isn't
+// treated as slow.
+func (x *xdpState) tooSlowForXDP(ifaceName string) (slow, known bool) {
+	if x.common.minInterfaceSpeed <= 0 {
+		return false, true
+	}
+	logCxt := log.WithFields(log.Fields{
+		"iface":    ifaceName,
+		"minSpeed": x.common.minInterfaceSpeed,
+	})
+	speed, err := x.common.bpfLib.InterfaceSpeed(ifaceName)
+	if err != nil {
+		logCxt.WithError(err).Debug("Couldn't read the interface's link speed; not skipping XDP.")
+		return false, false
+	}
+	if speed >= x.common.minInterfaceSpeed {
+		return false, true
+	}
+	if !x.common.slowIfaces.Contains(ifaceName) {
+		logCxt.WithField("speed", speed).Info(
+			"Interface's link speed is below XDPMinInterfaceSpeed; skipping XDP on it.")
+	}
+	return true, true
+}
+
 func (x *xdpState) DepopulateCallbacks(cbs *common.Callbacks) {
 	if x.ipV4State != nil {
 		for _, id := range x.ipV4State.cbIDs {
@@ -178,8 +498,39 @@ func (x *xdpState) DepopulateCallbacks(cbs *common.Callbacks) {
 	}
 }
 
+// AttachToEndpoints starts following the interfaces that host endpoints resolve to, beginning
+// with those that they resolve to now.  It's used when XDP acceleration is turned on.
+func (x *xdpState) AttachToEndpoints(cbs *common.Callbacks, epSourceV4 endpointsSource) {
+	x.PopulateCallbacks(cbs)
+	if x.ipV4State != nil {
+		for ifaceName, hostEPID := range epSourceV4.GetActiveHostInterfaces() {
+			x.addInterfaceV4(ifaceName, hostEPID)
+		}
+	}
+}
+
+// DetachFromEndpoints stops following the interfaces that host endpoints resolve to and
+// forgets the ones it knew about.  It's used when XDP acceleration is turned off, once the XDP
+// programs are gone; the policies and IP sets are still tracked while XDP is off.
+func (x *xdpState) DetachFromEndpoints(cbs *common.Callbacks) {
+	x.DepopulateCallbacks(cbs)
+	x.common.ifaceHostEPIDs = make(map[string]proto.HostEndpointID)
+	x.common.slowIfaces.Clear()
+	if x.ipV4State != nil {
+		pds := x.ipV4State.pendingDiffState
+		pds.NewIfaceNameToHostEpID = make(map[string]proto.HostEndpointID)
+		pds.IfaceNamesToDrop.Clear()
+		pds.IfaceEpIDChange = make(map[string]proto.HostEndpointID)
+		x.ipV4State.currentState.IfaceNameToData = make(map[string]xdpIfaceData)
+		x.ipV4State.cleanupCache()
+	}
+}
+
 func (x *xdpState) QueueResync() {
 	x.common.needResync = true
+	x.common.reasonsNeedResync = true
+	x.common.allowListDirty = true
+	x.common.graceListDirty = true
 }
 
 func (x *xdpState) ProcessPendingDiffState(epSourceV4 endpointsSource) {
@@ -215,8 +566,15 @@ func (x *xdpState) ResyncIfNeeded(ipsSourceV4 ipsetsSource) error {
 
 func (x *xdpState) ApplyBPFActions(ipsSource ipsetsSource) error {
 	if x.ipV4State != nil {
-		memberCacheV4 := newXDPMemberCache(x.ipV4State.getBpfIPFamily(), x.common.bpfLib)
-		err := x.ipV4State.bpfActions.apply(memberCacheV4, x.ipV4State.ipsetIDsToMembers, newConvertingIPSetsSource(ipsSource), x.common.xdpModes)
+		memberCacheV4 := x.newMemberCacheV4()
+		x.ipV4State.bpfActions.RemoveMap.Iter(func(iface string) error {
+			delete(x.ipV4State.blocklistOverflow, iface)
+			return nil
+		})
+		err := x.ipV4State.bpfActions.apply(memberCacheV4, x.ipV4State.ipsetIDsToMembers, newConvertingIPSetsSource(ipsSource), x.ipV4State.xdpModesForIface(x.common.xdpModes), x.common.egressBlocklist, x.common.eventLog)
+		// Maps that were created or removed took their destination maps with them.
+		x.ipV4State.bpfActions.CreateMap.Iter(x.ipV4State.forgetDstMap)
+		x.ipV4State.bpfActions.RemoveMap.Iter(x.ipV4State.forgetDstMap)
 		x.ipV4State.bpfActions = newXDPBPFActions()
 		if err != nil {
 			log.WithError(err).Info("Applying BPF actions did not succeed. Queueing XDP resync.")
@@ -227,29 +585,240 @@ func (x *xdpState) ApplyBPFActions(ipsSource ipsetsSource) error {
 	return nil
 }
 
-func (x *xdpState) ProcessMemberUpdates() error {
+func (x *xdpState) ProcessMemberUpdates(ipsSourceV4 ipsetsSource) error {
+	x.common.firstPendingIPSetUpdate = time.Time{}
 	if x.ipV4State != nil {
-		memberCacheV4 := newXDPMemberCache(x.ipV4State.getBpfIPFamily(), x.common.bpfLib)
+		memberCacheV4 := x.newMemberCacheV4()
 		err := x.ipV4State.processMemberUpdates(memberCacheV4)
+		if err == nil {
+			err = x.ipV4State.syncDstMaps(memberCacheV4, newConvertingIPSetsSource(ipsSourceV4))
+		}
 		if err != nil {
 			log.WithError(err).Info("Processing member updates did not succeed. Queueing XDP resync.")
 			x.QueueResync()
 			return err
 		}
 	}
+	if err := x.syncBlocklistReasons(); err != nil {
+		log.WithError(err).Warn("Failed to update the blocklist reasons map.")
+		return err
+	}
+	if err := x.syncAllowList(); err != nil {
+		log.WithError(err).Warn("Failed to update the XDP allow-list.")
+		return err
+	}
+	if err := x.syncGraceList(); err != nil {
+		log.WithError(err).Warn("Failed to update the XDP grace list.")
+		return err
+	}
 	return nil
 }
 
+// desiredAllowList returns the IPv4 CIDRs that should be in the allow-list: the host's IP, its
+// local routes and the addresses of its workloads.
+func (x *xdpState) desiredAllowList() set.Set[string] {
+	c := &x.common
+	desired := set.New[string]()
+	add := func(cidr string) {
+		addIPv4CIDR(desired, cidr)
+	}
+	if c.hostIP != "" {
+		add(c.hostIP)
+	}
+	c.localRouteCIDRs.Iter(func(cidr string) error {
+		add(cidr)
+		return nil
+	})
+	for _, cidrs := range c.workloadCIDRs {
+		for _, cidr := range cidrs {
+			add(cidr)
+		}
+	}
+	return desired
+}
+
+// syncAllowList brings the allow-list up to date if any of the addresses that it's made from
+// have changed, or a resync is queued, by diffing it against the desired contents.
+func (x *xdpState) syncAllowList() error {
+	c := &x.common
+	if !c.allowListDirty {
+		return nil
+	}
+	err := syncHostCIDRMap(x.desiredAllowList(),
+		c.bpfLib.DumpXDPAllowMap, c.bpfLib.RemoveItemXDPAllowMap, c.bpfLib.UpdateXDPAllowMap)
+	if err != nil {
+		return err
+	}
+	c.allowListDirty = false
+	return nil
+}
+
+// syncGraceList brings the grace list up to date if the blocklist CIDRs that are in their
+// grace period have changed, or a resync is queued, by diffing it against the desired contents.
+func (x *xdpState) syncGraceList() error {
+	c := &x.common
+	if !c.graceListDirty {
+		return nil
+	}
+	desired := set.New[string]()
+	c.graceCIDRs.Iter(func(cidr string) error {
+		addIPv4CIDR(desired, cidr)
+		return nil
+	})
+	err := syncHostCIDRMap(desired,
+		c.bpfLib.DumpXDPGraceMap, c.bpfLib.RemoveItemXDPGraceMap, c.bpfLib.UpdateXDPGraceMap)
+	if err != nil {
+		return err
+	}
+	c.graceListDirty = false
+	return nil
+}
+
+// addIPv4CIDR adds the given CIDR, or IP, to the set in its canonical form if it's IPv4.
+func addIPv4CIDR(cidrs set.Set[string], cidr string) {
+	if !strings.Contains(cidr, "/") {
+		cidr += "/32"
+	}
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil || ipNet.IP.To4() == nil {
+		// The XDP program only looks at IPv4 packets.
+		return
+	}
+	cidrs.Add(ipNet.String())
+}
+
+// syncHostCIDRMap makes one of the host's XDP CIDR maps, which are shared by all interfaces,
+// hold the desired CIDRs.  It dumps the map and then removes and adds the CIDRs that differ.
+func syncHostCIDRMap(
+	desired set.Set[string],
+	dump func() (map[bpf.CIDRMapKey]uint32, error),
+	remove func(ip net.IP, mask int) error,
+	update func(ip net.IP, mask int) error,
+) error {
+	actual, err := dump()
+	if err != nil {
+		return err
+	}
+	for k := range actual {
+		ipNet := k.ToIPNet()
+		if desired.Contains(ipNet.String()) {
+			desired.Discard(ipNet.String())
+			continue
+		}
+		ones, _ := ipNet.Mask.Size()
+		if err := remove(ipNet.IP, ones); err != nil {
+			return err
+		}
+	}
+	desired.Iter(func(cidr string) error {
+		var ip *net.IP
+		var mask int
+		ip, mask, err = bpf.MemberToIPMask(cidr)
+		if err == nil {
+			err = update(*ip, mask)
+		}
+		if err != nil {
+			return set.StopIteration
+		}
+		return nil
+	})
+	return err
+}
+
+// syncBlocklistReasons brings the blocklist reasons map up to date.  After a resync is queued,
+// the map is dumped and compared against the reasons that Felix wants; otherwise only the CIDRs
+// whose reasons have changed are written.  CIDRs that can't be written stay dirty and are
+// retried next time.
+func (x *xdpState) syncBlocklistReasons() error {
+	c := &x.common
+	if c.reasonsNeedResync {
+		actual, err := c.bpfLib.DumpBlocklistReasonsMap()
+		if err != nil {
+			return err
+		}
+		seen := set.New[string]()
+		for k, reason := range actual {
+			cidr := k.ToIPNet().String()
+			seen.Add(cidr)
+			if desired, ok := c.blocklistReasons[cidr]; !ok || !blocklistReasonMatches(desired, reason) {
+				c.dirtyReasons.Add(cidr)
+			}
+		}
+		for cidr := range c.blocklistReasons {
+			if !seen.Contains(cidr) {
+				c.dirtyReasons.Add(cidr)
+			}
+		}
+		c.reasonsNeedResync = false
+	}
+
+	var lastErr error
+	c.dirtyReasons.Iter(func(cidr string) error {
+		ip, mask, err := bpf.MemberToIPMask(cidr)
+		if err != nil || ip.To4() == nil {
+			// The XDP blocklist is IPv4 only, so there's nowhere to record the reason.
+			log.WithField("cidr", cidr).Debug("Ignoring reason for non-IPv4 blocklist entry")
+			return set.RemoveItem
+		}
+		if reason, ok := c.blocklistReasons[cidr]; ok {
+			err = c.bpfLib.UpdateBlocklistReasonsMap(*ip, mask, reason)
+		} else {
+			err = c.bpfLib.RemoveItemBlocklistReasonsMap(*ip, mask)
+		}
+		if err != nil {
+			log.WithError(err).WithField("cidr", cidr).Warn("Failed to update blocklist reason")
+			lastErr = err
+			return nil
+		}
+		return set.RemoveItem
+	})
+	return lastErr
+}
+
+// blocklistReasonMatches returns true if the reason read from the map is the desired reason,
+// allowing for the map truncating long reasons.
+func blocklistReasonMatches(desired, actual string) bool {
+	if len(desired) > bpf.BlocklistReasonMaxLen {
+		desired = desired[:bpf.BlocklistReasonMaxLen]
+	}
+	return desired == actual
+}
+
+// newMemberCacheV4 returns a member cache for the IPv4 blocklists that enforces the limit on
+// their size.
+func (x *xdpState) newMemberCacheV4() *xdpMemberCache {
+	c := newXDPMemberCache(x.ipV4State.getBpfIPFamily(), x.common.bpfLib)
+	c.maxEntries = x.common.maxBlocklistEntries
+	c.overflow = x.ipV4State.blocklistOverflow
+	return c
+}
+
 func (x *xdpState) DropPendingDiffState() {
 	if x.ipV4State != nil {
 		x.ipV4State.pendingDiffState = newXDPPendingDiffState()
 	}
 }
 
+// TrackPendingDiffState brings the XDP state up to date with the pending changes to host
+// endpoints and policies without programming them, for when XDP acceleration is turned off.
+// Turning it back on queues a resync, which programs the dataplane from the up-to-date state.
+func (x *xdpState) TrackPendingDiffState(epSourceV4 endpointsSource) {
+	x.common.firstPendingIPSetUpdate = time.Time{}
+	if x.ipV4State != nil {
+		x.ipV4State.processPendingDiffState(epSourceV4)
+		x.ipV4State.bpfActions = newXDPBPFActions()
+		x.ipV4State.pendingDiffState = newXDPPendingDiffState()
+		x.ipV4State.currentState, x.ipV4State.newCurrentState = x.ipV4State.newCurrentState, nil
+		x.ipV4State.cleanupCache()
+	}
+}
+
 func (x *xdpState) UpdateState() {
 	if x.ipV4State != nil {
 		x.ipV4State.currentState, x.ipV4State.newCurrentState = x.ipV4State.newCurrentState, nil
 		x.ipV4State.cleanupCache()
+		xdpStatus.publish(x.ipV4State.blocklistSizes(), time.Now())
+		x.ipV4State.publishSkippedEntries()
 	}
 }
 
@@ -272,6 +841,16 @@ func (x *xdpState) WipeXDP() error {
 	if err := x.ApplyBPFActions(ipsSource); err != nil {
 		return err
 	}
+	if err := x.common.bpfLib.RemoveBlocklistReasonsMap(); err != nil {
+		return err
+	}
+	if err := x.common.bpfLib.RemoveXDPAllowMap(); err != nil {
+		return err
+	}
+	if err := x.common.bpfLib.RemoveXDPGraceMap(); err != nil {
+		return err
+	}
+	xdpStatus.reset()
 	x.QueueResync()
 	return nil
 }
@@ -300,8 +879,17 @@ type xdpIPState struct {
 	pendingDiffState  *xdpPendingDiffState
 	newCurrentState   *xdpSystemState
 	bpfActions        *xdpBPFActions
-	cbIDs             []*common.CbID
-	logCxt            *log.Entry
+	// dstMapContents holds what Felix believes is in the destination map of each
+	// interface; an interface's map is dumped when it has no entry.
+	dstMapContents map[string]set.Set[bpf.CIDRMapKey]
+	// blocklistOverflow holds, for each interface, the CIDRs that were left out of its
+	// blocklist because it was full, with their reference counts.
+	blocklistOverflow map[string]map[bpf.CIDRMapKey]uint32
+	// resynced is set once a resync has succeeded.  The first resync takes over whatever
+	// an earlier Felix left behind, so only the later ones count repairs.
+	resynced bool
+	cbIDs    []*common.CbID
+	logCxt   *log.Entry
 }
 
 type ipsetIDsToMembers struct {
@@ -435,6 +1023,8 @@ func newXDPIPState(ipFamily int) *xdpIPState {
 		currentState:      newXDPSystemState(),
 		pendingDiffState:  newXDPPendingDiffState(),
 		bpfActions:        newXDPBPFActions(),
+		dstMapContents:    make(map[string]set.Set[bpf.CIDRMapKey]),
+		blocklistOverflow: make(map[string]map[bpf.CIDRMapKey]uint32),
 		cbIDs:             nil,
 		logCxt:            log.WithField("family", ipFamily),
 	}
@@ -451,12 +1041,13 @@ func (s *xdpIPState) getBpfIPFamily() bpf.IPFamily {
 }
 
 // newXDPResyncState creates the xdpResyncState object, returning an error on failure.
-func (s *xdpIPState) newXDPResyncState(bpfLib bpf.BPFDataplane, ipsSource ipsetsSource, programTag string, xdpModes []bpf.XDPMode) (*xdpResyncState, error) {
+func (s *xdpIPState) newXDPResyncState(bpfLib bpf.BPFDataplane, ipsSource ipsetsSource, programTag string, xdpModes func(iface string) []bpf.XDPMode, egressBlocklist bool) (*xdpResyncState, error) {
 	xdpIfaces, err := bpfLib.GetXDPIfaces()
 	if err != nil {
 		return nil, err
 	}
 	s.logCxt.WithField("ifaces", xdpIfaces).Debug("Interfaces with XDP program installed.")
+	xdpStatus.setAllDetached()
 	ifacesWithProgs := make(map[string]progInfo, len(xdpIfaces))
 	for _, iface := range xdpIfaces {
 		tag, tagErr := bpfLib.GetXDPTag(iface)
@@ -471,14 +1062,28 @@ func (s *xdpIPState) newXDPResyncState(bpfLib bpf.BPFDataplane, ipsSource ipsets
 		}
 		if modeErr != nil {
 			bogosityReasons = append(bogosityReasons, fmt.Sprintf("error getting mode: %s", modeErr.Error()))
-		} else if !isValidMode(mode, xdpModes) {
-			bogosityReasons = append(bogosityReasons, fmt.Sprintf("installed program uses disallowed mode: %v", mode))
+		} else {
+			xdpStatus.setAttached(iface, mode)
+			if !isValidMode(mode, xdpModes(iface)) {
+				bogosityReasons = append(bogosityReasons, fmt.Sprintf("installed program uses disallowed mode: %v", mode))
+			}
+		}
+		// The TC egress program is attached and detached with the XDP program, so if it
+		// is missing or unwanted then the pair needs reinstalling.
+		if hasEgress, err := bpfLib.HasTCEgress(iface); err != nil {
+			bogosityReasons = append(bogosityReasons, fmt.Sprintf("error checking for TC egress program: %s", err.Error()))
+		} else if hasEgress != egressBlocklist {
+			bogosityReasons = append(bogosityReasons, fmt.Sprintf("TC egress program attached (%v) but wanted (%v)",
+				hasEgress, egressBlocklist))
 		}
 		if len(bogosityReasons) > 0 {
 			log.WithFields(log.Fields{
 				"reasons": bogosityReasons,
 				"iface":   iface,
 			}).Info("Program on interface is bogus in some way.  Will need to reapply it.")
+		} else {
+			gaugeXDPProgramMode.DeletePartialMatch(prometheus.Labels{"iface": iface})
+			gaugeXDPProgramMode.WithLabelValues(iface, mode.String()).Set(1)
 		}
 
 		ifacesWithProgs[iface] = progInfo{
@@ -582,6 +1187,32 @@ func isValidMode(mode bpf.XDPMode, xdpModes []bpf.XDPMode) bool {
 	return false
 }
 
+// xdpModesForIface returns a function that gives the modes to try, in order, when attaching
+// the XDP program to an interface.  If the interface's host endpoint overrides the XDP mode
+// then only that mode is allowed, otherwise the given defaults are used.
+func (s *xdpIPState) xdpModesForIface(defaults []bpf.XDPMode) func(iface string) []bpf.XDPMode {
+	return func(iface string) []bpf.XDPMode {
+		state := s.newCurrentState
+		if state == nil {
+			state = s.currentState
+		}
+		return xdpModesWithOverride(state.IfaceNameToData[iface].XDPMode, defaults)
+	}
+}
+
+// xdpModesWithOverride returns the XDP modes allowed by a host endpoint's XDPMode override, or
+// the defaults if there's no override.  An explicit "Generic" override is honoured even if
+// generic XDP is disabled in the Felix configuration.
+func xdpModesWithOverride(override string, defaults []bpf.XDPMode) []bpf.XDPMode {
+	switch override {
+	case string(apiv3.XDPModeNative):
+		return []bpf.XDPMode{bpf.XDPDriver}
+	case string(apiv3.XDPModeGeneric):
+		return []bpf.XDPMode{bpf.XDPGeneric}
+	}
+	return defaults
+}
+
 func (s *xdpIPState) getIPSetMembers(setID string, ipsSource ipsetsSource) (set.Set[string], error) {
 	return getIPSetMembers(s.ipsetIDsToMembers, setID, ipsSource)
 }
@@ -600,16 +1231,91 @@ func (s *xdpIPState) tryResync(common *xdpStateCommon, ipsSource ipsetsSource) e
 	defer func() {
 		s.logCxt.WithField("resyncDuration", time.Since(resyncStart)).Debug("Finished XDP resync.")
 	}()
+	// Note what Felix believes is in the blocklists before the resync forgets it, so that
+	// the resync can tell which of them it repairs.
+	programmed := s.programmedBlocklists()
 	s.ipsetIDsToMembers.Clear()
-	resyncState, err := s.newXDPResyncState(common.bpfLib, ipsSource, common.programTag, common.xdpModes)
+	s.dstMapContents = make(map[string]set.Set[bpf.CIDRMapKey])
+	// The resync adds whatever's missing from the blocklists, including the CIDRs that
+	// were left out, so they're left out again if there's still no room.
+	s.blocklistOverflow = make(map[string]map[bpf.CIDRMapKey]uint32)
+	resyncState, err := s.newXDPResyncState(common.bpfLib, ipsSource, common.programTag, s.xdpModesForIface(common.xdpModes), common.egressBlocklist)
 	if err != nil {
 		return err
 	}
 	s.fixupXDPProgramAndMapConsistency(resyncState)
 	s.fixupBlocklistContents(resyncState)
+	if s.resynced {
+		repairs := s.countResyncRepairs(resyncState, programmed)
+		counterXDPLifecycleOperations.WithLabelValues(xdpOpResyncRepair).Add(float64(repairs))
+	}
+	s.resynced = true
 	return nil
 }
 
+// programmedBlocklists returns what Felix believes is in the blocklist map of each interface
+// that needs XDP in the current state, from the IP set members that it last programmed, less
+// the CIDRs that were left out because the map was full.
+func (s *xdpIPState) programmedBlocklists() map[string]map[bpf.CIDRMapKey]uint32 {
+	memberToCIDRMapKey := getMemberToCIDRMapKeyFunc(s.getBpfIPFamily())
+	blocklists := make(map[string]map[bpf.CIDRMapKey]uint32)
+	for iface, data := range s.currentState.IfaceNameToData {
+		if !data.NeedsXDP() {
+			continue
+		}
+		contents := make(map[bpf.CIDRMapKey]uint32)
+		for _, setIDs := range data.PoliciesToSetIDs {
+			setIDs.Iter(func(setID string) error {
+				members, ok := s.ipsetIDsToMembers.GetCached(setID)
+				if !ok {
+					return nil
+				}
+				members.Iter(func(member string) error {
+					if key, err := memberToCIDRMapKey(member); err == nil {
+						contents[key]++
+					}
+					return nil
+				})
+				return nil
+			})
+		}
+		for key := range s.blocklistOverflow[iface] {
+			delete(contents, key)
+		}
+		blocklists[iface] = contents
+	}
+	return blocklists
+}
+
+// countResyncRepairs returns the number of interfaces whose XDP program or blocklist map the
+// resync found different from what Felix had programmed: a program or map that is missing,
+// unwanted, bogus or mismatched, or a map with the wrong contents.
+func (s *xdpIPState) countResyncRepairs(resyncState *xdpResyncState, programmed map[string]map[bpf.CIDRMapKey]uint32) int {
+	ifaces := set.New[string]()
+	for iface := range programmed {
+		ifaces.Add(iface)
+	}
+	for iface := range resyncState.ifacesWithProgs {
+		ifaces.Add(iface)
+	}
+	for iface := range resyncState.ifacesWithMaps {
+		ifaces.Add(iface)
+	}
+	repairs := 0
+	ifaces.Iter(func(iface string) error {
+		contents, wanted := programmed[iface]
+		prog, hasProg := resyncState.ifacesWithProgs[iface]
+		m, hasMap := resyncState.ifacesWithMaps[iface]
+		if hasProg != wanted || hasMap != wanted || prog.bogus || m.bogus || m.mismatched ||
+			(hasMap && !bpf.CIDRMapContentsEqual(m.contents, contents)) {
+			s.logCxt.WithField("iface", iface).Info("Resync found XDP out of step with what Felix programmed; repairing it.")
+			repairs++
+		}
+		return nil
+	})
+	return repairs
+}
+
 // fixupXDPProgramAndMapConsistency ensures that XDP programs are
 // installed on the proper network interfaces, are valid, and
 // reference the correct maps.
@@ -683,6 +1389,7 @@ func (s *xdpIPState) fixupXDPProgramAndMapConsistency(resyncState *xdpResyncStat
 
 			if !hasXDP && shouldHaveXDP {
 				s.bpfActions.InstallXDP.Add(iface)
+				s.bpfActions.XDPReasons[iface] = "resync: program missing"
 				s.bpfActions.UninstallXDP.Discard(iface)
 				if !mapExists {
 					s.bpfActions.CreateMap.Add(iface)
@@ -702,6 +1409,7 @@ func (s *xdpIPState) fixupXDPProgramAndMapConsistency(resyncState *xdpResyncStat
 			if hasXDP && !shouldHaveXDP {
 				s.bpfActions.InstallXDP.Discard(iface)
 				s.bpfActions.UninstallXDP.Add(iface)
+				s.bpfActions.XDPReasons[iface] = "resync: program not wanted"
 				if !mapExists {
 					s.bpfActions.CreateMap.Discard(iface)
 					s.bpfActions.RemoveMap.Discard(iface)
@@ -720,6 +1428,7 @@ func (s *xdpIPState) fixupXDPProgramAndMapConsistency(resyncState *xdpResyncStat
 					// map needs to be created.
 					s.bpfActions.InstallXDP.Add(iface)
 					s.bpfActions.UninstallXDP.Add(iface)
+					s.bpfActions.XDPReasons[iface] = "resync: map missing"
 					s.bpfActions.CreateMap.Add(iface)
 					s.bpfActions.RemoveMap.Discard(iface)
 				} else if mapBogus {
@@ -729,6 +1438,7 @@ func (s *xdpIPState) fixupXDPProgramAndMapConsistency(resyncState *xdpResyncStat
 					// maps. The map needs to be replaced.
 					s.bpfActions.InstallXDP.Add(iface)
 					s.bpfActions.UninstallXDP.Add(iface)
+					s.bpfActions.XDPReasons[iface] = "resync: map bogus"
 					s.bpfActions.CreateMap.Add(iface)
 					s.bpfActions.RemoveMap.Add(iface)
 				} else if mapMismatch {
@@ -739,6 +1449,7 @@ func (s *xdpIPState) fixupXDPProgramAndMapConsistency(resyncState *xdpResyncStat
 					// fine.
 					s.bpfActions.InstallXDP.Add(iface)
 					s.bpfActions.UninstallXDP.Add(iface)
+					s.bpfActions.XDPReasons[iface] = "resync: map mismatched"
 					s.bpfActions.CreateMap.Discard(iface)
 					s.bpfActions.RemoveMap.Discard(iface)
 				} else {
@@ -755,6 +1466,7 @@ func (s *xdpIPState) fixupXDPProgramAndMapConsistency(resyncState *xdpResyncStat
 			if hasXDP && hasBogusXDP && shouldHaveXDP {
 				s.bpfActions.InstallXDP.Add(iface)
 				s.bpfActions.UninstallXDP.Add(iface)
+				s.bpfActions.XDPReasons[iface] = "resync: program bogus"
 				if !mapExists {
 					s.bpfActions.CreateMap.Add(iface)
 					s.bpfActions.RemoveMap.Discard(iface)
@@ -905,6 +1617,48 @@ func (s *xdpIPState) getSetIDToRefCountFromNewState(iface string) map[string]uin
 	return setIDToRefCount
 }
 
+// blocklistSizes returns the number of CIDRs in the blocklist of each interface that needs
+// XDP in the current state.  A CIDR that's in more than one of an interface's IP sets has
+// one entry in the map, so it's only counted once.
+func (s *xdpIPState) blocklistSizes() map[string]int {
+	memberToCIDRMapKey := getMemberToCIDRMapKeyFunc(s.getBpfIPFamily())
+	sizes := make(map[string]int)
+	for iface, data := range s.currentState.IfaceNameToData {
+		if !data.NeedsXDP() {
+			continue
+		}
+		keys := set.New[bpf.CIDRMapKey]()
+		for _, setIDs := range data.PoliciesToSetIDs {
+			setIDs.Iter(func(setID string) error {
+				members, ok := s.ipsetIDsToMembers.GetCached(setID)
+				if !ok {
+					return nil
+				}
+				members.Iter(func(member string) error {
+					if key, err := memberToCIDRMapKey(member); err == nil {
+						keys.Add(key)
+					}
+					return nil
+				})
+				return nil
+			})
+		}
+		sizes[iface] = keys.Len()
+	}
+	return sizes
+}
+
+// publishSkippedEntries updates the metric of the number of CIDRs that have been left out
+// of each interface's blocklist.
+func (s *xdpIPState) publishSkippedEntries() {
+	gaugeXDPBlocklistSkippedEntries.Reset()
+	for iface, data := range s.currentState.IfaceNameToData {
+		if data.NeedsXDP() {
+			gaugeXDPBlocklistSkippedEntries.WithLabelValues(iface).Set(float64(len(s.blocklistOverflow[iface])))
+		}
+	}
+}
+
 type IfaceFlags uint8
 
 const (
@@ -970,6 +1724,7 @@ func (s *xdpIPState) processMemberUpdates(memberCache *xdpMemberCache) error {
 	// process member changes
 	changes := s.getMemberChanges()
 
+	updatedIfaces := set.New[string]()
 	for setID, change := range changes {
 		ifacesToRefCounts := s.getAffectedIfaces(setID)
 		s.logCxt.WithFields(log.Fields{
@@ -999,12 +1754,95 @@ func (s *xdpIPState) processMemberUpdates(memberCache *xdpMemberCache) error {
 			if err := processMemberAdds(memberCache, iface, miAdd); err != nil {
 				return err
 			}
+			if miDelete.Len() > 0 || miAdd.Len() > 0 {
+				updatedIfaces.Add(iface)
+			}
 		}
 	}
+	counterXDPLifecycleOperations.WithLabelValues(xdpOpMapUpdate).Add(float64(updatedIfaces.Len()))
 	s.logCxt.Debug("Updating ipsetIDsToMembers cache.")
 
 	s.ipsetIDsToMembers.UpdateCache()
+	if len(changes) > 0 {
+		counterXDPMemberUpdateBatches.Inc()
+	}
+
+	return nil
+}
+
+// forgetDstMap drops what Felix believes is in the destination map of an interface, so that
+// the map is dumped the next time that it's synced.
+func (s *xdpIPState) forgetDstMap(iface string) error {
+	delete(s.dstMapContents, iface)
+	return nil
+}
+
+// syncDstMaps brings the destination map of each interface that needs XDP into line with
+// the members of the destination IP sets of its policies.  Unlike the blocklist maps, the
+// destination maps aren't reference counted; they're small enough to just diff.
+func (s *xdpIPState) syncDstMaps(memberCache *xdpMemberCache, ipsSource ipsetsSource) error {
+	if s.newCurrentState == nil {
+		return nil
+	}
+	for iface, data := range s.newCurrentState.IfaceNameToData {
+		if !data.NeedsXDP() {
+			continue
+		}
+		var desired set.Set[bpf.CIDRMapKey] = set.New[bpf.CIDRMapKey]()
+		for _, setIDs := range data.PoliciesToDstSetIDs {
+			for _, setID := range setIDs.Slice() {
+				members, err := s.getIPSetMembers(setID, ipsSource)
+				if err != nil {
+					return err
+				}
+				for _, member := range members.Slice() {
+					key, err := memberCache.GetCIDRMapKeyForMember(member)
+					if err != nil {
+						return err
+					}
+					desired.Add(key)
+				}
+			}
+		}
+
+		actual, ok := s.dstMapContents[iface]
+		if !ok {
+			dump, err := memberCache.bpfLib.DumpDstCIDRMap(iface, memberCache.GetFamily())
+			if err != nil {
+				return err
+			}
+			actual = set.New[bpf.CIDRMapKey]()
+			for key := range dump {
+				actual.Add(key)
+			}
+			s.dstMapContents[iface] = actual
+		}
 
+		for _, key := range setDifference(actual, desired).Slice() {
+			ipnet := key.ToIPNet()
+			ones, _ := ipnet.Mask.Size()
+			s.logCxt.WithFields(log.Fields{
+				"iface": iface,
+				"cidr":  ipnet,
+			}).Debug("Removing destination from XDP destination map.")
+			if err := memberCache.bpfLib.RemoveItemDstCIDRMap(iface, memberCache.GetFamily(), ipnet.IP, ones); err != nil {
+				return err
+			}
+			actual.Discard(key)
+		}
+		for _, key := range setDifference(desired, actual).Slice() {
+			ipnet := key.ToIPNet()
+			ones, _ := ipnet.Mask.Size()
+			s.logCxt.WithFields(log.Fields{
+				"iface": iface,
+				"cidr":  ipnet,
+			}).Debug("Adding destination to XDP destination map.")
+			if err := memberCache.bpfLib.UpdateDstCIDRMap(iface, memberCache.GetFamily(), ipnet.IP, ones); err != nil {
+				return err
+			}
+			actual.Add(key)
+		}
+	}
 	return nil
 }
 
@@ -1075,6 +1913,7 @@ func (s *xdpIPState) processPendingDiffState(epSource endpointsSource) {
 		}
 		if dropXDP {
 			ba.UninstallXDP.Add(ifName)
+			ba.XDPReasons[ifName] = "interface removed"
 			ba.RemoveMap.Add(ifName)
 		}
 
@@ -1204,6 +2043,7 @@ func (s *xdpIPState) processPendingDiffState(epSource endpointsSource) {
 					return nil
 				})
 				newCs.IfaceNameToData[ifaceName].PoliciesToSetIDs[policyID] = newSetIDs
+				newCs.IfaceNameToData[ifaceName].PoliciesToDstSetIDs[policyID] = getDstSetIDs(rules)
 			} else {
 				s.logCxt.WithFields(log.Fields{
 					"iface":      ifaceName,
@@ -1218,6 +2058,7 @@ func (s *xdpIPState) processPendingDiffState(epSource endpointsSource) {
 				}).Info("Policy can not be optimized.")
 				// this means that new policy can't be optimized
 				delete(newCs.IfaceNameToData[ifaceName].PoliciesToSetIDs, policyID)
+				delete(newCs.IfaceNameToData[ifaceName].PoliciesToDstSetIDs, policyID)
 			}
 		}
 		if rules != nil {
@@ -1234,9 +2075,11 @@ func (s *xdpIPState) processPendingDiffState(epSource endpointsSource) {
 		newNeedsXDP := newData.NeedsXDP()
 		if oldNeedsXDP && !newNeedsXDP {
 			ba.UninstallXDP.Add(ifaceName)
+			ba.XDPReasons[ifaceName] = "policy changed"
 			ba.RemoveMap.Add(ifaceName)
 		} else if !oldNeedsXDP && newNeedsXDP {
 			ba.InstallXDP.Add(ifaceName)
+			ba.XDPReasons[ifaceName] = "policy changed"
 			ba.CreateMap.Add(ifaceName)
 		}
 		return nil
@@ -1284,6 +2127,7 @@ func dumpSetToString(s set.Set[string]) string {
 
 func (s *xdpIPState) processHostEndpointChange(ifaceName string, oldData *xdpIfaceData, newHepID proto.HostEndpointID, newEP *proto.HostEndpoint, changeInMaps map[string]map[string]int) {
 	policiesToSetIDs := make(map[proto.PolicyID]set.Set[string] /*<string>*/)
+	policiesToDstSetIDs := make(map[proto.PolicyID]set.Set[string] /*<string>*/)
 	oldSetIDs := make(map[string]int)
 	for _, setIDs := range oldData.PoliciesToSetIDs {
 		setIDs.Iter(func(setID string) error {
@@ -1313,6 +2157,7 @@ func (s *xdpIPState) processHostEndpointChange(ifaceName string, oldData *xdpIfa
 
 		rulesSetIDs := getSetIDs(rules)
 		policiesToSetIDs[policyID] = rulesSetIDs
+		policiesToDstSetIDs[policyID] = getDstSetIDs(rules)
 
 		rulesSetIDs.Iter(func(setID string) error {
 			newSetIDs[setID] += 1
@@ -1326,18 +2171,27 @@ func (s *xdpIPState) processHostEndpointChange(ifaceName string, oldData *xdpIfa
 	}).Debug("Processing host endpoint change.")
 
 	newData := xdpIfaceData{
-		EpID:             newHepID,
-		PoliciesToSetIDs: policiesToSetIDs,
+		EpID:                newHepID,
+		PoliciesToSetIDs:    policiesToSetIDs,
+		PoliciesToDstSetIDs: policiesToDstSetIDs,
+		XDPMode:             newEP.GetXdpMode(),
 	}
 	s.newCurrentState.IfaceNameToData[ifaceName] = newData
 	oldNeedsXDP := oldData.NeedsXDP()
 	newNeedsXDP := newData.NeedsXDP()
 	if oldNeedsXDP && !newNeedsXDP {
 		s.bpfActions.UninstallXDP.Add(ifaceName)
+		s.bpfActions.XDPReasons[ifaceName] = "host endpoint changed"
 		s.bpfActions.RemoveMap.Add(ifaceName)
 	} else if !oldNeedsXDP && newNeedsXDP {
 		s.bpfActions.InstallXDP.Add(ifaceName)
+		s.bpfActions.XDPReasons[ifaceName] = "host endpoint changed"
 		s.bpfActions.CreateMap.Add(ifaceName)
+	} else if oldNeedsXDP && newNeedsXDP && oldData.XDPMode != newData.XDPMode {
+		// The program needs to be reattached in the new mode; the map can stay.
+		s.bpfActions.UninstallXDP.Add(ifaceName)
+		s.bpfActions.InstallXDP.Add(ifaceName)
+		s.bpfActions.XDPReasons[ifaceName] = "XDP mode changed"
 	}
 	m, ok := changeInMaps[ifaceName]
 	if !ok {
@@ -1386,6 +2240,16 @@ func getSetIDs(rules *xdpRules) set.Set[string] /*<string>*/ {
 	return setIDs
 }
 
+func getDstSetIDs(rules *xdpRules) set.Set[string] /*<string>*/ {
+	setIDs := set.New[string]()
+	for _, rule := range rules.Rules {
+		for _, setID := range rule.DstSetIDs {
+			setIDs.Add(setID)
+		}
+	}
+	return setIDs
+}
+
 func (s *xdpIPState) getLatestRulesForPolicyID(policyID proto.PolicyID) *xdpRules {
 	logCxt := s.logCxt.WithField("policyID", policyID.String())
 	rules, ok := s.pendingDiffState.PoliciesToUpdate[policyID]
@@ -1440,7 +2304,8 @@ func xdpRulesFromProtoRules(inboundRules, outboundRules []*proto.Rule) (xdpRules
 	if isValid {
 		xdpRules.Rules = []xdpRule{
 			{
-				SetIDs: inboundRules[0].SrcIpSetIds,
+				SetIDs:    inboundRules[0].SrcIpSetIds,
+				DstSetIDs: inboundRules[0].DstIpSetIds,
 			},
 		}
 	}
@@ -1463,8 +2328,9 @@ func isValidRuleForXDP(rule *proto.Rule) bool {
 		len(rule.SrcNet) == 0 &&
 		len(rule.SrcPorts) == 0 &&
 		len(rule.SrcNamedPortIpSetIds) == 0 &&
-		// have only a single ip-only selector
-		len(rule.SrcIpSetIds) == 1 &&
+		// have only a single ip-only selector, either on the
+		// source or on the destination
+		len(rule.SrcIpSetIds)+len(rule.DstIpSetIds) == 1 &&
 		rule.NotProtocol == nil &&
 		len(rule.NotSrcNet) == 0 &&
 		len(rule.NotSrcPorts) == 0 &&
@@ -1473,11 +2339,10 @@ func isValidRuleForXDP(rule *proto.Rule) bool {
 		// have no icmp stuff
 		rule.Icmp == nil &&
 		rule.NotIcmp == nil &&
-		// have no destination stuff
+		// have no other destination stuff
 		len(rule.DstNet) == 0 &&
 		len(rule.DstPorts) == 0 &&
 		len(rule.DstNamedPortIpSetIds) == 0 &&
-		len(rule.DstIpSetIds) == 0 &&
 		len(rule.DstIpPortSetIds) == 0 &&
 		len(rule.NotDstNet) == 0 &&
 		len(rule.NotDstPorts) == 0 &&
@@ -1554,6 +2419,11 @@ func (s *xdpIPState) isSetIDInCurrentState(setID string) bool {
 				return true
 			}
 		}
+		for _, setIDs := range data.PoliciesToDstSetIDs {
+			if setIDs.Contains(setID) {
+				return true
+			}
+		}
 	}
 	return false
 }
@@ -1682,6 +2552,46 @@ type xdpStateCommon struct {
 	needResync bool
 	bpfLib     bpf.BPFDataplane
 	xdpModes   []bpf.XDPMode
+	eventLog   *xdpEventLog
+
+	updateDebounce          time.Duration
+	firstPendingIPSetUpdate time.Time
+
+	// egressBlocklist is true if the blocklist also applies to the host's outbound
+	// traffic, which is dropped by a TC egress program.
+	egressBlocklist bool
+
+	// maxBlocklistEntries limits the number of CIDRs in each interface's blocklist; zero
+	// means no limit.
+	maxBlocklistEntries int
+
+	// minInterfaceSpeed is the slowest link speed, in Mbit/s, of an interface that Felix
+	// attaches XDP programs to; zero means any speed.  ifaceHostEPIDs maps each interface that
+	// a host endpoint resolves to to the endpoint's ID.  slowIfaces holds those that were too
+	// slow when their speed was last checked, which the XDP state doesn't track.
+	minInterfaceSpeed int
+	ifaceHostEPIDs    map[string]proto.HostEndpointID
+	slowIfaces        set.Set[string]
+
+	// blocklistReasons holds the reason that Felix wants to record for each blocklisted
+	// CIDR, dirtyReasons the CIDRs whose reason may not be in the map yet.
+	blocklistReasons  map[string]string
+	dirtyReasons      set.Set[string]
+	reasonsNeedResync bool
+
+	// hostname is the name of this node.  Its IP, its local routes and the addresses of its
+	// workloads make up the allow-list; allowListDirty is true if they may have changed since
+	// the allow-list was last written.
+	hostname        string
+	hostIP          string
+	localRouteCIDRs set.Set[string]
+	workloadCIDRs   map[proto.WorkloadEndpointID][]string
+	allowListDirty  bool
+
+	// graceCIDRs holds the blocklist CIDRs that are in their grace period; graceListDirty is
+	// true if they may have changed since the grace list was last written.
+	graceCIDRs     set.Set[string]
+	graceListDirty bool
 }
 
 type xdpSystemState struct {
@@ -1762,6 +2672,11 @@ type xdpBPFActions struct {
 	// unloaded/detached
 	UninstallXDP set.Set[string]
 
+	// keys are interface names, values are the reasons why the
+	// XDP program is being installed, uninstalled or reinstalled
+	// there, for the XDP event log
+	XDPReasons map[string]string
+
 	// Resync fallout
 	// keys are interface names, values are maps, where keys are
 	// members and values are ref counts
@@ -1777,6 +2692,7 @@ func newXDPBPFActions() *xdpBPFActions {
 		RemoveFromMap: make(map[string]map[string]uint32),
 		InstallXDP:    set.New[string](),
 		UninstallXDP:  set.New[string](),
+		XDPReasons:    make(map[string]string),
 		MembersToDrop: make(map[string]map[string]uint32),
 		MembersToAdd:  make(map[string]map[string]uint32),
 	}
@@ -1785,11 +2701,28 @@ func newXDPBPFActions() *xdpBPFActions {
 // apply processes the contents of BPF actions - uninstalls and
 // installs XDP programs, creates and removes BPF maps, adds and
 // removes whole ipsets into/from the BPF maps, adds and removes
-// certain members to/from BPF maps.
-func (a *xdpBPFActions) apply(memberCache *xdpMemberCache, ipsetIDsToMembers *ipsetIDsToMembers, ipsSource ipsetsSource, xdpModes []bpf.XDPMode) error {
+// certain members to/from BPF maps. Attaching, detaching and
+// reloading XDP programs is recorded in the event log. If
+// egressBlocklist is true, a TC egress program is attached along
+// with each XDP program; it is always detached with the XDP program.
+func (a *xdpBPFActions) apply(memberCache *xdpMemberCache, ipsetIDsToMembers *ipsetIDsToMembers, ipsSource ipsetsSource, xdpModesForIface func(iface string) []bpf.XDPMode, egressBlocklist bool, eventLog *xdpEventLog) error {
 	var opErr error
 	logCxt := log.WithField("family", memberCache.GetFamily().String())
 
+	// programTag is only needed for the event log, so avoid running
+	// bpftool for it otherwise.
+	programTag := func(iface string) string {
+		if eventLog == nil {
+			return ""
+		}
+		tag, err := memberCache.bpfLib.GetXDPTag(iface)
+		if err != nil {
+			logCxt.WithError(err).WithField("iface", iface).Debug("Failed to get XDP program tag.")
+			return ""
+		}
+		return tag
+	}
+
 	// used for dropping programs, to handle the case when generic
 	// xdp is currently disabled and we need to drop a program
 	// installed in generic mode by previous felix instance which
@@ -1799,6 +2732,11 @@ func (a *xdpBPFActions) apply(memberCache *xdpMemberCache, ipsetIDsToMembers *ip
 	a.UninstallXDP.Iter(func(iface string) error {
 		var removeErrs []error
 		logCxt.WithField("iface", iface).Debug("Removing XDP programs.")
+		tag := programTag(iface)
+		if err := memberCache.bpfLib.RemoveTCEgress(iface); err != nil {
+			opErr = fmt.Errorf("failed to remove TC egress program from %s: %v", iface, err)
+			return set.StopIteration
+		}
 		for _, mode := range allXDPModes {
 			if err := memberCache.bpfLib.RemoveXDP(iface, mode); err != nil {
 				removeErrs = append(removeErrs, err)
@@ -1814,6 +2752,15 @@ func (a *xdpBPFActions) apply(memberCache *xdpMemberCache, ipsetIDsToMembers *ip
 			opErr = fmt.Errorf("failed to remove XDP program from %s: %v", iface, removeErrs)
 			return set.StopIteration
 		}
+		gaugeXDPProgramMode.DeletePartialMatch(prometheus.Labels{"iface": iface})
+		xdpStatus.setDetached(iface)
+		if !a.InstallXDP.Contains(iface) {
+			// Otherwise, it's a reload, which is recorded once the
+			// program is loaded again.
+			eventLog.record(xdpEventDetach, iface, "", tag, a.XDPReasons[iface])
+			counterXDPProgramOperations.Inc()
+			counterXDPLifecycleOperations.WithLabelValues(xdpOpDetach).Inc()
+		}
 		return nil
 	})
 	if opErr != nil {
@@ -1838,12 +2785,25 @@ func (a *xdpBPFActions) apply(memberCache *xdpMemberCache, ipsetIDsToMembers *ip
 			opErr = err
 			return set.StopIteration
 		}
+		counterXDPLifecycleOperations.WithLabelValues(xdpOpMapCreate).Inc()
 		return nil
 	})
 	if opErr != nil {
 		return opErr
 	}
 
+	// Each interface whose blocklist is written to counts as one map update, however many
+	// entries change.
+	updatedIfaces := set.New[string]()
+	noteUpdate := func(iface string, mi memberIter) {
+		if mi.Len() > 0 {
+			updatedIfaces.Add(iface)
+		}
+	}
+	defer func() {
+		counterXDPLifecycleOperations.WithLabelValues(xdpOpMapUpdate).Add(float64(updatedIfaces.Len()))
+	}()
+
 	for iface, memberMap := range a.MembersToAdd {
 		mi := &memberIterMap{
 			memberMap: memberMap,
@@ -1851,6 +2811,7 @@ func (a *xdpBPFActions) apply(memberCache *xdpMemberCache, ipsetIDsToMembers *ip
 		if err := processMemberAdds(memberCache, iface, mi); err != nil {
 			return err
 		}
+		noteUpdate(iface, mi)
 	}
 
 	for iface, setIDMap := range a.AddToMap {
@@ -1871,6 +2832,7 @@ func (a *xdpBPFActions) apply(memberCache *xdpMemberCache, ipsetIDsToMembers *ip
 			if err := processMemberAdds(memberCache, iface, mi); err != nil {
 				return err
 			}
+			noteUpdate(iface, mi)
 		}
 	}
 
@@ -1882,6 +2844,7 @@ func (a *xdpBPFActions) apply(memberCache *xdpMemberCache, ipsetIDsToMembers *ip
 		if err := processMemberDeletions(memberCache, iface, mi); err != nil {
 			return err
 		}
+		noteUpdate(iface, mi)
 	}
 
 	for iface, setIDMap := range a.RemoveFromMap {
@@ -1902,20 +2865,48 @@ func (a *xdpBPFActions) apply(memberCache *xdpMemberCache, ipsetIDsToMembers *ip
 			if err := processMemberDeletions(memberCache, iface, mi); err != nil {
 				return err
 			}
+			noteUpdate(iface, mi)
 		}
 	}
 
 	a.InstallXDP.Iter(func(iface string) error {
 		logCxt.WithField("iface", iface).Debug("Loading XDP program.")
 		var loadErrs []error
-		for _, mode := range xdpModes {
-			if err := memberCache.bpfLib.LoadXDPAuto(iface, mode); err != nil {
+		xdpModes := xdpModesForIface(iface)
+		for i, mode := range xdpModes {
+			err := memberCache.bpfLib.SupportsXDPOnInterface(iface, mode)
+			if err == nil {
+				err = memberCache.bpfLib.LoadXDPAuto(iface, mode)
+			}
+			if err != nil {
 				loadErrs = append(loadErrs, err)
+				if i+1 < len(xdpModes) {
+					// Typically, the driver doesn't support this mode; try the next,
+					// less efficient, one.
+					logCxt.WithError(err).WithFields(log.Fields{
+						"iface": iface,
+						"mode":  mode,
+					}).Infof("Failed to load XDP program, falling back to %v mode.", xdpModes[i+1])
+				}
 			} else {
 				logCxt.WithFields(log.Fields{
 					"iface": iface,
 					"mode":  mode,
 				}).Debug("Loading XDP program succeeded.")
+				gaugeXDPProgramMode.DeletePartialMatch(prometheus.Labels{"iface": iface})
+				gaugeXDPProgramMode.WithLabelValues(iface, mode.String()).Set(1)
+				xdpStatus.setAttached(iface, mode)
+				event := xdpEventAttach
+				if a.UninstallXDP.Contains(iface) {
+					event = xdpEventReload
+				}
+				eventLog.record(event, iface, mode.String(), programTag(iface), a.XDPReasons[iface])
+				counterXDPProgramOperations.Inc()
+				if event == xdpEventReload {
+					counterXDPLifecycleOperations.WithLabelValues(xdpOpReload).Inc()
+				} else {
+					counterXDPLifecycleOperations.WithLabelValues(xdpOpAttach).Inc()
+				}
 				loadErrs = nil
 				break
 			}
@@ -1924,6 +2915,13 @@ func (a *xdpBPFActions) apply(memberCache *xdpMemberCache, ipsetIDsToMembers *ip
 			opErr = fmt.Errorf("failed to load XDP program from %s: %v", iface, loadErrs)
 			return set.StopIteration
 		}
+		if egressBlocklist {
+			if err := memberCache.bpfLib.LoadTCEgressAuto(iface); err != nil {
+				opErr = fmt.Errorf("failed to load TC egress program to %s: %v", iface, err)
+				return set.StopIteration
+			}
+			logCxt.WithField("iface", iface).Info("Loading TC egress program succeeded.")
+		}
 		return nil
 	})
 	if opErr != nil {
@@ -2020,7 +3018,8 @@ func processMemberAdds(memberCache *xdpMemberCache, iface string, mi memberIter)
 	if err != nil {
 		return err
 	}
-	return mi.Iter(func(member string, refCount uint32) error {
+	numSkipped := 0
+	err = mi.Iter(func(member string, refCount uint32) error {
 		ip, mask, err := bpf.MemberToIPMask(member)
 		if err != nil {
 			return err
@@ -2029,7 +3028,15 @@ func processMemberAdds(memberCache *xdpMemberCache, iface string, mi memberIter)
 		if err != nil {
 			return err
 		}
-		if bpfRefCount, ok := bpfMembers[mapKey]; ok {
+		if overflowRefCount, ok := memberCache.overflow[iface][mapKey]; ok {
+			logCxt.WithFields(log.Fields{
+				"iface":    iface,
+				"oldCount": overflowRefCount,
+				"newCount": overflowRefCount + refCount,
+				"member":   member,
+			}).Debug("Updating refcount of a member left out of BPF blocklist map.")
+			memberCache.overflow[iface][mapKey] = overflowRefCount + refCount
+		} else if bpfRefCount, ok := bpfMembers[mapKey]; ok {
 			logCxt.WithFields(log.Fields{
 				"iface":    iface,
 				"oldCount": bpfRefCount,
@@ -2040,6 +3047,14 @@ func processMemberAdds(memberCache *xdpMemberCache, iface string, mi memberIter)
 			if err := memberCache.bpfLib.UpdateCIDRMap(iface, memberCache.GetFamily(), *ip, mask, bpfRefCount+refCount); err != nil {
 				return err
 			}
+		} else if memberCache.IsFull(len(bpfMembers)) {
+			logCxt.WithFields(log.Fields{
+				"iface":    iface,
+				"refCount": refCount,
+				"member":   member,
+			}).Debug("BPF blocklist map is full, leaving out a member.")
+			memberCache.addOverflow(iface, mapKey, refCount)
+			numSkipped++
 		} else {
 			logCxt.WithFields(log.Fields{
 				"iface":    iface,
@@ -2053,6 +3068,15 @@ func processMemberAdds(memberCache *xdpMemberCache, iface string, mi memberIter)
 		}
 		return nil
 	})
+	if numSkipped > 0 {
+		logCxt.WithFields(log.Fields{
+			"iface":      iface,
+			"maxEntries": memberCache.maxEntries,
+			"numSkipped": numSkipped,
+			"numLeftOut": len(memberCache.overflow[iface]),
+		}).Warn("XDP blocklist is full; some CIDRs are not blocked by XDP.")
+	}
+	return err
 }
 
 func processMemberDeletions(memberCache *xdpMemberCache, iface string, mi memberIter) error {
@@ -2064,7 +3088,7 @@ func processMemberDeletions(memberCache *xdpMemberCache, iface string, mi member
 	if err != nil {
 		return err
 	}
-	return mi.Iter(func(member string, refCount uint32) error {
+	err = mi.Iter(func(member string, refCount uint32) error {
 		ip, mask, err := bpf.MemberToIPMask(member)
 		if err != nil {
 			return err
@@ -2073,7 +3097,19 @@ func processMemberDeletions(memberCache *xdpMemberCache, iface string, mi member
 		if err != nil {
 			return err
 		}
-		if bpfRefCount, ok := bpfMembers[mapKey]; ok {
+		if overflowRefCount, ok := memberCache.overflow[iface][mapKey]; ok {
+			if overflowRefCount < refCount {
+				return fmt.Errorf("wanted to drop refcount of %s (%d) by %d, which would lead to negative refcount", member, overflowRefCount, refCount)
+			} else if overflowRefCount == refCount {
+				logCxt.WithFields(log.Fields{
+					"iface":  iface,
+					"member": member,
+				}).Debug("Forgetting a member left out of BPF blocklist map.")
+				delete(memberCache.overflow[iface], mapKey)
+			} else {
+				memberCache.overflow[iface][mapKey] = overflowRefCount - refCount
+			}
+		} else if bpfRefCount, ok := bpfMembers[mapKey]; ok {
 			if bpfRefCount < refCount {
 				logCxt.WithFields(log.Fields{
 					"iface":    iface,
@@ -2108,11 +3144,60 @@ func processMemberDeletions(memberCache *xdpMemberCache, iface string, mi member
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	return promoteOverflow(memberCache, iface)
+}
+
+// promoteOverflow moves CIDRs that were left out of an interface's blocklist into it, for as
+// long as there's room.  The CIDRs are taken in order so that the same ones are blocked
+// whichever order they were left out in.
+func promoteOverflow(memberCache *xdpMemberCache, iface string) error {
+	overflow := memberCache.overflow[iface]
+	if len(overflow) == 0 {
+		return nil
+	}
+	bpfMembers, err := memberCache.GetMembers(iface)
+	if err != nil {
+		return err
+	}
+	keys := make([]bpf.CIDRMapKey, 0, len(overflow))
+	for k := range overflow {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ToIPNet().String() < keys[j].ToIPNet().String()
+	})
+	for _, k := range keys {
+		if memberCache.IsFull(len(bpfMembers)) {
+			break
+		}
+		ipNet := k.ToIPNet()
+		mask, _ := ipNet.Mask.Size()
+		log.WithFields(log.Fields{
+			"iface":    iface,
+			"refCount": overflow[k],
+			"member":   ipNet.String(),
+		}).Debug("Adding a member that was left out to BPF blocklist map.")
+		if err := memberCache.bpfLib.UpdateCIDRMap(iface, memberCache.GetFamily(), ipNet.IP, mask, overflow[k]); err != nil {
+			return err
+		}
+		bpfMembers[k] = overflow[k]
+		delete(overflow, k)
+	}
+	return nil
 }
 
 type xdpIfaceData struct {
 	EpID             proto.HostEndpointID
 	PoliciesToSetIDs map[proto.PolicyID]set.Set[string]
+	// PoliciesToDstSetIDs holds the IP sets of destinations that the policies deny
+	// to all sources; their members go in the interface's destination map rather
+	// than its blocklist.
+	PoliciesToDstSetIDs map[proto.PolicyID]set.Set[string]
+	// XDPMode is the host endpoint's XDP mode override, if any.
+	XDPMode string
 }
 
 func (data xdpIfaceData) Copy() xdpIfaceData {
@@ -2123,6 +3208,10 @@ func (data xdpIfaceData) Copy() xdpIfaceData {
 		// just strings
 		new.PoliciesToSetIDs[k] = v.Copy()
 	}
+	new.PoliciesToDstSetIDs = make(map[proto.PolicyID]set.Set[string], len(data.PoliciesToDstSetIDs))
+	for k, v := range data.PoliciesToDstSetIDs {
+		new.PoliciesToDstSetIDs[k] = v.Copy()
+	}
 	return new
 }
 
@@ -2132,6 +3221,11 @@ func (d *xdpIfaceData) NeedsXDP() bool {
 			return true
 		}
 	}
+	for _, setIDs := range d.PoliciesToDstSetIDs {
+		if setIDs.Len() > 0 {
+			return true
+		}
+	}
 	return false
 }
 
@@ -2144,18 +3238,23 @@ func (rs xdpRules) Copy() xdpRules {
 	for _, r := range rs.Rules {
 		newSetIDs := make([]string, len(r.SetIDs))
 		copy(newSetIDs, r.SetIDs)
-		newRules = append(newRules, xdpRule{SetIDs: newSetIDs})
+		newDstSetIDs := append([]string(nil), r.DstSetIDs...)
+		newRules = append(newRules, xdpRule{SetIDs: newSetIDs, DstSetIDs: newDstSetIDs})
 	}
 
 	return xdpRules{Rules: newRules}
 }
 
 type xdpRule struct {
+	// SetIDs are the IP sets of the sources that the rule denies.
 	SetIDs []string
+	// DstSetIDs are the IP sets of the destinations that the rule denies.
+	DstSetIDs []string
 }
 
 type endpointsSource interface {
 	GetRawHostEndpoints() map[proto.HostEndpointID]*proto.HostEndpoint
+	GetActiveHostInterfaces() map[string]proto.HostEndpointID
 }
 
 var _ endpointsSource = &endpointManager{}
@@ -2183,6 +3282,11 @@ type xdpMemberCache struct {
 	cache                  map[string]map[bpf.CIDRMapKey]uint32
 	memberToCIDRMapKeyFunc func(member string) (bpf.CIDRMapKey, error)
 	bpfLib                 bpf.BPFDataplane
+
+	// maxEntries, if non-zero, limits the number of CIDRs in each blocklist map.  CIDRs
+	// that don't fit are recorded in overflow instead.
+	maxEntries int
+	overflow   map[string]map[bpf.CIDRMapKey]uint32
 }
 
 func newXDPMemberCache(family bpf.IPFamily, bpfLib bpf.BPFDataplane) *xdpMemberCache {
@@ -2227,6 +3331,19 @@ func (c *xdpMemberCache) GetMembers(iface string) (map[bpf.CIDRMapKey]uint32, er
 	return members, nil
 }
 
+// IsFull returns whether a blocklist map with the given number of entries has room for no
+// more.
+func (c *xdpMemberCache) IsFull(numEntries int) bool {
+	return c.maxEntries > 0 && numEntries >= c.maxEntries
+}
+
+func (c *xdpMemberCache) addOverflow(iface string, key bpf.CIDRMapKey, refCount uint32) {
+	if c.overflow[iface] == nil {
+		c.overflow[iface] = make(map[bpf.CIDRMapKey]uint32)
+	}
+	c.overflow[iface][key] += refCount
+}
+
 func (c *xdpMemberCache) GetFamily() bpf.IPFamily {
 	return c.family
 }
@@ -16,14 +16,22 @@ package intdataplane
 
 import (
 	"fmt"
+	"net"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"syscall"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
 	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/dataplane/common"
+	"github.com/projectcalico/calico/felix/ifacemonitor"
 	"github.com/projectcalico/calico/felix/ipsets"
 	"github.com/projectcalico/calico/felix/proto"
 	"github.com/projectcalico/calico/libcalico-go/lib/set"
@@ -47,13 +55,18 @@ func (s *mockIPSetsSource) GetIPSetMembers(setID string) (set.Set[string], error
 }
 
 type mockEndpointsSource struct {
-	rawHep map[proto.HostEndpointID]*proto.HostEndpoint
+	rawHep       map[proto.HostEndpointID]*proto.HostEndpoint
+	activeIfaces map[string]proto.HostEndpointID
 }
 
 func (s *mockEndpointsSource) GetRawHostEndpoints() map[proto.HostEndpointID]*proto.HostEndpoint {
 	return s.rawHep
 }
 
+func (s *mockEndpointsSource) GetActiveHostInterfaces() map[string]proto.HostEndpointID {
+	return s.activeIfaces
+}
+
 func stateToBPFDataplane(state map[string]map[string]uint32, family bpf.IPFamily) bpf.BPFDataplane {
 	lib := bpf.NewMockBPFLib("../../bpf-apache/bin")
 	_, err := lib.NewFailsafeMap()
@@ -371,14 +384,17 @@ type testIfaceData struct {
 func testStateToRealState(testIfaces map[string]testIfaceData, testEligiblePolicies map[string][][]string, realState *xdpSystemState) {
 	for ifaceName, ifaceData := range testIfaces {
 		policiesToSetIDs := make(map[proto.PolicyID]set.Set[string], len(ifaceData.policiesToSets))
+		policiesToDstSetIDs := make(map[proto.PolicyID]set.Set[string], len(ifaceData.policiesToSets))
 		for policyID, setIDs := range ifaceData.policiesToSets {
 			protoID := proto.PolicyID{Tier: "default", Name: policyID}
 			setIDsSet := set.FromArray(setIDs)
 			policiesToSetIDs[protoID] = setIDsSet
+			policiesToDstSetIDs[protoID] = set.New[string]()
 		}
 		realState.IfaceNameToData[ifaceName] = xdpIfaceData{
-			EpID:             proto.HostEndpointID{EndpointId: ifaceData.epID},
-			PoliciesToSetIDs: policiesToSetIDs,
+			EpID:                proto.HostEndpointID{EndpointId: ifaceData.epID},
+			PoliciesToSetIDs:    policiesToSetIDs,
+			PoliciesToDstSetIDs: policiesToDstSetIDs,
 		}
 	}
 	for policyID, testRules := range testEligiblePolicies {
@@ -1763,6 +1779,9 @@ var _ = Describe("XDP state", func() {
 					if s.actions.UninstallXDP == nil {
 						s.actions.UninstallXDP = set.New[string]()
 					}
+					if s.actions.XDPReasons == nil {
+						s.actions.XDPReasons = make(map[string]string)
+					}
 					if s.actions.MembersToDrop == nil {
 						s.actions.MembersToDrop = make(map[string]map[string]uint32)
 					}
@@ -1797,6 +1816,10 @@ var _ = Describe("XDP state", func() {
 					actions: &xdpBPFActions{
 						RemoveMap:    set.From("ifMap", "ifProgMap"),
 						UninstallXDP: set.From("ifProg", "ifProgMap"),
+						XDPReasons: map[string]string{
+							"ifProg":    "resync: program not wanted",
+							"ifProgMap": "resync: program not wanted",
+						},
 					},
 				}),
 				Entry("no XDP, but should have it", testStruct{
@@ -1838,8 +1861,13 @@ var _ = Describe("XDP state", func() {
 					// nil ipsets source
 					actions: &xdpBPFActions{
 						InstallXDP: set.From("ifNoMap", "ifBogusMap", "ifOkMap"),
-						CreateMap:  set.From("ifNoMap", "ifBogusMap"),
-						RemoveMap:  set.From("ifBogusMap"),
+						XDPReasons: map[string]string{
+							"ifNoMap":    "resync: program missing",
+							"ifBogusMap": "resync: program missing",
+							"ifOkMap":    "resync: program missing",
+						},
+						CreateMap: set.From("ifNoMap", "ifBogusMap"),
+						RemoveMap: set.From("ifBogusMap"),
 						AddToMap: map[string]map[string]uint32{
 							"ifNoMap": {
 								"ipset": 1,
@@ -1903,8 +1931,13 @@ var _ = Describe("XDP state", func() {
 					actions: &xdpBPFActions{
 						InstallXDP:   set.From("ifNoMap", "ifBogusMap", "ifMismatchedMap"),
 						UninstallXDP: set.From("ifNoMap", "ifBogusMap", "ifMismatchedMap"),
-						CreateMap:    set.From("ifNoMap", "ifBogusMap"),
-						RemoveMap:    set.From("ifBogusMap"),
+						XDPReasons: map[string]string{
+							"ifNoMap":         "resync: map missing",
+							"ifBogusMap":      "resync: map bogus",
+							"ifMismatchedMap": "resync: map mismatched",
+						},
+						CreateMap: set.From("ifNoMap", "ifBogusMap"),
+						RemoveMap: set.From("ifBogusMap"),
 						AddToMap: map[string]map[string]uint32{
 							"ifNoMap": {
 								"ipset": 1,
@@ -1972,8 +2005,14 @@ var _ = Describe("XDP state", func() {
 					actions: &xdpBPFActions{
 						InstallXDP:   set.From("ifNoMap", "ifBogusMap", "ifMismatchedMap", "ifOkMap"),
 						UninstallXDP: set.From("ifNoMap", "ifBogusMap", "ifMismatchedMap", "ifOkMap"),
-						CreateMap:    set.From("ifNoMap", "ifBogusMap"),
-						RemoveMap:    set.From("ifBogusMap"),
+						XDPReasons: map[string]string{
+							"ifNoMap":         "resync: program bogus",
+							"ifBogusMap":      "resync: program bogus",
+							"ifMismatchedMap": "resync: program bogus",
+							"ifOkMap":         "resync: program bogus",
+						},
+						CreateMap: set.From("ifNoMap", "ifBogusMap"),
+						RemoveMap: set.From("ifBogusMap"),
 						AddToMap: map[string]map[string]uint32{
 							"ifNoMap": {
 								"ipset": 1,
@@ -2041,8 +2080,14 @@ var _ = Describe("XDP state", func() {
 					actions: &xdpBPFActions{
 						InstallXDP:   set.From("ifNoMap", "ifBogusMap", "ifMismatchedMap", "ifOkMap"),
 						UninstallXDP: set.From("ifNoMap", "ifBogusMap", "ifMismatchedMap", "ifOkMap"),
-						CreateMap:    set.From("ifNoMap", "ifBogusMap"),
-						RemoveMap:    set.From("ifBogusMap"),
+						XDPReasons: map[string]string{
+							"ifNoMap":         "resync: program bogus",
+							"ifBogusMap":      "resync: program bogus",
+							"ifMismatchedMap": "resync: program bogus",
+							"ifOkMap":         "resync: program bogus",
+						},
+						CreateMap: set.From("ifNoMap", "ifBogusMap"),
+						RemoveMap: set.From("ifBogusMap"),
 						AddToMap: map[string]map[string]uint32{
 							"ifNoMap": {
 								"ipset": 1,
@@ -2125,6 +2170,9 @@ var _ = Describe("XDP state", func() {
 					actions: &xdpBPFActions{
 						InstallXDP: set.From("ifNoMap"),
 						CreateMap:  set.From("ifNoMap"),
+						XDPReasons: map[string]string{
+							"ifNoMap": "resync: program missing",
+						},
 						AddToMap: map[string]map[string]uint32{
 							"ifNoMap": {
 								"ipset": 1,
@@ -2477,7 +2525,7 @@ var _ = Describe("XDP state", func() {
 					_, err := memberCache.bpfLib.NewFailsafeMap()
 					Expect(err).NotTo(HaveOccurred())
 
-					err = state.ipV4State.bpfActions.apply(memberCache, s.ipsetIDsToMembers, newConvertingIPSetsSource(s.ipsetsSrc), state.common.xdpModes)
+					err = state.ipV4State.bpfActions.apply(memberCache, s.ipsetIDsToMembers, newConvertingIPSetsSource(s.ipsetsSrc), state.ipV4State.xdpModesForIface(state.common.xdpModes), false, nil)
 					Expect(err).NotTo(HaveOccurred())
 
 					actual := bpfDataplaneDump(st, bpf.IPFamilyV4)
@@ -2689,6 +2737,212 @@ var _ = Describe("XDP state", func() {
 					},
 				}),
 			)
+
+			It("should fall back to generic mode if the driver rejects the other modes", func() {
+				lib := bpf.NewMockBPFLib("../../bpf-apache/bin")
+				lib.UnsupportedXDPModes = map[bpf.XDPMode]bool{
+					bpf.XDPOffload: true,
+					bpf.XDPDriver:  true,
+				}
+				_, err := lib.NewFailsafeMap()
+				Expect(err).NotTo(HaveOccurred())
+
+				state := NewXDPStateWithBPFLibrary(lib, true)
+				state.ipV4State.bpfActions.InstallXDP.Add("eth0")
+				state.ipV4State.bpfActions.CreateMap.Add("eth0")
+
+				memberCache := newXDPMemberCache(bpf.IPFamilyV4, lib)
+				err = state.ipV4State.bpfActions.apply(memberCache, newIPSetIDsToMembers(), newConvertingIPSetsSource(&mockIPSetsSource{}), state.ipV4State.xdpModesForIface(state.common.xdpModes), false, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				mode, err := lib.GetXDPMode("eth0")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mode).To(Equal(bpf.XDPGeneric))
+			})
+
+			It("should not try modes that the interface can't support, such as native mode on some SR-IOV VFs", func() {
+				lib := bpf.NewMockBPFLib("../../bpf-apache/bin")
+				lib.UnsupportedIfaceXDPModes = map[string]map[bpf.XDPMode]bool{
+					"eth0": {bpf.XDPOffload: true, bpf.XDPDriver: true},
+				}
+				_, err := lib.NewFailsafeMap()
+				Expect(err).NotTo(HaveOccurred())
+
+				state := NewXDPStateWithBPFLibrary(lib, true)
+				state.ipV4State.bpfActions.InstallXDP.Add("eth0")
+				state.ipV4State.bpfActions.CreateMap.Add("eth0")
+
+				memberCache := newXDPMemberCache(bpf.IPFamilyV4, lib)
+				err = state.ipV4State.bpfActions.apply(memberCache, newIPSetIDsToMembers(), newConvertingIPSetsSource(&mockIPSetsSource{}), state.ipV4State.xdpModesForIface(state.common.xdpModes), false, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(lib.AttemptedXDPModes["eth0"]).To(Equal([]bpf.XDPMode{bpf.XDPGeneric}))
+				mode, err := lib.GetXDPMode("eth0")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mode).To(Equal(bpf.XDPGeneric))
+			})
+
+			It("should fail cleanly if the interface supports none of the allowed modes", func() {
+				lib := bpf.NewMockBPFLib("../../bpf-apache/bin")
+				lib.UnsupportedIfaceXDPModes = map[string]map[bpf.XDPMode]bool{
+					"eth0": {bpf.XDPOffload: true, bpf.XDPDriver: true},
+				}
+				_, err := lib.NewFailsafeMap()
+				Expect(err).NotTo(HaveOccurred())
+
+				state := NewXDPStateWithBPFLibrary(lib, false)
+				state.ipV4State.bpfActions.InstallXDP.Add("eth0")
+				state.ipV4State.bpfActions.CreateMap.Add("eth0")
+
+				memberCache := newXDPMemberCache(bpf.IPFamilyV4, lib)
+				err = state.ipV4State.bpfActions.apply(memberCache, newIPSetIDsToMembers(), newConvertingIPSetsSource(&mockIPSetsSource{}), state.ipV4State.xdpModesForIface(state.common.xdpModes), false, nil)
+				Expect(err).To(HaveOccurred())
+				Expect(lib.AttemptedXDPModes["eth0"]).To(BeEmpty())
+			})
+
+			DescribeTable("should honour the host endpoint's XDP mode override",
+				func(override string, allowGeneric bool, expectedMode bpf.XDPMode) {
+					lib := bpf.NewMockBPFLib("../../bpf-apache/bin")
+					_, err := lib.NewFailsafeMap()
+					Expect(err).NotTo(HaveOccurred())
+
+					state := NewXDPStateWithBPFLibrary(lib, allowGeneric)
+					state.ipV4State.newCurrentState = newXDPSystemState()
+					state.ipV4State.newCurrentState.IfaceNameToData["eth0"] = xdpIfaceData{XDPMode: override}
+					state.ipV4State.bpfActions.InstallXDP.Add("eth0")
+					state.ipV4State.bpfActions.CreateMap.Add("eth0")
+
+					memberCache := newXDPMemberCache(bpf.IPFamilyV4, lib)
+					err = state.ipV4State.bpfActions.apply(memberCache, newIPSetIDsToMembers(), newConvertingIPSetsSource(&mockIPSetsSource{}), state.ipV4State.xdpModesForIface(state.common.xdpModes), false, nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					mode, err := lib.GetXDPMode("eth0")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(mode).To(Equal(expectedMode))
+				},
+				Entry("no override", "", true, bpf.XDPOffload),
+				Entry("native", "Native", true, bpf.XDPDriver),
+				Entry("generic", "Generic", true, bpf.XDPGeneric),
+				Entry("generic with generic XDP disabled", "Generic", false, bpf.XDPGeneric),
+			)
+
+			It("should not fall back from an overridden mode", func() {
+				lib := bpf.NewMockBPFLib("../../bpf-apache/bin")
+				lib.UnsupportedXDPModes = map[bpf.XDPMode]bool{
+					bpf.XDPDriver: true,
+				}
+				_, err := lib.NewFailsafeMap()
+				Expect(err).NotTo(HaveOccurred())
+
+				state := NewXDPStateWithBPFLibrary(lib, true)
+				state.ipV4State.newCurrentState = newXDPSystemState()
+				state.ipV4State.newCurrentState.IfaceNameToData["eth0"] = xdpIfaceData{XDPMode: "Native"}
+				state.ipV4State.bpfActions.InstallXDP.Add("eth0")
+				state.ipV4State.bpfActions.CreateMap.Add("eth0")
+
+				memberCache := newXDPMemberCache(bpf.IPFamilyV4, lib)
+				err = state.ipV4State.bpfActions.apply(memberCache, newIPSetIDsToMembers(), newConvertingIPSetsSource(&mockIPSetsSource{}), state.ipV4State.xdpModesForIface(state.common.xdpModes), false, nil)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should record attaching, reloading and detaching the program in the event log", func() {
+				lib := bpf.NewMockBPFLib("../../bpf-apache/bin")
+				_, err := lib.NewFailsafeMap()
+				Expect(err).NotTo(HaveOccurred())
+				tmpDir, err := os.MkdirTemp("", "xdp-event-log")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(tmpDir)
+				eventLog := newXDPEventLog(filepath.Join(tmpDir, "events.log"))
+
+				state := NewXDPStateWithBPFLibrary(lib, true)
+				memberCache := newXDPMemberCache(bpf.IPFamilyV4, lib)
+				applyActions := func(install, uninstall bool, reason string) {
+					actions := newXDPBPFActions()
+					if install {
+						actions.InstallXDP.Add("eth0")
+					}
+					if uninstall {
+						actions.UninstallXDP.Add("eth0")
+					}
+					if install && !uninstall {
+						actions.CreateMap.Add("eth0")
+					}
+					actions.XDPReasons["eth0"] = reason
+					err := actions.apply(memberCache, newIPSetIDsToMembers(), newConvertingIPSetsSource(&mockIPSetsSource{}), state.ipV4State.xdpModesForIface(state.common.xdpModes), false, eventLog)
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				applyActions(true, false, "host endpoint changed")
+				tag, err := lib.GetXDPTag("eth0")
+				Expect(err).NotTo(HaveOccurred())
+				applyActions(true, true, "resync: program bogus")
+				applyActions(false, true, "policy changed")
+
+				contents, err := os.ReadFile(eventLog.path)
+				Expect(err).NotTo(HaveOccurred())
+				lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+				Expect(lines).To(HaveLen(3))
+				// Strip the timestamps.
+				for i, l := range lines {
+					lines[i] = strings.SplitN(l, " ", 2)[1]
+				}
+				Expect(lines).To(Equal([]string{
+					fmt.Sprintf(`attach iface=eth0 mode=xdpoffload tag=%s reason="host endpoint changed"`, tag),
+					fmt.Sprintf(`reload iface=eth0 mode=xdpoffload tag=%s reason="resync: program bogus"`, tag),
+					fmt.Sprintf(`detach iface=eth0 tag=%s reason="policy changed"`, tag),
+				}))
+			})
+
+			It("should attach and detach the TC egress program along with the XDP program", func() {
+				lib := bpf.NewMockBPFLib("../../bpf-apache/bin")
+				_, err := lib.NewFailsafeMap()
+				Expect(err).NotTo(HaveOccurred())
+
+				state := NewXDPStateWithBPFLibrary(lib, true)
+				memberCache := newXDPMemberCache(bpf.IPFamilyV4, lib)
+				modes := state.ipV4State.xdpModesForIface(state.common.xdpModes)
+
+				actions := newXDPBPFActions()
+				actions.InstallXDP.Add("eth0")
+				actions.CreateMap.Add("eth0")
+				err = actions.apply(memberCache, newIPSetIDsToMembers(), newConvertingIPSetsSource(&mockIPSetsSource{}), modes, true, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lib.HasTCEgress("eth0")).To(BeTrue())
+
+				actions = newXDPBPFActions()
+				actions.UninstallXDP.Add("eth0")
+				err = actions.apply(memberCache, newIPSetIDsToMembers(), newConvertingIPSetsSource(&mockIPSetsSource{}), modes, false, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lib.HasTCEgress("eth0")).To(BeFalse())
+			})
+
+			DescribeTable("should consider the program bogus if the TC egress program doesn't match the config",
+				func(attachEgress, wantEgress, expectBogus bool) {
+					lib := bpf.NewMockBPFLib("../../bpf-apache/bin")
+					_, err := lib.NewFailsafeMap()
+					Expect(err).NotTo(HaveOccurred())
+
+					state := NewXDPStateWithBPFLibrary(lib, true)
+					state.ipV4State.newCurrentState = newXDPSystemState()
+					modes := state.ipV4State.xdpModesForIface(state.common.xdpModes)
+					actions := newXDPBPFActions()
+					actions.InstallXDP.Add("eth0")
+					actions.CreateMap.Add("eth0")
+					memberCache := newXDPMemberCache(bpf.IPFamilyV4, lib)
+					err = actions.apply(memberCache, newIPSetIDsToMembers(), newConvertingIPSetsSource(&mockIPSetsSource{}), modes, attachEgress, nil)
+					Expect(err).NotTo(HaveOccurred())
+					tag, err := lib.GetXDPTag("eth0")
+					Expect(err).NotTo(HaveOccurred())
+
+					resyncState, err := state.ipV4State.newXDPResyncState(lib, &nilIPSetsSource{}, tag, modes, wantEgress)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(resyncState.ifacesWithProgs["eth0"].bogus).To(Equal(expectBogus))
+				},
+				Entry("neither attached nor wanted", false, false, false),
+				Entry("attached and wanted", true, true, false),
+				Entry("missing", false, true, true),
+				Entry("unwanted", true, false, true),
+			)
 		})
 
 		Describe("getIfaces", func() {
@@ -2708,7 +2962,7 @@ var _ = Describe("XDP state", func() {
 					state := NewXDPStateWithBPFLibrary(bpf.NewMockBPFLib("../../bpf-apache/bin"), true)
 					state.ipV4State.newCurrentState = newXDPSystemState()
 					ipsetsSrc := &nilIPSetsSource{}
-					resyncState, err := state.ipV4State.newXDPResyncState(state.common.bpfLib, ipsetsSrc, state.common.programTag, state.common.xdpModes)
+					resyncState, err := state.ipV4State.newXDPResyncState(state.common.bpfLib, ipsetsSrc, state.common.programTag, state.ipV4State.xdpModesForIface(state.common.xdpModes), false)
 					Expect(err).NotTo(HaveOccurred())
 					state.ipV4State.bpfActions.InstallXDP.AddAll(s.install)
 					state.ipV4State.bpfActions.UninstallXDP.AddAll(s.uninstall)
@@ -2792,5 +3046,860 @@ var _ = Describe("XDP state", func() {
 				}),
 			)
 		})
+
+		DescribeTable("isPermissionError",
+			func(err error, expected bool) {
+				Expect(isPermissionError(err)).To(Equal(expected))
+			},
+			Entry("EPERM", fmt.Errorf("failed to load program: %w", syscall.EPERM), true),
+			Entry("bpftool output", fmt.Errorf("failed to get map: exit status 255\nError: get map by id (12): Operation not permitted"), true),
+			Entry("other error", fmt.Errorf("failed to get map: exit status 255\nError: no such map"), false),
+		)
+
+		Describe("update debounce", func() {
+			var state *xdpState
+
+			BeforeEach(func() {
+				state = NewXDPStateWithBPFLibrary(bpf.NewMockBPFLib("../../bpf-apache/bin"), true)
+				state.common.updateDebounce = time.Minute
+			})
+
+			It("should not hold back updates if no IP sets have changed", func() {
+				state.OnUpdate(&proto.ActivePolicyRemove{Id: &proto.PolicyID{Tier: "default", Name: "foo"}})
+				Expect(state.UpdateDebounceRemaining()).To(BeZero())
+			})
+
+			It("should hold back IP set updates until the window from the first one has passed", func() {
+				state.OnUpdate(&proto.IPSetUpdate{Id: "ipset", Members: []string{"10.0.0.1"}})
+				first := state.UpdateDebounceRemaining()
+				Expect(first).To(BeNumerically(">", 50*time.Second))
+
+				state.common.firstPendingIPSetUpdate = state.common.firstPendingIPSetUpdate.Add(-30 * time.Second)
+				state.OnUpdate(&proto.IPSetDeltaUpdate{Id: "ipset", AddedMembers: []string{"10.0.0.2"}})
+				Expect(state.UpdateDebounceRemaining()).To(BeNumerically("<=", 30*time.Second))
+
+				state.common.firstPendingIPSetUpdate = state.common.firstPendingIPSetUpdate.Add(-time.Minute)
+				Expect(state.UpdateDebounceRemaining()).To(BeZero())
+			})
+
+			It("should start a new window once the updates have been processed", func() {
+				state.OnUpdate(&proto.IPSetRemove{Id: "ipset"})
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+				Expect(state.UpdateDebounceRemaining()).To(BeZero())
+
+				state.OnUpdate(&proto.IPSetUpdate{Id: "ipset", Members: []string{"10.0.0.1"}})
+				Expect(state.UpdateDebounceRemaining()).To(BeNumerically(">", 50*time.Second))
+			})
+
+			It("should not hold back updates when the debounce is disabled", func() {
+				state.common.updateDebounce = 0
+				state.OnUpdate(&proto.IPSetUpdate{Id: "ipset", Members: []string{"10.0.0.1"}})
+				Expect(state.UpdateDebounceRemaining()).To(BeZero())
+			})
+		})
+
+		Describe("destination maps", func() {
+			var (
+				lib       *bpf.MockBPFLib
+				state     *xdpState
+				ipsSource *mockIPSetsSource
+				epSource  *mockEndpointsSource
+			)
+
+			BeforeEach(func() {
+				lib = bpf.NewMockBPFLib("../../bpf-apache/bin")
+				_, err := lib.NewFailsafeMap()
+				Expect(err).NotTo(HaveOccurred())
+				state = NewXDPStateWithBPFLibrary(lib, true)
+				ipsSource = &mockIPSetsSource{
+					ipsetsMap: map[string]mockIPSetValue{
+						"dsts": {
+							ipsetType: ipsets.IPSetTypeHashNet,
+							members:   set.From("10.0.0.5/32", "10.0.1.0/24"),
+						},
+					},
+				}
+				epSource = &mockEndpointsSource{
+					rawHep: map[proto.HostEndpointID]*proto.HostEndpoint{
+						{EndpointId: "ep"}: {
+							Name: "default.ep",
+							UntrackedTiers: []*proto.TierInfo{
+								{Name: "default", IngressPolicies: []string{"policy"}},
+							},
+						},
+					},
+				}
+			})
+
+			apply := func() {
+				state.ProcessPendingDiffState(epSource)
+				Expect(state.ResyncIfNeeded(ipsSource)).To(Succeed())
+				Expect(state.ApplyBPFActions(ipsSource)).To(Succeed())
+				Expect(state.ProcessMemberUpdates(ipsSource)).To(Succeed())
+				state.DropPendingDiffState()
+				state.UpdateState()
+			}
+
+			dstMap := func() []string {
+				dump, err := lib.DumpDstCIDRMap("eth0", bpf.IPFamilyV4)
+				Expect(err).NotTo(HaveOccurred())
+				var cidrs []string
+				for k := range dump {
+					cidrs = append(cidrs, k.ToIPNet().String())
+				}
+				return cidrs
+			}
+
+			It("should program a rule that denies destinations into the destination map", func() {
+				rule := &proto.Rule{Action: "deny", IpVersion: proto.IPVersion_IPV4, DstIpSetIds: []string{"dsts"}}
+				state.ipV4State.updatePolicy(proto.PolicyID{Tier: "default", Name: "policy"}, &proto.Policy{InboundRules: []*proto.Rule{rule}})
+				state.ipV4State.addInterface("eth0", proto.HostEndpointID{EndpointId: "ep"})
+				apply()
+
+				Expect(lib.XDPProgs).To(HaveKey("eth0"))
+				Expect(dstMap()).To(ConsistOf("10.0.0.5/32", "10.0.1.0/24"))
+				blocklist, err := lib.DumpCIDRMap("eth0", bpf.IPFamilyV4)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(blocklist).To(BeEmpty())
+
+				By("following changes to the IP set")
+				state.OnUpdate(&proto.IPSetDeltaUpdate{Id: "dsts", AddedMembers: []string{"10.0.2.0/24"}, RemovedMembers: []string{"10.0.1.0/24"}})
+				apply()
+				Expect(dstMap()).To(ConsistOf("10.0.0.5/32", "10.0.2.0/24"))
+
+				By("removing the program and maps when the policy no longer applies")
+				state.ipV4State.removePolicy(proto.PolicyID{Tier: "default", Name: "policy"})
+				state.ipV4State.updateHostEndpoint(proto.HostEndpointID{EndpointId: "ep"})
+				epSource.rawHep[proto.HostEndpointID{EndpointId: "ep"}].UntrackedTiers = nil
+				apply()
+				Expect(lib.XDPProgs).NotTo(HaveKey("eth0"))
+				Expect(lib.DstCIDRMaps).To(BeEmpty())
+			})
+
+			It("should empty a stale destination map after a resync", func() {
+				rule := &proto.Rule{Action: "deny", IpVersion: proto.IPVersion_IPV4, DstIpSetIds: []string{"dsts"}}
+				state.ipV4State.updatePolicy(proto.PolicyID{Tier: "default", Name: "policy"}, &proto.Policy{InboundRules: []*proto.Rule{rule}})
+				state.ipV4State.addInterface("eth0", proto.HostEndpointID{EndpointId: "ep"})
+				apply()
+
+				Expect(lib.UpdateDstCIDRMap("eth0", bpf.IPFamilyV4, net.ParseIP("10.9.9.9"), 32)).To(Succeed())
+				state.QueueResync()
+				apply()
+				Expect(dstMap()).To(ConsistOf("10.0.0.5/32", "10.0.1.0/24"))
+			})
+
+			It("should not optimize a rule that matches both the source and the destination", func() {
+				rule := &proto.Rule{Action: "deny", IpVersion: proto.IPVersion_IPV4, SrcIpSetIds: []string{"srcs"}, DstIpSetIds: []string{"dsts"}}
+				_, ok := xdpRulesFromProtoRules([]*proto.Rule{rule}, nil)
+				Expect(ok).To(BeFalse())
+			})
+		})
+
+		Describe("several host endpoints on one interface", func() {
+			var (
+				lib       *bpf.MockBPFLib
+				state     *xdpState
+				ipsSource *mockIPSetsSource
+				epSource  *mockEndpointsSource
+			)
+
+			hepA := proto.HostEndpointID{EndpointId: "hep-a"}
+			hepB := proto.HostEndpointID{EndpointId: "hep-b"}
+			hepWithPolicy := func(name, policy string) *proto.HostEndpoint {
+				return &proto.HostEndpoint{
+					Name: name,
+					UntrackedTiers: []*proto.TierInfo{
+						{Name: "default", IngressPolicies: []string{policy}},
+					},
+				}
+			}
+
+			BeforeEach(func() {
+				lib = bpf.NewMockBPFLib("../../bpf-apache/bin")
+				_, err := lib.NewFailsafeMap()
+				Expect(err).NotTo(HaveOccurred())
+				state = NewXDPStateWithBPFLibrary(lib, true)
+				// The sets share a member, which must stay in the blocklist when the
+				// interface switches from one endpoint to the other.
+				ipsSource = &mockIPSetsSource{
+					ipsetsMap: map[string]mockIPSetValue{
+						"srcs-a": {
+							ipsetType: ipsets.IPSetTypeHashNet,
+							members:   set.From("10.0.0.1/32", "10.0.0.2/32"),
+						},
+						"srcs-b": {
+							ipsetType: ipsets.IPSetTypeHashNet,
+							members:   set.From("10.0.0.2/32", "10.0.1.0/24"),
+						},
+					},
+				}
+				epSource = &mockEndpointsSource{
+					rawHep: map[proto.HostEndpointID]*proto.HostEndpoint{
+						hepA: hepWithPolicy("eth0", "policy-a"),
+						hepB: hepWithPolicy("eth0", "policy-b"),
+					},
+				}
+				for _, p := range []string{"a", "b"} {
+					rule := &proto.Rule{Action: "deny", IpVersion: proto.IPVersion_IPV4, SrcIpSetIds: []string{"srcs-" + p}}
+					state.ipV4State.updatePolicy(proto.PolicyID{Tier: "default", Name: "policy-" + p}, &proto.Policy{InboundRules: []*proto.Rule{rule}})
+				}
+				// The endpoint manager lets the endpoint with the earliest ID govern the
+				// interface.
+				state.ipV4State.addInterface("eth0", hepA)
+			})
+
+			apply := func() {
+				state.ProcessPendingDiffState(epSource)
+				Expect(state.ResyncIfNeeded(ipsSource)).To(Succeed())
+				Expect(state.ApplyBPFActions(ipsSource)).To(Succeed())
+				Expect(state.ProcessMemberUpdates(ipsSource)).To(Succeed())
+				state.DropPendingDiffState()
+				state.UpdateState()
+			}
+
+			blocklist := func() []string {
+				dump, err := lib.DumpCIDRMap("eth0", bpf.IPFamilyV4)
+				Expect(err).NotTo(HaveOccurred())
+				var cidrs []string
+				for k := range dump {
+					cidrs = append(cidrs, k.ToIPNet().String())
+				}
+				return cidrs
+			}
+
+			// These mirror the callbacks that the endpoint manager makes when the
+			// governing endpoint is removed and when an earlier one is added.
+			removeHEP := func(removed, takeover proto.HostEndpointID) {
+				delete(epSource.rawHep, removed)
+				state.ipV4State.removeHostEndpoint(removed)
+				state.ipV4State.updateInterface("eth0", takeover)
+			}
+			addHEP := func(added proto.HostEndpointID, ep *proto.HostEndpoint) {
+				epSource.rawHep[added] = ep
+				state.ipV4State.updateHostEndpoint(added)
+				state.ipV4State.updateInterface("eth0", added)
+			}
+
+			It("should only program the governing endpoint's blocklist and follow takeovers", func() {
+				apply()
+				Expect(lib.XDPProgs).To(HaveKey("eth0"))
+				Expect(blocklist()).To(ConsistOf("10.0.0.1/32", "10.0.0.2/32"))
+
+				By("switching to the other endpoint's blocklist when the governing one is removed")
+				removeHEP(hepA, hepB)
+				apply()
+				Expect(lib.XDPProgs).To(HaveKey("eth0"))
+				Expect(blocklist()).To(ConsistOf("10.0.0.2/32", "10.0.1.0/24"))
+
+				By("switching back when the earlier endpoint is added again")
+				addHEP(hepA, hepWithPolicy("eth0", "policy-a"))
+				apply()
+				Expect(blocklist()).To(ConsistOf("10.0.0.1/32", "10.0.0.2/32"))
+
+				By("ignoring changes to the endpoint that doesn't govern the interface")
+				epSource.rawHep[hepB] = hepWithPolicy("eth0", "policy-a")
+				state.ipV4State.updateHostEndpoint(hepB)
+				apply()
+				Expect(blocklist()).To(ConsistOf("10.0.0.1/32", "10.0.0.2/32"))
+
+				By("keeping the same blocklist after a resync")
+				state.QueueResync()
+				apply()
+				Expect(blocklist()).To(ConsistOf("10.0.0.1/32", "10.0.0.2/32"))
+			})
+
+			It("should remove the program when the endpoint that takes over has no untracked policy", func() {
+				epSource.rawHep[hepB] = &proto.HostEndpoint{Name: "eth0"}
+				apply()
+
+				removeHEP(hepA, hepB)
+				apply()
+				Expect(lib.XDPProgs).NotTo(HaveKey("eth0"))
+
+				addHEP(hepA, hepWithPolicy("eth0", "policy-a"))
+				apply()
+				Expect(lib.XDPProgs).To(HaveKey("eth0"))
+				Expect(blocklist()).To(ConsistOf("10.0.0.1/32", "10.0.0.2/32"))
+			})
+		})
+
+		Describe("blocklist size limit", func() {
+			var (
+				lib       *bpf.MockBPFLib
+				state     *xdpState
+				ipsSource *mockIPSetsSource
+				epSource  *mockEndpointsSource
+			)
+
+			BeforeEach(func() {
+				lib = bpf.NewMockBPFLib("../../bpf-apache/bin")
+				_, err := lib.NewFailsafeMap()
+				Expect(err).NotTo(HaveOccurred())
+				state = NewXDPStateWithBPFLibrary(lib, true)
+				state.common.maxBlocklistEntries = 2
+				ipsSource = &mockIPSetsSource{
+					ipsetsMap: map[string]mockIPSetValue{
+						"srcs": {
+							ipsetType: ipsets.IPSetTypeHashNet,
+							members:   set.From("10.0.0.1/32", "10.0.0.2/32", "10.0.0.3/32"),
+						},
+					},
+				}
+				epSource = &mockEndpointsSource{
+					rawHep: map[proto.HostEndpointID]*proto.HostEndpoint{
+						{EndpointId: "ep"}: {
+							Name: "default.ep",
+							UntrackedTiers: []*proto.TierInfo{
+								{Name: "default", IngressPolicies: []string{"policy"}},
+							},
+						},
+					},
+				}
+				rule := &proto.Rule{Action: "deny", IpVersion: proto.IPVersion_IPV4, SrcIpSetIds: []string{"srcs"}}
+				state.ipV4State.updatePolicy(proto.PolicyID{Tier: "default", Name: "policy"}, &proto.Policy{InboundRules: []*proto.Rule{rule}})
+				state.ipV4State.addInterface("eth0", proto.HostEndpointID{EndpointId: "ep"})
+			})
+
+			apply := func() {
+				state.ProcessPendingDiffState(epSource)
+				Expect(state.ResyncIfNeeded(ipsSource)).To(Succeed())
+				Expect(state.ApplyBPFActions(ipsSource)).To(Succeed())
+				Expect(state.ProcessMemberUpdates(ipsSource)).To(Succeed())
+				state.DropPendingDiffState()
+				state.UpdateState()
+			}
+
+			blocklist := func() []string {
+				dump, err := lib.DumpCIDRMap("eth0", bpf.IPFamilyV4)
+				Expect(err).NotTo(HaveOccurred())
+				var cidrs []string
+				for k := range dump {
+					cidrs = append(cidrs, k.ToIPNet().String())
+				}
+				return cidrs
+			}
+
+			leftOut := func() string {
+				for _, m := range []string{"10.0.0.1/32", "10.0.0.2/32", "10.0.0.3/32"} {
+					if !set.FromArray(blocklist()).Contains(m) {
+						return m
+					}
+				}
+				return ""
+			}
+
+			It("should leave out the CIDRs that don't fit and add them when there's room", func() {
+				apply()
+				Expect(blocklist()).To(HaveLen(2))
+				Expect(state.ipV4State.blocklistOverflow["eth0"]).To(HaveLen(1))
+
+				By("adding the left out CIDR when another is removed")
+				missing := leftOut()
+				var removed string
+				for _, m := range blocklist() {
+					removed = m
+				}
+				ipsSource.ipsetsMap["srcs"].members.Discard(removed)
+				state.OnUpdate(&proto.IPSetDeltaUpdate{Id: "srcs", RemovedMembers: []string{removed}})
+				apply()
+				Expect(blocklist()).To(HaveLen(2))
+				Expect(blocklist()).To(ContainElement(missing))
+				Expect(state.ipV4State.blocklistOverflow["eth0"]).To(BeEmpty())
+			})
+
+			It("should forget a left out CIDR when it's removed from the IP set", func() {
+				apply()
+				missing := leftOut()
+				programmed := blocklist()
+				ipsSource.ipsetsMap["srcs"].members.Discard(missing)
+				state.OnUpdate(&proto.IPSetDeltaUpdate{Id: "srcs", RemovedMembers: []string{missing}})
+				apply()
+				Expect(blocklist()).To(ConsistOf(programmed))
+				Expect(state.ipV4State.blocklistOverflow["eth0"]).To(BeEmpty())
+			})
+
+			It("should leave out the same number of CIDRs after a resync", func() {
+				apply()
+				state.QueueResync()
+				apply()
+				Expect(blocklist()).To(HaveLen(2))
+				Expect(state.ipV4State.blocklistOverflow["eth0"]).To(HaveLen(1))
+			})
+		})
+
+		Describe("lifecycle metrics", func() {
+			var (
+				lib       *bpf.MockBPFLib
+				state     *xdpState
+				ipsSource *mockIPSetsSource
+				epSource  *mockEndpointsSource
+			)
+
+			BeforeEach(func() {
+				lib = bpf.NewMockBPFLib("../../bpf-apache/bin")
+				_, err := lib.NewFailsafeMap()
+				Expect(err).NotTo(HaveOccurred())
+				state = NewXDPStateWithBPFLibrary(lib, true)
+				ipsSource = &mockIPSetsSource{
+					ipsetsMap: map[string]mockIPSetValue{
+						"srcs": {
+							ipsetType: ipsets.IPSetTypeHashNet,
+							members:   set.From("10.0.0.1/32"),
+						},
+					},
+				}
+				epSource = &mockEndpointsSource{
+					rawHep: map[proto.HostEndpointID]*proto.HostEndpoint{
+						{EndpointId: "ep"}: {
+							Name: "default.ep",
+							UntrackedTiers: []*proto.TierInfo{
+								{Name: "default", IngressPolicies: []string{"policy"}},
+							},
+						},
+					},
+				}
+				rule := &proto.Rule{Action: "deny", IpVersion: proto.IPVersion_IPV4, SrcIpSetIds: []string{"srcs"}}
+				state.ipV4State.updatePolicy(proto.PolicyID{Tier: "default", Name: "policy"}, &proto.Policy{InboundRules: []*proto.Rule{rule}})
+				state.ipV4State.addInterface("eth0", proto.HostEndpointID{EndpointId: "ep"})
+			})
+
+			apply := func() {
+				state.ProcessPendingDiffState(epSource)
+				Expect(state.ResyncIfNeeded(ipsSource)).To(Succeed())
+				Expect(state.ApplyBPFActions(ipsSource)).To(Succeed())
+				Expect(state.ProcessMemberUpdates(ipsSource)).To(Succeed())
+				state.DropPendingDiffState()
+				state.UpdateState()
+			}
+
+			// counts returns how far each operation's count has advanced since start.
+			counts := func(start map[string]float64) map[string]float64 {
+				c := map[string]float64{}
+				for _, op := range []string{xdpOpAttach, xdpOpDetach, xdpOpReload, xdpOpMapCreate, xdpOpMapUpdate, xdpOpResyncRepair} {
+					c[op] = testutil.ToFloat64(counterXDPLifecycleOperations.WithLabelValues(op))
+					if start != nil {
+						c[op] -= start[op]
+					}
+				}
+				return c
+			}
+
+			It("should count each operation of the program's lifecycle once", func() {
+				start := counts(nil)
+
+				By("attaching the program with a fresh, populated map")
+				apply()
+				Expect(counts(start)).To(Equal(map[string]float64{
+					xdpOpAttach: 1, xdpOpDetach: 0, xdpOpReload: 0, xdpOpMapCreate: 1, xdpOpMapUpdate: 1, xdpOpResyncRepair: 0,
+				}))
+
+				By("updating the map when the IP set changes")
+				ipsSource.ipsetsMap["srcs"].members.Add("10.0.0.2/32")
+				state.OnUpdate(&proto.IPSetDeltaUpdate{Id: "srcs", AddedMembers: []string{"10.0.0.2/32"}})
+				apply()
+				Expect(counts(start)).To(Equal(map[string]float64{
+					xdpOpAttach: 1, xdpOpDetach: 0, xdpOpReload: 0, xdpOpMapCreate: 1, xdpOpMapUpdate: 2, xdpOpResyncRepair: 0,
+				}))
+
+				By("not counting a resync that finds nothing to repair")
+				state.QueueResync()
+				apply()
+				Expect(counts(start)[xdpOpResyncRepair]).To(BeZero())
+
+				By("repairing a map entry that was removed behind Felix's back")
+				Expect(lib.RemoveItemCIDRMap("eth0", bpf.IPFamilyV4, net.ParseIP("10.0.0.1"), 32)).To(Succeed())
+				state.QueueResync()
+				apply()
+				Expect(counts(start)).To(Equal(map[string]float64{
+					xdpOpAttach: 1, xdpOpDetach: 0, xdpOpReload: 0, xdpOpMapCreate: 1, xdpOpMapUpdate: 3, xdpOpResyncRepair: 1,
+				}))
+
+				By("detaching the program when the host endpoint goes")
+				state.ipV4State.removeInterface("eth0")
+				apply()
+				Expect(counts(start)).To(Equal(map[string]float64{
+					xdpOpAttach: 1, xdpOpDetach: 1, xdpOpReload: 0, xdpOpMapCreate: 1, xdpOpMapUpdate: 3, xdpOpResyncRepair: 1,
+				}))
+			})
+		})
+
+		Describe("minimum interface speed", func() {
+			var (
+				lib       *bpf.MockBPFLib
+				state     *xdpState
+				ipsSource *mockIPSetsSource
+				epSource  *mockEndpointsSource
+			)
+
+			BeforeEach(func() {
+				lib = bpf.NewMockBPFLib("../../bpf-apache/bin")
+				_, err := lib.NewFailsafeMap()
+				Expect(err).NotTo(HaveOccurred())
+				// eth2 doesn't report a speed.
+				lib.InterfaceSpeeds = map[string]int{"eth0": 1000, "eth1": 25000}
+				state = NewXDPStateWithBPFLibrary(lib, true)
+				state.common.minInterfaceSpeed = 10000
+				ipsSource = &mockIPSetsSource{
+					ipsetsMap: map[string]mockIPSetValue{
+						"srcs": {
+							ipsetType: ipsets.IPSetTypeHashNet,
+							members:   set.From("10.0.0.1/32"),
+						},
+					},
+				}
+				epSource = &mockEndpointsSource{
+					rawHep: map[proto.HostEndpointID]*proto.HostEndpoint{
+						{EndpointId: "ep"}: {
+							Name: "default.ep",
+							UntrackedTiers: []*proto.TierInfo{
+								{Name: "default", IngressPolicies: []string{"policy"}},
+							},
+						},
+					},
+				}
+				rule := &proto.Rule{Action: "deny", IpVersion: proto.IPVersion_IPV4, SrcIpSetIds: []string{"srcs"}}
+				state.ipV4State.updatePolicy(proto.PolicyID{Tier: "default", Name: "policy"}, &proto.Policy{InboundRules: []*proto.Rule{rule}})
+				for _, iface := range []string{"eth0", "eth1", "eth2"} {
+					state.addInterfaceV4(iface, proto.HostEndpointID{EndpointId: "ep"})
+				}
+			})
+
+			apply := func() {
+				state.ProcessPendingDiffState(epSource)
+				Expect(state.ResyncIfNeeded(ipsSource)).To(Succeed())
+				Expect(state.ApplyBPFActions(ipsSource)).To(Succeed())
+				Expect(state.ProcessMemberUpdates(ipsSource)).To(Succeed())
+				state.DropPendingDiffState()
+				state.UpdateState()
+			}
+
+			xdpIfaces := func() []string {
+				ifaces, err := lib.GetXDPIfaces()
+				Expect(err).NotTo(HaveOccurred())
+				return ifaces
+			}
+
+			It("should skip XDP on interfaces slower than the minimum, but not on those of unknown speed", func() {
+				apply()
+				Expect(xdpIfaces()).To(ConsistOf("eth1", "eth2"))
+			})
+
+			It("should ignore updates to and removal of a skipped interface", func() {
+				apply()
+				state.updateInterfaceV4("eth0", proto.HostEndpointID{EndpointId: "ep"})
+				state.removeInterfaceV4("eth0")
+				state.removeInterfaceV4("eth1")
+				apply()
+				Expect(xdpIfaces()).To(ConsistOf("eth2"))
+				Expect(state.common.slowIfaces.Len()).To(BeZero())
+			})
+
+			It("should stop using XDP on an interface whose link slows down", func() {
+				apply()
+				lib.InterfaceSpeeds["eth1"] = 1000
+				state.OnUpdate(&ifaceStateUpdate{Name: "eth1", State: ifacemonitor.StateUp})
+				apply()
+				Expect(xdpIfaces()).To(ConsistOf("eth2"))
+				Expect(state.common.slowIfaces.Contains("eth1")).To(BeTrue())
+
+				By("ignoring the removal of the interface's host endpoint afterwards")
+				state.removeInterfaceV4("eth1")
+				apply()
+				Expect(xdpIfaces()).To(ConsistOf("eth2"))
+				Expect(state.common.slowIfaces.Contains("eth1")).To(BeFalse())
+			})
+
+			It("should start using XDP on a skipped interface whose link speeds up", func() {
+				apply()
+				lib.InterfaceSpeeds["eth0"] = 10000
+				state.OnUpdate(&ifaceAddrsUpdate{Name: "eth0", Addrs: set.From("10.0.0.10")})
+				apply()
+				Expect(xdpIfaces()).To(ConsistOf("eth0", "eth1", "eth2"))
+				Expect(state.common.slowIfaces.Len()).To(BeZero())
+
+				By("leaving an interface alone while its speed can't be read")
+				delete(lib.InterfaceSpeeds, "eth0")
+				state.OnUpdate(&ifaceStateUpdate{Name: "eth0", State: ifacemonitor.StateDown})
+				apply()
+				Expect(xdpIfaces()).To(ConsistOf("eth0", "eth1", "eth2"))
+			})
+
+			It("should not skip any interface without a minimum", func() {
+				state.common.minInterfaceSpeed = 0
+				state.addInterfaceV4("eth3", proto.HostEndpointID{EndpointId: "ep"})
+				lib.InterfaceSpeeds["eth3"] = 10
+				apply()
+				Expect(xdpIfaces()).To(ConsistOf("eth1", "eth2", "eth3"))
+			})
+		})
+
+		Describe("with XDP turned off", func() {
+			var (
+				lib       *bpf.MockBPFLib
+				state     *xdpState
+				ipsSource *mockIPSetsSource
+				epSource  *mockEndpointsSource
+				cbs       *common.Callbacks
+			)
+
+			BeforeEach(func() {
+				lib = bpf.NewMockBPFLib("../../bpf-apache/bin")
+				_, err := lib.NewFailsafeMap()
+				Expect(err).NotTo(HaveOccurred())
+				state = NewXDPStateWithBPFLibrary(lib, true)
+				ipsSource = &mockIPSetsSource{
+					ipsetsMap: map[string]mockIPSetValue{
+						"srcs": {
+							ipsetType: ipsets.IPSetTypeHashNet,
+							members:   set.From("10.0.0.1/32", "10.0.0.2/32"),
+						},
+					},
+				}
+				epSource = &mockEndpointsSource{
+					rawHep: map[proto.HostEndpointID]*proto.HostEndpoint{
+						{EndpointId: "ep"}: {
+							Name: "default.ep",
+							UntrackedTiers: []*proto.TierInfo{
+								{Name: "default", IngressPolicies: []string{"policy"}},
+							},
+						},
+					},
+				}
+				rule := &proto.Rule{Action: "deny", IpVersion: proto.IPVersion_IPV4, SrcIpSetIds: []string{"srcs"}}
+				state.ipV4State.updatePolicy(proto.PolicyID{Tier: "default", Name: "policy"}, &proto.Policy{InboundRules: []*proto.Rule{rule}})
+				epSource.activeIfaces = map[string]proto.HostEndpointID{"eth0": {EndpointId: "ep"}}
+				cbs = common.NewCallbacks()
+			})
+
+			apply := func() {
+				state.ProcessPendingDiffState(epSource)
+				Expect(state.ResyncIfNeeded(ipsSource)).To(Succeed())
+				Expect(state.ApplyBPFActions(ipsSource)).To(Succeed())
+				Expect(state.ProcessMemberUpdates(ipsSource)).To(Succeed())
+				state.DropPendingDiffState()
+				state.UpdateState()
+			}
+
+			xdpIfaces := func() []string {
+				ifaces, err := lib.GetXDPIfaces()
+				Expect(err).NotTo(HaveOccurred())
+				return ifaces
+			}
+
+			It("should track the policies without programming them and program the current interfaces when turned on", func() {
+				state.TrackPendingDiffState(epSource)
+				Expect(xdpIfaces()).To(BeEmpty())
+				Expect(state.ipV4State.currentState.IfaceNameToData).To(BeEmpty())
+				Expect(state.ipV4State.currentState.XDPEligiblePolicies).To(HaveKey(proto.PolicyID{Tier: "default", Name: "policy"}))
+
+				state.AttachToEndpoints(cbs, epSource)
+				Expect(state.ipV4State.cbIDs).NotTo(BeEmpty())
+				state.QueueResync()
+				apply()
+				Expect(xdpIfaces()).To(ConsistOf("eth0"))
+				dump, err := lib.DumpCIDRMap("eth0", bpf.IPFamilyV4)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dump).To(HaveLen(2))
+			})
+
+			It("should forget the interfaces when turned off", func() {
+				state.AttachToEndpoints(cbs, epSource)
+				state.QueueResync()
+				apply()
+				Expect(xdpIfaces()).To(ConsistOf("eth0"))
+
+				Expect(state.WipeXDP()).To(Succeed())
+				state.DetachFromEndpoints(cbs)
+				Expect(state.ipV4State.cbIDs).To(BeEmpty())
+				state.TrackPendingDiffState(epSource)
+				Expect(xdpIfaces()).To(BeEmpty())
+				Expect(state.ipV4State.currentState.IfaceNameToData).To(BeEmpty())
+
+				By("not programming an interface that was removed while XDP was off")
+				epSource.activeIfaces = nil
+				state.AttachToEndpoints(cbs, epSource)
+				state.QueueResync()
+				apply()
+				Expect(xdpIfaces()).To(BeEmpty())
+			})
+		})
+
+		Describe("blocklist reasons", func() {
+			var (
+				lib   *bpf.MockBPFLib
+				state *xdpState
+			)
+
+			BeforeEach(func() {
+				lib = bpf.NewMockBPFLib("../../bpf-apache/bin")
+				state = NewXDPStateWithBPFLibrary(lib, true)
+			})
+
+			reasons := func() map[string]string {
+				dump, err := lib.DumpBlocklistReasonsMap()
+				Expect(err).NotTo(HaveOccurred())
+				m := map[string]string{}
+				for k, reason := range dump {
+					m[k.ToIPNet().String()] = reason
+				}
+				return m
+			}
+
+			It("should record, update and remove the reasons", func() {
+				state.OnUpdate(&proto.BlocklistReasonUpdate{Cidr: "10.0.0.1/32", Reason: "threat-feed-x"})
+				state.OnUpdate(&proto.BlocklistReasonUpdate{Cidr: "10.0.1.0/24", Reason: "threat-feed-y"})
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+				Expect(reasons()).To(Equal(map[string]string{
+					"10.0.0.1/32": "threat-feed-x",
+					"10.0.1.0/24": "threat-feed-y",
+				}))
+
+				state.OnUpdate(&proto.BlocklistReasonUpdate{Cidr: "10.0.0.1/32", Reason: "threat-feed-z"})
+				state.OnUpdate(&proto.BlocklistReasonRemove{Cidr: "10.0.1.0/24"})
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+				Expect(reasons()).To(Equal(map[string]string{"10.0.0.1/32": "threat-feed-z"}))
+			})
+
+			It("should ignore reasons for IPv6 CIDRs", func() {
+				state.OnUpdate(&proto.BlocklistReasonUpdate{Cidr: "dead:beef::1/128", Reason: "threat-feed-x"})
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+				Expect(reasons()).To(BeEmpty())
+				Expect(state.common.dirtyReasons.Len()).To(BeZero())
+			})
+
+			It("should fix up the map after a resync", func() {
+				state.OnUpdate(&proto.BlocklistReasonUpdate{Cidr: "10.0.0.1/32", Reason: "threat-feed-x"})
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+
+				ip := net.ParseIP("10.0.2.0")
+				Expect(lib.UpdateBlocklistReasonsMap(ip, 24, "stale")).To(Succeed())
+				ip = net.ParseIP("10.0.0.1")
+				Expect(lib.UpdateBlocklistReasonsMap(ip, 32, "wrong")).To(Succeed())
+
+				state.QueueResync()
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+				Expect(reasons()).To(Equal(map[string]string{"10.0.0.1/32": "threat-feed-x"}))
+			})
+		})
+
+		Describe("allow-list", func() {
+			var (
+				lib   *bpf.MockBPFLib
+				state *xdpState
+			)
+
+			BeforeEach(func() {
+				lib = bpf.NewMockBPFLib("../../bpf-apache/bin")
+				state = NewXDPStateWithBPFLibrary(lib, true)
+				state.common.hostname = "host1"
+			})
+
+			allowList := func() []string {
+				dump, err := lib.DumpXDPAllowMap()
+				Expect(err).NotTo(HaveOccurred())
+				var cidrs []string
+				for k := range dump {
+					cidrs = append(cidrs, k.ToIPNet().String())
+				}
+				return cidrs
+			}
+
+			wepID := proto.WorkloadEndpointID{OrchestratorId: "k8s", WorkloadId: "default/pod1", EndpointId: "eth0"}
+
+			It("should hold the host's IP, its local routes and its workloads' addresses", func() {
+				state.OnUpdate(&proto.HostMetadataUpdate{Hostname: "host1", Ipv4Addr: "172.17.0.3"})
+				state.OnUpdate(&proto.HostMetadataUpdate{Hostname: "host2", Ipv4Addr: "172.17.0.4"})
+				state.OnUpdate(&proto.RouteUpdate{Type: proto.RouteType_LOCAL_WORKLOAD, Dst: "10.65.0.0/26", DstNodeName: "host1"})
+				state.OnUpdate(&proto.RouteUpdate{Type: proto.RouteType_LOCAL_TUNNEL, Dst: "10.65.0.1/32", DstNodeName: "host1"})
+				state.OnUpdate(&proto.RouteUpdate{Type: proto.RouteType_REMOTE_WORKLOAD, Dst: "10.65.1.0/26", DstNodeName: "host2"})
+				state.OnUpdate(&proto.WorkloadEndpointUpdate{
+					Id:       &wepID,
+					Endpoint: &proto.WorkloadEndpoint{Ipv4Nets: []string{"10.65.0.2/32"}, Ipv6Nets: []string{"dead:beef::2/128"}},
+				})
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+				Expect(allowList()).To(ConsistOf("172.17.0.3/32", "10.65.0.0/26", "10.65.0.1/32", "10.65.0.2/32"))
+				Expect(state.common.allowListDirty).To(BeFalse())
+
+				state.OnUpdate(&proto.RouteRemove{Dst: "10.65.0.1/32"})
+				state.OnUpdate(&proto.RouteUpdate{Type: proto.RouteType_REMOTE_WORKLOAD, Dst: "10.65.0.0/26", DstNodeName: "host2"})
+				state.OnUpdate(&proto.WorkloadEndpointRemove{Id: &wepID})
+				state.OnUpdate(&proto.HostMetadataRemove{Hostname: "host1"})
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+				Expect(allowList()).To(BeEmpty())
+			})
+
+			It("should fix up the allow-list after a resync", func() {
+				state.OnUpdate(&proto.HostMetadataUpdate{Hostname: "host1", Ipv4Addr: "172.17.0.3"})
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+
+				Expect(lib.UpdateXDPAllowMap(net.ParseIP("10.0.0.0"), 8)).To(Succeed())
+				Expect(lib.RemoveItemXDPAllowMap(net.ParseIP("172.17.0.3"), 32)).To(Succeed())
+
+				state.QueueResync()
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+				Expect(allowList()).To(ConsistOf("172.17.0.3/32"))
+			})
+
+			It("should remove the allow-list when wiping XDP", func() {
+				state.OnUpdate(&proto.HostMetadataUpdate{Hostname: "host1", Ipv4Addr: "172.17.0.3"})
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+				Expect(state.WipeXDP()).To(Succeed())
+				Expect(lib.XDPAllowList).To(BeNil())
+			})
+		})
+
+		Describe("grace list", func() {
+			var (
+				lib   *bpf.MockBPFLib
+				state *xdpState
+			)
+
+			BeforeEach(func() {
+				lib = bpf.NewMockBPFLib("../../bpf-apache/bin")
+				state = NewXDPStateWithBPFLibrary(lib, true)
+			})
+
+			graceList := func() []string {
+				dump, err := lib.DumpXDPGraceMap()
+				Expect(err).NotTo(HaveOccurred())
+				var cidrs []string
+				for k := range dump {
+					cidrs = append(cidrs, k.ToIPNet().String())
+				}
+				return cidrs
+			}
+
+			It("should hold the IPv4 CIDRs that are in their grace period", func() {
+				state.OnUpdate(&proto.BlocklistGraceUpdate{Cidr: "10.0.0.1/32"})
+				state.OnUpdate(&proto.BlocklistGraceUpdate{Cidr: "10.0.1.0/24"})
+				state.OnUpdate(&proto.BlocklistGraceUpdate{Cidr: "dead:beef::1/128"})
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+				Expect(graceList()).To(ConsistOf("10.0.0.1/32", "10.0.1.0/24"))
+				Expect(state.common.graceListDirty).To(BeFalse())
+
+				state.OnUpdate(&proto.BlocklistGraceRemove{Cidr: "10.0.0.1/32"})
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+				Expect(graceList()).To(ConsistOf("10.0.1.0/24"))
+			})
+
+			It("should fix up the grace list after a resync", func() {
+				state.OnUpdate(&proto.BlocklistGraceUpdate{Cidr: "10.0.0.1/32"})
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+
+				Expect(lib.UpdateXDPGraceMap(net.ParseIP("10.0.2.0"), 24)).To(Succeed())
+				Expect(lib.RemoveItemXDPGraceMap(net.ParseIP("10.0.0.1"), 32)).To(Succeed())
+
+				state.QueueResync()
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+				Expect(graceList()).To(ConsistOf("10.0.0.1/32"))
+			})
+
+			It("should remove the grace list when wiping XDP", func() {
+				state.OnUpdate(&proto.BlocklistGraceUpdate{Cidr: "10.0.0.1/32"})
+				Expect(state.ProcessMemberUpdates(&nilIPSetsSource{})).To(Succeed())
+				Expect(state.WipeXDP()).To(Succeed())
+				Expect(lib.XDPGraceList).To(BeNil())
+			})
+		})
 	})
 })
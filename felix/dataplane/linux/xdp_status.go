@@ -0,0 +1,126 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/bpf"
+)
+
+// xdpStatus is the XDP status that Felix serves at bpf.XDPStatusPath on the Prometheus
+// metrics port.  Like the XDP metrics, it's shared by all the XDP states; there's only one
+// at a time.
+var xdpStatus = newXDPStatusReporter()
+
+func init() {
+	http.Handle(bpf.XDPStatusPath, xdpStatus)
+}
+
+// xdpStatusReporter tracks the XDP status of each interface.  The dataplane goroutine
+// records the XDP programs that it attaches and detaches as it does so, and then publishes
+// the rest of the status once it has finished reconciling; the HTTP handler only ever sees
+// the published status.
+type xdpStatusReporter struct {
+	lock sync.Mutex
+	// attachedModes holds the mode of each interface that has an XDP program attached.
+	attachedModes map[string]bpf.XDPMode
+	// published is the status as of the last reconcile.
+	published map[string]bpf.XDPStatus
+}
+
+func newXDPStatusReporter() *xdpStatusReporter {
+	return &xdpStatusReporter{
+		attachedModes: map[string]bpf.XDPMode{},
+		published:     map[string]bpf.XDPStatus{},
+	}
+}
+
+// setAttached records that an XDP program is attached to the interface in the given mode.
+func (r *xdpStatusReporter) setAttached(iface string, mode bpf.XDPMode) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.attachedModes[iface] = mode
+}
+
+// setDetached records that the interface has no XDP program attached.
+func (r *xdpStatusReporter) setDetached(iface string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.attachedModes, iface)
+}
+
+// setAllDetached forgets the XDP programs that were attached, before they're listed again
+// by a resync.
+func (r *xdpStatusReporter) setAllDetached() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.attachedModes = map[string]bpf.XDPMode{}
+}
+
+// publish replaces the published status with that of the interfaces that want XDP, given
+// with the number of entries in their blocklists, and of those with XDP programs attached.
+func (r *xdpStatusReporter) publish(desired map[string]int, now time.Time) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	published := make(map[string]bpf.XDPStatus, len(desired))
+	for iface, entries := range desired {
+		published[iface] = bpf.XDPStatus{
+			Desired:          true,
+			BlocklistEntries: entries,
+			LastReconcile:    now,
+		}
+	}
+	for iface, mode := range r.attachedModes {
+		st := published[iface]
+		st.Attached = true
+		st.Mode = mode.String()
+		st.AttachFlags = mode.AttachFlags()
+		st.LastReconcile = now
+		published[iface] = st
+	}
+	r.published = published
+}
+
+// reset forgets everything; for example, because XDP has been disabled.
+func (r *xdpStatusReporter) reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.attachedModes = map[string]bpf.XDPMode{}
+	r.published = map[string]bpf.XDPStatus{}
+}
+
+// snapshot returns a copy of the published status.
+func (r *xdpStatusReporter) snapshot() map[string]bpf.XDPStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	snap := make(map[string]bpf.XDPStatus, len(r.published))
+	for iface, st := range r.published {
+		snap[iface] = st
+	}
+	return snap
+}
+
+func (r *xdpStatusReporter) ServeHTTP(rsp http.ResponseWriter, req *http.Request) {
+	rsp.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rsp).Encode(r.snapshot()); err != nil {
+		log.WithError(err).Warn("Failed to write XDP status.")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intdataplane
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/sys/unix"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/proto"
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
+)
+
+var _ = Describe("XDP status", func() {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		xdpStatus.reset()
+	})
+
+	AfterEach(func() {
+		xdpStatus.reset()
+	})
+
+	It("should merge the desired and attached interfaces", func() {
+		r := newXDPStatusReporter()
+		r.setAttached("eth0", bpf.XDPDriver)
+		r.setAttached("eth1", bpf.XDPGeneric)
+		r.publish(map[string]int{"eth0": 3, "eth2": 1}, now)
+
+		Expect(r.snapshot()).To(Equal(map[string]bpf.XDPStatus{
+			"eth0": {
+				Desired:          true,
+				Attached:         true,
+				Mode:             "xdpdrv",
+				AttachFlags:      unix.XDP_FLAGS_DRV_MODE | unix.XDP_FLAGS_UPDATE_IF_NOEXIST,
+				BlocklistEntries: 3,
+				LastReconcile:    now,
+			},
+			"eth1": {
+				Attached:      true,
+				Mode:          "xdpgeneric",
+				AttachFlags:   unix.XDP_FLAGS_SKB_MODE | unix.XDP_FLAGS_UPDATE_IF_NOEXIST,
+				LastReconcile: now,
+			},
+			"eth2": {Desired: true, BlocklistEntries: 1, LastReconcile: now},
+		}))
+	})
+
+	It("should only change the served status when it's published", func() {
+		r := newXDPStatusReporter()
+		r.publish(map[string]int{"eth0": 1}, now)
+		r.setAttached("eth0", bpf.XDPDriver)
+		Expect(r.snapshot()["eth0"].Attached).To(BeFalse())
+
+		r.publish(map[string]int{"eth0": 1}, now)
+		Expect(r.snapshot()["eth0"].Attached).To(BeTrue())
+
+		r.setDetached("eth0")
+		r.publish(map[string]int{"eth0": 1}, now)
+		Expect(r.snapshot()["eth0"].Attached).To(BeFalse())
+	})
+
+	It("should serve the status as JSON", func() {
+		r := newXDPStatusReporter()
+		r.setAttached("eth0", bpf.XDPDriver)
+		r.publish(map[string]int{"eth0": 2}, now)
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("GET", bpf.XDPStatusPath, nil))
+		Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+
+		var status map[string]bpf.XDPStatus
+		Expect(json.Unmarshal(rec.Body.Bytes(), &status)).To(Succeed())
+		Expect(status).To(Equal(r.snapshot()))
+	})
+
+	It("should be published by the XDP state as it attaches programs and updates its state", func() {
+		lib := bpf.NewMockBPFLib("../../bpf-apache/bin")
+		_, err := lib.NewFailsafeMap()
+		Expect(err).NotTo(HaveOccurred())
+
+		state := NewXDPStateWithBPFLibrary(lib, true)
+		memberCache := newXDPMemberCache(bpf.IPFamilyV4, lib)
+		modes := state.ipV4State.xdpModesForIface(state.common.xdpModes)
+
+		actions := newXDPBPFActions()
+		actions.InstallXDP.Add("eth0")
+		actions.CreateMap.Add("eth0")
+		err = actions.apply(memberCache, newIPSetIDsToMembers(), newConvertingIPSetsSource(&mockIPSetsSource{}), modes, false, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		// The same CIDR, with and without a prefix length, is only one map entry.
+		state.ipV4State.newCurrentState = newXDPSystemState()
+		state.ipV4State.newCurrentState.IfaceNameToData["eth0"] = xdpIfaceData{
+			PoliciesToSetIDs: map[proto.PolicyID]set.Set[string]{
+				{Tier: "default", Name: "xdp-filter"}: set.From("s1"),
+			},
+		}
+		state.ipV4State.ipsetIDsToMembers.SetCache("s1", set.From("10.0.0.5", "10.0.0.5/32", "10.0.0.0/24"))
+		state.UpdateState()
+
+		st := xdpStatus.snapshot()["eth0"]
+		Expect(st.Desired).To(BeTrue())
+		Expect(st.Attached).To(BeTrue())
+		Expect(st.Mode).To(Equal(bpf.XDPOffload.String()))
+		Expect(st.BlocklistEntries).To(Equal(2))
+		Expect(st.LastReconcile).NotTo(BeZero())
+	})
+})
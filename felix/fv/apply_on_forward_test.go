@@ -101,8 +101,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ apply on forward tests; wit
 
 	itShouldHaveWorkloadToWorkloadAndHostConnectivity := func() {
 		It("should have workload to workload/host connectivity", func() {
-			cc.ExpectSome(w[0], w[1])
-			cc.ExpectSome(w[1], w[0])
+			cc.ExpectMutualSome(w[0], w[1])
 			cc.ExpectSome(w[0], hostW[1])
 			cc.ExpectSome(w[1], hostW[0])
 			cc.CheckConnectivity()
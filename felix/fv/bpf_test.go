@@ -493,8 +493,7 @@ func describeBPFTests(opts ...bpfTestOpt) bool {
 					options.ExtraEnvVars["FELIX_DefaultEndpointToHostAction"] = "DROP"
 				})
 				It("should only allow traffic from workload to workload", func() {
-					cc.ExpectSome(w[0], w[1])
-					cc.ExpectSome(w[1], w[0])
+					cc.ExpectMutualSome(w[0], w[1])
 					cc.ExpectNone(w[1], hostW)
 					cc.ExpectSome(hostW, w[0])
 					cc.CheckConnectivity(conntrackChecks(felixes)...)
@@ -535,8 +534,7 @@ func describeBPFTests(opts ...bpfTestOpt) bool {
 					options.ExtraEnvVars["FELIX_DefaultEndpointToHostAction"] = "ACCEPT"
 				})
 				It("should allow traffic from workload to workload and to/from host", func() {
-					cc.ExpectSome(w[0], w[1])
-					cc.ExpectSome(w[1], w[0])
+					cc.ExpectMutualSome(w[0], w[1])
 					cc.ExpectSome(w[1], hostW)
 					cc.ExpectSome(hostW, w[0])
 					cc.CheckConnectivity(conntrackChecks(felixes)...)
@@ -1051,11 +1049,9 @@ func describeBPFTests(opts ...bpfTestOpt) bool {
 
 			It("should only allow traffic from the local host by default", func() {
 				// Same host, other workload.
-				cc.ExpectNone(w[0][0], w[0][1])
-				cc.ExpectNone(w[0][1], w[0][0])
+				cc.ExpectMutualNone(w[0][0], w[0][1])
 				// Workloads on other host.
-				cc.ExpectNone(w[0][0], w[1][0])
-				cc.ExpectNone(w[1][0], w[0][0])
+				cc.ExpectMutualNone(w[0][0], w[1][0])
 				// Hosts.
 				cc.ExpectSome(felixes[0], w[0][0])
 				cc.ExpectNone(felixes[1], w[0][0])
@@ -0,0 +1,96 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectivity
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/onsi/ginkgo"
+)
+
+// AnySource returns a connection source that stands for a group of sources: a probe from it
+// probes from all of the sources at once and connects if any of them connects.  For example:
+//
+//	cc.ExpectNone(connectivity.AnySource(w[0], w[1]), w[2])
+//
+// expects that neither w[0] nor w[1] can connect to w[2].
+func AnySource(sources ...ConnectionSource) ConnectionSource {
+	return &anySource{sources: sources}
+}
+
+type anySource struct {
+	sources []ConnectionSource
+}
+
+func (a *anySource) SourceName() string {
+	names := make([]string, len(a.sources))
+	for i, s := range a.sources {
+		names[i] = s.SourceName()
+	}
+	return "any of [" + strings.Join(names, ", ") + "]"
+}
+
+func (a *anySource) SourceIPs() []string {
+	var ips []string
+	for _, s := range a.sources {
+		ips = append(ips, s.SourceIPs()...)
+	}
+	return ips
+}
+
+func (a *anySource) PreRetryCleanup(ip, port, protocol string, opts ...CheckOption) {
+	for _, s := range a.sources {
+		s.PreRetryCleanup(ip, port, protocol, opts...)
+	}
+}
+
+// CanConnectTo returns the result of the first source, in the order that they were given,
+// that connected or, if none did, the result of the last source.
+func (a *anySource) CanConnectTo(ip, port, protocol string, opts ...CheckOption) *Result {
+	results := make([]*Result, len(a.sources))
+	var wg sync.WaitGroup
+	for i, s := range a.sources {
+		wg.Add(1)
+		go func(i int, s ConnectionSource) {
+			defer ginkgo.GinkgoRecover()
+			defer wg.Done()
+			results[i] = s.CanConnectTo(ip, port, protocol, opts...)
+		}(i, s)
+	}
+	wg.Wait()
+
+	var res *Result
+	for _, res = range results {
+		if res.HasConnectivity() {
+			break
+		}
+	}
+	if res != nil && res.PortScan != nil {
+		// For a port scan, a port is reachable if it's reachable from any of the sources.
+		merged := *res
+		merged.PortScan = map[int]bool{}
+		for _, r := range results {
+			if r == nil {
+				continue
+			}
+			for port, reachable := range r.PortScan {
+				merged.PortScan[port] = merged.PortScan[port] || reachable
+			}
+		}
+		res = &merged
+	}
+	return res
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectivity
+
+import (
+	"fmt"
+	"math"
+
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+)
+
+// ConnectionCountReporter is implemented by connection targets that can report how many
+// connections reached them; see ExpectBalancedAcross.
+type ConnectionCountReporter interface {
+	// ConnectionCount returns the number of connections, or UDP requests, that the target
+	// has received so far.
+	ConnectionCount() (int, error)
+}
+
+// ExpectBalancedAcross opens n connections from the source to the given port of the servers,
+// spreading them like a load balancer: round robin, and a server that fails a connection is
+// taken out of the rotation and the connection goes to the next server.  It asserts that
+//
+//   - every connection reaches one of the servers;
+//   - each server that stays in the rotation serves within tolerance, as a fraction, of an
+//     even share of the connections;
+//   - each server that drops out, for example because an XDP blocklist protects it from the
+//     source, receives none of the connections, according to its own count.
+//
+// The servers must be ConnectionCountReporters.  Unlike most Expect methods, it checks
+// straight away, rather than recording an expectation for CheckConnectivity.
+func (c *Checker) ExpectBalancedAcross(servers []ConnectionTarget, from ConnectionSource, port uint16,
+	n int, tolerance float64) {
+
+	reporters := make([]ConnectionCountReporter, len(servers))
+	countsBefore := make([]int, len(servers))
+	for i, s := range servers {
+		r, ok := s.(ConnectionCountReporter)
+		if !ok {
+			panic(fmt.Sprintf("%T can't report its connection count", s))
+		}
+		reporters[i] = r
+		count, err := r.ConnectionCount()
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		countsBefore[i] = count
+	}
+
+	protocol := "tcp"
+	if c.Protocol != "" {
+		protocol = c.Protocol
+	}
+	var opts []CheckOption
+	if c.ConnectTimeout > 0 {
+		opts = append(opts, WithConnectTimeout(c.ConnectTimeout))
+	}
+
+	served := make([]int, len(servers))
+	ejected := make([]bool, len(servers))
+	next := 0
+	for conn := 0; conn < n; conn++ {
+		connected := false
+		for tries := 0; tries < len(servers) && !connected; tries++ {
+			i := next
+			next = (next + 1) % len(servers)
+			if ejected[i] {
+				continue
+			}
+			m := servers[i].ToMatcher(port)
+			if from.CanConnectTo(m.IP, m.Port, protocol, opts...).HasConnectivity() {
+				served[i]++
+				connected = true
+			} else {
+				log.WithField("server", m.TargetName).Info("Server failed a connection, taking it out of the rotation.")
+				ejected[i] = true
+			}
+		}
+		ExpectWithOffset(1, connected).To(BeTrue(),
+			fmt.Sprintf("Connection %d from %s didn't reach any server", conn, from.SourceName()))
+	}
+
+	var inRotation int
+	for _, e := range ejected {
+		if !e {
+			inRotation++
+		}
+	}
+	share := float64(n) / float64(inRotation)
+	for i, s := range servers {
+		name := s.ToMatcher(port).TargetName
+		log.WithFields(log.Fields{
+			"server":  name,
+			"served":  served[i],
+			"ejected": ejected[i],
+		}).Info("Connections served.")
+		if ejected[i] {
+			// The count file may lag slightly so wait a moment for any connection that
+			// got through to show up.
+			ConsistentlyWithOffset(1, reporters[i].ConnectionCount, "1s", "200ms").Should(Equal(countsBefore[i]),
+				fmt.Sprintf("%s failed a connection but received some", name))
+			continue
+		}
+		ExpectWithOffset(1, math.Abs(float64(served[i])-share)).To(BeNumerically("<=", tolerance*share),
+			fmt.Sprintf("%s served %d of %d connections, more than %.0f%% off an even share of %.1f",
+				name, served[i], n, tolerance*100, share))
+		EventuallyWithOffset(1, func() (int, error) {
+			count, err := reporters[i].ConnectionCount()
+			return count - countsBefore[i], err
+		}, "2s", "100ms").Should(BeNumerically(">=", served[i]),
+			fmt.Sprintf("%s reports fewer connections than it served", name))
+	}
+}
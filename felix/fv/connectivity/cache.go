@@ -0,0 +1,111 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectivity
+
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ResultCache memoizes the results of connectivity probes so that a Checker with many identical
+// expectations (same source, target, port, protocol and options) only probes each path once.  To
+// use it, set Checker.Cache:
+//
+//	cc.Cache = &connectivity.ResultCache{StateVersion: datastoreRevision}
+//
+// The cache is invalidated by Checker.ResetExpectations(), before each retry of a failed check,
+// and whenever StateVersion changes.  Probes that measure packet loss are never cached.
+type ResultCache struct {
+	// StateVersion, if set, returns a value that changes whenever something that could affect
+	// connectivity changes; for example, the datastore revision, which changes when any policy
+	// or set is updated.  It is called once per check.
+	StateVersion func() string
+
+	lock    sync.Mutex
+	version string
+	entries map[string]*cacheEntry
+	hits    int
+}
+
+type cacheEntry struct {
+	done   chan struct{}
+	result *Result
+}
+
+// Invalidate drops all the cached results.
+func (rc *ResultCache) Invalidate() {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+	rc.entries = nil
+}
+
+// Hits returns the number of probes that were answered from the cache.
+func (rc *ResultCache) Hits() int {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+	return rc.hits
+}
+
+// checkVersion invalidates the cache if the state version has changed since it was last checked.
+func (rc *ResultCache) checkVersion() {
+	if rc.StateVersion == nil {
+		return
+	}
+	version := rc.StateVersion()
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+	if version != rc.version {
+		log.WithFields(log.Fields{
+			"oldVersion": rc.version,
+			"newVersion": version,
+		}).Debug("State changed, invalidating connectivity cache.")
+		rc.version = version
+		rc.entries = nil
+	}
+}
+
+// probe returns the cached result for the given key or, if there isn't one, calls doProbe and
+// caches its result.  Concurrent callers with the same key wait for the first one's probe.
+func (rc *ResultCache) probe(key string, doProbe func() *Result) *Result {
+	rc.lock.Lock()
+	if e, ok := rc.entries[key]; ok {
+		rc.hits++
+		rc.lock.Unlock()
+		log.WithField("probe", key).Debug("Using cached connectivity result.")
+		<-e.done
+		return e.result
+	}
+	if rc.entries == nil {
+		rc.entries = map[string]*cacheEntry{}
+	}
+	e := &cacheEntry{done: make(chan struct{})}
+	rc.entries[key] = e
+	rc.lock.Unlock()
+
+	defer close(e.done)
+	e.result = doProbe()
+	return e.result
+}
+
+// probeKey identifies a probe by everything that is passed to ConnectionSource.CanConnectTo().
+func probeKey(from ConnectionSource, ip, port, protocol string, opts ...CheckOption) string {
+	var cmd CheckCmd
+	for _, o := range opts {
+		o(&cmd)
+	}
+	return fmt.Sprintf("%s%v -> %s:%s/%s %+v", from.SourceName(), from.SourceIPs(), ip, port, protocol, cmd)
+}
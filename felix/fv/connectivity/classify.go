@@ -0,0 +1,120 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectivity
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FailureReason says why a connection failed, as far as ClassifyFailure can tell.
+type FailureReason string
+
+const (
+	// FailureNone means that the connection succeeded.
+	FailureNone FailureReason = ""
+	// FailurePolicyDrop means that nothing answered although there are routes both ways,
+	// which is what a policy, or XDP, that drops the packets looks like.
+	FailurePolicyDrop FailureReason = "POLICY_DROP"
+	// FailureNoRoute means that the source got an ICMP unreachable, or that the source has
+	// no route to the target or the target has no route back.
+	FailureNoRoute FailureReason = "NO_ROUTE"
+	// FailurePortClosed means that the target refused the connection.
+	FailurePortClosed FailureReason = "PORT_CLOSED"
+	// FailureTimeout means that nothing answered and the routes couldn't be checked.
+	FailureTimeout FailureReason = "TIMEOUT"
+)
+
+// RouteChecker is implemented by connection sources and targets that can look up their own
+// routes; see ClassifyFailure.
+type RouteChecker interface {
+	// HasRouteTo returns whether the endpoint has a usable route to the given IP.
+	HasRouteTo(ip string) (bool, error)
+}
+
+// ClassifyFailure probes the target's port from the source, straight away, and says why the
+// connection failed, or FailureNone if it didn't.  A refusal or an ICMP unreachable speaks
+// for itself.  If nothing answered, the routes from the source to the target and back decide
+// between a routing problem and a drop; that stops a test from putting a misrouted topology
+// down to policy.  The routes can only be checked if both the source and the target are
+// RouteCheckers; otherwise, a silent failure is FailureTimeout.  For example:
+//
+//	Expect(cc.ClassifyFailure(w[0], w[1], 8055)).To(Equal(connectivity.FailurePolicyDrop))
+func (c *Checker) ClassifyFailure(from ConnectionSource, to ConnectionTarget, port uint16) FailureReason {
+	p := "tcp"
+	if c.Protocol != "" {
+		p = c.Protocol
+	}
+	m := to.ToMatcher(port)
+
+	var opts []CheckOption
+	if c.ConnectTimeout > 0 {
+		opts = append(opts, WithConnectTimeout(c.ConnectTimeout))
+	}
+	res := from.CanConnectTo(m.IP, m.Port, p, opts...)
+	if res.HasConnectivity() {
+		return FailureNone
+	}
+	if res != nil {
+		switch res.ConnectFailure {
+		case ConnectFailureRefused:
+			return FailurePortClosed
+		case ConnectFailureUnreachable:
+			return FailureNoRoute
+		}
+	}
+
+	routed, err := routedBothWays(from, to, m.IP)
+	if err != nil {
+		log.WithError(err).WithField("target", m.TargetName).Info("Can't check routes, classifying as a timeout.")
+		return FailureTimeout
+	}
+	if !routed {
+		return FailureNoRoute
+	}
+	return FailurePolicyDrop
+}
+
+// routedBothWays returns whether the source has a route to the target's IP and the target has
+// a route back to the source.
+func routedBothWays(from ConnectionSource, to ConnectionTarget, targetIP string) (bool, error) {
+	fromRC, ok := from.(RouteChecker)
+	if !ok {
+		return false, fmt.Errorf("%s can't check its routes", from.SourceName())
+	}
+	toRC, ok := to.(RouteChecker)
+	if !ok {
+		return false, fmt.Errorf("%T can't check its routes", to)
+	}
+	if routed, err := fromRC.HasRouteTo(targetIP); err != nil || !routed {
+		return false, err
+	}
+	return toRC.HasRouteTo(from.SourceIPs()[0])
+}
+
+// ParseRouteGet interprets the combined output of "ip route get <ip>" and its error, for
+// RouteCheckers.  The command fails with an RTNETLINK error if there's no route, or if the
+// route is unreachable, prohibit or blackhole.
+func ParseRouteGet(out string, err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	if strings.Contains(out, "RTNETLINK answers") {
+		return false, nil
+	}
+	return false, fmt.Errorf("ip route get failed: %w: %s", err, out)
+}
@@ -53,9 +53,32 @@ type Checker struct {
 	RetriesDisabled  bool
 	StaggerStartBy   time.Duration
 
+	// ConnectTimeout, if set, limits how long each TCP probe waits for the handshake; by
+	// default, it waits for most of the probe's timeout.  A short ConnectTimeout makes
+	// ExpectNone quicker for flows whose SYNs are silently dropped but it must still be long
+	// enough for a working connection to complete, including any ARP resolution and SYN
+	// retransmits: a connection that's slower than ConnectTimeout looks the same as a drop,
+	// so ExpectNone and ExpectTimeout would pass and ExpectSome would fail.
+	ConnectTimeout time.Duration
+
+	// DontFragment, if set, makes each UDP probe set the don't fragment bit so that a request
+	// that's bigger than the client's MTU fails to send instead of being fragmented.  Use
+	// ExpectWithDatagramLen to send requests that big.
+	DontFragment bool
+
+	// ThroughputSendRate is the rate, in packets per second, at which MeasureUDPThroughput
+	// sends; if zero, it sends at DefaultThroughputSendRate.
+	ThroughputSendRate int
+
 	// OnFail, if set, will be called instead of ginkgo.Fail().  (Useful for testing the checker itself.)
 	OnFail func(msg string)
 
+	// roles maps a role, such as "client", to the workload that plays it; see SetRole().
+	roles map[string]RoleEndpoint
+
+	// Cache, if set, is used to avoid probing identical paths more than once; see ResultCache.
+	Cache *ResultCache
+
 	description string
 	init        func()       // called before testing starts
 	beforeRetry func()       // called when a test fails and before it is retried
@@ -118,6 +141,29 @@ func (c *Checker) ExpectNone(from ConnectionSource, to ConnectionTarget, explici
 	c.expect(None, from, to, ExpectWithPorts(explicitPort...))
 }
 
+// ExpectMutualSome asserts that a can connect to b and that b can connect to a.
+func (c *Checker) ExpectMutualSome(a, b RoleEndpoint, explicitPort ...uint16) {
+	c.ExpectSome(a, b, explicitPort...)
+	c.ExpectSome(b, a, explicitPort...)
+}
+
+// ExpectMutualNone asserts that a can't connect to b and that b can't connect to a.
+func (c *Checker) ExpectMutualNone(a, b RoleEndpoint, explicitPort ...uint16) {
+	c.ExpectNone(a, b, explicitPort...)
+	c.ExpectNone(b, a, explicitPort...)
+}
+
+// ExpectSomeFromAny asserts that at least one of the given sources can connect to the target;
+// for example, that the sources that aren't blocked still reach a server.
+func (c *Checker) ExpectSomeFromAny(from []ConnectionSource, to ConnectionTarget, explicitPort ...uint16) {
+	c.ExpectSome(AnySource(from...), to, explicitPort...)
+}
+
+// ExpectNoneFromAll asserts that none of the given sources can connect to the target.
+func (c *Checker) ExpectNoneFromAll(from []ConnectionSource, to ConnectionTarget, explicitPort ...uint16) {
+	c.ExpectNone(AnySource(from...), to, explicitPort...)
+}
+
 // Expect asserts existing connectivity between a ConnectionSource
 // and ConnectionTarget with details configurable with ExpectationOption(s).
 // This is a super set of ExpectSome()
@@ -139,6 +185,173 @@ func (c *Checker) ExpectLoss(from ConnectionSource, to ConnectionTarget,
 	)
 }
 
+// ExpectConcurrent asserts that all of n connections that are opened at the same
+// time from the source to the target succeed.  To assert that none of them get
+// through, use Expect(None, ...) with ExpectWithConcurrentConns(n).
+func (c *Checker) ExpectConcurrent(from ConnectionSource, to ConnectionTarget, port uint16, n int) {
+	c.expect(Some, from, to, ExpectWithPorts(port), ExpectWithConcurrentConns(n))
+}
+
+// ExpectInOrder asserts that all of count numbered UDP datagrams, sent back to back
+// from the source, reach the target and that the target receives them in the order
+// that they were sent.
+func (c *Checker) ExpectInOrder(from ConnectionSource, to ConnectionTarget, port uint16, count int) {
+	c.expect(Some, from, to, ExpectWithPorts(port), ExpectWithSequencedPackets(count))
+}
+
+// ExpectSomeWithMaxMSS asserts that there is TCP connectivity from the source to the
+// target and that the MSS seen by the server is at most maxMSS; for example, because
+// the SYN's MSS option was clamped on the way.
+func (c *Checker) ExpectSomeWithMaxMSS(from ConnectionSource, to ConnectionTarget, port uint16, maxMSS int) {
+	c.expect(Some, from, to, ExpectWithPorts(port), ExpectWithMaxMSS(maxMSS))
+}
+
+// ExpectSomeWithWindowScale asserts that there is TCP connectivity from the source to the
+// target and that the server negotiated a non-zero window scale with the client; that is,
+// the window scale option in the client's SYN reached the server intact.
+func (c *Checker) ExpectSomeWithWindowScale(from ConnectionSource, to ConnectionTarget, port uint16) {
+	c.expect(Some, from, to, ExpectWithPorts(port), ExpectWithWindowScale())
+}
+
+// ExpectFirstNThenBlocked asserts that, of connections opened one after the other from
+// the source to the target, the first n are allowed and the ones after them are blocked;
+// for example, by a connection limit.  Since the probes use up the limit, the check
+// isn't retried.
+func (c *Checker) ExpectFirstNThenBlocked(from ConnectionSource, to ConnectionTarget, port uint16, n int) {
+	c.RetriesDisabled = true
+	c.expect(Some, from, to, ExpectWithPorts(port), ExpectWithFirstNThenBlocked(n))
+}
+
+// ExpectFragNeeded asserts that a UDP datagram of sendLen bytes, sent from the source to
+// the target with the don't fragment bit set, makes a hop on the way send the source an
+// ICMP fragmentation needed that reports the given MTU.  To assert that nothing comes
+// back, for example because the datagram is dropped first, use Expect(None, ...) with
+// ExpectWithFragNeeded(sendLen, 0).
+func (c *Checker) ExpectFragNeeded(from ConnectionSource, to ConnectionTarget, port uint16, sendLen, mtu int) {
+	c.expect(Some, from, to, ExpectWithPorts(port), ExpectWithFragNeeded(sendLen, mtu))
+}
+
+// ExpectXDPDropNotMTU is the counterpart of ExpectFragNeeded for a source or target that is
+// blocklisted: it asserts that a UDP datagram of sendLen bytes, too big for a hop on the way,
+// sent with the don't fragment bit set gets no ICMP fragmentation needed back, because it is
+// dropped before it reaches that hop.  Checking the same size with ExpectFragNeeded for an
+// allowed flow on the same path shows that the silence is a policy drop rather than an MTU
+// problem; the XDP drop counters confirm who dropped it.
+func (c *Checker) ExpectXDPDropNotMTU(from ConnectionSource, to ConnectionTarget, port uint16, sendLen int) {
+	c.expect(None, from, to, ExpectWithPorts(port), ExpectWithFragNeeded(sendLen, 0))
+}
+
+// ExpectUDPPortClosed asserts that a UDP datagram sent from the source to the target's port
+// reaches the target host but finds nothing listening, so that the host answers with an ICMP
+// port unreachable.  This tells a closed port apart from a datagram that is dropped on the
+// way, for example by XDP, which ExpectNone can't do for UDP since both look like silence.
+func (c *Checker) ExpectUDPPortClosed(from ConnectionSource, to ConnectionTarget, port uint16) {
+	c.expect(Some, from, to, ExpectWithPorts(port), ExpectWithPortUnreachable())
+}
+
+// ExpectTimeout asserts that TCP connections from the source to the target's port time out
+// without any response to the SYNs, as they do when the SYNs are silently dropped; for
+// example, by XDP.  The probes give up after the Checker's ConnectTimeout, if set, so that has
+// to be long enough for the connection to succeed if the SYNs weren't being dropped.
+func (c *Checker) ExpectTimeout(from ConnectionSource, to ConnectionTarget, port uint16) {
+	c.expect(None, from, to, ExpectWithPorts(port), ExpectNoneWithConnectFailure(ConnectFailureTimeout))
+}
+
+// ExpectRefused asserts that TCP connections from the source to the target's port are
+// refused with a RST; for example, because nothing is listening on the port.
+func (c *Checker) ExpectRefused(from ConnectionSource, to ConnectionTarget, port uint16) {
+	c.expect(None, from, to, ExpectWithPorts(port), ExpectNoneWithConnectFailure(ConnectFailureRefused))
+}
+
+// ExpectEcho asserts that a request from the source to the target's port that carries the
+// given payload gets a response that echoes the payload back intact, which shows that the
+// request made the full round trip without being corrupted or truncated.  The test workload
+// reads UDP requests of up to 1KiB, so longer payloads aren't answered.
+func (c *Checker) ExpectEcho(from ConnectionSource, to ConnectionTarget, port uint16, payload string) {
+	c.expect(Some, from, to, ExpectWithPorts(port), ExpectWithEcho(payload))
+}
+
+// ExpectSomeWithECN asserts that a UDP request that the source sends to the target's port
+// with the given ECN codepoint reaches the target with the same codepoint; for example, that
+// nothing on the way clears ECT(0) or ECT(1).  The Checker's protocol must be "udp".
+func (c *Checker) ExpectSomeWithECN(from ConnectionSource, to ConnectionTarget, port uint16, ecn ECN) {
+	c.expect(Some, from, to, ExpectWithPorts(port), ExpectWithECN(ecn))
+}
+
+// PolicyDropReporter is implemented by connection targets whose host can report how many
+// packets each policy has dropped on their way to the target; see ExpectBlockedByPolicy.
+type PolicyDropReporter interface {
+	// PolicyDrops returns the number of packets that the named policy, such as
+	// "default.my-policy", has dropped so far.
+	PolicyDrops(policy string) (int, error)
+}
+
+// ExpectBlockedByPolicy asserts that the source can't connect to the target's port and that
+// it's the named policy, such as "default.my-policy", that drops the connection: the policy's
+// drop count on the target's host goes up while the check runs.  That stops a test passing
+// because some other policy, or a broken path, blocks the connection.  The target must be a
+// PolicyDropReporter, and the host must attribute drops to policies, which Felix only does in
+// BPF mode with BPFPolicyDebugEnabled.  The check reads the count before and after each probe,
+// so the attribution is only reliable if nothing else in the same check is dropped by the same
+// policy.
+func (c *Checker) ExpectBlockedByPolicy(from ConnectionSource, to ConnectionTarget, port uint16, policyName string) {
+	reporter, ok := to.(PolicyDropReporter)
+	if !ok {
+		panic(fmt.Sprintf("%T can't report policy drops", to))
+	}
+	c.expect(None, from, to, ExpectWithPorts(port), func(e *Expectation) {
+		e.blockingPolicy = policyName
+		e.policyDrops = reporter
+	})
+}
+
+// ExpectSelf asserts that the source can connect to the given port on its own IP; for
+// example, that a host can reach its own services.  Such connections never leave the host so
+// nothing that filters the host's incoming traffic, such as a blocklisted CIDR that happens to
+// contain the host's own IP, should stop them.
+func (c *Checker) ExpectSelf(from ConnectionSource, port uint16) {
+	c.expect(Some, from, TargetIP(from.SourceIPs()[0]), ExpectWithPorts(port))
+}
+
+// DefaultThroughputSendRate is the rate, in packets per second, at which MeasureUDPThroughput
+// sends unless the Checker's ThroughputSendRate says otherwise.
+const DefaultThroughputSendRate = 1000
+
+// MeasureUDPThroughput streams UDP requests from the source to the target's port for the
+// given duration, which must be a whole number of seconds, and returns the rate at which
+// responses came back, in packets per second, and the percentage of requests that got no
+// response.  A flow that's blocked loses 100%.  Unlike the Expect methods, it measures
+// straight away and doesn't record an expectation, so that the caller can compare the
+// results with its own targets.
+func (c *Checker) MeasureUDPThroughput(from ConnectionSource, to ConnectionTarget, port uint16,
+	duration time.Duration) (pps, lossPct float64) {
+
+	ExpectWithOffset(1, duration%time.Second).To(BeZero(), "duration must be a whole number of seconds")
+	ExpectWithOffset(1, duration).To(BeNumerically(">=", time.Second), "duration must be at least a second")
+	rate := c.ThroughputSendRate
+	if rate == 0 {
+		rate = DefaultThroughputSendRate
+	}
+
+	m := to.ToMatcher(port)
+	res := from.CanConnectTo(m.IP, m.Port, "udp", WithDuration(duration), WithSendRate(rate))
+	if res == nil || res.Stats.RequestsSent == 0 {
+		pps, lossPct = 0, 100
+	} else {
+		pps = float64(res.Stats.ResponsesReceived) / duration.Seconds()
+		lossPct = res.Stats.LostPercent()
+	}
+	log.WithFields(log.Fields{
+		"from":     from.SourceName(),
+		"to":       m.TargetName,
+		"port":     port,
+		"sendRate": rate,
+		"pps":      pps,
+		"loss%":    lossPct,
+	}).Info("Measured UDP throughput.")
+	return
+}
+
 func (c *Checker) expect(expected Expected, from ConnectionSource, to ConnectionTarget,
 	opts ...ExpectationOption) {
 
@@ -166,6 +379,61 @@ func (c *Checker) expect(expected Expected, from ConnectionSource, to Connection
 	c.expectations = append(c.expectations, e)
 }
 
+// RoleEndpoint is anything that can be both the source and the target of a connection,
+// such as a workload.
+type RoleEndpoint interface {
+	ConnectionSource
+	ConnectionTarget
+}
+
+// SetRole records that the given endpoint plays the given role, so that expectations can
+// be written in terms of roles; for example:
+//
+//	cc.SetRole("client", w[0])
+//	cc.SetRole("server", w[1])
+//	cc.From("client").To("server").ExpectSome(8055)
+//
+// Roles are kept by ResetExpectations().
+func (c *Checker) SetRole(role string, ep RoleEndpoint) {
+	if c.roles == nil {
+		c.roles = map[string]RoleEndpoint{}
+	}
+	c.roles[role] = ep
+}
+
+func (c *Checker) endpointForRole(role string) RoleEndpoint {
+	ep, ok := c.roles[role]
+	ExpectWithOffset(2, ok).To(BeTrue(), "no endpoint has role %q", role)
+	return ep
+}
+
+// From starts an expectation from the endpoint with the given role.
+func (c *Checker) From(role string) *RoleExpectation {
+	return &RoleExpectation{c: c, from: c.endpointForRole(role)}
+}
+
+// RoleExpectation is an expectation between two roles, which is being built by
+// Checker.From(role).To(role).
+type RoleExpectation struct {
+	c    *Checker
+	from ConnectionSource
+	to   ConnectionTarget
+}
+
+// To sets the target of the expectation to the endpoint with the given role.
+func (r *RoleExpectation) To(role string) *RoleExpectation {
+	r.to = r.c.endpointForRole(role)
+	return r
+}
+
+func (r *RoleExpectation) ExpectSome(explicitPort ...uint16) {
+	r.c.ExpectSome(r.from, r.to, explicitPort...)
+}
+
+func (r *RoleExpectation) ExpectNone(explicitPort ...uint16) {
+	r.c.ExpectNone(r.from, r.to, explicitPort...)
+}
+
 func (c *Checker) ResetExpectations() {
 	c.expectations = nil
 	c.CheckSNAT = false
@@ -174,6 +442,10 @@ func (c *Checker) ResetExpectations() {
 	c.description = ""
 	c.beforeRetry = nil
 	c.finalTest = nil
+
+	if c.Cache != nil {
+		c.Cache.Invalidate()
+	}
 }
 
 // ActualConnectivity calculates the current connectivity for all the expected paths.  It returns a
@@ -197,6 +469,10 @@ func (c *Checker) ActualConnectivity(isARetry bool) ([]*Result, []string) {
 			WithDuration(exp.ExpectedPacketLoss.Duration),
 		}
 
+		if c.ConnectTimeout > 0 {
+			opts = append(opts, WithConnectTimeout(c.ConnectTimeout))
+		}
+
 		if exp.sendLen > 0 || exp.recvLen > 0 {
 			opts = append(opts, WithSendLen(exp.sendLen), WithRecvLen(exp.recvLen))
 		}
@@ -204,6 +480,38 @@ func (c *Checker) ActualConnectivity(isARetry bool) ([]*Result, []string) {
 		if exp.srcPort != 0 {
 			opts = append(opts, WithSourcePort(strconv.Itoa(int(exp.srcPort))))
 		}
+
+		if exp.concurrentConns > 0 {
+			opts = append(opts, WithConcurrentConns(exp.concurrentConns))
+		}
+
+		if exp.sequencedPackets > 0 {
+			opts = append(opts, WithSequencedPackets(exp.sequencedPackets))
+		}
+
+		if exp.dfSendLen > 0 {
+			opts = append(opts, WithDFSendLen(exp.dfSendLen))
+		}
+
+		if exp.portUnreachable {
+			opts = append(opts, WithPortUnreachable())
+		}
+
+		if exp.echoPayload != "" {
+			opts = append(opts, WithPayload(exp.echoPayload))
+		}
+
+		if exp.ecn != "" {
+			opts = append(opts, WithECN(exp.ecn))
+		}
+
+		if exp.datagramLen > 0 {
+			opts = append(opts, WithDatagramLen(exp.datagramLen))
+		}
+
+		if c.DontFragment {
+			opts = append(opts, WithDontFragment())
+		}
 		preCalcOpts[i] = opts
 	}
 
@@ -223,13 +531,38 @@ func (c *Checker) ActualConnectivity(isARetry bool) ([]*Result, []string) {
 		wg.Wait()
 	}
 
+	if c.Cache != nil {
+		if isARetry {
+			// Something was wrong last time; probe everything again.
+			c.Cache.Invalidate()
+		}
+		c.Cache.checkVersion()
+	}
+
 	// Actually run the checks and format the results.
 	for i, exp := range c.expectations {
 		wg.Add(1)
 		go func(i int, exp Expectation) {
 			defer ginkgo.GinkgoRecover()
 			defer wg.Done()
-			res := exp.From.CanConnectTo(exp.To.IP, exp.To.Port, p, preCalcOpts[i]...)
+			canConnect := func() *Result {
+				return exp.From.CanConnectTo(exp.To.IP, exp.To.Port, p, preCalcOpts[i]...)
+			}
+			var res *Result
+			if exp.blockingPolicy != "" {
+				// The drop count has to go up because of this probe, so don't cache.
+				res = probeAttributingDrops(exp.policyDrops, exp.blockingPolicy, canConnect)
+			} else if exp.dropCause != "" {
+				// Likewise, the drop counts have to go up because of this probe.
+				res = probeAttributingSourceDrops(exp.sourceDrops, exp.dropSource, canConnect)
+			} else if exp.firstNThenBlocked > 0 {
+				// Each probe changes the state that the next one sees so don't cache.
+				res = probeSequentially(exp.firstNThenBlocked+firstNThenBlockedExtraProbes, canConnect)
+			} else if c.Cache != nil && exp.ExpectedPacketLoss.Duration == 0 {
+				res = c.Cache.probe(probeKey(exp.From, exp.To.IP, exp.To.Port, p, preCalcOpts[i]...), canConnect)
+			} else {
+				res = canConnect()
+			}
 			pretty[i] += fmt.Sprintf("%s -> %s = %v", exp.From.SourceName(), exp.To.TargetName, res.HasConnectivity())
 
 			if res != nil {
@@ -240,6 +573,51 @@ func (c *Checker) ActualConnectivity(isARetry bool) ([]*Result, []string) {
 				if res.ClientMTU.Start != 0 {
 					pretty[i] += fmt.Sprintf(" (client MTU %d -> %d)", res.ClientMTU.Start, res.ClientMTU.End)
 				}
+				if res.SYNDataAcked {
+					pretty[i] += " (SYN data acked)"
+				}
+				if exp.maxMSS > 0 {
+					pretty[i] += fmt.Sprintf(" (MSS %d)", res.LastResponse.MSS)
+				}
+				if exp.windowScale {
+					pretty[i] += fmt.Sprintf(" (window scale %d)", res.LastResponse.WindowScale)
+				}
+				if exp.concurrentConns > 0 {
+					pretty[i] += fmt.Sprintf(" (conns: %d/%d)", res.Stats.ResponsesReceived, res.Stats.RequestsSent)
+				}
+				if exp.sequencedPackets > 0 {
+					pretty[i] += fmt.Sprintf(" (received: %d/%d, out of order: %d)",
+						res.Stats.ResponsesReceived, res.Stats.RequestsSent, res.OutOfOrder)
+				}
+				if exp.dfSendLen > 0 {
+					pretty[i] += fmt.Sprintf(" (frag needed MTU %d)", res.FragNeededMTU)
+				}
+				if exp.portUnreachable && res.PortUnreachable {
+					pretty[i] += " (port unreachable)"
+				}
+				if exp.connectFailure != "" {
+					pretty[i] += fmt.Sprintf(" (connect failure: %s)", res.ConnectFailure)
+				}
+				if exp.echoPayload != "" && exp.Expected {
+					pretty[i] += fmt.Sprintf(" (echoed %q)", res.LastResponse.Request.Payload)
+				}
+				if exp.ecn != "" && exp.Expected {
+					pretty[i] += fmt.Sprintf(" (received ECN %s)", res.LastResponse.ECN)
+				}
+				if exp.datagramLen > 0 && exp.Expected {
+					pretty[i] += fmt.Sprintf(" (received %d/%d bytes)", res.LastResponse.ReceivedLen, exp.datagramLen)
+				}
+				if exp.blockingPolicy != "" {
+					pretty[i] += fmt.Sprintf(" (dropped by %s: %d)", exp.blockingPolicy, res.PolicyDrops)
+				}
+				if exp.dropCause != "" {
+					pretty[i] += fmt.Sprintf(" (expected %s drop; XDP drops: %d, RPF drops: %d)",
+						exp.dropCause, res.XDPDrops, res.RPFDrops)
+				}
+				if exp.firstNThenBlocked > 0 {
+					pretty[i] += fmt.Sprintf(" (allowed: %d/%d, first blocked: %d)",
+						res.Stats.ResponsesReceived, res.Stats.RequestsSent, res.FirstBlocked)
+				}
 				if exp.ExpectedPacketLoss.Duration > 0 {
 					sent := res.Stats.RequestsSent
 					lost := res.Stats.Lost()
@@ -269,6 +647,42 @@ func (c *Checker) ExpectedConnectivityPretty() []string {
 			if exp.clientMTUStart != 0 || exp.clientMTUEnd != 0 {
 				result[i] += fmt.Sprintf(" (client MTU %d -> %d)", exp.clientMTUStart, exp.clientMTUEnd)
 			}
+			if exp.synDataAcked {
+				result[i] += " (SYN data acked)"
+			}
+			if exp.maxMSS > 0 {
+				result[i] += fmt.Sprintf(" (MSS <= %d)", exp.maxMSS)
+			}
+			if exp.windowScale {
+				result[i] += " (window scale > 0)"
+			}
+			if exp.concurrentConns > 0 {
+				result[i] += fmt.Sprintf(" (conns: %d/%d)", exp.concurrentConns, exp.concurrentConns)
+			}
+			if exp.sequencedPackets > 0 {
+				result[i] += fmt.Sprintf(" (received: %d/%d, out of order: 0)", exp.sequencedPackets, exp.sequencedPackets)
+			}
+			if exp.dfSendLen > 0 {
+				result[i] += fmt.Sprintf(" (frag needed MTU %d)", exp.fragNeededMTU)
+			}
+			if exp.portUnreachable {
+				result[i] += " (port unreachable)"
+			}
+			if exp.echoPayload != "" {
+				result[i] += fmt.Sprintf(" (echoed %q)", exp.echoPayload)
+			}
+			if exp.ecn != "" {
+				result[i] += fmt.Sprintf(" (received ECN %s)", exp.ecn)
+			}
+			if n := exp.firstNThenBlocked; n > 0 {
+				result[i] += fmt.Sprintf(" (allowed: %d/%d, first blocked: %d)", n, n+firstNThenBlockedExtraProbes, n+1)
+			}
+		} else if exp.concurrentConns > 0 {
+			result[i] += fmt.Sprintf(" (conns: 0/%d)", exp.concurrentConns)
+		} else if exp.dfSendLen > 0 {
+			result[i] += " (frag needed MTU 0)"
+		} else if exp.connectFailure != "" {
+			result[i] += fmt.Sprintf(" (connect failure: %s)", exp.connectFailure)
 		}
 		if exp.ExpectedPacketLoss.Duration > 0 {
 			if exp.ExpectedPacketLoss.MaxNumber >= 0 {
@@ -422,6 +836,12 @@ type Request struct {
 	Payload      string
 	SendSize     int
 	ResponseSize int
+	// Sequence is the position, starting from 1, of the request in a sequenced probe,
+	// in which all the requests share the same ID.  It is 0 for other requests.
+	Sequence int
+	// Padding makes a UDP request up to the size asked for with ExpectWithDatagramLen.  The
+	// server doesn't echo it back.
+	Padding string `json:",omitempty"`
 }
 
 func (req Request) Equal(oth Request) bool {
@@ -433,11 +853,37 @@ type Response struct {
 
 	SourceAddr string
 	ServerAddr string
+	// MSS is the server's MSS for a TCP connection, or 0 if not known.
+	MSS int
+	// WindowScale is the window scale that the client advertised in its SYN, as the
+	// server negotiated it for a TCP connection, or 0 if the connection doesn't use
+	// window scaling or it's not known.
+	WindowScale int
+	// ECN is the ECN codepoint of a UDP request as the server received it, or empty if
+	// not known.
+	ECN ECN
+	// ReceivedIndex is the order, starting from 1, in which the server received a
+	// sequenced request among the requests with the same ID, or 0 for other requests.
+	ReceivedIndex int
+	// ReceivedLen is the size of a UDP request as the server received it, after any
+	// reassembly, or 0 if not known.
+	ReceivedLen int
 
 	Request  Request
 	ErrorStr string
 }
 
+// ListenQueueStats are the high-water marks, since the test workload started, of the queues of
+// its TCP listeners, summed over the listeners.
+type ListenQueueStats struct {
+	// AcceptQueueMax is the most connections that completed the handshake but that the
+	// server hadn't accepted yet.
+	AcceptQueueMax int
+	// SynRecvMax is the most half-open connections: SYNs that the server answered but
+	// that the client hadn't acknowledged yet.
+	SynRecvMax int
+}
+
 func (r *Response) SourceIP() string {
 	return strings.Split(r.SourceAddr, ":")[0]
 }
@@ -563,6 +1009,105 @@ func ExpectWithLoss(duration time.Duration, maxPacketLossPercent float64, maxPac
 	}
 }
 
+// ExpectWithSYNDataAcked asserts that the server accepted the data carried in
+// the client's SYN.  Only meaningful with the "tcp-fastopen" protocol.
+func ExpectWithSYNDataAcked() ExpectationOption {
+	return func(e *Expectation) {
+		e.synDataAcked = true
+	}
+}
+
+// ExpectWithConcurrentConns makes the check open n connections at the same time,
+// each sending one request.  With Some, all n must succeed; with None, none may.
+func ExpectWithConcurrentConns(n int) ExpectationOption {
+	return func(e *Expectation) {
+		e.concurrentConns = n
+	}
+}
+
+// ExpectWithSequencedPackets makes the check send n numbered UDP datagrams back to
+// back and asserts that the server receives all of them, in order.
+func ExpectWithSequencedPackets(n int) ExpectationOption {
+	return func(e *Expectation) {
+		e.sequencedPackets = n
+	}
+}
+
+// ExpectWithMaxMSS asserts that the MSS seen by the server is at most maxMSS.
+func ExpectWithMaxMSS(maxMSS int) ExpectationOption {
+	return func(e *Expectation) {
+		e.maxMSS = maxMSS
+	}
+}
+
+// ExpectWithWindowScale asserts that the server negotiated a non-zero window scale.
+func ExpectWithWindowScale() ExpectationOption {
+	return func(e *Expectation) {
+		e.windowScale = true
+	}
+}
+
+// ExpectWithFirstNThenBlocked makes the check open n+firstNThenBlockedExtraProbes
+// connections, one after the other, and asserts that exactly the first n are allowed.
+// Only meaningful with Some.
+func ExpectWithFirstNThenBlocked(n int) ExpectationOption {
+	return func(e *Expectation) {
+		e.firstNThenBlocked = n
+	}
+}
+
+// ExpectWithFragNeeded makes the check send one UDP datagram of sendLen bytes with
+// the don't fragment bit set, instead of a request that the server answers.  With
+// Some, it asserts that the source gets an ICMP fragmentation needed reporting mtu;
+// with None, that nothing comes back.
+func ExpectWithFragNeeded(sendLen, mtu int) ExpectationOption {
+	return func(e *Expectation) {
+		e.dfSendLen = sendLen
+		e.fragNeededMTU = mtu
+	}
+}
+
+// ExpectWithPortUnreachable makes the check send one UDP datagram, instead of a request
+// that the server answers, and wait for an ICMP port unreachable.  With Some, it asserts
+// that the ICMP arrives; with None, that nothing comes back.
+func ExpectWithPortUnreachable() ExpectationOption {
+	return func(e *Expectation) {
+		e.portUnreachable = true
+	}
+}
+
+// ExpectWithEcho makes the check send the given payload in its request and asserts that
+// the response echoes it back.
+func ExpectWithEcho(payload string) ExpectationOption {
+	return func(e *Expectation) {
+		e.echoPayload = payload
+	}
+}
+
+// ExpectWithECN makes the check send its UDP request with the given ECN codepoint and
+// asserts that the server receives it with the same codepoint.
+func ExpectWithECN(ecn ECN) ExpectationOption {
+	return func(e *Expectation) {
+		e.ecn = ecn
+	}
+}
+
+// ExpectWithDatagramLen makes the check pad its UDP request to l bytes, which may be more
+// than the MTU so that the request is fragmented, and asserts that the server receives all
+// l bytes in one datagram.
+func ExpectWithDatagramLen(l int) ExpectationOption {
+	return func(e *Expectation) {
+		e.datagramLen = l
+	}
+}
+
+// ExpectNoneWithConnectFailure asserts that the TCP connection fails in the given way.
+func ExpectNoneWithConnectFailure(f ConnectFailure) ExpectationOption {
+	return func(e *Expectation) {
+		e.connectFailure = f
+	}
+}
+
 func ExpectWithPorts(ports ...uint16) ExpectationOption {
 	return func(e *Expectation) {
 		e.explicitPorts = ports
@@ -586,6 +1131,42 @@ type Expectation struct {
 
 	srcPort uint16
 
+	synDataAcked bool
+
+	concurrentConns int
+
+	maxMSS int
+
+	windowScale bool
+
+	sequencedPackets int
+
+	dfSendLen     int
+	fragNeededMTU int
+
+	portUnreachable bool
+
+	firstNThenBlocked int
+
+	connectFailure ConnectFailure
+
+	echoPayload string
+
+	ecn ECN
+
+	datagramLen int
+
+	// blockingPolicy is the policy that must drop the connection, according to
+	// policyDrops; see ExpectBlockedByPolicy.
+	blockingPolicy string
+	policyDrops    PolicyDropReporter
+
+	// dropCause is what must drop the connection's packets from dropSource, according to
+	// sourceDrops; see ExpectBlockedDistinctFromRPF.
+	dropCause   DropCause
+	dropSource  string
+	sourceDrops SourceDropReporter
+
 	ErrorStr string
 }
 
@@ -621,6 +1202,52 @@ func (e Expectation) Matches(response *Result, checkSNAT bool) bool {
 			return false
 		}
 
+		if e.synDataAcked && !response.SYNDataAcked {
+			return false
+		}
+
+		if e.concurrentConns > 0 && response.Stats.ResponsesReceived != e.concurrentConns {
+			return false
+		}
+
+		if e.maxMSS > 0 && (response.LastResponse.MSS == 0 || response.LastResponse.MSS > e.maxMSS) {
+			return false
+		}
+
+		if e.windowScale && response.LastResponse.WindowScale == 0 {
+			return false
+		}
+
+		if e.sequencedPackets > 0 &&
+			(response.Stats.ResponsesReceived != e.sequencedPackets || response.OutOfOrder != 0) {
+			return false
+		}
+
+		if e.dfSendLen > 0 && response.FragNeededMTU != e.fragNeededMTU {
+			return false
+		}
+
+		if e.portUnreachable && !response.PortUnreachable {
+			return false
+		}
+
+		if e.echoPayload != "" && response.LastResponse.Request.Payload != e.echoPayload {
+			return false
+		}
+
+		if e.ecn != "" && response.LastResponse.ECN != e.ecn {
+			return false
+		}
+
+		if e.datagramLen > 0 && response.LastResponse.ReceivedLen != e.datagramLen {
+			return false
+		}
+
+		if e.firstNThenBlocked > 0 &&
+			(response.Stats.ResponsesReceived != e.firstNThenBlocked || response.FirstBlocked != e.firstNThenBlocked+1) {
+			return false
+		}
+
 		if e.ExpectedPacketLoss.Duration > 0 {
 			// This is a packet loss test.
 			lossCount := response.Stats.Lost()
@@ -636,6 +1263,17 @@ func (e Expectation) Matches(response *Result, checkSNAT bool) bool {
 			return false
 		}
 	} else {
+		if e.blockingPolicy != "" && (response == nil || response.PolicyDrops <= 0) {
+			// Blocked, perhaps, but not by the expected policy.
+			return false
+		}
+		if e.dropCause != "" && (response == nil || !response.droppedBy(e.dropCause)) {
+			// Blocked, perhaps, but not (only) by the expected cause.
+			return false
+		}
+		if e.connectFailure != "" {
+			return response != nil && response.ConnectFailure == e.connectFailure
+		}
 		if response != nil {
 			if e.ErrorStr != "" {
 				// Return a match if the error string expected is in the response
@@ -673,6 +1311,89 @@ type Result struct {
 	LastResponse Response
 	Stats        Stats
 	ClientMTU    MTUPair
+	// SYNDataAcked is only set by "tcp-fastopen" checks; it records whether the
+	// server accepted the data that the client sent in its SYN.
+	SYNDataAcked bool
+	// OutOfOrder is only set by sequenced checks; it counts the requests that the
+	// server received in a different position to the one they were sent in.
+	OutOfOrder int
+	// FragNeededMTU is only set by don't fragment checks; it is the next-hop MTU from
+	// the ICMP fragmentation needed that the client received, or 0 if there wasn't one.
+	FragNeededMTU int
+	// PortUnreachable is only set by port unreachable checks; it records whether the
+	// client received an ICMP port unreachable.
+	PortUnreachable bool
+	// FirstBlocked is only set by ExpectFirstNThenBlocked checks; it is the position,
+	// starting from 1, of the first connection that was blocked, or 0 if none were.
+	FirstBlocked int
+	// ConnectFailure is set if a TCP connection couldn't be established and says why.
+	ConnectFailure ConnectFailure
+	// PortScan is only set by port scans; it records whether each scanned port was
+	// reachable.
+	PortScan map[int]bool
+	// PolicyDrops is only set by ExpectBlockedByPolicy checks; it is the number of packets
+	// that the expected policy dropped during the check, or -1 if the count couldn't be read.
+	PolicyDrops int
+	// XDPDrops and RPFDrops are only set by ExpectBlockedDistinctFromRPF checks; they are the
+	// numbers of packets from the spoofed source that XDP dropped, and of packets that the
+	// reverse path filter dropped, during the check, or -1 if the counts couldn't be read.
+	XDPDrops int
+	RPFDrops int
+}
+
+// ConnectFailure classifies why a TCP connection couldn't be established.
+type ConnectFailure string
+
+const (
+	// ConnectFailureTimeout means that nothing answered the SYNs.
+	ConnectFailureTimeout ConnectFailure = "timeout"
+	// ConnectFailureRefused means that the target answered the SYN with a RST.
+	ConnectFailureRefused ConnectFailure = "refused"
+	// ConnectFailureUnreachable means that the source has no route to the target or got an
+	// ICMP unreachable for the SYN.
+	ConnectFailureUnreachable ConnectFailure = "unreachable"
+)
+
+// firstNThenBlockedExtraProbes is the number of connections that ExpectFirstNThenBlocked
+// expects to be blocked after the first n.
+const firstNThenBlockedExtraProbes = 3
+
+// probeAttributingDrops makes a probe and records in its result how many packets the given
+// policy dropped while it ran.
+func probeAttributingDrops(reporter PolicyDropReporter, policy string, probe func() *Result) *Result {
+	before, beforeErr := reporter.PolicyDrops(policy)
+	res := probe()
+	after, afterErr := reporter.PolicyDrops(policy)
+	if res == nil {
+		return nil
+	}
+	if beforeErr != nil || afterErr != nil {
+		log.WithFields(log.Fields{
+			"policy":      policy,
+			"errorBefore": beforeErr,
+			"errorAfter":  afterErr,
+		}).Warn("Failed to read policy drop count.")
+		res.PolicyDrops = -1
+	} else {
+		res.PolicyDrops = after - before
+	}
+	return res
+}
+
+// probeSequentially makes count probes, one after the other, and combines their results into
+// one that records how many of them were allowed and which was the first to be blocked.
+func probeSequentially(count int, canConnect func() *Result) *Result {
+	res := &Result{Stats: Stats{RequestsSent: count}}
+	for i := 1; i <= count; i++ {
+		r := canConnect()
+		if r.HasConnectivity() {
+			res.Stats.ResponsesReceived++
+			res.LastResponse = r.LastResponse
+		} else if res.FirstBlocked == 0 {
+			res.FirstBlocked = i
+		}
+	}
+	return res
 }
 
 func (r Result) PrintToStdout() {
@@ -726,6 +1447,27 @@ type CheckCmd struct {
 
 	sendLen int
 	recvLen int
+
+	conns int
+
+	sequenced int
+
+	dfSendLen int
+
+	portUnreachable bool
+
+	payload string
+
+	ecn ECN
+
+	datagramLen  int
+	dontFragment bool
+
+	scanPorts []int
+
+	connectTimeout time.Duration
+
+	sendRate int
 }
 
 // BinaryName is the name of the binary that the connectivity Check() executes
@@ -755,6 +1497,54 @@ func (cmd *CheckCmd) run(cName string, logMsg string) *Result {
 		args = append(args, fmt.Sprintf("--source-port=%s", cmd.portSource))
 	}
 
+	if cmd.conns > 1 {
+		args = append(args, fmt.Sprintf("--conns=%d", cmd.conns))
+	}
+
+	if cmd.sequenced > 0 {
+		args = append(args, fmt.Sprintf("--sequenced=%d", cmd.sequenced))
+	}
+
+	if cmd.dfSendLen > 0 {
+		args = append(args, fmt.Sprintf("--df-sendlen=%d", cmd.dfSendLen))
+	}
+
+	if cmd.portUnreachable {
+		args = append(args, "--port-unreachable")
+	}
+
+	if cmd.payload != "" {
+		args = append(args, "--payload="+cmd.payload)
+	}
+
+	if cmd.ecn != "" {
+		args = append(args, fmt.Sprintf("--ecn=%d", cmd.ecn.Codepoint()))
+	}
+
+	if cmd.datagramLen > 0 {
+		args = append(args, fmt.Sprintf("--datagram-len=%d", cmd.datagramLen))
+	}
+
+	if cmd.dontFragment {
+		args = append(args, "--dont-fragment")
+	}
+
+	if cmd.connectTimeout > 0 {
+		args = append(args, fmt.Sprintf("--connect-timeout=%f", cmd.connectTimeout.Seconds()))
+	}
+
+	if cmd.sendRate > 0 {
+		args = append(args, fmt.Sprintf("--send-rate=%d", cmd.sendRate))
+	}
+
+	if len(cmd.scanPorts) > 0 {
+		ports := make([]string, len(cmd.scanPorts))
+		for i, p := range cmd.scanPorts {
+			ports[i] = strconv.Itoa(p)
+		}
+		args = append(args, "--scan-ports="+strings.Join(ports, ","))
+	}
+
 	// Run 'test-connection' to the target.
 	connectionCmd := utils.Command("docker", args...)
 	connectionCmd.Env = []string{"GODEBUG=netdns=1"}
@@ -842,6 +1632,89 @@ func WithRecvLen(l int) CheckOption {
 	}
 }
 
+// WithConcurrentConns tells the check to open n connections at the same time
+func WithConcurrentConns(n int) CheckOption {
+	return func(c *CheckCmd) {
+		c.conns = n
+	}
+}
+
+// WithSequencedPackets tells the check to send n numbered UDP datagrams back to back
+// and to count the ones that the server received out of order
+func WithSequencedPackets(n int) CheckOption {
+	return func(c *CheckCmd) {
+		c.sequenced = n
+	}
+}
+
+// WithDFSendLen tells the check to send one UDP datagram of l bytes with the don't
+// fragment bit set and to wait for an ICMP fragmentation needed
+func WithDFSendLen(l int) CheckOption {
+	return func(c *CheckCmd) {
+		c.dfSendLen = l
+	}
+}
+
+// WithPortUnreachable tells the check to send one UDP datagram and to wait for an ICMP
+// port unreachable
+func WithPortUnreachable() CheckOption {
+	return func(c *CheckCmd) {
+		c.portUnreachable = true
+	}
+}
+
+// WithPayload tells the check to send the given payload in its request, for the server to
+// echo back
+func WithPayload(payload string) CheckOption {
+	return func(c *CheckCmd) {
+		c.payload = payload
+	}
+}
+
+// WithECN tells the check to send its UDP request with the given ECN codepoint
+func WithECN(ecn ECN) CheckOption {
+	return func(c *CheckCmd) {
+		c.ecn = ecn
+	}
+}
+
+// WithDatagramLen tells the check to pad its UDP request to l bytes
+func WithDatagramLen(l int) CheckOption {
+	return func(c *CheckCmd) {
+		c.datagramLen = l
+	}
+}
+
+// WithDontFragment tells the check to set the don't fragment bit on its UDP requests
+func WithDontFragment() CheckOption {
+	return func(c *CheckCmd) {
+		c.dontFragment = true
+	}
+}
+
+// WithScanPorts makes the check probe each of the given ports, instead of just the target
+// port, and report which were reachable in Result.PortScan.
+func WithScanPorts(ports []int) CheckOption {
+	return func(c *CheckCmd) {
+		c.scanPorts = ports
+	}
+}
+
+// WithConnectTimeout limits how long a TCP probe waits for the handshake; see
+// Checker.ConnectTimeout.
+func WithConnectTimeout(t time.Duration) CheckOption {
+	return func(c *CheckCmd) {
+		c.connectTimeout = t
+	}
+}
+
+// WithSendRate sets the rate, in packets per second, at which a packet loss test sends.
+func WithSendRate(pps int) CheckOption {
+	return func(c *CheckCmd) {
+		c.sendRate = pps
+	}
+}
+
 func WithTimeout(t time.Duration) CheckOption {
 	return func(c *CheckCmd) {
 		c.timeout = t
@@ -1064,3 +1937,270 @@ func (pc *PersistentConnection) PongCount() int {
 	log.WithField("name", pc.Name).Infof("pong count %d", pc.pongCount)
 	return pc.pongCount
 }
+
+// ConnectionSet is a set of connections from one source to one target that are
+// opened concurrently by a single test-connection process and then held open until
+// Stop() is called.  It is used to put a known load on conntrack (or to verify that
+// an untracked policy handles that load without it).
+type ConnectionSet struct {
+	sync.Mutex
+
+	RuntimeName   string
+	Runtime       Runtime
+	Name          string
+	Protocol      string
+	IP            string
+	Port          int
+	NumConns      int
+	NamespacePath string
+	Timeout       time.Duration
+
+	loopFile string
+	runCmd   *exec.Cmd
+
+	numEstablished int
+	summarySeen    chan struct{}
+}
+
+var connSetIdx = 0
+
+var connSetSummaryRegexp = regexp.MustCompile(`^CONNS=(\d+)/(\d+)$`)
+
+func (cs *ConnectionSet) Start() error {
+	namespacePath := cs.NamespacePath
+	if namespacePath == "" {
+		namespacePath = "-"
+	}
+
+	connSetIdx++
+	n := fmt.Sprintf("%s-cs%d", cs.RuntimeName, connSetIdx)
+	loopFile := fmt.Sprintf("/tmp/%s-loop", n)
+
+	err := cs.Runtime.ExecMayFail("sh", "-c", fmt.Sprintf("echo > %s", loopFile))
+	if err != nil {
+		return err
+	}
+
+	args := []string{
+		"exec",
+		cs.RuntimeName,
+		"test-connection",
+		namespacePath,
+		cs.IP,
+		fmt.Sprintf("%d", cs.Port),
+		fmt.Sprintf("--protocol=%s", cs.Protocol),
+		fmt.Sprintf("--conns=%d", cs.NumConns),
+		fmt.Sprintf("--loop-with-file=%s", loopFile),
+	}
+	if cs.Timeout > 0 {
+		args = append(args, fmt.Sprintf("--timeout=%f", cs.Timeout.Seconds()))
+	}
+	runCmd := utils.Command("docker", args...)
+	logName := fmt.Sprintf("connection set %s", n)
+	stdout, err := runCmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start output logging for %s", logName)
+	}
+	stderr, err := runCmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start error logging for %s", logName)
+	}
+
+	cs.summarySeen = make(chan struct{})
+	stdoutReader := bufio.NewReader(stdout)
+	go func() {
+		defer log.WithField("name", logName).Info("stdout reader exited")
+		for {
+			line, err := stdoutReader.ReadString('\n')
+			if err != nil {
+				log.WithError(err).Info("End of connection set stdout")
+				return
+			}
+			line = strings.TrimSpace(line)
+			log.Infof("%s stdout: %s", logName, line)
+			if m := connSetSummaryRegexp.FindStringSubmatch(line); m != nil {
+				numEstablished, _ := strconv.Atoi(m[1])
+				cs.Lock()
+				cs.numEstablished = numEstablished
+				cs.Unlock()
+				close(cs.summarySeen)
+			}
+		}
+	}()
+	stderrReader := bufio.NewReader(stderr)
+	go func() {
+		for {
+			line, err := stderrReader.ReadString('\n')
+			if err != nil {
+				log.WithError(err).Info("End of connection set stderr")
+				return
+			}
+			log.Infof("%s stderr: %s", logName, strings.TrimSpace(line))
+		}
+	}()
+	if err := runCmd.Start(); err != nil {
+		return fmt.Errorf("failed to start a connection set: %v", err)
+	}
+
+	timeout := cs.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	Eventually(cs.summarySeen, timeout+10*time.Second).Should(BeClosed(),
+		"Failed to wait for test-connection to report on its connections")
+
+	cs.loopFile = loopFile
+	cs.runCmd = runCmd
+	cs.Name = n
+
+	return nil
+}
+
+// NumEstablished returns the number of connections in the set that were
+// established and got a response.
+func (cs *ConnectionSet) NumEstablished() int {
+	cs.Lock()
+	defer cs.Unlock()
+	return cs.numEstablished
+}
+
+// Stop closes all the connections in the set.
+func (cs *ConnectionSet) Stop() {
+	err := cs.Runtime.ExecMayFail("sh", "-c", fmt.Sprintf("echo > %s", cs.loopFile))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cs.runCmd.Wait()).NotTo(HaveOccurred())
+}
+
+// Traffic is a steady stream of requests from one source to one target, sent in
+// the background by a test-connection process running in packet loss mode for a
+// fixed duration.  It is used to check that something done while the stream is
+// running, such as a dataplane update, doesn't disrupt an established flow.
+type Traffic struct {
+	RuntimeName   string
+	Name          string
+	Protocol      string
+	IP            string
+	Port          int
+	NamespacePath string
+	Duration      time.Duration
+
+	runCmd *exec.Cmd
+	done   chan struct{}
+	result *Result
+}
+
+var trafficIdx = 0
+
+var trafficResultRegexp = regexp.MustCompile(`^RESULT=(.*)$`)
+
+func (t *Traffic) Start() error {
+	namespacePath := t.NamespacePath
+	if namespacePath == "" {
+		namespacePath = "-"
+	}
+
+	trafficIdx++
+	t.Name = fmt.Sprintf("%s-traffic%d", t.RuntimeName, trafficIdx)
+
+	seconds := int(t.Duration.Seconds())
+	if seconds < 1 {
+		return fmt.Errorf("traffic duration must be at least a second, not %v", t.Duration)
+	}
+	runCmd := utils.Command(
+		"docker",
+		"exec",
+		t.RuntimeName,
+		"test-connection",
+		namespacePath,
+		t.IP,
+		fmt.Sprintf("%d", t.Port),
+		fmt.Sprintf("--protocol=%s", t.Protocol),
+		fmt.Sprintf("--duration=%d", seconds),
+	)
+	logName := fmt.Sprintf("traffic %s", t.Name)
+	stdout, err := runCmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start output logging for %s", logName)
+	}
+	stderr, err := runCmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start error logging for %s", logName)
+	}
+
+	t.done = make(chan struct{})
+	stdoutReader := bufio.NewReader(stdout)
+	go func() {
+		defer close(t.done)
+		for {
+			line, err := stdoutReader.ReadString('\n')
+			if err != nil {
+				log.WithError(err).Info("End of traffic stdout")
+				return
+			}
+			line = strings.TrimSpace(line)
+			log.Infof("%s stdout: %s", logName, line)
+			if m := trafficResultRegexp.FindStringSubmatch(line); m != nil {
+				var res Result
+				if err := json.Unmarshal([]byte(m[1]), &res); err != nil {
+					log.WithError(err).WithField("line", line).Warn("Failed to parse traffic result")
+					continue
+				}
+				t.result = &res
+			}
+		}
+	}()
+	stderrReader := bufio.NewReader(stderr)
+	go func() {
+		for {
+			line, err := stderrReader.ReadString('\n')
+			if err != nil {
+				log.WithError(err).Info("End of traffic stderr")
+				return
+			}
+			log.Infof("%s stderr: %s", logName, strings.TrimSpace(line))
+		}
+	}()
+	if err := runCmd.Start(); err != nil {
+		return fmt.Errorf("failed to start traffic: %v", err)
+	}
+	t.runCmd = runCmd
+
+	return nil
+}
+
+// Wait waits for the stream to finish and returns the number of requests that
+// were sent and the number of responses that came back.
+func (t *Traffic) Wait() (Stats, error) {
+	select {
+	case <-t.done:
+	case <-time.After(t.Duration + 10*time.Second):
+		return Stats{}, fmt.Errorf("timed out waiting for %s to finish", t.Name)
+	}
+	if err := t.runCmd.Wait(); err != nil {
+		return Stats{}, fmt.Errorf("%s failed: %w", t.Name, err)
+	}
+	if t.result == nil {
+		return Stats{}, fmt.Errorf("%s didn't report a result", t.Name)
+	}
+	return t.result.Stats, nil
+}
+
+// ExpectNoLeaks waits for the stream, which should be blocked, to finish and
+// asserts that none of its requests got a response.
+func (t *Traffic) ExpectNoLeaks() {
+	stats, err := t.Wait()
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	ExpectWithOffset(1, stats.RequestsSent).To(BeNumerically(">", 0), "%s didn't send any requests", t.Name)
+	ExpectWithOffset(1, stats.ResponsesReceived).To(BeZero(),
+		"%s leaked %d of %d requests", t.Name, stats.ResponsesReceived, stats.RequestsSent)
+}
+
+// ExpectNoLoss waits for the stream to finish and asserts that every request got
+// a response.
+func (t *Traffic) ExpectNoLoss() {
+	stats, err := t.Wait()
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	ExpectWithOffset(1, stats.RequestsSent).To(BeNumerically(">", 0), "%s didn't send any requests", t.Name)
+	ExpectWithOffset(1, stats.Lost()).To(BeZero(),
+		"%s lost %d of %d requests", t.Name, stats.Lost(), stats.RequestsSent)
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectivity
+
+import "fmt"
+
+// ECN is an explicit congestion notification codepoint, the low two bits of the IPv4 TOS or
+// IPv6 traffic class.  The zero value means that the codepoint isn't known.
+type ECN string
+
+const (
+	ECNNotECT ECN = "Not-ECT"
+	ECNECT1   ECN = "ECT(1)"
+	ECNECT0   ECN = "ECT(0)"
+	ECNCE     ECN = "CE"
+)
+
+var ecnCodepoints = []ECN{ECNNotECT, ECNECT1, ECNECT0, ECNCE}
+
+// ECNFromTOS returns the ECN codepoint of the given IPv4 TOS or IPv6 traffic class.
+func ECNFromTOS(tos int) ECN {
+	return ecnCodepoints[tos&3]
+}
+
+// Codepoint returns the value of the ECN bits.
+func (e ECN) Codepoint() int {
+	for i, c := range ecnCodepoints {
+		if c == e {
+			return i
+		}
+	}
+	panic(fmt.Sprintf("unknown ECN codepoint %q", string(e)))
+}
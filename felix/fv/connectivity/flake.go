@@ -0,0 +1,119 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectivity
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/gomega"
+)
+
+// FlakeCell is the record of one expectation, that is, one cell of the connectivity matrix,
+// over the runs of a FlakeReport.
+type FlakeCell struct {
+	// Path is the path that the expectation probes, for example "host0 -> host1 on port 8055";
+	// the IPs of endpoints that have roles are replaced by the role names.
+	Path     string
+	Expected Expected
+	Runs     int
+	Matched  int
+}
+
+// Unstable returns true if the expectation matched in some runs but not in others.
+func (f FlakeCell) Unstable() bool {
+	return f.Matched > 0 && f.Matched < f.Runs
+}
+
+func (f FlakeCell) String() string {
+	return fmt.Sprintf("%s = %v: matched %d/%d runs", f.Path, f.Expected, f.Matched, f.Runs)
+}
+
+// FlakeReport records whether each of a Checker's expectations matched over repeated runs, so
+// that a test can find the cells of a connectivity matrix that are non-deterministic.
+// CheckConnectivity retries until every expectation matches, so it hides a cell that only
+// sometimes fails; a FlakeReport probes each time without retrying and keeps every result.
+type FlakeReport struct {
+	checker *Checker
+	cells   []FlakeCell
+}
+
+// NewFlakeReport returns a FlakeReport for the expectations that are recorded in the given
+// Checker.  Record all the expectations before the first Run.
+func NewFlakeReport(c *Checker) *FlakeReport {
+	return &FlakeReport{checker: c}
+}
+
+// Run probes all the expectations once, without retrying or using cached results, and
+// records whether each one matched.
+func (r *FlakeReport) Run() {
+	c := r.checker
+	if r.cells == nil {
+		normalise := c.roleIPReplacer()
+		for _, exp := range c.expectations {
+			r.cells = append(r.cells, FlakeCell{
+				Path:     normalise.Replace(fmt.Sprintf("%s -> %s", exp.From.SourceName(), exp.To.TargetName)),
+				Expected: exp.Expected,
+			})
+		}
+	}
+	ExpectWithOffset(1, c.expectations).To(HaveLen(len(r.cells)),
+		"expectations were added or reset after the FlakeReport's first run")
+
+	results, _ := c.ActualConnectivity(true)
+	for i, exp := range c.expectations {
+		r.cells[i].Runs++
+		if exp.Matches(results[i], c.CheckSNAT) {
+			r.cells[i].Matched++
+		}
+	}
+}
+
+// RunN calls Run n times.
+func (r *FlakeReport) RunN(n int) {
+	for i := 0; i < n; i++ {
+		r.Run()
+	}
+}
+
+// Cells returns the records of all the expectations, in the order that they were recorded.
+func (r *FlakeReport) Cells() []FlakeCell {
+	return r.cells
+}
+
+// Unstable returns the records of the expectations that matched in some runs but not in
+// others.
+func (r *FlakeReport) Unstable() []FlakeCell {
+	var unstable []FlakeCell
+	for _, cell := range r.cells {
+		if cell.Unstable() {
+			unstable = append(unstable, cell)
+		}
+	}
+	return unstable
+}
+
+// String formats the report with one line per expectation, marking the unstable ones, so that
+// it can be used as the description of a failed assertion.
+func (r *FlakeReport) String() string {
+	lines := make([]string, len(r.cells))
+	for i, cell := range r.cells {
+		lines[i] = cell.String()
+		if cell.Unstable() {
+			lines[i] += " <---- UNSTABLE"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectivity
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+)
+
+// UpdateGoldenEnvVar is the environment variable that, when set to "true", makes
+// CompareToGolden (re)write golden files from the actual connectivity instead of checking it.
+const UpdateGoldenEnvVar = "FV_UPDATE_GOLDEN"
+
+const goldenHeader = "# Connectivity matrix, one path per line.  Regenerate with " + UpdateGoldenEnvVar + "=true.\n"
+
+// CompareToGolden probes the paths of the recorded expectations and checks the results
+// against the golden file at the given path, which has a line for each path in the order that
+// the expectations were recorded; for example:
+//
+//	host0 -> host1 on port 8055 = false
+//
+// The golden file takes the place of the expected outcome of each expectation, so it doesn't
+// matter whether the paths were recorded with ExpectSome or ExpectNone.  Like
+// CheckConnectivity, it retries until the results match or it times out.
+//
+// So that the file is the same from run to run, the IPs of endpoints that have roles (see
+// SetRole) are replaced by the role names and details that depend on timing, such as packet
+// counts, are left out.
+func (c *Checker) CompareToGolden(path string) {
+	ExpectWithOffset(1, c.expectations).NotTo(BeEmpty(), "no paths to compare to the golden file")
+
+	if os.Getenv(UpdateGoldenEnvVar) == "true" {
+		results, _ := c.ActualConnectivity(false)
+		golden := goldenHeader + strings.Join(c.goldenLines(results), "\n") + "\n"
+		ExpectWithOffset(1, os.WriteFile(path, []byte(golden), 0644)).To(Succeed())
+		log.WithField("path", path).Info("Wrote connectivity golden file.")
+		return
+	}
+
+	raw, err := os.ReadFile(path)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred(),
+		"failed to read golden file; run with %s=true to create it", UpdateGoldenEnvVar)
+	var expected []string
+	for _, line := range strings.Split(string(raw), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		expected = append(expected, line)
+	}
+
+	var actual []string
+	start := time.Now()
+	completedAttempts := 0
+	for {
+		results, _ := c.ActualConnectivity(completedAttempts > 0)
+		actual = c.goldenLines(results)
+		completedAttempts++
+		if equalLines(actual, expected) {
+			log.WithField("attempts", completedAttempts).Info("Connectivity matches golden file.")
+			return
+		}
+		if c.RetriesDisabled || (time.Since(start) > defaultConnectivityTimeout && completedAttempts >= 2) {
+			break
+		}
+		if c.beforeRetry != nil {
+			c.beforeRetry()
+		}
+	}
+
+	message := fmt.Sprintf(
+		"Connectivity didn't match golden file %s:\n\nExpected\n    %s\nto match\n    %s\n\n"+
+			"If the change is intended, run with %s=true to update the file.",
+		path,
+		strings.Join(actual, "\n    "),
+		strings.Join(expected, "\n    "),
+		UpdateGoldenEnvVar,
+	)
+	if c.description != "" {
+		message += "\nDescription:\n" + c.description
+	}
+	log.Warn(message)
+	message += fmt.Sprintf("\n\n Test took %s and %d tries.\n", time.Since(start), completedAttempts)
+
+	if c.OnFail != nil {
+		c.OnFail(message)
+	} else {
+		ginkgo.Fail(message, 1)
+	}
+}
+
+// goldenLines formats the results of the expectations as they're written to a golden file.
+func (c *Checker) goldenLines(results []*Result) []string {
+	normalise := c.roleIPReplacer()
+	lines := make([]string, len(c.expectations))
+	for i, exp := range c.expectations {
+		res := results[i]
+		line := fmt.Sprintf("%s -> %s = %v", exp.From.SourceName(), exp.To.TargetName, res.HasConnectivity())
+		if res != nil {
+			if c.CheckSNAT && res.LastResponse.SourceAddr != "" {
+				line += " (from " + strings.Split(res.LastResponse.SourceAddr, ":")[0] + ")"
+			}
+			if res.PortUnreachable {
+				line += " (port unreachable)"
+			}
+			if res.ConnectFailure != "" {
+				line += fmt.Sprintf(" (connect failure: %s)", res.ConnectFailure)
+			}
+		}
+		lines[i] = normalise.Replace(line)
+	}
+	return lines
+}
+
+// roleIPReplacer returns a replacer that replaces the IPs of the endpoints that have roles
+// with the names of the roles.
+func (c *Checker) roleIPReplacer() *strings.Replacer {
+	var ipToRole [][2]string
+	for role, ep := range c.roles {
+		for _, ip := range ep.SourceIPs() {
+			ipToRole = append(ipToRole, [2]string{ip, "<" + role + ">"})
+		}
+	}
+	// The replacer tries the replacements in order so put longer IPs first, so that, for
+	// example, 10.0.0.10 isn't replaced as 10.0.0.1 followed by a 0.
+	sort.Slice(ipToRole, func(i, j int) bool {
+		if len(ipToRole[i][0]) != len(ipToRole[j][0]) {
+			return len(ipToRole[i][0]) > len(ipToRole[j][0])
+		}
+		return ipToRole[i][0] < ipToRole[j][0]
+	})
+	var oldnew []string
+	for _, r := range ipToRole {
+		oldnew = append(oldnew, r[0], r[1])
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
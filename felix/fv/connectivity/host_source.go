@@ -0,0 +1,58 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectivity
+
+import (
+	"os"
+)
+
+// Host is a container, such as a Felix, that can originate connections from its own network
+// stack.
+type Host interface {
+	ConnectionSource
+	ExecMayFail(cmd ...string) error
+}
+
+// HostSource returns a connection source for probes that originate from the host's own
+// network stack, rather than from a workload on the host.  For example:
+//
+//	cc.ExpectNone(connectivity.HostSource(felixes[0]), w[1])
+//
+// Before retrying a UDP or SCTP probe, it removes the conntrack entries that the previous
+// attempt left behind, as a workload source does.
+func HostSource(h Host) ConnectionSource {
+	return &hostSource{Host: h}
+}
+
+type hostSource struct {
+	Host
+}
+
+func (h *hostSource) SourceName() string {
+	return h.Host.SourceName() + " (host)"
+}
+
+func (h *hostSource) PreRetryCleanup(ip, port, protocol string, opts ...CheckOption) {
+	if protocol != "udp" && protocol != "sctp" {
+		return
+	}
+	for _, srcIP := range h.SourceIPs() {
+		if os.Getenv("FELIX_FV_ENABLE_BPF") == "true" {
+			_ = h.ExecMayFail("calico-bpf", "conntrack", "remove", protocol, srcIP, ip)
+		} else {
+			_ = h.ExecMayFail("conntrack", "-D", "-p", protocol, "-s", srcIP, "-d", ip)
+		}
+	}
+}
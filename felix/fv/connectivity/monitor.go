@@ -0,0 +1,203 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectivity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	log "github.com/sirupsen/logrus"
+)
+
+// Monitor probes one path over and over in the background, recording whether each probe got
+// through, so that a test can find out when a change to the dataplane took effect on the
+// path.  Start it with BackgroundMonitor before making the change and Stop it afterwards:
+//
+//	m := connectivity.BackgroundMonitor(w[0], w[1], 8055)
+//	... apply the policy and wait for it to take effect ...
+//	timeline := m.Stop()
+//	Expect(timeline.Transitions()).To(HaveLen(1))
+type Monitor struct {
+	protocol       string
+	interval       time.Duration
+	connectTimeout time.Duration
+
+	lock     sync.Mutex
+	timeline Timeline
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// MonitorOpt is an option to BackgroundMonitor().
+type MonitorOpt func(*Monitor)
+
+// MonitorWithProtocol sets the protocol of the probes; by default, TCP.
+func MonitorWithProtocol(protocol string) MonitorOpt {
+	return func(m *Monitor) {
+		m.protocol = protocol
+	}
+}
+
+// MonitorWithInterval sets the minimum time between the starts of successive probes.  By
+// default, each probe starts as soon as the previous one has finished.
+func MonitorWithInterval(interval time.Duration) MonitorOpt {
+	return func(m *Monitor) {
+		m.interval = interval
+	}
+}
+
+// MonitorWithConnectTimeout limits how long each TCP probe waits for the handshake; see
+// Checker.ConnectTimeout.  A short timeout makes the probes of a blocked path quicker, and so
+// the timeline finer.
+func MonitorWithConnectTimeout(timeout time.Duration) MonitorOpt {
+	return func(m *Monitor) {
+		m.connectTimeout = timeout
+	}
+}
+
+// BackgroundMonitor starts probing the given port of the target from the source, in the same
+// sense as ExpectSome, until Stop is called.
+func BackgroundMonitor(from ConnectionSource, to ConnectionTarget, port uint16, opts ...MonitorOpt) *Monitor {
+	m := &Monitor{
+		protocol: "tcp",
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(m)
+	}
+	ip := to.ToMatcher(port).IP
+
+	var checkOpts []CheckOption
+	if m.connectTimeout > 0 {
+		checkOpts = append(checkOpts, WithConnectTimeout(m.connectTimeout))
+	}
+
+	logCxt := log.WithFields(log.Fields{
+		"source": from.SourceName(),
+		"target": fmt.Sprintf("%s:%d", ip, port),
+	})
+	logCxt.Info("Starting background connectivity monitor.")
+	go func() {
+		defer ginkgo.GinkgoRecover()
+		defer close(m.done)
+		for {
+			start := time.Now()
+			connected := from.CanConnectTo(ip, strconv.Itoa(int(port)), m.protocol, checkOpts...).HasConnectivity()
+			end := time.Now()
+
+			m.lock.Lock()
+			if n := len(m.timeline); n == 0 || m.timeline[n-1].Connected != connected {
+				logCxt.WithField("connected", connected).Info("Connectivity changed.")
+			}
+			m.timeline = append(m.timeline, Probe{Start: start, End: end, Connected: connected})
+			m.lock.Unlock()
+
+			select {
+			case <-m.stop:
+				return
+			case <-time.After(m.interval - time.Since(start)):
+			}
+		}
+	}()
+	return m
+}
+
+// Timeline returns the probes so far, without stopping the monitor.
+func (m *Monitor) Timeline() Timeline {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append(Timeline(nil), m.timeline...)
+}
+
+// Connected returns whether the latest probe got through; false if there hasn't been a probe
+// yet.  For example, to wait for a policy to take effect:
+//
+//	Eventually(m.Connected, "10s", "100ms").Should(BeFalse())
+func (m *Monitor) Connected() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.timeline) > 0 && m.timeline[len(m.timeline)-1].Connected
+}
+
+// Stop waits for the probe in progress to finish, stops the monitor and returns the probes.
+// It's safe to call more than once, so it can be deferred as well as called explicitly.
+func (m *Monitor) Stop() Timeline {
+	m.stopOnce.Do(func() { close(m.stop) })
+	<-m.done
+	return m.Timeline()
+}
+
+// Probe is the outcome of one probe of a monitored path.
+type Probe struct {
+	Start, End time.Time
+	Connected  bool
+}
+
+// Timeline is the probes of a monitored path, in the order that they were made.
+type Timeline []Probe
+
+// Transition is a change in the connectivity of a monitored path: the path became connected,
+// or stopped being connected, at some time between After and Before.
+type Transition struct {
+	After, Before time.Time
+	Connected     bool
+}
+
+// Transitions returns the changes in the connectivity of the path, in order.  A change is
+// only known to have happened between the start of the last probe before it and the end of
+// the first probe after it.
+func (t Timeline) Transitions() []Transition {
+	var transitions []Transition
+	for i := 1; i < len(t); i++ {
+		if t[i].Connected != t[i-1].Connected {
+			transitions = append(transitions, Transition{
+				After:     t[i-1].Start,
+				Before:    t[i].End,
+				Connected: t[i].Connected,
+			})
+		}
+	}
+	return transitions
+}
+
+// String summarises the timeline as runs of probes with the same outcome, for use in failure
+// messages.
+func (t Timeline) String() string {
+	if len(t) == 0 {
+		return "no probes"
+	}
+	var runs []string
+	runStart := 0
+	for i := 1; i <= len(t); i++ {
+		if i < len(t) && t[i].Connected == t[runStart].Connected {
+			continue
+		}
+		outcome := "blocked"
+		if t[runStart].Connected {
+			outcome = "connected"
+		}
+		runs = append(runs, fmt.Sprintf("%s x%d (%s-%s)", outcome, i-runStart,
+			t[runStart].Start.Format("15:04:05.000"), t[i-1].End.Format("15:04:05.000")))
+		runStart = i
+	}
+	return strings.Join(runs, ", ")
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectivity
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// ScanPorts probes each of the given ports of the target from the source, straight away, and
+// returns whether each one was reachable, in the same sense as ExpectSome.  All the ports are
+// probed by one run of the client if the source supports it, otherwise by one run per port.
+// For example, to check that exactly the failsafe ports are open:
+//
+//	Expect(cc.ScanPorts(w[0], w[1], []int{22, 68, 8055})).To(
+//		connectivity.MatchPortScan(map[int]bool{22: true, 68: true, 8055: false}))
+func (c *Checker) ScanPorts(from ConnectionSource, to ConnectionTarget, ports []int) map[int]bool {
+	if len(ports) == 0 {
+		return map[int]bool{}
+	}
+	p := "tcp"
+	if c.Protocol != "" {
+		p = c.Protocol
+	}
+	ip := to.ToMatcher(uint16(ports[0])).IP
+
+	var opts []CheckOption
+	if c.ConnectTimeout > 0 {
+		opts = append(opts, WithConnectTimeout(c.ConnectTimeout))
+	}
+
+	res := from.CanConnectTo(ip, strconv.Itoa(ports[0]), p, append(opts, WithScanPorts(ports))...)
+	if res != nil && res.PortScan != nil {
+		scan := make(map[int]bool, len(ports))
+		for _, port := range ports {
+			scan[port] = res.PortScan[port]
+		}
+		return scan
+	}
+
+	// The source ran a normal check instead; fall back to probing the ports one by one.
+	log.WithField("source", from.SourceName()).Info("Source doesn't support port scans, probing each port.")
+	scan := make(map[int]bool, len(ports))
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for _, port := range ports {
+		wg.Add(1)
+		go func(port int) {
+			defer ginkgo.GinkgoRecover()
+			defer wg.Done()
+			reachable := from.CanConnectTo(ip, strconv.Itoa(port), p, opts...).HasConnectivity()
+			lock.Lock()
+			defer lock.Unlock()
+			scan[port] = reachable
+		}(port)
+	}
+	wg.Wait()
+	return scan
+}
+
+// MatchPortScan matches the result of ScanPorts against the expected reachability of each
+// port.  Unlike Equal, its failure message lists the ports that were unexpectedly open or
+// closed.
+func MatchPortScan(expected map[int]bool) types.GomegaMatcher {
+	return &portScanMatcher{expected: expected}
+}
+
+type portScanMatcher struct {
+	expected map[int]bool
+}
+
+func (m *portScanMatcher) Match(actual interface{}) (success bool, err error) {
+	scan, ok := actual.(map[int]bool)
+	if !ok {
+		return false, fmt.Errorf("MatchPortScan expects a map[int]bool, not %T", actual)
+	}
+	return len(m.mismatches(scan)) == 0, nil
+}
+
+// mismatches returns a description of each port whose reachability differs from the
+// expected, in port order.
+func (m *portScanMatcher) mismatches(scan map[int]bool) []string {
+	ports := map[int]bool{}
+	for port := range m.expected {
+		ports[port] = true
+	}
+	for port := range scan {
+		ports[port] = true
+	}
+	var sorted []int
+	for port := range ports {
+		sorted = append(sorted, port)
+	}
+	sort.Ints(sorted)
+
+	var mismatches []string
+	for _, port := range sorted {
+		expected, wasExpected := m.expected[port]
+		actual, wasScanned := scan[port]
+		switch {
+		case !wasExpected:
+			mismatches = append(mismatches, fmt.Sprintf("%d was scanned but not expected", port))
+		case !wasScanned:
+			mismatches = append(mismatches, fmt.Sprintf("%d was expected but not scanned", port))
+		case expected && !actual:
+			mismatches = append(mismatches, fmt.Sprintf("%d is unexpectedly closed", port))
+		case !expected && actual:
+			mismatches = append(mismatches, fmt.Sprintf("%d is unexpectedly open", port))
+		}
+	}
+	return mismatches
+}
+
+func (m *portScanMatcher) FailureMessage(actual interface{}) (message string) {
+	return fmt.Sprintf("Expected port scan\n\t%v\nto match\n\t%v\nbut port %s",
+		actual, m.expected, strings.Join(m.mismatches(actual.(map[int]bool)), ", port "))
+}
+
+func (m *portScanMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return fmt.Sprintf("Expected port scan\n\t%v\nnot to match\n\t%v", actual, m.expected)
+}
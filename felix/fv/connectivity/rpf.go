@@ -0,0 +1,134 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connectivity
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SourceDropReporter is implemented by connection targets whose host can report who dropped the
+// packets on their way to the target; see ExpectBlockedDistinctFromRPF.
+type SourceDropReporter interface {
+	// XDPDrops returns the number of packets from the given source IP that the blocklist XDP
+	// program on the target's host has dropped so far.
+	XDPDrops(src string) (int, error)
+	// RPFDrops returns the number of packets that the target host's kernel has dropped so
+	// far because they failed the reverse path filter.
+	RPFDrops() (int, error)
+}
+
+// DropCause says what should drop the packets of a check; see ExpectBlockedDistinctFromRPF.
+type DropCause string
+
+const (
+	// DropCauseXDP means that the blocklist XDP program drops the packets, before the kernel
+	// sees them.
+	DropCauseXDP DropCause = "XDP"
+	// DropCauseRPF means that the kernel's reverse path filter drops the packets.
+	DropCauseRPF DropCause = "RPF"
+)
+
+// SpoofSource returns a connection source that probes from the given source, but with the given
+// source IP; the probe adds the IP to the source's interface if it isn't there already.  Whether
+// the target's host accepts the packets, and can reply, depends on its routes back to that IP.
+func SpoofSource(from ConnectionSource, ip string) ConnectionSource {
+	return &spoofedSource{ConnectionSource: from, ip: ip}
+}
+
+type spoofedSource struct {
+	ConnectionSource
+	ip string
+}
+
+func (s *spoofedSource) SourceName() string {
+	return fmt.Sprintf("%s[spoofing %s]", s.ConnectionSource.SourceName(), s.ip)
+}
+
+func (s *spoofedSource) SourceIPs() []string {
+	return []string{s.ip}
+}
+
+func (s *spoofedSource) PreRetryCleanup(ip, port, protocol string, opts ...CheckOption) {
+	s.ConnectionSource.PreRetryCleanup(ip, port, protocol, append(opts, WithSourceIP(s.ip))...)
+}
+
+func (s *spoofedSource) CanConnectTo(ip, port, protocol string, opts ...CheckOption) *Result {
+	return s.ConnectionSource.CanConnectTo(ip, port, protocol, append(opts, WithSourceIP(s.ip))...)
+}
+
+// ExpectBlockedDistinctFromRPF asserts that probes from the source to the target's port, spoofing
+// two different source IPs, are both dropped, but by different things.  Probes from rpfValidSrc,
+// which the target's host routes back through the interface that they arrive on, must be dropped
+// by the blocklist XDP program, so rpfValidSrc must be blocklisted.  Probes from rpfInvalidSrc,
+// which the host routes back some other way, must be dropped by the kernel's reverse path filter
+// instead, so rpfInvalidSrc mustn't be blocklisted and the filter must be strict.  That stops a
+// test of the blocklist passing because of a drop that the kernel would have made anyway.  The
+// target must be a SourceDropReporter.  The check reads the counts before and after each probe,
+// so the attribution is only reliable if nothing else drops packets from the same sources, or
+// fails the reverse path filter, during the check.
+func (c *Checker) ExpectBlockedDistinctFromRPF(from ConnectionSource, to ConnectionTarget, port uint16, rpfValidSrc, rpfInvalidSrc string) {
+	reporter, ok := to.(SourceDropReporter)
+	if !ok {
+		panic(fmt.Sprintf("%T can't report XDP and RPF drops", to))
+	}
+	for src, cause := range map[string]DropCause{rpfValidSrc: DropCauseXDP, rpfInvalidSrc: DropCauseRPF} {
+		src, cause := src, cause
+		c.expect(None, SpoofSource(from, src), to, ExpectWithPorts(port), func(e *Expectation) {
+			e.dropCause = cause
+			e.dropSource = src
+			e.sourceDrops = reporter
+		})
+	}
+}
+
+// probeAttributingSourceDrops makes a probe and records in its result how many packets from the
+// given source XDP dropped, and how many packets the reverse path filter dropped, meanwhile.
+func probeAttributingSourceDrops(reporter SourceDropReporter, src string, probe func() *Result) *Result {
+	xdpBefore, xdpBeforeErr := reporter.XDPDrops(src)
+	rpfBefore, rpfBeforeErr := reporter.RPFDrops()
+	res := probe()
+	xdpAfter, xdpAfterErr := reporter.XDPDrops(src)
+	rpfAfter, rpfAfterErr := reporter.RPFDrops()
+	if res == nil {
+		return nil
+	}
+	if xdpBeforeErr != nil || xdpAfterErr != nil || rpfBeforeErr != nil || rpfAfterErr != nil {
+		log.WithFields(log.Fields{
+			"source":         src,
+			"xdpErrorBefore": xdpBeforeErr,
+			"xdpErrorAfter":  xdpAfterErr,
+			"rpfErrorBefore": rpfBeforeErr,
+			"rpfErrorAfter":  rpfAfterErr,
+		}).Warn("Failed to read drop counts.")
+		res.XDPDrops, res.RPFDrops = -1, -1
+	} else {
+		res.XDPDrops, res.RPFDrops = xdpAfter-xdpBefore, rpfAfter-rpfBefore
+	}
+	return res
+}
+
+// droppedBy returns whether the result's drop counts show that the given cause, and only that
+// cause, dropped the probe's packets.
+func (r *Result) droppedBy(cause DropCause) bool {
+	switch cause {
+	case DropCauseXDP:
+		return r.XDPDrops > 0 && r.RPFDrops == 0
+	case DropCauseRPF:
+		return r.RPFDrops > 0 && r.XDPDrops == 0
+	}
+	return false
+}
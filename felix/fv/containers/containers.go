@@ -692,6 +692,12 @@ func (c *Container) ExecCombinedOutput(args ...string) (string, error) {
 	return string(out), nil
 }
 
+// HasRouteTo returns whether the container has a usable route to the given IP, so that it can
+// be a connectivity.RouteChecker.
+func (c *Container) HasRouteTo(ip string) (bool, error) {
+	return connectivity.ParseRouteGet(c.ExecCombinedOutput("ip", "route", "get", ip))
+}
+
 func (c *Container) SourceName() string {
 	return c.Name
 }
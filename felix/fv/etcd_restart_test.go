@@ -106,8 +106,7 @@ var _ = Context("etcd connection interruption", func() {
 
 	It("shouldn't use excessive CPU when etcd is stopped", func() {
 		By("having initial workload to workload connectivity", func() {
-			cc.ExpectSome(w[0], w[1])
-			cc.ExpectSome(w[1], w[0])
+			cc.ExpectMutualSome(w[0], w[1])
 			cc.CheckConnectivity()
 		})
 
@@ -127,8 +126,7 @@ var _ = Context("etcd connection interruption", func() {
 
 	It("should detect and reconnect after the etcd connection is black-holed", func() {
 		By("having initial workload to workload connectivity", func() {
-			cc.ExpectSome(w[0], w[1])
-			cc.ExpectSome(w[1], w[0])
+			cc.ExpectMutualSome(w[0], w[1])
 			cc.CheckConnectivity()
 		})
 
@@ -187,8 +185,7 @@ var _ = Context("etcd connection interruption", func() {
 
 			// Felix should start applying policy again when it detects the connection failure.
 			cc.ResetExpectations()
-			cc.ExpectNone(w[0], w[1])
-			cc.ExpectNone(w[1], w[0])
+			cc.ExpectMutualNone(w[0], w[1])
 			cc.CheckConnectivityWithTimeout(120 * time.Second)
 		})
 	})
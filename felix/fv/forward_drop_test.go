@@ -79,8 +79,7 @@ var _ = infrastructure.DatastoreDescribe("Base FORWARD behaviour", []apiconfig.D
 	})
 
 	It("should not forward because of FORWARD DROP policy", func() {
-		cc.ExpectNone(w[0], w[1])
-		cc.ExpectNone(w[1], w[0])
+		cc.ExpectMutualNone(w[0], w[1])
 		cc.CheckConnectivity()
 	})
 
@@ -90,8 +89,7 @@ var _ = infrastructure.DatastoreDescribe("Base FORWARD behaviour", []apiconfig.D
 		})
 
 		It("should now forward", func() {
-			cc.ExpectSome(w[0], w[1])
-			cc.ExpectSome(w[1], w[0])
+			cc.ExpectMutualSome(w[0], w[1])
 			cc.CheckConnectivity()
 		})
 	})
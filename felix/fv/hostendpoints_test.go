@@ -159,8 +159,7 @@ func describeHostEndpointTests(getInfra infrastructure.InfraFactory, allInterfac
 	}
 
 	expectPodToPodTraffic := func() {
-		cc.ExpectSome(w[0], w[1])
-		cc.ExpectSome(w[1], w[0])
+		cc.ExpectMutualSome(w[0], w[1])
 	}
 
 	expectLocalPodToRemotePodViaServiceTraffic := func() {
@@ -0,0 +1,167 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infrastructure
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/fv/utils"
+)
+
+// Capture is a packet capture, taken with tcpdump, on one of a Felix container's interfaces.
+// Unlike tcpdump.TCPDump, which matches tcpdump's text output as it goes, a Capture keeps the
+// packets themselves so that a test can inspect exactly what crossed the interface.
+type Capture struct {
+	felix *Felix
+	iface string
+	// contPath is the path of the pcap file in the container; tcpdump writes its PID to
+	// contPath + ".pid".
+	contPath string
+	cmd      *exec.Cmd
+	hostPath string
+}
+
+// Packet is a packet from a Capture.
+type Packet struct {
+	gopacket.Packet
+}
+
+// SrcMAC returns the packet's Ethernet source address or "" if it has no Ethernet header.
+func (p Packet) SrcMAC() string {
+	if eth, ok := p.Layer(layers.LayerTypeEthernet).(*layers.Ethernet); ok {
+		return eth.SrcMAC.String()
+	}
+	return ""
+}
+
+// SrcIP returns the packet's IPv4 source address or "" if it isn't an IPv4 packet.
+func (p Packet) SrcIP() string {
+	if ip, ok := p.Layer(layers.LayerTypeIPv4).(*layers.IPv4); ok {
+		return ip.SrcIP.String()
+	}
+	return ""
+}
+
+// DstIP returns the packet's IPv4 destination address or "" if it isn't an IPv4 packet.
+func (p Packet) DstIP() string {
+	if ip, ok := p.Layer(layers.LayerTypeIPv4).(*layers.IPv4); ok {
+		return ip.DstIP.String()
+	}
+	return ""
+}
+
+// DstPort returns the packet's TCP or UDP destination port or 0 if it has neither.
+func (p Packet) DstPort() uint16 {
+	switch l4 := p.TransportLayer().(type) {
+	case *layers.TCP:
+		return uint16(l4.DstPort)
+	case *layers.UDP:
+		return uint16(l4.DstPort)
+	}
+	return 0
+}
+
+// StartCapture starts capturing the packets on the given interface that match the given
+// tcpdump filter expression, which may be empty to capture everything.  It returns once tcpdump
+// is listening.
+func (f *Felix) StartCapture(iface, filter string) *Capture {
+	c := &Capture{
+		felix:    f,
+		iface:    iface,
+		contPath: fmt.Sprintf("/tmp/capture-%s-%d.pcap", iface, time.Now().UnixNano()),
+	}
+	// Packets are written to the file as they arrive (-U) so that nothing is lost when
+	// tcpdump is interrupted.
+	tcpdump := fmt.Sprintf("echo $$ > %s.pid; exec tcpdump -n -U -i %s -w %s", c.contPath, iface, c.contPath)
+	if filter != "" {
+		tcpdump += " '" + filter + "'"
+	}
+	c.cmd = utils.Command("docker", "exec", f.Name, "sh", "-c", tcpdump)
+	stderr, err := c.cmd.StderrPipe()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(c.cmd.Start()).To(Succeed())
+
+	listening := make(chan struct{})
+	go func() {
+		s := bufio.NewScanner(stderr)
+		closed := false
+		for s.Scan() {
+			line := s.Text()
+			log.Infof("[%s capture %s] %s", f.Name, iface, line)
+			if !closed && strings.Contains(line, "listening") {
+				close(listening)
+				closed = true
+			}
+		}
+	}()
+	select {
+	case <-listening:
+	case <-time.After(60 * time.Second):
+		ginkgo.Fail("Failed to start packet capture: tcpdump never reported that it was listening")
+	}
+	return c
+}
+
+// Stop stops the capture and returns the packets that it captured, in the order that they
+// were captured.  The pcap file is kept on the host for diagnostics; see PcapFile.
+func (c *Capture) Stop() []Packet {
+	// SIGINT makes tcpdump flush and close the file before it exits.
+	err := c.felix.ExecMayFail("sh", "-c", fmt.Sprintf("kill -INT $(cat %s.pid)", c.contPath))
+	if err != nil {
+		log.WithError(err).Error("Failed to interrupt tcpdump; maybe it failed to start?")
+	}
+	_ = c.cmd.Wait()
+
+	c.hostPath = filepath.Join(os.TempDir(), c.felix.Name+"-"+filepath.Base(c.contPath))
+	Expect(utils.Command("docker", "cp", c.felix.Name+":"+c.contPath, c.hostPath).Run()).To(Succeed())
+	log.WithField("file", c.hostPath).Info("Saved packet capture.")
+
+	file, err := os.Open(c.hostPath)
+	Expect(err).NotTo(HaveOccurred())
+	defer file.Close()
+	r, err := pcapgo.NewReader(file)
+	Expect(err).NotTo(HaveOccurred())
+
+	var packets []Packet
+	for {
+		data, ci, err := r.ReadPacketData()
+		if err != nil {
+			break
+		}
+		pkt := gopacket.NewPacket(data, r.LinkType(), gopacket.Default)
+		pkt.Metadata().CaptureInfo = ci
+		packets = append(packets, Packet{pkt})
+	}
+	return packets
+}
+
+// PcapFile returns the path on the host of the pcap file of a stopped capture, which can be
+// opened with tcpdump -r or Wireshark.
+func (c *Capture) PcapFile() string {
+	return c.hostPath
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infrastructure
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/felix/bpf"
+)
+
+// counterConservationComment marks the iptables rule that AssertCounterConservation uses to
+// count the packets that reach iptables.
+const counterConservationComment = "fv-counter-conservation"
+
+var iptablesSaveCountersRegexp = regexp.MustCompile(`^\[(\d+):\d+\]`)
+
+// CounterConservation is the account, from AssertCounterConservation, of what happened to the
+// packets from one source.
+type CounterConservation struct {
+	// Sent is the number of packets that the traffic function said it sent.
+	Sent int
+	// XDPDropped is the number of packets that the blocklist XDP program counted as dropped.
+	XDPDropped int
+	// XDPPassed is the number of packets that a capture, which sees packets after the XDP
+	// program, saw on the interface.
+	XDPPassed int
+	// IptablesSeen is the number of packets that reached the start of the raw table's
+	// PREROUTING chain, before any of Felix's rules could accept or drop them.
+	IptablesSeen int
+}
+
+// AssertCounterConservation checks that the blocklist XDP program and iptables between them
+// account for every packet from the given source that reaches the given interface, once and
+// only once.  It counts the packets from the source as they pass three stages: the XDP
+// program's per-source drop counts, a packet capture, which only sees the packets that XDP
+// passed, and a rule at the top of the raw PREROUTING chain.  Then it calls send, which should
+// send packets from the source to the interface and return how many it sent, and checks that
+// the packets that XDP dropped and passed add up to those sent and that iptables saw exactly
+// the packets that XDP passed.  No other packets from the source should arrive in the
+// meantime.  It returns the counts so that the test can check how the packets were split.
+// Not available in BPF mode, which has no per-source counts.
+//
+// Felix moves its own rules back to the top of PREROUTING when it next refreshes the raw
+// table, after which packets that Felix's rules accept or drop are missed, so send should
+// finish within a few seconds.
+func AssertCounterConservation(felix *Felix, iface, src string, send func() int) CounterConservation {
+	ruleArgs := []string{
+		"PREROUTING", "-i", iface, "-s", src,
+		"-m", "comment", "--comment", counterConservationComment,
+	}
+	felix.Exec(append([]string{"iptables", "-w", "10", "-W", "100000", "-t", "raw", "-I"}, ruleArgs...)...)
+	defer felix.Exec(append([]string{"iptables", "-w", "10", "-W", "100000", "-t", "raw", "-D"}, ruleArgs...)...)
+
+	dropCount := func() int {
+		counts, err := bpf.PerSourceDropCounts(felix, iface)
+		ExpectWithOffset(2, err).NotTo(HaveOccurred())
+		return int(counts[src])
+	}
+	dropsBefore := dropCount()
+	capture := felix.StartCapture(iface, "ip and src host "+src)
+
+	c := CounterConservation{Sent: send()}
+	// The counters lag the packets slightly, so wait for them to settle.
+	EventuallyWithOffset(1, func() int {
+		c.XDPDropped = dropCount() - dropsBefore
+		c.IptablesSeen = felix.counterConservationRulePackets()
+		return c.XDPDropped + c.IptablesSeen
+	}, "5s", "200ms").Should(Equal(c.Sent),
+		"XDP drops and iptables don't add up to the packets sent from %s", src)
+	c.XDPPassed = len(capture.Stop())
+
+	ExpectWithOffset(1, c.XDPDropped+c.XDPPassed).To(Equal(c.Sent),
+		"Packets from %s were lost or double-counted by XDP (%+v); see %s", src, c, capture.PcapFile())
+	ExpectWithOffset(1, c.IptablesSeen).To(Equal(c.XDPPassed),
+		"iptables didn't see exactly the packets from %s that XDP passed (%+v); see %s", src, c, capture.PcapFile())
+	return c
+}
+
+// counterConservationRulePackets returns the packet count of AssertCounterConservation's rule
+// in the raw table.
+func (f *Felix) counterConservationRulePackets() int {
+	out, err := f.ExecOutput("iptables-save", "-c", "-t", "raw")
+	ExpectWithOffset(2, err).NotTo(HaveOccurred())
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.Contains(line, counterConservationComment) {
+			continue
+		}
+		m := iptablesSaveCountersRegexp.FindStringSubmatch(line)
+		ExpectWithOffset(2, m).NotTo(BeNil(), "no counters in iptables-save line: "+line)
+		n, err := strconv.Atoi(m[1])
+		ExpectWithOffset(2, err).NotTo(HaveOccurred())
+		return n
+	}
+	ginkgo.Fail("Counter conservation rule is missing from the raw table:\n"+out, 2)
+	return 0
+}
@@ -16,19 +16,33 @@ package infrastructure
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"path"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	. "github.com/onsi/gomega"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/bpf/conntrack"
+	"github.com/projectcalico/calico/felix/bpf/counters"
+	"github.com/projectcalico/calico/felix/environment"
+	"github.com/projectcalico/calico/felix/fv/connectivity"
 	"github.com/projectcalico/calico/felix/fv/containers"
+	"github.com/projectcalico/calico/felix/fv/metrics"
 	"github.com/projectcalico/calico/felix/fv/tcpdump"
 	"github.com/projectcalico/calico/felix/fv/utils"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
 )
 
 // FIXME: isolate individual Felix instances in their own cgroups.  Unfortunately, this doesn't work on systems that are using cgroupv1
@@ -56,12 +70,17 @@ type Felix struct {
 
 	// IP of the Typha that this Felix is using (if any).
 	TyphaIP string
+	// The Typha that this Felix is using (if any).  All the Felixes in a topology share
+	// the same Typha.
+	Typha *Typha
 
 	// If set, acts like an external IP of a node. Filled in by SetExternalIP().
 	ExternalIP string
 
 	startupDelayed bool
-	Workloads      []workload
+	// xdpEventLog is the path, in the container, of Felix's XDP event log (if any).
+	xdpEventLog string
+	Workloads   []workload
 }
 
 type workload interface {
@@ -99,7 +118,18 @@ func RunFelix(infra DatastoreInfra, id int, options TopologyOptions) *Felix {
 	bpfEnableIPv6 := fmt.Sprint(options.BPFEnableIPv6)
 
 	args := infra.GetDockerArgs()
-	args = append(args, "--privileged")
+	restricted := len(options.FelixCapabilities) > 0
+	if restricted {
+		args = append(args, "--cap-drop=ALL")
+		for _, c := range options.FelixCapabilities {
+			args = append(args, "--cap-add="+c)
+		}
+		// Felix can't mount the BPF filesystem without CAP_SYS_ADMIN so have Docker mount
+		// one for it, as a hostPath volume would in a real deployment.
+		args = append(args, "--mount", "type=volume,dst=/sys/fs/bpf,volume-opt=type=bpf,volume-opt=device=bpf")
+	} else {
+		args = append(args, "--privileged")
+	}
 
 	// Collect the environment variables for starting this particular container.  Note: we
 	// are called concurrently with other instances of RunFelix so it's important to only
@@ -165,6 +195,29 @@ func RunFelix(infra DatastoreInfra, id int, options TopologyOptions) *Felix {
 		args = append(args, "-e", fmt.Sprintf("%s=%s", k, v))
 	}
 
+	var sysctls []string
+	if options.EnableIPv6 {
+		sysctls = []string{
+			"net.ipv6.conf.all.disable_ipv6=0",
+			"net.ipv6.conf.default.disable_ipv6=0",
+			"net.ipv6.conf.lo.disable_ipv6=0",
+			"net.ipv6.conf.all.forwarding=1",
+		}
+	} else {
+		sysctls = []string{
+			"net.ipv6.conf.all.disable_ipv6=1",
+			"net.ipv6.conf.default.disable_ipv6=1",
+			"net.ipv6.conf.lo.disable_ipv6=1",
+			"net.ipv6.conf.all.forwarding=0",
+		}
+	}
+	if restricted {
+		// /proc/sys is read-only in an unprivileged container so Docker has to set them.
+		for _, s := range sysctls {
+			args = append(args, "--sysctl", s)
+		}
+	}
+
 	// Add in the volumes.
 	for k, v := range options.ExtraVolumes {
 		volumes[k] = v
@@ -190,16 +243,10 @@ func RunFelix(infra DatastoreInfra, id int, options TopologyOptions) *Felix {
 	}
 	c := containers.RunWithFixedName(containerName, felixOpts, args...)
 
-	if options.EnableIPv6 {
-		c.Exec("sysctl", "-w", "net.ipv6.conf.all.disable_ipv6=0")
-		c.Exec("sysctl", "-w", "net.ipv6.conf.default.disable_ipv6=0")
-		c.Exec("sysctl", "-w", "net.ipv6.conf.lo.disable_ipv6=0")
-		c.Exec("sysctl", "-w", "net.ipv6.conf.all.forwarding=1")
-	} else {
-		c.Exec("sysctl", "-w", "net.ipv6.conf.all.disable_ipv6=1")
-		c.Exec("sysctl", "-w", "net.ipv6.conf.default.disable_ipv6=1")
-		c.Exec("sysctl", "-w", "net.ipv6.conf.lo.disable_ipv6=1")
-		c.Exec("sysctl", "-w", "net.ipv6.conf.all.forwarding=0")
+	if !restricted {
+		for _, s := range sysctls {
+			c.Exec("sysctl", "-w", s)
+		}
 	}
 
 	// Configure our model host to drop forwarded traffic by default.  Modern
@@ -216,6 +263,7 @@ func RunFelix(infra DatastoreInfra, id int, options TopologyOptions) *Felix {
 	return &Felix{
 		Container:      c,
 		startupDelayed: options.DelayFelixStart,
+		xdpEventLog:    envVars["FELIX_XDPEVENTLOG"],
 	}
 }
 
@@ -232,6 +280,34 @@ func (f *Felix) Restart() {
 	Eventually(f.GetFelixPID, "10s", "100ms").ShouldNot(Equal(oldPID))
 }
 
+// HardKill kills Felix with SIGKILL, as the OOM killer would, and waits for it to be started
+// again.  Unlike Restart, Felix gets no chance to shut down cleanly, so it has to recover from
+// whatever state it left behind: its pinned BPF maps and programs, and its iptables rules.
+func (f *Felix) HardKill() {
+	oldPID := f.GetFelixPID()
+	// Tell the wrapper to start Felix again rather than exit, taking the container with it.
+	f.Exec("touch", "/restart-after-kill")
+	f.Exec("kill", "-KILL", fmt.Sprint(oldPID))
+	Eventually(f.GetFelixPID, "10s", "100ms").ShouldNot(Equal(oldPID))
+}
+
+// DumpBPFMaps exports all of Felix's BPF maps with "calico-bpf dump-all" and prints the
+// exported files, so that the maps' contents end up in the test log when a test fails.  It's
+// best effort: a command that fails only leaves a warning, with its output, in the log.
+func (f *Felix) DumpBPFMaps() {
+	const dumpDir = "/tmp/bpf-dump"
+	_ = f.ExecMayFail("calico-bpf", "dump-all", "--out", dumpDir)
+	_ = f.ExecMayFail("sh", "-c", "for f in "+dumpDir+"/*.json; do echo $f; cat $f; done")
+}
+
+// RemountBPFFS replaces the container's bpffs with a fresh, empty, instance; as if the host's
+// bpffs had been lost.  All the pinned maps and programs are lost but programs that are
+// attached to interfaces keep running, using their now-unpinned maps.
+func (f *Felix) RemountBPFFS() {
+	f.Exec("umount", "/sys/fs/bpf")
+	f.Exec("mount", "-t", "bpf", "bpffs", "/sys/fs/bpf")
+}
+
 func (f *Felix) SetEvn(env map[string]string) {
 	fn := "extra-env.sh"
 
@@ -267,6 +343,957 @@ func (f *Felix) ProgramIptablesDNAT(serviceIP, targetIP, chain string) {
 	)
 }
 
+// ConntrackCount returns the number of entries in the conntrack table that is in
+// use: the BPF conntrack map in BPF mode, otherwise the kernel's conntrack table.
+func (f *Felix) ConntrackCount() int {
+	if os.Getenv("FELIX_FV_ENABLE_BPF") == "true" {
+		entries, err := conntrack.Dump(f)
+		Expect(err).NotTo(HaveOccurred())
+		return len(entries)
+	}
+
+	out, err := f.ExecOutput("conntrack", "-C")
+	Expect(err).NotTo(HaveOccurred())
+	count, err := strconv.Atoi(strings.TrimSpace(out))
+	Expect(err).NotTo(HaveOccurred())
+	return count
+}
+
+// ConntrackMax returns the most entries that the kernel's conntrack table can hold.  The limit
+// is the host's; it can't be changed from inside the Felix's network namespace.
+func (f *Felix) ConntrackMax() int {
+	out, err := f.ExecOutput("cat", "/proc/sys/net/netfilter/nf_conntrack_max")
+	Expect(err).NotTo(HaveOccurred())
+	max, err := strconv.Atoi(strings.TrimSpace(out))
+	Expect(err).NotTo(HaveOccurred())
+	return max
+}
+
+// conntrackFillDst is the destination of the entries that FillConntrack adds, so that they can
+// be found and deleted again.
+const conntrackFillDst = "198.19.255.254"
+
+// conntrackFillWorkers is how many conntrack processes FillConntrack runs at once.
+const conntrackFillWorkers = 8
+
+// FillConntrack adds n established TCP entries to the kernel's conntrack table, between made-up
+// addresses in 198.18.0.0/15.  The entries are marked as assured, so the kernel won't evict them
+// to make room for new connections; once the table is full, packets that would need a new entry
+// are dropped.  Entries that don't fit are silently skipped.  ClearConntrackFill removes them.
+func (f *Felix) FillConntrack(n int) {
+	log.WithFields(log.Fields{"felix": f.Name, "entries": n}).Info("Filling conntrack table")
+	var script strings.Builder
+	for w := 0; w < conntrackFillWorkers; w++ {
+		first, last := n*w/conntrackFillWorkers, n*(w+1)/conntrackFillWorkers-1
+		if first > last {
+			continue
+		}
+		// Each worker uses its own source port so that the entries never clash.
+		fmt.Fprintf(&script, "seq %d %d | while read i; do "+
+			"conntrack -I -p tcp -s 198.$((18 + i / 65536)).$((i / 256 %% 256)).$((i %% 256)) -d %s "+
+			"--sport %d --dport 80 --state ESTABLISHED -u ASSURED,SEEN_REPLY -t 3600 >/dev/null 2>&1; "+
+			"done &\n", first, last, conntrackFillDst, 10000+w)
+	}
+	script.WriteString("wait\n")
+	f.Exec("sh", "-c", script.String())
+}
+
+// ClearConntrackFill removes the entries that FillConntrack added.
+func (f *Felix) ClearConntrackFill() {
+	// conntrack fails if there's nothing to delete.
+	_ = f.ExecMayFail("conntrack", "-D", "-p", "tcp", "-d", conntrackFillDst)
+}
+
+// Interfaces returns the names of all the interfaces in the Felix's network namespace.
+func (f *Felix) Interfaces() []string {
+	return f.linkNames(func(string) bool { return true })
+}
+
+// MACAddress returns the MAC address of the given interface.
+func (f *Felix) MACAddress(iface string) (string, error) {
+	out, err := f.ExecOutput("cat", path.Join("/sys/class/net", iface, "address"))
+	return strings.TrimSpace(out), err
+}
+
+// KernelVersion returns the version of the kernel that the Felix runs on.  The Felix containers
+// share the kernel of the Docker host; there is no option to boot them under another kernel, so
+// covering several kernels means running the suite on a host with each of them.
+func (f *Felix) KernelVersion() (*environment.Version, error) {
+	out, err := f.ExecOutput("cat", "/proc/version")
+	if err != nil {
+		return nil, err
+	}
+	return environment.GetVersionFromString(out)
+}
+
+// XDPAttachedInterfaces returns the names of the interfaces that have an XDP program attached,
+// in any mode.
+func (f *Felix) XDPAttachedInterfaces() []string {
+	return f.linkNames(func(link string) bool {
+		return strings.Contains(link, "prog/xdp")
+	})
+}
+
+// XDPStatus returns Felix's own report of the XDP status of the given interface: whether it
+// wants XDP there, whether the program is attached and in which mode, and how big the
+// blocklist is.  An interface that Felix doesn't know about has the zero status.
+func (f *Felix) XDPStatus(iface string) (bpf.XDPStatus, error) {
+	httpClient := http.Client{Timeout: time.Second}
+	defer httpClient.CloseIdleConnections()
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(f.IP, metrics.PortString()), bpf.XDPStatusPath)
+	rsp, err := httpClient.Get(url)
+	if err != nil {
+		return bpf.XDPStatus{}, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return bpf.XDPStatus{}, fmt.Errorf("GET %s: %s", url, rsp.Status)
+	}
+	var status map[string]bpf.XDPStatus
+	if err := json.NewDecoder(rsp.Body).Decode(&status); err != nil {
+		return bpf.XDPStatus{}, fmt.Errorf("failed to decode XDP status: %w", err)
+	}
+	return status[iface], nil
+}
+
+// XDPBlocklistCIDRs returns the CIDRs in the IPv4 XDP blocklist map of the given interface,
+// sorted, from a single dump of the map.  Overlapping CIDRs are separate entries so, unlike a
+// lookup, this shows exactly which prefixes Felix has programmed.
+func (f *Felix) XDPBlocklistCIDRs(iface string) ([]string, error) {
+	return f.xdpMapCIDRs(fmt.Sprintf("%s_ipv4_v1_blacklist", iface))
+}
+
+// XDPDstCIDRs returns the CIDRs in the XDP destination map of the given interface, which
+// hold the destinations that untracked policy denies to all sources, sorted.
+func (f *Felix) XDPDstCIDRs(iface string) ([]string, error) {
+	return f.xdpMapCIDRs(fmt.Sprintf("%s_ipv4_v1_dstlist", iface))
+}
+
+// XDPAllowListCIDRs returns the CIDRs in the host's XDP allow-list, which hold the sources
+// that the XDP programs pass whatever their blocklists say, sorted.
+func (f *Felix) XDPAllowListCIDRs() ([]string, error) {
+	return f.xdpMapCIDRs("calico_allow_v4")
+}
+
+func (f *Felix) xdpMapCIDRs(mapName string) ([]string, error) {
+	out, err := f.ExecOutput("bpftool", "--json", "map", "dump", "pinned",
+		"/sys/fs/bpf/calico/xdp/"+mapName)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := bpf.ParseCIDRMapDump([]byte(out), bpf.IPFamilyV4)
+	if err != nil {
+		return nil, err
+	}
+	cidrs := []string{}
+	for k := range entries {
+		cidrs = append(cidrs, k.ToIPNet().String())
+	}
+	sort.Strings(cidrs)
+	return cidrs, nil
+}
+
+// XDPBlocklistKeys returns the raw keys of the IPv4 XDP blocklist map of the given interface,
+// each as space-separated hex bytes in the order that bpftool prints them, sorted.  Unlike
+// XDPBlocklistCIDRs, it doesn't decode the keys, so it shows exactly what the XDP program
+// matches against.
+func (f *Felix) XDPBlocklistKeys(iface string) ([]string, error) {
+	out, err := f.ExecOutput("bpftool", "--json", "map", "dump", "pinned",
+		fmt.Sprintf("/sys/fs/bpf/calico/xdp/%s_ipv4_v1_blacklist", iface))
+	if err != nil {
+		return nil, err
+	}
+	var entries []struct {
+		Key []string `json:"key"`
+	}
+	if err := json.Unmarshal([]byte(out), &entries); err != nil {
+		return nil, fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+	}
+	keys := []string{}
+	for _, e := range entries {
+		var b []string
+		for _, h := range e.Key {
+			b = append(b, strings.TrimPrefix(h, "0x"))
+		}
+		keys = append(keys, strings.Join(b, " "))
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// XDPProgramming identifies what Felix has programmed for XDP on an interface.  If any of it
+// changes then Felix has reprogrammed the interface.
+type XDPProgramming struct {
+	// ProgramID and ProgramTag identify the XDP program attached to the interface.
+	ProgramID  int
+	ProgramTag string
+	// BlocklistMapID identifies the interface's blocklist map.
+	BlocklistMapID int
+	// Operations is the number of XDP programs that Felix has attached, detached or
+	// reloaded, on any interface.
+	Operations int
+}
+
+var xdpProgIDRegexp = regexp.MustCompile(`prog/xdp id (\d+)`)
+
+// XDPProgramming returns the XDP program and blocklist map on the given interface, along with
+// Felix's count of XDP program operations.  Not available in BPF mode.
+func (f *Felix) XDPProgramming(iface string) (XDPProgramming, error) {
+	var p XDPProgramming
+	out, err := f.ExecCombinedOutput("ip", "link", "show", "dev", iface)
+	if err != nil {
+		return p, err
+	}
+	m := xdpProgIDRegexp.FindStringSubmatch(out)
+	if m == nil {
+		return p, fmt.Errorf("no XDP program attached to %s", iface)
+	}
+	p.ProgramID, _ = strconv.Atoi(m[1])
+
+	out, err = f.ExecOutput("bpftool", "--json", "prog", "show", "id", m[1])
+	if err != nil {
+		return p, err
+	}
+	var prog bpf.ProgInfo
+	if err := json.Unmarshal([]byte(out), &prog); err != nil {
+		return p, fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+	}
+	p.ProgramTag = prog.Tag
+
+	out, err = f.ExecOutput("bpftool", "--json", "map", "show", "pinned",
+		fmt.Sprintf("/sys/fs/bpf/calico/xdp/%s_ipv4_v1_blacklist", iface))
+	if err != nil {
+		return p, err
+	}
+	var blocklist struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(out), &blocklist); err != nil {
+		return p, fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+	}
+	p.BlocklistMapID = blocklist.ID
+
+	p.Operations, err = metrics.GetFelixMetricInt(f.IP, "felix_xdp_program_operations")
+	return p, err
+}
+
+// XDPLifecycleMetrics holds Felix's counts of XDP lifecycle operations, over all interfaces.
+type XDPLifecycleMetrics struct {
+	Attaches   int
+	Detaches   int
+	Reloads    int
+	MapCreates int
+	// MapUpdates counts each batch of changes to an interface's blocklist map once, however
+	// many entries it changes.
+	MapUpdates int
+	// ResyncRepairs counts the interfaces whose program or blocklist map a resync found out
+	// of step with what Felix had programmed.
+	ResyncRepairs int
+}
+
+// Sub returns the number of each operation since the earlier counts.
+func (m XDPLifecycleMetrics) Sub(earlier XDPLifecycleMetrics) XDPLifecycleMetrics {
+	return XDPLifecycleMetrics{
+		Attaches:      m.Attaches - earlier.Attaches,
+		Detaches:      m.Detaches - earlier.Detaches,
+		Reloads:       m.Reloads - earlier.Reloads,
+		MapCreates:    m.MapCreates - earlier.MapCreates,
+		MapUpdates:    m.MapUpdates - earlier.MapUpdates,
+		ResyncRepairs: m.ResyncRepairs - earlier.ResyncRepairs,
+	}
+}
+
+// XDPLifecycleMetrics reads Felix's counts of XDP lifecycle operations from its Prometheus
+// metrics.  Not available in BPF mode.
+func (f *Felix) XDPLifecycleMetrics() (XDPLifecycleMetrics, error) {
+	var m XDPLifecycleMetrics
+	for op, count := range map[string]*int{
+		"attach":        &m.Attaches,
+		"detach":        &m.Detaches,
+		"reload":        &m.Reloads,
+		"map_create":    &m.MapCreates,
+		"map_update":    &m.MapUpdates,
+		"resync_repair": &m.ResyncRepairs,
+	} {
+		n, err := metrics.GetFelixMetricInt(f.IP, fmt.Sprintf(`felix_xdp_lifecycle_operations{operation="%s"}`, op))
+		if err != nil {
+			return m, err
+		}
+		*count = n
+	}
+	return m, nil
+}
+
+// AssertXDPNotReprogrammed runs change, which should make no difference to the XDP policy of
+// the given interface, and then checks that Felix doesn't reprogram XDP for the next few
+// seconds: the same program and blocklist map stay in place and no programs are attached,
+// detached or reloaded.  A no-op change that Felix mistakes for a real one would briefly
+// disrupt the host's traffic.  Not available in BPF mode.
+func AssertXDPNotReprogrammed(f *Felix, iface string, change func()) {
+	before, err := f.XDPProgramming(iface)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+
+	change()
+
+	ConsistentlyWithOffset(1, func() (XDPProgramming, error) {
+		return f.XDPProgramming(iface)
+	}, "5s", "500ms").Should(Equal(before), "%s reprogrammed XDP on %s", f.Name, iface)
+}
+
+// AssertNoBlipDuringReload checks that an allowed flow keeps flowing while Felix reloads an XDP
+// program.  It monitors the given path in the background, using the checker's protocol and
+// connect timeout, waits for the path to connect and then runs reloadFn, which should trigger
+// the reload and wait for it to finish, for example by changing a host endpoint's XDP mode and
+// waiting for the interface to switch.  Every probe from then on must get through: a reload
+// that detaches the old program before attaching the new one, or that briefly runs the new
+// program against empty maps, would drop some of them.
+func AssertNoBlipDuringReload(
+	cc *connectivity.Checker,
+	from connectivity.ConnectionSource,
+	to connectivity.ConnectionTarget,
+	port uint16,
+	reloadFn func(),
+) {
+	assertNoDropsWhile(cc, from, to, port, "the reload", reloadFn)
+}
+
+// AssertNoDropsDuringChurn checks that programming and unprogramming other policies doesn't
+// disturb an allowed flow.  It monitors the given path in the same way as
+// AssertNoBlipDuringReload while churnFn runs; churnFn should repeatedly create and delete
+// policies, or network sets, that don't apply to the path.  Every probe must get through.
+func AssertNoDropsDuringChurn(
+	cc *connectivity.Checker,
+	from connectivity.ConnectionSource,
+	to connectivity.ConnectionTarget,
+	port uint16,
+	churnFn func(),
+) {
+	assertNoDropsWhile(cc, from, to, port, "the churn", churnFn)
+}
+
+func assertNoDropsWhile(
+	cc *connectivity.Checker,
+	from connectivity.ConnectionSource,
+	to connectivity.ConnectionTarget,
+	port uint16,
+	what string,
+	fn func(),
+) {
+	protocol := "tcp"
+	if cc.Protocol != "" {
+		protocol = cc.Protocol
+	}
+	opts := []connectivity.MonitorOpt{
+		connectivity.MonitorWithProtocol(protocol),
+		connectivity.MonitorWithInterval(100 * time.Millisecond),
+	}
+	if cc.ConnectTimeout > 0 {
+		opts = append(opts, connectivity.MonitorWithConnectTimeout(cc.ConnectTimeout))
+	}
+	monitor := connectivity.BackgroundMonitor(from, to, port, opts...)
+	defer monitor.Stop()
+	EventuallyWithOffset(2, monitor.Connected, "10s", "100ms").Should(BeTrue(),
+		"%s never connected to port %d before %s", from.SourceName(), port, what)
+	start := len(monitor.Timeline())
+
+	fn()
+	// Keep probing for a little while, in case Felix finishes in the background.
+	time.Sleep(time.Second)
+
+	timeline := monitor.Stop()
+	var dropped int
+	for _, probe := range timeline[start:] {
+		if !probe.Connected {
+			dropped++
+		}
+	}
+	ExpectWithOffset(2, dropped).To(BeZero(),
+		"%d probes from %s to port %d were dropped during %s: %v",
+		dropped, from.SourceName(), port, what, timeline[start:])
+}
+
+// MeasureAttachLatency returns how long after the datastore accepted the named global network
+// policy the given Felix attached an XDP program: the time between the policy's creation
+// timestamp and the first attach event in Felix's XDP event log that follows it.  Creation
+// timestamps only have a resolution of a second, so the latency may be overestimated by up to
+// a second but never underestimated.  It returns an error if Felix hasn't attached a program
+// since the policy was created, so callers can wait for the attach with Eventually.  Requires
+// FELIX_XDPEVENTLOG, so not available in BPF mode.
+func MeasureAttachLatency(c client.Interface, f *Felix, policyName string) (time.Duration, error) {
+	if f.xdpEventLog == "" {
+		return 0, fmt.Errorf("%s has no XDP event log", f.Name)
+	}
+	policy, err := c.GlobalNetworkPolicies().Get(utils.Ctx, policyName, options.GetOptions{})
+	if err != nil {
+		return 0, err
+	}
+	accepted := policy.CreationTimestamp.Time
+
+	out, err := f.ExecOutput("cat", f.xdpEventLog)
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[1] != "attach" {
+			continue
+		}
+		attached, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return 0, fmt.Errorf("bad timestamp in XDP event %q: %w", line, err)
+		}
+		if attached.Before(accepted) {
+			continue
+		}
+		return attached.Sub(accepted), nil
+	}
+	return 0, fmt.Errorf("%s hasn't attached an XDP program since %s was created at %s",
+		f.Name, policyName, accepted.Format(time.RFC3339))
+}
+
+// AssertNoLeakedBPFObjects checks that the given Felixes have cleaned up the BPF objects that
+// they create for the XDP programs of particular interfaces: the programs attached to the
+// interfaces and their pinned programs and maps.  Call it after deleting all the policies and
+// host endpoints; it gives Felix a few seconds to clean up.  Objects that live as long as Felix
+// does, such as the failsafe ports map and the BPF dataplane's global maps, aren't leaks.
+func AssertNoLeakedBPFObjects(felixes []*Felix) {
+	for _, f := range felixes {
+		EventuallyWithOffset(1, f.leakedXDPObjects, "10s", "500ms").Should(BeEmpty(),
+			"%s leaked XDP programs or maps", f.Name)
+	}
+}
+
+func (f *Felix) leakedXDPObjects() ([]string, error) {
+	var leaked []string
+	for _, iface := range f.XDPAttachedInterfaces() {
+		leaked = append(leaked, "XDP program attached to "+iface)
+	}
+	pins, err := bpf.XDPInterfacePins(f)
+	return append(leaked, pins...), err
+}
+
+func (f *Felix) linkNames(include func(link string) bool) []string {
+	out, err := f.ExecOutput("ip", "-o", "link", "show")
+	Expect(err).NotTo(HaveOccurred())
+	var names []string
+	for _, link := range strings.Split(strings.TrimSpace(out), "\n") {
+		// <index>: <name>[@<peer>]: <flags> ...
+		fields := strings.Fields(link)
+		if len(fields) < 2 || !include(link) {
+			continue
+		}
+		name := strings.TrimSuffix(fields[1], ":")
+		names = append(names, strings.Split(name, "@")[0])
+	}
+	return names
+}
+
+// AddVLANInterface adds an 802.1Q sub-interface of parent, named "<parent>.<vlanID>", with the
+// given address, so that the Felix sends and receives tagged frames on parent.  It returns an
+// error if the kernel doesn't support VLANs.
+func (f *Felix) AddVLANInterface(parent string, vlanID int, addr string) (string, error) {
+	name := fmt.Sprintf("%s.%d", parent, vlanID)
+	out, err := f.ExecCombinedOutput("ip", "link", "add", "link", parent, "name", name,
+		"type", "vlan", "id", fmt.Sprint(vlanID))
+	if err != nil {
+		return "", fmt.Errorf("failed to add VLAN interface %s: %w: %s", name, err, out)
+	}
+	f.Exec("ip", "addr", "add", addr, "dev", name)
+	f.Exec("ip", "link", "set", name, "up")
+	return name, nil
+}
+
+// SRIOVPhysicalFunction returns the name of a host interface whose NIC supports SR-IOV, for tests
+// that need a real virtual function, or "" if the FV_SRIOV_PF environment variable doesn't name
+// one.  There's no way to simulate SR-IOV, so those tests only run on suitable hardware.
+func SRIOVPhysicalFunction() string {
+	return os.Getenv("FV_SRIOV_PF")
+}
+
+// AddSRIOVVirtualFunction creates a virtual function of the given host physical function, using
+// the NIC's own driver, and moves it into the Felix's network namespace.  It returns the name of
+// the VF's interface.  Only one VF per physical function is supported; remove it with
+// RemoveSRIOVVirtualFunctions.
+func (f *Felix) AddSRIOVVirtualFunction(pf string) (string, error) {
+	device := path.Join("/sys/class/net", pf, "device")
+	if err := os.WriteFile(path.Join(device, "sriov_numvfs"), []byte("1"), 0o644); err != nil {
+		return "", fmt.Errorf("failed to create a VF of %s: %w", pf, err)
+	}
+	var vf string
+	for start := time.Now(); vf == "" && time.Since(start) < 10*time.Second; {
+		// The VF's interface appears once its driver has probed it.
+		if entries, err := os.ReadDir(path.Join(device, "virtfn0", "net")); err == nil && len(entries) > 0 {
+			vf = entries[0].Name()
+		} else {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	if vf == "" {
+		return "", fmt.Errorf("VF of %s didn't get an interface", pf)
+	}
+
+	pid, err := utils.GetCommandOutput("docker", "inspect", "-f", "{{.State.Pid}}", f.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the network namespace of %s: %w", f.Name, err)
+	}
+	if err := utils.RunCommand("ip", "link", "set", "dev", vf, "netns", strings.TrimSpace(pid)); err != nil {
+		return "", fmt.Errorf("failed to move %s into %s: %w", vf, f.Name, err)
+	}
+	f.Exec("ip", "link", "set", vf, "up")
+	return vf, nil
+}
+
+// RemoveSRIOVVirtualFunctions removes the virtual functions of the given host physical function,
+// wherever their interfaces are.
+func RemoveSRIOVVirtualFunctions(pf string) error {
+	return os.WriteFile(path.Join("/sys/class/net", pf, "device", "sriov_numvfs"), []byte("0"), 0o644)
+}
+
+// InterfaceDriver returns the name of the kernel driver behind the given interface, or "" if it
+// has no device.
+func (f *Felix) InterfaceDriver(iface string) string {
+	out, err := f.ExecOutput("readlink", path.Join("/sys/class/net", iface, "device", "driver"))
+	if err != nil {
+		return ""
+	}
+	return path.Base(strings.TrimSpace(out))
+}
+
+// DeleteInterface deletes an interface in the Felix's network namespace, as happens when a NIC
+// is removed.
+func (f *Felix) DeleteInterface(iface string) error {
+	out, err := f.ExecCombinedOutput("ip", "link", "del", iface)
+	if err != nil {
+		return fmt.Errorf("failed to delete interface %s: %w: %s", iface, err, out)
+	}
+	return nil
+}
+
+// RenameInterface renames an interface in the Felix's network namespace, as udev does when it
+// gives a NIC a predictable name.  An interface has to be down to be renamed, so, if it's up, it
+// is taken down first and brought back up afterwards.  Taking it down deletes the routes through
+// it, so the default route is put back afterwards.
+func (f *Felix) RenameInterface(oldName, newName string) error {
+	out, err := f.ExecOutput("ip", "-o", "link", "show", "dev", oldName)
+	if err != nil {
+		return fmt.Errorf("failed to get state of %s: %w: %s", oldName, err, out)
+	}
+	wasUp := regexp.MustCompile(`<[^>]*\bUP\b`).MatchString(out)
+
+	defaultRoute, err := f.ExecOutput("ip", "-4", "route", "show", "default")
+	if err != nil {
+		return fmt.Errorf("failed to get default route: %w: %s", err, defaultRoute)
+	}
+
+	if wasUp {
+		if out, err := f.ExecCombinedOutput("ip", "link", "set", "dev", oldName, "down"); err != nil {
+			return fmt.Errorf("failed to take %s down: %w: %s", oldName, err, out)
+		}
+	}
+	if out, err := f.ExecCombinedOutput("ip", "link", "set", "dev", oldName, "name", newName); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w: %s", oldName, newName, err, out)
+	}
+	if !wasUp {
+		return nil
+	}
+	if out, err := f.ExecCombinedOutput("ip", "link", "set", "dev", newName, "up"); err != nil {
+		return fmt.Errorf("failed to bring %s up: %w: %s", newName, err, out)
+	}
+
+	route := strings.Fields(strings.SplitN(strings.TrimSpace(defaultRoute), "\n", 2)[0])
+	for i := range route {
+		if i > 0 && route[i-1] == "dev" && route[i] == oldName {
+			route[i] = newName
+		}
+	}
+	if len(route) > 0 {
+		args := append([]string{"ip", "-4", "route", "replace"}, route...)
+		if out, err := f.ExecCombinedOutput(args...); err != nil {
+			return fmt.Errorf("failed to restore default route %q: %w: %s", strings.Join(route, " "), err, out)
+		}
+	}
+	return nil
+}
+
+// ChangeInterfaceIP replaces the IPv4 addresses of an interface in the Felix's network namespace
+// with the given address, keeping the prefix length, as happens when a DHCP lease is renewed
+// with a different address.  Deleting the old address also deletes the routes through it, so
+// the default route is put back afterwards.  If the old address was the Felix's IP, f.IP is
+// updated so that the helpers that talk to Felix, such as the metrics ones, keep working.
+func (f *Felix) ChangeInterfaceIP(iface, newIP string) error {
+	out, err := f.ExecOutput("ip", "-4", "-o", "addr", "show", "dev", iface)
+	if err != nil {
+		return fmt.Errorf("failed to get addresses of %s: %w: %s", iface, err, out)
+	}
+	var oldCIDRs []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		for i := 0; i+1 < len(fields); i++ {
+			if fields[i] == "inet" {
+				oldCIDRs = append(oldCIDRs, fields[i+1])
+			}
+		}
+	}
+	if len(oldCIDRs) == 0 {
+		return fmt.Errorf("interface %s has no IPv4 address to change", iface)
+	}
+	prefixLen := strings.SplitN(oldCIDRs[0], "/", 2)[1]
+
+	defaultRoute, err := f.ExecOutput("ip", "-4", "route", "show", "default")
+	if err != nil {
+		return fmt.Errorf("failed to get default route: %w: %s", err, defaultRoute)
+	}
+
+	if out, err := f.ExecCombinedOutput("ip", "addr", "add", newIP+"/"+prefixLen, "dev", iface); err != nil {
+		return fmt.Errorf("failed to add %s to %s: %w: %s", newIP, iface, err, out)
+	}
+	for _, cidr := range oldCIDRs {
+		if out, err := f.ExecCombinedOutput("ip", "addr", "del", cidr, "dev", iface); err != nil {
+			return fmt.Errorf("failed to remove %s from %s: %w: %s", cidr, iface, err, out)
+		}
+		if strings.SplitN(cidr, "/", 2)[0] == f.IP {
+			f.IP = newIP
+		}
+	}
+
+	if route := strings.SplitN(strings.TrimSpace(defaultRoute), "\n", 2)[0]; route != "" {
+		args := append([]string{"ip", "-4", "route", "replace"}, strings.Fields(route)...)
+		if out, err := f.ExecCombinedOutput(args...); err != nil {
+			return fmt.Errorf("failed to restore default route %q: %w: %s", route, err, out)
+		}
+	}
+	return nil
+}
+
+// SetOffload turns an offload feature, such as "gro", on or off for an interface in the Felix's
+// network namespace.  It returns an error if the driver doesn't allow the feature to be changed.
+func (f *Felix) SetOffload(iface, feature string, on bool) error {
+	state := "off"
+	if on {
+		state = "on"
+	}
+	out, err := f.ExecCombinedOutput("ethtool", "-K", iface, feature, state)
+	if err != nil {
+		return fmt.Errorf("failed to turn %s %s on %s: %w: %s", feature, state, iface, err, out)
+	}
+	return nil
+}
+
+// CPUStats is a snapshot of the CPU time counters from /proc/stat.
+type CPUStats struct {
+	User    time.Duration
+	System  time.Duration
+	SoftIRQ time.Duration
+}
+
+// Sub returns the CPU time used between the snapshots o and s.
+func (s CPUStats) Sub(o CPUStats) CPUStats {
+	return CPUStats{
+		User:    s.User - o.User,
+		System:  s.System - o.System,
+		SoftIRQ: s.SoftIRQ - o.SoftIRQ,
+	}
+}
+
+// CPUStats returns the CPU time counters for all CPUs.  Since the containers share the host's
+// kernel, these cover the whole host, not just this Felix; in particular, XDP programs run in
+// softirq context so their cost shows up in SoftIRQ.
+func (f *Felix) CPUStats() CPUStats {
+	out, err := f.ExecOutput("head", "-n", "1", "/proc/stat")
+	Expect(err).NotTo(HaveOccurred())
+	// cpu  <user> <nice> <system> <idle> <iowait> <irq> <softirq> ...
+	fields := strings.Fields(out)
+	Expect(len(fields)).To(BeNumerically(">=", 8), "unexpected /proc/stat format: "+out)
+	// The kernel reports in USER_HZ, which is 100 on all the platforms we support.
+	ticks := func(i int) time.Duration {
+		n, err := strconv.ParseInt(fields[i], 10, 64)
+		Expect(err).NotTo(HaveOccurred())
+		return time.Duration(n) * time.Second / 100
+	}
+	return CPUStats{
+		User:    ticks(1) + ticks(2),
+		System:  ticks(3),
+		SoftIRQ: ticks(7),
+	}
+}
+
+// SNMPCounters returns the kernel's counters for one protocol group of /proc/net/snmp, such as
+// "Ip" or "Udp", in the Felix's network namespace.  Comparing the counters of different stages,
+// for example Ip's InReceives and ForwDatagrams, shows how far through the stack packets got.
+func (f *Felix) SNMPCounters(group string) map[string]int64 {
+	out, err := f.ExecOutput("cat", "/proc/net/snmp")
+	Expect(err).NotTo(HaveOccurred())
+	// Each group has a line of names followed by a line of values.
+	var header, values []string
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.HasPrefix(line, group+": ") {
+			continue
+		}
+		if header == nil {
+			header = strings.Fields(line)
+		} else {
+			values = strings.Fields(line)
+		}
+	}
+	Expect(header).NotTo(BeEmpty(), "no "+group+" counters in /proc/net/snmp: "+out)
+	Expect(values).To(HaveLen(len(header)), "unexpected /proc/net/snmp format: "+out)
+	counters := map[string]int64{}
+	for i, name := range header[1:] {
+		n, err := strconv.ParseInt(values[i+1], 10, 64)
+		Expect(err).NotTo(HaveOccurred())
+		counters[name] = n
+	}
+	return counters
+}
+
+// XDPVerdictCounts reads the BPF-mode XDP verdict counters for the given interface, as dumped
+// by calico-bpf.
+func (f *Felix) XDPVerdictCounts(iface string) counters.XDPVerdicts {
+	out, err := f.ExecOutput("calico-bpf", "counters", "dump", fmt.Sprintf("--iface=%s", iface))
+	Expect(err).NotTo(HaveOccurred())
+
+	// The category column is merged across rows so remember the last one that we saw.
+	var (
+		verdicts counters.XDPVerdicts
+		category string
+	)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.FieldsFunc(line, func(c rune) bool { return c == '|' })
+		if len(fields) < 5 {
+			continue
+		}
+		if c := strings.TrimSpace(strings.ToLower(fields[0])); c != "" {
+			category = c
+		}
+		value, err := strconv.ParseUint(strings.TrimSpace(fields[4]), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch category + "/" + strings.TrimSpace(strings.ToLower(fields[1])) {
+		case "accepted/by policy":
+			verdicts.Pass = value
+		case "accepted/by failsafe":
+			verdicts.Failsafe = value
+		case "passed/by xdp, no policy match":
+			verdicts.NotMatched = value
+		case "xdp verdict/xdp_pass":
+			verdicts.StackPassed = value
+		case "dropped/by policy":
+			verdicts.DroppedByPolicy = value
+		case "dropped/too short packets":
+			verdicts.DroppedShort = value
+		}
+	}
+	return verdicts
+}
+
+// ExpectLogMatch asserts that Felix logs a line matching the given regular
+// expression within the timeout.  Lines logged before the call also count.
+func (f *Felix) ExpectLogMatch(pattern string, timeout time.Duration) {
+	re := regexp.MustCompile(pattern)
+	// Start watching before scanning the existing logs so that we can't miss a
+	// line that is emitted in between.
+	watch := f.WatchStdoutFor(re)
+	if f.logsMatch(re) {
+		return
+	}
+	EventuallyWithOffset(1, watch, timeout).Should(BeClosed(),
+		fmt.Sprintf("Felix %s did not log a line matching %q", f.Name, pattern))
+}
+
+// ExpectNoLogMatch asserts that Felix has not logged a line matching the given
+// regular expression so far and that it doesn't log one for the given duration.
+func (f *Felix) ExpectNoLogMatch(pattern string, duration time.Duration) {
+	re := regexp.MustCompile(pattern)
+	watch := f.WatchStdoutFor(re)
+	ExpectWithOffset(1, f.logsMatch(re)).To(BeFalse(),
+		fmt.Sprintf("Felix %s logged a line matching %q", f.Name, pattern))
+	ConsistentlyWithOffset(1, watch, duration).ShouldNot(BeClosed(),
+		fmt.Sprintf("Felix %s logged a line matching %q", f.Name, pattern))
+}
+
+// logsMatch returns whether any line that Felix has logged so far matches re.
+func (f *Felix) logsMatch(re *regexp.Regexp) bool {
+	out, err := utils.Command("docker", "logs", f.Name).Output()
+	Expect(err).NotTo(HaveOccurred())
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, 10*1024*1024)
+	for scanner.Scan() {
+		if re.MatchString(scanner.Text()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChainNode is an iptables chain along with the cali- chains that it jumps to.
+type ChainNode struct {
+	Table string
+	Name  string
+	// IPSets lists the IP sets that the chain's rules match on, in order.
+	IPSets []string
+	// Targets lists the jump and goto targets of the chain's rules, in order.
+	Targets  []string
+	Children []*ChainNode
+}
+
+// PolicyChainTree returns the tree of cali- chains that render the given policy
+// (for example, "default.xdp-filter").  The root node is named after the policy
+// and its children are the policy's inbound and outbound chains in every table
+// that has them.  Felix hashes chain names that would be too long for iptables,
+// so this only finds policies with short names.
+func (f *Felix) PolicyChainTree(policyName string) (*ChainNode, error) {
+	out, err := f.ExecOutput("iptables-save")
+	if err != nil {
+		return nil, err
+	}
+
+	type chain struct {
+		ipSets, targets []string
+	}
+	tables := map[string]map[string]*chain{}
+	var tableNames []string
+	var table string
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "*"):
+			table = line[1:]
+			tables[table] = map[string]*chain{}
+			tableNames = append(tableNames, table)
+		case strings.HasPrefix(line, ":"):
+			tables[table][strings.Fields(line[1:])[0]] = &chain{}
+		case strings.HasPrefix(line, "-A "):
+			fields := strings.Fields(line)
+			c := tables[table][fields[1]]
+			for i := 2; i < len(fields)-1; i++ {
+				switch fields[i] {
+				case "--match-set":
+					c.ipSets = appendIfMissing(c.ipSets, fields[i+1])
+				case "-j", "-g", "--jump", "--goto":
+					c.targets = appendIfMissing(c.targets, fields[i+1])
+				}
+			}
+		}
+	}
+
+	var build func(table, name string) *ChainNode
+	build = func(table, name string) *ChainNode {
+		c := tables[table][name]
+		node := &ChainNode{
+			Table:   table,
+			Name:    name,
+			IPSets:  c.ipSets,
+			Targets: c.targets,
+		}
+		for _, t := range c.targets {
+			if _, ok := tables[table][t]; ok && strings.HasPrefix(t, "cali") {
+				node.Children = append(node.Children, build(table, t))
+			}
+		}
+		return node
+	}
+
+	root := &ChainNode{Name: policyName}
+	for _, table := range tableNames {
+		for _, prefix := range []string{"cali-pi-", "cali-po-"} {
+			if _, ok := tables[table][prefix+policyName]; ok {
+				root.Children = append(root.Children, build(table, prefix+policyName))
+			}
+		}
+	}
+	if len(root.Children) == 0 {
+		return nil, fmt.Errorf("no chains found for policy %s", policyName)
+	}
+	return root, nil
+}
+
+// Find returns the first node in the tree with the given table and chain name,
+// or nil if there is no such node.
+func (n *ChainNode) Find(table, name string) *ChainNode {
+	if n.Table == table && n.Name == name {
+		return n
+	}
+	for _, c := range n.Children {
+		if found := c.Find(table, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func appendIfMissing(items []string, item string) []string {
+	for _, i := range items {
+		if i == item {
+			return items
+		}
+	}
+	return append(items, item)
+}
+
+// RawPreroutingTargets returns, in the order that a packet arriving on iface would reach
+// them, the chains that the raw table's PREROUTING chain jumps or goes to, following the
+// jumps through the cali- chains.  Rules that only apply to other interfaces are skipped
+// but other matches are ignored, so the list is a superset of the chains that any one
+// packet traverses.
+func (f *Felix) RawPreroutingTargets(iface string) ([]string, error) {
+	out, err := f.ExecOutput("iptables-save", "-t", "raw")
+	if err != nil {
+		return nil, err
+	}
+
+	chains := map[string][][]string{}
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, ":"):
+			chains[strings.Fields(line[1:])[0]] = nil
+		case strings.HasPrefix(line, "-A "):
+			fields := strings.Fields(line)
+			chains[fields[1]] = append(chains[fields[1]], fields[2:])
+		}
+	}
+	if _, ok := chains["PREROUTING"]; !ok {
+		return nil, fmt.Errorf("no PREROUTING chain in the raw table")
+	}
+
+	var targets []string
+	var walk func(chain string)
+	walk = func(chain string) {
+		for _, rule := range chains[chain] {
+			target := ""
+			appliesToIface := true
+			for i := 0; i < len(rule)-1; i++ {
+				switch rule[i] {
+				case "-i", "--in-interface":
+					negated := i > 0 && rule[i-1] == "!"
+					if ifaceMatches(rule[i+1], iface) == negated {
+						appliesToIface = false
+					}
+				case "-j", "-g", "--jump", "--goto":
+					target = rule[i+1]
+				}
+			}
+			if _, isChain := chains[target]; !appliesToIface || !isChain {
+				continue
+			}
+			targets = append(targets, target)
+			if strings.HasPrefix(target, "cali") {
+				walk(target)
+			}
+		}
+	}
+	walk("PREROUTING")
+	return targets, nil
+}
+
+// ifaceMatches returns whether iface matches an iptables interface name, which may end
+// in a "+" wildcard.
+func ifaceMatches(pattern, iface string) bool {
+	if strings.HasSuffix(pattern, "+") {
+		return strings.HasPrefix(iface, strings.TrimSuffix(pattern, "+"))
+	}
+	return pattern == iface
+}
+
 type BPFIfState struct {
 	IfIndex  int
 	Workload bool
@@ -302,3 +1329,66 @@ func (f *Felix) BPFIfState() map[string]BPFIfState {
 
 	return states
 }
+
+// ProcessedRevision returns the highest datastore revision that Felix has processed and passed
+// on to its dataplane.
+func (f *Felix) ProcessedRevision() (float64, error) {
+	return metrics.GetFelixMetricFloat(f.IP, "felix_calc_graph_processed_revision")
+}
+
+// inSyncSettleTime is how long the Felixes must keep agreeing on a revision before
+// WaitForAllInSync considers them in sync.  It covers the time that a datastore write takes
+// to reach the Felixes.
+const inSyncSettleTime = time.Second
+
+// WaitForAllInSync waits until all the given Felixes report the same processed datastore
+// revision and keep reporting it for inSyncSettleTime; that is, until they have all processed
+// the datastore writes that were made before the call.  Use it after changing policy or
+// network sets and before probing, so that a probe can't race with a Felix that hasn't yet
+// programmed the change.
+func WaitForAllInSync(felixes []*Felix, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	var agreed float64
+	var agreedSince time.Time
+	for {
+		revs, err := processedRevisions(felixes)
+		if err == nil && allEqual(revs) {
+			if agreedSince.IsZero() || revs[0] != agreed {
+				agreed = revs[0]
+				agreedSince = time.Now()
+			} else if time.Since(agreedSince) >= inSyncSettleTime {
+				return nil
+			}
+		} else {
+			agreedSince = time.Time{}
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("felixes weren't in sync after %v: %w", timeout, err)
+			}
+			return fmt.Errorf("felixes weren't in sync after %v: processed revisions %v", timeout, revs)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func processedRevisions(felixes []*Felix) ([]float64, error) {
+	revs := make([]float64, len(felixes))
+	for i, f := range felixes {
+		rev, err := f.ProcessedRevision()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get processed revision of %s: %w", f.Name, err)
+		}
+		revs[i] = rev
+	}
+	return revs, nil
+}
+
+func allEqual(revs []float64) bool {
+	for _, rev := range revs {
+		if rev != revs[0] {
+			return false
+		}
+	}
+	return true
+}
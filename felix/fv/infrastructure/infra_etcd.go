@@ -39,6 +39,8 @@ type EtcdDatastoreInfra struct {
 
 	Endpoint    string
 	BadEndpoint string
+
+	topologyRecorder
 }
 
 func createEtcdDatastoreInfra() DatastoreInfra {
@@ -180,10 +182,15 @@ func (eds *EtcdDatastoreInfra) AddNode(felix *Felix, idx int, needBGP bool) {
 		}
 		return err
 	}, "10s", "500ms").ShouldNot(HaveOccurred())
+	eds.recordNode(felix, idx)
 }
 
 func (eds *EtcdDatastoreInfra) AddWorkload(wep *libapi.WorkloadEndpoint) (*libapi.WorkloadEndpoint, error) {
-	return eds.GetCalicoClient().WorkloadEndpoints().Create(utils.Ctx, wep, utils.NoOptions)
+	wepOut, err := eds.GetCalicoClient().WorkloadEndpoints().Create(utils.Ctx, wep, utils.NoOptions)
+	if err == nil {
+		eds.recordWorkload(wep)
+	}
+	return wepOut, err
 }
 
 func (eds *EtcdDatastoreInfra) RemoveWorkload(ns string, name string) error {
@@ -207,6 +214,18 @@ func (eds *EtcdDatastoreInfra) AddAllowToDatastore(selector string) error {
 	return err
 }
 
+func (eds *EtcdDatastoreInfra) CreateIPPool(cidr string, opts IPPoolOptions) (*api.IPPool, error) {
+	return createIPPool(eds.GetCalicoClient(), cidr, opts)
+}
+
+func (eds *EtcdDatastoreInfra) DeleteIPPool(name string) error {
+	return deleteIPPool(eds.GetCalicoClient(), name)
+}
+
+func (eds *EtcdDatastoreInfra) AssignIPFromPool(felix *Felix, poolName string) (string, error) {
+	return assignIPFromPool(eds.GetCalicoClient(), felix, poolName)
+}
+
 func (eds *EtcdDatastoreInfra) AddDefaultAllow() string {
 	defaultProfile := api.NewProfile()
 	defaultProfile.Name = "default"
@@ -224,6 +243,7 @@ func (eds *EtcdDatastoreInfra) AddDefaultDeny() error {
 
 func (eds *EtcdDatastoreInfra) DumpErrorData() {
 	eds.etcdContainer.Exec("etcdctl", "get", "/", "--prefix", "--keys-only")
+	eds.dumpFelixBPFMaps()
 }
 
 func (eds *EtcdDatastoreInfra) Stop() {
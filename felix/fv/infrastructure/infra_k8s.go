@@ -34,6 +34,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
@@ -59,6 +60,8 @@ type K8sDatastoreInfra struct {
 
 	calicoClient client.Interface
 	K8sClient    *kubernetes.Clientset
+	// DynamicClient is for CRDs that K8sClient and calicoClient don't cover.
+	DynamicClient dynamic.Interface
 
 	Endpoint    string
 	EndpointIP  string
@@ -71,6 +74,8 @@ type K8sDatastoreInfra struct {
 	needsCleanup bool
 
 	runningTest string
+
+	topologyRecorder
 }
 
 var (
@@ -162,6 +167,8 @@ func GetK8sDatastoreInfra() (*K8sDatastoreInfra, error) {
 }
 
 func (kds *K8sDatastoreInfra) PerTestSetup() {
+	kds.resetTopology()
+
 	// In BPF mode, start BPF logging.
 	arch := utils.GetSysArch()
 
@@ -266,12 +273,16 @@ func setupK8sDatastoreInfra() (*K8sDatastoreInfra, error) {
 	start := time.Now()
 	for {
 		var err error
-		kds.K8sClient, err = kubernetes.NewForConfig(&rest.Config{
+		k8sConfig := &rest.Config{
 			Transport: insecureTransport,
 			Host:      "https://" + kds.k8sApiContainer.IP + ":6443",
 			QPS:       100,
 			Burst:     100,
-		})
+		}
+		kds.K8sClient, err = kubernetes.NewForConfig(k8sConfig)
+		if err == nil {
+			kds.DynamicClient, err = dynamic.NewForConfig(k8sConfig)
+		}
 		if err == nil {
 			break
 		}
@@ -502,6 +513,7 @@ func (kds *K8sDatastoreInfra) CleanUp() {
 		cleanupIPAM,
 		cleanupAllGlobalNetworkPolicies,
 		cleanupAllNetworkPolicies,
+		cleanupAllGlobalNetworkSets,
 		cleanupAllHostEndpoints,
 		cleanupAllFelixConfigurations,
 		cleanupAllServices,
@@ -662,6 +674,7 @@ func (kds *K8sDatastoreInfra) AddNode(felix *Felix, idx int, needBGP bool) {
 	if err != nil {
 		panic(err)
 	}
+	kds.recordNode(felix, idx)
 }
 
 func (kds *K8sDatastoreInfra) ensureNamespace(name string) {
@@ -741,6 +754,7 @@ func (kds *K8sDatastoreInfra) AddWorkload(wep *libapi.WorkloadEndpoint) (*libapi
 		panic(err)
 	}
 	log.WithField("podOut", podOut).Debug("Updated pod status")
+	kds.recordWorkload(wep)
 
 	wepid := names.WorkloadEndpointIdentifiers{
 		Node:         wep.Spec.Node,
@@ -773,6 +787,18 @@ func (kds *K8sDatastoreInfra) AddAllowToDatastore(selector string) error {
 	return err
 }
 
+func (kds *K8sDatastoreInfra) CreateIPPool(cidr string, opts IPPoolOptions) (*api.IPPool, error) {
+	return createIPPool(kds.calicoClient, cidr, opts)
+}
+
+func (kds *K8sDatastoreInfra) DeleteIPPool(name string) error {
+	return deleteIPPool(kds.calicoClient, name)
+}
+
+func (kds *K8sDatastoreInfra) AssignIPFromPool(felix *Felix, poolName string) (string, error) {
+	return assignIPFromPool(kds.calicoClient, felix, poolName)
+}
+
 func (kds *K8sDatastoreInfra) AddDefaultAllow() string {
 	return "kns.default"
 }
@@ -839,6 +865,7 @@ func (kds *K8sDatastoreInfra) DumpErrorData() {
 			log.Info(spew.Sdump(hep))
 		}
 	}
+	kds.dumpFelixBPFMaps()
 }
 
 var (
@@ -974,6 +1001,23 @@ func cleanupAllNetworkPolicies(clientset *kubernetes.Clientset, client client.In
 	log.Info("Cleaned up network policies")
 }
 
+func cleanupAllGlobalNetworkSets(clientset *kubernetes.Clientset, client client.Interface) {
+	log.Info("Cleaning up global network sets")
+	ctx := context.Background()
+	gnss, err := client.GlobalNetworkSets().List(ctx, options.ListOptions{})
+	if err != nil {
+		panic(err)
+	}
+	log.WithField("count", len(gnss.Items)).Info("GlobalNetworkSets present")
+	for _, gns := range gnss.Items {
+		_, err = client.GlobalNetworkSets().Delete(ctx, gns.Name, options.DeleteOptions{})
+		if err != nil {
+			panic(err)
+		}
+	}
+	log.Info("Cleaned up global network sets")
+}
+
 func cleanupAllHostEndpoints(clientset *kubernetes.Clientset, client client.Interface) {
 	log.Info("Cleaning up host endpoints")
 	ctx := context.Background()
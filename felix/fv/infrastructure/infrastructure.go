@@ -74,6 +74,9 @@ type DatastoreInfra interface {
 	AddWorkload(wep *libapi.WorkloadEndpoint) (*libapi.WorkloadEndpoint, error)
 	// RemoveWorkload reverses the effect of AddWorkload.
 	RemoveWorkload(ns string, name string) error
+	// Topology returns the addresses of the nodes and workloads that have been
+	// added so far.
+	Topology() Topology
 	// AddDefaultAllow will ensure that the datastore is configured so that
 	// the default profile/namespace will allow traffic. Returns the name of the
 	// default profile.
@@ -84,6 +87,15 @@ type DatastoreInfra interface {
 	// AddAllowToDatastore adds a policy to allow endpoints that match the given
 	// selector to reach the datastore.
 	AddAllowToDatastore(selector string) error
+	// CreateIPPool creates an IP pool with the given CIDR alongside the default
+	// one, so that a test can put workloads in more than one subnet.
+	CreateIPPool(cidr string, opts IPPoolOptions) (*api.IPPool, error)
+	// DeleteIPPool releases any addresses that AssignIPFromPool handed out
+	// from the named pool and then deletes the pool.
+	DeleteIPPool(name string) error
+	// AssignIPFromPool uses Calico IPAM to allocate an address from the named
+	// pool for a workload on the given Felix's node.
+	AssignIPFromPool(felix *Felix, poolName string) (string, error)
 
 	// DumpErrorData prints out extra information that may help when an error
 	// occurs.
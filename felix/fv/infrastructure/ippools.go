@@ -0,0 +1,125 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infrastructure
+
+import (
+	"fmt"
+	"strings"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	log "github.com/sirupsen/logrus"
+
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/ipam"
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+
+	"github.com/projectcalico/calico/felix/fv/utils"
+)
+
+// IPPoolOptions configures an extra IP pool created with DatastoreInfra.CreateIPPool.
+type IPPoolOptions struct {
+	// Name defaults to one derived from the pool's CIDR.
+	Name string
+	// BlockSize defaults to Calico's default block size for the pool's IP version.
+	BlockSize   int
+	IPIPMode    api.IPIPMode
+	VXLANMode   api.VXLANMode
+	NATOutgoing bool
+}
+
+// IPPoolName returns the name that CreateIPPool gives a pool with the given CIDR if no name is
+// specified.
+func IPPoolName(cidr string) string {
+	return "test-pool-" + strings.NewReplacer(".", "-", ":", "-", "/", "-").Replace(cidr)
+}
+
+func createIPPool(c client.Interface, cidr string, opts IPPoolOptions) (*api.IPPool, error) {
+	pool := api.NewIPPool()
+	pool.Name = opts.Name
+	if pool.Name == "" {
+		pool.Name = IPPoolName(cidr)
+	}
+	pool.Spec.CIDR = cidr
+	pool.Spec.BlockSize = opts.BlockSize
+	pool.Spec.IPIPMode = opts.IPIPMode
+	if pool.Spec.IPIPMode == "" {
+		pool.Spec.IPIPMode = api.IPIPModeNever
+	}
+	pool.Spec.VXLANMode = opts.VXLANMode
+	if pool.Spec.VXLANMode == "" {
+		pool.Spec.VXLANMode = api.VXLANModeNever
+	}
+	pool.Spec.NATOutgoing = opts.NATOutgoing
+	return c.IPPools().Create(utils.Ctx, pool, utils.NoOptions)
+}
+
+// ipPoolHandle is the IPAM handle that assignIPFromPool uses for all the addresses that it hands
+// out from a pool, so that deleteIPPool can release them in one go.
+func ipPoolHandle(poolName string) string {
+	return "felixfv." + poolName
+}
+
+func assignIPFromPool(c client.Interface, felix *Felix, poolName string) (string, error) {
+	pool, err := c.IPPools().Get(utils.Ctx, poolName, options.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	_, poolCIDR, err := cnet.ParseCIDR(pool.Spec.CIDR)
+	if err != nil {
+		return "", err
+	}
+
+	handle := ipPoolHandle(poolName)
+	args := ipam.AutoAssignArgs{
+		HandleID:    &handle,
+		Hostname:    felix.Hostname,
+		IntendedUse: api.IPPoolAllowedUseWorkload,
+	}
+	if poolCIDR.Version() == 4 {
+		args.Num4 = 1
+		args.IPv4Pools = []cnet.IPNet{*poolCIDR}
+	} else {
+		args.Num6 = 1
+		args.IPv6Pools = []cnet.IPNet{*poolCIDR}
+	}
+	v4, v6, err := c.IPAM().AutoAssign(utils.Ctx, args)
+	if err != nil {
+		return "", err
+	}
+	assigned := v4
+	if poolCIDR.Version() == 6 {
+		assigned = v6
+	}
+	if assigned == nil || len(assigned.IPs) == 0 {
+		return "", fmt.Errorf("no addresses left in IP pool %s", poolName)
+	}
+	log.WithFields(log.Fields{
+		"pool": poolName,
+		"node": felix.Hostname,
+		"ip":   assigned.IPs[0].IP,
+	}).Info("Assigned workload IP from pool")
+	return assigned.IPs[0].IP.String(), nil
+}
+
+func deleteIPPool(c client.Interface, name string) error {
+	err := c.IPAM().ReleaseByHandle(utils.Ctx, ipPoolHandle(name))
+	if _, ok := err.(cerrors.ErrorResourceDoesNotExist); err != nil && !ok {
+		return err
+	}
+	_, err = c.IPPools().Delete(utils.Ctx, name, options.DeleteOptions{})
+	return err
+}
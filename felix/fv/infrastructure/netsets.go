@@ -0,0 +1,60 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infrastructure
+
+import (
+	"fmt"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+
+	"github.com/projectcalico/calico/felix/fv/utils"
+)
+
+// maxNetSetBase is the first address of the /32s that CreateMaxGlobalNetworkSet puts in a
+// network set; 10.128.0.0/9 doesn't overlap the addresses that the FV tests use.
+const maxNetSetBase = 10<<24 | 128<<16
+
+// CreateMaxGlobalNetworkSet creates a GlobalNetworkSet, with the given name and labels, that
+// holds targetEntries distinct /32s, and returns them.  If targetEntries is zero it defaults
+// to bpf.CIDRMapMaxEntries, the capacity of an XDP blocklist map, which is the practical limit
+// on the size of a set that's enforced by XDP.
+func CreateMaxGlobalNetworkSet(c client.Interface, name string, targetEntries int, labels map[string]string) ([]string, error) {
+	if targetEntries == 0 {
+		targetEntries = bpf.CIDRMapMaxEntries
+	}
+	if targetEntries < 0 || targetEntries > 1<<23 {
+		return nil, fmt.Errorf("can't create a network set with %d entries", targetEntries)
+	}
+
+	nets := make([]string, targetEntries)
+	for i := range nets {
+		addr := maxNetSetBase + i
+		nets[i] = fmt.Sprintf("%d.%d.%d.%d/32", addr>>24, addr>>16&0xff, addr>>8&0xff, addr&0xff)
+	}
+
+	netSet := api.NewGlobalNetworkSet()
+	netSet.Name = name
+	netSet.Labels = labels
+	netSet.Spec.Nets = nets
+	log.WithFields(log.Fields{"name": name, "entries": targetEntries}).Info("Creating large GlobalNetworkSet")
+	if _, err := c.GlobalNetworkSets().Create(utils.Ctx, netSet, utils.NoOptions); err != nil {
+		return nil, fmt.Errorf("failed to create GlobalNetworkSet %s with %d entries: %w", name, targetEntries, err)
+	}
+	return nets, nil
+}
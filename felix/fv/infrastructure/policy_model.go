@@ -0,0 +1,479 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infrastructure
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/gomega"
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/api/pkg/lib/numorstring"
+
+	"github.com/projectcalico/calico/felix/fv/connectivity"
+	"github.com/projectcalico/calico/felix/fv/utils"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+	"github.com/projectcalico/calico/libcalico-go/lib/selector"
+)
+
+// PolicyTuple is one path for AssertMatchesPolicyModel to check: connections from From to
+// Port on To over Protocol, or over the checker's protocol if Protocol is empty.
+type PolicyTuple struct {
+	From     connectivity.ConnectionSource
+	To       connectivity.ConnectionTarget
+	Protocol string
+	Port     uint16
+}
+
+// PolicyModel is a small evaluator of Calico policy, for working out what the dataplane
+// should do with a connection independently of Felix.  It's deliberately simple:
+//
+//   - Only GlobalNetworkPolicies and profiles are modelled, and only their ingress rules, at
+//     the endpoint that owns the destination IP.  The sources' egress should be open, as it
+//     is with the default-allow profile.
+//   - Endpoints are the host endpoints, with their expected IPs, and the workload endpoints.
+//     A destination that no endpoint owns is always reachable.
+//   - Untracked policy is evaluated first, for host endpoints only; connections that it
+//     doesn't allow or deny go on to the normal policy.  Pre-DNAT policy is ignored.
+//   - A rule that uses anything else, such as named ports, source ports, ICMP matches or
+//     the Pass action, is an error rather than being guessed at.
+//
+// Felix's failsafe ports are open whatever the policy says, so tuples shouldn't use them.
+type PolicyModel struct {
+	// policies are in the order that they apply.
+	policies  []api.GlobalNetworkPolicy
+	profiles  map[string]*api.Profile
+	endpoints []modelEndpoint
+}
+
+// modelEndpoint is anything that a selector can match: an endpoint or a network set.
+type modelEndpoint struct {
+	name     string
+	labels   map[string]string
+	nets     []*net.IPNet
+	profiles []string
+	// isSet is true for a network set, which can match a source but doesn't own addresses.
+	isSet bool
+	// isHost is true for a host endpoint, the only kind of endpoint with untracked policy.
+	isHost bool
+}
+
+// modelFlow is a connection that the model is asked about.
+type modelFlow struct {
+	src, dst net.IP
+	srcEPs   []modelEndpoint
+	dstEP    modelEndpoint
+	protocol uint8
+	port     uint16
+}
+
+// LoadPolicyModel reads the policies, profiles, endpoints and network sets from the datastore.
+func LoadPolicyModel(c client.Interface) (*PolicyModel, error) {
+	m := &PolicyModel{profiles: map[string]*api.Profile{}}
+
+	gnps, err := c.GlobalNetworkPolicies().List(utils.Ctx, options.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	m.policies = gnps.Items
+	sort.Slice(m.policies, func(i, j int) bool {
+		oi, oj := m.policies[i].Spec.Order, m.policies[j].Spec.Order
+		if (oi == nil) != (oj == nil) {
+			// Policies without an order come last.
+			return oj == nil
+		}
+		if oi != nil && *oi != *oj {
+			return *oi < *oj
+		}
+		return m.policies[i].Name < m.policies[j].Name
+	})
+
+	profiles, err := c.Profiles().List(utils.Ctx, options.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range profiles.Items {
+		m.profiles[profiles.Items[i].Name] = &profiles.Items[i]
+	}
+
+	heps, err := c.HostEndpoints().List(utils.Ctx, options.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, hep := range heps.Items {
+		ep, err := m.newModelEndpoint("hep:"+hep.Name, hep.Labels, hep.Spec.ExpectedIPs, hep.Spec.Profiles)
+		if err != nil {
+			return nil, err
+		}
+		ep.isHost = true
+		m.endpoints = append(m.endpoints, ep)
+	}
+
+	weps, err := c.WorkloadEndpoints().List(utils.Ctx, options.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, wep := range weps.Items {
+		ep, err := m.newModelEndpoint("wep:"+wep.Name, wep.Labels, wep.Spec.IPNetworks, wep.Spec.Profiles)
+		if err != nil {
+			return nil, err
+		}
+		m.endpoints = append(m.endpoints, ep)
+	}
+
+	sets, err := c.GlobalNetworkSets().List(utils.Ctx, options.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, set := range sets.Items {
+		ep, err := m.newModelEndpoint("set:"+set.Name, set.Labels, set.Spec.Nets, nil)
+		if err != nil {
+			return nil, err
+		}
+		ep.isSet = true
+		m.endpoints = append(m.endpoints, ep)
+	}
+
+	return m, nil
+}
+
+// newModelEndpoint returns an endpoint with its own labels on top of those that its profiles
+// apply.
+func (m *PolicyModel) newModelEndpoint(name string, labels map[string]string, cidrs, profiles []string) (modelEndpoint, error) {
+	ep := modelEndpoint{name: name, labels: map[string]string{}, profiles: profiles}
+	for _, p := range profiles {
+		if prof, ok := m.profiles[p]; ok {
+			for k, v := range prof.Spec.LabelsToApply {
+				ep.labels[k] = v
+			}
+		}
+	}
+	for k, v := range labels {
+		ep.labels[k] = v
+	}
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			cidr += "/32"
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return ep, fmt.Errorf("bad address %q on %s: %w", cidr, name, err)
+		}
+		ep.nets = append(ep.nets, ipNet)
+	}
+	return ep, nil
+}
+
+func (ep modelEndpoint) contains(ip net.IP) bool {
+	for _, n := range ep.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Verdict returns whether the model allows a connection from src to port on dst over the
+// given protocol.
+func (m *PolicyModel) Verdict(src, dst net.IP, protocol string, port uint16) (bool, error) {
+	proto, err := protocolNumber(protocol)
+	if err != nil {
+		return false, err
+	}
+	f := modelFlow{src: src, dst: dst, protocol: proto, port: port}
+	foundDst := false
+	for _, ep := range m.endpoints {
+		if ep.contains(src) {
+			f.srcEPs = append(f.srcEPs, ep)
+		}
+		if !foundDst && !ep.isSet && ep.contains(dst) {
+			f.dstEP = ep
+			foundDst = true
+		}
+	}
+	if !foundDst {
+		return true, nil
+	}
+
+	if f.dstEP.isHost {
+		allowed, decided, err := m.evalPolicies(f, true)
+		if err != nil || decided {
+			return allowed, err
+		}
+	}
+	allowed, decided, err := m.evalPolicies(f, false)
+	if err != nil || decided {
+		return allowed, err
+	}
+	for _, name := range f.dstEP.profiles {
+		prof, ok := m.profiles[name]
+		if !ok {
+			continue
+		}
+		allowed, decided, err := evalRules(prof.Spec.Ingress, f)
+		if err != nil || decided {
+			return allowed, err
+		}
+	}
+	return false, nil
+}
+
+// evalPolicies evaluates the untracked or the normal policies that apply to the destination.
+// If any normal policy applies then the verdict is decided, by default as a deny.
+func (m *PolicyModel) evalPolicies(f modelFlow, untracked bool) (allowed, decided bool, err error) {
+	applied := false
+	for _, p := range m.policies {
+		if p.Spec.DoNotTrack != untracked || p.Spec.PreDNAT {
+			continue
+		}
+		if !appliesToIngress(p.Spec.Types) {
+			continue
+		}
+		sel := p.Spec.Selector
+		if sel == "" {
+			sel = "all()"
+		}
+		match, err := selectorMatches(sel, []modelEndpoint{f.dstEP})
+		if err != nil {
+			return false, false, fmt.Errorf("policy %s: %w", p.Name, err)
+		}
+		if !match {
+			continue
+		}
+		applied = true
+		allowed, decided, err := evalRules(p.Spec.Ingress, f)
+		if err != nil {
+			return false, false, fmt.Errorf("policy %s: %w", p.Name, err)
+		}
+		if decided {
+			return allowed, true, nil
+		}
+	}
+	return false, applied && !untracked, nil
+}
+
+func appliesToIngress(types []api.PolicyType) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if t == api.PolicyTypeIngress {
+			return true
+		}
+	}
+	return false
+}
+
+// evalRules returns the verdict of the first rule that matches the flow, if any.
+func evalRules(rules []api.Rule, f modelFlow) (allowed, decided bool, err error) {
+	for i, r := range rules {
+		match, err := ruleMatches(r, f)
+		if err != nil {
+			return false, false, fmt.Errorf("rule %d: %w", i, err)
+		}
+		if !match {
+			continue
+		}
+		switch r.Action {
+		case api.Allow:
+			return true, true, nil
+		case api.Deny:
+			return false, true, nil
+		case api.Log:
+			continue
+		default:
+			return false, false, fmt.Errorf("rule %d: action %q isn't modelled", i, r.Action)
+		}
+	}
+	return false, false, nil
+}
+
+func ruleMatches(r api.Rule, f modelFlow) (bool, error) {
+	if r.HTTP != nil || r.ICMP != nil || r.NotICMP != nil ||
+		len(r.Source.Ports) > 0 || len(r.Source.NotPorts) > 0 ||
+		r.Source.ServiceAccounts != nil || r.Destination.ServiceAccounts != nil ||
+		r.Source.Services != nil || r.Destination.Services != nil ||
+		r.Source.NamespaceSelector != "" || r.Destination.NamespaceSelector != "" {
+		return false, fmt.Errorf("the rule uses a match that isn't modelled")
+	}
+	if r.IPVersion != nil && *r.IPVersion != 4 {
+		return false, nil
+	}
+	if r.Protocol != nil {
+		if p, err := ruleProtocolNumber(*r.Protocol); err != nil || p != f.protocol {
+			return false, err
+		}
+	}
+	if r.NotProtocol != nil {
+		if p, err := ruleProtocolNumber(*r.NotProtocol); err != nil || p == f.protocol {
+			return false, err
+		}
+	}
+	if match, err := entityMatches(r.Source, f.src, f.srcEPs); err != nil || !match {
+		return false, err
+	}
+	if match, err := entityMatches(r.Destination, f.dst, []modelEndpoint{f.dstEP}); err != nil || !match {
+		return false, err
+	}
+	if len(r.Destination.Ports) > 0 {
+		if match, err := portsMatch(r.Destination.Ports, f.port); err != nil || !match {
+			return false, err
+		}
+	}
+	if match, err := portsMatch(r.Destination.NotPorts, f.port); err != nil || match {
+		return false, err
+	}
+	return true, nil
+}
+
+// entityMatches returns whether an IP, and the endpoints and network sets that it belongs to,
+// match one side of a rule.
+func entityMatches(e api.EntityRule, ip net.IP, eps []modelEndpoint) (bool, error) {
+	if len(e.Nets) > 0 {
+		if match, err := netsContain(e.Nets, ip); err != nil || !match {
+			return false, err
+		}
+	}
+	if match, err := netsContain(e.NotNets, ip); err != nil || match {
+		return false, err
+	}
+	if e.Selector != "" {
+		if match, err := selectorMatches(e.Selector, eps); err != nil || !match {
+			return false, err
+		}
+	}
+	if e.NotSelector != "" {
+		if match, err := selectorMatches(e.NotSelector, eps); err != nil || match {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func netsContain(cidrs []string, ip net.IP) (bool, error) {
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			cidr += "/32"
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return false, err
+		}
+		if ipNet.Contains(ip) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func selectorMatches(sel string, eps []modelEndpoint) (bool, error) {
+	s, err := selector.Parse(sel)
+	if err != nil {
+		return false, fmt.Errorf("bad selector %q: %w", sel, err)
+	}
+	for _, ep := range eps {
+		if s.Evaluate(ep.labels) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func portsMatch(ports []numorstring.Port, port uint16) (bool, error) {
+	for _, p := range ports {
+		if p.PortName != "" {
+			return false, fmt.Errorf("named port %q isn't modelled", p.PortName)
+		}
+		if port >= p.MinPort && port <= p.MaxPort {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func ruleProtocolNumber(p numorstring.Protocol) (uint8, error) {
+	if p.Type == numorstring.NumOrStringNum {
+		return p.NumVal, nil
+	}
+	return protocolNumber(p.StrVal)
+}
+
+func protocolNumber(name string) (uint8, error) {
+	switch strings.ToLower(name) {
+	case "", "tcp":
+		return 6, nil
+	case "udp", "udp-noconn", "udp-recvmsg":
+		return 17, nil
+	case "sctp":
+		return 132, nil
+	case "icmp":
+		return 1, nil
+	}
+	n, err := strconv.ParseUint(name, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("unknown protocol %q", name)
+	}
+	return uint8(n), nil
+}
+
+// AssertMatchesPolicyModel works out, with a PolicyModel of the policy in the datastore,
+// whether each tuple should connect, and then checks that the dataplane agrees.  The tuples
+// are checked in a batch per protocol.  The datastore should be settled, and Felix in sync
+// with it, before calling this.
+func AssertMatchesPolicyModel(c client.Interface, cc *connectivity.Checker, tuples []PolicyTuple) {
+	model, err := LoadPolicyModel(c)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+
+	var protocols []string
+	byProtocol := map[string][]PolicyTuple{}
+	for _, t := range tuples {
+		p := t.Protocol
+		if p == "" {
+			p = cc.Protocol
+		}
+		if _, ok := byProtocol[p]; !ok {
+			protocols = append(protocols, p)
+		}
+		byProtocol[p] = append(byProtocol[p], t)
+	}
+
+	savedProtocol := cc.Protocol
+	defer func() {
+		cc.Protocol = savedProtocol
+		cc.ResetExpectations()
+	}()
+	for _, p := range protocols {
+		cc.ResetExpectations()
+		cc.Protocol = p
+		for _, t := range byProtocol[p] {
+			srcIPs := t.From.SourceIPs()
+			ExpectWithOffset(1, srcIPs).NotTo(BeEmpty(), "%s has no IP to model", t.From.SourceName())
+			dstIP := t.To.ToMatcher(t.Port).IP
+			allowed, err := model.Verdict(net.ParseIP(srcIPs[0]), net.ParseIP(dstIP), p, t.Port)
+			ExpectWithOffset(1, err).NotTo(HaveOccurred(),
+				"can't model %s -> %s:%d over %s", t.From.SourceName(), dstIP, t.Port, p)
+			if allowed {
+				cc.ExpectSome(t.From, t.To, t.Port)
+			} else {
+				cc.ExpectNone(t.From, t.To, t.Port)
+			}
+		}
+		cc.CheckConnectivityOffset(1)
+	}
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/projectcalico/calico/libcalico-go/lib/options"
 	"github.com/projectcalico/calico/libcalico-go/lib/resources"
 
+	"github.com/projectcalico/calico/felix/fv/connectivity"
 	"github.com/projectcalico/calico/felix/fv/containers"
 )
 
@@ -63,6 +64,14 @@ type TopologyOptions struct {
 	ExternalIPs               bool
 	UseIPPools                bool
 	NeedNodeIP                bool
+	// FelixCapabilities, if set, runs the Felix containers with only these capabilities,
+	// such as "NET_ADMIN", instead of privileged.
+	FelixCapabilities []string
+	// SameHostPairs, if set, makes each pair of nodes share a host: StartNNodeTopology starts
+	// one Felix for nodes 0 and 1, one for nodes 2 and 3, and so on, and returns each Felix at
+	// both of its indexes.  A test that puts its client on one node and its server on the
+	// next then covers the path between two workloads on the same host.
+	SameHostPairs bool
 }
 
 func DefaultTopologyOptions() TopologyOptions {
@@ -193,6 +202,11 @@ func StartNNodeTopology(n int, opts TopologyOptions, infra DatastoreInfra) (feli
 		opts.VXLANMode = api.VXLANModeNever
 	}
 
+	numHosts := n
+	if opts.SameHostPairs {
+		numHosts = (n + 1) / 2
+	}
+
 	// Get client.
 	client = infra.GetCalicoClient()
 	mustInitDatastore(client)
@@ -238,21 +252,22 @@ func StartNNodeTopology(n int, opts TopologyOptions, infra DatastoreInfra) (feli
 	}
 
 	typhaIP := ""
+	var typha *Typha
 	if opts.WithTypha {
-		typha := RunTypha(infra, opts)
+		typha = RunTypha(infra, opts)
 		opts.ExtraEnvVars["FELIX_TYPHAADDR"] = typha.IP + ":5473"
 		typhaIP = typha.IP
 	}
 
-	felixes = make([]*Felix, n)
+	felixes = make([]*Felix, numHosts)
 	var wg sync.WaitGroup
 
 	// Make a separate copy of TopologyOptions for each Felix that we will run.  This
 	// is because we need to modify ExtraEnvVars for some of them.  If we kept using
 	// the same copy, while starting Felixes, we could hit a concurrent map read/write
 	// problem.
-	optsPerFelix := make([]TopologyOptions, n)
-	for i := 0; i < n; i++ {
+	optsPerFelix := make([]TopologyOptions, numHosts)
+	for i := 0; i < numHosts; i++ {
 		optsPerFelix[i] = opts
 		optsPerFelix[i].ExtraEnvVars = map[string]string{}
 		for k, v := range opts.ExtraEnvVars {
@@ -272,7 +287,7 @@ func StartNNodeTopology(n int, opts TopologyOptions, infra DatastoreInfra) (feli
 	}
 
 	// Now start the Felixes.
-	for i := 0; i < n; i++ {
+	for i := 0; i < numHosts; i++ {
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
@@ -282,10 +297,11 @@ func StartNNodeTopology(n int, opts TopologyOptions, infra DatastoreInfra) (feli
 	}
 	wg.Wait()
 
-	for i := 0; i < n; i++ {
+	for i := 0; i < numHosts; i++ {
 		opts.ExtraEnvVars["BPF_LOG_PFX"] = ""
 		felix := felixes[i]
 		felix.TyphaIP = typhaIP
+		felix.Typha = typha
 
 		if opts.EnableIPv6 {
 			Expect(felix.IPv6).ToNot(BeEmpty(), "IPv6 enabled but Felix didn't get an IPv6 address, is docker configured for IPv6?")
@@ -406,10 +422,43 @@ func StartNNodeTopology(n int, opts TopologyOptions, infra DatastoreInfra) (feli
 		}
 	}
 	wg.Wait()
+
+	if opts.SameHostPairs {
+		hosts := felixes
+		felixes = make([]*Felix, n)
+		for i := range felixes {
+			felixes[i] = hosts[i/2]
+		}
+	}
 	success = true
 	return
 }
 
+// AssertDefaultDeny checks the baseline that tests of host endpoint policy rely on:
+// before any policy is applied, no host can reach the workloads on the other
+// hosts.  workloads[i] must be running on felixes[i].  Each workload is checked on
+// each of the given ports, or on its default port if none are given.  Call it
+// straight after starting the topology to catch a setup that is accidentally
+// permissive.
+func AssertDefaultDeny(cc *connectivity.Checker, felixes []*Felix, workloads []connectivity.ConnectionTarget, ports ...uint16) {
+	ExpectWithOffset(1, workloads).To(HaveLen(len(felixes)), "AssertDefaultDeny needs one workload per Felix")
+	for i, felix := range felixes {
+		for j, w := range workloads {
+			if i == j {
+				continue
+			}
+			if len(ports) == 0 {
+				cc.ExpectNone(felix, w)
+			}
+			for _, port := range ports {
+				cc.ExpectNone(felix, w, port)
+			}
+		}
+	}
+	cc.CheckConnectivityOffset(1)
+	cc.ResetExpectations()
+}
+
 func mustInitDatastore(client client.Interface) {
 	Eventually(func() error {
 		log.Info("Initializing the datastore...")
@@ -0,0 +1,170 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infrastructure
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	libapi "github.com/projectcalico/calico/libcalico-go/lib/apis/v3"
+)
+
+// Topology describes the addresses that the infrastructure has handed out to the nodes and
+// workloads of the current test.  It saves tests from re-deriving the per-node CIDRs and from
+// reaching into each Felix for its IPs.
+type Topology struct {
+	IPPoolCIDR   string
+	IPv6PoolCIDR string
+	// Nodes is indexed by the idx that was passed to AddNode.
+	Nodes []TopologyNode
+}
+
+// TopologyNode holds the addresses of a single node.
+type TopologyNode struct {
+	Name string
+	IP   string
+	IPv6 string
+	// IPIPTunnelIP and VXLANTunnelIP are empty if the node isn't expected to get that tunnel.
+	IPIPTunnelIP  string
+	VXLANTunnelIP string
+	// WorkloadCIDR and WorkloadCIDRV6 are the blocks that the node's workloads are routed to.
+	WorkloadCIDR   string
+	WorkloadCIDRV6 string
+	// WorkloadIPs are the IPs of the workloads that have been added to the datastore on this
+	// node, in the order they were added.
+	WorkloadIPs []string
+}
+
+// OtherNodes returns every node apart from the i'th.
+func (t Topology) OtherNodes(i int) []TopologyNode {
+	var others []TopologyNode
+	for j, n := range t.Nodes {
+		if j != i {
+			others = append(others, n)
+		}
+	}
+	return others
+}
+
+// NodeIPs returns the IPv4 address of each node.
+func (t Topology) NodeIPs() []string {
+	ips := make([]string, len(t.Nodes))
+	for i, n := range t.Nodes {
+		ips[i] = n.IP
+	}
+	return ips
+}
+
+// WorkloadIPs returns the IPs of all the workloads in the topology.
+func (t Topology) WorkloadIPs() []string {
+	var ips []string
+	for _, n := range t.Nodes {
+		ips = append(ips, n.WorkloadIPs...)
+	}
+	return ips
+}
+
+// NodeByName returns the node with the given name, if it's in the topology.
+func (t Topology) NodeByName(name string) (TopologyNode, bool) {
+	for _, n := range t.Nodes {
+		if n.Name == name {
+			return n, true
+		}
+	}
+	return TopologyNode{}, false
+}
+
+// topologyRecorder is embedded in each DatastoreInfra to collect the Topology as nodes and
+// workloads are added.
+type topologyRecorder struct {
+	lock     sync.Mutex
+	topology Topology
+	felixes  []*Felix
+}
+
+// Topology returns a snapshot of the addresses handed out so far.
+func (r *topologyRecorder) Topology() Topology {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	t := Topology{
+		IPPoolCIDR:   DefaultIPPoolCIDR,
+		IPv6PoolCIDR: DefaultIPv6PoolCIDR,
+		Nodes:        make([]TopologyNode, len(r.topology.Nodes)),
+	}
+	for i, n := range r.topology.Nodes {
+		n.WorkloadIPs = append([]string(nil), n.WorkloadIPs...)
+		t.Nodes[i] = n
+	}
+	return t
+}
+
+func (r *topologyRecorder) recordNode(felix *Felix, idx int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for len(r.topology.Nodes) <= idx {
+		r.topology.Nodes = append(r.topology.Nodes, TopologyNode{})
+	}
+	r.felixes = append(r.felixes, felix)
+	r.topology.Nodes[idx] = TopologyNode{
+		Name:           felix.Hostname,
+		IP:             felix.IP,
+		IPv6:           felix.IPv6,
+		IPIPTunnelIP:   felix.ExpectedIPIPTunnelAddr,
+		VXLANTunnelIP:  felix.ExpectedVXLANTunnelAddr,
+		WorkloadCIDR:   fmt.Sprintf("10.65.%d.0/24", idx),
+		WorkloadCIDRV6: fmt.Sprintf("dead:beef::100:%d:0/96", idx),
+	}
+}
+
+func (r *topologyRecorder) recordWorkload(wep *libapi.WorkloadEndpoint) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i := range r.topology.Nodes {
+		n := &r.topology.Nodes[i]
+		if n.Name != wep.Spec.Node {
+			continue
+		}
+		for _, ipNet := range wep.Spec.IPNetworks {
+			n.WorkloadIPs = append(n.WorkloadIPs, strings.Split(ipNet, "/")[0])
+		}
+		return
+	}
+	log.WithField("node", wep.Spec.Node).Debug("Workload on a node that isn't in the topology")
+}
+
+func (r *topologyRecorder) resetTopology() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.topology = Topology{}
+	r.felixes = nil
+}
+
+// dumpFelixBPFMaps dumps the BPF maps of each Felix that has been added as a node.
+func (r *topologyRecorder) dumpFelixBPFMaps() {
+	r.lock.Lock()
+	felixes := append([]*Felix(nil), r.felixes...)
+	r.lock.Unlock()
+
+	for _, felix := range felixes {
+		felix.DumpBPFMaps()
+	}
+}
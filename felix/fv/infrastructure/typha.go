@@ -20,6 +20,7 @@ import (
 	"os/exec"
 	"path/filepath"
 
+	. "github.com/onsi/gomega"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/projectcalico/calico/typha/pkg/tlsutils"
@@ -40,6 +41,16 @@ func (f *Typha) GetTyphaPIDs() []int {
 	return f.GetPIDs("calico-typha")
 }
 
+// Restart restarts the Typha container in place, so that it keeps its IP.  The Felixes
+// that use it lose their connections and have to resync from the new Typha process.
+// Typha's logs from after the restart aren't captured.
+func (f *Typha) Restart() {
+	oldPID := f.GetTyphaPID()
+	utils.Run("docker", "restart", f.Name)
+	Eventually(f.GetTyphaPID, "20s", "200ms").ShouldNot(Equal(oldPID))
+	Expect(f.GetIP()).To(Equal(f.IP), "Typha came back with a different IP")
+}
+
 func RunTypha(infra DatastoreInfra, options TopologyOptions) *Typha {
 	log.Info("Starting typha")
 
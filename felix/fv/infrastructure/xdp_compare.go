@@ -0,0 +1,168 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infrastructure
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	. "github.com/onsi/gomega"
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/fv/connectivity"
+	"github.com/projectcalico/calico/felix/fv/utils"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+)
+
+// EnforcementMatrix describes the paths that CompareXDPvsIPTables probes on each of the
+// Felixes that it compares.
+type EnforcementMatrix struct {
+	// Policy is the policy under test.  CompareXDPvsIPTables makes it untracked and creates
+	// it; its selector should pick out a host endpoint on each of the Felixes.
+	Policy *api.GlobalNetworkPolicy
+	// Sources are the sources to probe each Felix from.
+	Sources []connectivity.ConnectionSource
+	// Ports are the ports, on each Felix's own IP, to probe.  Something must be listening on
+	// them on both Felixes.
+	Ports []uint16
+}
+
+// CompareXDPvsIPTables checks that an untracked policy has the same effect whether Felix
+// enforces it with XDP or with iptables.  felixXDP must have XDP enabled and felixNoXDP
+// must have it disabled; both must have their host endpoints on eth0.  It creates the
+// matrix's policy, waits for both Felixes to program it, checks that only felixXDP attached
+// an XDP program and then probes every path of the matrix on each Felix, expecting the
+// same outcomes.  It returns the agreed outcomes, one line per path, so that the caller can
+// check that the policy did what it should.  Not available in BPF mode, which always uses XDP.
+func CompareXDPvsIPTables(
+	c client.Interface,
+	felixXDP, felixNoXDP *Felix,
+	cc *connectivity.Checker,
+	matrix EnforcementMatrix,
+) []string {
+	createUntrackedPolicy(c, matrix)
+
+	ExpectWithOffset(1, WaitForAllInSync([]*Felix{felixXDP, felixNoXDP}, 20*time.Second)).To(Succeed())
+	EventuallyWithOffset(1, felixXDP.XDPAttachedInterfaces, "10s", "1s").Should(ConsistOf("eth0"),
+		"%s didn't accelerate the policy with XDP", felixXDP.Name)
+	ExpectWithOffset(1, felixNoXDP.XDPAttachedInterfaces()).To(BeEmpty(),
+		"%s used XDP even though it's disabled", felixNoXDP.Name)
+
+	// Both Felixes are in sync, so the outcomes should agree straight away; retry briefly
+	// in case one of the probes is unlucky.
+	var xdpOutcomes []string
+	EventuallyWithOffset(1, func() error {
+		xdpOutcomes = probeEnforcementMatrix(cc, felixXDP, matrix)
+		noXDPOutcomes := probeEnforcementMatrix(cc, felixNoXDP, matrix)
+		if !reflect.DeepEqual(xdpOutcomes, noXDPOutcomes) {
+			return fmt.Errorf("XDP on %s and iptables on %s enforced the policy differently:\n"+
+				"XDP:\n    %s\niptables:\n    %s",
+				felixXDP.Name, felixNoXDP.Name,
+				strings.Join(xdpOutcomes, "\n    "), strings.Join(noXDPOutcomes, "\n    "))
+		}
+		return nil
+	}, "20s", "1s").Should(Succeed())
+
+	log.WithField("outcomes", xdpOutcomes).Info("XDP and iptables enforced the policy the same way.")
+	return xdpOutcomes
+}
+
+// ExpectSameUnderFallback checks that, when Felix falls back from XDP to iptables because it
+// can't load the XDP program, iptables enforces an untracked policy exactly as XDP did.  felix
+// must have XDP enabled and its host endpoint on eth0.  It creates the matrix's policy and
+// probes every path while XDP enforces it, then restarts felix with
+// DebugSimulateXDPLoadFailure, which replaces any environment set with SetEvn, waits for it to
+// remove its XDP program and probes again, expecting the same outcomes.  felix keeps falling
+// back until it's restarted without the setting.  It returns the agreed outcomes, as
+// CompareXDPvsIPTables does.  Not available in BPF mode, which has no iptables fallback.
+func ExpectSameUnderFallback(
+	c client.Interface,
+	felix *Felix,
+	cc *connectivity.Checker,
+	matrix EnforcementMatrix,
+) []string {
+	createUntrackedPolicy(c, matrix)
+
+	ExpectWithOffset(1, WaitForAllInSync([]*Felix{felix}, 20*time.Second)).To(Succeed())
+	EventuallyWithOffset(1, felix.XDPAttachedInterfaces, "10s", "1s").Should(ConsistOf("eth0"),
+		"%s didn't accelerate the policy with XDP", felix.Name)
+	// Wait for two probes in a row to agree, so that the XDP program has the whole policy
+	// before we record what it does.
+	var xdpOutcomes []string
+	EventuallyWithOffset(1, func() error {
+		outcomes := probeEnforcementMatrix(cc, felix, matrix)
+		if !reflect.DeepEqual(outcomes, xdpOutcomes) {
+			xdpOutcomes = outcomes
+			return fmt.Errorf("outcomes changed to %v", outcomes)
+		}
+		return nil
+	}, "20s", "1s").Should(Succeed())
+
+	felix.SetEvn(map[string]string{"FELIX_DEBUGSIMULATEXDPLOADFAILURE": "true"})
+	felix.Restart()
+	EventuallyWithOffset(1, felix.XDPAttachedInterfaces, "20s", "1s").Should(BeEmpty(),
+		"%s didn't fall back to iptables", felix.Name)
+
+	// Retry briefly in case one of the probes is unlucky.
+	var fallbackOutcomes []string
+	EventuallyWithOffset(1, func() error {
+		fallbackOutcomes = probeEnforcementMatrix(cc, felix, matrix)
+		if !reflect.DeepEqual(xdpOutcomes, fallbackOutcomes) {
+			return fmt.Errorf("%s enforced the policy differently after falling back to iptables:\n"+
+				"XDP:\n    %s\niptables fallback:\n    %s",
+				felix.Name, strings.Join(xdpOutcomes, "\n    "), strings.Join(fallbackOutcomes, "\n    "))
+		}
+		return nil
+	}, "20s", "1s").Should(Succeed())
+	ConsistentlyWithOffset(1, felix.XDPAttachedInterfaces, "3s", "1s").Should(BeEmpty(),
+		"%s reattached its XDP program after falling back", felix.Name)
+
+	log.WithField("outcomes", xdpOutcomes).Info("iptables fallback enforced the policy the same way as XDP.")
+	return xdpOutcomes
+}
+
+// createUntrackedPolicy creates the matrix's policy as an untracked policy.
+func createUntrackedPolicy(c client.Interface, matrix EnforcementMatrix) {
+	policy := matrix.Policy.DeepCopy()
+	policy.Spec.DoNotTrack = true
+	policy.Spec.ApplyOnForward = true
+	_, err := c.GlobalNetworkPolicies().Create(utils.Ctx, policy, utils.NoOptions)
+	ExpectWithOffset(2, err).NotTo(HaveOccurred())
+}
+
+// probeEnforcementMatrix probes each path of the matrix once on the given Felix and returns the
+// outcomes in a form that doesn't depend on which Felix was probed.
+func probeEnforcementMatrix(cc *connectivity.Checker, f *Felix, matrix EnforcementMatrix) []string {
+	cc.ResetExpectations()
+	defer cc.ResetExpectations()
+
+	var paths []string
+	for _, src := range matrix.Sources {
+		for _, port := range matrix.Ports {
+			cc.ExpectSome(src, connectivity.TargetIP(f.IP), port)
+			paths = append(paths, fmt.Sprintf("%s -> port %d", src.SourceName(), port))
+		}
+	}
+	results, _ := cc.ActualConnectivity(false)
+
+	outcomes := make([]string, len(paths))
+	for i, path := range paths {
+		outcomes[i] = fmt.Sprintf("%s = %v", path, results[i].HasConnectivity())
+	}
+	return outcomes
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infrastructure
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/bpf"
+)
+
+// xdpEventsContainerDir is where the collector's socket appears inside the Felix containers.
+const xdpEventsContainerDir = "/var/run/calico/xdp-events"
+
+// XDPEventCollector listens on a unix socket for the XDP events that Felix forwards when
+// XDPEventsSocket is set, so a test can watch the verdicts as they happen rather than
+// reading the ring buffer with calico-bpf after the fact.  Felix must be running the debug
+// build of the BPF programs (BPFLogLevel=Debug) with XDPLogLevel set.
+//
+// While Felix is connected, it consumes the ring buffer, so bpf.ReadXDPRingbuf sees no
+// events.
+type XDPEventCollector struct {
+	dir      string
+	listener net.Listener
+
+	lock   sync.Mutex
+	events []bpf.XDPEvent
+	conns  []net.Conn
+}
+
+// NewXDPEventCollector starts listening on a socket in a new temporary directory.  Call
+// Configure before starting Felix and Stop when the test is done.
+func NewXDPEventCollector() *XDPEventCollector {
+	dir, err := os.MkdirTemp("", "felixfv-xdp-events")
+	Expect(err).NotTo(HaveOccurred())
+	l, err := net.Listen("unix", filepath.Join(dir, "events.sock"))
+	Expect(err).NotTo(HaveOccurred())
+
+	c := &XDPEventCollector{
+		dir:      dir,
+		listener: l,
+	}
+	go c.accept()
+	return c
+}
+
+// Configure mounts the collector's socket into the Felix containers and tells Felix to send
+// its XDP events there.  All the Felixes in the topology share the collector.
+func (c *XDPEventCollector) Configure(opts *TopologyOptions) {
+	opts.ExtraVolumes[c.dir] = xdpEventsContainerDir
+	opts.ExtraEnvVars["FELIX_XDPEVENTSSOCKET"] = xdpEventsContainerDir + "/events.sock"
+}
+
+func (c *XDPEventCollector) accept() {
+	for {
+		conn, err := c.listener.Accept()
+		if err != nil {
+			// The listener has been closed.
+			return
+		}
+		c.lock.Lock()
+		c.conns = append(c.conns, conn)
+		c.lock.Unlock()
+		go c.read(conn)
+	}
+}
+
+func (c *XDPEventCollector) read(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		e, err := bpf.ParseXDPEvent(scanner.Text())
+		if err != nil {
+			log.WithError(err).Warn("Ignoring bad XDP event from Felix.")
+			continue
+		}
+		c.lock.Lock()
+		c.events = append(c.events, e)
+		c.lock.Unlock()
+	}
+}
+
+// Events returns the events that have been collected since the collector started or was
+// last reset.
+func (c *XDPEventCollector) Events() []bpf.XDPEvent {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]bpf.XDPEvent(nil), c.events...)
+}
+
+// Reset discards the events that have been collected so far.
+func (c *XDPEventCollector) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.events = nil
+}
+
+// Stop closes the socket and its connections and removes the temporary directory.
+func (c *XDPEventCollector) Stop() {
+	_ = c.listener.Close()
+	c.lock.Lock()
+	for _, conn := range c.conns {
+		_ = conn.Close()
+	}
+	c.conns = nil
+	c.lock.Unlock()
+	_ = os.RemoveAll(c.dir)
+}
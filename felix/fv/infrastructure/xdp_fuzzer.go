@@ -0,0 +1,243 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package infrastructure
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	log "github.com/sirupsen/logrus"
+
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
+
+	"github.com/projectcalico/calico/felix/fv/utils"
+)
+
+const (
+	numFuzzPolicies = 3
+	numFuzzSets     = 4
+	fuzzSetLabel    = "xdp-fuzz-set"
+)
+
+// XDPFuzzer applies and deletes random combinations of deny policies and the
+// GlobalNetworkSets that they block, in a random order, to shake out ordering and resync
+// bugs in Felix's XDP programming.  It keeps track of what it has written so that, once Felix
+// has caught up, a test can check the XDP blocklist against ExpectedBlocklist.
+//
+// All its choices come from the given seed, so a failing sequence can be replayed by reusing
+// the seed.
+type XDPFuzzer struct {
+	client   client.Interface
+	rand     *rand.Rand
+	selector string
+
+	// policies maps the name of each policy that exists to the policy as last written.
+	policies map[string]*api.GlobalNetworkPolicy
+	// policySets maps the name of each policy that exists to the names of the network sets
+	// that it blocks.
+	policySets map[string][]string
+	// sets maps the name of each network set that exists to its nets.
+	sets map[string][]string
+}
+
+// NewXDPFuzzer returns a fuzzer whose policies apply to the host endpoints that match
+// selector.
+func NewXDPFuzzer(c client.Interface, seed int64, selector string) *XDPFuzzer {
+	return &XDPFuzzer{
+		client:     c,
+		rand:       rand.New(rand.NewSource(seed)),
+		selector:   selector,
+		policies:   map[string]*api.GlobalNetworkPolicy{},
+		policySets: map[string][]string{},
+		sets:       map[string][]string{},
+	}
+}
+
+// Step makes one random change: it creates, updates or deletes one of the policies or
+// network sets.  Policies are untracked, and so candidates for XDP, most of the time.
+func (f *XDPFuzzer) Step() error {
+	if f.rand.Intn(2) == 0 {
+		return f.stepPolicy(fmt.Sprintf("xdp-fuzz-%d", f.rand.Intn(numFuzzPolicies)))
+	}
+	return f.stepSet(fmt.Sprintf("xdp-fuzz-%d", f.rand.Intn(numFuzzSets)))
+}
+
+func (f *XDPFuzzer) stepPolicy(name string) error {
+	if old, ok := f.policies[name]; ok && f.rand.Intn(3) == 0 {
+		log.WithField("policy", name).Info("XDP fuzzer: deleting policy")
+		if _, err := f.client.GlobalNetworkPolicies().Delete(utils.Ctx, name, options.DeleteOptions{}); err != nil {
+			return err
+		}
+		delete(f.policies, old.Name)
+		delete(f.policySets, old.Name)
+		return nil
+	}
+
+	setNames := f.randomSetNames()
+	untracked := f.rand.Intn(4) != 0
+	order := float64(10 + f.rand.Intn(10))
+	policy := api.NewGlobalNetworkPolicy()
+	policy.Name = name
+	if old, ok := f.policies[name]; ok {
+		policy = old
+	}
+	policy.Spec.Order = &order
+	policy.Spec.Selector = f.selector
+	policy.Spec.DoNotTrack = untracked
+	policy.Spec.ApplyOnForward = untracked
+	policy.Spec.Ingress = []api.Rule{{
+		Action: api.Deny,
+		Source: api.EntityRule{
+			Selector: fmt.Sprintf("%s in {'%s'}", fuzzSetLabel, strings.Join(setNames, "', '")),
+		},
+	}}
+	log.WithFields(log.Fields{
+		"policy":    name,
+		"sets":      setNames,
+		"untracked": untracked,
+	}).Info("XDP fuzzer: applying policy")
+
+	var err error
+	if policy.ResourceVersion == "" {
+		policy, err = f.client.GlobalNetworkPolicies().Create(utils.Ctx, policy, utils.NoOptions)
+	} else {
+		policy, err = f.client.GlobalNetworkPolicies().Update(utils.Ctx, policy, utils.NoOptions)
+	}
+	if err != nil {
+		return err
+	}
+	f.policies[name] = policy
+	f.policySets[name] = setNames
+	return nil
+}
+
+func (f *XDPFuzzer) stepSet(name string) error {
+	if _, ok := f.sets[name]; ok && f.rand.Intn(3) == 0 {
+		log.WithField("set", name).Info("XDP fuzzer: deleting network set")
+		if _, err := f.client.GlobalNetworkSets().Delete(utils.Ctx, name, options.DeleteOptions{}); err != nil {
+			return err
+		}
+		delete(f.sets, name)
+		return nil
+	}
+
+	nets := f.randomNets()
+	log.WithFields(log.Fields{
+		"set":  name,
+		"nets": nets,
+	}).Info("XDP fuzzer: applying network set")
+
+	netSet, err := f.client.GlobalNetworkSets().Get(utils.Ctx, name, options.GetOptions{})
+	if _, ok := err.(cerrors.ErrorResourceDoesNotExist); ok {
+		netSet = api.NewGlobalNetworkSet()
+		netSet.Name = name
+		netSet.Labels = map[string]string{fuzzSetLabel: name}
+		netSet.Spec.Nets = nets
+		_, err = f.client.GlobalNetworkSets().Create(utils.Ctx, netSet, utils.NoOptions)
+	} else if err == nil {
+		netSet.Spec.Nets = nets
+		_, err = f.client.GlobalNetworkSets().Update(utils.Ctx, netSet, utils.NoOptions)
+	}
+	if err != nil {
+		return err
+	}
+	f.sets[name] = nets
+	return nil
+}
+
+// randomSetNames returns a non-empty random selection of the network set names, which may
+// include sets that don't exist yet.
+func (f *XDPFuzzer) randomSetNames() []string {
+	var names []string
+	for len(names) == 0 {
+		for i := 0; i < numFuzzSets; i++ {
+			if f.rand.Intn(2) == 0 {
+				names = append(names, fmt.Sprintf("xdp-fuzz-%d", i))
+			}
+		}
+	}
+	return names
+}
+
+// randomNets returns between one and three nets from a small pool, so that the sets often
+// overlap.
+func (f *XDPFuzzer) randomNets() []string {
+	nets := set.New[string]()
+	for n := 1 + f.rand.Intn(3); nets.Len() < n; {
+		if f.rand.Intn(4) == 0 {
+			nets.Add(fmt.Sprintf("10.65.%d.0/24", f.rand.Intn(4)))
+		} else {
+			nets.Add(fmt.Sprintf("10.65.%d.%d/32", f.rand.Intn(4), 1+f.rand.Intn(4)))
+		}
+	}
+	return sortedStrings(nets)
+}
+
+// ExpectedBlocklist returns the sorted CIDRs that the XDP blocklist should hold once Felix
+// has processed all the changes so far: the nets of all the sets that an untracked policy
+// blocks.
+func (f *XDPFuzzer) ExpectedBlocklist() []string {
+	cidrs := set.New[string]()
+	for name, policy := range f.policies {
+		if !policy.Spec.DoNotTrack {
+			continue
+		}
+		for _, setName := range f.policySets[name] {
+			for _, n := range f.sets[setName] {
+				_, ipNet, err := net.ParseCIDR(n)
+				if err != nil {
+					log.WithError(err).Panic("XDP fuzzer generated a bad CIDR")
+				}
+				cidrs.Add(ipNet.String())
+			}
+		}
+	}
+	return sortedStrings(cidrs)
+}
+
+// Cleanup deletes all the policies and network sets that the fuzzer has created.
+func (f *XDPFuzzer) Cleanup() error {
+	for name := range f.policies {
+		if _, err := f.client.GlobalNetworkPolicies().Delete(utils.Ctx, name, options.DeleteOptions{}); err != nil {
+			return err
+		}
+		delete(f.policies, name)
+		delete(f.policySets, name)
+	}
+	for name := range f.sets {
+		if _, err := f.client.GlobalNetworkSets().Delete(utils.Ctx, name, options.DeleteOptions{}); err != nil {
+			return err
+		}
+		delete(f.sets, name)
+	}
+	return nil
+}
+
+func sortedStrings(s set.Set[string]) []string {
+	strs := make([]string, 0, s.Len())
+	s.Iter(func(item string) error {
+		strs = append(strs, item)
+		return nil
+	})
+	sort.Strings(strs)
+	return strs
+}
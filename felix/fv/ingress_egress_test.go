@@ -107,8 +107,7 @@ var _ = Context("_INGRESS-EGRESS_ _BPF-SAFE_ with initialized Felix, etcd datast
 
 		It("only w1 can connect into w0, but egress from w0 is unrestricted", func() {
 			cc.ExpectNone(w[2], w[0])
-			cc.ExpectSome(w[1], w[0])
-			cc.ExpectSome(w[0], w[1])
+			cc.ExpectMutualSome(w[1], w[0])
 			cc.ExpectSome(w[0], w[2])
 			cc.CheckConnectivity()
 		})
@@ -199,8 +198,7 @@ var _ = Context("_INGRESS-EGRESS_ _BPF-SAFE_ with initialized Felix, etcd datast
 
 			It("only w1 can connect into w0, and all egress from w0 is allowed", func() {
 				cc.ExpectNone(w[2], w[0])
-				cc.ExpectSome(w[1], w[0])
-				cc.ExpectSome(w[0], w[1])
+				cc.ExpectMutualSome(w[1], w[0])
 				cc.ExpectSome(w[0], w[2])
 				cc.CheckConnectivity()
 			})
@@ -396,8 +394,7 @@ var _ = Context("with Typha and Felix-Typha TLS", func() {
 
 		It("only w1 can connect into w0, but egress from w0 is unrestricted", func() {
 			cc.ExpectNone(w[2], w[0])
-			cc.ExpectSome(w[1], w[0])
-			cc.ExpectSome(w[0], w[1])
+			cc.ExpectMutualSome(w[1], w[0])
 			cc.ExpectSome(w[0], w[2])
 			cc.CheckConnectivity()
 		})
@@ -27,6 +27,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/projectcalico/calico/felix/bpf"
 	"github.com/projectcalico/calico/felix/fv/connectivity"
 	"github.com/projectcalico/calico/felix/fv/utils"
 
@@ -141,11 +142,61 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ IPIP topology before adding
 	})
 
 	It("should have workload to workload connectivity", func() {
-		cc.ExpectSome(w[0], w[1])
-		cc.ExpectSome(w[1], w[0])
+		cc.ExpectMutualSome(w[0], w[1])
 		cc.CheckConnectivity()
 	})
 
+	Context("with Calico's XDP program on the hosts' eth0", func() {
+		BeforeEach(func() {
+			if BPFMode() {
+				Skip("XDP acceleration is not used in BPF mode")
+			}
+			if err := bpf.SupportsXDP(); err != nil {
+				Skip(fmt.Sprintf("XDP acceleration not supported: %v", err))
+			}
+
+			err := infra.AddAllowToDatastore("host-endpoint=='true'")
+			Expect(err).NotTo(HaveOccurred())
+			for _, f := range felixes {
+				hep := api.NewHostEndpoint()
+				hep.Name = "eth0-" + f.Name
+				hep.Labels = map[string]string{"host-endpoint": "true"}
+				hep.Spec.Node = f.Hostname
+				hep.Spec.InterfaceName = "eth0"
+				hep.Spec.ExpectedIPs = []string{f.IP}
+				_, err := client.HostEndpoints().Create(utils.Ctx, hep, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			// An untracked deny of a source that isn't in the topology is enough for Felix to
+			// attach its XDP program, which then sees the IPIP traffic between the hosts.
+			policy := api.NewGlobalNetworkPolicy()
+			policy.Name = "xdp-deny-unused"
+			policy.Spec.Selector = "host-endpoint=='true'"
+			policy.Spec.DoNotTrack = true
+			policy.Spec.ApplyOnForward = true
+			policy.Spec.Ingress = []api.Rule{{
+				Action: api.Deny,
+				Source: api.EntityRule{Nets: []string{"10.66.0.0/16"}},
+			}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, policy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, f := range felixes {
+				Eventually(f.XDPAttachedInterfaces, "10s", "1s").Should(ContainElement("eth0"))
+			}
+		})
+
+		It("should hand forwarded TCP SYNs to the stack with their MSS intact", func() {
+			// Calico doesn't clamp the MSS itself, so the server should see the MSS that the
+			// client's MTU gives; a larger one would mean the SYN was rewritten on the way.
+			maxMSS := w[0].MTU - 40
+			cc.ExpectSomeWithMaxMSS(w[0], w[1], 8055, maxMSS)
+			cc.ExpectSomeWithMaxMSS(w[1], w[0], 8055, maxMSS)
+			cc.CheckConnectivity()
+		})
+	})
+
 	It("should have host to workload connectivity", func() {
 		cc.ExpectSome(felixes[0], w[1])
 		cc.ExpectSome(felixes[0], w[0])
@@ -185,8 +236,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ IPIP topology before adding
 			cc.ExpectNone(felixes[0], hostW[1])
 			cc.ExpectNone(felixes[1], hostW[0])
 			// But the rules to allow IPIP between our hosts let the workload traffic through.
-			cc.ExpectSome(w[0], w[1])
-			cc.ExpectSome(w[1], w[0])
+			cc.ExpectMutualSome(w[0], w[1])
 			cc.CheckConnectivity()
 		})
 	})
@@ -219,8 +269,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ IPIP topology before adding
 		It("should block host-to-host traffic in the absence of policy allowing it", func() {
 			cc.ExpectNone(felixes[0], hostW[1])
 			cc.ExpectNone(felixes[1], hostW[0])
-			cc.ExpectSome(w[0], w[1])
-			cc.ExpectSome(w[1], w[0])
+			cc.ExpectMutualSome(w[0], w[1])
 			cc.CheckConnectivity()
 		})
 
@@ -246,8 +295,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ IPIP topology before adding
 			cc.ExpectNone(felixes[1], hostW[0])
 
 			// Workload connectivity is unchanged.
-			cc.ExpectSome(w[0], w[1])
-			cc.ExpectSome(w[1], w[0])
+			cc.ExpectMutualSome(w[0], w[1])
 			cc.CheckConnectivity()
 			cc.ResetExpectations()
 
@@ -264,8 +312,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ IPIP topology before adding
 			cc.ExpectNone(felixes[1], hostW[0])
 
 			// Workload connectivity is unchanged.
-			cc.ExpectSome(w[0], w[1])
-			cc.ExpectSome(w[1], w[0])
+			cc.ExpectMutualSome(w[0], w[1])
 			cc.CheckConnectivity()
 		})
 
@@ -370,8 +417,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ IPIP topology before adding
 		})
 
 		It("should have no workload to workload connectivity", func() {
-			cc.ExpectNone(w[0], w[1])
-			cc.ExpectNone(w[1], w[0])
+			cc.ExpectMutualNone(w[0], w[1])
 			cc.CheckConnectivity()
 		})
 	})
@@ -155,8 +155,7 @@ var _ = Context("_BPF-SAFE_ Latency tests with initialized Felix and etcd datast
 
 			pol = createPolicy(pol)
 
-			cc.ExpectSome(w[0], w[1])
-			cc.ExpectSome(w[1], w[0])
+			cc.ExpectMutualSome(w[0], w[1])
 			cc.CheckConnectivity()
 		})
 
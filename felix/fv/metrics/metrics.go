@@ -143,6 +143,31 @@ func GetFelixMetricInt(felixIP, name string) (metric int, err error) {
 	return strconv.Atoi(s)
 }
 
+// GetFelixPolicyRulePackets returns the number of packets that the BPF programs have counted
+// for a policy rule, which Felix reports when BPFPolicyDebugEnabled is set.  The rule is given by
+// its policy's name, such as "default.my-policy", its direction and index, such as "ingress-0",
+// and its action in lower case, so the allow and deny rules of a policy have separate counts.
+// It returns 0 if the rule hasn't been counted yet.
+func GetFelixPolicyRulePackets(felixIP, policy, rule, action string) (int, error) {
+	s, err := GetFelixMetric(felixIP,
+		fmt.Sprintf(`felix_policy_rule_packets{action=%q,policy=%q,rule=%q}`, action, policy, rule))
+	if err != nil || s == "" {
+		return 0, err
+	}
+	return strconv.Atoi(s)
+}
+
+// GetFelixPolicyDroppedPackets returns the number of packets that the deny rules of a policy,
+// such as "default.my-policy", have dropped in the BPF programs, which Felix reports when
+// BPFPolicyDebugEnabled is set.  It returns 0 if the policy hasn't dropped anything yet.
+func GetFelixPolicyDroppedPackets(felixIP, policy string) (int, error) {
+	s, err := GetFelixMetric(felixIP, fmt.Sprintf(`felix_policy_dropped_packets{policy=%q}`, policy))
+	if err != nil || s == "" {
+		return 0, err
+	}
+	return strconv.Atoi(s)
+}
+
 func GetFelixMetricFloat(felixIP, name string) (metric float64, err error) {
 	s, err := GetFelixMetric(felixIP, name)
 	if err != nil {
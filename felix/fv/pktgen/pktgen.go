@@ -15,9 +15,11 @@
 package main
 
 import (
+	"fmt"
 	"math"
 	"net"
 	"strconv"
+	"time"
 
 	"golang.org/x/sys/unix"
 
@@ -30,7 +32,17 @@ import (
 const usage = `pktgen: generates packets for Felix FV testing.
 
 Usage:
-  pktgen <ip_src> <ip_dst> <proto> [--ip-id=<ip_id>] [--port-src=<port_src>] [--port-dst=<port_dst>]`
+  pktgen <ip_src> <ip_dst> <proto> [--ip-id=<ip_id>] [--port-src=<port_src>] [--port-dst=<port_dst>] [--count=<count>] [--bare-ip] [--truncate=<bytes>] [--iface=<iface> --mac-src=<mac_src> --mac-dst=<mac_dst>]
+
+Options:
+  --count=<count>      Number of copies of the packet to send, as fast as possible [default: 1].
+  --bare-ip            Send just the IP header, with no <proto> header or payload.
+  --truncate=<bytes>   Cut the frame down to this many bytes, for example to make a runt below
+                       the minimum Ethernet frame size.  Requires --iface.
+  --iface=<iface>      Send the packet as an Ethernet frame on this interface, bypassing routing
+                       and ARP.  Requires --mac-src and --mac-dst.
+  --mac-src=<mac_src>  Source MAC of the frame, which needn't be the interface's own.
+  --mac-dst=<mac_dst>  Destination MAC of the frame.`
 
 func main() {
 	log.SetLevel(log.InfoLevel)
@@ -91,6 +103,46 @@ func main() {
 		dport = uint16(p)
 	}
 
+	count, err := strconv.Atoi(args["--count"].(string))
+	if err != nil || count < 1 {
+		log.Fatal("count should be a positive number")
+	}
+
+	var iface *net.Interface
+	var macsrc, macdst net.HardwareAddr
+	if args["--iface"] != nil {
+		if args["--mac-src"] == nil || args["--mac-dst"] == nil {
+			log.Fatal("--iface requires --mac-src and --mac-dst")
+		}
+		iface, err = net.InterfaceByName(args["--iface"].(string))
+		if err != nil {
+			log.WithError(err).Fatal("unknown interface")
+		}
+		macsrc, err = net.ParseMAC(args["--mac-src"].(string))
+		if err != nil {
+			log.WithError(err).Fatal("invalid source MAC")
+		}
+		macdst, err = net.ParseMAC(args["--mac-dst"].(string))
+		if err != nil {
+			log.WithError(err).Fatal("invalid destination MAC")
+		}
+	} else if args["--mac-src"] != nil || args["--mac-dst"] != nil {
+		log.Fatal("--mac-src and --mac-dst require --iface")
+	}
+
+	bareIP := args["--bare-ip"].(bool)
+
+	truncate := 0
+	if args["--truncate"] != nil {
+		if iface == nil {
+			log.Fatal("--truncate requires --iface")
+		}
+		truncate, err = strconv.Atoi(args["--truncate"].(string))
+		if err != nil || truncate < 1 {
+			log.Fatal("truncate should be a positive number")
+		}
+	}
+
 	var proto layers.IPProtocol
 
 	switch args["<proto>"] {
@@ -116,8 +168,9 @@ func main() {
 
 	var l4 gopacket.SerializableLayer
 
-	switch proto {
-	case layers.IPProtocolUDP:
+	switch {
+	case bareIP:
+	case proto == layers.IPProtocolUDP:
 		udp := &layers.UDP{
 			SrcPort: layers.UDPPort(sport),
 			DstPort: layers.UDPPort(dport),
@@ -133,30 +186,78 @@ func main() {
 	}
 
 	pkt := gopacket.NewSerializeBuffer()
-	err = gopacket.SerializeLayers(pkt, gopacket.SerializeOptions{ComputeChecksums: true},
-		ipv4, l4, gopacket.Payload(payload))
+	pktLayers := []gopacket.SerializableLayer{ipv4}
+	if !bareIP {
+		pktLayers = append(pktLayers, l4, gopacket.Payload(payload))
+	}
+	if iface != nil {
+		eth := &layers.Ethernet{
+			SrcMAC:       macsrc,
+			DstMAC:       macdst,
+			EthernetType: layers.EthernetTypeIPv4,
+		}
+		pktLayers = append([]gopacket.SerializableLayer{eth}, pktLayers...)
+	}
+	err = gopacket.SerializeLayers(pkt, gopacket.SerializeOptions{ComputeChecksums: true}, pktLayers...)
 
 	if err != nil {
 		log.WithError(err).Fatal("failed to serialized packet")
 	}
+	frame := pkt.Bytes()
+	if truncate > 0 && truncate < len(frame) {
+		frame = frame[:truncate]
+	}
 
-	s, err := unix.Socket(unix.AF_INET, unix.SOCK_RAW, unix.IPPROTO_RAW)
+	var (
+		s    int
+		addr unix.Sockaddr
+	)
+	if iface != nil {
+		// A packet socket sends the frame exactly as we built it, so the kernel neither
+		// routes it nor fills in the MACs.
+		s, err = unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, int(htons(unix.ETH_P_IP)))
+		if err != nil || s < 0 {
+			log.WithError(err).Fatal("failed to create packet socket")
+		}
+		ll := &unix.SockaddrLinklayer{
+			Ifindex:  iface.Index,
+			Protocol: htons(unix.ETH_P_IP),
+			Halen:    uint8(len(macdst)),
+		}
+		copy(ll.Addr[:], macdst)
+		addr = ll
+	} else {
+		s, err = unix.Socket(unix.AF_INET, unix.SOCK_RAW, unix.IPPROTO_RAW)
 
-	if err != nil || s < 0 {
-		log.WithError(err).Fatal("failed to create raw socket")
-	}
+		if err != nil || s < 0 {
+			log.WithError(err).Fatal("failed to create raw socket")
+		}
 
-	err = unix.SetsockoptInt(s, unix.IPPROTO_IP, unix.IP_HDRINCL, 1)
-	if err != nil {
-		log.WithError(err).Fatal("failed to set IP_HDRINCL")
-	}
+		err = unix.SetsockoptInt(s, unix.IPPROTO_IP, unix.IP_HDRINCL, 1)
+		if err != nil {
+			log.WithError(err).Fatal("failed to set IP_HDRINCL")
+		}
 
-	addr := &unix.SockaddrInet4{
-		Port: int(dport),
+		in4 := &unix.SockaddrInet4{
+			Port: int(dport),
+		}
+		copy(in4.Addr[:], ipdst.To4()[:4])
+		addr = in4
 	}
-	copy(addr.Addr[:], ipdst.To4()[:4])
 
-	if err := unix.Sendto(s, pkt.Bytes(), 0, addr); err != nil {
-		log.WithError(err).Fatal("failed to send packet")
+	start := time.Now()
+	for i := 0; i < count; i++ {
+		if err := unix.Sendto(s, frame, 0, addr); err != nil {
+			log.WithError(err).Fatal("failed to send packet")
+		}
+	}
+	if count > 1 {
+		// Report the sending time so that callers can work out the packet rate without
+		// including the overhead of starting pktgen.
+		fmt.Printf("sent %d packets in %v\n", count, time.Since(start))
 	}
 }
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
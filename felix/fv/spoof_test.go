@@ -74,8 +74,7 @@ var _ = Describe("Spoof tests", func() {
 			cc.ExpectSome(w[2], w[1])
 			// And a connection from w[0] without spoofing, vice versa,
 			// should also succeed.
-			cc.ExpectSome(w[0], w[1])
-			cc.ExpectSome(w[1], w[0])
+			cc.ExpectMutualSome(w[0], w[1])
 			cc.CheckConnectivity()
 		})
 
@@ -87,8 +86,7 @@ var _ = Describe("Spoof tests", func() {
 				SpoofedSourceIP: w[0].IP,
 			}
 			// The spoofed connection should be allowed.
-			cc.ExpectSome(spoofed, w[1])
-			cc.ExpectSome(w[1], spoofed)
+			cc.ExpectMutualSome(spoofed, w[1])
 			cc.CheckConnectivity()
 		})
 	}
@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -30,6 +31,7 @@ import (
 	"sync"
 	"syscall"
 	"time"
+	"unsafe"
 
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/docopt/docopt-go"
@@ -47,12 +49,12 @@ import (
 const usage = `test-connection: test connection to some target, for Felix FV testing.
 
 Usage:
-  test-connection <namespace-path> <ip-address> <port> [--source-ip=<source_ip>] [--source-port=<source>] [--protocol=<protocol>] [--duration=<seconds>] [--loop-with-file=<file>] [--sendlen=<bytes>] [--recvlen=<bytes>] [--log-pongs] [--stdin] [--timeout=<seconds>]
+  test-connection <namespace-path> <ip-address> <port> [--source-ip=<source_ip>] [--source-port=<source>] [--protocol=<protocol>] [--duration=<seconds>] [--loop-with-file=<file>] [--sendlen=<bytes>] [--recvlen=<bytes>] [--log-pongs] [--stdin] [--timeout=<seconds>] [--conns=<n>] [--sequenced=<n>] [--df-sendlen=<bytes>] [--port-unreachable] [--payload=<text>] [--ecn=<codepoint>] [--datagram-len=<bytes>] [--dont-fragment] [--scan-ports=<ports>] [--connect-timeout=<seconds>] [--send-rate=<pps>]
 
 Options:
   --source-ip=<source_ip>  Source IP to use for the connection [default: 0.0.0.0].
   --source-port=<source>   Source port to use for the connection [default: 0].
-  --protocol=<protocol>    Protocol to test tcp (default), udp (connected) udp-noconn (unconnected).
+  --protocol=<protocol>    Protocol to test tcp (default), tcp-fastopen (data in the SYN), udp (connected) udp-noconn (unconnected).
   --duration=<seconds>     Total seconds test should run. 0 means run a one off connectivity check. Non-Zero means packets loss test.[default: 0]
   --loop-with-file=<file>  Whether to send messages repeatedly, file is used for synchronization
   --log-pongs              Whether to log every response
@@ -61,11 +63,41 @@ Options:
   --recvlen=<bytes>        Tell the other side to send this many additional bytes
   --stdin                  Read and send data from stdin
   --timeout=<seconds>      Exit after timeout if pong not received
+  --conns=<n>              Open this many connections concurrently, each from an ephemeral source port [default: 1].
+  --sequenced=<n>          Send this many numbered UDP datagrams back to back and count the ones the server received out of order [default: 0].
+  --df-sendlen=<bytes>     Send one UDP datagram of this many bytes with the don't fragment bit set and wait for an ICMP fragmentation needed [default: 0].
+  --port-unreachable       Send one UDP datagram and wait for an ICMP port unreachable, which shows that it reached a host with nothing listening on the port.
+  --payload=<text>         Send this as the payload of a one-off request; the server echoes it back in its response.
+  --ecn=<codepoint>        Send UDP requests with this ECN codepoint (0-3) in the TOS or traffic class.
+  --datagram-len=<bytes>   Pad the UDP request of a one-off check to this many bytes, which may need fragmenting.
+  --dont-fragment          Send UDP requests with the don't fragment bit set, so that ones bigger than the MTU fail to send.
+  --scan-ports=<ports>     Ping each of this comma-separated list of ports, instead of <port>, and report which ones replied.
+  --connect-timeout=<seconds>  Give up on a TCP connect after this long, instead of just before the overall timeout.
+  --send-rate=<pps>        How many requests per second to send in a packet loss test [default: 200].
 
 If connection is successful, test-connection exits successfully.
 
 If connection is unsuccessful, test-connection panics and so exits with a failure status.`
 
+// tcpConnectTimeout limits how long a TCP connect can take, so that a connection that times
+// out can be reported as such before the global timeout kills the process.
+var tcpConnectTimeout time.Duration
+
+// streamSendInterval is the time between the requests of a packet loss test.
+var streamSendInterval = 5 * time.Millisecond
+
+// requestPayload, if set, replaces the generated payload of a one-off request.
+var requestPayload string
+
+// requestECN, if not negative, is the ECN codepoint that UDP requests are sent with.
+var requestECN = -1
+
+// requestDatagramLen, if set, is the size that a one-off UDP request is padded to.
+var requestDatagramLen int
+
+// dontFragment, if set, makes UDP requests set the don't fragment bit.
+var dontFragment bool
+
 // Note about the --loop-with-file=<FILE> flag:
 //
 // This flag takes a path to a file as a value. The file existence is
@@ -162,9 +194,87 @@ func main() {
 		timeout = time.Duration(timeoutSecs * float64(time.Second))
 	}
 
+	var connectTimeout time.Duration
+	if toval := arguments["--connect-timeout"]; toval != nil {
+		connectTimeoutSecs, err := strconv.ParseFloat(toval.(string), 64)
+		if err != nil || connectTimeoutSecs <= 0 {
+			log.WithField("connect-timeout", toval).Fatal("Invalid --connect-timeout argument")
+		}
+		connectTimeout = time.Duration(connectTimeoutSecs * float64(time.Second))
+	}
+
+	sendRate, err := strconv.Atoi(arguments["--send-rate"].(string))
+	if err != nil || sendRate < 1 {
+		log.WithField("send-rate", arguments["--send-rate"]).Fatal("Invalid --send-rate argument")
+	}
+	streamSendInterval = time.Second / time.Duration(sendRate)
+
+	numConns, err := strconv.Atoi(arguments["--conns"].(string))
+	if err != nil || numConns < 1 {
+		log.WithField("conns", arguments["--conns"]).Fatal("Invalid --conns argument")
+	}
+
+	numSequenced, err := strconv.Atoi(arguments["--sequenced"].(string))
+	if err != nil || numSequenced < 0 {
+		log.WithField("sequenced", arguments["--sequenced"]).Fatal("Invalid --sequenced argument")
+	}
+	if numSequenced > 0 && !strings.HasPrefix(protocol, "udp") {
+		log.WithField("protocol", protocol).Fatal("--sequenced is only supported for UDP")
+	}
+
+	dfSendLen, err := strconv.Atoi(arguments["--df-sendlen"].(string))
+	if err != nil || dfSendLen < 0 {
+		log.WithField("df-sendlen", arguments["--df-sendlen"]).Fatal("Invalid --df-sendlen argument")
+	}
+	if dfSendLen > 0 && (!strings.HasPrefix(protocol, "udp") || strings.Contains(ipAddress, ":")) {
+		log.WithField("protocol", protocol).Fatal("--df-sendlen is only supported for UDP over IPv4")
+	}
+
+	portUnreachable, err := arguments.Bool("--port-unreachable")
+	if err != nil {
+		log.WithError(err).Fatal("Invalid --port-unreachable")
+	}
+	if portUnreachable && (!strings.HasPrefix(protocol, "udp") || strings.Contains(ipAddress, ":")) {
+		log.WithField("protocol", protocol).Fatal("--port-unreachable is only supported for UDP over IPv4")
+	}
+
+	if payload, ok := arguments["--payload"].(string); ok {
+		requestPayload = payload
+	}
+
+	if ecn, ok := arguments["--ecn"].(string); ok {
+		requestECN, err = strconv.Atoi(ecn)
+		if err != nil || requestECN < 0 || requestECN > 3 {
+			log.WithField("ecn", ecn).Fatal("Invalid --ecn argument")
+		}
+		if protocol != "udp" && protocol != "udp-recvmsg" {
+			log.WithField("protocol", protocol).Fatal("--ecn is only supported for connected UDP")
+		}
+	}
+
+	if l, ok := arguments["--datagram-len"].(string); ok {
+		requestDatagramLen, err = strconv.Atoi(l)
+		if err != nil || requestDatagramLen < 0 {
+			log.WithField("datagram-len", l).Fatal("Invalid --datagram-len argument")
+		}
+	}
+
+	dontFragment, err = arguments.Bool("--dont-fragment")
+	if err != nil {
+		log.WithError(err).Fatal("Invalid --dont-fragment")
+	}
+	if (requestDatagramLen > 0 || dontFragment) && protocol != "udp" && protocol != "udp-recvmsg" {
+		log.WithField("protocol", protocol).Fatal("--datagram-len and --dont-fragment are only supported for connected UDP")
+	}
+
+	var scanPorts []string
+	if ports, ok := arguments["--scan-ports"].(string); ok && ports != "" {
+		scanPorts = strings.Split(ports, ",")
+	}
+
 	log.Infof("Test connection from namespace %v IP %v port %v to IP %v port %v proto %v "+
-		"max duration %d seconds, timeout %v logging pongs (%v), stdin %v",
-		namespacePath, sourceIpAddress, sourcePort, ipAddress, port, protocol, seconds, timeout, logPongs, stdin)
+		"max duration %d seconds, timeout %v logging pongs (%v), stdin %v, conns %d",
+		namespacePath, sourceIpAddress, sourcePort, ipAddress, port, protocol, seconds, timeout, logPongs, stdin, numConns)
 
 	if loopFile == "" {
 		// I found that configuring the timeouts on all the network calls was a bit fiddly.  Since
@@ -174,6 +284,12 @@ func main() {
 			time.Sleep(timeout * time.Second)
 			log.Fatal("Timed out")
 		}()
+		// Give up on TCP connects before the global timeout fires so that we can report
+		// that the connection timed out.
+		tcpConnectTimeout = time.Duration(seconds)*time.Second + 1500*time.Millisecond
+	}
+	if connectTimeout > 0 {
+		tcpConnectTimeout = connectTimeout
 	}
 
 	if namespacePath == "-" {
@@ -181,8 +297,20 @@ func main() {
 		err = maybeAddAddr(sourceIpAddress)
 		// Test connection from wherever we are already running.
 		if err == nil {
-			err = tryConnect(ipAddress, port, sourceIpAddress, sourcePort, protocol,
-				seconds, loopFile, sendLen, recvLen, logPongs, stdin, timeout)
+			if numConns > 1 {
+				err = tryMultiConn(ipAddress, port, sourceIpAddress, protocol, numConns, loopFile, timeout)
+			} else if numSequenced > 0 {
+				err = trySequenced(ipAddress, port, sourceIpAddress, sourcePort, protocol, numSequenced, timeout)
+			} else if dfSendLen > 0 {
+				err = tryFragNeeded(ipAddress, port, sourceIpAddress, sourcePort, dfSendLen, timeout)
+			} else if portUnreachable {
+				err = tryPortUnreachable(ipAddress, port, sourceIpAddress, sourcePort, timeout)
+			} else if len(scanPorts) > 0 {
+				err = tryScanPorts(ipAddress, scanPorts, sourceIpAddress, protocol, timeout)
+			} else {
+				err = tryConnect(ipAddress, port, sourceIpAddress, sourcePort, protocol,
+					seconds, loopFile, sendLen, recvLen, logPongs, stdin, timeout)
+			}
 		}
 	} else {
 		// Get the specified network namespace (representing a workload).
@@ -200,6 +328,21 @@ func main() {
 			if e != nil {
 				return e
 			}
+			if numConns > 1 {
+				return tryMultiConn(ipAddress, port, sourceIpAddress, protocol, numConns, loopFile, timeout)
+			}
+			if numSequenced > 0 {
+				return trySequenced(ipAddress, port, sourceIpAddress, sourcePort, protocol, numSequenced, timeout)
+			}
+			if dfSendLen > 0 {
+				return tryFragNeeded(ipAddress, port, sourceIpAddress, sourcePort, dfSendLen, timeout)
+			}
+			if portUnreachable {
+				return tryPortUnreachable(ipAddress, port, sourceIpAddress, sourcePort, timeout)
+			}
+			if len(scanPorts) > 0 {
+				return tryScanPorts(ipAddress, scanPorts, sourceIpAddress, protocol, timeout)
+			}
 			return tryConnect(ipAddress, port, sourceIpAddress, sourcePort, protocol,
 				seconds, loopFile, sendLen, recvLen, logPongs, stdin, timeout)
 		})
@@ -212,21 +355,22 @@ func main() {
 
 func maybeAddAddr(sourceIP string) error {
 	if sourceIP != defaultIPv4SourceIP && sourceIP != defaultIPv6SourceIP {
-		if !strings.Contains(sourceIP, ":") {
-			sourceIP += "/32"
-		} else {
-			sourceIP += "/128"
-		}
-
-		// Check if the IP is already set on eth0.
+		// Check if the IP is already set on eth0, with any prefix length; for example, if
+		// we're binding to one of several existing addresses.
 		out, err := exec.Command("ip", "a", "show", "dev", "eth0").Output()
 		if err != nil {
 			return err
 		}
-		if strings.Contains(string(out), sourceIP) {
+		if strings.Contains(string(out), " "+sourceIP+"/") {
 			log.Infof("IP addr %s already exists on eth0, skip adding IP", sourceIP)
 			return nil
 		}
+
+		if !strings.Contains(sourceIP, ":") {
+			sourceIP += "/32"
+		} else {
+			sourceIP += "/128"
+		}
 		cmd := exec.Command("ip", "addr", "add", sourceIP, "dev", "eth0")
 		return cmd.Run()
 	}
@@ -317,10 +461,17 @@ func NewTestConn(remoteIpAddr, remotePort, sourceIpAddr, sourcePort, protocol st
 				remoteIpAddr: remoteIpAddr,
 				remotePort:   remotePort,
 			}
-		default:
+		case "tcp-fastopen":
 			driver = &connectedTCP{
 				localAddr:  localAddr,
 				remoteAddr: remoteAddr,
+				fastOpen:   true,
+			}
+		default:
+			driver = &connectedTCP{
+				localAddr:      localAddr,
+				remoteAddr:     remoteAddr,
+				connectTimeout: tcpConnectTimeout,
 			}
 		}
 	}
@@ -358,7 +509,7 @@ func tryConnect(remoteIPAddr, remotePort, sourceIPAddr, sourcePort, protocol str
 	tc, err := NewTestConn(remoteIPAddr, remotePort, sourceIPAddr, sourcePort, protocol,
 		time.Duration(seconds)*time.Second, sendLen, recvLen, stdin)
 	if err != nil {
-		tc.sendErrorResp(err)
+		sendConnectErrorResp(err)
 		log.WithError(err).Fatal("Failed to create TestConn")
 	}
 	defer func() {
@@ -418,6 +569,419 @@ func tryConnect(remoteIPAddr, remotePort, sourceIPAddr, sourcePort, protocol str
 	return tc.tryConnectWithPacketLoss()
 }
 
+// tryMultiConn opens numConns connections to the target concurrently and sends a
+// single ping over each of them.  Once every connection has either received its
+// pong or timed out, it prints the number that succeeded as "CONNS=<ok>/<total>",
+// along with a RESULT whose stats count one request per connection.
+// If a loop file is given, the established connections are then held open until
+// the loop file is recreated, see the note about --loop-with-file above.
+func tryMultiConn(remoteIPAddr, remotePort, sourceIPAddr, protocol string,
+	numConns int, loopFile string, timeout time.Duration) error {
+
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+
+	// Connecting may block for much longer than the timeout (for example, if the
+	// SYN is dropped) so we collect results over a channel rather than waiting for
+	// all the goroutines to finish.
+	type connResult struct {
+		tc   *testConn
+		resp *connectivity.Response
+	}
+	results := make(chan connResult, numConns)
+	for i := 0; i < numConns; i++ {
+		go func(i int) {
+			logCxt := log.WithField("conn", i)
+			tc, err := NewTestConn(remoteIPAddr, remotePort, sourceIPAddr, "0", protocol,
+				0, 0, 0, false)
+			if err != nil {
+				logCxt.WithError(err).Info("Failed to connect")
+				results <- connResult{}
+				return
+			}
+			resp, err := tc.pingOnce(timeout)
+			if err != nil {
+				logCxt.WithError(err).Info("Failed to ping")
+				_ = tc.Close()
+				results <- connResult{}
+				return
+			}
+			results <- connResult{tc: tc, resp: resp}
+		}(i)
+	}
+
+	var established []*testConn
+	var lastResponse connectivity.Response
+	deadline := time.After(timeout)
+	received := 0
+collect:
+	for ; received < numConns; received++ {
+		select {
+		case r := <-results:
+			if r.tc != nil {
+				established = append(established, r.tc)
+				lastResponse = *r.resp
+			}
+		case <-deadline:
+			log.WithField("received", received).Info("Timed out waiting for connections")
+			break collect
+		}
+	}
+	if received < numConns {
+		// The stragglers don't count, but close them as they turn up so that they don't
+		// hold on to their sockets while we loop below.
+		go func(late int) {
+			for i := 0; i < late; i++ {
+				if r := <-results; r.tc != nil {
+					_ = r.tc.Close()
+				}
+			}
+		}(numConns - received)
+	}
+	defer func() {
+		for _, tc := range established {
+			_ = tc.Close()
+		}
+	}()
+
+	connectivity.Result{
+		LastResponse: lastResponse,
+		Stats: connectivity.Stats{
+			RequestsSent:      numConns,
+			ResponsesReceived: len(established),
+		},
+	}.PrintToStdout()
+	fmt.Printf("CONNS=%d/%d\n", len(established), numConns)
+
+	ls := newLoopState(loopFile)
+	for ls.Next() {
+	}
+	return nil
+}
+
+// tryScanPorts pings each of the ports concurrently, each from an ephemeral source port, and
+// reports which of them replied.
+func tryScanPorts(remoteIPAddr string, remotePorts []string, sourceIPAddr, protocol string,
+	timeout time.Duration) error {
+
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+
+	// As for tryMultiConn, connecting may block for much longer than the timeout so
+	// collect the results over a channel.
+	type scanResult struct {
+		port      int
+		reachable bool
+	}
+	results := make(chan scanResult, len(remotePorts))
+	for _, remotePort := range remotePorts {
+		port, err := strconv.Atoi(remotePort)
+		if err != nil {
+			return fmt.Errorf("invalid port %q to scan: %w", remotePort, err)
+		}
+		go func(port int, remotePort string) {
+			logCxt := log.WithField("port", port)
+			tc, err := NewTestConn(remoteIPAddr, remotePort, sourceIPAddr, "0", protocol,
+				0, 0, 0, false)
+			if err != nil {
+				logCxt.WithError(err).Info("Failed to connect")
+				results <- scanResult{port: port}
+				return
+			}
+			defer func() { _ = tc.Close() }()
+			if _, err := tc.pingOnce(timeout); err != nil {
+				logCxt.WithError(err).Info("Failed to ping")
+				results <- scanResult{port: port}
+				return
+			}
+			results <- scanResult{port: port, reachable: true}
+		}(port, remotePort)
+	}
+
+	res := connectivity.Result{
+		Stats:    connectivity.Stats{RequestsSent: len(remotePorts)},
+		PortScan: map[int]bool{},
+	}
+	deadline := time.After(timeout)
+collect:
+	for received := 0; received < len(remotePorts); received++ {
+		select {
+		case r := <-results:
+			res.PortScan[r.port] = r.reachable
+			if r.reachable {
+				res.Stats.ResponsesReceived++
+			}
+		case <-deadline:
+			log.WithField("received", received).Info("Timed out waiting for port scan")
+			break collect
+		}
+	}
+	res.PrintToStdout()
+	return nil
+}
+
+// trySequenced sends numPackets numbered datagrams back to back, all with the same
+// request ID, and then collects the responses.  The server numbers the requests of
+// the probe in the order that it receives them, so a response whose number doesn't
+// match its request's sequence number shows that the datagrams were reordered on the
+// way to the server.
+func trySequenced(remoteIPAddr, remotePort, sourceIPAddr, sourcePort, protocol string,
+	numPackets int, timeout time.Duration) error {
+
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+
+	tc, err := NewTestConn(remoteIPAddr, remotePort, sourceIPAddr, sourcePort, protocol,
+		0, 0, 0, false)
+	if err != nil {
+		tc.sendErrorResp(err)
+		log.WithError(err).Fatal("Failed to create TestConn")
+	}
+	defer func() {
+		_ = tc.Close()
+	}()
+
+	probe := tc.GetTestMessage(0)
+	for seq := 1; seq <= numPackets; seq++ {
+		req := probe
+		req.Sequence = seq
+		msg, err := json.Marshal(req)
+		if err != nil {
+			log.WithError(err).Panic("Failed to marshall request")
+		}
+		if err := tc.protocol.Send(msg); err != nil {
+			return err
+		}
+	}
+
+	var lastResponse connectivity.Response
+	received := 0
+	outOfOrder := 0
+	deadline := time.Now().Add(timeout)
+	for received < numPackets {
+		if err := tc.protocol.SetReadDeadline(deadline); err != nil {
+			return err
+		}
+		respRaw, err := tc.protocol.Receive()
+		if e, ok := err.(net.Error); ok && e.Timeout() {
+			log.WithField("received", received).Info("Timed out waiting for responses")
+			break
+		} else if err != nil {
+			return err
+		}
+
+		var resp connectivity.Response
+		if err := json.Unmarshal(respRaw, &resp); err != nil {
+			log.WithError(err).Warning("Failed to unmarshall response")
+			continue
+		}
+		if resp.Request.ID != probe.ID {
+			continue
+		}
+		if resp.ReceivedIndex != resp.Request.Sequence {
+			log.WithFields(log.Fields{
+				"sequence": resp.Request.Sequence,
+				"received": resp.ReceivedIndex,
+			}).Info("Datagram received out of order")
+			outOfOrder++
+		}
+		lastResponse = resp
+		received++
+	}
+
+	connectivity.Result{
+		LastResponse: lastResponse,
+		Stats: connectivity.Stats{
+			RequestsSent:      numPackets,
+			ResponsesReceived: received,
+		},
+		OutOfOrder: outOfOrder,
+	}.PrintToStdout()
+	return nil
+}
+
+const (
+	icmpDestUnreachable = 3
+	icmpPortUnreachable = 3
+	icmpFragNeeded      = 4
+)
+
+// tryFragNeeded sends a single datagram of sendLen bytes with the don't fragment bit set and
+// waits for the ICMP "fragmentation needed" that a hop with a smaller MTU should send back.
+// The server isn't expected to answer, so the probe counts as a response only if the ICMP
+// arrives, and the result records the next-hop MTU that it reported.
+func tryFragNeeded(remoteIPAddr, remotePort, sourceIPAddr, sourcePort string, sendLen int, timeout time.Duration) error {
+	mtu, found, err := probeDestUnreachable(remoteIPAddr, remotePort, sourceIPAddr, sourcePort,
+		sendLen, true, icmpFragNeeded, timeout)
+	if err != nil {
+		return err
+	}
+	log.WithField("mtu", mtu).Info("Finished waiting for ICMP fragmentation needed")
+
+	res := connectivity.Result{
+		Stats:         connectivity.Stats{RequestsSent: 1},
+		FragNeededMTU: int(mtu),
+	}
+	if found {
+		res.Stats.ResponsesReceived = 1
+	}
+	res.PrintToStdout()
+	return nil
+}
+
+// tryPortUnreachable sends a single datagram and waits for the ICMP "port unreachable" that the
+// target host sends back if nothing is listening on the port.  Unlike silence, which could also
+// mean that the datagram was dropped on the way, the ICMP shows that it reached the host.  The
+// probe counts as a response only if the ICMP arrives.
+func tryPortUnreachable(remoteIPAddr, remotePort, sourceIPAddr, sourcePort string, timeout time.Duration) error {
+	_, found, err := probeDestUnreachable(remoteIPAddr, remotePort, sourceIPAddr, sourcePort,
+		1, false, icmpPortUnreachable, timeout)
+	if err != nil {
+		return err
+	}
+	log.WithField("portUnreachable", found).Info("Finished waiting for ICMP port unreachable")
+
+	res := connectivity.Result{
+		Stats:           connectivity.Stats{RequestsSent: 1},
+		PortUnreachable: found,
+	}
+	if found {
+		res.Stats.ResponsesReceived = 1
+	}
+	res.PrintToStdout()
+	return nil
+}
+
+// probeDestUnreachable sends a single UDP datagram of sendLen bytes and waits up to timeout for
+// an ICMP destination unreachable with the given code to show up on the socket's error queue.
+// It returns the ICMP's info field, which is the next-hop MTU for fragmentation needed, and
+// whether the ICMP arrived.
+func probeDestUnreachable(remoteIPAddr, remotePort, sourceIPAddr, sourcePort string, sendLen int, dontFragment bool,
+	code uint8, timeout time.Duration) (uint32, bool, error) {
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+
+	conn, err := reuse.Dial("udp", sourceIPAddr+":"+sourcePort, remoteIPAddr+":"+remotePort)
+	if err != nil {
+		return 0, false, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	rawConn, err := conn.(*net.UDPConn).SyscallConn()
+	if err != nil {
+		return 0, false, err
+	}
+
+	var sockErr error
+	err = rawConn.Control(func(fd uintptr) {
+		if dontFragment {
+			// Unlike IP_PMTUDISC_DO, PROBE ignores any path MTU that the kernel has
+			// already learned, so that repeated probes still get as far as the hop with
+			// the small MTU.
+			sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MTU_DISCOVER, unix.IP_PMTUDISC_PROBE)
+		}
+		if sockErr == nil {
+			sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_RECVERR, 1)
+		}
+	})
+	if err == nil {
+		err = sockErr
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to set socket options: %w", err)
+	}
+
+	if _, err := conn.Write(make([]byte, sendLen)); err != nil {
+		return 0, false, err
+	}
+
+	var (
+		info  uint32
+		found bool
+	)
+	for deadline := time.Now().Add(timeout); !found && time.Now().Before(deadline); {
+		err = rawConn.Control(func(fd uintptr) {
+			info, found, sockErr = readDestUnreachable(int(fd), code)
+		})
+		if err == nil {
+			err = sockErr
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		if !found {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	return info, found, nil
+}
+
+// readDestUnreachable drains the socket's error queue, without blocking, and returns the info
+// field of any ICMP destination unreachable with the given code in it, and whether there was one.
+func readDestUnreachable(fd int, code uint8) (uint32, bool, error) {
+	for {
+		oob := make([]byte, 512)
+		_, oobn, _, _, err := unix.Recvmsg(fd, make([]byte, 1), oob, unix.MSG_ERRQUEUE|unix.MSG_DONTWAIT)
+		if err == unix.EAGAIN {
+			return 0, false, nil
+		} else if err != nil {
+			return 0, false, err
+		}
+		msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			return 0, false, err
+		}
+		for _, m := range msgs {
+			if m.Header.Level != unix.SOL_IP || m.Header.Type != unix.IP_RECVERR ||
+				len(m.Data) < int(unsafe.Sizeof(unix.SockExtendedErr{})) {
+				continue
+			}
+			ee := (*unix.SockExtendedErr)(unsafe.Pointer(&m.Data[0]))
+			log.WithField("err", *ee).Info("Read socket error queue")
+			if ee.Origin == unix.SO_EE_ORIGIN_ICMP && ee.Type == icmpDestUnreachable && ee.Code == code {
+				return ee.Info, true, nil
+			}
+		}
+	}
+}
+
+// pingOnce sends a single test message and waits up to timeout for the matching
+// response, which it returns.
+func (tc *testConn) pingOnce(timeout time.Duration) (*connectivity.Response, error) {
+	req := tc.GetTestMessage(0)
+	msg, err := json.Marshal(req)
+	if err != nil {
+		log.WithError(err).Panic("Failed to marshall request")
+	}
+
+	if err := tc.protocol.Send(msg); err != nil {
+		return nil, err
+	}
+	if err := tc.protocol.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	respRaw, err := tc.protocol.Receive()
+	if err != nil {
+		return nil, err
+	}
+
+	var resp connectivity.Response
+	if err := json.Unmarshal(respRaw, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Request.Equal(req) {
+		return nil, fmt.Errorf("unexpected response %v", resp)
+	}
+	tc.stat.totalReq++
+	tc.stat.totalReply++
+	return &resp, nil
+}
+
 func (tc *testConn) GetTestMessage(sequence int) connectivity.Request {
 	req := tc.config.GetTestMessage(sequence)
 	req.SendSize = tc.sendLen
@@ -521,6 +1085,27 @@ func (tc *testConn) sendErrorResp(err error) {
 	res.PrintToStdout()
 }
 
+// sendConnectErrorResp reports a failure to connect, along with why the connection failed,
+// if we can tell.
+func sendConnectErrorResp(err error) {
+	res := connectivity.Result{
+		LastResponse: connectivity.Response{ErrorStr: err.Error()},
+		Stats: connectivity.Stats{
+			RequestsSent:      1,
+			ResponsesReceived: 0,
+		},
+	}
+	var netErr net.Error
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		res.ConnectFailure = connectivity.ConnectFailureRefused
+	} else if errors.Is(err, syscall.EHOSTUNREACH) || errors.Is(err, syscall.ENETUNREACH) {
+		res.ConnectFailure = connectivity.ConnectFailureUnreachable
+	} else if errors.As(err, &netErr) && netErr.Timeout() {
+		res.ConnectFailure = connectivity.ConnectFailureTimeout
+	}
+	res.PrintToStdout()
+}
+
 func (tc *testConn) tryConnectOnceOff(timeout time.Duration) error {
 	log.Info("Doing single-shot test...")
 	if timeout != 0 {
@@ -550,10 +1135,19 @@ func (tc *testConn) tryConnectOnceOff(timeout time.Duration) error {
 	}
 
 	req := tc.GetTestMessage(0)
+	if requestPayload != "" {
+		req.Payload = requestPayload
+	}
 	msg, err := json.Marshal(req)
 	if err != nil {
 		log.WithError(err).Panic("Failed to marshall request")
 	}
+	if requestDatagramLen > 0 {
+		msg, err = padRequest(req, len(msg), requestDatagramLen)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to pad request")
+		}
+	}
 
 	mtuPair := connectivity.MTUPair{}
 	mtuPair.Start, err = tc.protocol.MTU()
@@ -612,6 +1206,12 @@ func (tc *testConn) tryConnectOnceOff(timeout time.Duration) error {
 		},
 		ClientMTU: mtuPair,
 	}
+	if d, ok := tc.protocol.(*connectedTCP); ok && d.fastOpen {
+		res.SYNDataAcked, err = d.SYNDataAcked()
+		if err != nil {
+			log.WithError(err).Fatal("Failed to get TCP info")
+		}
+	}
 	res.PrintToStdout()
 
 	return nil
@@ -698,6 +1298,7 @@ func (tc *testConn) tryConnectWithPacketLoss() error {
 		defer wg.Done()
 
 		count := 0
+		start := time.Now()
 		for {
 			select {
 			case <-ctx.Done():
@@ -727,8 +1328,9 @@ func (tc *testConn) tryConnectWithPacketLoss() error {
 				// which is not the right kind of packet loss we want to trace.
 				// watch -n 1 'cat  /proc/net/udp' to monitor udp buffer overflow.
 
-				// Max 200 packets per second.
-				time.Sleep(5 * time.Millisecond)
+				// Pace the requests from the start of the test rather than sleeping for the
+				// interval, so that the time spent sending doesn't lower the rate.
+				time.Sleep(time.Until(start.Add(time.Duration(count) * streamSendInterval)))
 			}
 		}
 
@@ -835,9 +1437,79 @@ func (d *connectedUDP) Connect() error {
 	}
 	d.conn = conn.(*net.UDPConn)
 	d.r = bufio.NewReader(d.conn)
+	if dontFragment {
+		if err := setDontFragment(d.conn); err != nil {
+			return err
+		}
+	}
+	if requestECN >= 0 {
+		return setECN(d.conn, requestECN)
+	}
 	return nil
 }
 
+// padRequest returns the request, marshalled with enough padding that it, and the newline that
+// Send() adds, take up datagramLen bytes.  unpaddedLen is the length of the request marshalled
+// without padding.
+func padRequest(req connectivity.Request, unpaddedLen, datagramLen int) ([]byte, error) {
+	// The padding adds `,"Padding":"..."` to the JSON.
+	overhead := len(`,"Padding":""`)
+	padLen := datagramLen - unpaddedLen - len("\n") - overhead
+	if padLen < 0 {
+		return nil, fmt.Errorf("request is already %d bytes, more than %d", unpaddedLen+1, datagramLen)
+	}
+	req.Padding = strings.Repeat("x", padLen)
+	msg, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg)+1 != datagramLen {
+		return nil, fmt.Errorf("padded request is %d bytes, not %d", len(msg)+1, datagramLen)
+	}
+	return msg, nil
+}
+
+// setDontFragment makes the socket set the don't fragment bit, and never fragment locally,
+// so that sending a datagram bigger than the MTU fails.
+func setDontFragment(conn *net.UDPConn) error {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	level, opt, val := unix.IPPROTO_IP, unix.IP_MTU_DISCOVER, unix.IP_PMTUDISC_DO
+	if conn.LocalAddr().(*net.UDPAddr).IP.To4() == nil {
+		level, opt, val = unix.IPPROTO_IPV6, unix.IPV6_MTU_DISCOVER, unix.IPV6_PMTUDISC_DO
+	}
+	var sockErr error
+	err = rc.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), level, opt, val)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// setECN sets the ECN bits of the TOS, or IPv6 traffic class, that the socket sends with.
+func setECN(conn *net.UDPConn, ecn int) error {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	level, opt := unix.IPPROTO_IP, unix.IP_TOS
+	if conn.LocalAddr().(*net.UDPAddr).IP.To4() == nil {
+		level, opt = unix.IPPROTO_IPV6, unix.IPV6_TCLASS
+	}
+	var sockErr error
+	err = rc.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), level, opt, ecn)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
 func (d *connectedUDP) Send(msg []byte) error {
 	msg = append(msg, '\n')
 	_, err := d.conn.Write(msg)
@@ -1160,6 +1832,9 @@ func tcpForceV6(ip net.IP, port int) (net.Conn, error) {
 type connectedTCP struct {
 	localAddr  string
 	remoteAddr string
+	fastOpen   bool
+	// connectTimeout, if non-zero, limits how long Connect waits for the handshake.
+	connectTimeout time.Duration
 
 	conn net.Conn
 	r    *bufio.Reader
@@ -1192,6 +1867,30 @@ func (d *connectedTCP) Connect() error {
 		}
 	}
 
+	if conn == nil && d.fastOpen {
+		var err error
+		conn, err = dialFastOpen(d.localAddr, d.remoteAddr)
+		if err != nil {
+			return err
+		}
+	}
+
+	if conn == nil && d.connectTimeout != 0 {
+		nla, err := reuse.ResolveAddr("tcp", d.localAddr)
+		if err != nil {
+			return fmt.Errorf("failed to resolve local addr: %w", err)
+		}
+		dialer := net.Dialer{
+			LocalAddr: nla,
+			Control:   reuse.Control,
+			Timeout:   d.connectTimeout,
+		}
+		conn, err = dialer.Dial("tcp", d.remoteAddr)
+		if err != nil {
+			return err
+		}
+	}
+
 	if conn == nil {
 		var err error
 		conn, err = reuse.Dial("tcp", d.localAddr, d.remoteAddr)
@@ -1237,3 +1936,60 @@ func (d *connectedTCP) MTU() (int, error) {
 func (d *connectedTCP) SetReadDeadline(t time.Time) error {
 	return d.conn.SetReadDeadline(t)
 }
+
+// TCPI_OPT_SYN_DATA from linux/tcp.h, not defined by x/sys/unix.
+const tcpiOptSYNData = 0x20
+
+// SYNDataAcked returns whether the server acknowledged the data that we sent in
+// our SYN.  Only meaningful once the handshake has completed.
+func (d *connectedTCP) SYNDataAcked() (bool, error) {
+	sc, err := d.conn.(utils.HasSyscallConn).SyscallConn()
+	if err != nil {
+		return false, err
+	}
+
+	var info *unix.TCPInfo
+	var sysErr error
+	err = sc.Control(func(fd uintptr) {
+		info, sysErr = unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+	})
+	if err != nil {
+		return false, err
+	}
+	if sysErr != nil {
+		return false, sysErr
+	}
+
+	return info.Options&tcpiOptSYNData != 0, nil
+}
+
+// dialFastOpen dials a TCP connection with TCP fast open enabled.  The connect
+// returns straight away and the first write is carried in the SYN.  We don't
+// require a cookie so that the very first connection carries data.
+func dialFastOpen(localAddr, remoteAddr string) (net.Conn, error) {
+	nla, err := reuse.ResolveAddr("tcp", localAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve local addr: %w", err)
+	}
+	dialer := net.Dialer{
+		LocalAddr: nla,
+		Control: func(network, address string, c syscall.RawConn) error {
+			if err := reuse.Control(network, address, c); err != nil {
+				return err
+			}
+			var sysErr error
+			err := c.Control(func(fd uintptr) {
+				sysErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_FASTOPEN_CONNECT, 1)
+				if sysErr != nil {
+					return
+				}
+				sysErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_FASTOPEN_NO_COOKIE, 1)
+			})
+			if err != nil {
+				return err
+			}
+			return sysErr
+		},
+	}
+	return dialer.Dial("tcp", remoteAddr)
+}
@@ -16,6 +16,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -24,7 +25,10 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
+	"unsafe"
 
 	"github.com/projectcalico/calico/felix/fv/cgroup"
 	"github.com/projectcalico/calico/felix/fv/connectivity"
@@ -36,6 +40,7 @@ import (
 	"github.com/ishidawataru/sctp"
 	log "github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 const usage = `test-workload, test workload for Felix FV testing.
@@ -43,7 +48,7 @@ const usage = `test-workload, test workload for Felix FV testing.
 If <interface-name> is "", the workload will start in the current namespace.
 
 Usage:
-  test-workload [--protocol=<protocol>] [--namespace-path=<path>] [--sidecar-iptables] [--up-lo] [--mtu=<mtu>] [--listen-any-ip] <interface-name> <ip-address> <ports>
+  test-workload [--protocol=<protocol>] [--namespace-path=<path>] [--sidecar-iptables] [--up-lo] [--mtu=<mtu>] [--listen-any-ip] [--count-file=<path>] [--queue-file=<path>] <interface-name> <ip-address> <ports>
 `
 
 func main() {
@@ -79,6 +84,15 @@ func main() {
 		listenAnyIP = true
 	}
 
+	if arg, ok := arguments["--count-file"].(string); ok && arg != "" {
+		go writeConnCount(arg)
+	}
+
+	queueFile := ""
+	if arg, ok := arguments["--queue-file"].(string); ok {
+		queueFile = arg
+	}
+
 	ports := strings.Split(portsStr, ",")
 
 	var namespace ns.NetNS
@@ -300,11 +314,22 @@ func main() {
 				"localAddr":  conn.LocalAddr(),
 				"remoteAddr": conn.RemoteAddr(),
 			}).Info("Accepted new connection.")
+			atomic.AddUint64(&connCount, 1)
 			defer func() {
 				err := conn.Close()
 				log.WithError(err).Info("Closed connection.")
 			}()
 
+			mss := 0
+			windowScale := 0
+			if tcpConn, ok := conn.(*net.TCPConn); ok {
+				var err error
+				mss, err = utils.ConnMSS(tcpConn)
+				log.WithError(err).Infof("server MSS: %d", mss)
+				windowScale, err = utils.ConnWindowScale(tcpConn)
+				log.WithError(err).Infof("client window scale: %d", windowScale)
+			}
+
 			if hasSyscallConn, ok := conn.(utils.HasSyscallConn); ok {
 				mtu, err := utils.ConnMTU(hasSyscallConn)
 				log.WithError(err).Infof("server start PMTU: %d", mtu)
@@ -367,10 +392,12 @@ func main() {
 				}
 
 				response := connectivity.Response{
-					Timestamp:  time.Now(),
-					SourceAddr: seenSrc,
-					ServerAddr: seenLocal,
-					Request:    request,
+					Timestamp:   time.Now(),
+					SourceAddr:  seenSrc,
+					ServerAddr:  seenLocal,
+					MSS:         mss,
+					WindowScale: windowScale,
+					Request:     request,
 				}
 
 				respBytes, err := json.Marshal(&response)
@@ -409,6 +436,7 @@ func main() {
 		}
 
 		// Listen on each port.
+		var tcpListeners []*net.TCPListener
 		for _, port := range ports {
 			var myAddr string
 			if listenAnyIP {
@@ -464,9 +492,11 @@ func main() {
 				}()
 			} else {
 				logCxt.Info("About to listen for TCP connections")
-				l, err := net.Listen("tcp", myAddr)
+				lc := net.ListenConfig{Control: enableTCPFastOpen}
+				l, err := lc.Listen(context.Background(), "tcp", myAddr)
 				panicIfError(err)
 				logCxt.Info("Listening for TCP connections")
+				tcpListeners = append(tcpListeners, l.(*net.TCPListener))
 				go func() {
 					defer l.Close()
 					for {
@@ -477,6 +507,11 @@ func main() {
 				}()
 			}
 		}
+		if queueFile != "" && len(tcpListeners) > 0 {
+			// Sample on this goroutine, which stays in the workload's namespace, so that
+			// it sees the workload's half-open connections.
+			writeListenQueueStats(queueFile, tcpListeners)
+		}
 		for {
 			time.Sleep(10 * time.Second)
 		}
@@ -484,12 +519,149 @@ func main() {
 	panicIfError(err)
 }
 
+// writeListenQueueStats samples the given listeners' queues every 10ms and keeps the given file
+// up to date with their high-water marks, so that the test can find out whether connections got
+// as far as the workload's kernel.  It must run in the workload's network namespace.
+func writeListenQueueStats(path string, listeners []*net.TCPListener) {
+	ports := map[int]bool{}
+	for _, l := range listeners {
+		ports[l.Addr().(*net.TCPAddr).Port] = true
+	}
+	var stats, written connectivity.ListenQueueStats
+	for first := true; ; first = false {
+		accept := 0
+		for _, l := range listeners {
+			n, err := acceptQueueLen(l)
+			if err != nil {
+				log.WithError(err).Warn("Failed to read accept queue length")
+			}
+			accept += n
+		}
+		if accept > stats.AcceptQueueMax {
+			stats.AcceptQueueMax = accept
+		}
+		if n := countSynRecv(ports); n > stats.SynRecvMax {
+			stats.SynRecvMax = n
+		}
+		if first || stats != written {
+			bs, err := json.Marshal(stats)
+			panicIfError(err)
+			tmp := path + ".tmp"
+			if err := os.WriteFile(tmp, bs, 0644); err != nil {
+				log.WithError(err).Warn("Failed to write listen queue stats")
+			} else if err := os.Rename(tmp, path); err != nil {
+				log.WithError(err).Warn("Failed to rename listen queue stats file")
+			} else {
+				written = stats
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// acceptQueueLen returns the number of connections waiting for the listener to accept them.
+// For a listening socket, the kernel reports that length in tcpi_unacked.
+func acceptQueueLen(l *net.TCPListener) (int, error) {
+	c, err := l.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var info *unix.TCPInfo
+	var sysErr error
+	err = c.Control(func(fd uintptr) {
+		info, sysErr = unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if sysErr != nil {
+		return 0, sysErr
+	}
+	return int(info.Unacked), nil
+}
+
+// tcpStateSynRecv is TCP_SYN_RECV in the st column of /proc/net/tcp.
+const tcpStateSynRecv = "03"
+
+// countSynRecv returns the number of half-open connections to the given local ports in the
+// calling thread's network namespace.
+func countSynRecv(ports map[int]bool) int {
+	count := 0
+	for _, file := range []string{"/proc/thread-self/net/tcp", "/proc/thread-self/net/tcp6"} {
+		bs, err := os.ReadFile(file)
+		if err != nil {
+			// No tcp6 file if IPv6 is disabled.
+			continue
+		}
+		for _, line := range strings.Split(string(bs), "\n")[1:] {
+			// sl local_address rem_address st ...; addresses are <hex IP>:<hex port>.
+			fields := strings.Fields(line)
+			if len(fields) < 4 || fields[3] != tcpStateSynRecv {
+				continue
+			}
+			local := fields[1]
+			port, err := strconv.ParseInt(local[strings.LastIndex(local, ":")+1:], 16, 32)
+			if err == nil && ports[int(port)] {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// connCount is the number of TCP and SCTP connections that the workload has accepted plus
+// the number of UDP and raw IP requests, other than those of a stream, that it has answered.
+var connCount uint64
+
+// writeConnCount keeps the given file up to date with connCount, so that the test can find out
+// how many connections reached the workload.
+func writeConnCount(path string) {
+	var written uint64
+	for first := true; ; first = false {
+		n := atomic.LoadUint64(&connCount)
+		if first || n != written {
+			tmp := path + ".tmp"
+			if err := os.WriteFile(tmp, []byte(strconv.FormatUint(n, 10)), 0644); err != nil {
+				log.WithError(err).Warn("Failed to write connection count")
+			} else if err := os.Rename(tmp, path); err != nil {
+				log.WithError(err).Warn("Failed to rename connection count file")
+			} else {
+				written = n
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
 func loopRespondingToPackets(logCxt *log.Entry, p net.PacketConn) {
 	defer p.Close()
+	// Number of requests received so far for each sequenced probe, keyed on the probe's
+	// request ID.
+	sequencedReceived := map[string]int{}
+	udpConn, isUDP := p.(*net.UDPConn)
+	if isUDP {
+		enableRecvTOS(logCxt, udpConn)
+	}
 	for {
-		buffer := make([]byte, 1024)
-		n, addr, err := p.ReadFrom(buffer)
-		panicIfError(err)
+		// Big enough for the largest UDP datagram, since padded requests may be reassembled
+		// from several fragments.
+		buffer := make([]byte, 65536)
+		var (
+			n    int
+			addr net.Addr
+			err  error
+			ecn  connectivity.ECN
+		)
+		if isUDP {
+			oob := make([]byte, 128)
+			var oobn int
+			n, oobn, _, addr, err = udpConn.ReadMsgUDP(buffer, oob)
+			panicIfError(err)
+			ecn = receivedECN(oob[:oobn])
+		} else {
+			n, addr, err = p.ReadFrom(buffer)
+			panicIfError(err)
+		}
 
 		var request connectivity.Request
 		err = json.Unmarshal(buffer[:n], &request)
@@ -498,11 +670,22 @@ func loopRespondingToPackets(logCxt *log.Entry, p net.PacketConn) {
 			continue
 		}
 
+		// Don't send the padding back; the response only needs to say how much arrived.
+		request.Padding = ""
 		response := connectivity.Response{
-			Timestamp:  time.Now(),
-			SourceAddr: addr.String(),
-			ServerAddr: p.LocalAddr().String(),
-			Request:    request,
+			Timestamp:   time.Now(),
+			SourceAddr:  addr.String(),
+			ServerAddr:  p.LocalAddr().String(),
+			Request:     request,
+			ECN:         ecn,
+			ReceivedLen: n,
+		}
+		if !connectivity.IsMessagePartOfStream(request.Payload) {
+			atomic.AddUint64(&connCount, 1)
+		}
+		if request.Sequence > 0 {
+			sequencedReceived[request.ID]++
+			response.ReceivedIndex = sequencedReceived[request.ID]
 		}
 
 		data, err := json.Marshal(&response)
@@ -521,6 +704,58 @@ func loopRespondingToPackets(logCxt *log.Entry, p net.PacketConn) {
 	}
 }
 
+// enableRecvTOS asks the kernel to report the TOS, or IPv6 traffic class, of each datagram that
+// the socket receives, so that the server can report the ECN codepoint that arrived.
+func enableRecvTOS(logCxt *log.Entry, conn *net.UDPConn) {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		logCxt.WithError(err).Warn("Failed to get raw UDP socket")
+		return
+	}
+	err = rc.Control(func(fd uintptr) {
+		// The socket may be IPv4 or IPv6 so one of these is expected to fail.
+		_ = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_RECVTOS, 1)
+		_ = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_RECVTCLASS, 1)
+	})
+	if err != nil {
+		logCxt.WithError(err).Warn("Failed to enable receiving TOS")
+	}
+}
+
+// receivedECN returns the ECN codepoint from a received datagram's control messages, or the
+// empty ECN if they don't include the TOS or traffic class.
+func receivedECN(oob []byte) connectivity.ECN {
+	msgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return ""
+	}
+	for _, m := range msgs {
+		switch {
+		case m.Header.Level == unix.IPPROTO_IP && m.Header.Type == unix.IP_TOS && len(m.Data) >= 1:
+			return connectivity.ECNFromTOS(int(m.Data[0]))
+		case m.Header.Level == unix.IPPROTO_IPV6 && m.Header.Type == unix.IPV6_TCLASS && len(m.Data) >= 4:
+			return connectivity.ECNFromTOS(int(*(*int32)(unsafe.Pointer(&m.Data[0]))))
+		}
+	}
+	return ""
+}
+
+// enableTCPFastOpen enables TCP fast open on a listening socket, without requiring
+// a cookie, so that clients can send data in their SYN.  The kernel only honours
+// it if net.ipv4.tcp_fastopen has the server bit set so it is a no-op by default.
+func enableTCPFastOpen(network, address string, c syscall.RawConn) error {
+	err := c.Control(func(fd uintptr) {
+		if err := unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_FASTOPEN, 16); err != nil {
+			log.WithError(err).Warn("Failed to enable TCP fast open")
+			return
+		}
+		if err := unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_FASTOPEN_NO_COOKIE, 1); err != nil {
+			log.WithError(err).Warn("Failed to disable TCP fast open cookies")
+		}
+	})
+	return err
+}
+
 func panicIfError(err error) {
 	if err != nil {
 		panic(err)
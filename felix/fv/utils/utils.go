@@ -24,10 +24,12 @@ import (
 	"strings"
 	"syscall"
 	"time"
+	"unsafe"
 
 	"github.com/kelseyhightower/envconfig"
 	. "github.com/onsi/gomega"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
 
 	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
 
@@ -259,6 +261,65 @@ func ConnMTU(hsc HasSyscallConn) (int, error) {
 	return mtu, nil
 }
 
+// ConnMSS returns the maximum segment size of a connected TCP connection; that is, the
+// MSS advertised by the peer, less the space for any TCP options.
+func ConnMSS(hsc HasSyscallConn) (int, error) {
+	c, err := hsc.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	mss := 0
+	var sysErr error
+	err = c.Control(func(fd uintptr) {
+		mss, sysErr = syscall.GetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_MAXSEG)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if sysErr != nil {
+		return 0, sysErr
+	}
+
+	return mss, nil
+}
+
+// TCPI_OPT_WSCALE from linux/tcp.h, not defined by x/sys/unix.
+const tcpiOptWScale = 0x4
+
+// ConnWindowScale returns the window scale that the peer of a connected TCP connection
+// advertised in its SYN, or 0 if the connection doesn't use window scaling; for example,
+// because the option was stripped from the SYN.
+func ConnWindowScale(hsc HasSyscallConn) (int, error) {
+	c, err := hsc.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var info *unix.TCPInfo
+	var sysErr error
+	err = c.Control(func(fd uintptr) {
+		info, sysErr = unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if sysErr != nil {
+		return 0, sysErr
+	}
+
+	if info.Options&tcpiOptWScale == 0 {
+		return 0, nil
+	}
+	// The kernel packs tcpi_snd_wscale and tcpi_rcv_wscale into the 4-bit halves of
+	// the byte after tcpi_options, which x/sys/unix leaves as padding.  tcpi_snd_wscale,
+	// the peer's scale, is the low half.
+	wscale := *(*uint8)(unsafe.Add(unsafe.Pointer(info), unsafe.Offsetof(info.Options)+1))
+	return int(wscale & 0xf), nil
+}
+
 func UpdateFelixConfig(client client.Interface, deltaFn func(*api.FelixConfiguration)) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
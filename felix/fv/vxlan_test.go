@@ -206,8 +206,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ VXLAN topology before addin
 				}
 			})
 			It("should have workload to workload connectivity", func() {
-				cc.ExpectSome(w[0], w[1])
-				cc.ExpectSome(w[1], w[0])
+				cc.ExpectMutualSome(w[0], w[1])
 				cc.CheckConnectivity()
 			})
 
@@ -282,8 +281,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ VXLAN topology before addin
 					cc.ExpectNone(felixes[0], hostW[1])
 					cc.ExpectNone(felixes[1], hostW[0])
 					// But the rules to allow VXLAN between our hosts let the workload traffic through.
-					cc.ExpectSome(w[0], w[1])
-					cc.ExpectSome(w[1], w[0])
+					cc.ExpectMutualSome(w[0], w[1])
 					cc.CheckConnectivity()
 				})
 			})
@@ -326,8 +324,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ VXLAN topology before addin
 					cc.ExpectSome(felixes[1], w[1])
 
 					// But the rules to allow VXLAN between our hosts let the workload traffic through.
-					cc.ExpectSome(w[0], w[1])
-					cc.ExpectSome(w[1], w[0])
+					cc.ExpectMutualSome(w[0], w[1])
 					cc.CheckConnectivity()
 				})
 
@@ -347,8 +344,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ VXLAN topology before addin
 					cc.ExpectNone(felixes[1], hostW[0])
 
 					// Workload connectivity is unchanged.
-					cc.ExpectSome(w[0], w[1])
-					cc.ExpectSome(w[1], w[0])
+					cc.ExpectMutualSome(w[0], w[1])
 					cc.CheckConnectivity()
 
 					cc.ResetExpectations()
@@ -368,8 +364,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ VXLAN topology before addin
 					cc.ExpectNone(felixes[1], hostW[0])
 
 					// Workload connectivity is unchanged.
-					cc.ExpectSome(w[0], w[1])
-					cc.ExpectSome(w[1], w[0])
+					cc.ExpectMutualSome(w[0], w[1])
 					cc.CheckConnectivity()
 				})
 
@@ -460,8 +455,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ VXLAN topology before addin
 						return getNumIPSetMembers(felixes[0].Container, "cali40all-vxlan-net")
 					}, "5s", "200ms").Should(Equal(len(felixes) - 2))
 
-					cc.ExpectSome(w[0], w[1])
-					cc.ExpectSome(w[1], w[0])
+					cc.ExpectMutualSome(w[0], w[1])
 					cc.ExpectNone(w[0], w[2])
 					cc.ExpectNone(w[1], w[2])
 					cc.ExpectNone(w[2], w[0])
@@ -502,8 +496,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ VXLAN topology before addin
 					It("after manually removing third node from allow list should have expected connectivity", func() {
 						felixes[0].Exec("ipset", "del", "cali40all-vxlan-net", felixes[2].IP)
 
-						cc.ExpectSome(w[0], w[1])
-						cc.ExpectSome(w[1], w[0])
+						cc.ExpectMutualSome(w[0], w[1])
 						cc.ExpectSome(w[1], w[2])
 						cc.ExpectNone(w[2], w[0])
 						cc.CheckConnectivity()
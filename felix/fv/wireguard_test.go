@@ -860,12 +860,10 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ WireGuard-Supported", []api
 					}, "5s", "100ms").ShouldNot(HaveOccurred())
 
 					if wireguardEnabledV4 {
-						cc.ExpectNone(wlsV4[0], wlsV4[1])
-						cc.ExpectNone(wlsV4[1], wlsV4[0])
+						cc.ExpectMutualNone(wlsV4[0], wlsV4[1])
 					}
 					if wireguardEnabledV6 {
-						cc.ExpectNone(wlsV6[0], wlsV6[1])
-						cc.ExpectNone(wlsV6[1], wlsV6[0])
+						cc.ExpectMutualNone(wlsV6[0], wlsV6[1])
 					}
 					cc.CheckConnectivity()
 
@@ -911,12 +909,10 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ WireGuard-Supported", []api
 					}, "5s", "100ms").ShouldNot(HaveOccurred())
 
 					if wireguardEnabledV4 {
-						cc.ExpectSome(wlsV4[0], wlsV4[1])
-						cc.ExpectSome(wlsV4[1], wlsV4[0])
+						cc.ExpectMutualSome(wlsV4[0], wlsV4[1])
 					}
 					if wireguardEnabledV6 {
-						cc.ExpectSome(wlsV6[0], wlsV6[1])
-						cc.ExpectSome(wlsV6[1], wlsV6[0])
+						cc.ExpectMutualSome(wlsV6[0], wlsV6[1])
 					}
 					cc.CheckConnectivity()
 
@@ -1012,12 +1008,10 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ WireGuard-Supported", []api
 
 				It("workload connectivity remains but uses un-encrypted tunnel", func() {
 					if wireguardEnabledV4 {
-						cc.ExpectSome(wlsV4[0], wlsV4[1])
-						cc.ExpectSome(wlsV4[1], wlsV4[0])
+						cc.ExpectMutualSome(wlsV4[0], wlsV4[1])
 					}
 					if wireguardEnabledV6 {
-						cc.ExpectSome(wlsV6[0], wlsV6[1])
-						cc.ExpectSome(wlsV6[1], wlsV6[0])
+						cc.ExpectMutualSome(wlsV6[0], wlsV6[1])
 					}
 					cc.CheckConnectivity()
 
@@ -1319,8 +1313,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ WireGuard-Supported 3 node
 		})
 
 		It("transfer should be encrypted/plain between workloads on WireGuard enabled/disabled nodes", func() {
-			cc.ExpectSome(wls[0], wls[1])
-			cc.ExpectSome(wls[1], wls[0])
+			cc.ExpectMutualSome(wls[0], wls[1])
 			cc.CheckConnectivity()
 
 			By("verifying packets between felix-0 and felix-1 is encrypted")
@@ -1335,8 +1328,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ WireGuard-Supported 3 node
 
 			cc.ResetExpectations()
 
-			cc.ExpectSome(wls[2], wls[0])
-			cc.ExpectSome(wls[0], wls[2])
+			cc.ExpectMutualSome(wls[2], wls[0])
 			cc.CheckConnectivity()
 
 			By("verifying packets between felix-0 and felix-2 are not encrypted")
@@ -1625,8 +1617,7 @@ var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ WireGuard-Supported 3-node
 		}
 
 		By("verifying packets between felix-0 and felix-1 is encrypted")
-		cc.ExpectSome(wlsByHost[0][1], wlsByHost[1][0])
-		cc.ExpectSome(wlsByHost[1][0], wlsByHost[0][1])
+		cc.ExpectMutualSome(wlsByHost[0][1], wlsByHost[1][0])
 		cc.CheckConnectivity()
 
 		for i := range []int{0, 1} {
@@ -16,6 +16,7 @@ package workload
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -34,9 +35,11 @@ import (
 	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
 	"github.com/projectcalico/calico/libcalico-go/lib/options"
 
+	"github.com/projectcalico/calico/felix/bpf"
 	"github.com/projectcalico/calico/felix/fv/connectivity"
 	"github.com/projectcalico/calico/felix/fv/containers"
 	"github.com/projectcalico/calico/felix/fv/infrastructure"
+	"github.com/projectcalico/calico/felix/fv/metrics"
 	"github.com/projectcalico/calico/felix/fv/tcpdump"
 	"github.com/projectcalico/calico/felix/fv/utils"
 )
@@ -109,6 +112,31 @@ func (w *Workload) Stop() {
 	}
 }
 
+// Migrate simulates a live migration: it stops the workload on one Felix and starts it again,
+// with the same name, IP and ports, on another.  Only the workload itself moves; if it's in the
+// datastore then RemoveFromInfra before and ConfigureInInfra after moves its endpoint too.
+func (w *Workload) Migrate(from, to *infrastructure.Felix) {
+	Expect(w.C).To(BeIdenticalTo(from.Container), "workload isn't running on the Felix it's migrating from")
+	log.WithFields(log.Fields{"workload": w.Name, "from": from.Name, "to": to.Name}).Info("Migrating workload")
+	w.Stop()
+	for i, fw := range from.Workloads {
+		if fw == w {
+			from.Workloads = append(from.Workloads[:i], from.Workloads[i+1:]...)
+			break
+		}
+	}
+
+	w.C = to.Container
+	w.WorkloadEndpoint.Spec.Node = to.Hostname
+	to.Workloads = append(to.Workloads, w)
+	err := w.Start()
+	if err != nil {
+		log.WithError(err).Info("Starting migrated workload failed, retrying")
+		err = w.Start()
+	}
+	Expect(err).NotTo(HaveOccurred())
+}
+
 func Run(c *infrastructure.Felix, name, profile, ip, ports, protocol string, opts ...Opt) (w *Workload) {
 	w, err := run(c, name, profile, ip, ports, protocol, opts...)
 	if err != nil {
@@ -120,6 +148,16 @@ func Run(c *infrastructure.Felix, name, profile, ip, ports, protocol string, opt
 	return w
 }
 
+// RunInIPPool runs a workload on the given Felix with an address that Calico IPAM allocates
+// from the named pool, and adds the workload to the datastore.
+func RunInIPPool(infra infrastructure.DatastoreInfra, c *infrastructure.Felix, poolName, name, profile, ports, protocol string, opts ...Opt) *Workload {
+	ip, err := infra.AssignIPFromPool(c, poolName)
+	Expect(err).NotTo(HaveOccurred())
+	w := Run(c, name, profile, ip, ports, protocol, opts...)
+	w.ConfigureInInfra(infra)
+	return w
+}
+
 type Opt func(*Workload)
 
 func WithMTU(mtu int) Opt {
@@ -134,6 +172,16 @@ func WithListenAnyIP() Opt {
 	}
 }
 
+// WithHostNetworking runs the workload in the host's network namespace even though its IP isn't
+// the host's main IP, for example to listen on an address of a secondary or VLAN interface.
+func WithHostNetworking() Opt {
+	return func(w *Workload) {
+		w.InterfaceName = ""
+		w.SpoofInterfaceName = ""
+		w.WorkloadEndpoint.Spec.InterfaceName = ""
+	}
+}
+
 func New(c *infrastructure.Felix, name, profile, ip, ports, protocol string, opts ...Opt) *Workload {
 	workloadIdx++
 	n := fmt.Sprintf("%s-idx%v", name, workloadIdx)
@@ -213,6 +261,9 @@ func (w *Workload) Start() error {
 		command += " --listen-any-ip"
 	}
 
+	command += " --count-file=" + w.countFile()
+	command += " --queue-file=" + w.queueFile()
+
 	w.runCmd = utils.Command("docker", "exec", w.C.Name, "sh", "-c", command)
 	w.outPipe, err = w.runCmd.StdoutPipe()
 	if err != nil {
@@ -604,6 +655,46 @@ func (w *Workload) StartPersistentConnection(ip string, port int,
 	return pc
 }
 
+// OpenConns opens n connections concurrently from this workload to the given IP
+// and port, and holds them open until the returned set is stopped.  Use
+// NumEstablished() on the result to check how many of the connections succeeded.
+func (w *Workload) OpenConns(ip string, port, n int) *connectivity.ConnectionSet {
+	cs := &connectivity.ConnectionSet{
+		RuntimeName:   w.C.Name,
+		Runtime:       w.C,
+		IP:            ip,
+		Port:          port,
+		Protocol:      w.Protocol,
+		NumConns:      n,
+		NamespacePath: w.namespacePath,
+	}
+
+	err := cs.Start()
+	Expect(err).NotTo(HaveOccurred())
+
+	return cs
+}
+
+// StartTraffic starts sending a steady stream of requests from this workload to
+// the given IP and port, in the background, for the given duration.  Call
+// ExpectNoLoss() or Wait() on the result to check how many of them got a response.
+// Only supported for UDP workloads.
+func (w *Workload) StartTraffic(ip string, port int, duration time.Duration) *connectivity.Traffic {
+	t := &connectivity.Traffic{
+		RuntimeName:   w.C.Name,
+		IP:            ip,
+		Port:          port,
+		Protocol:      w.Protocol,
+		NamespacePath: w.namespacePath,
+		Duration:      duration,
+	}
+
+	err := t.Start()
+	Expect(err).NotTo(HaveOccurred())
+
+	return t
+}
+
 func (w *Workload) ToMatcher(explicitPort ...uint16) *connectivity.Matcher {
 	var port string
 	if len(explicitPort) == 1 {
@@ -623,6 +714,88 @@ func (w *Workload) ToMatcher(explicitPort ...uint16) *connectivity.Matcher {
 	}
 }
 
+// PolicyDrops returns the number of packets that the named policy has dropped on the workload's
+// host, so that the workload can be the target of connectivity.Checker.ExpectBlockedByPolicy.
+func (w *Workload) PolicyDrops(policy string) (int, error) {
+	return metrics.GetFelixPolicyDroppedPackets(w.C.IP, policy)
+}
+
+// XDPDrops returns the number of packets from the given source IP that the blocklist XDP program
+// on the host's eth0 has dropped, so that the workload can be the target of
+// connectivity.Checker.ExpectBlockedDistinctFromRPF.  A source that XDP hasn't dropped anything
+// from counts as zero.  Not available in BPF mode.
+func (w *Workload) XDPDrops(src string) (int, error) {
+	counts, err := bpf.PerSourceDropCounts(w.C, "eth0")
+	if err != nil {
+		return 0, err
+	}
+	return int(counts[src]), nil
+}
+
+// RPFDrops returns the number of packets that the host's kernel has dropped because they failed
+// the reverse path filter, so that the workload can be the target of
+// connectivity.Checker.ExpectBlockedDistinctFromRPF.  The kernel only counts them if the filter
+// is strict (rp_filter=1) on the interface that they arrive on.
+func (w *Workload) RPFDrops() (int, error) {
+	out, err := w.C.ExecOutput("cat", "/proc/net/netstat")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read /proc/net/netstat: %w", err)
+	}
+	// Like /proc/net/snmp, each group has a line of names followed by a line of values.
+	var header []string
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.HasPrefix(line, "TcpExt: ") {
+			continue
+		}
+		if header == nil {
+			header = strings.Fields(line)
+			continue
+		}
+		values := strings.Fields(line)
+		for i, name := range header {
+			if name == "IPReversePathFilter" && i < len(values) {
+				return strconv.Atoi(values[i])
+			}
+		}
+		break
+	}
+	return 0, fmt.Errorf("no IPReversePathFilter counter in /proc/net/netstat:\n%s", out)
+}
+
+// ConnectionCount returns the number of connections, or UDP requests, that the workload has
+// received since it started, so that the workload can be a target of
+// connectivity.Checker.ExpectBalancedAcross.  The workload updates the count every 100ms.
+func (w *Workload) ConnectionCount() (int, error) {
+	out, err := w.C.ExecOutput("cat", w.countFile())
+	if err != nil {
+		// The workload writes the file shortly after starting.
+		return 0, fmt.Errorf("failed to read %s connection count: %w", w.Name, err)
+	}
+	return strconv.Atoi(strings.TrimSpace(out))
+}
+
+func (w *Workload) countFile() string {
+	return fmt.Sprintf("/tmp/%s.count", w.Name)
+}
+
+// ListenQueueStats returns the high-water marks of the queues of the workload's TCP listeners
+// since it started.  The workload updates them every 10ms; queue lengths that last a shorter
+// time may be missed.
+func (w *Workload) ListenQueueStats() (connectivity.ListenQueueStats, error) {
+	var stats connectivity.ListenQueueStats
+	out, err := w.C.ExecOutput("cat", w.queueFile())
+	if err != nil {
+		// The workload writes the file shortly after starting.
+		return stats, fmt.Errorf("failed to read %s listen queue stats: %w", w.Name, err)
+	}
+	err = json.Unmarshal([]byte(out), &stats)
+	return stats, err
+}
+
+func (w *Workload) queueFile() string {
+	return fmt.Sprintf("/tmp/%s.queues", w.Name)
+}
+
 const nsprefix = "/var/run/netns/"
 
 func (w *Workload) netns() string {
@@ -633,6 +806,12 @@ func (w *Workload) netns() string {
 	return ""
 }
 
+// HasRouteTo returns whether the workload has a usable route to the given IP, so that it can be
+// a connectivity.RouteChecker.
+func (w *Workload) HasRouteTo(ip string) (bool, error) {
+	return connectivity.ParseRouteGet(w.RunCmd("ip", "route", "get", ip))
+}
+
 func (w *Workload) RunCmd(cmd string, args ...string) (string, error) {
 	netns := w.netns()
 	dockerArgs := []string{"exec", w.C.Name}
@@ -707,16 +886,33 @@ func (s *SpoofedWorkload) appendSourceIPOpt(opts []connectivity.CheckOption) []c
 type Port struct {
 	*Workload
 	Port uint16
+	// LocalAddr, if set, is the local address that the client binds before connecting.
+	LocalAddr string
+}
+
+// WithLocalAddr returns a copy of the port that binds the given local address before
+// connecting; for a workload with several addresses, it chooses the source address.
+func (p *Port) WithLocalAddr(ip string) *Port {
+	p2 := *p
+	p2.LocalAddr = ip
+	return &p2
 }
 
 func (p *Port) SourceName() string {
+	name := p.Name
+	if p.LocalAddr != "" {
+		name = fmt.Sprintf("%s[%s]", name, p.LocalAddr)
+	}
 	if p.Port == 0 {
-		return p.Name
+		return name
 	}
-	return fmt.Sprintf("%s:%d", p.Name, p.Port)
+	return fmt.Sprintf("%s:%d", name, p.Port)
 }
 
 func (p *Port) SourceIPs() []string {
+	if p.LocalAddr != "" {
+		return []string{p.LocalAddr}
+	}
 	return []string{p.IP}
 }
 
@@ -736,6 +932,9 @@ func (p *Port) maybeAppendPortOpt(opts []connectivity.CheckOption) []connectivit
 	if p.Port != 0 {
 		opts = append(opts, connectivity.WithSourcePort(strconv.Itoa(int(p.Port))))
 	}
+	if p.LocalAddr != "" {
+		opts = append(opts, connectivity.WithSourceIP(p.LocalAddr))
+	}
 	return opts
 }
 
@@ -0,0 +1,219 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build fvtests
+
+package fv_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/fv/connectivity"
+	"github.com/projectcalico/calico/felix/fv/infrastructure"
+	"github.com/projectcalico/calico/felix/fv/utils"
+	"github.com/projectcalico/calico/felix/fv/workload"
+	"github.com/projectcalico/calico/kube-controllers/pkg/config"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/autoblocklist"
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+// Felix exports the sources that exceed XDPAutoBlocklistConnRate on its Node, and the
+// auto-blocklist controller in kube-controllers turns them into a GlobalNetworkSet while the
+// XDPAutoBlocklist exists.  These tests run the controller in-process against the test's API
+// server.  The BPF dataplane doesn't support automatic blocklisting.
+var _ = infrastructure.DatastoreDescribe("XDP automatic blocklisting of high connection rate sources",
+	[]apiconfig.DatastoreType{apiconfig.Kubernetes},
+	func(getInfra infrastructure.InfraFactory) {
+		const clnt, srvr = 0, 1
+
+		var (
+			infra      infrastructure.DatastoreInfra
+			felixes    []*infrastructure.Felix
+			client     client.Interface
+			cc         *connectivity.Checker
+			hostW      [2]*workload.Workload
+			netSetName string
+			stopCtrl   chan struct{}
+			cancelCtrl context.CancelFunc
+		)
+
+		BeforeEach(func() {
+			if BPFMode() {
+				Skip("Automatic blocklisting is not supported in BPF mode")
+			}
+			if err := bpf.SupportsXDP(); err != nil {
+				Skip(fmt.Sprintf("XDP acceleration not supported: %v", err))
+			}
+			infra = getInfra()
+			opts := infrastructure.DefaultTopologyOptions()
+			felixes, client = infrastructure.StartNNodeTopology(2, opts, infra)
+			netSetName = autoblocklist.NetworkSetNamePrefix + felixes[srvr].Hostname
+
+			err := infra.AddAllowToDatastore("host-endpoint=='true'")
+			Expect(err).NotTo(HaveOccurred())
+
+			for ii := range felixes {
+				hostW[ii] = workload.Run(felixes[ii], fmt.Sprintf("host%d", ii), "", felixes[ii].IP, "8055", "tcp")
+			}
+
+			hostEp := api.NewHostEndpoint()
+			hostEp.Name = "host-endpoint-server"
+			hostEp.Labels = map[string]string{"host-endpoint": "true", "role": "server"}
+			hostEp.Spec.Node = felixes[srvr].Hostname
+			hostEp.Spec.InterfaceName = "eth0"
+			hostEp.Spec.ExpectedIPs = []string{felixes[srvr].IP}
+			_, err = client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			order := float64(5)
+			autoPolicy := api.NewGlobalNetworkPolicy()
+			autoPolicy.Name = "xdp-auto"
+			autoPolicy.Spec.Order = &order
+			autoPolicy.Spec.DoNotTrack = true
+			autoPolicy.Spec.ApplyOnForward = true
+			autoPolicy.Spec.Selector = "role=='server'"
+			autoPolicy.Spec.Ingress = []api.Rule{{
+				Action: api.Deny,
+				Source: api.EntityRule{
+					Selector: api.LabelAutoBlocklist + "=='true'",
+				},
+			}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, autoPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			connRate := 20
+			fc := api.NewFelixConfiguration()
+			fc.Name = "default"
+			fc.Spec.XDPAutoBlocklistConnRate = &connRate
+			_, err = client.FelixConfigurations().Create(utils.Ctx, fc, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Felix restarts to pick up the new config and then records over-rate sources in
+			// the raw table.
+			Eventually(func() string {
+				out, _ := felixes[srvr].ExecOutput("iptables-save", "-t", "raw")
+				return out
+			}, "20s", "500ms").Should(ContainSubstring("--name cali-autobl"))
+
+			autoBlocklist := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "crd.projectcalico.org/v1",
+				"kind":       "XDPAutoBlocklist",
+				"metadata":   map[string]interface{}{"name": "default"},
+				"spec":       map[string]interface{}{"expiry": "1m"},
+			}}
+			_, err = infra.(*infrastructure.K8sDatastoreInfra).DynamicClient.Resource(autoblocklist.XDPAutoBlocklistResource).
+				Create(utils.Ctx, autoBlocklist, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			var ctx context.Context
+			ctx, cancelCtrl = context.WithCancel(context.Background())
+			stopCtrl = make(chan struct{})
+			ctrl := autoblocklist.NewAutoBlocklistController(ctx,
+				infra.(*infrastructure.K8sDatastoreInfra).DynamicClient, client,
+				config.GenericControllerConfig{ReconcilerPeriod: time.Second})
+			go ctrl.Run(stopCtrl)
+
+			cc = &connectivity.Checker{Protocol: "tcp"}
+		})
+
+		AfterEach(func() {
+			if CurrentGinkgoTestDescription().Failed {
+				infra.DumpErrorData()
+				felixes[srvr].Exec("iptables-save", "-c")
+				felixes[srvr].Exec("ipset", "list")
+			}
+			close(stopCtrl)
+			cancelCtrl()
+			for _, wl := range hostW {
+				wl.Stop()
+			}
+			for _, felix := range felixes {
+				felix.Stop()
+			}
+			infra.Stop()
+		})
+
+		expectClientBlocked := func(blocked bool) {
+			if blocked {
+				cc.ExpectNone(hostW[clnt], hostW[srvr])
+			} else {
+				cc.ExpectSome(hostW[clnt], hostW[srvr])
+			}
+			cc.CheckConnectivityOffset(1)
+			cc.ResetExpectations()
+		}
+
+		netSetNets := func() []string {
+			netSet, err := client.GlobalNetworkSets().Get(utils.Ctx, netSetName, options.GetOptions{})
+			if err != nil {
+				return nil
+			}
+			return netSet.Spec.Nets
+		}
+
+		It("should blocklist and then drop a source that opens connections too quickly", func() {
+			expectClientBlocked(false)
+
+			conns := hostW[clnt].OpenConns(hostW[srvr].IP, 8055, 100)
+			conns.Stop()
+
+			By("exporting the source on the server's Node")
+			Eventually(func() string {
+				node, err := client.Nodes().Get(utils.Ctx, felixes[srvr].Hostname, options.GetOptions{})
+				if err != nil {
+					return ""
+				}
+				return node.Annotations[api.AnnotationAutoBlocklistSources]
+			}, "10s", "500ms").Should(ContainSubstring(hostW[clnt].IP))
+
+			By("adding it to the server's GlobalNetworkSet, scoped to the server")
+			Eventually(netSetNets, "10s", "500ms").Should(ContainElement(hostW[clnt].IP + "/32"))
+			netSet, err := client.GlobalNetworkSets().Get(utils.Ctx, netSetName, options.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netSet.Labels).To(HaveKeyWithValue(api.LabelNodeScope, felixes[srvr].Hostname))
+
+			By("programming the XDP blocklist")
+			Eventually(func() ([]string, error) {
+				return felixes[srvr].XDPBlocklistCIDRs("eth0")
+			}, "10s", "1s").Should(ContainElement(hostW[clnt].IP + "/32"))
+			expectClientBlocked(true)
+
+			By("removing the GlobalNetworkSet when the XDPAutoBlocklist is deleted")
+			err = infra.(*infrastructure.K8sDatastoreInfra).DynamicClient.Resource(autoblocklist.XDPAutoBlocklistResource).
+				Delete(utils.Ctx, "default", metav1.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(netSetNets, "10s", "500ms").Should(BeNil())
+			expectClientBlocked(false)
+		})
+
+		It("should not blocklist a source that stays under the rate", func() {
+			for i := 0; i < 5; i++ {
+				expectClientBlocked(false)
+				time.Sleep(time.Second)
+			}
+			Expect(netSetNets()).To(BeNil())
+		})
+	})
@@ -0,0 +1,213 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build fvtests
+
+package fv_test
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/fv/infrastructure"
+	"github.com/projectcalico/calico/felix/fv/utils"
+	"github.com/projectcalico/calico/felix/fv/workload"
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+)
+
+// The XDP benchmark sends the same flood of blocklisted packets to a node in iptables mode and
+// to a node in BPF mode and reports the rate at which each dropped them and the CPU that it
+// took.  The numbers depend heavily on the host so the benchmark doesn't assert anything about
+// them; it is slow, so it only runs if FV_XDP_BENCHMARK=true.
+var _ = infrastructure.DatastoreDescribe("XDP drop benchmark, iptables mode vs BPF mode",
+	[]apiconfig.DatastoreType{apiconfig.EtcdV3},
+	func(getInfra infrastructure.InfraFactory) {
+		const (
+			clnt       = 0
+			iptSrvr    = 1
+			bpfSrvr    = 2
+			numPackets = 200000
+		)
+
+		var (
+			infra   infrastructure.DatastoreInfra
+			felixes []*infrastructure.Felix
+			hostW   [3]*workload.Workload
+			client  client.Interface
+		)
+
+		BeforeEach(func() {
+			if os.Getenv("FV_XDP_BENCHMARK") != "true" {
+				Skip("Set FV_XDP_BENCHMARK=true to run the XDP benchmark.")
+			}
+			if err := bpf.SupportsXDP(); err != nil {
+				Skip(fmt.Sprintf("XDP acceleration not supported: %v", err))
+			}
+
+			infra = getInfra()
+			opts := infrastructure.DefaultTopologyOptions()
+			// We enable BPF mode on one node only, below.
+			opts.TestManagesBPF = true
+			roles := []string{"client", "server", "server"}
+			felixes, client = infrastructure.StartNNodeTopology(len(roles), opts, infra)
+
+			err := infra.AddAllowToDatastore("host-endpoint=='true'")
+			Expect(err).NotTo(HaveOccurred())
+
+			for ii, felix := range felixes {
+				hostW[ii] = workload.Run(felix, fmt.Sprintf("host%d", ii), "", felix.IP, "8055", "udp")
+
+				hostEp := api.NewHostEndpoint()
+				hostEp.Name = fmt.Sprintf("host-endpoint-%d", ii)
+				hostEp.Labels = map[string]string{
+					"host-endpoint": "true",
+					"role":          roles[ii],
+				}
+				hostEp.Spec.Node = felix.Hostname
+				hostEp.Spec.InterfaceName = "eth0"
+				hostEp.Spec.ExpectedIPs = []string{felix.IP}
+				_, err = client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			bpfEnabled := true
+			fc := api.NewFelixConfiguration()
+			fc.Name = "node." + felixes[bpfSrvr].Hostname
+			fc.Spec.BPFEnabled = &bpfEnabled
+			_, err = client.FelixConfigurations().Create(utils.Ctx, fc, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			order := float64(20)
+			allowAllPolicy := api.NewGlobalNetworkPolicy()
+			allowAllPolicy.Name = "allow-all"
+			allowAllPolicy.Spec.Order = &order
+			allowAllPolicy.Spec.Selector = "all()"
+			allowAllPolicy.Spec.Ingress = []api.Rule{{Action: api.Allow}}
+			allowAllPolicy.Spec.Egress = []api.Rule{{Action: api.Allow}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, allowAllPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			blocklist := api.NewGlobalNetworkSet()
+			blocklist.Name = "xdpblocklist"
+			blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+			blocklist.Spec.Nets = []string{hostW[clnt].IP + "/32"}
+			_, err = client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			order = float64(10)
+			xdpPolicy := api.NewGlobalNetworkPolicy()
+			xdpPolicy.Name = "xdp-filter"
+			xdpPolicy.Spec.Order = &order
+			xdpPolicy.Spec.DoNotTrack = true
+			xdpPolicy.Spec.ApplyOnForward = true
+			xdpPolicy.Spec.Selector = "role=='server'"
+			xdpPolicy.Spec.Ingress = []api.Rule{{
+				Action: api.Deny,
+				Source: api.EntityRule{Selector: "xdpblocklist-set=='true'"},
+			}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, xdpPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, srvr := range []int{iptSrvr, bpfSrvr} {
+				felix := felixes[srvr]
+				Eventually(func() string {
+					out, _ := felix.ExecOutput("ip", "link", "show", "dev", "eth0")
+					return out
+				}, "60s", "1s").Should(ContainSubstring("prog/xdp"))
+			}
+		})
+
+		AfterEach(func() {
+			if CurrentGinkgoTestDescription().Failed {
+				infra.DumpErrorData()
+			}
+			for _, wl := range hostW {
+				if wl != nil {
+					wl.Stop()
+				}
+			}
+			for _, felix := range felixes {
+				felix.Stop()
+			}
+			infra.Stop()
+		})
+
+		// udpReceived returns the number of UDP datagrams that reached the UDP stack in the
+		// felix's network namespace, whether or not there was a socket to receive them.
+		udpReceived := func(felix *infrastructure.Felix) int {
+			udp := felix.SNMPCounters("Udp")
+			return int(udp["InDatagrams"] + udp["NoPorts"] + udp["InErrors"])
+		}
+
+		type dropResult struct {
+			pps            float64
+			cpuPerMpkt     time.Duration
+			softIRQPerMpkt time.Duration
+		}
+
+		sentRegexp := regexp.MustCompile(`sent (\d+) packets in (\S+)`)
+
+		measureDrops := func(srvr int) dropResult {
+			udpBefore := udpReceived(felixes[srvr])
+			cpuBefore := felixes[srvr].CPUStats()
+			out, err := hostW[clnt].RunCmd("pktgen", hostW[clnt].IP, hostW[srvr].IP, "udp",
+				"--port-dst", "8055", "--count", fmt.Sprint(numPackets))
+			Expect(err).NotTo(HaveOccurred(), out)
+			cpu := felixes[srvr].CPUStats().Sub(cpuBefore)
+
+			matches := sentRegexp.FindStringSubmatch(out)
+			Expect(matches).NotTo(BeNil(), "unexpected pktgen output: "+out)
+			sendTime, err := time.ParseDuration(matches[2])
+			Expect(err).NotTo(HaveOccurred())
+
+			// Every packet should have been dropped by XDP, before it reached the stack.  Allow
+			// a little slack for unrelated background traffic.
+			Expect(udpReceived(felixes[srvr]) - udpBefore).To(BeNumerically("<", numPackets/100))
+
+			// The CPU counters are host-wide, so they include the cost of generating the
+			// packets too; that's the same in both modes so the difference between the modes
+			// is still meaningful.
+			perMpkt := func(d time.Duration) time.Duration {
+				return d * 1000000 / numPackets
+			}
+			return dropResult{
+				pps:            float64(numPackets) / sendTime.Seconds(),
+				cpuPerMpkt:     perMpkt(cpu.User + cpu.System + cpu.SoftIRQ),
+				softIRQPerMpkt: perMpkt(cpu.SoftIRQ),
+			}
+		}
+
+		It("should drop the blocklisted flood in both modes and report the difference", func() {
+			ipt := measureDrops(iptSrvr)
+			bpfMode := measureDrops(bpfSrvr)
+
+			report := fmt.Sprintf("XDP drop benchmark (%d packets):\n", numPackets) +
+				fmt.Sprintf("  %-14s %12s %16s %20s\n", "mode", "drop pps", "CPU per Mpkt", "softirq per Mpkt") +
+				fmt.Sprintf("  %-14s %12.0f %16v %20v\n", "iptables", ipt.pps, ipt.cpuPerMpkt, ipt.softIRQPerMpkt) +
+				fmt.Sprintf("  %-14s %12.0f %16v %20v\n", "BPF", bpfMode.pps, bpfMode.cpuPerMpkt, bpfMode.softIRQPerMpkt) +
+				fmt.Sprintf("  BPF/iptables:  pps x%.2f, CPU x%.2f",
+					bpfMode.pps/ipt.pps, float64(bpfMode.cpuPerMpkt)/float64(ipt.cpuPerMpkt))
+			log.Info(report)
+			fmt.Fprintln(GinkgoWriter, report)
+		})
+	})
@@ -0,0 +1,198 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build fvtests
+
+package fv_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/fv/connectivity"
+	"github.com/projectcalico/calico/felix/fv/infrastructure"
+	"github.com/projectcalico/calico/felix/fv/utils"
+	"github.com/projectcalico/calico/felix/fv/workload"
+	"github.com/projectcalico/calico/kube-controllers/pkg/config"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/xdpblocklist"
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+// The XDPBlockList CRD lets users manage blocklists with their own tooling; the XDP blocklist
+// controller in kube-controllers turns each one into a GlobalNetworkSet.  These tests run the
+// controller in-process against the test's API server.
+var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ XDP blocklist from an XDPBlockList",
+	[]apiconfig.DatastoreType{apiconfig.Kubernetes},
+	func(getInfra infrastructure.InfraFactory) {
+		const clnt, srvr = 0, 1
+		const gnsName = "xdpblocklist.feed"
+
+		var (
+			infra      infrastructure.DatastoreInfra
+			felixes    []*infrastructure.Felix
+			client     client.Interface
+			cc         *connectivity.Checker
+			hostW      [2]*workload.Workload
+			stopCtrl   chan struct{}
+			cancelCtrl context.CancelFunc
+		)
+
+		BeforeEach(func() {
+			if err := bpf.SupportsXDP(); err != nil {
+				Skip(fmt.Sprintf("XDP acceleration not supported: %v", err))
+			}
+			infra = getInfra()
+			opts := infrastructure.DefaultTopologyOptions()
+			felixes, client = infrastructure.StartNNodeTopology(2, opts, infra)
+
+			err := infra.AddAllowToDatastore("host-endpoint=='true'")
+			Expect(err).NotTo(HaveOccurred())
+
+			for ii := range felixes {
+				hostW[ii] = workload.Run(felixes[ii], fmt.Sprintf("host%d", ii), "", felixes[ii].IP, "8055", "tcp")
+			}
+
+			hostEp := api.NewHostEndpoint()
+			hostEp.Name = "host-endpoint-server"
+			hostEp.Labels = map[string]string{"host-endpoint": "true", "role": "server"}
+			hostEp.Spec.Node = felixes[srvr].Hostname
+			hostEp.Spec.InterfaceName = "eth0"
+			hostEp.Spec.ExpectedIPs = []string{felixes[srvr].IP}
+			_, err = client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			order := float64(10)
+			xdpPolicy := api.NewGlobalNetworkPolicy()
+			xdpPolicy.Name = "xdp-filter"
+			xdpPolicy.Spec.Order = &order
+			xdpPolicy.Spec.DoNotTrack = true
+			xdpPolicy.Spec.ApplyOnForward = true
+			xdpPolicy.Spec.Selector = "role=='server'"
+			xdpPolicy.Spec.Ingress = []api.Rule{{
+				Action: api.Deny,
+				Source: api.EntityRule{Selector: "xdpblocklist-set=='true'"},
+			}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, xdpPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			var ctx context.Context
+			ctx, cancelCtrl = context.WithCancel(context.Background())
+			stopCtrl = make(chan struct{})
+			ctrl := xdpblocklist.NewXDPBlockListController(ctx,
+				infra.(*infrastructure.K8sDatastoreInfra).DynamicClient, client,
+				config.GenericControllerConfig{ReconcilerPeriod: time.Minute, NumberOfWorkers: 1})
+			go ctrl.Run(stopCtrl)
+
+			cc = &connectivity.Checker{Protocol: "tcp"}
+		})
+
+		AfterEach(func() {
+			if CurrentGinkgoTestDescription().Failed {
+				infra.DumpErrorData()
+				felixes[srvr].Exec("iptables-save", "-c")
+				felixes[srvr].Exec("ip", "link")
+			}
+			close(stopCtrl)
+			cancelCtrl()
+			for _, wl := range hostW {
+				wl.Stop()
+			}
+			for _, felix := range felixes {
+				felix.Stop()
+			}
+			infra.Stop()
+		})
+
+		blockLists := func() dynamic.NamespaceableResourceInterface {
+			return infra.(*infrastructure.K8sDatastoreInfra).DynamicClient.Resource(xdpblocklist.XDPBlockListResource)
+		}
+
+		expectClientBlocked := func(blocked bool) {
+			if blocked {
+				cc.ExpectNone(hostW[clnt], hostW[srvr])
+			} else {
+				cc.ExpectSome(hostW[clnt], hostW[srvr])
+			}
+			cc.CheckConnectivityOffset(1)
+			cc.ResetExpectations()
+		}
+
+		It("should program the XDP blocklist from the XDPBlockList and follow its changes", func() {
+			expectClientBlocked(false)
+
+			bl := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "crd.projectcalico.org/v1",
+				"kind":       "XDPBlockList",
+				"metadata": map[string]interface{}{
+					"name":   "feed",
+					"labels": map[string]interface{}{"xdpblocklist-set": "true"},
+				},
+				"spec": map[string]interface{}{
+					"nets": []interface{}{hostW[clnt].IP + "/32"},
+				},
+			}}
+			bl, err := blockLists().Create(utils.Ctx, bl, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("writing a GlobalNetworkSet for it")
+			Eventually(func() ([]string, error) {
+				gns, err := client.GlobalNetworkSets().Get(utils.Ctx, gnsName, options.GetOptions{})
+				if err != nil {
+					return nil, err
+				}
+				return gns.Spec.Nets, nil
+			}, "10s", "500ms").Should(ConsistOf(hostW[clnt].IP + "/32"))
+
+			By("programming the XDP blocklist")
+			if !BPFMode() {
+				// The BPF dataplane compiles the untracked policy into the XDP program
+				// rather than keeping a blocklist map.
+				Eventually(func() ([]string, error) {
+					return felixes[srvr].XDPBlocklistCIDRs("eth0")
+				}, "10s", "1s").Should(ConsistOf(hostW[clnt].IP + "/32"))
+			}
+			expectClientBlocked(true)
+
+			By("following an update to the XDPBlockList")
+			Expect(unstructured.SetNestedStringSlice(bl.Object, []string{"10.123.0.0/16"}, "spec", "nets")).To(Succeed())
+			_, err = blockLists().Update(utils.Ctx, bl, metav1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			if !BPFMode() {
+				Eventually(func() ([]string, error) {
+					return felixes[srvr].XDPBlocklistCIDRs("eth0")
+				}, "10s", "1s").Should(ConsistOf("10.123.0.0/16"))
+			}
+			expectClientBlocked(false)
+
+			By("removing the GlobalNetworkSet when the XDPBlockList is deleted")
+			err = blockLists().Delete(utils.Ctx, "feed", metav1.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() error {
+				_, err := client.GlobalNetworkSets().Get(utils.Ctx, gnsName, options.GetOptions{})
+				return err
+			}, "10s", "500ms").Should(HaveOccurred())
+		})
+	})
@@ -0,0 +1,157 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build fvtests
+
+package fv_test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/fv/connectivity"
+	"github.com/projectcalico/calico/felix/fv/infrastructure"
+	"github.com/projectcalico/calico/felix/fv/utils"
+	"github.com/projectcalico/calico/felix/fv/workload"
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+)
+
+// Hardened deployments run Felix with a minimal set of capabilities rather than privileged.  Felix
+// should then either attach its XDP programs or say clearly why it can't, and enforce untracked
+// policy with iptables either way.
+var _ = infrastructure.DatastoreDescribe("XDP with restricted Felix capabilities",
+	[]apiconfig.DatastoreType{apiconfig.EtcdV3},
+	func(getInfra infrastructure.InfraFactory) {
+		const (
+			clnt = 0
+			srvr = 1
+		)
+
+		var (
+			infra   infrastructure.DatastoreInfra
+			felixes []*infrastructure.Felix
+			hostW   [2]*workload.Workload
+			client  client.Interface
+			cc      *connectivity.Checker
+		)
+
+		BeforeEach(func() {
+			if err := bpf.SupportsXDP(); err != nil {
+				Skip(fmt.Sprintf("XDP acceleration not supported: %v", err))
+			}
+
+			infra = getInfra()
+			opts := infrastructure.DefaultTopologyOptions()
+			// NET_RAW is for iptables, which Felix still needs for everything else.
+			opts.FelixCapabilities = []string{"NET_ADMIN", "NET_RAW", "BPF"}
+			felixes, client = infrastructure.StartNNodeTopology(2, opts, infra)
+
+			err := infra.AddAllowToDatastore("host-endpoint=='true'")
+			Expect(err).NotTo(HaveOccurred())
+
+			for ii, felix := range felixes {
+				hostW[ii] = workload.Run(felix, fmt.Sprintf("host%d", ii), "", felix.IP, "8055", "tcp")
+
+				hostEp := api.NewHostEndpoint()
+				hostEp.Name = fmt.Sprintf("host-endpoint-%d", ii)
+				hostEp.Labels = map[string]string{"host-endpoint": "true"}
+				hostEp.Spec.Node = felix.Hostname
+				hostEp.Spec.InterfaceName = "eth0"
+				hostEp.Spec.ExpectedIPs = []string{felix.IP}
+				_, err = client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			order := float64(20)
+			allowAllPolicy := api.NewGlobalNetworkPolicy()
+			allowAllPolicy.Name = "allow-all"
+			allowAllPolicy.Spec.Order = &order
+			allowAllPolicy.Spec.Selector = "all()"
+			allowAllPolicy.Spec.Ingress = []api.Rule{{Action: api.Allow}}
+			allowAllPolicy.Spec.Egress = []api.Rule{{Action: api.Allow}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, allowAllPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			blocklist := api.NewGlobalNetworkSet()
+			blocklist.Name = "xdpblocklist"
+			blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+			blocklist.Spec.Nets = []string{hostW[clnt].IP + "/32"}
+			_, err = client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			order = float64(10)
+			xdpPolicy := api.NewGlobalNetworkPolicy()
+			xdpPolicy.Name = "xdp-filter"
+			xdpPolicy.Spec.Order = &order
+			xdpPolicy.Spec.DoNotTrack = true
+			xdpPolicy.Spec.Selector = "host-endpoint=='true'"
+			xdpPolicy.Spec.Ingress = []api.Rule{{
+				Action: api.Deny,
+				Source: api.EntityRule{Selector: "xdpblocklist-set=='true'"},
+			}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, xdpPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			cc = &connectivity.Checker{Protocol: "tcp"}
+		})
+
+		AfterEach(func() {
+			if CurrentGinkgoTestDescription().Failed {
+				infra.DumpErrorData()
+				for _, felix := range felixes {
+					felix.Exec("iptables-save", "-c")
+					felix.Exec("ip", "link", "show", "dev", "eth0")
+				}
+			}
+			for _, wl := range hostW {
+				if wl != nil {
+					wl.Stop()
+				}
+			}
+			for _, felix := range felixes {
+				felix.Stop()
+			}
+			infra.Stop()
+		})
+
+		It("should attach XDP or report why it can't, and enforce the policy either way", func() {
+			// The untracked deny also drops the client's replies, so check that the server can
+			// still reach itself rather than the client.
+			cc.ExpectNone(hostW[clnt], hostW[srvr].Port(8055))
+			cc.ExpectSelf(hostW[srvr], 8055)
+			cc.CheckConnectivity()
+
+			attached := func() bool {
+				return len(felixes[srvr].XDPAttachedInterfaces()) > 0
+			}
+			deadline := time.Now().Add(30 * time.Second)
+			for !attached() && time.Now().Before(deadline) {
+				time.Sleep(time.Second)
+			}
+			if attached() {
+				By("keeping the XDP program attached")
+				Consistently(attached, "5s", "1s").Should(BeTrue())
+			} else {
+				By("reporting that Felix lacks the privileges for XDP")
+				felixes[srvr].ExpectLogMatch("lacks the privileges to manage XDP|failed to mount the BPF filesystem",
+					10*time.Second)
+			}
+		})
+	})
@@ -0,0 +1,180 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build fvtests
+
+package fv_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/fv/connectivity"
+	"github.com/projectcalico/calico/felix/fv/infrastructure"
+	"github.com/projectcalico/calico/felix/fv/utils"
+	"github.com/projectcalico/calico/felix/fv/workload"
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+// The XDP program only handles IPv4; it passes IPv6 packets, leaving ip6tables to apply the
+// IPv6 failsafe ports and untracked policy.  These tests check that the two families' failsafe
+// ports and blocklists stay independent on a dual-stack host endpoint.
+var _ = infrastructure.DatastoreDescribe("XDP failsafe ports with a dual-stack host endpoint",
+	[]apiconfig.DatastoreType{apiconfig.EtcdV3, apiconfig.Kubernetes},
+	func(getInfra infrastructure.InfraFactory) {
+		const (
+			clnt, srvr   = 0, 1
+			failsafePort = 1234
+			blockedPort  = 8055
+		)
+
+		var (
+			infra   infrastructure.DatastoreInfra
+			felixes []*infrastructure.Felix
+			client  client.Interface
+			cc      *connectivity.Checker
+			hostW4  [2]*workload.Workload
+			hostW6  [2]*workload.Workload
+		)
+
+		BeforeEach(func() {
+			if BPFMode() {
+				Skip("The BPF dataplane doesn't support IPv6 host endpoints")
+			}
+			if err := bpf.SupportsXDP(); err != nil {
+				Skip(fmt.Sprintf("XDP acceleration not supported: %v", err))
+			}
+			infra = getInfra()
+			opts := infrastructure.DefaultTopologyOptions()
+			opts.EnableIPv6 = true
+			opts.ExtraEnvVars["FELIX_FAILSAFEINBOUNDHOSTPORTS"] = "tcp:22, udp:68, tcp:179, tcp:2379, tcp:2380, " +
+				fmt.Sprintf("tcp:5473, tcp:6443, tcp:6666, tcp:6667, tcp:%d", failsafePort)
+			felixes, client = infrastructure.StartNNodeTopology(2, opts, infra)
+			for _, felix := range felixes {
+				if felix.IPv6 == "" {
+					Skip("The FV network doesn't give the Felixes IPv6 addresses")
+				}
+			}
+
+			err := infra.AddAllowToDatastore("host-endpoint=='true'")
+			Expect(err).NotTo(HaveOccurred())
+
+			ports := fmt.Sprintf("%d,%d", blockedPort, failsafePort)
+			for ii, felix := range felixes {
+				hostW4[ii] = workload.Run(felix, fmt.Sprintf("host%d-v4", ii), "", felix.IP, ports, "tcp")
+				hostW6[ii] = workload.Run(felix, fmt.Sprintf("host%d-v6", ii), "", felix.IPv6, ports, "tcp")
+			}
+
+			hostEp := api.NewHostEndpoint()
+			hostEp.Name = "host-endpoint-server"
+			hostEp.Labels = map[string]string{"host-endpoint": "true", "role": "server"}
+			hostEp.Spec.Node = felixes[srvr].Hostname
+			hostEp.Spec.InterfaceName = "eth0"
+			hostEp.Spec.ExpectedIPs = []string{felixes[srvr].IP, felixes[srvr].IPv6}
+			_, err = client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			blocklist := api.NewGlobalNetworkSet()
+			blocklist.Name = "xdpblocklist"
+			blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+			blocklist.Spec.Nets = []string{felixes[clnt].IPv6 + "/128"}
+			_, err = client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			order := float64(10)
+			xdpPolicy := api.NewGlobalNetworkPolicy()
+			xdpPolicy.Name = "xdp-filter"
+			xdpPolicy.Spec.Order = &order
+			xdpPolicy.Spec.DoNotTrack = true
+			xdpPolicy.Spec.ApplyOnForward = true
+			xdpPolicy.Spec.Selector = "role=='server'"
+			xdpPolicy.Spec.Ingress = []api.Rule{{
+				Action: api.Deny,
+				Source: api.EntityRule{Selector: "xdpblocklist-set=='true'"},
+			}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, xdpPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			cc = &connectivity.Checker{Protocol: "tcp"}
+		})
+
+		AfterEach(func() {
+			if CurrentGinkgoTestDescription().Failed {
+				infra.DumpErrorData()
+				felixes[srvr].Exec("iptables-save", "-c")
+				felixes[srvr].Exec("ip6tables-save", "-c")
+				felixes[srvr].Exec("ip", "link")
+			}
+			for _, wls := range [][2]*workload.Workload{hostW4, hostW6} {
+				for _, wl := range wls {
+					if wl != nil {
+						wl.Stop()
+					}
+				}
+			}
+			for _, felix := range felixes {
+				felix.Stop()
+			}
+			infra.Stop()
+		})
+
+		setBlocklist := func(nets ...string) {
+			blocklist, err := client.GlobalNetworkSets().Get(utils.Ctx, "xdpblocklist", options.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			blocklist.Spec.Nets = nets
+			_, err = client.GlobalNetworkSets().Update(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		// expectFamilies checks both families' blocked and failsafe ports.  The failsafe port
+		// must be open whether or not the family's source is blocklisted.
+		expectFamilies := func(v4Blocked, v6Blocked bool) {
+			for _, fam := range []struct {
+				w       [2]*workload.Workload
+				blocked bool
+			}{{hostW4, v4Blocked}, {hostW6, v6Blocked}} {
+				if fam.blocked {
+					cc.ExpectNone(fam.w[clnt], fam.w[srvr], blockedPort)
+				} else {
+					cc.ExpectSome(fam.w[clnt], fam.w[srvr], blockedPort)
+				}
+				cc.ExpectSome(fam.w[clnt], fam.w[srvr], failsafePort)
+			}
+			cc.CheckConnectivityOffset(1)
+			cc.ResetExpectations()
+		}
+
+		It("should honour each family's failsafe ports and blocklist independently", func() {
+			expectFamilies(false, true)
+
+			By("blocking the IPv4 source as well")
+			setBlocklist(felixes[clnt].IPv6+"/128", felixes[clnt].IP+"/32")
+			// The XDP blocklist only holds the IPv4 CIDRs.
+			Eventually(func() ([]string, error) {
+				return felixes[srvr].XDPBlocklistCIDRs("eth0")
+			}, "10s", "1s").Should(ConsistOf(felixes[clnt].IP + "/32"))
+			expectFamilies(true, true)
+
+			By("unblocking only the IPv6 source")
+			setBlocklist(felixes[clnt].IP + "/32")
+			expectFamilies(true, false)
+		})
+	})
@@ -0,0 +1,187 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build fvtests
+
+package fv_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/fv/connectivity"
+	"github.com/projectcalico/calico/felix/fv/infrastructure"
+	"github.com/projectcalico/calico/felix/fv/utils"
+	"github.com/projectcalico/calico/felix/fv/workload"
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+)
+
+var _ = infrastructure.DatastoreDescribe("XDP and iptables enforcement of the same untracked policy",
+	[]apiconfig.DatastoreType{apiconfig.EtcdV3, apiconfig.Kubernetes},
+	func(getInfra infrastructure.InfraFactory) {
+		const (
+			clnt, xdpSrvr, iptSrvr = 0, 1, 2
+
+			// secondaryIP is a second address on the client, so that the policy can block one
+			// of the client's addresses and not the other.
+			secondaryIP = "10.200.0.1"
+		)
+
+		var (
+			infra   infrastructure.DatastoreInfra
+			felixes []*infrastructure.Felix
+			client  client.Interface
+			cc      *connectivity.Checker
+			hostW   [3]*workload.Workload
+		)
+
+		BeforeEach(func() {
+			if BPFMode() {
+				Skip("BPF mode always enforces untracked policy with XDP")
+			}
+			if err := bpf.SupportsXDP(); err != nil {
+				Skip(fmt.Sprintf("XDP acceleration not supported: %v", err))
+			}
+			infra = getInfra()
+			opts := infrastructure.DefaultTopologyOptions()
+			felixes, client = infrastructure.StartNNodeTopology(3, opts, infra)
+
+			felixes[iptSrvr].SetEvn(map[string]string{"FELIX_XDPENABLED": "false"})
+			felixes[iptSrvr].Restart()
+
+			felixes[clnt].Exec("ip", "addr", "add", secondaryIP+"/32", "dev", "eth0")
+			for ii, felix := range felixes {
+				hostW[ii] = workload.Run(felix, fmt.Sprintf("host%d", ii), "", felix.IP, "8055,8056", "tcp")
+				if ii == clnt {
+					continue
+				}
+				felix.Exec("ip", "route", "add", secondaryIP+"/32", "via", felixes[clnt].IP)
+
+				hostEp := api.NewHostEndpoint()
+				hostEp.Name = fmt.Sprintf("host-endpoint-%d", ii)
+				hostEp.Labels = map[string]string{"role": "server"}
+				hostEp.Spec.Node = felix.Hostname
+				hostEp.Spec.InterfaceName = "eth0"
+				hostEp.Spec.ExpectedIPs = []string{felix.IP}
+				_, err := client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			order := float64(20)
+			allowAllPolicy := api.NewGlobalNetworkPolicy()
+			allowAllPolicy.Name = "allow-all"
+			allowAllPolicy.Spec.Order = &order
+			allowAllPolicy.Spec.Selector = "all()"
+			allowAllPolicy.Spec.Ingress = []api.Rule{{Action: api.Allow}}
+			allowAllPolicy.Spec.Egress = []api.Rule{{Action: api.Allow}}
+			_, err := client.GlobalNetworkPolicies().Create(utils.Ctx, allowAllPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			cc = &connectivity.Checker{Protocol: "tcp"}
+		})
+
+		AfterEach(func() {
+			if CurrentGinkgoTestDescription().Failed {
+				infra.DumpErrorData()
+				for _, felix := range felixes {
+					felix.Exec("iptables-save", "-c")
+					felix.Exec("ip", "link")
+				}
+			}
+			for _, w := range hostW {
+				if w != nil {
+					w.Stop()
+				}
+			}
+			for _, felix := range felixes {
+				felix.Stop()
+			}
+			infra.Stop()
+		})
+
+		// matrix returns a matrix whose policy denies the given source rule on the servers.
+		matrix := func(source api.EntityRule) infrastructure.EnforcementMatrix {
+			order := float64(10)
+			policy := api.NewGlobalNetworkPolicy()
+			policy.Name = "xdp-filter"
+			policy.Spec.Order = &order
+			policy.Spec.Selector = "role=='server'"
+			policy.Spec.Ingress = []api.Rule{{Action: api.Deny, Source: source}}
+
+			return infrastructure.EnforcementMatrix{
+				Policy: policy,
+				Sources: []connectivity.ConnectionSource{
+					hostW[clnt].Port(0).WithLocalAddr(hostW[clnt].IP),
+					hostW[clnt].Port(0).WithLocalAddr(secondaryIP),
+				},
+				Ports: []uint16{8055, 8056},
+			}
+		}
+
+		// compare applies a policy that denies the given source rule to both servers and
+		// returns the outcomes that XDP and iptables agreed on.
+		compare := func(source api.EntityRule) []string {
+			return infrastructure.CompareXDPvsIPTables(client, felixes[xdpSrvr], felixes[iptSrvr], cc, matrix(source))
+		}
+
+		expectedOutcomes := func(primaryAllowed, secondaryAllowed bool) []string {
+			var outcomes []string
+			for _, src := range []struct {
+				ip      string
+				allowed bool
+			}{{hostW[clnt].IP, primaryAllowed}, {secondaryIP, secondaryAllowed}} {
+				for _, port := range []int{8055, 8056} {
+					outcomes = append(outcomes,
+						fmt.Sprintf("%s[%s] -> port %d = %v", hostW[clnt].Name, src.ip, port, src.allowed))
+				}
+			}
+			return outcomes
+		}
+
+		It("should block the same sources when they're in a network set", func() {
+			netSet := api.NewGlobalNetworkSet()
+			netSet.Name = "xdpblocklist"
+			netSet.Labels = map[string]string{"xdpblocklist-set": "true"}
+			netSet.Spec.Nets = []string{secondaryIP + "/32"}
+			_, err := client.GlobalNetworkSets().Create(utils.Ctx, netSet, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			outcomes := compare(api.EntityRule{Selector: "xdpblocklist-set=='true'"})
+			Expect(outcomes).To(Equal(expectedOutcomes(true, false)))
+		})
+
+		It("should block the same sources when the rule lists their CIDRs", func() {
+			outcomes := compare(api.EntityRule{Nets: []string{hostW[clnt].IP + "/32", "10.200.0.0/24"}})
+			Expect(outcomes).To(Equal(expectedOutcomes(false, false)))
+		})
+
+		It("should block the same sources after falling back from XDP to iptables", func() {
+			netSet := api.NewGlobalNetworkSet()
+			netSet.Name = "xdpblocklist"
+			netSet.Labels = map[string]string{"xdpblocklist-set": "true"}
+			netSet.Spec.Nets = []string{secondaryIP + "/32"}
+			_, err := client.GlobalNetworkSets().Create(utils.Ctx, netSet, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			outcomes := infrastructure.ExpectSameUnderFallback(client, felixes[xdpSrvr], cc,
+				matrix(api.EntityRule{Selector: "xdpblocklist-set=='true'"}))
+			Expect(outcomes).To(Equal(expectedOutcomes(true, false)))
+		})
+	})
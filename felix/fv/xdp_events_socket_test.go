@@ -0,0 +1,168 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build fvtests
+
+package fv_test
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/fv/connectivity"
+	"github.com/projectcalico/calico/felix/fv/infrastructure"
+	"github.com/projectcalico/calico/felix/fv/utils"
+	"github.com/projectcalico/calico/felix/fv/workload"
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+)
+
+var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ XDP events streamed to a unix socket",
+	[]apiconfig.DatastoreType{apiconfig.EtcdV3, apiconfig.Kubernetes},
+	func(getInfra infrastructure.InfraFactory) {
+		const clnt, srvr = 0, 1
+
+		var (
+			infra     infrastructure.DatastoreInfra
+			felixes   []*infrastructure.Felix
+			client    client.Interface
+			cc        *connectivity.Checker
+			hostW     [2]*workload.Workload
+			collector *infrastructure.XDPEventCollector
+		)
+
+		BeforeEach(func() {
+			if !BPFMode() {
+				Skip("XDP events are only reported in BPF mode")
+			}
+			if err := bpf.SupportsXDP(); err != nil {
+				Skip(fmt.Sprintf("XDP acceleration not supported: %v", err))
+			}
+			infra = getInfra()
+			opts := infrastructure.DefaultTopologyOptions()
+			opts.ExtraEnvVars["FELIX_XDPLOGLEVEL"] = "debug"
+			collector = infrastructure.NewXDPEventCollector()
+			collector.Configure(&opts)
+			felixes, client = infrastructure.StartNNodeTopology(2, opts, infra)
+
+			err := infra.AddAllowToDatastore("host-endpoint=='true'")
+			Expect(err).NotTo(HaveOccurred())
+
+			for ii := range felixes {
+				hostW[ii] = workload.Run(felixes[ii], fmt.Sprintf("host%d", ii), "", felixes[ii].IP, "8055", "udp")
+			}
+
+			hostEp := api.NewHostEndpoint()
+			hostEp.Name = "host-endpoint-server"
+			hostEp.Labels = map[string]string{"host-endpoint": "true", "role": "server"}
+			hostEp.Spec.Node = felixes[srvr].Hostname
+			hostEp.Spec.InterfaceName = "eth0"
+			hostEp.Spec.ExpectedIPs = []string{felixes[srvr].IP}
+			_, err = client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			blocklist := api.NewGlobalNetworkSet()
+			blocklist.Name = "xdpblocklist"
+			blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+			blocklist.Spec.Nets = []string{hostW[clnt].IP + "/32"}
+			_, err = client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			order := float64(10)
+			xdpPolicy := api.NewGlobalNetworkPolicy()
+			xdpPolicy.Name = "xdp-filter"
+			xdpPolicy.Spec.Order = &order
+			xdpPolicy.Spec.DoNotTrack = true
+			xdpPolicy.Spec.ApplyOnForward = true
+			xdpPolicy.Spec.Selector = "role=='server'"
+			xdpPolicy.Spec.Ingress = []api.Rule{{
+				Action: api.Deny,
+				Source: api.EntityRule{Selector: "xdpblocklist-set=='true'"},
+			}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, xdpPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			cc = &connectivity.Checker{Protocol: "udp"}
+		})
+
+		AfterEach(func() {
+			if CurrentGinkgoTestDescription().Failed {
+				infra.DumpErrorData()
+			}
+			for _, wl := range hostW {
+				wl.Stop()
+			}
+			for _, felix := range felixes {
+				felix.Stop()
+			}
+			infra.Stop()
+			collector.Stop()
+		})
+
+		blockedDrop := func() bpf.XDPEvent {
+			return bpf.XDPEvent{
+				SrcIP:   net.ParseIP(hostW[clnt].IP).To4(),
+				Verdict: bpf.XDPVerdictDrop,
+			}
+		}
+
+		It("should report the drop while the blocked probe is still running", func() {
+			Eventually(func() (string, error) {
+				return felixes[srvr].ExecOutput("ip", "link", "show", "dev", "eth0")
+			}, "10s", "1s").Should(ContainSubstring("prog/xdp"))
+			collector.Reset()
+
+			probeDone := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(probeDone)
+				cc.ExpectNone(hostW[clnt], hostW[srvr])
+				cc.CheckConnectivity()
+			}()
+
+			// The checker only gives up on the probe after its timeout, which is much
+			// longer than this.
+			Eventually(collector.Events, "5s", "100ms").Should(ContainElement(blockedDrop()))
+			Expect(probeDone).NotTo(BeClosed(), "Probe finished before the drop was reported")
+			Eventually(probeDone, "60s").Should(BeClosed())
+		})
+
+		It("should leave the events in the ring buffer once the collector has gone", func() {
+			sendProbe := func() {
+				_, err := hostW[clnt].RunCmd("pktgen", hostW[clnt].IP, hostW[srvr].IP, "udp", "--port-dst", "8055")
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Eventually(func() []bpf.XDPEvent {
+				sendProbe()
+				return collector.Events()
+			}, "10s", "500ms").Should(ContainElement(blockedDrop()))
+
+			collector.Stop()
+			// Felix notices that the collector has gone the next time that it writes an
+			// event, so the first events after the collector stops may be lost.
+			Eventually(func() []bpf.XDPEvent {
+				sendProbe()
+				events, err := bpf.ReadXDPRingbuf(felixes[srvr], time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				return events
+			}, "10s").Should(ContainElement(blockedDrop()))
+		})
+	})
@@ -0,0 +1,177 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build fvtests
+
+package fv_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/fv/connectivity"
+	"github.com/projectcalico/calico/felix/fv/infrastructure"
+	"github.com/projectcalico/calico/felix/fv/utils"
+	"github.com/projectcalico/calico/felix/fv/workload"
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ XDP blocklist with a workload that moves between hosts",
+	[]apiconfig.DatastoreType{apiconfig.EtcdV3, apiconfig.Kubernetes},
+	func(getInfra infrastructure.InfraFactory) {
+		const (
+			// The server is in the middle so that the workload can move from the host on one
+			// side of it to the host on the other.
+			first, srvr, second = 0, 1, 2
+		)
+
+		var (
+			infra   infrastructure.DatastoreInfra
+			felixes []*infrastructure.Felix
+			client  client.Interface
+			cc      *connectivity.Checker
+			hostW   *workload.Workload
+			movingW *workload.Workload
+		)
+
+		BeforeEach(func() {
+			if err := bpf.SupportsXDP(); err != nil {
+				Skip(fmt.Sprintf("XDP acceleration not supported: %v", err))
+			}
+			infra = getInfra()
+			opts := infrastructure.DefaultTopologyOptions()
+			felixes, client = infrastructure.StartNNodeTopology(3, opts, infra)
+			infra.AddDefaultAllow()
+
+			hostW = workload.Run(felixes[srvr], "host1", "", felixes[srvr].IP, "8055", "tcp")
+			movingW = workload.Run(felixes[first], "moving", "default", "10.65.0.2", "8055", "tcp")
+			movingW.ConfigureInInfra(infra)
+
+			order := float64(20)
+			allowAllPolicy := api.NewGlobalNetworkPolicy()
+			allowAllPolicy.Name = "allow-all"
+			allowAllPolicy.Spec.Order = &order
+			allowAllPolicy.Spec.Selector = "all()"
+			allowAllPolicy.Spec.Ingress = []api.Rule{{Action: api.Allow}}
+			allowAllPolicy.Spec.Egress = []api.Rule{{Action: api.Allow}}
+			_, err := client.GlobalNetworkPolicies().Create(utils.Ctx, allowAllPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			hostEp := api.NewHostEndpoint()
+			hostEp.Name = "host-endpoint-server"
+			hostEp.Labels = map[string]string{"role": "server"}
+			hostEp.Spec.Node = felixes[srvr].Hostname
+			hostEp.Spec.InterfaceName = "eth0"
+			hostEp.Spec.ExpectedIPs = []string{felixes[srvr].IP}
+			_, err = client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			order = float64(10)
+			xdpPolicy := api.NewGlobalNetworkPolicy()
+			xdpPolicy.Name = "xdp-filter"
+			xdpPolicy.Spec.Order = &order
+			xdpPolicy.Spec.DoNotTrack = true
+			xdpPolicy.Spec.ApplyOnForward = true
+			xdpPolicy.Spec.Selector = "role=='server'"
+			xdpPolicy.Spec.Ingress = []api.Rule{{
+				Action: api.Deny,
+				Source: api.EntityRule{Selector: "xdpblocklist-set=='true'"},
+			}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, xdpPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			cc = &connectivity.Checker{Protocol: "tcp"}
+		})
+
+		AfterEach(func() {
+			if CurrentGinkgoTestDescription().Failed {
+				infra.DumpErrorData()
+				for _, felix := range felixes {
+					felix.Exec("iptables-save", "-c")
+					felix.Exec("ip", "r")
+				}
+			}
+			movingW.Stop()
+			hostW.Stop()
+			for _, felix := range felixes {
+				felix.Stop()
+			}
+			infra.Stop()
+		})
+
+		blocklist := func(nets ...string) {
+			netSet, err := client.GlobalNetworkSets().Get(utils.Ctx, "xdpblocklist", options.GetOptions{})
+			if err != nil {
+				netSet = api.NewGlobalNetworkSet()
+				netSet.Name = "xdpblocklist"
+				netSet.Labels = map[string]string{"xdpblocklist-set": "true"}
+				netSet.Spec.Nets = nets
+				_, err = client.GlobalNetworkSets().Create(utils.Ctx, netSet, utils.NoOptions)
+			} else {
+				netSet.Spec.Nets = nets
+				_, err = client.GlobalNetworkSets().Update(utils.Ctx, netSet, utils.NoOptions)
+			}
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		check := func(expectConnected bool) {
+			if expectConnected {
+				cc.ExpectSome(movingW, hostW.Port(8055))
+			} else {
+				cc.ExpectNone(movingW, hostW.Port(8055))
+			}
+			cc.CheckConnectivity()
+			cc.ResetExpectations()
+		}
+
+		It("should keep blocking the workload's IP after it moves to another host", func() {
+			check(true)
+			blocklist(movingW.IP + "/32")
+			check(false)
+
+			By("moving the workload to the host on the other side of the server")
+			movingW.RemoveFromInfra(infra)
+			movingW.Migrate(felixes[first], felixes[second])
+			movingW.ConfigureInInfra(infra)
+			// The workload's address is in the first host's block, so the server needs a
+			// more specific route to reach it on the second host.
+			felixes[srvr].Exec("ip", "route", "add", movingW.IP+"/32", "via", felixes[second].IP, "dev", "eth0")
+
+			var dropsBefore uint64
+			if !BPFMode() {
+				counts, err := bpf.PerSourceDropCounts(felixes[srvr], "eth0")
+				Expect(err).NotTo(HaveOccurred())
+				dropsBefore = counts[movingW.IP]
+			}
+			check(false)
+			if !BPFMode() {
+				Eventually(func() (uint64, error) {
+					counts, err := bpf.PerSourceDropCounts(felixes[srvr], "eth0")
+					return counts[movingW.IP], err
+				}, "5s", "200ms").Should(BeNumerically(">", dropsBefore),
+					"the moved workload's packets should be dropped by XDP")
+			}
+
+			By("letting the workload through from its new host once it's unblocked")
+			blocklist("10.123.0.1/32")
+			check(true)
+		})
+	})
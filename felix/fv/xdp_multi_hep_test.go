@@ -0,0 +1,188 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build fvtests
+
+package fv_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/fv/connectivity"
+	"github.com/projectcalico/calico/felix/fv/infrastructure"
+	"github.com/projectcalico/calico/felix/fv/utils"
+	"github.com/projectcalico/calico/felix/fv/workload"
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+// Calico allows several HostEndpoints to match the same interface, but only the one whose name
+// sorts first governs it; the others take over if it's removed.  The XDP program must follow
+// the governing endpoint's untracked policy rather than merging the endpoints' blocklists,
+// which would make it disagree with the iptables rules for the same interface.
+var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ XDP blocklist with two host endpoints on the same interface",
+	[]apiconfig.DatastoreType{apiconfig.EtcdV3, apiconfig.Kubernetes},
+	func(getInfra infrastructure.InfraFactory) {
+		const clnt, srvr = 0, 1
+		// Doesn't include the client, so that it's only blocked while host-endpoint-a
+		// governs eth0.
+		const otherBlockedCIDR = "10.123.0.0/16"
+
+		var (
+			infra   infrastructure.DatastoreInfra
+			felixes []*infrastructure.Felix
+			client  client.Interface
+			cc      *connectivity.Checker
+			hostW   [2]*workload.Workload
+		)
+
+		createHEP := func(name, role string) {
+			hostEp := api.NewHostEndpoint()
+			hostEp.Name = name
+			hostEp.Labels = map[string]string{"host-endpoint": "true", "role": role}
+			hostEp.Spec.Node = felixes[srvr].Hostname
+			hostEp.Spec.InterfaceName = "eth0"
+			hostEp.Spec.ExpectedIPs = []string{felixes[srvr].IP}
+			_, err := client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		createBlocklistPolicy := func(role, cidr string) {
+			set := api.NewGlobalNetworkSet()
+			set.Name = "blocklist-" + role
+			set.Labels = map[string]string{"blocklist": role}
+			set.Spec.Nets = []string{cidr}
+			_, err := client.GlobalNetworkSets().Create(utils.Ctx, set, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			order := float64(10)
+			policy := api.NewGlobalNetworkPolicy()
+			policy.Name = "xdp-filter-" + role
+			policy.Spec.Order = &order
+			policy.Spec.DoNotTrack = true
+			policy.Spec.ApplyOnForward = true
+			policy.Spec.Selector = fmt.Sprintf("role=='%s'", role)
+			policy.Spec.Ingress = []api.Rule{{
+				Action: api.Deny,
+				Source: api.EntityRule{Selector: fmt.Sprintf("blocklist=='%s'", role)},
+			}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, policy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			if err := bpf.SupportsXDP(); err != nil {
+				Skip(fmt.Sprintf("XDP acceleration not supported: %v", err))
+			}
+			infra = getInfra()
+			opts := infrastructure.DefaultTopologyOptions()
+			felixes, client = infrastructure.StartNNodeTopology(2, opts, infra)
+
+			err := infra.AddAllowToDatastore("host-endpoint=='true'")
+			Expect(err).NotTo(HaveOccurred())
+
+			for ii := range felixes {
+				hostW[ii] = workload.Run(felixes[ii], fmt.Sprintf("host%d", ii), "", felixes[ii].IP, "8055", "tcp")
+			}
+
+			createBlocklistPolicy("a", hostW[clnt].IP+"/32")
+			createBlocklistPolicy("b", otherBlockedCIDR)
+			createHEP("host-endpoint-a", "a")
+			createHEP("host-endpoint-b", "b")
+
+			cc = &connectivity.Checker{Protocol: "tcp"}
+		})
+
+		AfterEach(func() {
+			if CurrentGinkgoTestDescription().Failed {
+				infra.DumpErrorData()
+				felixes[srvr].Exec("iptables-save", "-c")
+				felixes[srvr].Exec("ip", "link")
+			}
+			for _, wl := range hostW {
+				wl.Stop()
+			}
+			for _, felix := range felixes {
+				felix.Stop()
+			}
+			infra.Stop()
+		})
+
+		expectBlocklist := func(cidrs ...string) {
+			if BPFMode() {
+				// The BPF dataplane compiles the untracked policy into the XDP program
+				// rather than keeping a blocklist map.
+				return
+			}
+			EventuallyWithOffset(1, func() ([]string, error) {
+				return felixes[srvr].XDPBlocklistCIDRs("eth0")
+			}, "10s", "1s").Should(ConsistOf(cidrs))
+		}
+
+		expectClientBlocked := func(blocked bool) {
+			if blocked {
+				cc.ExpectNone(hostW[clnt], hostW[srvr])
+			} else {
+				cc.ExpectSome(hostW[clnt], hostW[srvr])
+			}
+			cc.CheckConnectivityOffset(1)
+			cc.ResetExpectations()
+		}
+
+		It("should follow the governing host endpoint as the endpoints are removed and added", func() {
+			Eventually(func() (string, error) {
+				return felixes[srvr].ExecOutput("ip", "link", "show", "dev", "eth0")
+			}, "10s", "1s").Should(ContainSubstring("prog/xdp"))
+			expectBlocklist(hostW[clnt].IP + "/32")
+			expectClientBlocked(true)
+
+			By("switching to host-endpoint-b's policy when host-endpoint-a is removed")
+			_, err := client.HostEndpoints().Delete(utils.Ctx, "host-endpoint-a", options.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			expectBlocklist(otherBlockedCIDR)
+			expectClientBlocked(false)
+
+			By("switching back when host-endpoint-a is added again")
+			createHEP("host-endpoint-a", "a")
+			expectBlocklist(hostW[clnt].IP + "/32")
+			expectClientBlocked(true)
+
+			By("keeping host-endpoint-a's policy when host-endpoint-b is removed")
+			_, err = client.HostEndpoints().Delete(utils.Ctx, "host-endpoint-b", options.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			if !BPFMode() {
+				Consistently(func() ([]string, error) {
+					return felixes[srvr].XDPBlocklistCIDRs("eth0")
+				}, "3s", "1s").Should(ConsistOf(hostW[clnt].IP + "/32"))
+			}
+			expectClientBlocked(true)
+
+			By("removing the program when neither endpoint is left")
+			_, err = client.HostEndpoints().Delete(utils.Ctx, "host-endpoint-a", options.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			if !BPFMode() {
+				Eventually(func() (string, error) {
+					return felixes[srvr].ExecOutput("ip", "link", "show", "dev", "eth0")
+				}, "10s", "1s").ShouldNot(ContainSubstring("prog/xdp"))
+			}
+			expectClientBlocked(false)
+		})
+	})
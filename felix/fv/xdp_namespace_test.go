@@ -0,0 +1,186 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build fvtests
+
+package fv_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/fv/connectivity"
+	"github.com/projectcalico/calico/felix/fv/infrastructure"
+	"github.com/projectcalico/calico/felix/fv/utils"
+	"github.com/projectcalico/calico/felix/fv/workload"
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+// Namespaced untracked policy applies to the host endpoints that carry the namespace label, so
+// each namespace can have its own XDP blocklist, made up of its own NetworkSets.
+var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ per-namespace XDP blocklists",
+	[]apiconfig.DatastoreType{apiconfig.EtcdV3},
+	func(getInfra infrastructure.InfraFactory) {
+		const (
+			clnt  = 0
+			srvr1 = 1
+			srvr2 = 2
+
+			// An address that isn't in the topology, so that a blocklist can be non-empty
+			// without blocking the client.
+			otherIP = "10.123.0.1"
+		)
+
+		var (
+			infra   infrastructure.DatastoreInfra
+			felixes []*infrastructure.Felix
+			hostW   [3]*workload.Workload
+			client  client.Interface
+			cc      *connectivity.Checker
+		)
+
+		BeforeEach(func() {
+			if err := bpf.SupportsXDP(); err != nil {
+				Skip(fmt.Sprintf("XDP acceleration not supported: %v", err))
+			}
+
+			infra = getInfra()
+			opts := infrastructure.DefaultTopologyOptions()
+			namespaces := []string{"", "ns1", "ns2"}
+			felixes, client = infrastructure.StartNNodeTopology(len(namespaces), opts, infra)
+
+			err := infra.AddAllowToDatastore("host-endpoint=='true'")
+			Expect(err).NotTo(HaveOccurred())
+
+			topology := infra.Topology()
+			for ii, felix := range felixes {
+				node := topology.Nodes[ii]
+				hostW[ii] = workload.Run(felix, fmt.Sprintf("host%d", ii), "", node.IP, "8055", "udp")
+
+				hostEp := api.NewHostEndpoint()
+				hostEp.Name = fmt.Sprintf("host-endpoint-%d", ii)
+				hostEp.Labels = map[string]string{"host-endpoint": "true"}
+				if namespaces[ii] != "" {
+					hostEp.Labels[api.LabelNamespace] = namespaces[ii]
+				}
+				hostEp.Spec.Node = node.Name
+				hostEp.Spec.InterfaceName = "eth0"
+				hostEp.Spec.ExpectedIPs = []string{node.IP}
+				_, err = client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			order := float64(20)
+			allowAllPolicy := api.NewGlobalNetworkPolicy()
+			allowAllPolicy.Name = "allow-all"
+			allowAllPolicy.Spec.Order = &order
+			allowAllPolicy.Spec.Selector = "all()"
+			allowAllPolicy.Spec.Ingress = []api.Rule{{Action: api.Allow}}
+			allowAllPolicy.Spec.Egress = []api.Rule{{Action: api.Allow}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, allowAllPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Give each namespace a blocklist and an untracked policy that enforces it; only
+			// ns1's blocklist has the client in it.
+			for _, ns := range namespaces[1:] {
+				blocklist := api.NewNetworkSet()
+				blocklist.Name = "xdpblocklist"
+				blocklist.Namespace = ns
+				blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+				blocklist.Spec.Nets = []string{otherIP + "/32"}
+				if ns == "ns1" {
+					blocklist.Spec.Nets = append(blocklist.Spec.Nets, hostW[clnt].IP+"/32")
+				}
+				_, err = client.NetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+
+				order := float64(10)
+				xdpPolicy := api.NewNetworkPolicy()
+				xdpPolicy.Name = "xdp-filter"
+				xdpPolicy.Namespace = ns
+				xdpPolicy.Spec.Order = &order
+				xdpPolicy.Spec.DoNotTrack = true
+				xdpPolicy.Spec.Selector = "all()"
+				xdpPolicy.Spec.Ingress = []api.Rule{{
+					Action: api.Deny,
+					Source: api.EntityRule{Selector: "xdpblocklist-set=='true'"},
+				}}
+				_, err = client.NetworkPolicies().Create(utils.Ctx, xdpPolicy, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			for _, srvr := range []int{srvr1, srvr2} {
+				felix := felixes[srvr]
+				Eventually(func() string {
+					out, _ := felix.ExecOutput("ip", "link", "show", "dev", "eth0")
+					return out
+				}, "60s", "1s").Should(ContainSubstring("prog/xdp"))
+			}
+
+			cc = &connectivity.Checker{Protocol: "udp"}
+		})
+
+		AfterEach(func() {
+			if CurrentGinkgoTestDescription().Failed {
+				infra.DumpErrorData()
+			}
+			for _, wl := range hostW {
+				if wl != nil {
+					wl.Stop()
+				}
+			}
+			for _, felix := range felixes {
+				felix.Stop()
+			}
+			infra.Stop()
+		})
+
+		updateBlocklist := func(ns string, nets ...string) {
+			blocklist, err := client.NetworkSets().Get(utils.Ctx, ns, "xdpblocklist", options.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			blocklist.Spec.Nets = nets
+			_, err = client.NetworkSets().Update(utils.Ctx, blocklist, options.SetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		It("should only apply each namespace's blocklist to that namespace's host endpoints", func() {
+			cc.ExpectNone(hostW[clnt], hostW[srvr1].Port(8055))
+			cc.ExpectSome(hostW[clnt], hostW[srvr2].Port(8055))
+			cc.CheckConnectivity()
+			cc.ResetExpectations()
+
+			By("moving the client from ns1's blocklist to ns2's")
+			updateBlocklist("ns1", otherIP+"/32")
+			updateBlocklist("ns2", otherIP+"/32", hostW[clnt].IP+"/32")
+
+			cc.ExpectSome(hostW[clnt], hostW[srvr1].Port(8055))
+			cc.ExpectNone(hostW[clnt], hostW[srvr2].Port(8055))
+			cc.CheckConnectivity()
+		})
+
+		It("should not apply a namespace's blocklist once its untracked policy is deleted", func() {
+			_, err := client.NetworkPolicies().Delete(utils.Ctx, "ns1", "xdp-filter", options.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			cc.ExpectSome(hostW[clnt], hostW[srvr1].Port(8055))
+			cc.ExpectSome(hostW[clnt], hostW[srvr2].Port(8055))
+			cc.CheckConnectivity()
+		})
+	})
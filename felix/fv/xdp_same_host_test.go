@@ -0,0 +1,157 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build fvtests
+
+package fv_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/fv/connectivity"
+	"github.com/projectcalico/calico/felix/fv/infrastructure"
+	"github.com/projectcalico/calico/felix/fv/utils"
+	"github.com/projectcalico/calico/felix/fv/workload"
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+var _ = infrastructure.DatastoreDescribe("_BPF-SAFE_ XDP blocklist with the client and server on the same host",
+	[]apiconfig.DatastoreType{apiconfig.EtcdV3, apiconfig.Kubernetes},
+	func(getInfra infrastructure.InfraFactory) {
+		const clnt, srvr = 0, 1
+
+		var (
+			infra   infrastructure.DatastoreInfra
+			felixes []*infrastructure.Felix
+			client  client.Interface
+			cc      *connectivity.Checker
+			clientW *workload.Workload
+			serverW *workload.Workload
+		)
+
+		BeforeEach(func() {
+			if err := bpf.SupportsXDP(); err != nil {
+				Skip(fmt.Sprintf("XDP acceleration not supported: %v", err))
+			}
+			infra = getInfra()
+			opts := infrastructure.DefaultTopologyOptions()
+			opts.SameHostPairs = true
+			// Let the workloads reach the host's own services.
+			opts.ExtraEnvVars["FELIX_DefaultEndpointToHostAction"] = "ACCEPT"
+			felixes, client = infrastructure.StartNNodeTopology(2, opts, infra)
+			Expect(felixes[clnt]).To(BeIdenticalTo(felixes[srvr]))
+			infra.AddDefaultAllow()
+
+			clientW = workload.Run(felixes[clnt], "client", "default", "10.65.0.2", "8055", "tcp")
+			clientW.ConfigureInInfra(infra)
+			serverW = workload.Run(felixes[srvr], "server", "default", "10.65.0.3", "8055", "tcp")
+			serverW.ConfigureInInfra(infra)
+
+			hostEp := api.NewHostEndpoint()
+			hostEp.Name = "host-endpoint-server"
+			hostEp.Labels = map[string]string{"role": "server"}
+			hostEp.Spec.Node = felixes[srvr].Hostname
+			hostEp.Spec.InterfaceName = "eth0"
+			hostEp.Spec.ExpectedIPs = []string{felixes[srvr].IP}
+			_, err := client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			blocklist := api.NewGlobalNetworkSet()
+			blocklist.Name = "xdpblocklist"
+			blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+			blocklist.Spec.Nets = []string{clientW.IP + "/32"}
+			_, err = client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			order := float64(10)
+			xdpPolicy := api.NewGlobalNetworkPolicy()
+			xdpPolicy.Name = "xdp-filter"
+			xdpPolicy.Spec.Order = &order
+			xdpPolicy.Spec.DoNotTrack = true
+			xdpPolicy.Spec.ApplyOnForward = true
+			xdpPolicy.Spec.Selector = "role=='server'"
+			xdpPolicy.Spec.Ingress = []api.Rule{{
+				Action: api.Deny,
+				Source: api.EntityRule{Selector: "xdpblocklist-set=='true'"},
+			}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, xdpPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			cc = &connectivity.Checker{Protocol: "tcp"}
+		})
+
+		AfterEach(func() {
+			if CurrentGinkgoTestDescription().Failed {
+				infra.DumpErrorData()
+				felixes[srvr].Exec("iptables-save", "-c")
+				felixes[srvr].Exec("ip", "link")
+			}
+			clientW.Stop()
+			serverW.Stop()
+			// Both nodes share the Felix.
+			felixes[srvr].Stop()
+			infra.Stop()
+		})
+
+		It("should not apply the eth0 XDP blocklist to traffic between local workloads", func() {
+			Eventually(func() (string, error) {
+				return felixes[srvr].ExecOutput("ip", "link", "show", "dev", "eth0")
+			}, "10s", "1s").Should(ContainSubstring("prog/xdp"))
+			if !BPFMode() {
+				Eventually(func() ([]string, error) {
+					return felixes[srvr].XDPBlocklistCIDRs("eth0")
+				}, "10s", "1s").Should(ConsistOf(clientW.IP + "/32"))
+			}
+
+			// The packets go from one veth to the other without passing through eth0.
+			cc.ExpectSome(clientW, serverW)
+			cc.CheckConnectivity()
+		})
+
+		It("should not apply a blocklist CIDR that contains the pod CIDR to the node's own pods and addresses", func() {
+			hostW := workload.Run(felixes[srvr], "host", "", felixes[srvr].IP, "8056", "tcp")
+			defer hostW.Stop()
+
+			blocklist, err := client.GlobalNetworkSets().Get(utils.Ctx, "xdpblocklist", options.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			blocklist.Spec.Nets = []string{"10.0.0.0/8"}
+			_, err = client.GlobalNetworkSets().Update(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			if !BPFMode() {
+				Eventually(func() ([]string, error) {
+					return felixes[srvr].XDPBlocklistCIDRs("eth0")
+				}, "10s", "1s").Should(ConsistOf("10.0.0.0/8"))
+				Eventually(felixes[srvr].XDPAllowListCIDRs, "10s", "1s").Should(ContainElements(
+					felixes[srvr].IP+"/32", clientW.IP+"/32", serverW.IP+"/32"))
+
+				// The program passes the node's own pods before it looks at the blocklist,
+				// but still drops other addresses in the blocked CIDR.
+				Expect(bpf.XDPTestLookup(felixes[srvr], "eth0", clientW.IP)).To(Equal(bpf.XDPVerdictPass))
+				Expect(bpf.XDPTestLookup(felixes[srvr], "eth0", "10.65.99.1")).To(Equal(bpf.XDPVerdictDrop))
+			}
+
+			cc.ExpectSome(clientW, hostW)
+			cc.ExpectSome(clientW, serverW)
+			cc.CheckConnectivity()
+		})
+	})
@@ -17,9 +17,18 @@
 package fv_test
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
 	"regexp"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -29,35 +38,68 @@ import (
 	"github.com/projectcalico/calico/felix/fv/connectivity"
 
 	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/api/pkg/lib/numorstring"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/bpf/conntrack"
+	"github.com/projectcalico/calico/felix/bpf/counters"
 	"github.com/projectcalico/calico/felix/fv/infrastructure"
+	"github.com/projectcalico/calico/felix/fv/metrics"
+	"github.com/projectcalico/calico/felix/fv/tcpdump"
 	"github.com/projectcalico/calico/felix/fv/utils"
 	"github.com/projectcalico/calico/felix/fv/workload"
+	"github.com/projectcalico/calico/felix/fv/xdpmaps"
+	"github.com/projectcalico/calico/felix/labelindex"
+	"github.com/projectcalico/calico/kube-controllers/pkg/config"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/networksetasn"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/networksetfeed"
 	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
 	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/errors"
 	"github.com/projectcalico/calico/libcalico-go/lib/options"
 )
 
 const (
 	resyncPeriod = 11 * time.Second
+
+	// xdpEventLogPath is where, in each Felix container, Felix records the XDP programs that it
+	// attaches and detaches.
+	xdpEventLogPath = "/tmp/xdp-events.log"
+
+	// xdpAttachLatencyBound is how soon after an untracked policy is accepted by the datastore
+	// Felix should attach the XDP program that enforces it.
+	xdpAttachLatencyBound = 5 * time.Second
+
+	// minAllowedUDPRate and maxAllowedUDPLossPct are what an allowed UDP flow through an
+	// interface with an XDP program should achieve when sent at the checker's default rate.
+	minAllowedUDPRate    = 0.9 * connectivity.DefaultThroughputSendRate
+	maxAllowedUDPLossPct = 2.0
 )
 
 var (
-	_ = describeXDPTests("tcp")
-	_ = describeXDPTests("udp")
+	_ = describeXDPTests("tcp", false)
+	_ = describeXDPTests("udp", false)
+	// Policy and IP set updates reach Felix through Typha in real clusters; running one of
+	// the protocols that way is enough to cover the fan-out path.
+	_ = describeXDPTests("udp", true)
 )
 
-func describeXDPTests(proto string) bool {
+func describeXDPTests(proto string, withTypha bool) bool {
+	description := fmt.Sprintf("_BPF-SAFE_ XDP tests with initialized Felix proto=%s", proto)
+	if withTypha {
+		description += " with Typha"
+	}
 	return infrastructure.DatastoreDescribe(
-		fmt.Sprintf("_BPF-SAFE_ XDP tests with initialized Felix proto=%s", proto),
-		[]apiconfig.DatastoreType{apiconfig.EtcdV3 /*, apiconfig.Kubernetes*/},
+		description,
+		[]apiconfig.DatastoreType{apiconfig.EtcdV3, apiconfig.Kubernetes},
 		func(getInfra infrastructure.InfraFactory) {
-			xdpTest(getInfra, proto)
+			xdpTest(getInfra, proto, withTypha)
 		})
 }
 
-func xdpTest(getInfra infrastructure.InfraFactory, proto string) {
+func xdpTest(getInfra infrastructure.InfraFactory, proto string, withTypha bool) {
 	var (
 		infra       infrastructure.DatastoreInfra
 		felixes     []*infrastructure.Felix
@@ -73,14 +115,22 @@ func xdpTest(getInfra infrastructure.InfraFactory, proto string) {
 		}
 		infra = getInfra()
 		opts := infrastructure.DefaultTopologyOptions()
+		opts.WithTypha = withTypha
 
 		opts.ExtraEnvVars = map[string]string{
 			"FELIX_GENERICXDPENABLED":  "1",
 			"FELIX_XDPREFRESHINTERVAL": "10",
 			"FELIX_LOGSEVERITYSCREEN":  "debug",
+			"FELIX_XDPEVENTLOG":        xdpEventLogPath,
 			"FELIX_FAILSAFEINBOUNDHOSTPORTS": "tcp:22, udp:68, tcp:179, tcp:2379, tcp:2380, " +
 				"tcp:5473, tcp:6443, tcp:6666, tcp:6667, " + proto + ":1234", // defaults + 1234
 		}
+		if BPFMode() {
+			// Needed for the per-rule packet counters.
+			opts.ExtraEnvVars["FELIX_BPFPOLICYDEBUGENABLED"] = "true"
+			// Needed for the XDP events ring buffer, which is off by default.
+			opts.ExtraEnvVars["FELIX_XDPLOGLEVEL"] = "debug"
+		}
 
 		roles := []string{"client", "server"}
 		felixes, client = infrastructure.StartNNodeTopology(len(roles), opts, infra)
@@ -112,7 +162,34 @@ func xdpTest(getInfra infrastructure.InfraFactory, proto string) {
 			Expect(err).NotTo(HaveOccurred())
 		}
 
-		cc = &connectivity.Checker{Protocol: proto}
+		cc = &connectivity.Checker{
+			Protocol: proto,
+			Cache:    &connectivity.ResultCache{StateVersion: datastoreRevision(client)},
+		}
+		for ii, role := range roles {
+			cc.SetRole(role, hostW[ii])
+		}
+	})
+
+	AfterEach(func() {
+		if CurrentGinkgoTestDescription().Failed {
+			return
+		}
+		// Remove everything that makes Felix program XDP and check that it cleans up after
+		// itself.  This runs before the AfterEach below stops the Felixes.
+		heps, err := client.HostEndpoints().List(utils.Ctx, options.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		for _, hep := range heps.Items {
+			_, err = client.HostEndpoints().Delete(utils.Ctx, hep.Name, options.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		gnps, err := client.GlobalNetworkPolicies().List(utils.Ctx, options.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		for _, gnp := range gnps.Items {
+			_, err = client.GlobalNetworkPolicies().Delete(utils.Ctx, gnp.Name, options.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		infrastructure.AssertNoLeakedBPFObjects(felixes)
 	})
 
 	AfterEach(func() {
@@ -129,39 +206,41 @@ func xdpTest(getInfra infrastructure.InfraFactory, proto string) {
 		for _, felix := range felixes {
 			felix.Stop()
 		}
+		if len(felixes) > 0 && felixes[0].Typha != nil {
+			felixes[0].Typha.Stop()
+		}
 
 		infra.Stop()
 	})
 
 	clnt, srvr := 0, 1
 
-	expectNoConnectivity := func(cc *connectivity.Checker) {
-		cc.ExpectNone(felixes[clnt], hostW[srvr].Port(8055))
-		cc.ExpectNone(felixes[srvr], hostW[clnt].Port(8055))
-		cc.ExpectNone(felixes[clnt], hostW[srvr].Port(8056))
-		cc.ExpectNone(felixes[srvr], hostW[clnt].Port(8056))
-		cc.CheckConnectivityOffset(1)
-		cc.ResetExpectations()
+	// etcdOnly skips a spec that depends on the etcd datastore when running against the
+	// Kubernetes datastore.
+	etcdOnly := func(reason string) {
+		if _, ok := infra.(*infrastructure.K8sDatastoreInfra); ok {
+			Skip("Only runs against etcd: " + reason)
+		}
 	}
 
 	expectAllAllowed := func(cc *connectivity.Checker) {
-		cc.ExpectSome(felixes[clnt], hostW[srvr].Port(8055))
-		cc.ExpectSome(felixes[clnt], hostW[srvr].Port(8056))
+		cc.From("client").To("server").ExpectSome(8055)
+		cc.From("client").To("server").ExpectSome(8056)
 		cc.CheckConnectivityOffset(1)
 		cc.ResetExpectations()
 	}
 
 	expectBlocked := func(cc *connectivity.Checker) {
-		cc.ExpectNone(felixes[clnt], hostW[srvr].Port(8055))
-		cc.ExpectNone(felixes[clnt], hostW[srvr].Port(8056))
+		cc.From("client").To("server").ExpectNone(8055)
+		cc.From("client").To("server").ExpectNone(8056)
 		cc.CheckConnectivityOffset(1)
 		cc.ResetExpectations()
 	}
 
 	expectFailsafePortsOpen := func(cc *connectivity.Checker) {
-		cc.ExpectNone(felixes[clnt], hostW[srvr].Port(8055))
-		cc.ExpectNone(felixes[clnt], hostW[srvr].Port(8056))
-		cc.ExpectSome(felixes[clnt], hostW[srvr].Port(1234))
+		cc.From("client").To("server").ExpectNone(8055)
+		cc.From("client").To("server").ExpectNone(8056)
+		cc.From("client").To("server").ExpectSome(1234)
 		cc.CheckConnectivityOffset(1)
 		cc.ResetExpectations()
 	}
@@ -179,7 +258,8 @@ func xdpTest(getInfra infrastructure.InfraFactory, proto string) {
 	}
 
 	It("should have expected no connectivity at first", func() {
-		expectNoConnectivity(cc)
+		infrastructure.AssertDefaultDeny(cc, felixes,
+			[]connectivity.ConnectionTarget{hostW[clnt], hostW[srvr]}, 8055, 8056)
 	})
 
 	xdpProgramID := func(felix *infrastructure.Felix, iface string) int {
@@ -195,6 +275,25 @@ func xdpTest(getInfra infrastructure.InfraFactory, proto string) {
 		return id
 	}
 
+	xdpMode := func(felix *infrastructure.Felix, iface string) string {
+		out, err := felix.ExecCombinedOutput("ip", "link", "show", "dev", iface)
+		Expect(err).NotTo(HaveOccurred())
+		r := regexp.MustCompile(`mtu \d+ (xdp\w*) `)
+		matches := r.FindStringSubmatch(out)
+		if len(matches) == 0 {
+			return ""
+		}
+		return matches[1]
+	}
+
+	setXDPMode := func(name string, mode api.XDPMode) {
+		hostEp, err := client.HostEndpoints().Get(utils.Ctx, name, options.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		hostEp.Spec.XDPMode = mode
+		_, err = client.HostEndpoints().Update(utils.Ctx, hostEp, options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
 	xdpProgramAttached := func(felix *infrastructure.Felix, iface string) bool {
 		return xdpProgramID(felix, iface) != 0
 	}
@@ -213,6 +312,329 @@ func xdpTest(getInfra infrastructure.InfraFactory, proto string) {
 			Eventually(xdpProgramAttached_server_eth0, "10s", "1s").Should(BeFalse())
 			Consistently(xdpProgramAttached_server_eth0, "2s", "1s").Should(BeFalse())
 		})
+
+		if !BPFMode() {
+			It("should count each step of an XDP program's lifecycle once", func() {
+				lifecycle := func(before infrastructure.XDPLifecycleMetrics) func() (infrastructure.XDPLifecycleMetrics, error) {
+					return func() (infrastructure.XDPLifecycleMetrics, error) {
+						m, err := felixes[srvr].XDPLifecycleMetrics()
+						return m.Sub(before), err
+					}
+				}
+				var before infrastructure.XDPLifecycleMetrics
+				Eventually(func() (err error) {
+					before, err = felixes[srvr].XDPLifecycleMetrics()
+					return
+				}, "10s", "1s").Should(Succeed())
+
+				By("creating an untracked policy that blocks a network set")
+				netSet := api.NewGlobalNetworkSet()
+				netSet.Name = "xdpblocklist"
+				netSet.Spec.Nets = []string{hostW[clnt].IP + "/32"}
+				netSet.Labels = map[string]string{"xdpblocklist-set": "true"}
+				netSet, err := client.GlobalNetworkSets().Create(utils.Ctx, netSet, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+				xdpPolicy := api.NewGlobalNetworkPolicy()
+				xdpPolicy.Name = "xdp-filter"
+				xdpPolicy.Spec.DoNotTrack = true
+				xdpPolicy.Spec.ApplyOnForward = true
+				xdpPolicy.Spec.Selector = "role=='server'"
+				xdpPolicy.Spec.Ingress = []api.Rule{{
+					Action: api.Deny,
+					Source: api.EntityRule{Selector: "xdpblocklist-set=='true'"},
+				}}
+				_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, xdpPolicy, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(xdpProgramAttached_server_eth0, "10s", "1s").Should(BeTrue())
+				Eventually(lifecycle(before), "10s", "1s").Should(Equal(infrastructure.XDPLifecycleMetrics{
+					Attaches: 1, MapCreates: 1, MapUpdates: 1,
+				}))
+
+				By("adding a CIDR to the network set")
+				netSet.Spec.Nets = append(netSet.Spec.Nets, "10.65.0.1/32")
+				_, err = client.GlobalNetworkSets().Update(utils.Ctx, netSet, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(lifecycle(before), "10s", "1s").Should(Equal(infrastructure.XDPLifecycleMetrics{
+					Attaches: 1, MapCreates: 1, MapUpdates: 2,
+				}))
+
+				By("deleting the policy")
+				_, err = client.GlobalNetworkPolicies().Delete(utils.Ctx, "xdp-filter", options.DeleteOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(xdpProgramAttached_server_eth0, "10s", "1s").Should(BeFalse())
+				expected := infrastructure.XDPLifecycleMetrics{
+					Attaches: 1, Detaches: 1, MapCreates: 1, MapUpdates: 2,
+				}
+				Eventually(lifecycle(before), "10s", "1s").Should(Equal(expected))
+
+				By("resyncing without finding anything to repair")
+				Consistently(lifecycle(before), resyncPeriod, "1s").Should(Equal(expected))
+				_, err = client.GlobalNetworkSets().Delete(utils.Ctx, "xdpblocklist", options.DeleteOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		}
+	})
+
+	Context("with a tracked deny policy on felix[srvr]", func() {
+		BeforeEach(func() {
+			order := float64(20)
+			allowAllPolicy := api.NewGlobalNetworkPolicy()
+			allowAllPolicy.Name = "allow-all"
+			allowAllPolicy.Spec.Order = &order
+			allowAllPolicy.Spec.Selector = "all()"
+			allowAllPolicy.Spec.Ingress = []api.Rule{{Action: api.Allow}}
+			allowAllPolicy.Spec.Egress = []api.Rule{{Action: api.Allow}}
+			_, err := client.GlobalNetworkPolicies().Create(utils.Ctx, allowAllPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			// The same blocklist and policy as the XDP blocklist tests use, but without
+			// DoNotTrack.
+			blocklist := api.NewGlobalNetworkSet()
+			blocklist.Name = "xdpblocklist"
+			blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+			blocklist.Spec.Nets = []string{hostW[clnt].IP + "/32"}
+			_, err = client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			order = float64(10)
+			denyPolicy := api.NewGlobalNetworkPolicy()
+			denyPolicy.Name = "xdp-filter"
+			denyPolicy.Spec.Order = &order
+			denyPolicy.Spec.Selector = "role=='server'"
+			denyPolicy.Spec.Ingress = []api.Rule{{
+				Action: api.Deny,
+				Source: api.EntityRule{Selector: "xdpblocklist-set=='true'"},
+			}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, denyPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should enforce the policy without attaching an XDP program", func() {
+			Expect(infrastructure.WaitForAllInSync(felixes, 20*time.Second)).To(Succeed())
+			expectBlocked(cc)
+			Consistently(xdpProgramAttached_server_eth0, "5s", "1s").Should(BeFalse())
+
+			By("making the policy untracked")
+			denyPolicy, err := client.GlobalNetworkPolicies().Get(utils.Ctx, "xdp-filter", options.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			denyPolicy.Spec.DoNotTrack = true
+			denyPolicy.Spec.ApplyOnForward = true
+			_, err = client.GlobalNetworkPolicies().Update(utils.Ctx, denyPolicy, options.SetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(xdpProgramAttached_server_eth0, "10s", "1s").Should(BeTrue())
+			expectBlocked(cc)
+		})
+	})
+
+	Context("with an untracked policy denying ICMP echo requests from felixes[clnt]", func() {
+		BeforeEach(func() {
+			order := float64(20)
+			allowAllPolicy := api.NewGlobalNetworkPolicy()
+			allowAllPolicy.Name = "allow-all"
+			allowAllPolicy.Spec.Order = &order
+			allowAllPolicy.Spec.Selector = "all()"
+			allowAllPolicy.Spec.Ingress = []api.Rule{{Action: api.Allow}}
+			allowAllPolicy.Spec.Egress = []api.Rule{{Action: api.Allow}}
+			_, err := client.GlobalNetworkPolicies().Create(utils.Ctx, allowAllPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			blocklist := api.NewGlobalNetworkSet()
+			blocklist.Name = "xdpblocklist"
+			blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+			blocklist.Spec.Nets = []string{hostW[clnt].IP + "/32"}
+			_, err = client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			order = float64(10)
+			icmpProto := numorstring.ProtocolFromString("ICMP")
+			echoRequest := 8
+			denyPolicy := api.NewGlobalNetworkPolicy()
+			denyPolicy.Name = "xdp-filter"
+			denyPolicy.Spec.Order = &order
+			denyPolicy.Spec.DoNotTrack = true
+			denyPolicy.Spec.ApplyOnForward = true
+			denyPolicy.Spec.Selector = "role=='server'"
+			denyPolicy.Spec.Ingress = []api.Rule{{
+				Action:   api.Deny,
+				Protocol: &icmpProto,
+				ICMP:     &api.ICMPFields{Type: &echoRequest},
+				Source:   api.EntityRule{Selector: "xdpblocklist-set=='true'"},
+			}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, denyPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			_, _ = client.GlobalNetworkPolicies().Delete(utils.Ctx, "allow-all", options.DeleteOptions{})
+			_, _ = client.GlobalNetworkSets().Delete(utils.Ctx, "xdpblocklist", options.DeleteOptions{})
+			_, _ = client.GlobalNetworkPolicies().Delete(utils.Ctx, "xdp-filter", options.DeleteOptions{})
+		})
+
+		It("should drop pings but let ICMP errors and other traffic through", func() {
+			doPing := func() error {
+				return utils.RunMayFail("docker", "exec", felixes[clnt].Name, "ping", "-c", "1", "-w", "1", hostW[srvr].IP)
+			}
+			Eventually(doPing, "20s", "100ms").Should(HaveOccurred())
+			Expect(utils.LastRunOutput).To(ContainSubstring(`100% packet loss`))
+			Expect(doPing()).To(HaveOccurred())
+
+			if BPFMode() {
+				// The XDP policy program matches the ICMP type itself.
+				Expect(xdpProgramAttached_server_eth0()).To(BeTrue())
+			} else {
+				// The iptables-mode XDP program can only match on source CIDRs so
+				// the policy is rendered only in the raw table.
+				Consistently(xdpProgramAttached_server_eth0, "2s", "1s").Should(BeFalse())
+			}
+
+			By("getting the client's port unreachable errors back on the server")
+			icmpErrs := &connectivity.Checker{Protocol: "udp"}
+			icmpErrs.ExpectUDPPortClosed(hostW[srvr], hostW[clnt], 8057)
+			icmpErrs.CheckConnectivity()
+
+			expectAllAllowed(cc)
+		})
+	})
+
+	if !BPFMode() {
+		Context("with random sequences of XDP policy and network set changes", func() {
+			var fuzzer *infrastructure.XDPFuzzer
+
+			BeforeEach(func() {
+				seed := time.Now().UnixNano()
+				if s := os.Getenv("FV_XDP_FUZZ_SEED"); s != "" {
+					var err error
+					seed, err = strconv.ParseInt(s, 10, 64)
+					Expect(err).NotTo(HaveOccurred())
+				}
+				log.WithField("seed", seed).Info("Fuzzing XDP policies; set FV_XDP_FUZZ_SEED to replay")
+				fuzzer = infrastructure.NewXDPFuzzer(client, seed, "role=='server'")
+			})
+
+			AfterEach(func() {
+				Expect(fuzzer.Cleanup()).To(Succeed())
+			})
+
+			blocklist := func() ([]string, error) {
+				const blocklistMapPath = "/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist"
+				if felixes[srvr].ExecMayFail("test", "-e", blocklistMapPath) != nil {
+					// Felix removes the map when no untracked policy needs it.
+					return []string{}, nil
+				}
+				snapshot, err := bpf.SnapshotMap(felixes[srvr], blocklistMapPath)
+				if err != nil {
+					return nil, err
+				}
+				return snapshot.CIDRs()
+			}
+
+			It("should converge the XDP blocklist on the datastore", func() {
+				for i := 0; i < 5; i++ {
+					for j := 0; j < 10; j++ {
+						Expect(fuzzer.Step()).To(Succeed())
+					}
+					Expect(infrastructure.WaitForAllInSync(felixes, 20*time.Second)).To(Succeed())
+					Eventually(blocklist, "10s", "200ms").Should(Equal(fuzzer.ExpectedBlocklist()))
+				}
+			})
+		})
+	}
+
+	Context("with an untracked policy on felix[srvr] denying ingress to one of its addresses", func() {
+		const deniedIP = "10.65.222.2"
+
+		var deniedW *workload.Workload
+
+		BeforeEach(func() {
+			if BPFMode() {
+				Skip("Destination matching is only implemented by the iptables dataplane's XDP program.")
+			}
+
+			// Give the server a second address, with a workload listening on it, and route
+			// the client's traffic for it to the server.
+			felixes[srvr].Exec("ip", "addr", "add", deniedIP+"/32", "dev", "eth0")
+			felixes[clnt].Exec("ip", "route", "add", deniedIP+"/32", "via", felixes[srvr].IP)
+			deniedW = workload.Run(felixes[srvr], "host1-denied", "", deniedIP, "8055", proto,
+				workload.WithHostNetworking())
+
+			order := float64(20)
+			allowAllPolicy := api.NewGlobalNetworkPolicy()
+			allowAllPolicy.Name = "allow-all"
+			allowAllPolicy.Spec.Order = &order
+			allowAllPolicy.Spec.Selector = "all()"
+			allowAllPolicy.Spec.Ingress = []api.Rule{{Action: api.Allow}}
+			allowAllPolicy.Spec.Egress = []api.Rule{{Action: api.Allow}}
+			_, err := client.GlobalNetworkPolicies().Create(utils.Ctx, allowAllPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			deniedDsts := api.NewGlobalNetworkSet()
+			deniedDsts.Name = "denied-dsts"
+			deniedDsts.Labels = map[string]string{"denied-dst": "true"}
+			deniedDsts.Spec.Nets = []string{deniedIP + "/32"}
+			_, err = client.GlobalNetworkSets().Create(utils.Ctx, deniedDsts, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			order = float64(10)
+			xdpPolicy := api.NewGlobalNetworkPolicy()
+			xdpPolicy.Name = "xdp-filter"
+			xdpPolicy.Spec.Order = &order
+			xdpPolicy.Spec.DoNotTrack = true
+			xdpPolicy.Spec.ApplyOnForward = true
+			xdpPolicy.Spec.Selector = "role=='server'"
+			xdpPolicy.Spec.Ingress = []api.Rule{{
+				Action:      api.Deny,
+				Destination: api.EntityRule{Selector: "denied-dst=='true'"},
+			}}
+			_, err = client.GlobalNetworkPolicies().Create(utils.Ctx, xdpPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(xdpProgramAttached_server_eth0, "10s", "1s").Should(BeTrue())
+		})
+
+		AfterEach(func() {
+			deniedW.Stop()
+			_, _ = client.GlobalNetworkPolicies().Delete(utils.Ctx, "allow-all", options.DeleteOptions{})
+			_, _ = client.GlobalNetworkSets().Delete(utils.Ctx, "denied-dsts", options.DeleteOptions{})
+			_, _ = client.GlobalNetworkPolicies().Delete(utils.Ctx, "xdp-filter", options.DeleteOptions{})
+		})
+
+		It("should drop traffic to the denied address in XDP and allow traffic to the others", func() {
+			Expect(infrastructure.WaitForAllInSync(felixes, 20*time.Second)).To(Succeed())
+			Eventually(func() ([]string, error) {
+				return felixes[srvr].XDPDstCIDRs("eth0")
+			}, "10s", "1s").Should(ConsistOf(deniedIP + "/32"))
+			blocklist, err := felixes[srvr].XDPBlocklistCIDRs("eth0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blocklist).To(BeEmpty(), "destinations shouldn't be put in the source blocklist")
+
+			cc.ExpectNone(hostW[clnt], deniedW.Port(8055))
+			cc.ExpectSome(hostW[clnt], hostW[srvr].Port(8055))
+			cc.ExpectSome(hostW[clnt], hostW[srvr].Port(8056))
+			cc.CheckConnectivity()
+			cc.ResetExpectations()
+
+			By("allowing the address again when it's removed from the set")
+			_, err = client.GlobalNetworkSets().Delete(utils.Ctx, "denied-dsts", options.DeleteOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			cc.ExpectSome(hostW[clnt], deniedW.Port(8055))
+			cc.ExpectSome(hostW[clnt], hostW[srvr].Port(8055))
+			cc.CheckConnectivity()
+		})
+		It("should balance connections across the allowed backends and send none to the denied one", func() {
+			const otherIP = "10.65.222.3"
+			felixes[srvr].Exec("ip", "addr", "add", otherIP+"/32", "dev", "eth0")
+			felixes[clnt].Exec("ip", "route", "add", otherIP+"/32", "via", felixes[srvr].IP)
+			otherW := workload.Run(felixes[srvr], "host1-other", "", otherIP, "8055", proto,
+				workload.WithHostNetworking())
+			defer otherW.Stop()
+			Eventually(func() ([]string, error) {
+				return felixes[srvr].XDPDstCIDRs("eth0")
+			}, "10s", "1s").Should(ConsistOf(deniedIP + "/32"))
+
+			cc.ConnectTimeout = 2 * time.Second
+			cc.ExpectBalancedAcross([]connectivity.ConnectionTarget{hostW[srvr], deniedW, otherW},
+				hostW[clnt], 8055, 20, 0.1)
+		})
 	})
 
 	Context("with XDP blocklist on felix[srvr] blocking felixes[clnt]", func() {
@@ -268,97 +690,1700 @@ func xdpTest(getInfra infrastructure.InfraFactory, proto string) {
 			Consistently(xdpProgramID_server_eth0(), "2s", "100ms").Should(Equal(id))
 		})
 
-		Context("with untracked policies deleted again", func() {
-			BeforeEach(func() {
-				_, _ = client.GlobalNetworkPolicies().Delete(utils.Ctx, "xdp-filter", options.DeleteOptions{})
-			})
+		It("should pass the TCP options of allowed SYNs through unchanged", func() {
+			if proto != "tcp" {
+				Skip("TCP options only apply to TCP")
+			}
+			// Block another source, so that the client's SYNs are looked up in a non-empty
+			// blocklist on their way through the XDP program.
+			blocklist := api.NewGlobalNetworkSet()
+			blocklist.Name = "xdpblocklist"
+			blocklist.Spec.Nets = []string{"10.123.0.0/16"}
+			blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+			_, err := client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
 
-			It("should not have XDP program attached", func() {
-				Eventually(xdpProgramAttached_server_eth0, "10s", "1s").Should(BeFalse())
-				Consistently(xdpProgramAttached_server_eth0, "2s", "1s").Should(BeFalse())
-			})
+			cc.ExpectSomeWithWindowScale(hostW[clnt], hostW[srvr], 8056)
+			cc.CheckConnectivity()
 		})
 
-		applyGlobalNetworkSets := func(name string, ip string, cidrToHexSuffix string, update bool) (hexCIDR []string) {
-			// create GlobalNetworkSet with IP of felixes[clnt]
-			var srcNS *api.GlobalNetworkSet
-			var err error
-			if update {
-				srcNS, err = client.GlobalNetworkSets().Get(utils.Ctx, name, options.GetOptions{})
-				Expect(err).NotTo(HaveOccurred())
+		It("should tell policy drops apart from missing routes and closed ports", func() {
+			if proto != "tcp" {
+				Skip("Closed ports and unreachables are only reported for TCP")
+			}
+			blocklist := api.NewGlobalNetworkSet()
+			blocklist.Name = "xdpblocklist"
+			blocklist.Spec.Nets = []string{hostW[clnt].IP}
+			blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+			blocklist, err := client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+			expectBlocked(cc)
+			Expect(cc.ClassifyFailure(hostW[clnt], hostW[srvr], 8055)).To(Equal(connectivity.FailurePolicyDrop))
 
-				srcNS.Spec.Nets = []string{ip}
+			By("unblocking the client")
+			blocklist.Spec.Nets = []string{"10.123.0.0/16"}
+			_, err = client.GlobalNetworkSets().Update(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+			cc.ExpectSome(hostW[clnt], hostW[srvr].Port(8055))
+			cc.CheckConnectivity()
+			Expect(cc.ClassifyFailure(hostW[clnt], hostW[srvr], 8055)).To(Equal(connectivity.FailureNone))
+			Expect(cc.ClassifyFailure(hostW[clnt], hostW[srvr], 8057)).To(Equal(connectivity.FailurePortClosed))
+
+			By("connecting to an address that the client has no route to")
+			felixes[clnt].Exec("ip", "route", "add", "unreachable", "10.65.250.0/24")
+			defer felixes[clnt].Exec("ip", "route", "del", "unreachable", "10.65.250.0/24")
+			Expect(cc.ClassifyFailure(hostW[clnt], connectivity.TargetIP("10.65.250.1"), 8055)).To(
+				Equal(connectivity.FailureNoRoute))
+		})
 
-				_, err = client.GlobalNetworkSets().Update(utils.Ctx, srcNS, utils.NoOptions)
-			} else {
-				srcNS = api.NewGlobalNetworkSet()
-				srcNS.Name = name
-				srcNS.Spec.Nets = []string{ip}
-				srcNS.Labels = map[string]string{
-					"xdpblocklist-set": "true",
-				}
-				_, err = client.GlobalNetworkSets().Create(utils.Ctx, srcNS, utils.NoOptions)
+		It("should drop a blocked SYN flood before it reaches the server's listen queues", func() {
+			if proto != "tcp" {
+				Skip("Listen queues only apply to TCP")
 			}
+			// Flood from spoofed sources, which never complete the handshake, so that any
+			// SYN that gets through leaves a half-open connection behind for a while.
+			const blockedSrc, allowedSrc = "10.65.222.1", "10.65.223.1"
+			blocklist := api.NewGlobalNetworkSet()
+			blocklist.Name = "xdpblocklist"
+			blocklist.Spec.Nets = []string{"10.65.222.0/24"}
+			blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+			_, err := client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
 			Expect(err).NotTo(HaveOccurred())
+			if !BPFMode() {
+				Eventually(func() ([]string, error) {
+					return felixes[srvr].XDPBlocklistCIDRs("eth0")
+				}, "10s", "1s").Should(ConsistOf("10.65.222.0/24"))
+			}
 
-			hexCIDR, err = bpf.CidrToHex(ip + cidrToHexSuffix)
-			Expect(err).NotTo(HaveOccurred())
-			return hexCIDR
-		}
+			flood := func(srcIP string) {
+				// hping3 fails when it gets no replies, which is the point.
+				_ = felixes[clnt].ExecMayFail("hping3", "-S", "-c", "200", "-i", "u1000",
+					"-a", srcIP, "-p", "8055", hostW[srvr].IP)
+			}
+			queueStats := func() (connectivity.ListenQueueStats, error) {
+				return hostW[srvr].ListenQueueStats()
+			}
 
-		Context("blocking server IP", func() {
-			BeforeEach(func() {
-				_ = applyGlobalNetworkSets("xdpblocklist", hostW[srvr].IP, "/32", false)
-			})
+			By("flooding from a blocked source")
+			flood(blockedSrc)
+			Consistently(queueStats, "2s", "200ms").Should(Equal(connectivity.ListenQueueStats{}))
 
-			It("should allow connections from other IPs to the server", func() {
-				expectAllAllowed(cc)
-			})
-			// NJ: this is odd; no blocklist testing here.
+			By("accepting connections from an allowed source meanwhile")
+			cc.ExpectSome(hostW[clnt], hostW[srvr].Port(8055))
+			cc.CheckConnectivity()
+
+			By("seeing an allowed source's flood in the half-open connections")
+			// Shows that the server would have reported the blocked flood.
+			flood(allowedSrc)
+			Eventually(queueStats, "5s", "200ms").Should(
+				WithTransform(func(s connectivity.ListenQueueStats) int { return s.SynRecvMax }, BeNumerically(">", 0)))
 		})
 
-		Context("blocking full IP", func() {
-			BeforeEach(func() {
-				hostHexCIDR = applyGlobalNetworkSets("xdpblocklist", hostW[clnt].IP, "/32", false)
-			})
+		It("should reuse its XDP maps rather than creating new ones as it reconciles", func() {
+			blocklist := api.NewGlobalNetworkSet()
+			blocklist.Name = "xdpblocklist"
+			blocklist.Spec.Nets = []string{hostW[clnt].IP}
+			blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+			blocklist, err := client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+			expectBlocked(cc)
 
-			It("should block packets smaller than UDP", func() {
-				doHping := func() error {
-					return utils.RunMayFail("docker", "exec", felixes[clnt].Name, "hping3", "--rawip", "-c", "1", "-H", "254", "-d", "1", hostW[srvr].IP)
+			countMaps := func(prefix string) func() (int, error) {
+				return func() (int, error) {
+					return bpf.CountCalicoMaps(felixes[srvr], prefix)
 				}
-				Eventually(doHping, "20s", "100ms").Should(HaveOccurred())
-				Expect(utils.LastRunOutput).To(ContainSubstring(`100% packet loss`))
-				Expect(doHping()).To(HaveOccurred())
+			}
+			ifaceMaps, err := countMaps("eth0_")()
+			Expect(err).NotTo(HaveOccurred())
+			sharedMaps, err := countMaps("calico_")()
+			Expect(err).NotTo(HaveOccurred())
+			if !BPFMode() {
+				// One map per blocklist shard; the rule has no destinations, so there's no
+				// destination map.
+				shards, err := xdpmaps.BlocklistShardInfo(felixes[srvr], "eth0")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ifaceMaps).To(Equal(len(shards)))
+			}
 
+			for i := 0; i < 3; i++ {
+				blocklist.Spec.Nets = append(blocklist.Spec.Nets, fmt.Sprintf("10.123.%d.0/24", i))
+				blocklist, err = client.GlobalNetworkSets().Update(utils.Ctx, blocklist, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
 				if !BPFMode() {
-					output, err := felixes[srvr].ExecOutput("iptables", "-t", "raw", "-v", "-n", "-L", "cali-pi-default.xdp-filter")
-					// the only rule that refers to a cali40-prefixed ipset should
-					// have 0 packets/bytes because the raw small packets should've been
-					// blocked by XDP
-					Expect(err).NotTo(HaveOccurred())
-					Expect(output).To(MatchRegexp(`(?m)^\s+0\s+0.*cali40s:`))
+					Eventually(func() ([]string, error) {
+						return felixes[srvr].XDPBlocklistCIDRs("eth0")
+					}, "10s", "1s").Should(ContainElement(fmt.Sprintf("10.123.%d.0/24", i)))
 				}
-			})
-
-			if BPFMode() {
-				// The following test case only works for the old iptables-mode XDP
-				// implementation of untracked ingress deny policy.  The BPF mode
-				// and iptables chain implementations of untracked ingress policy
-				// both match against both inbound and outbound failsafes - which we
-				// now believe is the most correct behaviour - and it was an
-				// oversight that outbound failsafes were not added to the old XDP
-				// program.  It isn't worth fixing the old XDP program now, as it's
-				// likely it will be replaced with the new XDP as used in BPF mode.
-			} else {
-				It("should block connections even if the source port is a failsafe port", func() {
-					expectSourceFailsafePortBlocked(cc)
-				})
 			}
+			expectBlocked(cc)
 
-			It("should block ICMP too", func() {
-				doPing := func() error {
-					return utils.RunMayFail("docker", "exec", felixes[clnt].Name, "ping", "-c", "1", "-w", "1", hostW[srvr].IP)
-				}
+			// Span a periodic XDP resync as well as the updates.
+			Consistently(countMaps("eth0_"), "12s", "2s").Should(Equal(ifaceMaps))
+			Expect(countMaps("calico_")()).To(Equal(sharedMaps))
+		})
+
+		It("should report the XDP verdict for blocked and allowed sources without sending traffic", func() {
+			blocklist := api.NewGlobalNetworkSet()
+			blocklist.Name = "xdpblocklist"
+			blocklist.Spec.Nets = []string{hostW[clnt].IP}
+			blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+			_, err := client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func() (bpf.XDPVerdict, error) {
+				return bpf.XDPTestLookup(felixes[srvr], "eth0", hostW[clnt].IP)
+			}, "10s", "500ms").Should(Equal(bpf.XDPVerdictDrop))
+			Expect(bpf.XDPTestLookup(felixes[srvr], "eth0", "10.65.99.1")).To(Equal(bpf.XDPVerdictPass))
+		})
+
+		It("should enforce the untracked and tracked policies as the policy model predicts", func() {
+			order := float64(15)
+			protocol := numorstring.ProtocolFromString(strings.ToUpper(proto))
+			denyPolicy := api.NewGlobalNetworkPolicy()
+			denyPolicy.Name = "deny-8056"
+			denyPolicy.Spec.Order = &order
+			denyPolicy.Spec.Selector = "role=='server'"
+			denyPolicy.Spec.Ingress = []api.Rule{{
+				Action:      api.Deny,
+				Protocol:    &protocol,
+				Destination: api.EntityRule{Ports: []numorstring.Port{numorstring.SinglePort(8056)}},
+			}}
+			_, err := client.GlobalNetworkPolicies().Create(utils.Ctx, denyPolicy, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			tuples := []infrastructure.PolicyTuple{
+				{From: hostW[clnt], To: hostW[srvr], Port: 8055},
+				{From: hostW[clnt], To: hostW[srvr], Port: 8056},
+				{From: hostW[srvr], To: hostW[clnt], Port: 8055},
+				{From: hostW[srvr], To: hostW[clnt], Port: 8056},
+			}
+			infrastructure.AssertMatchesPolicyModel(client, cc, tuples)
+
+			blocklist := api.NewGlobalNetworkSet()
+			blocklist.Name = "xdpblocklist"
+			blocklist.Spec.Nets = []string{hostW[clnt].IP}
+			blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+			_, err = client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Now the untracked policy would also drop the replies to the server's
+			// connections to the client, which the model doesn't know about, so only
+			// check the client's connections.
+			infrastructure.AssertMatchesPolicyModel(client, cc, tuples[:2])
+		})
+
+		It("should load the same XDP program build after Felix restarts", func() {
+			sha, err := bpf.XDPProgramSHA(felixes[srvr], "eth0")
+			Expect(err).NotTo(HaveOccurred())
+
+			felixes[srvr].Restart()
+			Eventually(xdpProgramAttached_server_eth0, "10s", "1s").Should(BeTrue())
+			Eventually(func() (string, error) {
+				return bpf.XDPProgramSHA(felixes[srvr], "eth0")
+			}, "10s", "1s").Should(Equal(sha))
+		})
+
+		It("should keep blocking the client while Felix is killed and recovers", func() {
+			blocklist := api.NewGlobalNetworkSet()
+			blocklist.Name = "xdpblocklist"
+			blocklist.Spec.Nets = []string{hostW[clnt].IP}
+			blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+			_, err := client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+			Expect(err).NotTo(HaveOccurred())
+			expectBlocked(cc)
+
+			monitor := connectivity.BackgroundMonitor(hostW[clnt], hostW[srvr], 8055,
+				connectivity.MonitorWithConnectTimeout(time.Second))
+			defer monitor.Stop()
+			Eventually(func() int { return len(monitor.Timeline()) }, "10s", "100ms").ShouldNot(BeZero())
+
+			felixes[srvr].HardKill()
+			Eventually(xdpProgramAttached_server_eth0, "10s", "1s").Should(BeTrue())
+			if !BPFMode() {
+				// The blocklist was either kept, pinned, or rebuilt from the datastore.
+				Eventually(func() ([]string, error) {
+					return felixes[srvr].XDPBlocklistCIDRs("eth0")
+				}, "10s", "500ms").Should(ConsistOf(hostW[clnt].IP + "/32"))
+			}
+			// Give any traffic that leaks through while Felix resyncs a chance to show up.
+			time.Sleep(2 * time.Second)
+
+			timeline := monitor.Stop()
+			Expect(timeline).NotTo(ContainElement(HaveField("Connected", BeTrue())),
+				"Client got through: %v", timeline)
+			expectBlocked(cc)
+		})
+
+		if !BPFMode() {
+			It("should program every entry of a GlobalNetworkSet that fills the blocklist map", func() {
+				nets, err := infrastructure.CreateMaxGlobalNetworkSet(client, "xdp-max", 0,
+					map[string]string{"xdpblocklist-set": "true"})
+				Expect(err).NotTo(HaveOccurred())
+				defer func() {
+					_, _ = client.GlobalNetworkSets().Delete(utils.Ctx, "xdp-max", options.DeleteOptions{})
+				}()
+
+				Eventually(func() error {
+					return bpf.VerifyAllProgrammed(felixes[srvr], "/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist", nets)
+				}, "60s", "2s").Should(Succeed())
+				expectAllAllowed(cc)
+			})
+		}
+
+		It("should log that the XDP program was attached without failures", func() {
+			// Felix logs successful attaches at debug level, which this suite turns on.
+			felixes[srvr].ExpectLogMatch(`Loading XDP program succeeded|Successfully attached XDP program`, 10*time.Second)
+			felixes[srvr].ExpectNoLogMatch(`failed to (load|attach) XDP program`, 2*time.Second)
+		})
+
+		if !BPFMode() {
+			// The event log isn't supported in BPF mode.
+			It("should attach the XDP program soon after the policy is accepted", func() {
+				var latency time.Duration
+				Eventually(func() (err error) {
+					latency, err = infrastructure.MeasureAttachLatency(client, felixes[srvr], "xdp-filter")
+					return
+				}, "10s", "500ms").Should(Succeed())
+				log.WithField("latency", latency).Info("Measured XDP attach latency.")
+				Expect(latency).To(BeNumerically("<=", xdpAttachLatencyBound),
+					"XDP program attached too long after the policy was accepted")
+			})
+
+			It("should record attaching and detaching the XDP program in the event log", func() {
+				_, err := client.GlobalNetworkPolicies().Delete(utils.Ctx, "xdp-filter", options.DeleteOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(xdpProgramAttached_server_eth0, "10s", "1s").Should(BeFalse())
+
+				out, err := felixes[srvr].ExecOutput("cat", xdpEventLogPath)
+				Expect(err).NotTo(HaveOccurred())
+				lines := strings.Split(strings.TrimSpace(out), "\n")
+				Expect(lines).To(HaveLen(2), "unexpected XDP events:\n%s", out)
+				Expect(lines[0]).To(MatchRegexp(`^\S+ attach iface=eth0 mode=xdpdrv tag=([0-9a-f]+) reason="policy changed"$`))
+				Expect(lines[1]).To(MatchRegexp(`^\S+ detach iface=eth0 tag=([0-9a-f]+) reason="policy changed"$`))
+				tagRegexp := regexp.MustCompile(`tag=([0-9a-f]+)`)
+				Expect(tagRegexp.FindStringSubmatch(lines[1])[1]).To(Equal(tagRegexp.FindStringSubmatch(lines[0])[1]))
+
+				_, err = felixes[clnt].ExecOutput("cat", xdpEventLogPath)
+				Expect(err).To(HaveOccurred(), "XDP events recorded on the client, which has no untracked policy")
+			})
+
+			It("should not reprogram XDP when the policy and blocklist are updated without changes", func() {
+				blocklist := api.NewGlobalNetworkSet()
+				blocklist.Name = "xdpblocklist"
+				blocklist.Spec.Nets = []string{hostW[clnt].IP + "/32"}
+				blocklist.Labels = map[string]string{"xdpblocklist-set": "true"}
+				_, err := client.GlobalNetworkSets().Create(utils.Ctx, blocklist, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(func() ([]string, error) {
+					return felixes[srvr].XDPBlocklistCIDRs("eth0")
+				}, "10s", "500ms").Should(ConsistOf(hostW[clnt].IP + "/32"))
+
+				infrastructure.AssertXDPNotReprogrammed(felixes[srvr], "eth0", func() {
+					policy, err := client.GlobalNetworkPolicies().Get(utils.Ctx, "xdp-filter", options.GetOptions{})
+					Expect(err).NotTo(HaveOccurred())
+					_, err = client.GlobalNetworkPolicies().Update(utils.Ctx, policy, utils.NoOptions)
+					Expect(err).NotTo(HaveOccurred())
+
+					blocklist, err := client.GlobalNetworkSets().Get(utils.Ctx, "xdpblocklist", options.GetOptions{})
+					Expect(err).NotTo(HaveOccurred())
+					_, err = client.GlobalNetworkSets().Update(utils.Ctx, blocklist, utils.NoOptions)
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+		}
+
+		It("should attach the XDP program to eth0 in native mode", func() {
+			Expect(xdpMode(felixes[srvr], "eth0")).To(Equal("xdp"))
+			felixes[srvr].ExpectNoLogMatch(`iface="?eth0"?.*falling back to xdpgeneric mode`, 2*time.Second)
+		})
+
+		if !BPFMode() {
+			It("should attach the XDP program without replacing any other XDP program", func() {
+				Eventually(func() (uint32, error) {
+					return bpf.XDPAttachFlags(felixes[srvr], "eth0")
+				}, "10s", "500ms").Should(Equal(bpf.XDPDriver.AttachFlags()))
+			})
+		}
+
+		Context("with a host endpoint on an interface that doesn't support native XDP", func() {
+			BeforeEach(func() {
+				// The dummy driver doesn't implement native XDP.
+				felixes[srvr].Exec("ip", "link", "add", "xdpfb0", "type", "dummy")
+				felixes[srvr].Exec("ip", "link", "set", "xdpfb0", "up")
+
+				hostEp := api.NewHostEndpoint()
+				hostEp.Name = "host-endpoint-xdpfb0"
+				hostEp.Labels = map[string]string{
+					"host-endpoint": "true",
+					"proto":         proto,
+					"role":          "server",
+				}
+				hostEp.Spec.Node = felixes[srvr].Hostname
+				hostEp.Spec.InterfaceName = "xdpfb0"
+				_, err := client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(func() bool {
+					return xdpProgramAttached(felixes[srvr], "xdpfb0")
+				}, "10s", "1s").Should(BeTrue())
+			})
+
+			AfterEach(func() {
+				_, _ = client.HostEndpoints().Delete(utils.Ctx, "host-endpoint-xdpfb0", options.DeleteOptions{})
+				felixes[srvr].Exec("ip", "link", "del", "xdpfb0")
+			})
+
+			It("should fall back to generic mode and log the fallback", func() {
+				Expect(xdpMode(felixes[srvr], "xdpfb0")).To(Equal("xdpgeneric"))
+				Expect(xdpMode(felixes[srvr], "eth0")).To(Equal("xdp"))
+				felixes[srvr].ExpectLogMatch(`falling back to xdpgeneric mode`, 10*time.Second)
+			})
+
+			if !BPFMode() {
+				It("should report the attach mode of each interface in the metrics", func() {
+					modeMetric := func(iface, mode string) func() (int, error) {
+						return func() (int, error) {
+							return metrics.GetFelixMetricInt(felixes[srvr].IP,
+								fmt.Sprintf(`felix_xdp_program_mode{iface="%s",mode="%s"}`, iface, mode))
+						}
+					}
+					Eventually(modeMetric("eth0", "xdpdrv"), "10s", "1s").Should(Equal(1))
+					Eventually(modeMetric("xdpfb0", "xdpgeneric"), "10s", "1s").Should(Equal(1))
+				})
+			}
+		})
+
+		Context("with a host endpoint on an SR-IOV virtual function", func() {
+			var pf, vf string
+
+			BeforeEach(func() {
+				pf = infrastructure.SRIOVPhysicalFunction()
+				if pf == "" {
+					Skip("Set FV_SRIOV_PF to a host interface that supports SR-IOV to run this test")
+				}
+				var err error
+				vf, err = felixes[srvr].AddSRIOVVirtualFunction(pf)
+				Expect(err).NotTo(HaveOccurred())
+
+				hostEp := api.NewHostEndpoint()
+				hostEp.Name = "host-endpoint-sriov-vf"
+				hostEp.Labels = map[string]string{
+					"host-endpoint": "true",
+					"proto":         proto,
+					"role":          "server",
+				}
+				hostEp.Spec.Node = felixes[srvr].Hostname
+				hostEp.Spec.InterfaceName = vf
+				_, err = client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				if pf == "" {
+					return
+				}
+				_, _ = client.HostEndpoints().Delete(utils.Ctx, "host-endpoint-sriov-vf", options.DeleteOptions{})
+				Expect(infrastructure.RemoveSRIOVVirtualFunctions(pf)).To(Succeed())
+			})
+
+			It("should attach in native mode if the VF's driver supports it and in generic mode otherwise", func() {
+				Eventually(func() bool {
+					return xdpProgramAttached(felixes[srvr], vf)
+				}, "10s", "1s").Should(BeTrue())
+
+				expectedMode := "xdpgeneric"
+				if bpf.VFDriverSupportsNativeXDP(felixes[srvr].InterfaceDriver(vf)) {
+					expectedMode = "xdp"
+				}
+				Expect(xdpMode(felixes[srvr], vf)).To(Equal(expectedMode))
+				Expect(xdpMode(felixes[srvr], "eth0")).To(Equal("xdp"))
+				expectAllAllowed(cc)
+			})
+		})
+
+		if !BPFMode() {
+			Context("with a host endpoint on an interface that gets deleted", func() {
+				BeforeEach(func() {
+					felixes[srvr].Exec("ip", "link", "add", "xdpdel0", "type", "dummy")
+					felixes[srvr].Exec("ip", "link", "set", "xdpdel0", "up")
+
+					hostEp := api.NewHostEndpoint()
+					hostEp.Name = "host-endpoint-xdpdel0"
+					hostEp.Labels = map[string]string{
+						"host-endpoint": "true",
+						"proto":         proto,
+						"role":          "server",
+					}
+					hostEp.Spec.Node = felixes[srvr].Hostname
+					hostEp.Spec.InterfaceName = "xdpdel0"
+					_, err := client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(func() bool {
+						return xdpProgramAttached(felixes[srvr], "xdpdel0")
+					}, "10s", "1s").Should(BeTrue())
+				})
+
+				AfterEach(func() {
+					_, _ = client.HostEndpoints().Delete(utils.Ctx, "host-endpoint-xdpdel0", options.DeleteOptions{})
+					_ = felixes[srvr].ExecMayFail("ip", "link", "del", "xdpdel0")
+				})
+
+				It("should unpin the interface's program and blocklist map", func() {
+					pinnedForIface := func() ([]string, error) {
+						out, err := felixes[srvr].ExecOutput("ls", "-1", "/sys/fs/bpf/calico/xdp")
+						if err != nil {
+							return nil, err
+						}
+						var pinned []string
+						for _, name := range strings.Fields(out) {
+							if strings.Contains(name, "xdpdel0") {
+								pinned = append(pinned, name)
+							}
+						}
+						return pinned, nil
+					}
+					Expect(pinnedForIface()).To(ContainElements(
+						"prefilter_v1_xdpdel0",
+						"xdpdel0_ipv4_v1_blacklist",
+					))
+
+					Expect(felixes[srvr].DeleteInterface("xdpdel0")).To(Succeed())
+					Eventually(pinnedForIface, "10s", "1s").Should(BeEmpty())
+
+					// The other interfaces are unaffected.
+					Expect(xdpProgramAttached_server_eth0()).To(BeTrue())
+					expectBlocked(cc)
+				})
+			})
+		}
+
+		Context("with a * host endpoint", func() {
+			// The data interface pattern that BPF mode uses by default.
+			dataIfaceRegexp := regexp.MustCompile(`^((en|wl|ww|sl|ib)[Popsx].*|(eth|wlan|wwan).*|tunl0$|vxlan.calico$|wireguard.cali$|wg-v6.cali$)`)
+
+			// wildcardMatches returns the interfaces that the * host endpoint should apply to:
+			// all the host's interfaces apart from loopback, or, in BPF mode, its data
+			// interfaces.  The host endpoint for eth0 applies the same policy so we don't need
+			// to treat eth0 specially.
+			wildcardMatches := func() []string {
+				var ifaces []string
+				for _, iface := range felixes[srvr].Interfaces() {
+					if iface == "lo" || (BPFMode() && !dataIfaceRegexp.MatchString(iface)) {
+						continue
+					}
+					ifaces = append(ifaces, iface)
+				}
+				return ifaces
+			}
+
+			addDummyIface := func(name string) {
+				felixes[srvr].Exec("ip", "link", "add", name, "type", "dummy")
+				felixes[srvr].Exec("ip", "link", "set", name, "up")
+			}
+
+			BeforeEach(func() {
+				addDummyIface("eth10")
+
+				hostEp := api.NewHostEndpoint()
+				hostEp.Name = "host-endpoint-wildcard"
+				hostEp.Labels = map[string]string{
+					"host-endpoint": "true",
+					"proto":         proto,
+					"role":          "server",
+				}
+				hostEp.Spec.Node = felixes[srvr].Hostname
+				hostEp.Spec.InterfaceName = "*"
+				_, err := client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				_, _ = client.HostEndpoints().Delete(utils.Ctx, "host-endpoint-wildcard", options.DeleteOptions{})
+				for _, iface := range []string{"eth10", "eth11"} {
+					_ = felixes[srvr].ExecMayFail("ip", "link", "del", iface)
+				}
+			})
+
+			It("should attach XDP to exactly the matching interfaces, as they come and go", func() {
+				Eventually(felixes[srvr].XDPAttachedInterfaces, "10s", "1s").Should(ConsistOf(wildcardMatches()))
+				Expect(felixes[srvr].XDPAttachedInterfaces()).To(ContainElement("eth10"))
+
+				By("adding an interface")
+				addDummyIface("eth11")
+				Eventually(felixes[srvr].XDPAttachedInterfaces, "10s", "1s").Should(ContainElement("eth11"))
+				Expect(felixes[srvr].XDPAttachedInterfaces()).To(ConsistOf(wildcardMatches()))
+
+				By("removing an interface")
+				felixes[srvr].Exec("ip", "link", "del", "eth10")
+				Eventually(felixes[srvr].XDPAttachedInterfaces, "10s", "1s").Should(ConsistOf(wildcardMatches()))
+				Expect(felixes[srvr].XDPAttachedInterfaces()).NotTo(ContainElement("eth10"))
+
+				felixes[srvr].ExpectNoLogMatch(`failed to (load|attach) XDP program`, 2*time.Second)
+			})
+
+			It("should detach XDP from the other interfaces when the * host endpoint is removed", func() {
+				Eventually(felixes[srvr].XDPAttachedInterfaces, "10s", "1s").Should(ContainElement("eth10"))
+
+				_, err := client.HostEndpoints().Delete(utils.Ctx, "host-endpoint-wildcard", options.DeleteOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(felixes[srvr].XDPAttachedInterfaces, "10s", "1s").Should(ConsistOf("eth0"))
+			})
+		})
+
+		Context("with host endpoints that override the XDP mode", func() {
+			BeforeEach(func() {
+				// veth supports native XDP, so forcing generic mode on it is a real override.
+				felixes[srvr].Exec("ip", "link", "add", "xdpmode0", "type", "veth", "peer", "name", "xdpmode1")
+				felixes[srvr].Exec("ip", "link", "set", "xdpmode0", "up")
+				felixes[srvr].Exec("ip", "link", "set", "xdpmode1", "up")
+
+				hostEp := api.NewHostEndpoint()
+				hostEp.Name = "host-endpoint-xdpmode0"
+				hostEp.Labels = map[string]string{
+					"host-endpoint": "true",
+					"proto":         proto,
+					"role":          "server",
+				}
+				hostEp.Spec.Node = felixes[srvr].Hostname
+				hostEp.Spec.InterfaceName = "xdpmode0"
+				hostEp.Spec.XDPMode = api.XDPModeGeneric
+				_, err := client.HostEndpoints().Create(utils.Ctx, hostEp, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+
+				setXDPMode(fmt.Sprintf("host-endpoint-%d", srvr), api.XDPModeNative)
+
+				Eventually(func() bool {
+					return xdpProgramAttached(felixes[srvr], "xdpmode0")
+				}, "10s", "1s").Should(BeTrue())
+			})
+
+			AfterEach(func() {
+				_, _ = client.HostEndpoints().Delete(utils.Ctx, "host-endpoint-xdpmode0", options.DeleteOptions{})
+				felixes[srvr].Exec("ip", "link", "del", "xdpmode0")
+			})
+
+			It("should attach each interface in the mode its host endpoint asks for", func() {
+				Eventually(func() string {
+					return xdpMode(felixes[srvr], "xdpmode0")
+				}, "10s", "1s").Should(Equal("xdpgeneric"))
+				Expect(xdpMode(felixes[srvr], "eth0")).To(Equal("xdp"))
+			})
+
+			It("should reattach the program when the override changes", func() {
+				setXDPMode(fmt.Sprintf("host-endpoint-%d", srvr), api.XDPModeGeneric)
+				Eventually(func() string {
+					return xdpMode(felixes[srvr], "eth0")
+				}, "10s", "1s").Should(Equal("xdpgeneric"))
+				Expect(xdpMode(felixes[srvr], "xdpmode0")).To(Equal("xdpgeneric"))
+				expectAllAllowed(cc)
+			})
+
+			It("should not drop allowed traffic while it reloads the program in the new mode", func() {
+				infrastructure.AssertNoBlipDuringReload(cc, felixes[clnt], hostW[srvr], 8055, func() {
+					setXDPMode(fmt.Sprintf("host-endpoint-%d", srvr), api.XDPModeGeneric)
+					Eventually(func() string {
+						return xdpMode(felixes[srvr], "eth0")
+					}, "10s", "100ms").Should(Equal("xdpgeneric"))
+				})
+			})
+
+			if !BPFMode() {
+				It("should report the overridden mode of each interface in the metrics", func() {
+					modeMetric := func(iface, mode string) func() (int, error) {
+						return func() (int, error) {
+							return metrics.GetFelixMetricInt(felixes[srvr].IP,
+								fmt.Sprintf(`felix_xdp_program_mode{iface="%s",mode="%s"}`, iface, mode))
+						}
+					}
+					Eventually(modeMetric("eth0", "xdpdrv"), "10s", "1s").Should(Equal(1))
+					Eventually(modeMetric("xdpmode0", "xdpgeneric"), "10s", "1s").Should(Equal(1))
+				})
+			}
+		})
+
+		It("should allow many concurrent connections and track them in conntrack", func() {
+			const numConns = 20
+			ctBefore := felixes[srvr].ConntrackCount()
+
+			conns := hostW[clnt].OpenConns(hostW[srvr].IP, 8055, numConns)
+			defer conns.Stop()
+			Expect(conns.NumEstablished()).To(Equal(numConns))
+			Expect(felixes[srvr].ConntrackCount()).To(BeNumerically(">=", ctBefore+numConns))
+		})
+
+		if BPFMode() {
+			It("should have a BPF conntrack entry with the right protocol, ports and state for an allowed flow", func() {
+				pc := hostW[clnt].StartPersistentConnection(hostW[srvr].IP, 8055,
+					workload.PersistentConnectionOpts{SourcePort: 12345})
+				defer pc.Stop()
+
+				ctProto := uint8(conntrack.ProtoTCP)
+				if proto == "udp" {
+					ctProto = conntrack.ProtoUDP
+				}
+				findEntry := func() *conntrack.Entry {
+					entries, err := conntrack.Dump(felixes[srvr])
+					Expect(err).NotTo(HaveOccurred())
+					for _, e := range entries {
+						k := e.Key
+						if k.Proto() != ctProto {
+							continue
+						}
+						if (k.PortA() == 12345 && k.PortB() == 8055 && k.AddrA().Equal(net.ParseIP(felixes[clnt].IP))) ||
+							(k.PortA() == 8055 && k.PortB() == 12345 && k.AddrB().Equal(net.ParseIP(felixes[clnt].IP))) {
+							return &e
+						}
+					}
+					return nil
+				}
+				Eventually(findEntry, "5s", "200ms").ShouldNot(BeNil())
+				e := findEntry()
+				Expect(e.Value.Type()).To(Equal(conntrack.TypeNormal))
+				if proto == "tcp" {
+					Expect(e.Value.Data().Established()).To(BeTrue(), "Conntrack entry not established: %v", e)
+				}
+			})
+		}
+
+		Context("with untracked policies deleted again", func() {
+			BeforeEach(func() {
+				_, _ = client.GlobalNetworkPolicies().Delete(utils.Ctx, "xdp-filter", options.DeleteOptions{})
+			})
+
+			It("should not have XDP program attached", func() {
+				Eventually(xdpProgramAttached_server_eth0, "10s", "1s").Should(BeFalse())
+				Consistently(xdpProgramAttached_server_eth0, "2s", "1s").Should(BeFalse())
+			})
+		})
+
+		applyGlobalNetworkSets := func(name string, ip string, cidrToHexSuffix string, update bool) (hexCIDR []string) {
+			// create GlobalNetworkSet with IP of felixes[clnt]
+			var srcNS *api.GlobalNetworkSet
+			var err error
+			if update {
+				srcNS, err = client.GlobalNetworkSets().Get(utils.Ctx, name, options.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				srcNS.Spec.Nets = []string{ip}
+
+				_, err = client.GlobalNetworkSets().Update(utils.Ctx, srcNS, utils.NoOptions)
+			} else {
+				srcNS = api.NewGlobalNetworkSet()
+				srcNS.Name = name
+				srcNS.Spec.Nets = []string{ip}
+				srcNS.Labels = map[string]string{
+					"xdpblocklist-set": "true",
+				}
+				_, err = client.GlobalNetworkSets().Create(utils.Ctx, srcNS, utils.NoOptions)
+			}
+			Expect(err).NotTo(HaveOccurred())
+
+			hexCIDR, err = bpf.CidrToHex(ip + cidrToHexSuffix)
+			Expect(err).NotTo(HaveOccurred())
+			return hexCIDR
+		}
+
+		It("should stop the client's traffic when it's added to the blocklist and not let any through afterwards", func() {
+			monitor := connectivity.BackgroundMonitor(hostW[clnt], hostW[srvr], 8055,
+				connectivity.MonitorWithProtocol(proto),
+				connectivity.MonitorWithConnectTimeout(time.Second))
+			defer monitor.Stop()
+			Eventually(monitor.Connected, "10s", "100ms").Should(BeTrue())
+
+			_ = applyGlobalNetworkSets("xdpblocklist", hostW[clnt].IP, "/32", false)
+			Eventually(monitor.Connected, "10s", "100ms").Should(BeFalse())
+			// Give any traffic that leaks through afterwards a chance to show up.
+			time.Sleep(2 * time.Second)
+
+			timeline := monitor.Stop()
+			transitions := timeline.Transitions()
+			Expect(transitions).To(HaveLen(1), "Connectivity flapped: %v", timeline)
+			Expect(transitions[0].Connected).To(BeFalse())
+		})
+
+		Context("blocking server IP", func() {
+			BeforeEach(func() {
+				_ = applyGlobalNetworkSets("xdpblocklist", hostW[srvr].IP, "/32", false)
+			})
+
+			It("should allow connections from other IPs to the server", func() {
+				expectAllAllowed(cc)
+			})
+
+			It("should allow many concurrent connections from other IPs to the server", func() {
+				cc.ExpectConcurrent(felixes[clnt], hostW[srvr], 8055, 50)
+				cc.CheckConnectivity()
+			})
+
+			It("should not disrupt allowed traffic while an unrelated XDP policy is created and deleted", func() {
+				churnSet := api.NewGlobalNetworkSet()
+				churnSet.Name = "xdp-churn"
+				churnSet.Spec.Nets = []string{"10.123.0.0/24"}
+				churnSet.Labels = map[string]string{"xdp-churn-set": "true"}
+				_, err := client.GlobalNetworkSets().Create(utils.Ctx, churnSet, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+				defer func() {
+					_, _ = client.GlobalNetworkPolicies().Delete(utils.Ctx, "xdp-churn", options.DeleteOptions{})
+					_, _ = client.GlobalNetworkSets().Delete(utils.Ctx, "xdp-churn", options.DeleteOptions{})
+				}()
+
+				infrastructure.AssertNoDropsDuringChurn(cc, felixes[clnt], hostW[srvr], 8055, func() {
+					// Each iteration adds a second untracked policy to the server's interface
+					// and removes it again; it doesn't cover the client.
+					order := float64(15)
+					deadline := time.Now().Add(8 * time.Second)
+					for time.Now().Before(deadline) {
+						churnPolicy := api.NewGlobalNetworkPolicy()
+						churnPolicy.Name = "xdp-churn"
+						churnPolicy.Spec.Order = &order
+						churnPolicy.Spec.DoNotTrack = true
+						churnPolicy.Spec.ApplyOnForward = true
+						churnPolicy.Spec.Selector = "role=='server'"
+						churnPolicy.Spec.Ingress = []api.Rule{{
+							Action: api.Deny,
+							Source: api.EntityRule{Selector: "xdp-churn-set=='true'"},
+						}}
+						_, err := client.GlobalNetworkPolicies().Create(utils.Ctx, churnPolicy, utils.NoOptions)
+						Expect(err).NotTo(HaveOccurred())
+						time.Sleep(300 * time.Millisecond)
+						_, err = client.GlobalNetworkPolicies().Delete(utils.Ctx, churnPolicy.Name, options.DeleteOptions{})
+						Expect(err).NotTo(HaveOccurred())
+						time.Sleep(300 * time.Millisecond)
+					}
+				})
+			})
+
+			if proto == "udp" {
+				// The traffic generator measures loss, which only makes sense for UDP.
+				It("should not disrupt allowed traffic while unrelated blocklist entries churn", func() {
+					traffic := hostW[clnt].StartTraffic(hostW[srvr].IP, 8055, 10*time.Second)
+					defer func() {
+						_, _ = client.GlobalNetworkSets().Delete(utils.Ctx, "xdp-churn", options.DeleteOptions{})
+					}()
+
+					// Each iteration adds an entry to the blocklist map and then removes it
+					// again; none of them covers the client.
+					deadline := time.Now().Add(8 * time.Second)
+					for i := 0; time.Now().Before(deadline); i++ {
+						churnSet := api.NewGlobalNetworkSet()
+						churnSet.Name = "xdp-churn"
+						churnSet.Spec.Nets = []string{fmt.Sprintf("10.123.%d.0/24", i%256)}
+						churnSet.Labels = map[string]string{
+							"xdpblocklist-set": "true",
+						}
+						_, err := client.GlobalNetworkSets().Create(utils.Ctx, churnSet, utils.NoOptions)
+						Expect(err).NotTo(HaveOccurred())
+						time.Sleep(200 * time.Millisecond)
+						_, err = client.GlobalNetworkSets().Delete(utils.Ctx, churnSet.Name, options.DeleteOptions{})
+						Expect(err).NotTo(HaveOccurred())
+						time.Sleep(200 * time.Millisecond)
+					}
+
+					traffic.ExpectNoLoss()
+				})
+
+				It("should pass allowed datagrams through XDP without reordering them", func() {
+					cc.ExpectInOrder(felixes[clnt], hostW[srvr], 8055, 100)
+					cc.CheckConnectivity()
+				})
+
+				It("should pass allowed datagrams through XDP without corrupting or truncating them", func() {
+					Eventually(xdpProgramAttached_server_eth0, "10s").Should(BeTrue())
+					cc.ExpectEcho(felixes[clnt], hostW[srvr], 8055, strings.Repeat("xdp-echo-", 80))
+					cc.CheckConnectivity()
+				})
+
+				It("should pass allowed datagrams through XDP without clearing their ECN marks", func() {
+					Eventually(xdpProgramAttached_server_eth0, "10s").Should(BeTrue())
+					for _, ecn := range []connectivity.ECN{connectivity.ECNECT0, connectivity.ECNECT1, connectivity.ECNNotECT} {
+						cc.ExpectSomeWithECN(felixes[clnt], hostW[srvr], 8055, ecn)
+						cc.CheckConnectivity()
+						cc.ResetExpectations()
+					}
+				})
+			}
+			// NJ: this is odd; no blocklist testing here.
+		})
+
+		Context("with a secondary address on the client", func() {
+			const secondaryIP = "10.200.0.1"
+
+			BeforeEach(func() {
+				felixes[clnt].Exec("ip", "addr", "add", secondaryIP+"/32", "dev", "eth0")
+				felixes[srvr].Exec("ip", "route", "add", secondaryIP+"/32", "via", felixes[clnt].IP)
+				_ = applyGlobalNetworkSets("xdpblocklist", secondaryIP, "/32", false)
+			})
+
+			It("should drop the blocklisted secondary address and pass the primary", func() {
+				cc.ExpectNone(hostW[clnt].Port(0).WithLocalAddr(secondaryIP), hostW[srvr].Port(8055))
+				cc.ExpectSome(hostW[clnt].Port(0).WithLocalAddr(hostW[clnt].IP), hostW[srvr].Port(8055))
+				cc.CheckConnectivityOffset(1)
+			})
+
+			It("should match the blocklist on the chosen source address", func() {
+				_ = applyGlobalNetworkSets("xdpblocklist", hostW[clnt].IP, "/32", true)
+				cc.ExpectSome(hostW[clnt].Port(0).WithLocalAddr(secondaryIP), hostW[srvr].Port(8055))
+				cc.ExpectNone(hostW[clnt].Port(0).WithLocalAddr(hostW[clnt].IP), hostW[srvr].Port(8055))
+				cc.CheckConnectivityOffset(1)
+			})
+
+			if !BPFMode() {
+				It("should count the dropped packets of each blocklisted source separately", func() {
+					netSet, err := client.GlobalNetworkSets().Get(utils.Ctx, "xdpblocklist", options.GetOptions{})
+					Expect(err).NotTo(HaveOccurred())
+					netSet.Spec.Nets = []string{secondaryIP + "/32", hostW[clnt].IP + "/32"}
+					_, err = client.GlobalNetworkSets().Update(utils.Ctx, netSet, utils.NoOptions)
+					Expect(err).NotTo(HaveOccurred())
+					cc.ExpectNoneFromAll([]connectivity.ConnectionSource{
+						hostW[clnt].Port(0).WithLocalAddr(secondaryIP),
+						hostW[clnt].Port(0).WithLocalAddr(hostW[clnt].IP),
+					}, hostW[srvr].Port(8055))
+					cc.CheckConnectivityOffset(1)
+
+					dropCounts := func() (map[string]uint64, error) {
+						return bpf.PerSourceDropCounts(felixes[srvr], "eth0")
+					}
+					before, err := dropCounts()
+					Expect(err).NotTo(HaveOccurred())
+
+					send := func(srcIP string, count int) {
+						// hping3 fails when it gets no replies, which is the point.
+						_ = felixes[clnt].ExecMayFail("hping3", "--udp", "-c", strconv.Itoa(count), "-i", "u10000",
+							"-a", srcIP, "-p", "8055", hostW[srvr].IP)
+					}
+					send(secondaryIP, 7)
+					send(hostW[clnt].IP, 3)
+					Eventually(dropCounts, "5s", "200ms").Should(Equal(map[string]uint64{
+						secondaryIP:    before[secondaryIP] + 7,
+						hostW[clnt].IP: before[hostW[clnt].IP] + 3,
+					}))
+				})
+
+				It("should count exactly the packets dropped since the counters were reset", func() {
+					cc.ExpectNone(hostW[clnt].Port(0).WithLocalAddr(secondaryIP), hostW[srvr].Port(8055))
+					cc.CheckConnectivityOffset(1)
+
+					Expect(bpf.ResetXDPCounters(felixes[srvr], "eth0")).To(Succeed())
+					Expect(bpf.PerSourceDropCounts(felixes[srvr], "eth0")).To(BeEmpty())
+
+					// hping3 fails when it gets no replies, which is the point.
+					_ = felixes[clnt].ExecMayFail("hping3", "--udp", "-c", "4", "-i", "u10000",
+						"-a", secondaryIP, "-p", "8055", hostW[srvr].IP)
+					Eventually(func() (map[string]uint64, error) {
+						return bpf.PerSourceDropCounts(felixes[srvr], "eth0")
+					}, "5s", "200ms").Should(Equal(map[string]uint64{secondaryIP: 4}))
+				})
+
+				It("should keep dropping the blocklisted address with XDP when the conntrack table is full", func() {
+					// Filling the table takes a while, and the outcome doesn't depend on the
+					// protocol or on Typha, so only do it once.
+					if proto != "tcp" || withTypha {
+						Skip("Only run with TCP and without Typha")
+					}
+					// The limit is the host's, so it may be too big to fill in reasonable time.
+					const maxFill = 300000
+					max := felixes[srvr].ConntrackMax()
+					if max > maxFill {
+						Skip(fmt.Sprintf("Host's conntrack table is too big to fill (%d entries)", max))
+					}
+
+					cc.ExpectNone(hostW[clnt].Port(0).WithLocalAddr(secondaryIP), hostW[srvr].Port(8055))
+					cc.ExpectSome(hostW[clnt].Port(0).WithLocalAddr(hostW[clnt].IP), hostW[srvr].Port(8055))
+					cc.CheckConnectivityOffset(1)
+					cc.ResetExpectations()
+
+					defer felixes[srvr].ClearConntrackFill()
+					felixes[srvr].FillConntrack(max)
+					Expect(felixes[srvr].ConntrackCount()).To(BeNumerically(">=", max))
+
+					dropCounts, err := bpf.PerSourceDropCounts(felixes[srvr], "eth0")
+					Expect(err).NotTo(HaveOccurred())
+					dropsBefore := dropCounts[secondaryIP]
+
+					By("dropping new tracked connections, which have nowhere to go in the table")
+					cc.ExpectNone(hostW[clnt].Port(0).WithLocalAddr(hostW[clnt].IP), hostW[srvr].Port(8055))
+					By("still dropping the blocklisted address at XDP, which doesn't need the table")
+					cc.ExpectNone(hostW[clnt].Port(0).WithLocalAddr(secondaryIP), hostW[srvr].Port(8055))
+					cc.CheckConnectivityOffset(1)
+					cc.ResetExpectations()
+					Eventually(func() (uint64, error) {
+						dropCounts, err := bpf.PerSourceDropCounts(felixes[srvr], "eth0")
+						return dropCounts[secondaryIP], err
+					}, "5s", "200ms").Should(BeNumerically(">", dropsBefore))
+
+					By("allowing tracked connections again once the table has room")
+					felixes[srvr].ClearConntrackFill()
+					cc.ExpectSome(hostW[clnt].Port(0).WithLocalAddr(hostW[clnt].IP), hostW[srvr].Port(8055))
+					cc.CheckConnectivityOffset(1)
+				})
+			}
+		})
+
+		if !BPFMode() {
+			Context("with spoofed sources that the server routes back through eth0 and through another interface", func() {
+				// The server routes replies to rpfValidIP back through eth0, where its packets
+				// arrive, so they pass the reverse path filter; only XDP can drop them.  It
+				// routes replies to rpfInvalidIP through a dummy interface, so the filter
+				// drops its packets; XDP mustn't.
+				const rpfValidIP = "10.200.1.1"
+				const rpfInvalidIP = "10.200.2.1"
+
+				BeforeEach(func() {
+					felixes[srvr].Exec("ip", "route", "add", rpfValidIP+"/32", "via", felixes[clnt].IP)
+					felixes[srvr].Exec("ip", "link", "add", "rpf0", "type", "dummy")
+					felixes[srvr].Exec("ip", "link", "set", "rpf0", "up")
+					felixes[srvr].Exec("ip", "route", "add", rpfInvalidIP+"/32", "dev", "rpf0")
+					// The kernel uses the stricter of the "all" and per-interface settings.
+					felixes[srvr].Exec("sysctl", "-w", "net.ipv4.conf.all.rp_filter=1")
+					felixes[srvr].Exec("sysctl", "-w", "net.ipv4.conf.eth0.rp_filter=1")
+					_ = applyGlobalNetworkSets("xdpblocklist", rpfValidIP, "/32", false)
+				})
+
+				It("should tell XDP drops of the blocklisted source apart from RPF drops", func() {
+					cc.ExpectBlockedDistinctFromRPF(hostW[clnt], hostW[srvr], 8055, rpfValidIP, rpfInvalidIP)
+					cc.CheckConnectivity()
+				})
+			})
+		}
+
+		Context("with the server's IP changed after the blocklist is in place", func() {
+			var (
+				newServerIP string
+				newServerW  *workload.Workload
+				progID      int
+			)
+
+			BeforeEach(func() {
+				etcdOnly("the wait below is sized for the etcd client's keepalive; the " +
+					"Kubernetes client notices a dead connection on a different schedule")
+				_ = applyGlobalNetworkSets("xdpblocklist", hostW[clnt].IP, "/32", false)
+				expectBlocked(cc)
+				progID = xdpProgramID_server_eth0()
+
+				// Docker hands out container IPs from the bottom of its subnet so .250 in the
+				// server's block is free.
+				ip := net.ParseIP(felixes[srvr].IP).To4()
+				newServerIP = fmt.Sprintf("%d.%d.%d.%d", ip[0], ip[1], ip[2], 250)
+				Expect(felixes[srvr].ChangeInterfaceIP("eth0", newServerIP)).To(Succeed())
+
+				hostEp, err := client.HostEndpoints().Get(utils.Ctx, "host-endpoint-1", options.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				hostEp.Spec.ExpectedIPs = []string{newServerIP}
+				_, err = client.HostEndpoints().Update(utils.Ctx, hostEp, options.SetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				// The original workload listens on the old IP.
+				newServerW = workload.Run(felixes[srvr], "host1-newip", "", newServerIP, "8055,8056,1234", proto)
+			})
+
+			AfterEach(func() {
+				newServerW.Stop()
+			})
+
+			It("should keep dropping the blocklisted source at the new IP", func() {
+				// The server's Felix has to notice that its etcd connection died with the old
+				// IP, which takes up to the keepalive timeout, and reconnect before it sees the
+				// HostEndpoint update.
+				Expect(infrastructure.WaitForAllInSync(felixes, 90*time.Second)).To(Succeed())
+
+				cc.ExpectNone(hostW[clnt], newServerW.Port(8055))
+				cc.ExpectNone(hostW[clnt], newServerW.Port(8056))
+				cc.ExpectSome(hostW[clnt], newServerW.Port(1234))
+				cc.CheckConnectivityOffset(1)
+
+				Expect(xdpProgramID_server_eth0()).To(Equal(progID),
+					"XDP program was reattached when the interface's IP changed")
+			})
+		})
+
+		It("should keep the blocklist's maps, and their entries, when it reloads the program", func() {
+			_ = applyGlobalNetworkSets("xdpblocklist", hostW[clnt].IP, "/32", false)
+			expectBlocked(cc)
+
+			// Changing the XDP mode is the one host endpoint or policy change that makes Felix
+			// reload the program without also recreating its maps.
+			err := bpf.AssertProgramReusesPinnedMaps(felixes[srvr], "eth0", func() error {
+				setXDPMode(fmt.Sprintf("host-endpoint-%d", srvr), api.XDPModeGeneric)
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(xdpMode(felixes[srvr], "eth0")).To(Equal("xdpgeneric"))
+
+			if !BPFMode() {
+				Expect(felixes[srvr].XDPBlocklistCIDRs("eth0")).To(ConsistOf(hostW[clnt].IP + "/32"))
+			}
+			expectBlocked(cc)
+		})
+
+		Context("with VLAN-tagged traffic between the hosts", func() {
+			const (
+				vlanID       = 100
+				clientVLANIP = "10.201.0.1"
+				serverVLANIP = "10.201.0.2"
+			)
+
+			var (
+				vlanSrvr *workload.Workload
+				vlanDump *tcpdump.TCPDump
+			)
+
+			BeforeEach(func() {
+				if _, err := felixes[clnt].AddVLANInterface("eth0", vlanID, clientVLANIP+"/24"); err != nil {
+					Skip(fmt.Sprintf("VLANs not supported: %v", err))
+				}
+				_, err := felixes[srvr].AddVLANInterface("eth0", vlanID, serverVLANIP+"/24")
+				Expect(err).NotTo(HaveOccurred())
+				vlanSrvr = workload.Run(felixes[srvr], "vlan-server", "", serverVLANIP, "8055", proto,
+					workload.WithHostNetworking())
+
+				// The tagged frames arrive on the server's eth0, which is where XDP is attached.
+				vlanDump = felixes[srvr].AttachTCPDump("eth0")
+				vlanDump.SetLogEnabled(true)
+				vlanDump.AddMatcher("tagged", regexp.MustCompile(fmt.Sprintf("%s\\.\\d+ > %s",
+					regexp.QuoteMeta(clientVLANIP), regexp.QuoteMeta(serverVLANIP))))
+				vlanDump.Start("vlan", fmt.Sprint(vlanID))
+
+				_ = applyGlobalNetworkSets("xdpblocklist", "10.123.0.1", "/32", false)
+			})
+
+			AfterEach(func() {
+				vlanDump.Stop()
+				vlanSrvr.Stop()
+			})
+
+			It("should match the blocklist on the source address inside the VLAN tag", func() {
+				cc.ExpectSome(hostW[clnt].Port(0).WithLocalAddr(clientVLANIP), vlanSrvr.Port(8055))
+				cc.CheckConnectivity()
+				cc.ResetExpectations()
+				Expect(vlanDump.MatchCount("tagged")).To(BeNumerically(">", 0),
+					"expected the client's traffic to reach the server's eth0 tagged")
+
+				By("blocklisting the client's VLAN address")
+				_ = applyGlobalNetworkSets("xdpblocklist", clientVLANIP, "/32", true)
+				cc.ExpectNone(hostW[clnt].Port(0).WithLocalAddr(clientVLANIP), vlanSrvr.Port(8055))
+				cc.ExpectSome(hostW[clnt], hostW[srvr].Port(8055))
+				cc.CheckConnectivity()
+			})
+		})
+
+		Context("blocking full IP", func() {
+			BeforeEach(func() {
+				hostHexCIDR = applyGlobalNetworkSets("xdpblocklist", hostW[clnt].IP, "/32", false)
+			})
+
+			// ruleCount returns the number of packets that the XDP program has counted for the
+			// given rule of the policy.  Only available in BPF mode.
+			ruleCount := func(rule, action string) int {
+				count, err := metrics.GetFelixPolicyRulePackets(felixes[srvr].IP, "default.xdp-filter", rule, action)
+				Expect(err).NotTo(HaveOccurred())
+				return count
+			}
+
+			// denyRuleCount returns the number of packets that the XDP program has dropped with
+			// the policy's deny rule.  Only available in BPF mode.
+			denyRuleCount := func() int {
+				return ruleCount("ingress-0", "deny")
+			}
+
+			It("should block all of many concurrent connections", func() {
+				cc.Expect(connectivity.None, felixes[clnt], hostW[srvr],
+					connectivity.ExpectWithPorts(8055),
+					connectivity.ExpectWithConcurrentConns(50),
+				)
+				cc.CheckConnectivity()
+			})
+
+			It("should give the connectivity in the golden file", func() {
+				// The outcomes come from the golden file, not from ExpectNone.
+				for _, port := range []uint16{8055, 8056, 1234} {
+					cc.From("client").To("server").ExpectNone(port)
+				}
+				for _, port := range []uint16{8055, 8056} {
+					cc.From("server").To("client").ExpectNone(port)
+				}
+				cc.CompareToGolden("testdata/xdp-blocking-full-ip.golden")
+			})
+
+			It("should give the same connectivity on every run of the matrix", func() {
+				cc.From("client").To("server").ExpectNone(8055)
+				cc.From("client").To("server").ExpectNone(8056)
+				cc.From("client").To("server").ExpectSome(1234)
+				cc.From("server").To("client").ExpectNone(8055)
+				cc.From("server").To("client").ExpectNone(8056)
+				// Settle first, so that the runs measure flakiness rather than the blocklist
+				// being programmed.
+				cc.CheckConnectivity()
+
+				report := connectivity.NewFlakeReport(cc)
+				report.RunN(10)
+				Expect(report.Unstable()).To(BeEmpty(), "Unstable cells:\n%s", report)
+				for _, cell := range report.Cells() {
+					Expect(cell.Matched).To(Equal(cell.Runs), "Connectivity changed after settling:\n%s", report)
+				}
+			})
+
+			if !BPFMode() {
+				It("should record the reason for the blocklist entry and update it when it changes", func() {
+					setReason := func(reason string) {
+						ns, err := client.GlobalNetworkSets().Get(utils.Ctx, "xdpblocklist", options.GetOptions{})
+						Expect(err).NotTo(HaveOccurred())
+						ns.Spec.NetReasons = map[string]string{hostW[clnt].IP + "/32": reason}
+						_, err = client.GlobalNetworkSets().Update(utils.Ctx, ns, utils.NoOptions)
+						Expect(err).NotTo(HaveOccurred())
+					}
+					reason := func() (string, error) {
+						return bpf.BlocklistEntryReason(felixes[srvr], hostW[clnt].IP)
+					}
+
+					setReason("threat-feed-x")
+					Eventually(reason, "10s", "200ms").Should(Equal("threat-feed-x"))
+					setReason("threat-feed-y")
+					Eventually(reason, "10s", "200ms").Should(Equal("threat-feed-y"))
+				})
+			}
+
+			Context("with XDP turned off and on again in the FelixConfiguration", func() {
+				const blocklistMapPath = "/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist"
+
+				// setXDPEnabled sets XDPEnabled in the default FelixConfiguration, which Felix
+				// should apply without restarting.
+				setXDPEnabled := func(enabled bool) {
+					cfg, err := client.FelixConfigurations().Get(utils.Ctx, "default", options.GetOptions{})
+					if _, ok := err.(errors.ErrorResourceDoesNotExist); ok {
+						cfg = api.NewFelixConfiguration()
+						cfg.Name = "default"
+						cfg.Spec.XDPEnabled = &enabled
+						_, err = client.FelixConfigurations().Create(utils.Ctx, cfg, utils.NoOptions)
+					} else {
+						Expect(err).NotTo(HaveOccurred())
+						cfg.Spec.XDPEnabled = &enabled
+						_, err = client.FelixConfigurations().Update(utils.Ctx, cfg, utils.NoOptions)
+					}
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				BeforeEach(func() {
+					if BPFMode() {
+						Skip("BPF mode always enforces untracked policy with XDP")
+					}
+					Eventually(felixes[srvr].XDPAttachedInterfaces, "10s", "1s").Should(ConsistOf("eth0"))
+				})
+
+				It("should detach and reattach the XDP program without restarting Felix", func() {
+					pid := felixes[srvr].GetFelixPID()
+
+					setXDPEnabled(false)
+					Eventually(felixes[srvr].XDPAttachedInterfaces, "10s", "1s").Should(BeEmpty())
+					// With XDP off, iptables still enforces the untracked policy.
+					expectBlocked(cc)
+
+					setXDPEnabled(true)
+					Eventually(felixes[srvr].XDPAttachedInterfaces, resyncPeriod, "1s").Should(ConsistOf("eth0"))
+					args := append([]string{"bpftool", "map", "lookup", "pinned", blocklistMapPath, "key", "hex"}, hostHexCIDR...)
+					Eventually(felixes[srvr].ExecOutputFn(args...), resyncPeriod, "1s").Should(ContainSubstring("value:"))
+					expectBlocked(cc)
+
+					Expect(felixes[srvr].GetFelixPID()).To(Equal(pid), "Felix restarted to apply XDPEnabled")
+				})
+			})
+
+			if !BPFMode() {
+				Context("with XDPMinInterfaceSpeed set around eth0's link speed", func() {
+					var eth0Speed int
+
+					// setMinInterfaceSpeed sets XDPMinInterfaceSpeed in the default
+					// FelixConfiguration; Felix restarts to pick it up.
+					setMinInterfaceSpeed := func(speed int) {
+						fc, err := client.FelixConfigurations().Get(utils.Ctx, "default", options.GetOptions{})
+						if _, ok := err.(errors.ErrorResourceDoesNotExist); ok {
+							fc = api.NewFelixConfiguration()
+							fc.Name = "default"
+							fc.Spec.XDPMinInterfaceSpeed = &speed
+							_, err = client.FelixConfigurations().Create(utils.Ctx, fc, utils.NoOptions)
+						} else {
+							Expect(err).NotTo(HaveOccurred())
+							fc.Spec.XDPMinInterfaceSpeed = &speed
+							_, err = client.FelixConfigurations().Update(utils.Ctx, fc, utils.NoOptions)
+						}
+						Expect(err).NotTo(HaveOccurred())
+					}
+
+					BeforeEach(func() {
+						out, err := felixes[srvr].ExecOutput("cat", "/sys/class/net/eth0/speed")
+						Expect(err).NotTo(HaveOccurred())
+						eth0Speed, err = strconv.Atoi(strings.TrimSpace(out))
+						Expect(err).NotTo(HaveOccurred())
+						Expect(eth0Speed).To(BeNumerically(">", 0), "eth0 doesn't report a link speed")
+						Eventually(felixes[srvr].XDPAttachedInterfaces, "10s", "1s").Should(ConsistOf("eth0"))
+					})
+
+					AfterEach(func() {
+						_, _ = client.FelixConfigurations().Delete(utils.Ctx, "default", options.DeleteOptions{})
+					})
+
+					It("should skip XDP on eth0 while it's too slow and still enforce the policy in iptables", func() {
+						setMinInterfaceSpeed(eth0Speed + 1)
+						Eventually(felixes[srvr].XDPAttachedInterfaces, "20s", "1s").Should(BeEmpty())
+						felixes[srvr].ExpectLogMatch(`below XDPMinInterfaceSpeed.*iface="?eth0"?`, 10*time.Second)
+						Consistently(felixes[srvr].XDPAttachedInterfaces, "5s", "1s").Should(BeEmpty())
+						expectBlocked(cc)
+
+						setMinInterfaceSpeed(eth0Speed)
+						Eventually(felixes[srvr].XDPAttachedInterfaces, "20s", "1s").Should(ConsistOf("eth0"))
+						expectBlocked(cc)
+					})
+				})
+			}
+
+			if !BPFMode() {
+				// A host endpoint that's matched by its IP, rather than its interface name,
+				// follows the interface when it's renamed.
+				Context("with the server's host endpoint matched by IP and its interface renamed", func() {
+					const newName = "xdpren0"
+
+					pinnedFor := func(iface string) func() ([]string, error) {
+						return func() ([]string, error) {
+							pins, err := bpf.XDPInterfacePins(felixes[srvr])
+							if err != nil {
+								return nil, err
+							}
+							var pinned []string
+							for _, p := range pins {
+								if strings.Contains(path.Base(p), iface) {
+									pinned = append(pinned, path.Base(p))
+								}
+							}
+							return pinned, nil
+						}
+					}
+
+					BeforeEach(func() {
+						hostEp, err := client.HostEndpoints().Get(utils.Ctx, fmt.Sprintf("host-endpoint-%d", srvr), options.GetOptions{})
+						Expect(err).NotTo(HaveOccurred())
+						hostEp.Spec.InterfaceName = ""
+						_, err = client.HostEndpoints().Update(utils.Ctx, hostEp, utils.NoOptions)
+						Expect(err).NotTo(HaveOccurred())
+						expectBlocked(cc)
+
+						Expect(felixes[srvr].RenameInterface("eth0", newName)).To(Succeed())
+					})
+
+					It("should move the XDP program and its pins to the new name and keep blocking", func() {
+						Eventually(func() bool {
+							return xdpProgramAttached(felixes[srvr], newName)
+						}, "10s", "1s").Should(BeTrue())
+						Eventually(pinnedFor(newName), "10s", "1s").Should(ContainElements(
+							"prefilter_v1_"+newName,
+							newName+"_ipv4_v1_blacklist",
+						))
+						Expect(pinnedFor("eth0")()).To(BeEmpty(), "Pins under the old name were left behind")
+
+						// The program that's attached is the one pinned under the new name,
+						// so it reads the blocklist that Felix now keeps up to date.
+						Expect(felixes[srvr].XDPBlocklistCIDRs(newName)).To(ConsistOf(hostW[clnt].IP + "/32"))
+						xdp, err := felixes[srvr].XDPProgramming(newName)
+						Expect(err).NotTo(HaveOccurred())
+						out, err := felixes[srvr].ExecOutput("bpftool", "--json", "prog", "show", "id", strconv.Itoa(xdp.ProgramID))
+						Expect(err).NotTo(HaveOccurred())
+						var prog bpf.ProgInfo
+						Expect(json.Unmarshal([]byte(out), &prog)).To(Succeed())
+						Expect(prog.MapIds).To(ContainElement(xdp.BlocklistMapID),
+							"The attached program doesn't use the blocklist map pinned under the new name")
+						expectBlocked(cc)
+						felixes[srvr].ExpectNoLogMatch("Applying BPF actions did not succeed", 2*time.Second)
+					})
+				})
+			}
+
+			Context("with GRO turned on or off on the server's interface", func() {
+				// In generic mode, XDP runs after GRO may have merged the packets that it
+				// sees; in native mode it runs before.  Either way the blocklist should apply.
+				for _, m := range []struct {
+					mode       api.XDPMode
+					ipLinkMode string
+				}{
+					{api.XDPModeNative, "xdp"},
+					{api.XDPModeGeneric, "xdpgeneric"},
+				} {
+					mode, ipLinkMode := m.mode, m.ipLinkMode
+					for _, gro := range []bool{false, true} {
+						gro := gro
+						groState := "off"
+						if gro {
+							groState = "on"
+						}
+						It(fmt.Sprintf("should drop blocklisted traffic in %s mode with GRO %s", mode, groState), func() {
+							if err := felixes[srvr].SetOffload("eth0", "gro", gro); err != nil {
+								Skip(fmt.Sprintf("Can't turn GRO %s on eth0: %v", groState, err))
+							}
+							setXDPMode(fmt.Sprintf("host-endpoint-%d", srvr), mode)
+							Eventually(func() string {
+								return xdpMode(felixes[srvr], "eth0")
+							}, "10s", "1s").Should(Equal(ipLinkMode))
+
+							expectFailsafePortsOpen(cc)
+						})
+					}
+				}
+			})
+
+			Context("with a route on the server that would forward the client's packets", func() {
+				// An address that the server routes to a dummy interface, so that packets to it
+				// are forwarded rather than delivered locally.
+				const forwardedIP = "10.66.0.1"
+				const numProbes = 10
+				// The dummy interface's MTU is smaller than eth0's so that the server is the
+				// hop that has to send the client an ICMP fragmentation needed.
+				const fwdMTU = 1200
+
+				var fwdDump *tcpdump.TCPDump
+
+				BeforeEach(func() {
+					felixes[srvr].Exec("sysctl", "-w", "net.ipv4.ip_forward=1")
+					felixes[srvr].Exec("ip", "link", "add", "fwd0", "type", "dummy")
+					felixes[srvr].Exec("ip", "link", "set", "fwd0", "mtu", fmt.Sprint(fwdMTU), "up")
+					felixes[srvr].Exec("ip", "route", "add", forwardedIP+"/32", "dev", "fwd0")
+					felixes[clnt].Exec("ip", "route", "add", forwardedIP+"/32", "via", felixes[srvr].IP)
+
+					// Check that the server would really forward the client's packets.
+					out, err := felixes[srvr].ExecOutput("ip", "route", "get", forwardedIP, "from", hostW[clnt].IP, "iif", "eth0")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(out).To(ContainSubstring("dev fwd0"))
+
+					fwdDump = felixes[srvr].AttachTCPDump("fwd0")
+					fwdDump.SetLogEnabled(true)
+					fwdDump.AddMatcher("forwarded", regexp.MustCompile(fmt.Sprintf("> %s", regexp.QuoteMeta(forwardedIP))))
+					fwdDump.Start("dst", "host", forwardedIP)
+				})
+
+				AfterEach(func() {
+					fwdDump.Stop()
+				})
+
+				sendProbes := func(n int) {
+					for i := 0; i < n; i++ {
+						_, err := hostW[clnt].RunCmd("pktgen", hostW[clnt].IP, forwardedIP, "udp", "--port-dst", "8055")
+						Expect(err).NotTo(HaveOccurred())
+					}
+				}
+
+				It("should drop blocklisted packets at XDP before the routing lookup", func() {
+					expectBlocked(cc)
+
+					var denyBefore int
+					if BPFMode() {
+						denyBefore = denyRuleCount()
+					}
+					forwardedBefore := felixes[srvr].SNMPCounters("Ip")["ForwDatagrams"]
+					sendProbes(numProbes)
+
+					if BPFMode() {
+						By("counting the probes as dropped by XDP")
+						Eventually(denyRuleCount, "5s", "200ms").Should(Equal(denyBefore + numProbes))
+					}
+					By("never seeing the probes on the forwarding path")
+					Consistently(fwdDump.MatchCountFn("forwarded"), "2s", "200ms").Should(BeZero())
+					Expect(felixes[srvr].SNMPCounters("Ip")["ForwDatagrams"]).To(
+						BeNumerically("<", forwardedBefore+numProbes))
+
+					By("forwarding the same probes once the policy is removed")
+					_, err := client.GlobalNetworkPolicies().Delete(utils.Ctx, "xdp-filter", options.DeleteOptions{})
+					Expect(err).NotTo(HaveOccurred())
+					Eventually(func() int {
+						sendProbes(1)
+						return fwdDump.MatchCount("forwarded")
+					}, "10s", "500ms").Should(BeNumerically(">", 0))
+					Expect(felixes[srvr].SNMPCounters("Ip")["ForwDatagrams"]).To(BeNumerically(">", forwardedBefore))
+				})
+
+				It("should only send ICMP fragmentation needed for too big packets that aren't blocklisted", func() {
+					const dfSendLen = fwdMTU + 100
+					pmtud := &connectivity.Checker{Protocol: "udp"}
+
+					var (
+						dropsBefore    uint64
+						verdictsBefore counters.XDPVerdicts
+					)
+					if BPFMode() {
+						verdictsBefore = felixes[srvr].XDPVerdictCounts("eth0")
+					} else {
+						drops, err := bpf.PerSourceDropCounts(felixes[srvr], "eth0")
+						Expect(err).NotTo(HaveOccurred())
+						dropsBefore = drops[hostW[clnt].IP]
+					}
+
+					By("getting nothing back while the client is blocklisted")
+					pmtud.ExpectXDPDropNotMTU(hostW[clnt], connectivity.TargetIP(forwardedIP), 8055, dfSendLen)
+					pmtud.CheckConnectivity()
+					pmtud.ResetExpectations()
+
+					By("counting the too big packets as dropped by XDP")
+					if BPFMode() {
+						Expect(felixes[srvr].XDPVerdictCounts("eth0").DroppedByPolicy).To(
+							BeNumerically(">", verdictsBefore.DroppedByPolicy))
+					} else {
+						Eventually(func() (uint64, error) {
+							drops, err := bpf.PerSourceDropCounts(felixes[srvr], "eth0")
+							return drops[hostW[clnt].IP], err
+						}, "5s", "200ms").Should(BeNumerically(">", dropsBefore))
+					}
+
+					By("getting the server's ICMP once the client is no longer blocklisted")
+					_ = applyGlobalNetworkSets("xdpblocklist", hostW[srvr].IP, "/32", true)
+					pmtud.ExpectFragNeeded(hostW[clnt], connectivity.TargetIP(forwardedIP), 8055, dfSendLen, fwdMTU)
+					pmtud.CheckConnectivity()
+				})
+			})
+
+			if proto == "udp" {
+				It("should drop every fragment of a blocklisted datagram and reassemble it once unblocked", func() {
+					// Several times the MTU, so that most fragments have no UDP header for XDP
+					// to look at.
+					const datagramLen = 4000
+					frag := &connectivity.Checker{Protocol: "udp"}
+					fragFilter := fmt.Sprintf("src host %s and ip[6:2] & 0x3fff != 0", hostW[clnt].IP)
+					laterFragFilter := fmt.Sprintf("src host %s and ip[6:2] & 0x1fff != 0", hostW[clnt].IP)
+
+					var dropsBefore uint64
+					if !BPFMode() {
+						drops, err := bpf.PerSourceDropCounts(felixes[srvr], "eth0")
+						Expect(err).NotTo(HaveOccurred())
+						dropsBefore = drops[hostW[clnt].IP]
+					}
+
+					By("getting nothing to the server while the client is blocklisted")
+					sent := felixes[clnt].StartCapture("eth0", fragFilter)
+					sentLater := felixes[clnt].StartCapture("eth0", laterFragFilter)
+					received := felixes[srvr].StartCapture("eth0", fragFilter)
+					frag.Expect(connectivity.None, hostW[clnt], hostW[srvr],
+						connectivity.ExpectWithPorts(8055),
+						connectivity.ExpectWithDatagramLen(datagramLen),
+					)
+					frag.CheckConnectivity()
+					frag.ResetExpectations()
+					time.Sleep(time.Second)
+					sentPkts := sent.Stop()
+					Expect(sentLater.Stop()).NotTo(BeEmpty(),
+						"client didn't send any fragments without a UDP header; see %s", sentLater.PcapFile())
+					Expect(received.Stop()).To(BeEmpty(),
+						"some fragments got past XDP; see %s", received.PcapFile())
+
+					if !BPFMode() {
+						By("counting every fragment as dropped by XDP")
+						Eventually(func() (uint64, error) {
+							drops, err := bpf.PerSourceDropCounts(felixes[srvr], "eth0")
+							return drops[hostW[clnt].IP] - dropsBefore, err
+						}, "5s", "200ms").Should(BeNumerically("==", len(sentPkts)))
+					}
+
+					By("reassembling the datagram on the server once the client is no longer blocklisted")
+					_ = applyGlobalNetworkSets("xdpblocklist", hostW[srvr].IP, "/32", true)
+					frag.Expect(connectivity.Some, hostW[clnt], hostW[srvr],
+						connectivity.ExpectWithPorts(8055),
+						connectivity.ExpectWithDatagramLen(datagramLen),
+					)
+					frag.CheckConnectivity()
+					frag.ResetExpectations()
+
+					By("failing to send the datagram at all with the don't fragment bit set")
+					frag.DontFragment = true
+					frag.Expect(connectivity.None, hostW[clnt], hostW[srvr],
+						connectivity.ExpectWithPorts(8055),
+						connectivity.ExpectWithDatagramLen(datagramLen),
+					)
+					frag.CheckConnectivity()
+				})
+			}
+
+			It("should drop blocklisted packets that reach the server's NIC before its packet taps", func() {
+				const numProbes = 10
+				expectBlocked(cc)
+
+				filter := fmt.Sprintf("udp and src host %s and dst port 8055", hostW[clnt].IP)
+				sent := felixes[clnt].StartCapture("eth0", filter)
+				received := felixes[srvr].StartCapture("eth0", filter)
+				for i := 0; i < numProbes; i++ {
+					_, err := hostW[clnt].RunCmd("pktgen", hostW[clnt].IP, hostW[srvr].IP, "udp", "--port-dst", "8055")
+					Expect(err).NotTo(HaveOccurred())
+				}
+				time.Sleep(time.Second)
+				sentPkts := sent.Stop()
+				receivedPkts := received.Stop()
+
+				By("seeing the probes leave the client")
+				Expect(sentPkts).To(HaveLen(numProbes), "probes missing from %s", sent.PcapFile())
+				for _, p := range sentPkts {
+					Expect(p.DstIP()).To(Equal(hostW[srvr].IP))
+				}
+				By("never seeing them on the server, since XDP runs before the packet taps")
+				Expect(receivedPkts).To(BeEmpty(), "probes reached the server's stack; see %s", received.PcapFile())
+			})
+
+			It("should drop all of a blocked UDP flow and pass an allowed one at full rate", func() {
+				if proto != "udp" {
+					Skip("Only run with UDP")
+				}
+				const duration = 3 * time.Second
+				expectBlocked(cc)
+
+				By("losing every packet of the blocklisted client's flow")
+				pps, lossPct := cc.MeasureUDPThroughput(hostW[clnt], hostW[srvr], 8055, duration)
+				Expect(pps).To(BeZero())
+				Expect(lossPct).To(BeNumerically("==", 100))
+
+				By("sustaining the send rate once the client is no longer blocklisted")
+				_ = applyGlobalNetworkSets("xdpblocklist", hostW[srvr].IP, "/32", true)
+				expectAllAllowed(cc)
+				pps, lossPct = cc.MeasureUDPThroughput(hostW[clnt], hostW[srvr], 8055, duration)
+				Expect(pps).To(BeNumerically(">=", minAllowedUDPRate))
+				Expect(lossPct).To(BeNumerically("<=", maxAllowedUDPLossPct))
+			})
+
+			It("should match the blocklist on the source IP whatever the source MAC", func() {
+				// Locally administered addresses that don't belong to any of the hosts.  The
+				// blocklist is keyed on IP so a program that looked at the L2 header, which
+				// generic XDP sees after some of the stack's L2 processing, would be wrong.
+				spoofedMACs := []string{"02:00:00:0f:ac:e1", "02:00:00:0f:ac:e2"}
+				srvrMAC, err := felixes[srvr].MACAddress("eth0")
+				Expect(err).NotTo(HaveOccurred())
+
+				filter := fmt.Sprintf("udp and src host %s and dst port 8055", hostW[clnt].IP)
+				sendSpoofedProbes := func() (sentPkts, receivedPkts []infrastructure.Packet) {
+					sent := felixes[clnt].StartCapture("eth0", filter)
+					received := felixes[srvr].StartCapture("eth0", filter)
+					for _, mac := range spoofedMACs {
+						_, err := hostW[clnt].RunCmd("pktgen", hostW[clnt].IP, hostW[srvr].IP, "udp",
+							"--port-dst", "8055", "--iface", "eth0", "--mac-src", mac, "--mac-dst", srvrMAC)
+						Expect(err).NotTo(HaveOccurred())
+					}
+					time.Sleep(time.Second)
+					sentPkts = sent.Stop()
+					Expect(sentPkts).To(HaveLen(len(spoofedMACs)), "probes missing from %s", sent.PcapFile())
+					var sentMACs []string
+					for _, p := range sentPkts {
+						sentMACs = append(sentMACs, p.SrcMAC())
+					}
+					Expect(sentMACs).To(ConsistOf(spoofedMACs), "probes left the client with the wrong MACs")
+					return sentPkts, received.Stop()
+				}
+
+				By("dropping the blocklisted client's frames whatever their source MAC")
+				expectBlocked(cc)
+				_, receivedPkts := sendSpoofedProbes()
+				Expect(receivedPkts).To(BeEmpty(), "spoofed-MAC probes reached the server's stack")
+
+				By("passing the same frames once the client's IP is no longer blocklisted")
+				_ = applyGlobalNetworkSets("xdpblocklist", hostW[srvr].IP, "/32", true)
+				expectAllAllowed(cc)
+				_, receivedPkts = sendSpoofedProbes()
+				Expect(receivedPkts).To(HaveLen(len(spoofedMACs)), "spoofed-MAC probes dropped by the server")
+			})
+
+			It("should block packets smaller than UDP", func() {
+				doHping := func() error {
+					return utils.RunMayFail("docker", "exec", felixes[clnt].Name, "hping3", "--rawip", "-c", "1", "-H", "254", "-d", "1", hostW[srvr].IP)
+				}
+				Eventually(doHping, "20s", "100ms").Should(HaveOccurred())
+				Expect(utils.LastRunOutput).To(ContainSubstring(`100% packet loss`))
+				Expect(doHping()).To(HaveOccurred())
+
+				if !BPFMode() {
+					output, err := felixes[srvr].ExecOutput("iptables", "-t", "raw", "-v", "-n", "-L", "cali-pi-default.xdp-filter")
+					// the only rule that refers to a cali40-prefixed ipset should
+					// have 0 packets/bytes because the raw small packets should've been
+					// blocked by XDP
+					Expect(err).NotTo(HaveOccurred())
+					Expect(output).To(MatchRegexp(`(?m)^\s+0\s+0.*cali40s:`))
+				}
+			})
+
+			It("should handle runt frames safely, judging those with full headers by their source", func() {
+				if proto != "udp" {
+					Skip("Only run with UDP; the frames are built by hand")
+				}
+				const (
+					allowedIP = "10.200.0.9"
+					// A MAC that nothing else uses, so that the capture only sees our frames.
+					runtMAC = "02:00:00:0f:ac:e9"
+					// Ethernet, IPv4 and UDP headers with no payload: enough for the program to
+					// find the source and destination, but short of the 60-byte minimum frame.
+					headersOnly = "42"
+					// Cut off part way through the IP header, before the source address.
+					midIPHeader = "26"
+				)
+				progID := xdpProgramID_server_eth0()
+				srvrMAC, err := felixes[srvr].MACAddress("eth0")
+				Expect(err).NotTo(HaveOccurred())
+				expectBlocked(cc)
+
+				sendRunts := func(srcIP string, pktgenArgs ...string) []infrastructure.Packet {
+					capture := felixes[srvr].StartCapture("eth0", "ether src "+runtMAC)
+					args := append([]string{srcIP, hostW[srvr].IP, "udp", "--port-dst", "8055", "--count", "5",
+						"--iface", "eth0", "--mac-src", runtMAC, "--mac-dst", srvrMAC}, pktgenArgs...)
+					_, err := hostW[clnt].RunCmd("pktgen", args...)
+					Expect(err).NotTo(HaveOccurred())
+					time.Sleep(time.Second)
+					return capture.Stop()
+				}
+				var (
+					dropsBefore    uint64
+					verdictsBefore counters.XDPVerdicts
+				)
+				if BPFMode() {
+					verdictsBefore = felixes[srvr].XDPVerdictCounts("eth0")
+				} else {
+					drops, err := bpf.PerSourceDropCounts(felixes[srvr], "eth0")
+					Expect(err).NotTo(HaveOccurred())
+					dropsBefore = drops[hostW[clnt].IP]
+				}
+
+				By("dropping runts from the blocklisted source")
+				Expect(sendRunts(hostW[clnt].IP, "--truncate", headersOnly)).To(BeEmpty())
+				By("passing runts from other sources")
+				Expect(sendRunts(allowedIP, "--truncate", headersOnly)).To(HaveLen(5))
+				By("dropping frames too short to hold the headers whatever their source")
+				for _, src := range []string{hostW[clnt].IP, allowedIP} {
+					Expect(sendRunts(src, "--bare-ip")).To(BeEmpty())
+					Expect(sendRunts(src, "--bare-ip", "--truncate", midIPHeader)).To(BeEmpty())
+				}
+
+				By("counting each drop for the right reason")
+				if BPFMode() {
+					verdicts := felixes[srvr].XDPVerdictCounts("eth0")
+					Expect(verdicts.DroppedByPolicy - verdictsBefore.DroppedByPolicy).To(BeNumerically("==", 5))
+					Expect(verdicts.DroppedShort - verdictsBefore.DroppedShort).To(BeNumerically("==", 20))
+				} else {
+					Eventually(func() (uint64, error) {
+						drops, err := bpf.PerSourceDropCounts(felixes[srvr], "eth0")
+						return drops[hostW[clnt].IP] - dropsBefore, err
+					}, "5s", "200ms").Should(BeNumerically("==", 5))
+				}
+
+				By("still running the same program and enforcing the blocklist")
+				Expect(xdpProgramID_server_eth0()).To(Equal(progID))
+				expectBlocked(cc)
+			})
+
+			if BPFMode() {
+				// The following test case only works for the old iptables-mode XDP
+				// implementation of untracked ingress deny policy.  The BPF mode
+				// and iptables chain implementations of untracked ingress policy
+				// both match against both inbound and outbound failsafes - which we
+				// now believe is the most correct behaviour - and it was an
+				// oversight that outbound failsafes were not added to the old XDP
+				// program.  It isn't worth fixing the old XDP program now, as it's
+				// likely it will be replaced with the new XDP as used in BPF mode.
+			} else {
+				It("should block connections even if the source port is a failsafe port", func() {
+					expectSourceFailsafePortBlocked(cc)
+				})
+			}
+
+			It("should block ICMP too", func() {
+				doPing := func() error {
+					return utils.RunMayFail("docker", "exec", felixes[clnt].Name, "ping", "-c", "1", "-w", "1", hostW[srvr].IP)
+				}
 				Eventually(doPing, "20s", "100ms").Should(HaveOccurred())
 				Expect(utils.LastRunOutput).To(ContainSubstring(`100% packet loss`))
 				Expect(doPing()).To(HaveOccurred())
@@ -378,39 +2403,414 @@ func xdpTest(getInfra infrastructure.InfraFactory, proto string) {
 					args := append([]string{"bpftool", "map", "lookup", "pinned", "/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist", "key", "hex"}, hostHexCIDR...)
 					Eventually(felixes[srvr].ExecOutputFn(args...), "10s").Should(ContainSubstring("value:"))
 				})
+
+				It("should render the XDP policy as a raw chain that matches the blocklist set", func() {
+					var tree *infrastructure.ChainNode
+					Eventually(func() error {
+						var err error
+						tree, err = felixes[srvr].PolicyChainTree("default.xdp-filter")
+						return err
+					}, "10s", "200ms").ShouldNot(HaveOccurred())
+
+					chain := tree.Find("raw", "cali-pi-default.xdp-filter")
+					Expect(chain).NotTo(BeNil())
+					Expect(chain.IPSets).To(HaveLen(1))
+					Expect(chain.IPSets[0]).To(HavePrefix("cali40s:"))
+					Expect(chain.Targets).To(ContainElement("DROP"))
+				})
+
+				It("should jump to the XDP policy chain after the failsafe chain for eth0", func() {
+					const policyChain = "cali-pi-default.xdp-filter"
+					var targets []string
+					Eventually(func() ([]string, error) {
+						var err error
+						targets, err = felixes[srvr].RawPreroutingTargets("eth0")
+						return targets, err
+					}, "10s", "200ms").Should(ContainElement(policyChain))
+
+					position := func(chain string) int {
+						for i, t := range targets {
+							if t == chain {
+								return i
+							}
+						}
+						Fail(fmt.Sprintf("%s isn't reached from raw PREROUTING: %v", chain, targets))
+						return -1
+					}
+					Expect(position("cali-PREROUTING")).To(BeNumerically("<", position("cali-fh-eth0")))
+					Expect(position("cali-fh-eth0")).To(BeNumerically("<", position("cali-failsafe-in")))
+					Expect(position("cali-failsafe-in")).To(BeNumerically("<", position(policyChain)))
+				})
+
+				It("should coalesce rapid blocklist updates with XDPUpdateDebounce", func() {
+					const numUpdates = 20
+					blocklistKey := func(cidr string) []string {
+						hex, err := bpf.CidrToHex(cidr)
+						Expect(err).NotTo(HaveOccurred())
+						return append([]string{"bpftool", "map", "lookup", "pinned", "/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist", "key", "hex"}, hex...)
+					}
+					updateBatches := func() (int, error) {
+						return metrics.GetFelixMetricInt(felixes[srvr].IP, "felix_xdp_member_update_batches")
+					}
+
+					felixes[srvr].SetEvn(map[string]string{"FELIX_XDPUPDATEDEBOUNCE": "2000"})
+					felixes[srvr].Restart()
+					Eventually(felixes[srvr].ExecOutputFn(blocklistKey(hostW[clnt].IP+"/32")...), "20s").Should(ContainSubstring("value:"))
+					// Let the batch for the initial sync settle before taking the baseline.
+					time.Sleep(3 * time.Second)
+					batchesBefore, err := updateBatches()
+					Expect(err).NotTo(HaveOccurred())
+
+					// Each update swaps the churning entry for the next one; only the last one
+					// should end up in the map.
+					for i := 0; i < numUpdates; i++ {
+						srcNS, err := client.GlobalNetworkSets().Get(utils.Ctx, "xdpblocklist", options.GetOptions{})
+						Expect(err).NotTo(HaveOccurred())
+						srcNS.Spec.Nets = []string{hostW[clnt].IP, fmt.Sprintf("10.123.%d.0/24", i)}
+						_, err = client.GlobalNetworkSets().Update(utils.Ctx, srcNS, utils.NoOptions)
+						Expect(err).NotTo(HaveOccurred())
+						time.Sleep(50 * time.Millisecond)
+					}
+
+					lastKey := blocklistKey(fmt.Sprintf("10.123.%d.0/24", numUpdates-1))
+					Eventually(felixes[srvr].ExecOutputFn(lastKey...), "10s").Should(ContainSubstring("value:"))
+					for i := 0; i < numUpdates-1; i++ {
+						_, err := felixes[srvr].ExecOutput(blocklistKey(fmt.Sprintf("10.123.%d.0/24", i))...)
+						Expect(err).To(HaveOccurred(), "stale entry for update %d is still in the blocklist map", i)
+					}
+					expectBlocked(cc)
+
+					// Without the debounce, each update would be written to the map separately.
+					Expect(updateBatches()).To(BeNumerically("<=", batchesBefore+numUpdates/4))
+				})
+
+				It("should report the XDP status of the host endpoint's interface", func() {
+					Eventually(func() (bpf.XDPStatus, error) {
+						return felixes[srvr].XDPStatus("eth0")
+					}, "10s", "500ms").Should(And(
+						HaveField("Desired", BeTrue()),
+						HaveField("Attached", BeTrue()),
+						HaveField("BlocklistEntries", Equal(1)),
+					))
+					status, err := felixes[srvr].XDPStatus("eth0")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(felixes[srvr].XDPAttachedInterfaces()).To(ContainElement("eth0"))
+					Expect(status.Mode).To(BeElementOf("xdpoffload", "xdpdrv", "xdpgeneric"))
+					Expect(status.LastReconcile).NotTo(BeZero())
+
+					// An interface without a host endpoint doesn't want XDP.
+					Expect(felixes[srvr].XDPStatus("lo")).To(Equal(bpf.XDPStatus{}))
+				})
+
+				It("should also stop the server sending to the blocklisted client with XDPEgressBlocklistEnabled", func() {
+					const numProbes = 10
+					// The client's replies are dropped by XDP either way, so look for the
+					// server's packets arriving at the client rather than at connectivity.
+					sendProbes := func() []infrastructure.Packet {
+						filter := fmt.Sprintf("udp and src host %s and dst port 8055", hostW[srvr].IP)
+						received := felixes[clnt].StartCapture("eth0", filter)
+						for i := 0; i < numProbes; i++ {
+							_, err := hostW[srvr].RunCmd("pktgen", hostW[srvr].IP, hostW[clnt].IP, "udp", "--port-dst", "8055")
+							Expect(err).NotTo(HaveOccurred())
+						}
+						time.Sleep(time.Second)
+						return received.Stop()
+					}
+
+					By("seeing the server's packets reach the client while only ingress is blocked")
+					expectBlocked(cc)
+					Expect(sendProbes()).To(HaveLen(numProbes))
+
+					felixes[srvr].SetEvn(map[string]string{"FELIX_XDPEGRESSBLOCKLISTENABLED": "true"})
+					felixes[srvr].Restart()
+					Eventually(felixes[srvr].ExecOutputFn("tc", "filter", "show", "dev", "eth0", "egress"), "20s").
+						Should(ContainSubstring("direct-action"))
+
+					By("dropping the server's packets to the client once egress is blocked too")
+					expectBlocked(cc)
+					Expect(sendProbes()).To(BeEmpty())
+
+					By("still letting the replies from the failsafe port out")
+					expectFailsafePortsOpen(cc)
+				})
+
+				It("should leave CIDRs out of a full blocklist and still block them with iptables", func() {
+					skippedEntries := func() (int, error) {
+						return metrics.GetFelixMetricInt(felixes[srvr].IP, `felix_xdp_blocklist_skipped_entries{iface="eth0"}`)
+					}
+					fullLogC := felixes[srvr].WatchStdoutFor(regexp.MustCompile("XDP blocklist is full"))
+
+					felixes[srvr].SetEvn(map[string]string{"FELIX_XDPMAXBLOCKLISTENTRIES": "2"})
+					felixes[srvr].Restart()
+					Eventually(func() ([]string, error) {
+						return felixes[srvr].XDPBlocklistCIDRs("eth0")
+					}, "20s", "500ms").Should(ConsistOf(hostW[clnt].IP + "/32"))
+
+					By("programming more CIDRs than fit")
+					srcNS, err := client.GlobalNetworkSets().Get(utils.Ctx, "xdpblocklist", options.GetOptions{})
+					Expect(err).NotTo(HaveOccurred())
+					srcNS.Spec.Nets = []string{hostW[clnt].IP, "10.123.0.1", "10.123.0.2", "10.123.0.3"}
+					_, err = client.GlobalNetworkSets().Update(utils.Ctx, srcNS, utils.NoOptions)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(skippedEntries, "10s", "500ms").Should(Equal(2))
+					Eventually(fullLogC, "10s").Should(BeClosed())
+					Expect(felixes[srvr].XDPBlocklistCIDRs("eth0")).To(HaveLen(2))
+					expectBlocked(cc)
+					expectFailsafePortsOpen(cc)
+
+					By("adding the left out CIDRs once there's room for them")
+					srcNS, err = client.GlobalNetworkSets().Get(utils.Ctx, "xdpblocklist", options.GetOptions{})
+					Expect(err).NotTo(HaveOccurred())
+					srcNS.Spec.Nets = []string{hostW[clnt].IP, "10.123.0.3"}
+					_, err = client.GlobalNetworkSets().Update(utils.Ctx, srcNS, utils.NoOptions)
+					Expect(err).NotTo(HaveOccurred())
+
+					Eventually(func() ([]string, error) {
+						return felixes[srvr].XDPBlocklistCIDRs("eth0")
+					}, "10s", "500ms").Should(ConsistOf(hostW[clnt].IP+"/32", "10.123.0.3/32"))
+					Eventually(skippedEntries, "10s", "500ms").Should(Equal(0))
+					expectBlocked(cc)
+				})
+			}
+
+			It("should have expected no connectivity from felixes[clnt] with XDP blocklist", func() {
+				expectBlocked(cc)
+			})
+
+			It("should have expected no dropped packets in iptables", func() {
+				kernelVersion, err := felixes[srvr].KernelVersion()
+				Expect(err).NotTo(HaveOccurred())
+
+				if proto == "tcp" && kernelVersion.Compare(environment.MustParseVersion("4.19.0")) < 0 {
+					Skip(fmt.Sprintf("Skipping TCP test on Linux %v (needs 4.19)", kernelVersion))
+					return
+				}
+
+				expectBlocked(cc)
+
+				if !BPFMode() {
+					// the only rule that refers to a cali40-prefixed ipset should have 0 packets/bytes
+					Eventually(func() string {
+						out, _ := felixes[srvr].ExecOutput("iptables", "-t", "raw", "-v", "-n", "-L",
+							"cali-pi-default.xdp-filter")
+						return out
+
+					}).Should(MatchRegexp(`(?m)^\s+0\s+0.*cali40s:`))
+				}
+			})
+
+			It("should have expected failsafe port 1234 to be open on felix[srvr] with XDP blocklist", func() {
+				expectFailsafePortsOpen(cc)
+			})
+
+			It("should leave only the failsafe port open on felix[srvr] with XDP blocklist", func() {
+				Eventually(func() map[int]bool {
+					return cc.ScanPorts(hostW[clnt], hostW[srvr], []int{1234, 8055, 8056})
+				}, "10s", "1s").Should(connectivity.MatchPortScan(map[int]bool{1234: true, 8055: false, 8056: false}))
+			})
+
+			if !BPFMode() && proto == "udp" {
+				It("should account for each blocklisted packet once, whether XDP drops it or passes it", func() {
+					expectBlocked(cc)
+					const numDropped, numFailsafe = 5, 3
+					c := infrastructure.AssertCounterConservation(felixes[srvr], "eth0", hostW[clnt].IP, func() int {
+						for port, n := range map[string]int{"8055": numDropped, "1234": numFailsafe} {
+							for i := 0; i < n; i++ {
+								_, err := hostW[clnt].RunCmd("pktgen", hostW[clnt].IP, hostW[srvr].IP, "udp",
+									"--port-dst", port)
+								Expect(err).NotTo(HaveOccurred())
+							}
+						}
+						return numDropped + numFailsafe
+					})
+					Expect(c.XDPDropped).To(Equal(numDropped), "XDP should drop the packets to port 8055")
+					Expect(c.XDPPassed).To(Equal(numFailsafe), "XDP should pass the packets to failsafe port 1234")
+				})
+			}
+
+			if !BPFMode() {
+				It("should attribute blocklisted packets to SSH to the inbound TCP 22 failsafe rule", func() {
+					expectBlocked(cc)
+					// Forget the probes to failsafe port 1234.
+					Expect(bpf.ResetXDPCounters(felixes[srvr], "eth0")).To(Succeed())
+
+					const numSYNs = 5
+					_ = felixes[clnt].ExecMayFail("hping3", "--syn", "-c", strconv.Itoa(numSYNs), "-i", "u10000",
+						"-p", "22", hostW[srvr].IP)
+
+					ssh := bpf.ProtoPort{Proto: labelindex.ProtocolTCP, Port: 22}
+					Eventually(func() ([]bpf.FailsafeBypassEvent, error) {
+						return bpf.FailsafeBypassEvents(felixes[srvr], "eth0")
+					}, "5s", "200ms").Should(ConsistOf(
+						bpf.FailsafeBypassEvent{ProtoPort: ssh, Packets: numSYNs},
+					), "Only the inbound TCP 22 failsafe rule should have let the client through")
+				})
 			}
 
-			It("should have expected no connectivity from felixes[clnt] with XDP blocklist", func() {
-				expectBlocked(cc)
-			})
+			if BPFMode() {
+				It("should count failsafe traffic separately from traffic passed by policy", func() {
+					before := felixes[srvr].XDPVerdictCounts("eth0")
+					expectFailsafePortsOpen(cc)
+					Eventually(func() uint64 {
+						return felixes[srvr].XDPVerdictCounts("eth0").Failsafe
+					}, "5s", "200ms").Should(BeNumerically(">", before.Failsafe))
+					// The XDP policy only has a deny rule so nothing should be passed by policy.
+					Expect(felixes[srvr].XDPVerdictCounts("eth0").Pass).To(Equal(before.Pass))
+				})
+
+				It("should hand allowed traffic to the stack", func() {
+					before := felixes[srvr].XDPVerdictCounts("eth0")
+					expectFailsafePortsOpen(cc)
+					Eventually(func() uint64 {
+						return felixes[srvr].XDPVerdictCounts("eth0").StackPassed
+					}, "5s", "200ms").Should(BeNumerically(">", before.StackPassed))
+				})
+
+				It("should count the packets dropped by the XDP deny rule", func() {
+					const numProbes = 10
+
+					expectBlocked(cc)
+					countBefore := denyRuleCount()
+					for i := 0; i < numProbes; i++ {
+						_, err := hostW[clnt].RunCmd("pktgen", hostW[clnt].IP, hostW[srvr].IP, "udp", "--port-dst", "8055")
+						Expect(err).NotTo(HaveOccurred())
+					}
+					Eventually(denyRuleCount, "5s", "200ms").Should(Equal(countBefore + numProbes))
+				})
+
+				It("should attribute the blocked connection to the XDP policy", func() {
+					cc.ExpectBlockedByPolicy(felixes[clnt], hostW[srvr], 8055, "default.xdp-filter")
+					cc.CheckConnectivity()
+				})
+
+				It("should count the packets of the allow and deny rules of a policy separately", func() {
+					const numAllowed = 5
+					const numDenied = 10
+					sendProbes := func(port, n int) {
+						for i := 0; i < n; i++ {
+							_, err := hostW[clnt].RunCmd("pktgen", hostW[clnt].IP, hostW[srvr].IP, "udp",
+								"--port-dst", strconv.Itoa(port))
+							Expect(err).NotTo(HaveOccurred())
+						}
+					}
+
+					// Let the blocklisted client through to 8056 only, ahead of the deny rule.
+					udp := numorstring.ProtocolFromString("UDP")
+					xdpPolicy, err := client.GlobalNetworkPolicies().Get(utils.Ctx, "xdp-filter", options.GetOptions{})
+					Expect(err).NotTo(HaveOccurred())
+					xdpPolicy.Spec.Ingress = append([]api.Rule{{
+						Action:   api.Allow,
+						Protocol: &udp,
+						Source: api.EntityRule{
+							Selector: "xdpblocklist-set=='true'",
+						},
+						Destination: api.EntityRule{
+							Ports: []numorstring.Port{numorstring.SinglePort(8056)},
+						},
+					}}, xdpPolicy.Spec.Ingress...)
+					_, err = client.GlobalNetworkPolicies().Update(utils.Ctx, xdpPolicy, options.SetOptions{})
+					Expect(err).NotTo(HaveOccurred())
+
+					allowCount := func() int { return ruleCount("ingress-0", "allow") }
+					denyCount := func() int { return ruleCount("ingress-1", "deny") }
+					Eventually(func() int {
+						sendProbes(8056, 1)
+						return allowCount()
+					}, "10s", "500ms").Should(BeNumerically(">", 0))
+
+					allowBefore, denyBefore := allowCount(), denyCount()
+					sendProbes(8056, numAllowed)
+					sendProbes(8055, numDenied)
+					Eventually(allowCount, "5s", "200ms").Should(Equal(allowBefore + numAllowed))
+					Eventually(denyCount, "5s", "200ms").Should(Equal(denyBefore + numDenied))
+					Consistently(allowCount, "1s", "200ms").Should(Equal(allowBefore + numAllowed))
+				})
+
+				It("should report the dropped packets in the XDP events ring buffer", func() {
+					expectBlocked(cc)
+
+					blockedDrop := bpf.XDPEvent{
+						SrcIP:   net.ParseIP(hostW[clnt].IP).To4(),
+						Verdict: bpf.XDPVerdictDrop,
+					}
+					Eventually(func() []bpf.XDPEvent {
+						_, err := hostW[clnt].RunCmd("pktgen", hostW[clnt].IP, hostW[srvr].IP, "udp", "--port-dst", "8055")
+						Expect(err).NotTo(HaveOccurred())
+						events, err := bpf.ReadXDPRingbuf(felixes[srvr], time.Second)
+						Expect(err).NotTo(HaveOccurred())
+						return events
+					}, "10s").Should(ContainElement(blockedDrop))
+				})
+
+				It("should keep dropping but report no events with XDPLogLevel=off", func() {
+					readEvents := func() []bpf.XDPEvent {
+						_, err := hostW[clnt].RunCmd("pktgen", hostW[clnt].IP, hostW[srvr].IP, "udp", "--port-dst", "8055")
+						Expect(err).NotTo(HaveOccurred())
+						events, err := bpf.ReadXDPRingbuf(felixes[srvr], time.Second)
+						Expect(err).NotTo(HaveOccurred())
+						return events
+					}
 
-			It("should have expected no dropped packets in iptables", func() {
-				versionReader, err := environment.GetKernelVersionReader()
-				Expect(err).NotTo(HaveOccurred())
+					felixes[srvr].SetEvn(map[string]string{"FELIX_XDPLOGLEVEL": "off"})
+					felixes[srvr].Restart()
 
-				kernelVersion, err := environment.GetKernelVersion(versionReader)
-				Expect(err).NotTo(HaveOccurred())
+					// The old program keeps reporting until Felix has reattached it with the
+					// new setting.
+					Eventually(readEvents, "20s").Should(BeEmpty())
 
-				if proto == "tcp" && kernelVersion.Compare(environment.MustParseVersion("4.19.0")) < 0 {
-					Skip(fmt.Sprintf("Skipping TCP test on Linux %v (needs 4.19)", kernelVersion))
-					return
-				}
+					const numProbes = 10
+					expectBlocked(cc)
+					countBefore := denyRuleCount()
+					for i := 0; i < numProbes; i++ {
+						_, err := hostW[clnt].RunCmd("pktgen", hostW[clnt].IP, hostW[srvr].IP, "udp", "--port-dst", "8055")
+						Expect(err).NotTo(HaveOccurred())
+					}
+					Eventually(denyRuleCount, "5s", "200ms").Should(Equal(countBefore + numProbes))
+					Expect(readEvents()).To(BeEmpty())
+				})
+			}
 
+			It("should block many concurrent connections without creating conntrack entries", func() {
+				const numConns = 20
 				expectBlocked(cc)
+				ctBefore := felixes[srvr].ConntrackCount()
+
+				conns := hostW[clnt].OpenConns(hostW[srvr].IP, 8055, numConns)
+				defer conns.Stop()
+				Expect(conns.NumEstablished()).To(BeZero())
+				// Allow for some background churn but the blocked connections should
+				// never have reached conntrack.
+				Expect(felixes[srvr].ConntrackCount()).To(BeNumerically("<", ctBefore+numConns))
+			})
 
-				if !BPFMode() {
-					// the only rule that refers to a cali40-prefixed ipset should have 0 packets/bytes
-					Eventually(func() string {
-						out, _ := felixes[srvr].ExecOutput("iptables", "-t", "raw", "-v", "-n", "-L",
-							"cali-pi-default.xdp-filter")
-						return out
+			if withTypha {
+				It("should resync the blocklist from Typha after Typha restarts", func() {
+					expectBlocked(cc)
+					felixes[srvr].Typha.Restart()
 
-					}).Should(MatchRegexp(`(?m)^\s+0\s+0.*cali40s:`))
-				}
-			})
+					By("unblocking the client once Felix has resynced")
+					_ = applyGlobalNetworkSets("xdpblocklist", hostW[srvr].IP, "/32", true)
+					expectAllAllowed(cc)
 
-			It("should have expected failsafe port 1234 to be open on felix[srvr] with XDP blocklist", func() {
-				expectFailsafePortsOpen(cc)
+					By("blocking it again")
+					_ = applyGlobalNetworkSets("xdpblocklist", hostW[clnt].IP, "/32", true)
+					expectBlocked(cc)
+				})
+			}
+
+			It("should drop the replies to connections that the server opens to the blocklisted host", func() {
+				// XDP only sees ingress traffic and doesn't track connections, so the server's
+				// own packets go out but the client's replies are dropped on the way back in.
+				hostCC := &connectivity.Checker{Protocol: proto}
+				hostCC.ExpectNone(connectivity.HostSource(felixes[srvr]), hostW[clnt].Port(8055))
+				hostCC.CheckConnectivity()
+				hostCC.ResetExpectations()
+
+				By("lifting the blocklist")
+				_ = applyGlobalNetworkSets("xdpblocklist", hostW[srvr].IP, "/32", true)
+				hostCC.ExpectSome(connectivity.HostSource(felixes[srvr]), hostW[clnt].Port(8055))
+				hostCC.CheckConnectivity()
 			})
 
 			It("should have expected connectivity after removing the policy", func() {
@@ -422,6 +2822,180 @@ func xdpTest(getInfra infrastructure.InfraFactory, proto string) {
 				expectAllAllowed(cc)
 			})
 
+			if proto == "tcp" {
+				It("should make connections time out rather than be refused", func() {
+					// Nothing listens on this port on the server, so it would answer a SYN that
+					// got through with a RST.
+					const closedPort = 8057
+
+					// The server is on the same network so a working handshake takes well
+					// under a second; don't wait any longer than that for the dropped SYNs.
+					failures := &connectivity.Checker{Protocol: "tcp", ConnectTimeout: time.Second}
+					failures.ExpectTimeout(hostW[clnt], hostW[srvr], 8055)
+					failures.ExpectTimeout(hostW[clnt], hostW[srvr], closedPort)
+					failures.CheckConnectivity()
+					failures.ResetExpectations()
+
+					By("getting the server's RST once the client is no longer blocklisted")
+					_ = applyGlobalNetworkSets("xdpblocklist", hostW[srvr].IP, "/32", true)
+					failures.ExpectRefused(hostW[clnt], hostW[srvr], closedPort)
+					failures.CheckConnectivity()
+				})
+			}
+
+			if proto == "udp" {
+				It("should drop datagrams to a closed port before the server can reject them", func() {
+					// Nothing listens on this port on the server, so once the blocklist is
+					// lifted the server answers with an ICMP port unreachable.
+					const closedPort = 8057
+
+					cc.Expect(connectivity.None, hostW[clnt], hostW[srvr].Port(closedPort),
+						connectivity.ExpectWithPortUnreachable())
+					cc.CheckConnectivity()
+					cc.ResetExpectations()
+
+					_, err := client.GlobalNetworkPolicies().Delete(utils.Ctx, "xdp-filter", options.DeleteOptions{})
+					Expect(err).NotTo(HaveOccurred())
+
+					cc.ExpectUDPPortClosed(hostW[clnt], hostW[srvr], closedPort)
+					cc.CheckConnectivity()
+				})
+
+				Context("with a second policy blocking the same source", func() {
+					BeforeEach(func() {
+						order := float64(11)
+						xdpPolicy2 := api.NewGlobalNetworkPolicy()
+						xdpPolicy2.Name = "xdp-filter-2"
+						xdpPolicy2.Spec.Order = &order
+						xdpPolicy2.Spec.DoNotTrack = true
+						xdpPolicy2.Spec.ApplyOnForward = true
+						xdpPolicy2.Spec.Selector = "role=='server'"
+						xdpPolicy2.Spec.Ingress = []api.Rule{{
+							Action: api.Deny,
+							Source: api.EntityRule{
+								Selector: "xdpblocklist-set=='true'",
+							},
+						}}
+						_, err := client.GlobalNetworkPolicies().Create(utils.Ctx, xdpPolicy2, utils.NoOptions)
+						Expect(err).NotTo(HaveOccurred())
+						expectBlocked(cc)
+					})
+
+					AfterEach(func() {
+						_, _ = client.GlobalNetworkPolicies().Delete(utils.Ctx, "xdp-filter-2", options.DeleteOptions{})
+					})
+
+					It("should not leak any packets until the last blocking policy is removed", func() {
+						// Both policies refer to the same IP set, so removing one of them
+						// mustn't remove the client from the blocklist, even briefly.
+						traffic := hostW[clnt].StartTraffic(hostW[srvr].IP, 8055, 6*time.Second)
+						time.Sleep(2 * time.Second)
+						_, err := client.GlobalNetworkPolicies().Delete(utils.Ctx, "xdp-filter", options.DeleteOptions{})
+						Expect(err).NotTo(HaveOccurred())
+						traffic.ExpectNoLeaks()
+
+						_, err = client.GlobalNetworkPolicies().Delete(utils.Ctx, "xdp-filter-2", options.DeleteOptions{})
+						Expect(err).NotTo(HaveOccurred())
+						expectAllAllowed(cc)
+					})
+				})
+			}
+
+			if proto == "tcp" {
+				Context("with TCP fast open", func() {
+					var tfo *connectivity.Checker
+
+					BeforeEach(func() {
+						// Enable both client and server side; the test workload and
+						// test-connection disable cookies per-socket.
+						for _, felix := range felixes {
+							if err := felix.ExecMayFail("sysctl", "-w", "net.ipv4.tcp_fastopen=3"); err != nil {
+								Skip(fmt.Sprintf("TCP fast open not supported: %v", err))
+							}
+						}
+						tfo = &connectivity.Checker{Protocol: "tcp-fastopen"}
+					})
+
+					It("should block SYNs carrying data from a blocklisted source", func() {
+						tfo.ExpectNone(felixes[clnt], hostW[srvr].Port(8055))
+						tfo.CheckConnectivity()
+					})
+
+					It("should accept SYN data once the source is no longer blocklisted", func() {
+						_ = applyGlobalNetworkSets("xdpblocklist", hostW[srvr].IP, "/32", true)
+
+						tfo.Expect(connectivity.Some, felixes[clnt], hostW[srvr].Port(8055),
+							connectivity.ExpectWithSYNDataAcked())
+						tfo.CheckConnectivity()
+					})
+				})
+			}
+
+			Context("with a pre-DNAT policy allowing a DNATed port", func() {
+				const dnatPort = 32055
+
+				BeforeEach(func() {
+					felixes[srvr].Exec(
+						"iptables", "-t", "nat",
+						"-w", "10", // Retry this for 10 seconds, e.g. if something else is holding the lock
+						"-W", "100000", // How often to probe the lock in microsecs.
+						"-A", "PREROUTING",
+						"-p", proto,
+						"-d", hostW[srvr].IP, "--dport", strconv.Itoa(dnatPort),
+						"-j", "DNAT", "--to", hostW[srvr].IP+":8055",
+					)
+
+					order := float64(5)
+					protocol := numorstring.ProtocolFromString(proto)
+					policy := api.NewGlobalNetworkPolicy()
+					policy.Name = "pre-dnat-allow"
+					policy.Spec.Order = &order
+					policy.Spec.PreDNAT = true
+					policy.Spec.ApplyOnForward = true
+					policy.Spec.Selector = "role=='server'"
+					policy.Spec.Ingress = []api.Rule{{
+						Action:   api.Allow,
+						Protocol: &protocol,
+						Destination: api.EntityRule{
+							Ports: []numorstring.Port{numorstring.SinglePort(dnatPort)},
+						},
+					}}
+					_, err := client.GlobalNetworkPolicies().Create(utils.Ctx, policy, utils.NoOptions)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("should reject a policy that is both DoNotTrack and PreDNAT", func() {
+					policy, err := client.GlobalNetworkPolicies().Get(utils.Ctx, "pre-dnat-allow", options.GetOptions{})
+					Expect(err).NotTo(HaveOccurred())
+					policy.Spec.DoNotTrack = true
+					_, err = client.GlobalNetworkPolicies().Update(utils.Ctx, policy, utils.NoOptions)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("PreDNAT and DoNotTrack cannot both be true"))
+				})
+
+				It("should drop blocklisted traffic to the DNATed port before DNAT", func() {
+					cc.ExpectNone(felixes[clnt], hostW[srvr], dnatPort)
+					cc.CheckConnectivity()
+					cc.ResetExpectations()
+
+					if !BPFMode() {
+						// The untracked policy runs before the nat table so the DNAT
+						// rule should never have seen a packet.
+						out, err := felixes[srvr].ExecOutput("iptables", "-t", "nat", "-v", "-n", "-L", "PREROUTING")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(out).To(MatchRegexp(`(?m)^\s+0\s+0\s+DNAT.*dpt:%d`, dnatPort))
+					}
+				})
+
+				It("should allow the DNATed port once the source is no longer blocklisted", func() {
+					_ = applyGlobalNetworkSets("xdpblocklist", hostW[srvr].IP, "/32", true)
+
+					cc.ExpectSome(felixes[clnt], hostW[srvr], dnatPort)
+					cc.CheckConnectivity()
+					cc.ResetExpectations()
+				})
+			})
+
 			Context("messing up with BPF maps", func() {
 
 				if BPFMode() {
@@ -429,17 +3003,63 @@ func xdpTest(getInfra infrastructure.InfraFactory, proto string) {
 					return
 				}
 
+				const blocklistMapPath = "/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist"
+
+				blocklistSnapshot := func() (bpf.Snapshot, error) {
+					return bpf.SnapshotMap(felixes[srvr], blocklistMapPath)
+				}
+
 				It("resync should've handled the external change of a BPF map", func() {
-					args := append([]string{"bpftool", "map", "lookup", "pinned", "/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist", "key", "hex"}, hostHexCIDR...)
+					args := append([]string{"bpftool", "map", "lookup", "pinned", blocklistMapPath, "key", "hex"}, hostHexCIDR...)
+					Eventually(felixes[srvr].ExecOutputFn(args...), "10s").Should(ContainSubstring("value:"))
+					synced, err := blocklistSnapshot()
+					Expect(err).NotTo(HaveOccurred())
+
+					felixes[srvr].Exec(append([]string{"bpftool", "map", "delete", "pinned", blocklistMapPath, "key", "hex"}, hostHexCIDR...)...)
+
+					// Resync should put back exactly what was there, reference count included.
+					Eventually(blocklistSnapshot, resyncPeriod).Should(Equal(synced))
+
+					expectBlocked(cc)
+				})
+
+				It("resync should've removed entries added to a BPF map behind Felix's back", func() {
+					args := append([]string{"bpftool", "map", "lookup", "pinned", blocklistMapPath, "key", "hex"}, hostHexCIDR...)
 					Eventually(felixes[srvr].ExecOutputFn(args...), "10s").Should(ContainSubstring("value:"))
+					synced, err := blocklistSnapshot()
+					Expect(err).NotTo(HaveOccurred())
 
-					felixes[srvr].Exec(append([]string{"bpftool", "map", "delete", "pinned", "/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist", "key", "hex"}, hostHexCIDR...)...)
+					// Block the server's own address too, which would cut off its replies.
+					tampered := bpf.Snapshot{}
+					for k, v := range synced {
+						tampered[k] = v
+					}
+					serverKey := append([]byte{32, 0, 0, 0}, net.ParseIP(hostW[srvr].IP).To4()...)
+					tampered[string(serverKey)] = []byte{1, 0, 0, 0}
+					Expect(bpf.RestoreMap(felixes[srvr], blocklistMapPath, tampered)).To(Succeed())
 
-					Eventually(felixes[srvr].ExecOutputFn(args...), resyncPeriod).Should(ContainSubstring("value:"))
+					Eventually(blocklistSnapshot, resyncPeriod).Should(Equal(synced))
 
 					expectBlocked(cc)
 				})
 
+				It("resync should've recreated the program and maps after bpffs was remounted", func() {
+					args := append([]string{"bpftool", "map", "lookup", "pinned", "/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist", "key", "hex"}, hostHexCIDR...)
+					Eventually(felixes[srvr].ExecOutputFn(args...), "10s").Should(ContainSubstring("value:"))
+
+					felixes[srvr].RemountBPFFS()
+					_, err := felixes[srvr].ExecOutput(args...)
+					Expect(err).To(HaveOccurred(), "blocklist map still pinned after remounting bpffs")
+
+					Eventually(felixes[srvr].ExecOutputFn(args...), 2*resyncPeriod).Should(ContainSubstring("value:"))
+					Expect(felixes[srvr].ExecOutput("bpftool", "map", "show", "pinned",
+						"/sys/fs/bpf/calico/calico_failsafe_ports_v1")).NotTo(BeEmpty())
+					Expect(xdpProgramAttached_server_eth0()).To(BeTrue())
+					felixes[srvr].ExpectNoLogMatch("disabling XDP", time.Second)
+
+					expectFailsafePortsOpen(cc)
+				})
+
 				It("resync should've handled manually detaching a BPF program", func() {
 					felixes[srvr].Exec("ip", "link", "set", "dev", "eth0", "xdp", "off")
 
@@ -476,6 +3096,393 @@ func xdpTest(getInfra infrastructure.InfraFactory, proto string) {
 					"/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist", "key", "hex"}, AdditionalHostHexCIDR...)
 				Eventually(felixes[srvr].ExecOutputFn(args...), "5s").Should(ContainSubstring("value:"))
 			})
+
+			It("should block the updated set's sources whichever RX queue their flows arrive on", func() {
+				_ = applyGlobalNetworkSets("xdpblocklist", "1.2.3.4", "/32", true)
+				Eventually(func() error {
+					return xdpmaps.VerifyMapConsistentAcrossQueues(felixes[srvr], "eth0")
+				}, "10s", "200ms").Should(Succeed())
+				expectAllAllowed(cc)
+
+				_ = applyGlobalNetworkSets("xdpblocklist", hostW[clnt].IP, "/32", true)
+				Eventually(func() ([][]string, error) {
+					return xdpmaps.BlocklistShardInfo(felixes[srvr], "eth0")
+				}, "10s", "200ms").Should(HaveEach(Equal([]string{hostW[clnt].IP + "/32"})))
+				Expect(xdpmaps.VerifyMapConsistentAcrossQueues(felixes[srvr], "eth0")).To(Succeed())
+
+				// Each connection has its own source port, so on a multi-queue NIC the
+				// connections are hashed across the RX queues.
+				cc.Expect(connectivity.None, felixes[clnt], hostW[srvr],
+					connectivity.ExpectWithPorts(8055),
+					connectivity.ExpectWithConcurrentConns(50),
+				)
+				cc.CheckConnectivity()
+			})
+		})
+
+		Context("with a GlobalNetworkSet fed from an HTTP feed", func() {
+			// The networksetfeed controller in kube-controllers syncs the feed.  Run it
+			// in-process, so the feed only needs to be served locally.
+			var (
+				feedServer *httptest.Server
+				feedMutex  sync.Mutex
+				feed       string
+				stopCtrl   chan struct{}
+				cancelCtrl context.CancelFunc
+			)
+
+			writeFeed := func(contents string) {
+				feedMutex.Lock()
+				defer feedMutex.Unlock()
+				feed = contents + "\n"
+			}
+
+			feedNets := func() []string {
+				netSet, err := client.GlobalNetworkSets().Get(utils.Ctx, "xdpblocklist", options.GetOptions{})
+				if err != nil {
+					return nil
+				}
+				return netSet.Spec.Nets
+			}
+
+			AfterEach(func() {
+				close(stopCtrl)
+				cancelCtrl()
+				feedServer.Close()
+			})
+
+			BeforeEach(func() {
+				writeFeed("1.2.3.4")
+				feedServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					feedMutex.Lock()
+					defer feedMutex.Unlock()
+					fmt.Fprint(w, feed)
+				}))
+
+				srcNS := api.NewGlobalNetworkSet()
+				srcNS.Name = "xdpblocklist"
+				srcNS.Labels = map[string]string{
+					"xdpblocklist-set": "true",
+				}
+				srcNS.Spec.FeedURL = feedServer.URL + "/feed.txt"
+				srcNS.Spec.FeedRefresh = &metav1.Duration{Duration: time.Second}
+				_, err := client.GlobalNetworkSets().Create(utils.Ctx, srcNS, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+
+				var ctx context.Context
+				ctx, cancelCtrl = context.WithCancel(context.Background())
+				stopCtrl = make(chan struct{})
+				ctrl := networksetfeed.NewNetworkSetFeedController(ctx, client,
+					config.NetworkSetFeedControllerConfig{
+						ReconcilerPeriod: time.Second,
+						// The test server listens on 127.0.0.1.
+						AllowedHosts: []string{"127.0.0.1"},
+					})
+				go ctrl.Run(stopCtrl)
+
+				Eventually(feedNets, "30s", "1s").Should(Equal([]string{"1.2.3.4/32"}))
+			})
+
+			It("should update the XDP blocklist when the feed changes", func() {
+				expectAllAllowed(cc)
+
+				writeFeed(hostW[clnt].IP)
+				Eventually(feedNets, "30s", "1s").Should(Equal([]string{hostW[clnt].IP + "/32"}))
+
+				if !BPFMode() {
+					hexCIDR, err := bpf.CidrToHex(hostW[clnt].IP + "/32")
+					Expect(err).NotTo(HaveOccurred())
+					args := append([]string{"bpftool", "map", "lookup", "pinned",
+						"/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist", "key", "hex"}, hexCIDR...)
+					Eventually(felixes[srvr].ExecOutputFn(args...), "10s").Should(ContainSubstring("value:"))
+				}
+				expectBlocked(cc)
+
+				// A feed with no entries is treated as broken, so move the feed back to
+				// an unrelated address to unblock the client.
+				writeFeed("1.2.3.4")
+				Eventually(feedNets, "30s", "1s").Should(Equal([]string{"1.2.3.4/32"}))
+				expectAllAllowed(cc)
+			})
+		})
+
+		Context("with a GlobalNetworkSet of AS numbers", func() {
+			const (
+				blockedAS = 64496
+				otherAS   = 64497
+			)
+
+			// The networksetasn controller in kube-controllers expands the AS numbers.  Run
+			// it in-process, so the dataset only needs to exist locally.
+			var (
+				datasetPath string
+				stopCtrl    chan struct{}
+				cancelCtrl  context.CancelFunc
+			)
+
+			writeDataset := func(lines ...string) {
+				contents := strings.Join(lines, "\n") + "\n"
+				Expect(os.WriteFile(datasetPath+".tmp", []byte(contents), 0644)).To(Succeed())
+				Expect(os.Rename(datasetPath+".tmp", datasetPath)).To(Succeed())
+			}
+
+			asNets := func() []string {
+				netSet, err := client.GlobalNetworkSets().Get(utils.Ctx, "xdpblocklist", options.GetOptions{})
+				if err != nil {
+					return nil
+				}
+				return netSet.Spec.Nets
+			}
+
+			AfterEach(func() {
+				close(stopCtrl)
+				cancelCtrl()
+				Expect(os.RemoveAll(path.Dir(datasetPath))).To(Succeed())
+			})
+
+			BeforeEach(func() {
+				datasetDir, err := os.MkdirTemp("", "xdp-asn")
+				Expect(err).NotTo(HaveOccurred())
+				datasetPath = path.Join(datasetDir, "asn.txt")
+				writeDataset(
+					"1.2.3.0/24 "+fmt.Sprint(otherAS),
+					hostW[clnt].IP+"/32 AS"+fmt.Sprint(blockedAS),
+				)
+
+				srcNS := api.NewGlobalNetworkSet()
+				srcNS.Name = "xdpblocklist"
+				srcNS.Labels = map[string]string{
+					"xdpblocklist-set": "true",
+				}
+				srcNS.Spec.ASNumbers = []numorstring.ASNumber{otherAS}
+				_, err = client.GlobalNetworkSets().Create(utils.Ctx, srcNS, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+
+				var ctx context.Context
+				ctx, cancelCtrl = context.WithCancel(context.Background())
+				stopCtrl = make(chan struct{})
+				ctrl := networksetasn.NewNetworkSetASNController(ctx, client,
+					config.NetworkSetASNControllerConfig{ReconcilerPeriod: time.Second, DatasetFile: datasetPath})
+				go ctrl.Run(stopCtrl)
+
+				Eventually(asNets, "30s", "1s").Should(Equal([]string{"1.2.3.0/24"}))
+			})
+
+			It("should block the networks of the set's AS numbers", func() {
+				expectAllAllowed(cc)
+
+				By("adding the client's AS to the set")
+				netSet, err := client.GlobalNetworkSets().Get(utils.Ctx, "xdpblocklist", options.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				netSet.Spec.ASNumbers = append(netSet.Spec.ASNumbers, blockedAS)
+				_, err = client.GlobalNetworkSets().Update(utils.Ctx, netSet, options.SetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(asNets, "30s", "1s").Should(ConsistOf("1.2.3.0/24", hostW[clnt].IP+"/32"))
+				expectBlocked(cc)
+
+				By("moving the client's network to another AS in the dataset")
+				writeDataset(
+					"1.2.3.0/24 "+fmt.Sprint(otherAS),
+					hostW[clnt].IP+"/32 64499",
+				)
+				Eventually(asNets, "30s", "1s").Should(Equal([]string{"1.2.3.0/24"}))
+				expectAllAllowed(cc)
+			})
+		})
+
+		Context("blocking full IP with an entry that expires", func() {
+			BeforeEach(func() {
+				srcNS := api.NewGlobalNetworkSet()
+				srcNS.Name = "xdpblocklist"
+				srcNS.Spec.Nets = []string{hostW[clnt].IP}
+				srcNS.Spec.NetExpiries = map[string]metav1.Time{
+					hostW[clnt].IP: metav1.NewTime(time.Now().Add(5 * time.Second)),
+				}
+				srcNS.Labels = map[string]string{
+					"xdpblocklist-set": "true",
+				}
+				_, err := client.GlobalNetworkSets().Create(utils.Ctx, srcNS, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+
+				hostHexCIDR, err = bpf.CidrToHex(hostW[clnt].IP + "/32")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should stop blocking once the entry expires", func() {
+				cc.ExpectNone(felixes[clnt], hostW[srvr].Port(8055))
+				cc.ExpectNone(felixes[clnt], hostW[srvr].Port(8056))
+				cc.CheckConnectivityWithTimeout(5 * time.Second)
+				cc.ResetExpectations()
+
+				// The entry should be removed without any change to the GlobalNetworkSet.
+				cc.ExpectSome(felixes[clnt], hostW[srvr].Port(8055))
+				cc.ExpectSome(felixes[clnt], hostW[srvr].Port(8056))
+				cc.CheckConnectivityWithTimeout(15 * time.Second)
+
+				if !BPFMode() {
+					args := append([]string{"bpftool", "map", "lookup", "pinned",
+						"/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist", "key", "hex"}, hostHexCIDR...)
+					Eventually(func() error {
+						_, err := felixes[srvr].ExecOutput(args...)
+						return err
+					}, "5s").Should(HaveOccurred())
+				}
+			})
+		})
+
+		Context("blocking full IP with an entry that has a grace period", func() {
+			It("should count, but pass, the client's packets until the grace period ends and then drop them", func() {
+				graceUntil := time.Now().Add(5 * time.Second)
+				srcNS := api.NewGlobalNetworkSet()
+				srcNS.Name = "xdpblocklist"
+				srcNS.Spec.Nets = []string{hostW[clnt].IP}
+				srcNS.Spec.NetGraceUntil = map[string]metav1.Time{
+					hostW[clnt].IP: metav1.NewTime(graceUntil),
+				}
+				srcNS.Labels = map[string]string{
+					"xdpblocklist-set": "true",
+				}
+				_, err := client.GlobalNetworkSets().Create(utils.Ctx, srcNS, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+
+				send := func(count int) {
+					_ = felixes[clnt].ExecMayFail("hping3", "--udp", "-c", strconv.Itoa(count), "-i", "u10000",
+						"-p", "8055", hostW[srvr].IP)
+				}
+				if !BPFMode() {
+					// In BPF mode, the entry is simply left out of the blocklist until its grace
+					// period ends, so only the blocklist XDP program counts the packets.
+					Eventually(func() (map[string]uint64, error) {
+						send(3)
+						return bpf.PerSourceGraceCounts(felixes[srvr], "eth0")
+					}, "4s", "200ms").Should(HaveKey(hostW[clnt].IP))
+					drops, err := bpf.PerSourceDropCounts(felixes[srvr], "eth0")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(drops).NotTo(HaveKey(hostW[clnt].IP))
+				}
+				cc.ExpectSome(felixes[clnt], hostW[srvr].Port(8055))
+				cc.CheckConnectivity()
+				Expect(time.Now()).To(BeTemporally("<", graceUntil),
+					"Checks took longer than the grace period so they don't show that it was in force")
+				cc.ResetExpectations()
+
+				// The entry should be enforced without any change to the GlobalNetworkSet.
+				cc.ExpectNone(felixes[clnt], hostW[srvr].Port(8055))
+				cc.CheckConnectivityWithTimeout(15 * time.Second)
+
+				if !BPFMode() {
+					graceHexCIDR, err := bpf.CidrToHex(hostW[clnt].IP + "/32")
+					Expect(err).NotTo(HaveOccurred())
+					args := append([]string{"bpftool", "map", "lookup", "pinned",
+						"/sys/fs/bpf/calico/xdp/calico_grace_v4", "key", "hex"}, graceHexCIDR...)
+					_, err = felixes[srvr].ExecOutput(args...)
+					Expect(err).To(HaveOccurred(), "Client is still in the grace list")
+
+					graceCounts, err := bpf.PerSourceGraceCounts(felixes[srvr], "eth0")
+					Expect(err).NotTo(HaveOccurred())
+					send(3)
+					Eventually(func() (map[string]uint64, error) {
+						return bpf.PerSourceDropCounts(felixes[srvr], "eth0")
+					}, "5s", "200ms").Should(HaveKey(hostW[clnt].IP))
+					Expect(bpf.PerSourceGraceCounts(felixes[srvr], "eth0")).To(Equal(graceCounts))
+				}
+			})
+		})
+
+		Context("blocking overlapping /32 and /24 entries", func() {
+			var clientNet string
+
+			BeforeEach(func() {
+				_, n, err := net.ParseCIDR(hostW[clnt].IP + "/24")
+				Expect(err).NotTo(HaveOccurred())
+				clientNet = n.String()
+
+				// Each set has an unrelated pair of overlapping entries as well as the
+				// client's, so that the map holds more than one of each prefix length.
+				_ = applyGlobalNetworkSets("xdpblocklist", hostW[clnt].IP+"/32", "", false)
+				srcNS, err := client.GlobalNetworkSets().Get(utils.Ctx, "xdpblocklist", options.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				srcNS.Spec.Nets = append(srcNS.Spec.Nets, "10.0.0.5/32")
+				_, err = client.GlobalNetworkSets().Update(utils.Ctx, srcNS, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+
+				netNS := api.NewGlobalNetworkSet()
+				netNS.Name = "xdpblocklist-net"
+				netNS.Spec.Nets = []string{clientNet, "10.0.0.0/24"}
+				netNS.Labels = map[string]string{
+					"xdpblocklist-set": "true",
+				}
+				_, err = client.GlobalNetworkSets().Create(utils.Ctx, netNS, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should keep blocking with the /32 after the /24 is removed", func() {
+				if !BPFMode() {
+					Eventually(func() ([]string, error) {
+						return felixes[srvr].XDPBlocklistCIDRs("eth0")
+					}, "10s", "500ms").Should(ConsistOf(
+						hostW[clnt].IP+"/32", clientNet, "10.0.0.5/32", "10.0.0.0/24"))
+				}
+				expectBlocked(cc)
+
+				_, err := client.GlobalNetworkSets().Delete(utils.Ctx, "xdpblocklist-net", options.DeleteOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				if !BPFMode() {
+					Eventually(func() ([]string, error) {
+						return felixes[srvr].XDPBlocklistCIDRs("eth0")
+					}, "10s", "500ms").Should(ConsistOf(hostW[clnt].IP+"/32", "10.0.0.5/32"))
+				}
+				expectBlocked(cc)
+				expectFailsafePortsOpen(cc)
+			})
+		})
+
+		Context("blocking CIDRs of several prefix lengths", func() {
+			var cidrs []string
+
+			BeforeEach(func() {
+				cidrs = []string{hostW[clnt].IP + "/32", "10.66.3.0/24", "11.0.0.0/8", "0.0.0.0/0"}
+				_ = applyGlobalNetworkSets("xdpblocklist", cidrs[0], "", false)
+				srcNS, err := client.GlobalNetworkSets().Get(utils.Ctx, "xdpblocklist", options.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				srcNS.Spec.Nets = cidrs
+				_, err = client.GlobalNetworkSets().Update(utils.Ctx, srcNS, utils.NoOptions)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should program LPM keys that match CidrToHex", func() {
+				if BPFMode() {
+					Skip("BPF mode doesn't use the XDP blocklist map")
+				}
+
+				// The kernel's LPM trie key is a host-order u32 prefix length followed
+				// by the address in network order.  XDP is only supported on little
+				// endian hosts, so the prefix length comes out least significant byte
+				// first.
+				var expectedKeys []string
+				for _, c := range cidrs {
+					ip, ipNet, err := net.ParseCIDR(c)
+					Expect(err).NotTo(HaveOccurred())
+					ones, _ := ipNet.Mask.Size()
+					key := []byte{byte(ones), byte(ones >> 8), byte(ones >> 16), byte(ones >> 24)}
+					key = append(key, ip.To4()...)
+					var hexBytes []string
+					for _, b := range key {
+						hexBytes = append(hexBytes, fmt.Sprintf("%02x", b))
+					}
+					expectedKeys = append(expectedKeys, strings.Join(hexBytes, " "))
+
+					hexCIDR, err := bpf.CidrToHex(c)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(hexCIDR).To(Equal(hexBytes), "CidrToHex disagrees on the key for %s", c)
+				}
+
+				Eventually(func() ([]string, error) {
+					return felixes[srvr].XDPBlocklistKeys("eth0")
+				}, "10s", "500ms").Should(ConsistOf(expectedKeys))
+				expectFailsafePortsOpen(cc)
+			})
 		})
 
 		Context("blocking CIDR", func() {
@@ -491,13 +3498,38 @@ func xdpTest(getInfra infrastructure.InfraFactory, proto string) {
 						"/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist", "key", "hex"}, hostHexCIDR...)
 					Eventually(felixes[srvr].ExecOutputFn(args...), "10s").Should(ContainSubstring("value:"))
 				})
+
+				It("should keep the blocklist in an LPM trie so that CIDRs match by prefix", func() {
+					// A hash map would still match /32 entries, so only the map type
+					// catches a regression to one.
+					Eventually(func() (string, error) {
+						return bpf.MapType(felixes[srvr], "/sys/fs/bpf/calico/xdp/eth0_ipv4_v1_blacklist")
+					}, "10s").Should(Equal("lpm_trie"))
+				})
+
+				It("should program the blocklist into every NUMA node's shard of the map", func() {
+					_, blocked, err := net.ParseCIDR(hostW[clnt].IP + "/8")
+					Expect(err).NotTo(HaveOccurred())
+					Eventually(func() ([][]string, error) {
+						return xdpmaps.BlocklistShardInfo(felixes[srvr], "eth0")
+					}, "10s", "200ms").Should(HaveEach(Equal([]string{blocked.String()})))
+				})
 			}
 
-			It("should have expected no dropped packets in iptables", func() {
-				versionReader, err := environment.GetKernelVersionReader()
+			It("should not block the server's connections to itself", func() {
+				_, blocked, err := net.ParseCIDR(hostW[clnt].IP + "/8")
 				Expect(err).NotTo(HaveOccurred())
+				Expect(blocked.Contains(net.ParseIP(felixes[srvr].IP))).To(BeTrue(),
+					"the blocklisted CIDR should contain the server's own IP")
 
-				kernelVersion, err := environment.GetKernelVersion(versionReader)
+				expectBlocked(cc)
+				cc.ExpectSelf(felixes[srvr], 8055)
+				cc.ExpectSelf(felixes[srvr], 8056)
+				cc.CheckConnectivity()
+			})
+
+			It("should have expected no dropped packets in iptables", func() {
+				kernelVersion, err := felixes[srvr].KernelVersion()
 				Expect(err).NotTo(HaveOccurred())
 
 				if proto == "tcp" && kernelVersion.Compare(environment.MustParseVersion("4.19.0")) < 0 {
@@ -522,5 +3554,69 @@ func xdpTest(getInfra infrastructure.InfraFactory, proto string) {
 				expectFailsafePortsOpen(cc)
 			})
 		})
+
+		Context("blocking one of several IP pools", func() {
+			var (
+				poolCIDRs = []string{"10.67.0.0/24", "10.68.0.0/24"}
+				poolWs    []*workload.Workload
+			)
+
+			BeforeEach(func() {
+				profile := infra.AddDefaultAllow()
+				poolWs = nil
+				for i, cidr := range poolCIDRs {
+					pool, err := infra.CreateIPPool(cidr, infrastructure.IPPoolOptions{})
+					Expect(err).NotTo(HaveOccurred())
+					poolWs = append(poolWs, workload.RunInIPPool(infra, felixes[clnt], pool.Name,
+						fmt.Sprintf("pool%d", i), profile, "8055", proto))
+
+					// We don't run BGP so tell the server how to get back to the pool.
+					felixes[srvr].Exec("ip", "route", "add", cidr, "via", felixes[clnt].IP, "dev", "eth0")
+				}
+
+				applyGlobalNetworkSets("xdpblocklist", poolCIDRs[0], "", false)
+				Eventually(xdpProgramAttached_server_eth0, "10s").Should(BeTrue())
+			})
+
+			AfterEach(func() {
+				for _, w := range poolWs {
+					w.Stop()
+				}
+			})
+
+			It("should only block the workloads in the blocked pool", func() {
+				Expect(poolWs[0].IP).To(HavePrefix("10.67.0."))
+				Expect(poolWs[1].IP).To(HavePrefix("10.68.0."))
+
+				cc.ExpectNone(poolWs[0], hostW[srvr].Port(8055))
+				cc.ExpectSome(poolWs[1], hostW[srvr].Port(8055))
+				cc.ExpectSome(hostW[clnt], hostW[srvr].Port(8055))
+				cc.CheckConnectivity()
+				cc.ResetExpectations()
+
+				By("deleting the blocked pool's workload and pool")
+				poolWs[0].Stop()
+				poolWs[0].RemoveFromInfra(infra)
+				poolWs[0] = nil
+				Expect(infra.DeleteIPPool(infrastructure.IPPoolName(poolCIDRs[0]))).To(Succeed())
+
+				cc.ExpectSome(poolWs[1], hostW[srvr].Port(8055))
+				cc.CheckConnectivity()
+			})
+		})
 	})
 }
+
+// datastoreRevision returns a function, for use as a connectivity.ResultCache's StateVersion,
+// that changes whenever any policy, network set or host endpoint is updated.
+func datastoreRevision(c client.Interface) func() string {
+	return func() string {
+		gnps, err := c.GlobalNetworkPolicies().List(utils.Ctx, options.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		gnss, err := c.GlobalNetworkSets().List(utils.Ctx, options.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		heps, err := c.HostEndpoints().List(utils.Ctx, options.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return strings.Join([]string{gnps.ResourceVersion, gnss.ResourceVersion, heps.ResourceVersion}, "/")
+	}
+}
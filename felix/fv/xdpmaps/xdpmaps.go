@@ -0,0 +1,167 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package xdpmaps has helpers for FV tests that check the XDP blocklist maps that Felix
+// programs.
+package xdpmaps
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/projectcalico/calico/felix/bpf"
+	"github.com/projectcalico/calico/felix/bpf/bpfdefs"
+)
+
+// maxBlocklistShards is the most blocklist map shards that Felix creates per interface; one
+// for each NUMA node, up to four.
+const maxBlocklistShards = 4
+
+var (
+	xdpDir                  = path.Join(bpfdefs.DefaultBPFfsPath, "calico", "xdp")
+	attachedXDPProgIDRegexp = regexp.MustCompile(`prog/xdp id (\d+)`)
+)
+
+// shardName returns the name of the given shard of the IPv4 blocklist map of an interface.
+// Shard 0 is the map that was always there.
+func shardName(iface string, shard int) string {
+	name := iface + "_ipv4_v1_blacklist"
+	if shard == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s_shard%d", name, shard)
+}
+
+// pinnedMaps returns the names of the maps pinned in the XDP directory of the given Felix.
+func pinnedMaps(felix bpf.CommandRunner) (map[string]bool, error) {
+	out, err := felix.ExecOutput("ls", "-1", xdpDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list XDP maps (%s): %w\n%s", xdpDir, err, out)
+	}
+	pinned := map[string]bool{}
+	for _, name := range strings.Fields(out) {
+		pinned[name] = true
+	}
+	return pinned, nil
+}
+
+// BlocklistShardInfo returns the CIDRs in each shard of the blocklist map of the given
+// interface in the given Felix, in shard order.  All the shards should hold the same CIDRs.
+func BlocklistShardInfo(felix bpf.CommandRunner, iface string) ([][]string, error) {
+	pinned, err := pinnedMaps(felix)
+	if err != nil {
+		return nil, err
+	}
+
+	var shards [][]string
+	for shard := 0; shard < maxBlocklistShards; shard++ {
+		name := shardName(iface, shard)
+		if !pinned[name] {
+			break
+		}
+		snapshot, err := bpf.SnapshotMap(felix, path.Join(xdpDir, name))
+		if err != nil {
+			return nil, err
+		}
+		cidrs, err := snapshot.CIDRs()
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, cidrs)
+	}
+	if len(shards) == 0 {
+		return nil, fmt.Errorf("no blocklist map for %s", iface)
+	}
+	return shards, nil
+}
+
+// VerifyMapConsistentAcrossQueues checks that every RX queue of the given interface in the
+// given Felix sees the same blocklist.  The queues all run the interface's one XDP program,
+// but each queue's CPU looks up the blocklist shard of its own NUMA node, so a shard that
+// Felix missed when it reprogrammed the blocklist, or that the program doesn't use, would let
+// some flows through depending on which queue they're hashed to.  It returns an error unless
+// the attached program uses every pinned shard and all the shards hold the same entries.
+func VerifyMapConsistentAcrossQueues(felix bpf.CommandRunner, iface string) error {
+	out, err := felix.ExecOutput("ip", "-o", "link", "show", "dev", iface)
+	if err != nil {
+		return fmt.Errorf("failed to show %s: %w\n%s", iface, err, out)
+	}
+	m := attachedXDPProgIDRegexp.FindStringSubmatch(out)
+	if m == nil {
+		return fmt.Errorf("no XDP program attached to %s", iface)
+	}
+	out, err = felix.ExecOutput("bpftool", "--json", "prog", "show", "id", m[1])
+	if err != nil {
+		return fmt.Errorf("failed to show XDP program %s: %w\n%s", m[1], err, out)
+	}
+	prog := bpf.ProgInfo{}
+	if err := json.Unmarshal([]byte(out), &prog); err != nil {
+		return fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+	}
+	progMaps := map[int]bool{}
+	for _, id := range prog.MapIds {
+		progMaps[id] = true
+	}
+
+	pinned, err := pinnedMaps(felix)
+	if err != nil {
+		return err
+	}
+
+	var first bpf.Snapshot
+	for shard := 0; shard < maxBlocklistShards; shard++ {
+		name := shardName(iface, shard)
+		if !pinned[name] {
+			if shard == 0 {
+				return fmt.Errorf("no blocklist map for %s", iface)
+			}
+			break
+		}
+		mapPath := path.Join(xdpDir, name)
+		out, err := felix.ExecOutput("bpftool", "--json", "map", "show", "pinned", mapPath)
+		if err != nil {
+			return fmt.Errorf("failed to show map (%s): %w\n%s", mapPath, err, out)
+		}
+		info := struct {
+			Id int `json:"id"`
+		}{}
+		if err := json.Unmarshal([]byte(out), &info); err != nil {
+			return fmt.Errorf("cannot parse json output: %w\n%s", err, out)
+		}
+		if !progMaps[info.Id] {
+			return fmt.Errorf("XDP program %d on %s doesn't use blocklist shard %d (map %d)",
+				prog.Id, iface, shard, info.Id)
+		}
+
+		snapshot, err := bpf.SnapshotMap(felix, mapPath)
+		if err != nil {
+			return err
+		}
+		if shard == 0 {
+			first = snapshot
+			continue
+		}
+		if !reflect.DeepEqual(snapshot, first) {
+			firstCIDRs, _ := first.CIDRs()
+			cidrs, _ := snapshot.CIDRs()
+			return fmt.Errorf("blocklist shard %d of %s differs from shard 0: %v != %v",
+				shard, iface, cidrs, firstCIDRs)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xdpmaps
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// fakeRunner emulates the commands that the helpers run.  Every pinned map holds the same
+// entries and is reported as map 1.
+type fakeRunner struct {
+	commands []string
+
+	// entries maps the hex key of each entry to its hex value.
+	entries map[string]string
+	// pinned lists the names of the pinned XDP maps.
+	pinned []string
+	// progMapIDs are the IDs of the maps that the attached program uses.
+	progMapIDs []int
+	// link is the output of "ip link show".
+	link string
+}
+
+func (r *fakeRunner) ExecOutput(args ...string) (string, error) {
+	r.commands = append(r.commands, strings.Join(args, " "))
+	switch {
+	case args[0] == "ls":
+		return strings.Join(r.pinned, "\n"), nil
+	case args[0] == "ip":
+		return r.link, nil
+	case args[2] == "prog" && args[3] == "show":
+		var ids []string
+		for _, id := range r.progMapIDs {
+			ids = append(ids, strconv.Itoa(id))
+		}
+		return fmt.Sprintf(`{"id":7,"type":"xdp","map_ids":[%s]}`, strings.Join(ids, ",")), nil
+	case args[2] == "map" && args[3] == "show":
+		return `{"id":1,"type":"lpm_trie"}`, nil
+	case args[2] == "map" && args[3] == "dump":
+		var dump []string
+		for k, v := range r.entries {
+			dump = append(dump, fmt.Sprintf(`{"key":%s,"value":%s}`, hexJSON(k), hexJSON(v)))
+		}
+		return "[" + strings.Join(dump, ",") + "]", nil
+	}
+	return "", fmt.Errorf("unexpected command %v", args)
+}
+
+func hexJSON(hex string) string {
+	var quoted []string
+	for _, h := range strings.Fields(hex) {
+		quoted = append(quoted, `"0x`+h+`"`)
+	}
+	return "[" + strings.Join(quoted, ",") + "]"
+}
+
+func TestBlocklistShardInfo(t *testing.T) {
+	RegisterTestingT(t)
+
+	runner := &fakeRunner{
+		entries: map[string]string{
+			"20 00 00 00 0a 41 00 02": "01 00 00 00",
+		},
+		pinned: []string{
+			"eth0_ipv4_v1_blacklist",
+			"eth0_ipv4_v1_blacklist_shard1",
+			"eth1_ipv4_v1_blacklist",
+		},
+	}
+	shards, err := BlocklistShardInfo(runner, "eth0")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(shards).To(Equal([][]string{{"10.65.0.2/32"}, {"10.65.0.2/32"}}))
+	Expect(runner.commands[2]).To(HaveSuffix("/xdp/eth0_ipv4_v1_blacklist_shard1"))
+
+	shards, err = BlocklistShardInfo(runner, "eth1")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(shards).To(HaveLen(1))
+
+	_, err = BlocklistShardInfo(runner, "eth2")
+	Expect(err).To(HaveOccurred())
+}
+
+func TestVerifyMapConsistentAcrossQueues(t *testing.T) {
+	RegisterTestingT(t)
+
+	runner := &fakeRunner{
+		entries: map[string]string{
+			"20 00 00 00 0a 41 00 02": "01 00 00 00",
+		},
+		pinned: []string{
+			"eth0_ipv4_v1_blacklist",
+			"eth0_ipv4_v1_blacklist_shard1",
+		},
+		progMapIDs: []int{1},
+		link: `2: eth0@if5: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 xdp qdisc noqueue state UP ` +
+			`\    link/ether 02:42:ac:11:00:02 brd ff:ff:ff:ff:ff:ff link-netnsid 0\    prog/xdp id 7 tag 1234`,
+	}
+	Expect(VerifyMapConsistentAcrossQueues(runner, "eth0")).To(Succeed())
+	Expect(runner.commands[1]).To(Equal("bpftool --json prog show id 7"))
+
+	t.Log("A shard that the program doesn't use should be reported")
+	runner.progMapIDs = []int{2}
+	Expect(VerifyMapConsistentAcrossQueues(runner, "eth0")).To(MatchError(ContainSubstring("shard 0")))
+
+	t.Log("An interface without a program should be reported")
+	runner.link = "3: eth1: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc noqueue state UP"
+	Expect(VerifyMapConsistentAcrossQueues(runner, "eth1")).To(MatchError(ContainSubstring("no XDP program")))
+}
@@ -308,6 +308,26 @@ func (m MatchCriteria) NotICMPV6TypeAndCode(t, c uint8) MatchCriteria {
 	return append(m, fmt.Sprintf("-m icmp6 ! --icmpv6-type %d/%d", t, c))
 }
 
+// TCPSyn matches the first packet of a new TCP connection; i.e. one with SYN set and ACK, RST
+// and FIN clear.  It should be used with a protocol==TCP match.
+func (m MatchCriteria) TCPSyn() MatchCriteria {
+	return append(m, "-m tcp --syn")
+}
+
+// SourceRateAbove matches packets once their source has sent more than ratePerSec matching
+// packets per second (after an initial burst of the same size).  The rate of each source IP
+// is tracked in the hashlimit table with the given name.
+func (m MatchCriteria) SourceRateAbove(name string, ratePerSec int) MatchCriteria {
+	return append(m, fmt.Sprintf("-m hashlimit --hashlimit-above %d/sec --hashlimit-burst %d "+
+		"--hashlimit-mode srcip --hashlimit-name %s", ratePerSec, ratePerSec, name))
+}
+
+// RecordSourceInRecentList always matches; it records the packet's source IP in the xt_recent
+// list with the given name, which can then be read from /proc/net/xt_recent/<name>.
+func (m MatchCriteria) RecordSourceInRecentList(name string) MatchCriteria {
+	return append(m, fmt.Sprintf("-m recent --name %s --set --rsource", name))
+}
+
 // VXLANVNI matches on the VNI contained within the VXLAN header.  It assumes that this is indeed a VXLAN
 // packet; i.e. it should be used with a protocol==UDP and port==VXLAN port match.
 //
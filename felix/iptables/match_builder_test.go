@@ -59,6 +59,10 @@ var _ = DescribeTable("MatchBuilder",
 	Entry("NotMarkMatchesWithMask", Match().NotMarkMatchesWithMask(0x400a, 0xf00f), "-m mark ! --mark 0x400a/0xf00f"),
 	// Conntrack.
 	Entry("ConntrackState", Match().ConntrackState("INVALID"), "-m conntrack --ctstate INVALID"),
+	Entry("TCPSyn", Match().Protocol("tcp").TCPSyn(), "-p tcp -m tcp --syn"),
+	Entry("SourceRateAbove", Match().SourceRateAbove("cali-test", 20),
+		"-m hashlimit --hashlimit-above 20/sec --hashlimit-burst 20 --hashlimit-mode srcip --hashlimit-name cali-test"),
+	Entry("RecordSourceInRecentList", Match().RecordSourceInRecentList("cali-test"), "-m recent --name cali-test --set --rsource"),
 	// Interfaces.
 	Entry("InInterface", Match().InInterface("tap1234abcd"), "--in-interface tap1234abcd"),
 	Entry("OutInterface", Match().OutInterface("tap1234abcd"), "--out-interface tap1234abcd"),
@@ -80,6 +80,10 @@ It has these top-level messages:
 	ServicePort
 	ServiceUpdate
 	ServiceRemove
+	BlocklistReasonUpdate
+	BlocklistReasonRemove
+	BlocklistGraceUpdate
+	BlocklistGraceRemove
 */
 package proto
 
@@ -275,6 +279,10 @@ type ToDataplane struct {
 	//	*ToDataplane_WireguardEndpointV6Remove
 	//	*ToDataplane_HostMetadataV6Update
 	//	*ToDataplane_HostMetadataV6Remove
+	//	*ToDataplane_BlocklistReasonUpdate
+	//	*ToDataplane_BlocklistReasonRemove
+	//	*ToDataplane_BlocklistGraceUpdate
+	//	*ToDataplane_BlocklistGraceRemove
 	Payload isToDataplane_Payload `protobuf_oneof:"payload"`
 }
 
@@ -400,6 +408,18 @@ type ToDataplane_HostMetadataV6Update struct {
 type ToDataplane_HostMetadataV6Remove struct {
 	HostMetadataV6Remove *HostMetadataV6Remove `protobuf:"bytes,36,opt,name=host_metadata_v6_remove,json=hostMetadataV6Remove,oneof"`
 }
+type ToDataplane_BlocklistReasonUpdate struct {
+	BlocklistReasonUpdate *BlocklistReasonUpdate `protobuf:"bytes,39,opt,name=blocklist_reason_update,json=blocklistReasonUpdate,oneof"`
+}
+type ToDataplane_BlocklistReasonRemove struct {
+	BlocklistReasonRemove *BlocklistReasonRemove `protobuf:"bytes,40,opt,name=blocklist_reason_remove,json=blocklistReasonRemove,oneof"`
+}
+type ToDataplane_BlocklistGraceUpdate struct {
+	BlocklistGraceUpdate *BlocklistGraceUpdate `protobuf:"bytes,41,opt,name=blocklist_grace_update,json=blocklistGraceUpdate,oneof"`
+}
+type ToDataplane_BlocklistGraceRemove struct {
+	BlocklistGraceRemove *BlocklistGraceRemove `protobuf:"bytes,42,opt,name=blocklist_grace_remove,json=blocklistGraceRemove,oneof"`
+}
 
 func (*ToDataplane_InSync) isToDataplane_Payload()                    {}
 func (*ToDataplane_IpsetUpdate) isToDataplane_Payload()               {}
@@ -438,6 +458,10 @@ func (*ToDataplane_WireguardEndpointV6Update) isToDataplane_Payload() {}
 func (*ToDataplane_WireguardEndpointV6Remove) isToDataplane_Payload() {}
 func (*ToDataplane_HostMetadataV6Update) isToDataplane_Payload()      {}
 func (*ToDataplane_HostMetadataV6Remove) isToDataplane_Payload()      {}
+func (*ToDataplane_BlocklistReasonUpdate) isToDataplane_Payload()     {}
+func (*ToDataplane_BlocklistReasonRemove) isToDataplane_Payload()     {}
+func (*ToDataplane_BlocklistGraceUpdate) isToDataplane_Payload()      {}
+func (*ToDataplane_BlocklistGraceRemove) isToDataplane_Payload()      {}
 
 func (m *ToDataplane) GetPayload() isToDataplane_Payload {
 	if m != nil {
@@ -712,6 +736,34 @@ func (m *ToDataplane) GetHostMetadataV6Remove() *HostMetadataV6Remove {
 	return nil
 }
 
+func (m *ToDataplane) GetBlocklistReasonUpdate() *BlocklistReasonUpdate {
+	if x, ok := m.GetPayload().(*ToDataplane_BlocklistReasonUpdate); ok {
+		return x.BlocklistReasonUpdate
+	}
+	return nil
+}
+
+func (m *ToDataplane) GetBlocklistReasonRemove() *BlocklistReasonRemove {
+	if x, ok := m.GetPayload().(*ToDataplane_BlocklistReasonRemove); ok {
+		return x.BlocklistReasonRemove
+	}
+	return nil
+}
+
+func (m *ToDataplane) GetBlocklistGraceUpdate() *BlocklistGraceUpdate {
+	if x, ok := m.GetPayload().(*ToDataplane_BlocklistGraceUpdate); ok {
+		return x.BlocklistGraceUpdate
+	}
+	return nil
+}
+
+func (m *ToDataplane) GetBlocklistGraceRemove() *BlocklistGraceRemove {
+	if x, ok := m.GetPayload().(*ToDataplane_BlocklistGraceRemove); ok {
+		return x.BlocklistGraceRemove
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*ToDataplane) XXX_OneofFuncs() (func(msg proto1.Message, b *proto1.Buffer) error, func(msg proto1.Message, tag, wire int, b *proto1.Buffer) (bool, error), func(msg proto1.Message) (n int), []interface{}) {
 	return _ToDataplane_OneofMarshaler, _ToDataplane_OneofUnmarshaler, _ToDataplane_OneofSizer, []interface{}{
@@ -752,6 +804,10 @@ func (*ToDataplane) XXX_OneofFuncs() (func(msg proto1.Message, b *proto1.Buffer)
 		(*ToDataplane_WireguardEndpointV6Remove)(nil),
 		(*ToDataplane_HostMetadataV6Update)(nil),
 		(*ToDataplane_HostMetadataV6Remove)(nil),
+		(*ToDataplane_BlocklistReasonUpdate)(nil),
+		(*ToDataplane_BlocklistReasonRemove)(nil),
+		(*ToDataplane_BlocklistGraceUpdate)(nil),
+		(*ToDataplane_BlocklistGraceRemove)(nil),
 	}
 }
 
@@ -944,6 +1000,26 @@ func _ToDataplane_OneofMarshaler(msg proto1.Message, b *proto1.Buffer) error {
 		if err := b.EncodeMessage(x.HostMetadataV6Remove); err != nil {
 			return err
 		}
+	case *ToDataplane_BlocklistReasonUpdate:
+		_ = b.EncodeVarint(39<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.BlocklistReasonUpdate); err != nil {
+			return err
+		}
+	case *ToDataplane_BlocklistReasonRemove:
+		_ = b.EncodeVarint(40<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.BlocklistReasonRemove); err != nil {
+			return err
+		}
+	case *ToDataplane_BlocklistGraceUpdate:
+		_ = b.EncodeVarint(41<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.BlocklistGraceUpdate); err != nil {
+			return err
+		}
+	case *ToDataplane_BlocklistGraceRemove:
+		_ = b.EncodeVarint(42<<3 | proto1.WireBytes)
+		if err := b.EncodeMessage(x.BlocklistGraceRemove); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("ToDataplane.Payload has unexpected type %T", x)
@@ -1250,6 +1326,38 @@ func _ToDataplane_OneofUnmarshaler(msg proto1.Message, tag, wire int, b *proto1.
 		err := b.DecodeMessage(msg)
 		m.Payload = &ToDataplane_HostMetadataV6Remove{msg}
 		return true, err
+	case 39: // payload.blocklist_reason_update
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(BlocklistReasonUpdate)
+		err := b.DecodeMessage(msg)
+		m.Payload = &ToDataplane_BlocklistReasonUpdate{msg}
+		return true, err
+	case 40: // payload.blocklist_reason_remove
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(BlocklistReasonRemove)
+		err := b.DecodeMessage(msg)
+		m.Payload = &ToDataplane_BlocklistReasonRemove{msg}
+		return true, err
+	case 41: // payload.blocklist_grace_update
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(BlocklistGraceUpdate)
+		err := b.DecodeMessage(msg)
+		m.Payload = &ToDataplane_BlocklistGraceUpdate{msg}
+		return true, err
+	case 42: // payload.blocklist_grace_remove
+		if wire != proto1.WireBytes {
+			return true, proto1.ErrInternalBadWireType
+		}
+		msg := new(BlocklistGraceRemove)
+		err := b.DecodeMessage(msg)
+		m.Payload = &ToDataplane_BlocklistGraceRemove{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -1444,6 +1552,26 @@ func _ToDataplane_OneofSizer(msg proto1.Message) (n int) {
 		n += proto1.SizeVarint(36<<3 | proto1.WireBytes)
 		n += proto1.SizeVarint(uint64(s))
 		n += s
+	case *ToDataplane_BlocklistReasonUpdate:
+		s := proto1.Size(x.BlocklistReasonUpdate)
+		n += proto1.SizeVarint(39<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *ToDataplane_BlocklistReasonRemove:
+		s := proto1.Size(x.BlocklistReasonRemove)
+		n += proto1.SizeVarint(40<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *ToDataplane_BlocklistGraceUpdate:
+		s := proto1.Size(x.BlocklistGraceUpdate)
+		n += proto1.SizeVarint(41<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
+	case *ToDataplane_BlocklistGraceRemove:
+		s := proto1.Size(x.BlocklistGraceRemove)
+		n += proto1.SizeVarint(42<<3 | proto1.WireBytes)
+		n += proto1.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -3112,6 +3240,7 @@ type HostEndpoint struct {
 	ForwardTiers      []*TierInfo `protobuf:"bytes,8,rep,name=forward_tiers,json=forwardTiers" json:"forward_tiers,omitempty"`
 	ExpectedIpv4Addrs []string    `protobuf:"bytes,4,rep,name=expected_ipv4_addrs,json=expectedIpv4Addrs" json:"expected_ipv4_addrs,omitempty"`
 	ExpectedIpv6Addrs []string    `protobuf:"bytes,5,rep,name=expected_ipv6_addrs,json=expectedIpv6Addrs" json:"expected_ipv6_addrs,omitempty"`
+	XdpMode           string      `protobuf:"bytes,9,opt,name=xdp_mode,json=xdpMode,proto3" json:"xdp_mode,omitempty"`
 }
 
 func (m *HostEndpoint) Reset()                    { *m = HostEndpoint{} }
@@ -3175,6 +3304,13 @@ func (m *HostEndpoint) GetExpectedIpv6Addrs() []string {
 	return nil
 }
 
+func (m *HostEndpoint) GetXdpMode() string {
+	if m != nil {
+		return m.XdpMode
+	}
+	return ""
+}
+
 type HostEndpointRemove struct {
 	Id *HostEndpointID `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
 }
@@ -4297,6 +4433,86 @@ func (m *ServiceRemove) GetNamespace() string {
 	return ""
 }
 
+type BlocklistReasonUpdate struct {
+	Cidr   string `protobuf:"bytes,1,opt,name=cidr,proto3" json:"cidr,omitempty"`
+	Reason string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (m *BlocklistReasonUpdate) Reset()         { *m = BlocklistReasonUpdate{} }
+func (m *BlocklistReasonUpdate) String() string { return proto1.CompactTextString(m) }
+func (*BlocklistReasonUpdate) ProtoMessage()    {}
+func (*BlocklistReasonUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptorFelixbackend, []int{70}
+}
+
+func (m *BlocklistReasonUpdate) GetCidr() string {
+	if m != nil {
+		return m.Cidr
+	}
+	return ""
+}
+
+func (m *BlocklistReasonUpdate) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type BlocklistReasonRemove struct {
+	Cidr string `protobuf:"bytes,1,opt,name=cidr,proto3" json:"cidr,omitempty"`
+}
+
+func (m *BlocklistReasonRemove) Reset()         { *m = BlocklistReasonRemove{} }
+func (m *BlocklistReasonRemove) String() string { return proto1.CompactTextString(m) }
+func (*BlocklistReasonRemove) ProtoMessage()    {}
+func (*BlocklistReasonRemove) Descriptor() ([]byte, []int) {
+	return fileDescriptorFelixbackend, []int{71}
+}
+
+func (m *BlocklistReasonRemove) GetCidr() string {
+	if m != nil {
+		return m.Cidr
+	}
+	return ""
+}
+
+type BlocklistGraceUpdate struct {
+	Cidr string `protobuf:"bytes,1,opt,name=cidr,proto3" json:"cidr,omitempty"`
+}
+
+func (m *BlocklistGraceUpdate) Reset()         { *m = BlocklistGraceUpdate{} }
+func (m *BlocklistGraceUpdate) String() string { return proto1.CompactTextString(m) }
+func (*BlocklistGraceUpdate) ProtoMessage()    {}
+func (*BlocklistGraceUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptorFelixbackend, []int{72}
+}
+
+func (m *BlocklistGraceUpdate) GetCidr() string {
+	if m != nil {
+		return m.Cidr
+	}
+	return ""
+}
+
+type BlocklistGraceRemove struct {
+	Cidr string `protobuf:"bytes,1,opt,name=cidr,proto3" json:"cidr,omitempty"`
+}
+
+func (m *BlocklistGraceRemove) Reset()         { *m = BlocklistGraceRemove{} }
+func (m *BlocklistGraceRemove) String() string { return proto1.CompactTextString(m) }
+func (*BlocklistGraceRemove) ProtoMessage()    {}
+func (*BlocklistGraceRemove) Descriptor() ([]byte, []int) {
+	return fileDescriptorFelixbackend, []int{73}
+}
+
+func (m *BlocklistGraceRemove) GetCidr() string {
+	if m != nil {
+		return m.Cidr
+	}
+	return ""
+}
+
 func init() {
 	proto1.RegisterType((*SyncRequest)(nil), "felix.SyncRequest")
 	proto1.RegisterType((*ToDataplane)(nil), "felix.ToDataplane")
@@ -4369,6 +4585,10 @@ func init() {
 	proto1.RegisterType((*ServicePort)(nil), "felix.ServicePort")
 	proto1.RegisterType((*ServiceUpdate)(nil), "felix.ServiceUpdate")
 	proto1.RegisterType((*ServiceRemove)(nil), "felix.ServiceRemove")
+	proto1.RegisterType((*BlocklistReasonUpdate)(nil), "felix.BlocklistReasonUpdate")
+	proto1.RegisterType((*BlocklistReasonRemove)(nil), "felix.BlocklistReasonRemove")
+	proto1.RegisterType((*BlocklistGraceUpdate)(nil), "felix.BlocklistGraceUpdate")
+	proto1.RegisterType((*BlocklistGraceRemove)(nil), "felix.BlocklistGraceRemove")
 	proto1.RegisterEnum("felix.IPVersion", IPVersion_name, IPVersion_value)
 	proto1.RegisterEnum("felix.RouteType", RouteType_name, RouteType_value)
 	proto1.RegisterEnum("felix.IPPoolType", IPPoolType_name, IPPoolType_value)
@@ -5124,6 +5344,70 @@ func (m *ToDataplane_HostMetadataV4V6Remove) MarshalTo(dAtA []byte) (int, error)
 	}
 	return i, nil
 }
+func (m *ToDataplane_BlocklistReasonUpdate) MarshalTo(dAtA []byte) (int, error) {
+	i := 0
+	if m.BlocklistReasonUpdate != nil {
+		dAtA[i] = 0xba
+		i++
+		dAtA[i] = 0x2
+		i++
+		i = encodeVarintFelixbackend(dAtA, i, uint64(m.BlocklistReasonUpdate.Size()))
+		n39, err := m.BlocklistReasonUpdate.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n39
+	}
+	return i, nil
+}
+func (m *ToDataplane_BlocklistReasonRemove) MarshalTo(dAtA []byte) (int, error) {
+	i := 0
+	if m.BlocklistReasonRemove != nil {
+		dAtA[i] = 0xc2
+		i++
+		dAtA[i] = 0x2
+		i++
+		i = encodeVarintFelixbackend(dAtA, i, uint64(m.BlocklistReasonRemove.Size()))
+		n40, err := m.BlocklistReasonRemove.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n40
+	}
+	return i, nil
+}
+func (m *ToDataplane_BlocklistGraceUpdate) MarshalTo(dAtA []byte) (int, error) {
+	i := 0
+	if m.BlocklistGraceUpdate != nil {
+		dAtA[i] = 0xca
+		i++
+		dAtA[i] = 0x2
+		i++
+		i = encodeVarintFelixbackend(dAtA, i, uint64(m.BlocklistGraceUpdate.Size()))
+		n41, err := m.BlocklistGraceUpdate.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n41
+	}
+	return i, nil
+}
+func (m *ToDataplane_BlocklistGraceRemove) MarshalTo(dAtA []byte) (int, error) {
+	i := 0
+	if m.BlocklistGraceRemove != nil {
+		dAtA[i] = 0xd2
+		i++
+		dAtA[i] = 0x2
+		i++
+		i = encodeVarintFelixbackend(dAtA, i, uint64(m.BlocklistGraceRemove.Size()))
+		n42, err := m.BlocklistGraceRemove.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n42
+	}
+	return i, nil
+}
 func (m *FromDataplane) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -6972,6 +7256,12 @@ func (m *HostEndpoint) MarshalTo(dAtA []byte) (int, error) {
 			i += n
 		}
 	}
+	if len(m.XdpMode) > 0 {
+		dAtA[i] = 0x4a
+		i++
+		i = encodeVarintFelixbackend(dAtA, i, uint64(len(m.XdpMode)))
+		i += copy(dAtA[i:], m.XdpMode)
+	}
 	return i, nil
 }
 
@@ -8427,6 +8717,108 @@ func (m *ServiceRemove) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
+func (m *BlocklistReasonUpdate) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BlocklistReasonUpdate) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Cidr) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintFelixbackend(dAtA, i, uint64(len(m.Cidr)))
+		i += copy(dAtA[i:], m.Cidr)
+	}
+	if len(m.Reason) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintFelixbackend(dAtA, i, uint64(len(m.Reason)))
+		i += copy(dAtA[i:], m.Reason)
+	}
+	return i, nil
+}
+
+func (m *BlocklistReasonRemove) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BlocklistReasonRemove) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Cidr) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintFelixbackend(dAtA, i, uint64(len(m.Cidr)))
+		i += copy(dAtA[i:], m.Cidr)
+	}
+	return i, nil
+}
+
+func (m *BlocklistGraceUpdate) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BlocklistGraceUpdate) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Cidr) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintFelixbackend(dAtA, i, uint64(len(m.Cidr)))
+		i += copy(dAtA[i:], m.Cidr)
+	}
+	return i, nil
+}
+
+func (m *BlocklistGraceRemove) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BlocklistGraceRemove) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Cidr) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintFelixbackend(dAtA, i, uint64(len(m.Cidr)))
+		i += copy(dAtA[i:], m.Cidr)
+	}
+	return i, nil
+}
+
 func encodeVarintFelixbackend(dAtA []byte, offset int, v uint64) int {
 	for v >= 1<<7 {
 		dAtA[offset] = uint8(v&0x7f | 0x80)
@@ -8787,19 +9179,55 @@ func (m *ToDataplane_HostMetadataV4V6Remove) Size() (n int) {
 	}
 	return n
 }
-func (m *FromDataplane) Size() (n int) {
+func (m *ToDataplane_BlocklistReasonUpdate) Size() (n int) {
 	var l int
 	_ = l
-	if m.Payload != nil {
-		n += m.Payload.Size()
-	}
-	if m.SequenceNumber != 0 {
-		n += 1 + sovFelixbackend(uint64(m.SequenceNumber))
+	if m.BlocklistReasonUpdate != nil {
+		l = m.BlocklistReasonUpdate.Size()
+		n += 2 + l + sovFelixbackend(uint64(l))
 	}
 	return n
 }
-
-func (m *FromDataplane_ProcessStatusUpdate) Size() (n int) {
+func (m *ToDataplane_BlocklistReasonRemove) Size() (n int) {
+	var l int
+	_ = l
+	if m.BlocklistReasonRemove != nil {
+		l = m.BlocklistReasonRemove.Size()
+		n += 2 + l + sovFelixbackend(uint64(l))
+	}
+	return n
+}
+func (m *ToDataplane_BlocklistGraceUpdate) Size() (n int) {
+	var l int
+	_ = l
+	if m.BlocklistGraceUpdate != nil {
+		l = m.BlocklistGraceUpdate.Size()
+		n += 2 + l + sovFelixbackend(uint64(l))
+	}
+	return n
+}
+func (m *ToDataplane_BlocklistGraceRemove) Size() (n int) {
+	var l int
+	_ = l
+	if m.BlocklistGraceRemove != nil {
+		l = m.BlocklistGraceRemove.Size()
+		n += 2 + l + sovFelixbackend(uint64(l))
+	}
+	return n
+}
+func (m *FromDataplane) Size() (n int) {
+	var l int
+	_ = l
+	if m.Payload != nil {
+		n += m.Payload.Size()
+	}
+	if m.SequenceNumber != 0 {
+		n += 1 + sovFelixbackend(uint64(m.SequenceNumber))
+	}
+	return n
+}
+
+func (m *FromDataplane_ProcessStatusUpdate) Size() (n int) {
 	var l int
 	_ = l
 	if m.ProcessStatusUpdate != nil {
@@ -9596,6 +10024,10 @@ func (m *HostEndpoint) Size() (n int) {
 			n += 1 + l + sovFelixbackend(uint64(l))
 		}
 	}
+	l = len(m.XdpMode)
+	if l > 0 {
+		n += 1 + l + sovFelixbackend(uint64(l))
+	}
 	return n
 }
 
@@ -10223,6 +10655,50 @@ func (m *ServiceRemove) Size() (n int) {
 	return n
 }
 
+func (m *BlocklistReasonUpdate) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Cidr)
+	if l > 0 {
+		n += 1 + l + sovFelixbackend(uint64(l))
+	}
+	l = len(m.Reason)
+	if l > 0 {
+		n += 1 + l + sovFelixbackend(uint64(l))
+	}
+	return n
+}
+
+func (m *BlocklistReasonRemove) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Cidr)
+	if l > 0 {
+		n += 1 + l + sovFelixbackend(uint64(l))
+	}
+	return n
+}
+
+func (m *BlocklistGraceUpdate) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Cidr)
+	if l > 0 {
+		n += 1 + l + sovFelixbackend(uint64(l))
+	}
+	return n
+}
+
+func (m *BlocklistGraceRemove) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Cidr)
+	if l > 0 {
+		n += 1 + l + sovFelixbackend(uint64(l))
+	}
+	return n
+}
+
 func sovFelixbackend(x uint64) (n int) {
 	for {
 		n++
@@ -11518,6 +11994,134 @@ func (m *ToDataplane) Unmarshal(dAtA []byte) error {
 			}
 			m.Payload = &ToDataplane_HostMetadataV4V6Remove{v}
 			iNdEx = postIndex
+		case 39:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlocklistReasonUpdate", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFelixbackend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &BlocklistReasonUpdate{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Payload = &ToDataplane_BlocklistReasonUpdate{v}
+			iNdEx = postIndex
+		case 40:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlocklistReasonRemove", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFelixbackend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &BlocklistReasonRemove{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Payload = &ToDataplane_BlocklistReasonRemove{v}
+			iNdEx = postIndex
+		case 41:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlocklistGraceUpdate", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFelixbackend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &BlocklistGraceUpdate{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Payload = &ToDataplane_BlocklistGraceUpdate{v}
+			iNdEx = postIndex
+		case 42:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlocklistGraceRemove", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFelixbackend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &BlocklistGraceRemove{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Payload = &ToDataplane_BlocklistGraceRemove{v}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipFelixbackend(dAtA[iNdEx:])
@@ -16830,6 +17434,35 @@ func (m *HostEndpoint) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field XdpMode", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFelixbackend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XdpMode = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipFelixbackend(dAtA[iNdEx:])
@@ -21719,6 +22352,351 @@ func (m *ServiceRemove) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *BlocklistReasonUpdate) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowFelixbackend
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BlocklistReasonUpdate: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BlocklistReasonUpdate: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cidr", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFelixbackend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Cidr = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Reason", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFelixbackend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Reason = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipFelixbackend(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BlocklistReasonRemove) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowFelixbackend
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BlocklistReasonRemove: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BlocklistReasonRemove: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cidr", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFelixbackend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Cidr = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipFelixbackend(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BlocklistGraceUpdate) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowFelixbackend
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BlocklistGraceUpdate: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BlocklistGraceUpdate: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cidr", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFelixbackend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Cidr = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipFelixbackend(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BlocklistGraceRemove) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowFelixbackend
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BlocklistGraceRemove: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BlocklistGraceRemove: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cidr", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFelixbackend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Cidr = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipFelixbackend(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthFelixbackend
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipFelixbackend(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -21827,267 +22805,274 @@ var (
 func init() { proto1.RegisterFile("felixbackend.proto", fileDescriptorFelixbackend) }
 
 var fileDescriptorFelixbackend = []byte{
-	// 4177 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x5b, 0xcb, 0x73, 0x1c, 0x49,
-	0x5a, 0x57, 0xb7, 0xa4, 0x56, 0xf7, 0xd7, 0xea, 0x56, 0x3b, 0xf5, 0x6a, 0xc9, 0xb6, 0xec, 0x29,
-	0x8f, 0x77, 0x34, 0x66, 0xc7, 0x63, 0x3c, 0xb2, 0xbc, 0x1e, 0x96, 0xd9, 0x90, 0x25, 0xcd, 0xa8,
-	0x67, 0x6c, 0x49, 0x94, 0x34, 0x1e, 0x66, 0xd9, 0x88, 0xa2, 0x54, 0x95, 0x92, 0x0a, 0x57, 0x57,
-	0xd5, 0x54, 0x65, 0xeb, 0xb1, 0x9c, 0x80, 0x25, 0x02, 0x82, 0x20, 0xe0, 0x40, 0x10, 0xfc, 0x01,
-	0x9c, 0x08, 0xfe, 0x03, 0x0e, 0x9c, 0x88, 0xd8, 0x0d, 0x2e, 0xf0, 0x07, 0x10, 0x41, 0x0c, 0x37,
-	0x6e, 0x1c, 0xb8, 0x13, 0x99, 0xf9, 0x65, 0xd6, 0xa3, 0xab, 0x65, 0x99, 0x19, 0x38, 0xa9, 0xf3,
-	0x7b, 0xfc, 0xf2, 0x97, 0x5f, 0x7d, 0xf9, 0x4e, 0x01, 0x39, 0xa6, 0xbe, 0x77, 0x71, 0x64, 0x3b,
-	0xaf, 0x69, 0xe0, 0x3e, 0x8c, 0xe2, 0x90, 0x85, 0x64, 0x52, 0xc8, 0x8c, 0x16, 0x34, 0x0f, 0x2e,
-	0x03, 0xc7, 0xa4, 0xdf, 0x0c, 0x68, 0xc2, 0x8c, 0x7f, 0x5e, 0x80, 0xe6, 0x61, 0xb8, 0x65, 0x33,
-	0x3b, 0xf2, 0xed, 0x80, 0x92, 0x55, 0x98, 0xf2, 0x02, 0x2b, 0xb9, 0x0c, 0x9c, 0x6e, 0xe5, 0x6e,
-	0x65, 0xb5, 0xf9, 0xb8, 0xf5, 0x50, 0xf8, 0x3d, 0xec, 0x05, 0xdc, 0x6d, 0x67, 0xcc, 0xac, 0x79,
-	0xe2, 0x17, 0x79, 0x0a, 0xd3, 0x5e, 0x94, 0x50, 0x66, 0x0d, 0x22, 0xd7, 0x66, 0xb4, 0x5b, 0x15,
-	0xe6, 0x44, 0x99, 0xef, 0x1f, 0x50, 0xf6, 0xa5, 0xd0, 0xec, 0x8c, 0x99, 0x4d, 0x61, 0x29, 0x8b,
-	0xe4, 0x33, 0x20, 0xd2, 0xd1, 0xa5, 0x3e, 0xb3, 0x95, 0xfb, 0xb8, 0x70, 0x5f, 0xcc, 0xba, 0x6f,
-	0x71, 0xbd, 0xc6, 0xe8, 0x08, 0xa7, 0x8c, 0x2c, 0x65, 0x10, 0xd3, 0x7e, 0x78, 0x46, 0xbb, 0x13,
-	0xc3, 0x0c, 0x4c, 0xa1, 0xd1, 0x0c, 0x64, 0x91, 0xec, 0xc3, 0xbc, 0xed, 0x30, 0xef, 0x8c, 0x5a,
-	0x51, 0x1c, 0x1e, 0x7b, 0x3e, 0x55, 0x24, 0x26, 0x05, 0xc2, 0x32, 0x22, 0x6c, 0x08, 0x9b, 0x7d,
-	0x69, 0xa2, 0x79, 0xcc, 0xda, 0xc3, 0xe2, 0x12, 0x44, 0xe4, 0x54, 0x1b, 0x8d, 0xa8, 0xb9, 0xe5,
-	0x11, 0x91, 0xe3, 0x4b, 0x98, 0x53, 0x88, 0xa1, 0xef, 0x39, 0x97, 0x8a, 0xe2, 0x94, 0x00, 0x5c,
-	0xca, 0x03, 0x0a, 0x0b, 0xcd, 0x90, 0xd8, 0x43, 0xd2, 0x61, 0x38, 0xe4, 0x57, 0x1f, 0x09, 0xa7,
-	0xe9, 0xe5, 0xe0, 0x52, 0x76, 0xa7, 0x61, 0xc2, 0x2c, 0x1a, 0xb8, 0x51, 0xe8, 0x05, 0x3a, 0x09,
-	0x1a, 0x39, 0xb8, 0x9d, 0x30, 0x61, 0xdb, 0x68, 0x91, 0xb2, 0x3b, 0x1d, 0x92, 0x0e, 0xc3, 0x21,
-	0x3b, 0x18, 0x09, 0x97, 0xb2, 0x3b, 0x1d, 0x92, 0x92, 0xaf, 0xa1, 0x7b, 0x1e, 0xc6, 0xaf, 0xfd,
-	0xd0, 0x76, 0x87, 0x18, 0x36, 0x05, 0xe4, 0x6d, 0x84, 0xfc, 0x0a, 0xcd, 0x86, 0x58, 0x2e, 0x9c,
-	0x97, 0x6a, 0xca, 0xa1, 0x91, 0xed, 0xf4, 0x95, 0xd0, 0x9a, 0xf1, 0x10, 0x34, 0xb2, 0xfe, 0x18,
-	0x5a, 0x4e, 0x18, 0x1c, 0x7b, 0x27, 0x8a, 0x6a, 0x4b, 0xe0, 0xcd, 0x22, 0xde, 0xa6, 0xd0, 0x69,
-	0x82, 0xd3, 0x4e, 0xa6, 0xac, 0x03, 0xd8, 0xa7, 0xcc, 0x76, 0xed, 0xb4, 0x57, 0xb5, 0x87, 0x02,
-	0xf8, 0x12, 0x2d, 0xf2, 0xdf, 0x23, 0x2f, 0x25, 0xef, 0xc1, 0x4c, 0xc2, 0x07, 0x88, 0xc0, 0xa1,
-	0x56, 0x30, 0xe8, 0x1f, 0xd1, 0xb8, 0x3b, 0x73, 0xb7, 0xb2, 0x3a, 0x61, 0xb6, 0x95, 0x78, 0x57,
-	0x48, 0xc9, 0x06, 0x74, 0xbc, 0xc8, 0xee, 0x5b, 0x51, 0x18, 0xfa, 0xaa, 0xce, 0x8e, 0xa8, 0x73,
-	0x5e, 0x77, 0xc3, 0x8d, 0x97, 0xfb, 0x61, 0xe8, 0xeb, 0xfa, 0xda, 0xdc, 0x21, 0x95, 0xe4, 0x21,
-	0x30, 0x92, 0x37, 0x4a, 0x21, 0x74, 0x04, 0x35, 0x44, 0x21, 0x1b, 0x75, 0xeb, 0x11, 0x86, 0x8c,
-	0x6c, 0x7d, 0x3e, 0x7d, 0xf2, 0x52, 0x72, 0x00, 0x0b, 0x09, 0x8d, 0xcf, 0x3c, 0x87, 0x5a, 0xb6,
-	0xe3, 0x84, 0x83, 0x34, 0x79, 0x66, 0x05, 0xe0, 0x4d, 0x04, 0x3c, 0x90, 0x46, 0x1b, 0xd2, 0x46,
-	0x37, 0x70, 0x2e, 0x29, 0x91, 0x97, 0x81, 0x22, 0xcb, 0xb9, 0x2b, 0x40, 0x35, 0xcf, 0x02, 0x28,
-	0x32, 0xdd, 0x84, 0x4e, 0x60, 0xf7, 0x69, 0x12, 0xd9, 0x8e, 0x1e, 0xc3, 0xe6, 0x05, 0xdc, 0x02,
-	0xc2, 0xed, 0x2a, 0xb5, 0xa6, 0x37, 0x13, 0xe4, 0x45, 0x79, 0x10, 0xe4, 0xb4, 0x50, 0x0e, 0xa2,
-	0xe9, 0xa4, 0x20, 0xc8, 0xe4, 0x29, 0x4c, 0xc7, 0xe1, 0x80, 0x69, 0x16, 0x8b, 0xb9, 0xb1, 0xd8,
-	0xe4, 0xaa, 0x74, 0x36, 0x88, 0xd3, 0x62, 0xea, 0x88, 0x35, 0x77, 0x87, 0x1d, 0xd3, 0x41, 0x3c,
-	0x4e, 0x8b, 0x64, 0x13, 0x9a, 0x67, 0x8c, 0x46, 0xaa, 0xc2, 0x25, 0xe1, 0x77, 0x17, 0xfd, 0x5e,
-	0xfd, 0xf6, 0x8b, 0x8d, 0xdd, 0xc3, 0x41, 0x10, 0x50, 0x7f, 0xa8, 0x6b, 0x03, 0x77, 0xd3, 0x6d,
-	0x97, 0x20, 0x58, 0xf9, 0xf2, 0x9b, 0x40, 0x34, 0x15, 0x01, 0x82, 0x4c, 0x7e, 0x06, 0x4b, 0xe7,
-	0x5e, 0x4c, 0x4f, 0x06, 0x76, 0x3c, 0x3c, 0xde, 0xdc, 0x14, 0x90, 0x2b, 0x6a, 0x50, 0x50, 0x76,
-	0x43, 0xac, 0x16, 0xcf, 0xcb, 0x55, 0x23, 0xd0, 0x91, 0xf0, 0xad, 0xab, 0xd1, 0x35, 0xdd, 0x61,
-	0x74, 0xe4, 0xfe, 0x15, 0x74, 0x4f, 0xfc, 0xf0, 0xc8, 0xf6, 0xad, 0xa3, 0x93, 0xc8, 0xca, 0x8f,
-	0x3f, 0xb7, 0x05, 0xf8, 0x2d, 0x04, 0xff, 0x4c, 0x98, 0x3d, 0xff, 0x6c, 0xbf, 0x30, 0x10, 0xcd,
-	0x4b, 0xff, 0xe7, 0x27, 0x51, 0x56, 0x41, 0x7e, 0x0c, 0x2d, 0x1a, 0x38, 0x76, 0x94, 0x0c, 0x7c,
-	0x9b, 0x79, 0x61, 0xd0, 0x5d, 0x11, 0x68, 0x73, 0x88, 0xb6, 0x9d, 0xd5, 0xed, 0x8c, 0x99, 0x79,
-	0x63, 0xf2, 0x9b, 0xd0, 0x56, 0xbd, 0x05, 0xc9, 0xdc, 0xc9, 0xb9, 0x63, 0x2f, 0xd1, 0x24, 0x5a,
-	0x49, 0x56, 0x90, 0x75, 0xc7, 0x40, 0xdd, 0x2d, 0x73, 0xd7, 0xe1, 0x51, 0xee, 0x18, 0x14, 0x07,
-	0x6e, 0x95, 0x84, 0xfc, 0x6c, 0x5d, 0x71, 0x79, 0x27, 0x97, 0x26, 0x43, 0x51, 0x7f, 0xb5, 0xae,
-	0x79, 0x2d, 0x9d, 0x8f, 0x52, 0x8e, 0xae, 0x04, 0x19, 0x1b, 0x6f, 0xaa, 0x44, 0xb3, 0x2f, 0xab,
-	0x04, 0x5b, 0x72, 0x08, 0x8b, 0xf9, 0x91, 0x31, 0x6d, 0xc4, 0xbd, 0xdc, 0xb0, 0x93, 0x1d, 0x1c,
-	0x33, 0xfc, 0xe7, 0x4e, 0x4b, 0xe4, 0xa5, 0xa8, 0xc8, 0xfa, 0xdd, 0x2b, 0x50, 0xd3, 0xc1, 0xec,
-	0xb4, 0x44, 0x4e, 0x7e, 0x0a, 0x4b, 0x05, 0xd4, 0xb5, 0x94, 0xed, 0xfd, 0xdc, 0xdc, 0x9a, 0xc3,
-	0x5d, 0xcb, 0xf0, 0x5d, 0xc8, 0x21, 0xaf, 0x9d, 0x29, 0xc6, 0xe5, 0xd8, 0xc8, 0xf9, 0x07, 0x57,
-	0x62, 0xa7, 0xf3, 0x76, 0x11, 0x5b, 0x6a, 0x9e, 0x37, 0x60, 0x2a, 0xb2, 0x2f, 0xf9, 0x84, 0x6e,
-	0xfc, 0xf9, 0x24, 0xb4, 0x3e, 0x8d, 0xc3, 0x7e, 0xba, 0x9e, 0xde, 0x87, 0xf9, 0x28, 0x0e, 0x1d,
-	0x9a, 0x24, 0x56, 0xc2, 0x6c, 0x36, 0x48, 0xf2, 0xeb, 0x5d, 0xb5, 0x30, 0xdc, 0x97, 0x36, 0x07,
-	0xc2, 0x24, 0x5d, 0x6a, 0x46, 0xc3, 0x62, 0xf2, 0xbb, 0x70, 0x33, 0xbf, 0x56, 0xca, 0xe3, 0xca,
-	0x45, 0xf0, 0x9d, 0x92, 0x25, 0x53, 0x01, 0xbc, 0x7b, 0x3a, 0x42, 0x37, 0xb2, 0x06, 0x0c, 0xd7,
-	0xe4, 0x1b, 0x6a, 0xd0, 0x01, 0x2b, 0xa9, 0x01, 0x3f, 0xb5, 0x0f, 0x77, 0x86, 0x57, 0x51, 0xf9,
-	0x76, 0xc8, 0x85, 0xf3, 0xbd, 0x11, 0x8b, 0xa9, 0x42, 0x5b, 0x6e, 0x9d, 0x5f, 0xa1, 0xbf, 0xb2,
-	0x36, 0x6c, 0xd3, 0xd4, 0x35, 0x6a, 0xd3, 0xed, 0x1a, 0x51, 0x1b, 0xb6, 0xad, 0x64, 0xed, 0x54,
-	0x2f, 0x5d, 0x3b, 0xbd, 0x82, 0x74, 0x54, 0x2e, 0x34, 0xbe, 0x91, 0x1b, 0x79, 0x75, 0xdf, 0x2f,
-	0xb4, 0x7a, 0xfe, 0xbc, 0x4c, 0x91, 0xcd, 0xc7, 0x7f, 0xad, 0xc2, 0x74, 0x6e, 0x54, 0x7e, 0x0a,
-	0x35, 0x39, 0xc6, 0x77, 0x2b, 0x77, 0xc7, 0x33, 0x5f, 0x31, 0x6b, 0x84, 0x85, 0xed, 0x80, 0xc5,
-	0x97, 0x26, 0x9a, 0x93, 0xdf, 0x81, 0xb9, 0x24, 0x1c, 0xc4, 0x0e, 0xb5, 0x58, 0x68, 0xc5, 0xf6,
-	0x39, 0x4e, 0x15, 0xdd, 0xaa, 0x80, 0x79, 0x50, 0x06, 0x73, 0x20, 0xec, 0x0f, 0x43, 0xd3, 0x3e,
-	0xcf, 0x22, 0xde, 0x48, 0x8a, 0x72, 0xd2, 0x85, 0xa9, 0x3e, 0x4d, 0x12, 0xfb, 0x44, 0x76, 0x8b,
-	0x86, 0xa9, 0x8a, 0xcb, 0xcf, 0xa0, 0x99, 0xf1, 0x25, 0x1d, 0x18, 0x7f, 0x4d, 0x2f, 0xc5, 0xce,
-	0xb4, 0x61, 0xf2, 0x9f, 0x64, 0x0e, 0x26, 0xcf, 0x6c, 0x7f, 0x20, 0xb7, 0x9f, 0x0d, 0x53, 0x16,
-	0x3e, 0xae, 0xfe, 0xa8, 0xb2, 0xfc, 0x0a, 0x16, 0xca, 0x19, 0x64, 0x51, 0x5a, 0x12, 0xe5, 0x07,
-	0x59, 0x94, 0xe6, 0xe3, 0x8e, 0x5a, 0x7d, 0x28, 0xbf, 0x0c, 0xae, 0xf1, 0x57, 0x15, 0x68, 0xa4,
-	0xd4, 0x17, 0xa0, 0x26, 0xdb, 0x83, 0xa4, 0xb0, 0x44, 0xd6, 0x74, 0xa0, 0x65, 0x84, 0x6e, 0x15,
-	0x21, 0xcb, 0xa2, 0xfc, 0x1d, 0x9a, 0x6b, 0xd4, 0xa1, 0x26, 0xb7, 0xe8, 0xc6, 0xdf, 0x54, 0xa0,
-	0x99, 0xd9, 0x7e, 0x93, 0x36, 0x54, 0x3d, 0x17, 0x41, 0xaa, 0x9e, 0x2b, 0xa3, 0xcd, 0x33, 0x30,
-	0x11, 0xdc, 0x44, 0xb4, 0x45, 0x91, 0x3c, 0x82, 0x09, 0x76, 0x19, 0xc9, 0x8f, 0xd0, 0xd6, 0x94,
-	0x33, 0x58, 0xf2, 0xf7, 0xe1, 0x65, 0x44, 0x4d, 0x61, 0x69, 0x7c, 0x00, 0x0d, 0x2d, 0x22, 0x35,
-	0xa8, 0xf6, 0xf6, 0x3b, 0x63, 0x64, 0x86, 0xd7, 0x6f, 0x6d, 0xec, 0x6e, 0x59, 0xfb, 0x7b, 0xe6,
-	0x61, 0xa7, 0x42, 0xa6, 0x60, 0x7c, 0x77, 0xfb, 0xb0, 0x53, 0x35, 0x22, 0xe8, 0x14, 0x77, 0xf6,
-	0x43, 0xf4, 0xee, 0x41, 0xcb, 0x76, 0x5d, 0xea, 0x5a, 0x79, 0x92, 0xd3, 0x42, 0xf8, 0x12, 0x99,
-	0xbe, 0x07, 0x33, 0xb2, 0xe7, 0xa6, 0x66, 0xe3, 0xc2, 0xac, 0x8d, 0x62, 0x34, 0x34, 0x6e, 0x63,
-	0x2c, 0xb0, 0x73, 0x16, 0x2a, 0x33, 0x6c, 0x98, 0x2d, 0xd9, 0xe5, 0x93, 0xbb, 0xda, 0x2c, 0x4d,
-	0x06, 0xb4, 0xe8, 0x6d, 0x09, 0x96, 0xab, 0x30, 0x85, 0x3b, 0x7d, 0xcc, 0x99, 0x76, 0xde, 0xcc,
-	0x54, 0x6a, 0xe3, 0x69, 0xa1, 0x0a, 0x64, 0xf2, 0xc6, 0x2a, 0x8c, 0x3b, 0xd0, 0xd0, 0x02, 0x42,
-	0x60, 0x82, 0x2f, 0xb9, 0x91, 0xba, 0xf8, 0x6d, 0x84, 0x30, 0x85, 0x06, 0xe4, 0x11, 0xb4, 0xbc,
-	0xe0, 0x28, 0x1c, 0x04, 0xae, 0x15, 0x0f, 0x7c, 0x9a, 0x60, 0xf7, 0x6e, 0xaa, 0xac, 0x1b, 0xf8,
-	0xd4, 0x9c, 0x46, 0x0b, 0x5e, 0x48, 0xc8, 0x63, 0x68, 0x87, 0x03, 0x96, 0x75, 0xa9, 0x0e, 0xbb,
-	0xb4, 0x94, 0x89, 0xf0, 0x31, 0x7e, 0x06, 0x64, 0xf8, 0xc0, 0x81, 0xdc, 0xc9, 0xb4, 0x64, 0x46,
-	0xb5, 0x44, 0x18, 0x60, 0xac, 0xee, 0x43, 0x4d, 0x1e, 0x3a, 0x60, 0xa8, 0x5a, 0x39, 0x23, 0x13,
-	0x95, 0xc6, 0x93, 0x3c, 0x3a, 0xc6, 0xe9, 0x4d, 0xe8, 0xc6, 0x63, 0xa8, 0xab, 0x32, 0x8f, 0x12,
-	0xf3, 0x68, 0xac, 0xa2, 0xc4, 0x7f, 0xeb, 0xc8, 0x55, 0x33, 0x91, 0xfb, 0xa7, 0x0a, 0xd4, 0xa4,
-	0xd3, 0xff, 0x4f, 0xe4, 0xc8, 0x2d, 0x68, 0x0c, 0x02, 0x16, 0xdb, 0xce, 0x6b, 0xea, 0x8a, 0xee,
-	0x55, 0x37, 0x53, 0x01, 0x59, 0x82, 0x7a, 0x14, 0x53, 0xcb, 0x0d, 0x6c, 0x26, 0xe6, 0xef, 0x3a,
-	0xcf, 0x1e, 0xba, 0x15, 0xd8, 0x8c, 0x3b, 0xea, 0xad, 0x96, 0x98, 0x79, 0x1b, 0x66, 0x2a, 0x30,
-	0xfe, 0xae, 0x03, 0x13, 0xbc, 0x02, 0x3e, 0x0c, 0xd9, 0x8e, 0x58, 0x66, 0xe3, 0x30, 0x24, 0x4b,
-	0xe4, 0x43, 0x00, 0x2f, 0xb2, 0xce, 0x68, 0x9c, 0x70, 0x5d, 0x55, 0xf4, 0xeb, 0x8e, 0xee, 0xd7,
-	0xaf, 0xa4, 0xdc, 0x6c, 0x78, 0x11, 0xfe, 0x24, 0xbf, 0xc6, 0xa9, 0x84, 0x2c, 0x74, 0x42, 0x1f,
-	0x97, 0x28, 0x33, 0x69, 0x72, 0x0a, 0xb1, 0xa9, 0x0d, 0xc8, 0x22, 0x4c, 0x25, 0xb1, 0x63, 0x05,
-	0x94, 0xd3, 0x1e, 0x17, 0xa3, 0x5f, 0xec, 0xec, 0x52, 0x46, 0x3e, 0x80, 0x06, 0x57, 0x44, 0x61,
-	0xcc, 0x92, 0xee, 0xa4, 0x88, 0x8e, 0xce, 0xf1, 0x30, 0x66, 0xa6, 0x1d, 0x9c, 0x50, 0xb3, 0x9e,
-	0xc4, 0x0e, 0x2f, 0x25, 0x1c, 0xc7, 0x4d, 0x98, 0xc0, 0xa9, 0x49, 0x1c, 0x37, 0x61, 0x88, 0xc3,
-	0x15, 0x12, 0x67, 0x6a, 0x14, 0x8e, 0x9b, 0x30, 0x89, 0x73, 0x1b, 0x1a, 0x9e, 0xd3, 0x8f, 0x2c,
-	0x31, 0x88, 0xf1, 0x49, 0x77, 0x72, 0x67, 0xcc, 0xac, 0x73, 0x91, 0x18, 0x9f, 0x3e, 0x81, 0xb6,
-	0x56, 0x5b, 0x4e, 0xe8, 0xaa, 0x79, 0x56, 0x6d, 0x73, 0x7b, 0x68, 0xb8, 0x11, 0xb8, 0x9b, 0xa1,
-	0x2b, 0x0e, 0x59, 0x94, 0x2f, 0x2f, 0x93, 0x7b, 0xd0, 0xe6, 0xad, 0xf2, 0x22, 0x2b, 0xa1, 0xcc,
-	0xf2, 0xdc, 0xa4, 0x0b, 0x82, 0x6d, 0x33, 0x89, 0x9d, 0x5e, 0x74, 0x40, 0x59, 0xcf, 0x4d, 0xb8,
-	0x11, 0xa7, 0x9c, 0x31, 0x6a, 0x4a, 0x23, 0x37, 0x61, 0xda, 0xe8, 0x29, 0x2c, 0x89, 0xc0, 0xd9,
-	0x7d, 0xea, 0x8a, 0xd6, 0x65, 0xed, 0xa7, 0x85, 0xfd, 0x1c, 0x0f, 0x25, 0xd7, 0xf3, 0xa6, 0x65,
-	0x1d, 0x45, 0xa4, 0x4a, 0x1d, 0x5b, 0xd2, 0x91, 0xc7, 0x6e, 0xc8, 0xf1, 0x87, 0x30, 0x8b, 0xb4,
-	0x84, 0x97, 0x72, 0x99, 0x11, 0x2e, 0x33, 0x82, 0x1b, 0xb7, 0x47, 0xeb, 0xc7, 0x30, 0x1d, 0x84,
-	0xcc, 0xd2, 0x99, 0x70, 0x5c, 0x9e, 0x09, 0xcd, 0x20, 0x64, 0xaa, 0x40, 0x56, 0x80, 0x17, 0x2d,
-	0x95, 0x10, 0x27, 0x02, 0xb9, 0x11, 0x84, 0xec, 0x40, 0xe6, 0xc4, 0x1a, 0xb4, 0x94, 0x5e, 0x7e,
-	0xcf, 0xd3, 0x11, 0xdf, 0xb3, 0x29, 0x7d, 0xe4, 0x27, 0x45, 0x54, 0x95, 0x1e, 0x9e, 0x46, 0xdd,
-	0x92, 0x19, 0x82, 0xa8, 0x69, 0x96, 0xfc, 0xde, 0x15, 0xa8, 0x5b, 0x2a, 0x51, 0xde, 0x95, 0x5e,
-	0x69, 0xb2, 0xbc, 0x16, 0xc9, 0x52, 0x11, 0x56, 0x2a, 0x0d, 0xc8, 0x36, 0x90, 0x9c, 0x95, 0xcc,
-	0x19, 0xff, 0xca, 0x9c, 0xa9, 0x98, 0x33, 0x19, 0x08, 0x91, 0x36, 0x0f, 0x24, 0x4c, 0x21, 0x75,
-	0xfa, 0x72, 0xba, 0x92, 0x6d, 0xd5, 0x9f, 0x09, 0x6d, 0x0b, 0x19, 0x14, 0x68, 0xdb, 0xad, 0x4c,
-	0x12, 0x7d, 0x02, 0xb7, 0x75, 0xc0, 0x4b, 0xf3, 0x21, 0x12, 0x6e, 0x8b, 0xf8, 0x09, 0x86, 0x52,
-	0x02, 0xfd, 0x47, 0xe7, 0xd3, 0x37, 0xda, 0x7f, 0xab, 0x2c, 0xa5, 0x1e, 0xc3, 0x7c, 0x18, 0x7b,
-	0x27, 0x5e, 0x60, 0xfb, 0x82, 0x44, 0x42, 0x7d, 0xea, 0xb0, 0x30, 0xee, 0xc6, 0x62, 0x08, 0x9a,
-	0x55, 0xca, 0x83, 0xd8, 0x39, 0x40, 0x55, 0xce, 0x87, 0x57, 0xac, 0x7d, 0x92, 0xbc, 0xcf, 0x56,
-	0xc2, 0xb4, 0xcf, 0x36, 0xdc, 0xc9, 0xd5, 0x93, 0x1e, 0x56, 0x69, 0x6f, 0x26, 0xbc, 0x6f, 0x65,
-	0x6a, 0xd4, 0x47, 0x56, 0xa5, 0x30, 0xaa, 0xcd, 0x05, 0x98, 0x41, 0x1e, 0x06, 0x5b, 0x9d, 0x87,
-	0x79, 0x06, 0x4b, 0x1a, 0x46, 0x85, 0x5f, 0x03, 0x9c, 0x09, 0x80, 0x05, 0x65, 0xb0, 0x2b, 0x22,
-	0x3f, 0xd2, 0x35, 0x17, 0x80, 0xf3, 0x21, 0xd7, 0x6c, 0x0c, 0xbe, 0x94, 0x03, 0x46, 0xf1, 0x04,
-	0xb1, 0x6f, 0x33, 0xe7, 0xb4, 0x7b, 0x91, 0xdb, 0x4a, 0xe6, 0x0f, 0x10, 0x5f, 0x72, 0x0b, 0x73,
-	0x21, 0xe1, 0x34, 0x86, 0xe4, 0x1c, 0x56, 0x92, 0x28, 0x83, 0xbd, 0x7c, 0x33, 0xac, 0xcb, 0x29,
-	0x0e, 0xc3, 0x7e, 0x08, 0x70, 0xca, 0x58, 0x84, 0x38, 0x3f, 0xcf, 0xad, 0x71, 0x76, 0x0e, 0x0f,
-	0xf7, 0xa5, 0x77, 0x83, 0xdb, 0x28, 0x87, 0xba, 0xda, 0x99, 0x77, 0x7f, 0x3f, 0x77, 0xea, 0xcd,
-	0x67, 0x37, 0x7d, 0x3c, 0xab, 0x8d, 0xc8, 0xaf, 0xc3, 0x5c, 0x21, 0x8f, 0x04, 0x8b, 0xee, 0x1f,
-	0xca, 0xe9, 0x8f, 0xe4, 0xf2, 0x48, 0xa8, 0xc8, 0x16, 0xac, 0x94, 0xb9, 0xa4, 0x79, 0xd0, 0xfd,
-	0x23, 0xe9, 0x7c, 0x73, 0xd8, 0x59, 0xa7, 0x41, 0xae, 0xe2, 0xcc, 0x17, 0xe9, 0xfe, 0xa2, 0x50,
-	0xf1, 0x81, 0x0e, 0x78, 0xae, 0xe2, 0xec, 0x47, 0x4c, 0x2b, 0xfe, 0xe3, 0x42, 0xc5, 0xa9, 0x73,
-	0x5a, 0x71, 0x17, 0xa6, 0xf8, 0x62, 0xc3, 0xf2, 0xdc, 0xee, 0xaf, 0x70, 0x8e, 0xe7, 0xe5, 0x9e,
-	0xfb, 0xbc, 0x06, 0x13, 0x7c, 0x88, 0x7a, 0x0e, 0x50, 0x57, 0xc3, 0xd5, 0xe7, 0xb5, 0xfa, 0x2f,
-	0x2b, 0x9d, 0x5f, 0x55, 0x4c, 0xf0, 0xc3, 0x13, 0x2b, 0x8a, 0xe9, 0xb1, 0x77, 0x61, 0x7c, 0x06,
-	0xb3, 0x65, 0x1f, 0x6b, 0x19, 0xea, 0x3a, 0x09, 0x25, 0xb0, 0x2e, 0xf3, 0xed, 0x86, 0x60, 0x89,
-	0x6b, 0x70, 0x59, 0x30, 0xfe, 0xb6, 0x02, 0x0d, 0xfd, 0x19, 0xe5, 0x76, 0x82, 0x9d, 0x86, 0xae,
-	0x5c, 0x3a, 0x89, 0xed, 0x84, 0x28, 0x92, 0x47, 0x30, 0x19, 0xd9, 0xec, 0x54, 0xad, 0x8f, 0x96,
-	0x8b, 0x19, 0xf0, 0x70, 0xdf, 0x66, 0xa7, 0x32, 0x17, 0xa4, 0xe1, 0xf2, 0x17, 0xd0, 0xd0, 0x32,
-	0xb2, 0x00, 0x93, 0xf4, 0xc2, 0x76, 0x98, 0x64, 0xb5, 0x33, 0x66, 0xca, 0x22, 0xe9, 0x42, 0x4d,
-	0xb6, 0x48, 0x2e, 0xe9, 0x76, 0xc6, 0x4c, 0x2c, 0x3f, 0x9f, 0x06, 0xe0, 0x38, 0x32, 0xef, 0x8c,
-	0xbf, 0xae, 0xc0, 0x74, 0x36, 0x7d, 0xc8, 0xa7, 0xd0, 0xb4, 0x83, 0x20, 0x64, 0xe2, 0x88, 0x51,
-	0x2d, 0xf4, 0xde, 0x2d, 0x49, 0xb4, 0x87, 0x1b, 0xa9, 0x99, 0xdc, 0xa0, 0x65, 0x1d, 0x97, 0x3f,
-	0x81, 0x4e, 0xd1, 0xe0, 0xad, 0xb6, 0x6a, 0xcf, 0x60, 0xa6, 0x30, 0x6d, 0x88, 0x85, 0x2b, 0x9f,
-	0x87, 0xb8, 0xff, 0xa4, 0xdc, 0x5b, 0x71, 0x99, 0x98, 0x70, 0xaa, 0x52, 0xc6, 0x7f, 0x1b, 0x2f,
-	0xa0, 0xae, 0x27, 0xdc, 0x2e, 0xd4, 0xf0, 0x7c, 0xa1, 0x82, 0x4b, 0x1d, 0x2c, 0x93, 0xb9, 0xec,
-	0x92, 0x77, 0x67, 0x4c, 0x2e, 0x7a, 0x9f, 0x77, 0xa0, 0x2d, 0xf5, 0x56, 0x18, 0x8b, 0xe4, 0x33,
-	0x9e, 0x40, 0x43, 0x4f, 0x90, 0x9c, 0xef, 0xb1, 0x17, 0x27, 0x0c, 0x39, 0xc8, 0x02, 0x27, 0xe1,
-	0xdb, 0x09, 0x53, 0x24, 0xf8, 0x6f, 0xe3, 0x2f, 0x2a, 0x40, 0x8a, 0x47, 0x24, 0xbd, 0x2d, 0xbe,
-	0x27, 0x0b, 0x63, 0xe7, 0x94, 0x26, 0x2c, 0xb6, 0x59, 0x18, 0xf3, 0x4c, 0x95, 0x4d, 0x6f, 0x67,
-	0xc5, 0x3d, 0x97, 0xdc, 0x81, 0xa6, 0x3e, 0x8f, 0xf1, 0x5c, 0xdc, 0xf2, 0x83, 0x12, 0x49, 0x03,
-	0x7d, 0x4e, 0xe3, 0xb9, 0x62, 0x49, 0xdc, 0x30, 0x41, 0x89, 0x7a, 0xee, 0xe7, 0x13, 0xf5, 0x4a,
-	0xa7, 0x6a, 0xd6, 0x4f, 0xc3, 0x84, 0x89, 0x86, 0x5c, 0xc0, 0x42, 0xf9, 0x4d, 0x1e, 0x79, 0x3f,
-	0xb3, 0x7d, 0x58, 0x1a, 0x71, 0xbc, 0x83, 0xdb, 0x94, 0x8f, 0xa0, 0xae, 0xaa, 0xc0, 0x33, 0xae,
-	0xc5, 0x51, 0x57, 0x79, 0xda, 0xd0, 0xf8, 0xef, 0x71, 0xe8, 0x14, 0xd5, 0x3c, 0x94, 0x09, 0xb3,
-	0x99, 0xda, 0xad, 0xc9, 0x42, 0xd9, 0x46, 0x84, 0xa7, 0x4d, 0xdf, 0x76, 0x30, 0x04, 0xfc, 0x27,
-	0x6f, 0xbb, 0xba, 0x42, 0xe6, 0x73, 0xb0, 0x5c, 0x57, 0x03, 0x8a, 0xf8, 0xb4, 0x7b, 0x13, 0x1a,
-	0x5e, 0x74, 0xb6, 0xc6, 0x97, 0x43, 0x72, 0x6d, 0xdd, 0x30, 0xeb, 0x5c, 0xb0, 0x4b, 0x99, 0x52,
-	0xae, 0x4b, 0x65, 0x4d, 0x2b, 0xd7, 0x85, 0xf2, 0x3e, 0x4c, 0xf2, 0x1d, 0x91, 0x5a, 0x49, 0xab,
-	0xe5, 0xdc, 0xa1, 0x47, 0xe3, 0x5e, 0x70, 0x1c, 0x9a, 0x52, 0x4b, 0xde, 0x87, 0xba, 0xac, 0xc0,
-	0x66, 0xdd, 0xba, 0xb0, 0x6c, 0xeb, 0x7b, 0x20, 0x26, 0x0c, 0xa7, 0x44, 0x7d, 0x36, 0x43, 0xd3,
-	0x75, 0x61, 0xda, 0x18, 0x69, 0xba, 0xce, 0x4d, 0x37, 0xe0, 0xb6, 0xed, 0xfb, 0xe1, 0xb9, 0x95,
-	0x44, 0x61, 0x78, 0x4c, 0x5d, 0x0b, 0x8f, 0x93, 0x64, 0xd7, 0xa5, 0x6a, 0x2d, 0xbd, 0x2c, 0x8c,
-	0x0e, 0xa4, 0x8d, 0x3c, 0xbf, 0xd9, 0x47, 0x0b, 0xf2, 0x79, 0xbe, 0xff, 0x36, 0x45, 0x85, 0xab,
-	0x23, 0xbe, 0xd1, 0xff, 0x71, 0x1f, 0xde, 0x1c, 0xce, 0x38, 0xdc, 0xb0, 0x5e, 0x3f, 0xe3, 0x8c,
-	0x0d, 0x68, 0x67, 0x8f, 0x4f, 0x7b, 0x5b, 0xc5, 0xcc, 0xaf, 0xbe, 0x31, 0xf3, 0x7d, 0x20, 0xc3,
-	0xb7, 0xec, 0xe4, 0x7e, 0x86, 0xc3, 0x7c, 0xc9, 0x41, 0x2d, 0x66, 0xfc, 0x87, 0x99, 0x8c, 0x1f,
-	0xcf, 0x4d, 0xbb, 0xb9, 0xab, 0xf6, 0x34, 0xdb, 0xff, 0xab, 0x0a, 0xd3, 0x59, 0x55, 0xd9, 0xb1,
-	0x44, 0x31, 0x83, 0xab, 0x43, 0x19, 0xac, 0xf3, 0x70, 0xfc, 0xca, 0x3c, 0x7c, 0x08, 0xb3, 0xf4,
-	0x22, 0xa2, 0x0e, 0xa3, 0xae, 0x25, 0x12, 0xd2, 0x76, 0xdd, 0x58, 0xf5, 0x88, 0x1b, 0x4a, 0xd5,
-	0x8b, 0xce, 0xd6, 0x36, 0xb8, 0xa2, 0x68, 0xbf, 0x8e, 0xf6, 0x93, 0x43, 0xf6, 0xeb, 0xd2, 0xfe,
-	0x47, 0x30, 0xa3, 0xb7, 0xe0, 0x96, 0x24, 0x54, 0x2b, 0x27, 0xd4, 0xd6, 0x76, 0x87, 0x82, 0xd9,
-	0x13, 0x68, 0xab, 0xfd, 0xba, 0x75, 0x65, 0x8f, 0x9a, 0xc6, 0x6d, 0xbc, 0x74, 0x5b, 0x83, 0xd6,
-	0x71, 0x18, 0x9f, 0xdb, 0xb1, 0xaa, 0xae, 0x3e, 0xc2, 0x0b, 0xad, 0x84, 0x97, 0xf1, 0x1b, 0xf9,
-	0x2f, 0x8c, 0x59, 0x76, 0xbd, 0x2f, 0x6c, 0xc4, 0x50, 0x57, 0xb0, 0xa5, 0xdf, 0xea, 0x7d, 0xe8,
-	0x78, 0xc1, 0x49, 0x4c, 0x93, 0x44, 0xbe, 0x0b, 0xf1, 0xf4, 0x5c, 0x3f, 0x83, 0xf2, 0x7d, 0x14,
-	0xf3, 0xe1, 0x9d, 0x16, 0x2c, 0xf1, 0xc8, 0x8d, 0xe6, 0x0c, 0x8d, 0xa7, 0x30, 0x85, 0xbd, 0x9f,
-	0xcc, 0x43, 0x8d, 0x5e, 0xf0, 0x3d, 0x85, 0x1a, 0x09, 0xe9, 0x05, 0xeb, 0x45, 0x5c, 0x2c, 0x12,
-	0x3c, 0x52, 0xfd, 0x8a, 0x13, 0x8e, 0x0c, 0x13, 0x66, 0x4b, 0xee, 0x41, 0xc8, 0x3d, 0x68, 0x79,
-	0x49, 0x68, 0x31, 0xaf, 0x4f, 0x13, 0x66, 0xf7, 0x15, 0xd6, 0xb4, 0x97, 0x84, 0x87, 0x4a, 0x46,
-	0x16, 0xa0, 0x36, 0x88, 0xb8, 0x89, 0x80, 0xac, 0x98, 0x58, 0x32, 0x22, 0xe8, 0x8e, 0xba, 0x03,
-	0xb9, 0x6e, 0x2f, 0xf9, 0x00, 0x6a, 0xf2, 0x74, 0x1e, 0x8f, 0xaf, 0xe6, 0xf5, 0x15, 0x66, 0xee,
-	0xf4, 0x1f, 0x8d, 0x8c, 0x55, 0x68, 0xe7, 0x35, 0xe2, 0x8c, 0x58, 0x02, 0xa8, 0x33, 0x62, 0x69,
-	0xb9, 0x51, 0xc6, 0xed, 0xed, 0xbe, 0xef, 0x05, 0xdc, 0xba, 0xea, 0x6a, 0xe4, 0x6d, 0xa6, 0xbf,
-	0xb7, 0x6c, 0x66, 0x6f, 0x54, 0xcd, 0x6f, 0x3f, 0x0c, 0x9e, 0xc0, 0x7c, 0xe9, 0x15, 0x07, 0xb9,
-	0x0d, 0x10, 0x0d, 0x8e, 0x7c, 0xcf, 0xb1, 0xd2, 0x71, 0xb9, 0x21, 0x25, 0x5f, 0xd0, 0xcb, 0xb7,
-	0x3e, 0xdc, 0x32, 0xfe, 0xa4, 0x0a, 0x0b, 0xe5, 0x57, 0x87, 0x7c, 0x15, 0xac, 0xc6, 0x54, 0xb5,
-	0x0a, 0x56, 0x65, 0x3d, 0xe3, 0xf2, 0xf1, 0x04, 0x33, 0x56, 0xcc, 0x90, 0x7c, 0x18, 0xd1, 0x33,
-	0xae, 0x50, 0x8e, 0x6b, 0xa5, 0x18, 0x63, 0x38, 0xaa, 0x9d, 0xe0, 0x22, 0x4d, 0xae, 0x62, 0x74,
-	0x99, 0x6c, 0x40, 0xcd, 0xb7, 0x8f, 0xa8, 0xaf, 0x0e, 0xc8, 0xde, 0xbf, 0xf2, 0x6e, 0xf3, 0xe1,
-	0x0b, 0x61, 0x8b, 0xd7, 0x05, 0xd2, 0x71, 0xf9, 0x19, 0x34, 0x33, 0xe2, 0xb7, 0x9a, 0xbf, 0x7e,
-	0x6b, 0x38, 0x12, 0xf8, 0xe1, 0xfe, 0xb7, 0x91, 0x30, 0x5e, 0xca, 0x81, 0xaa, 0xf0, 0x94, 0xe8,
-	0xfb, 0x82, 0xfb, 0xae, 0xec, 0xf6, 0x60, 0xae, 0xec, 0x8e, 0xfb, 0x1a, 0x80, 0xeb, 0x45, 0xc0,
-	0xf5, 0x72, 0xc0, 0x6b, 0x33, 0x1c, 0x01, 0xb8, 0x0d, 0xed, 0xfc, 0x63, 0xa9, 0x92, 0xab, 0x91,
-	0x89, 0x28, 0x0c, 0x7d, 0xec, 0xa0, 0x33, 0xc5, 0xe7, 0x51, 0x42, 0x69, 0xdc, 0x4d, 0x61, 0x46,
-	0x5c, 0x7a, 0xfc, 0x1c, 0xea, 0xca, 0x42, 0x6c, 0x32, 0x3c, 0x57, 0x9f, 0x98, 0xf3, 0xdf, 0x64,
-	0x05, 0xa0, 0x6f, 0x27, 0xdf, 0x0c, 0x68, 0x6c, 0xe3, 0xf6, 0xa3, 0x6e, 0x66, 0x24, 0xb2, 0x15,
-	0x5e, 0x64, 0xf5, 0xf9, 0xee, 0x44, 0xa7, 0xbc, 0x17, 0xbd, 0xe4, 0x3b, 0x99, 0xdb, 0x00, 0x67,
-	0x17, 0xbe, 0x1d, 0x48, 0xad, 0x4c, 0xfa, 0x86, 0x90, 0x70, 0xb5, 0xf1, 0x07, 0x15, 0x68, 0xe5,
-	0xde, 0x7e, 0x90, 0x77, 0x60, 0x5a, 0xa0, 0xd1, 0xc0, 0x3e, 0xf2, 0xa9, 0xe4, 0x59, 0x37, 0x9b,
-	0x5c, 0xb6, 0x2d, 0x45, 0x7c, 0x06, 0x90, 0x98, 0xca, 0x46, 0x72, 0x9a, 0x16, 0x42, 0x65, 0xb4,
-	0x0a, 0x9d, 0x9c, 0x91, 0x75, 0xb6, 0x8e, 0x27, 0xed, 0xed, 0xac, 0xdd, 0xab, 0x75, 0xe3, 0x1f,
-	0x2a, 0x30, 0x57, 0xf6, 0x76, 0x8b, 0xbc, 0x97, 0x19, 0xb3, 0x16, 0x4b, 0xcf, 0x3d, 0x70, 0xac,
-	0xfc, 0x89, 0xee, 0xbb, 0x72, 0x6b, 0xfb, 0xde, 0x15, 0x2f, 0xc2, 0xbe, 0xef, 0x9e, 0xfb, 0x93,
-	0x22, 0x79, 0x7d, 0xef, 0x7c, 0x3d, 0xf2, 0xc6, 0x16, 0x74, 0x8a, 0xf2, 0xfc, 0x35, 0x43, 0xa5,
-	0x70, 0xcd, 0x50, 0x7a, 0x85, 0xf2, 0xf7, 0x15, 0x98, 0x29, 0x3c, 0x2e, 0x23, 0x46, 0x86, 0x02,
-	0x29, 0xbe, 0x1d, 0xc3, 0xd0, 0x7d, 0x5c, 0x08, 0x9d, 0x51, 0xfe, 0x50, 0xed, 0xfb, 0x8e, 0xda,
-	0x93, 0x0c, 0x5b, 0x0c, 0xd8, 0x35, 0xd8, 0x1a, 0xef, 0x40, 0x33, 0x23, 0x2a, 0xbd, 0x85, 0x3b,
-	0x04, 0x90, 0x6f, 0xc4, 0x0e, 0x71, 0xd3, 0xce, 0x33, 0x17, 0xb3, 0x58, 0xfc, 0x16, 0xac, 0x78,
-	0x06, 0x62, 0xda, 0xca, 0x02, 0x0f, 0xb9, 0xbe, 0xbf, 0x57, 0x57, 0x42, 0x5a, 0x60, 0xfc, 0x5b,
-	0x15, 0x9a, 0x99, 0x57, 0x73, 0xe4, 0xdd, 0xcc, 0x01, 0x41, 0x3a, 0xcb, 0x09, 0x8b, 0xf4, 0x3a,
-	0x96, 0x7c, 0xc4, 0xfb, 0x92, 0x7c, 0x49, 0x29, 0xac, 0xe5, 0x9c, 0x78, 0x43, 0x0f, 0x14, 0xbc,
-	0xcb, 0x0b, 0x73, 0xf0, 0x22, 0xf5, 0x9b, 0x87, 0xd1, 0x4d, 0x98, 0xda, 0x83, 0xba, 0x09, 0x23,
-	0x06, 0xb4, 0xc4, 0x09, 0x69, 0xe8, 0xca, 0x53, 0x2a, 0xec, 0xc6, 0x4d, 0x37, 0x61, 0xbb, 0xa1,
-	0x2b, 0x0e, 0xa5, 0xc8, 0x0a, 0x34, 0xb5, 0x8d, 0x17, 0xa9, 0xab, 0x29, 0xb4, 0xe8, 0x45, 0x7c,
-	0x17, 0x90, 0xd8, 0x7d, 0x6a, 0x25, 0x83, 0xa3, 0x80, 0x32, 0xf1, 0xc0, 0xa2, 0x6e, 0x02, 0x17,
-	0x1d, 0x08, 0x09, 0xef, 0xf7, 0x7c, 0xfd, 0x1c, 0x0e, 0xd8, 0x49, 0xe8, 0x05, 0x27, 0xe2, 0xbe,
-	0xa6, 0x6e, 0x36, 0x03, 0x9b, 0xed, 0xa1, 0x88, 0xdc, 0x87, 0xb6, 0x1f, 0x3a, 0xb6, 0x6f, 0xa9,
-	0xb3, 0x01, 0x71, 0x61, 0x53, 0x37, 0x5b, 0x42, 0xaa, 0x56, 0x13, 0xe4, 0x31, 0x34, 0x99, 0xf8,
-	0x02, 0xb2, 0xd1, 0xf2, 0xd1, 0xb0, 0x6a, 0x74, 0xfa, 0x6d, 0x4c, 0x60, 0xfa, 0xb7, 0x71, 0x07,
-	0xc3, 0x8b, 0xb9, 0x80, 0x31, 0xa8, 0xea, 0x18, 0x18, 0xff, 0x59, 0x81, 0xa5, 0x91, 0xaf, 0x08,
-	0x45, 0x22, 0xf0, 0xf1, 0x4d, 0x25, 0x02, 0x1f, 0xf9, 0x70, 0x2f, 0x5f, 0x4d, 0xf7, 0xf2, 0xb9,
-	0x09, 0x69, 0xbc, 0xb0, 0x70, 0x58, 0x85, 0x4e, 0x64, 0xc7, 0x34, 0x60, 0x96, 0x4b, 0xc5, 0x79,
-	0xa0, 0x17, 0x61, 0x9c, 0xdb, 0x52, 0xbe, 0x25, 0xc4, 0x72, 0xb9, 0xdc, 0xb7, 0x1d, 0x3e, 0x9e,
-	0xc9, 0x28, 0x4f, 0xf6, 0x6d, 0xe7, 0xd5, 0x7a, 0x7e, 0x32, 0xa9, 0x15, 0x56, 0x1e, 0x3f, 0x04,
-	0x52, 0x44, 0x3f, 0x5b, 0x17, 0x5f, 0xa1, 0x61, 0x76, 0xf2, 0xf8, 0x67, 0xeb, 0xc6, 0x87, 0xa5,
-	0x6d, 0xc5, 0xd8, 0x94, 0xb4, 0xd5, 0xf8, 0x45, 0x05, 0x16, 0x47, 0xbc, 0x65, 0xbc, 0x72, 0x02,
-	0xcc, 0xaf, 0xe8, 0xaa, 0xc5, 0x15, 0xdd, 0x43, 0x98, 0xf5, 0x02, 0x46, 0xe3, 0x63, 0x5b, 0x32,
-	0xce, 0x85, 0xee, 0x86, 0x56, 0xa9, 0x3d, 0x9f, 0xf1, 0xa4, 0x84, 0xc5, 0x9b, 0xa7, 0x61, 0xe3,
-	0xcf, 0x2a, 0xb0, 0x34, 0xf2, 0xd5, 0xde, 0x95, 0xfc, 0x0d, 0x68, 0xa5, 0xfc, 0xf9, 0x17, 0x91,
-	0x4d, 0x68, 0xea, 0x26, 0xbc, 0x5a, 0x1f, 0x6a, 0xc4, 0xfa, 0xc8, 0x46, 0xc8, 0x79, 0xff, 0x69,
-	0x29, 0x99, 0x6b, 0x34, 0xe3, 0x1f, 0x2b, 0x30, 0x5f, 0xfa, 0x2a, 0x93, 0x3c, 0x86, 0x79, 0x75,
-	0xca, 0xec, 0xf8, 0x83, 0x84, 0xd1, 0xd8, 0xe2, 0x33, 0xbb, 0x3a, 0xa1, 0x9d, 0x45, 0xe5, 0xa6,
-	0xd4, 0x6d, 0x72, 0x15, 0x59, 0x4b, 0x1f, 0x28, 0xd3, 0x0b, 0x46, 0xe3, 0xc0, 0xf6, 0xd1, 0xa9,
-	0x8a, 0x17, 0x92, 0x52, 0xbb, 0x8d, 0x4a, 0xe9, 0xf5, 0x63, 0x58, 0x56, 0x5e, 0xbc, 0x2f, 0x1e,
-	0xd9, 0xbe, 0x1d, 0x38, 0xba, 0x3a, 0xb9, 0x41, 0xec, 0xa2, 0xc5, 0x8b, 0x8c, 0x81, 0xf0, 0x36,
-	0xbe, 0x86, 0x26, 0x4e, 0x45, 0xfb, 0x61, 0xcc, 0x78, 0x63, 0xd5, 0xe9, 0xa6, 0x6a, 0xac, 0x3e,
-	0xed, 0x24, 0x30, 0xc1, 0x6d, 0xd4, 0x41, 0xa4, 0xb2, 0xe7, 0xa3, 0x8d, 0x90, 0x8f, 0x0b, 0xb9,
-	0x2e, 0xf3, 0xfe, 0xdb, 0xca, 0xbd, 0x12, 0x2d, 0xdd, 0xff, 0xe6, 0xe6, 0xbd, 0x6a, 0xc9, 0xbc,
-	0xa7, 0xdf, 0xc3, 0x34, 0x70, 0x88, 0xbd, 0x0d, 0xa0, 0x42, 0xaa, 0x3b, 0x6c, 0x03, 0x25, 0xbd,
-	0x88, 0xef, 0x92, 0x73, 0x71, 0xd0, 0x43, 0x63, 0x3b, 0x2b, 0xee, 0x45, 0x7c, 0xf8, 0xd3, 0x61,
-	0xf6, 0x22, 0x75, 0x58, 0xd7, 0x54, 0xb2, 0x5e, 0x94, 0x90, 0x55, 0x98, 0xcc, 0xde, 0x7c, 0x93,
-	0xfc, 0xa4, 0x2e, 0x4e, 0x6e, 0xa5, 0x81, 0xb1, 0xa1, 0xdb, 0x9a, 0xe9, 0xb3, 0x6f, 0xd5, 0xd6,
-	0x07, 0xab, 0xd0, 0xd0, 0x7b, 0x26, 0x32, 0x05, 0xe3, 0x1b, 0xbb, 0x5f, 0x77, 0xc6, 0x48, 0x1d,
-	0x26, 0x7a, 0xfb, 0xaf, 0xd6, 0x3a, 0x13, 0xf8, 0x6b, 0xbd, 0x53, 0x7b, 0xf0, 0xa7, 0x15, 0x68,
-	0xe8, 0x89, 0x87, 0xb4, 0xa0, 0xb1, 0xd9, 0xdb, 0x32, 0xad, 0xde, 0xee, 0xa7, 0x7b, 0x9d, 0x31,
-	0x32, 0x0b, 0x33, 0xe6, 0xf6, 0xcb, 0xbd, 0xc3, 0x6d, 0xeb, 0xab, 0x3d, 0xf3, 0x8b, 0x17, 0x7b,
-	0x1b, 0x5b, 0x9d, 0x0a, 0x99, 0x81, 0x26, 0x0a, 0x77, 0xf6, 0x0e, 0x0e, 0x3b, 0x55, 0x42, 0xa0,
-	0xfd, 0x62, 0x6f, 0x73, 0xe3, 0x45, 0x6a, 0x34, 0x4e, 0xda, 0x00, 0x52, 0x26, 0x6c, 0x26, 0xc8,
-	0x0d, 0x68, 0xa1, 0xd3, 0xe1, 0x97, 0xbb, 0xbb, 0xdb, 0x2f, 0x3a, 0x93, 0xa4, 0x03, 0xd3, 0xd2,
-	0x04, 0x25, 0xb5, 0x07, 0xcf, 0x00, 0xd2, 0x59, 0x8d, 0x73, 0xdc, 0xdd, 0xdb, 0xdd, 0xee, 0x8c,
-	0x91, 0x69, 0xa8, 0xef, 0xee, 0x59, 0xdb, 0xbb, 0x9b, 0x1b, 0xfb, 0x9d, 0x0a, 0x69, 0xc0, 0xa4,
-	0x18, 0xde, 0x3a, 0x55, 0xd9, 0x8c, 0xde, 0x7e, 0x67, 0xfc, 0xf1, 0x27, 0x00, 0xf2, 0x09, 0x88,
-	0xf8, 0x6f, 0xa6, 0x47, 0x30, 0x21, 0xfe, 0xea, 0x20, 0xa7, 0xff, 0x23, 0xb5, 0xac, 0x64, 0x99,
-	0xff, 0x93, 0x7a, 0x54, 0x79, 0xbe, 0xf8, 0xcb, 0x6f, 0x57, 0x2a, 0xff, 0xf2, 0xed, 0x4a, 0xe5,
-	0xdf, 0xbf, 0x5d, 0xa9, 0xfc, 0xe5, 0x7f, 0xac, 0x8c, 0xfd, 0x74, 0x52, 0xdc, 0x97, 0x1f, 0xd5,
-	0xc4, 0x9f, 0x8f, 0xfe, 0x27, 0x00, 0x00, 0xff, 0xff, 0x20, 0xe4, 0x69, 0xa6, 0x85, 0x35, 0x00,
-	0x00,
+	// 4302 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x5b, 0xcd, 0x73, 0x23, 0x49,
+	0x56, 0xb7, 0x24, 0x4b, 0x96, 0x9e, 0x2c, 0x59, 0x9d, 0xfe, 0x92, 0xdd, 0x6e, 0x77, 0x4f, 0xcd,
+	0xf4, 0xb6, 0xbb, 0x77, 0xa7, 0xa7, 0xe9, 0x71, 0xab, 0x77, 0x86, 0xa5, 0x37, 0xe4, 0x8f, 0x69,
+	0x6b, 0xa6, 0x5b, 0x36, 0x65, 0x8f, 0x87, 0x59, 0x36, 0xa2, 0x28, 0x57, 0x95, 0xed, 0xa2, 0x4b,
+	0x55, 0x35, 0x55, 0x29, 0x7f, 0x2c, 0x27, 0x60, 0x89, 0x80, 0x00, 0x02, 0x0e, 0x04, 0xc1, 0x1f,
+	0x00, 0x17, 0x82, 0xff, 0x80, 0x03, 0x27, 0x22, 0x76, 0x6f, 0xf0, 0x07, 0x10, 0x41, 0x0c, 0x37,
+	0xee, 0xdc, 0x89, 0xfc, 0xac, 0x0f, 0xa5, 0xd4, 0x6e, 0x66, 0xd9, 0x93, 0x95, 0xef, 0xe3, 0x97,
+	0x2f, 0x5f, 0xbd, 0xcc, 0x97, 0xf9, 0x32, 0x0d, 0xe8, 0xd4, 0xf1, 0xdc, 0xab, 0x13, 0xd3, 0x7a,
+	0xe3, 0xf8, 0xf6, 0xe3, 0x30, 0x0a, 0x70, 0x80, 0xca, 0x94, 0xa6, 0x35, 0xa0, 0x7e, 0x78, 0xed,
+	0x5b, 0xba, 0xf3, 0xcd, 0xd0, 0x89, 0xb1, 0xf6, 0x0f, 0x2b, 0x50, 0x3f, 0x0a, 0x76, 0x4c, 0x6c,
+	0x86, 0x9e, 0xe9, 0x3b, 0x68, 0x03, 0x66, 0x5c, 0xdf, 0x88, 0xaf, 0x7d, 0xab, 0x5d, 0xb8, 0x57,
+	0xd8, 0xa8, 0x3f, 0x6d, 0x3c, 0xa6, 0x7a, 0x8f, 0x7b, 0x3e, 0x51, 0xdb, 0x9b, 0xd2, 0x2b, 0x2e,
+	0xfd, 0x85, 0x9e, 0xc3, 0xac, 0x1b, 0xc6, 0x0e, 0x36, 0x86, 0xa1, 0x6d, 0x62, 0xa7, 0x5d, 0xa4,
+	0xe2, 0x48, 0x88, 0x1f, 0x1c, 0x3a, 0xf8, 0x4b, 0xca, 0xd9, 0x9b, 0xd2, 0xeb, 0x54, 0x92, 0x35,
+	0xd1, 0x4b, 0x40, 0x4c, 0xd1, 0x76, 0x3c, 0x6c, 0x0a, 0xf5, 0x12, 0x55, 0x5f, 0x4e, 0xab, 0xef,
+	0x10, 0xbe, 0xc4, 0x68, 0x51, 0xa5, 0x14, 0x2d, 0xb1, 0x20, 0x72, 0x06, 0xc1, 0x85, 0xd3, 0x9e,
+	0x1e, 0xb5, 0x40, 0xa7, 0x1c, 0x69, 0x01, 0x6b, 0xa2, 0x03, 0x58, 0x34, 0x2d, 0xec, 0x5e, 0x38,
+	0x46, 0x18, 0x05, 0xa7, 0xae, 0xe7, 0x08, 0x23, 0xca, 0x14, 0x61, 0x95, 0x23, 0x74, 0xa9, 0xcc,
+	0x01, 0x13, 0x91, 0x76, 0xcc, 0x9b, 0xa3, 0x64, 0x05, 0x22, 0xb7, 0xa9, 0x32, 0x1e, 0x51, 0xda,
+	0x36, 0x6f, 0x8e, 0x92, 0xd1, 0x6b, 0x58, 0x10, 0x88, 0x81, 0xe7, 0x5a, 0xd7, 0xc2, 0xc4, 0x19,
+	0x0a, 0xb8, 0x92, 0x05, 0xa4, 0x12, 0xd2, 0x42, 0x64, 0x8e, 0x50, 0x47, 0xe1, 0xb8, 0x7d, 0xd5,
+	0xb1, 0x70, 0xd2, 0x3c, 0x64, 0x8e, 0x50, 0x09, 0xdc, 0x79, 0x10, 0x63, 0xc3, 0xf1, 0xed, 0x30,
+	0x70, 0x7d, 0x19, 0x04, 0xb5, 0x0c, 0xdc, 0x5e, 0x10, 0xe3, 0x5d, 0x2e, 0x91, 0x58, 0x77, 0x3e,
+	0x42, 0x1d, 0x85, 0xe3, 0xd6, 0xc1, 0x58, 0xb8, 0xc4, 0xba, 0xf3, 0x11, 0x2a, 0xfa, 0x1a, 0xda,
+	0x97, 0x41, 0xf4, 0xc6, 0x0b, 0x4c, 0x7b, 0xc4, 0xc2, 0x3a, 0x85, 0xbc, 0xc3, 0x21, 0xbf, 0xe2,
+	0x62, 0x23, 0x56, 0x2e, 0x5d, 0x2a, 0x39, 0x6a, 0x68, 0x6e, 0xed, 0xec, 0x44, 0x68, 0x69, 0xf1,
+	0xd2, 0xa5, 0x92, 0x83, 0x3e, 0x85, 0x86, 0x15, 0xf8, 0xa7, 0xee, 0x99, 0x30, 0xb5, 0x41, 0xf1,
+	0xe6, 0x39, 0xde, 0x36, 0xe5, 0x49, 0x03, 0x67, 0xad, 0x54, 0x5b, 0x3a, 0x70, 0xe0, 0x60, 0xd3,
+	0x36, 0x93, 0x59, 0xd5, 0x1c, 0x71, 0xe0, 0x6b, 0x2e, 0x91, 0xfd, 0x1e, 0x59, 0x2a, 0x7a, 0x00,
+	0x73, 0x31, 0x59, 0x20, 0x7c, 0xcb, 0x31, 0xfc, 0xe1, 0xe0, 0xc4, 0x89, 0xda, 0x73, 0xf7, 0x0a,
+	0x1b, 0xd3, 0x7a, 0x53, 0x90, 0xfb, 0x94, 0x8a, 0xba, 0xd0, 0x72, 0x43, 0x73, 0x60, 0x84, 0x41,
+	0xe0, 0x89, 0x3e, 0x5b, 0xb4, 0xcf, 0x45, 0x39, 0x0d, 0xbb, 0xaf, 0x0f, 0x82, 0xc0, 0x93, 0xfd,
+	0x35, 0x89, 0x42, 0x42, 0xc9, 0x42, 0x70, 0x4f, 0xde, 0x52, 0x42, 0x48, 0x0f, 0x4a, 0x88, 0x5c,
+	0x34, 0xca, 0xd1, 0x73, 0x18, 0x34, 0x76, 0xf4, 0xd9, 0xf0, 0xc9, 0x52, 0xd1, 0x21, 0x2c, 0xc5,
+	0x4e, 0x74, 0xe1, 0x5a, 0x8e, 0x61, 0x5a, 0x56, 0x30, 0x4c, 0x82, 0x67, 0x9e, 0x02, 0xde, 0xe6,
+	0x80, 0x87, 0x4c, 0xa8, 0xcb, 0x64, 0xe4, 0x00, 0x17, 0x62, 0x05, 0x5d, 0x05, 0xca, 0xad, 0x5c,
+	0x98, 0x00, 0x2a, 0xed, 0x5c, 0x88, 0x15, 0x74, 0xb4, 0x0d, 0x2d, 0xdf, 0x1c, 0x38, 0x71, 0x68,
+	0x5a, 0x72, 0x0d, 0x5b, 0xa4, 0x70, 0x4b, 0x1c, 0xae, 0x2f, 0xd8, 0xd2, 0xbc, 0x39, 0x3f, 0x4b,
+	0xca, 0x82, 0x70, 0x9b, 0x96, 0xd4, 0x20, 0xd2, 0x9c, 0x39, 0x3f, 0x4b, 0x22, 0x6b, 0x71, 0x14,
+	0x0c, 0xb1, 0xb4, 0x62, 0x39, 0xb3, 0x16, 0xeb, 0x84, 0x95, 0x64, 0x83, 0x28, 0x69, 0x26, 0x8a,
+	0xbc, 0xe7, 0xf6, 0xa8, 0x62, 0xb2, 0x88, 0x47, 0x49, 0x13, 0x6d, 0x43, 0xfd, 0x02, 0x3b, 0xa1,
+	0xe8, 0x70, 0x85, 0xea, 0xdd, 0xe3, 0x7a, 0xc7, 0xbf, 0xf3, 0xaa, 0xdb, 0x3f, 0x1a, 0xfa, 0xbe,
+	0xe3, 0x8d, 0x4c, 0x6d, 0x20, 0x6a, 0x72, 0xec, 0x0c, 0x84, 0x77, 0xbe, 0xfa, 0x36, 0x10, 0x69,
+	0x0a, 0x05, 0xe1, 0x96, 0xfc, 0x14, 0x56, 0x2e, 0xdd, 0xc8, 0x39, 0x1b, 0x9a, 0xd1, 0xe8, 0x7a,
+	0x73, 0x9b, 0x42, 0xae, 0x8b, 0x45, 0x41, 0xc8, 0x8d, 0x58, 0xb5, 0x7c, 0xa9, 0x66, 0x8d, 0x41,
+	0xe7, 0x06, 0xaf, 0x4d, 0x46, 0x97, 0xe6, 0x2e, 0x5f, 0xaa, 0x59, 0xe8, 0x2b, 0x68, 0x9f, 0x79,
+	0xc1, 0x89, 0xe9, 0x19, 0x27, 0x67, 0xa1, 0x91, 0x5d, 0x7f, 0xee, 0x50, 0xf0, 0x35, 0x0e, 0xfe,
+	0x92, 0x8a, 0x6d, 0xbd, 0x3c, 0xc8, 0x2d, 0x44, 0x8b, 0x4c, 0x7f, 0xeb, 0x2c, 0x4c, 0x33, 0xd0,
+	0x8f, 0xa0, 0xe1, 0xf8, 0x96, 0x19, 0xc6, 0x43, 0xcf, 0xc4, 0x6e, 0xe0, 0xb7, 0xd7, 0x29, 0xda,
+	0x02, 0x47, 0xdb, 0x4d, 0xf3, 0xf6, 0xa6, 0xf4, 0xac, 0x30, 0xfa, 0x2d, 0x68, 0x8a, 0xd9, 0xc2,
+	0x8d, 0xb9, 0x9b, 0x51, 0xe7, 0xb3, 0x44, 0x1a, 0xd1, 0x88, 0xd3, 0x84, 0xb4, 0x3a, 0x77, 0xd4,
+	0x3d, 0x95, 0xba, 0x74, 0x4f, 0x23, 0x4e, 0x13, 0x90, 0x05, 0x6b, 0x0a, 0x97, 0x5f, 0x74, 0x84,
+	0x2d, 0xef, 0x65, 0xc2, 0x64, 0xc4, 0xeb, 0xc7, 0x1d, 0x69, 0xd7, 0xca, 0xe5, 0x38, 0xe6, 0xf8,
+	0x4e, 0xb8, 0xc5, 0xda, 0xdb, 0x3a, 0x91, 0xd6, 0xaf, 0x5c, 0x8e, 0x63, 0xa2, 0x23, 0x58, 0xce,
+	0xae, 0x8c, 0xc9, 0x20, 0xde, 0xcf, 0x2c, 0x3b, 0xe9, 0xc5, 0x31, 0x65, 0xff, 0xc2, 0xb9, 0x82,
+	0xae, 0x44, 0xe5, 0x56, 0x7f, 0x30, 0x01, 0x35, 0x59, 0xcc, 0xce, 0x15, 0x74, 0x74, 0x0c, 0xcb,
+	0x27, 0x5e, 0x60, 0xbd, 0xf1, 0xdc, 0x98, 0xc4, 0xb7, 0x19, 0x07, 0xbe, 0xb0, 0xf5, 0x41, 0x26,
+	0x12, 0xb7, 0x84, 0x94, 0x4e, 0x85, 0x92, 0x48, 0x3c, 0x51, 0x31, 0x94, 0xb8, 0xdc, 0xda, 0x8d,
+	0x49, 0xb8, 0xd2, 0xdc, 0xc5, 0x13, 0x15, 0x03, 0xfd, 0x04, 0x56, 0x72, 0x5e, 0xd8, 0x4c, 0xbc,
+	0x7b, 0x3f, 0xb3, 0x17, 0xc8, 0xf8, 0x61, 0x33, 0xe5, 0xdf, 0xa5, 0x8c, 0x27, 0x36, 0x2f, 0x84,
+	0x87, 0xd5, 0xd8, 0xdc, 0xea, 0xef, 0x4d, 0xc4, 0x4e, 0xf6, 0x19, 0x79, 0xec, 0x24, 0xbd, 0x25,
+	0xfe, 0x38, 0x8b, 0x52, 0xa9, 0xe3, 0x61, 0xe6, 0xe3, 0x49, 0x77, 0xbc, 0x8c, 0xd2, 0xf9, 0x63,
+	0xe1, 0x44, 0x41, 0x57, 0x81, 0x72, 0x6b, 0x1f, 0x4d, 0x00, 0x4d, 0x22, 0xe2, 0x44, 0x41, 0xdf,
+	0xaa, 0xc1, 0x4c, 0x68, 0x5e, 0x93, 0xad, 0x92, 0xf6, 0x97, 0x65, 0x68, 0x7c, 0x16, 0x05, 0x83,
+	0xe4, 0xa4, 0x72, 0x00, 0x8b, 0x61, 0x14, 0x58, 0x4e, 0x1c, 0x1b, 0x31, 0x36, 0xf1, 0x30, 0xce,
+	0x9e, 0x24, 0xc4, 0x96, 0xfb, 0x80, 0xc9, 0x1c, 0x52, 0x91, 0x64, 0x13, 0x1f, 0x8e, 0x92, 0xd1,
+	0xef, 0xc1, 0xed, 0xec, 0x2e, 0x34, 0x8b, 0xcb, 0x8e, 0x17, 0x77, 0x15, 0x9b, 0xd1, 0x1c, 0x78,
+	0xfb, 0x7c, 0x0c, 0x6f, 0x6c, 0x0f, 0xdc, 0x55, 0xe5, 0xb7, 0xf4, 0x20, 0xdd, 0xd5, 0x3e, 0x1f,
+	0xc3, 0x43, 0x1e, 0xdc, 0x1d, 0xdd, 0x9f, 0x66, 0xc7, 0xc1, 0x8e, 0x24, 0xef, 0x8f, 0xd9, 0xa6,
+	0xe6, 0xc6, 0xb2, 0x76, 0x39, 0x81, 0x3f, 0xb1, 0x37, 0x3e, 0xa6, 0x99, 0x1b, 0xf4, 0x26, 0xc7,
+	0xb5, 0x76, 0x39, 0x81, 0xaf, 0xda, 0x95, 0x56, 0x95, 0xbb, 0xd2, 0x63, 0x48, 0xf2, 0x5d, 0x6e,
+	0xf0, 0xb5, 0xcc, 0x8c, 0x97, 0xab, 0x6a, 0x6e, 0xd4, 0x8b, 0x97, 0x2a, 0x46, 0x3a, 0x1e, 0xff,
+	0xbd, 0x08, 0xb3, 0x99, 0x7c, 0xf7, 0x1c, 0x2a, 0x2c, 0x7b, 0xb6, 0x0b, 0xf7, 0x4a, 0xa9, 0xaf,
+	0x98, 0x16, 0xe2, 0x8d, 0x5d, 0x1f, 0x47, 0xd7, 0x3a, 0x17, 0x47, 0xbf, 0x0b, 0x0b, 0x71, 0x30,
+	0x8c, 0x2c, 0xc7, 0xc0, 0x81, 0x11, 0x99, 0x97, 0x3c, 0x09, 0xb7, 0x8b, 0x14, 0xe6, 0x91, 0x0a,
+	0xe6, 0x90, 0xca, 0x1f, 0x05, 0xba, 0x79, 0x99, 0x46, 0xbc, 0x15, 0xe7, 0xe9, 0xa8, 0x0d, 0x33,
+	0x03, 0x27, 0x8e, 0xcd, 0x33, 0x36, 0x2d, 0x6a, 0xba, 0x68, 0xae, 0x7e, 0x02, 0xf5, 0x94, 0x2e,
+	0x6a, 0x41, 0xe9, 0x8d, 0x73, 0x4d, 0xcf, 0xfc, 0x35, 0x9d, 0xfc, 0x44, 0x0b, 0x50, 0xbe, 0x30,
+	0xbd, 0x21, 0x3b, 0xd8, 0xd7, 0x74, 0xd6, 0xf8, 0xb4, 0xf8, 0xc3, 0xc2, 0xea, 0x31, 0x2c, 0xa9,
+	0x2d, 0x48, 0xa3, 0x34, 0x18, 0xca, 0xf7, 0xd2, 0x28, 0xf5, 0xa7, 0x2d, 0xb1, 0xaf, 0x13, 0x7a,
+	0x29, 0x5c, 0xed, 0x6f, 0x0a, 0x50, 0x4b, 0x4c, 0x5f, 0x82, 0x0a, 0x1b, 0x0f, 0x37, 0x8a, 0xb7,
+	0xd0, 0x26, 0x54, 0x32, 0x1e, 0x5a, 0xcb, 0x43, 0xaa, 0xbc, 0xfc, 0x1d, 0x86, 0xab, 0x55, 0xa1,
+	0xc2, 0x8a, 0x1f, 0xda, 0xdf, 0x15, 0xa0, 0x9e, 0x2a, 0x6c, 0xa0, 0x26, 0x14, 0x5d, 0x9b, 0x83,
+	0x14, 0x5d, 0x9b, 0x79, 0x9b, 0x44, 0x60, 0x4c, 0x6d, 0xab, 0xe9, 0xa2, 0x89, 0x9e, 0xc0, 0x34,
+	0xbe, 0x0e, 0xd9, 0x47, 0x68, 0x4a, 0x93, 0x53, 0x58, 0xec, 0xf7, 0xd1, 0x75, 0xe8, 0xe8, 0x54,
+	0x52, 0xfb, 0x10, 0x6a, 0x92, 0x84, 0x2a, 0x50, 0xec, 0x1d, 0xb4, 0xa6, 0xd0, 0x1c, 0xe9, 0xdf,
+	0xe8, 0xf6, 0x77, 0x8c, 0x83, 0x7d, 0xfd, 0xa8, 0x55, 0x40, 0x33, 0x50, 0xea, 0xef, 0x1e, 0xb5,
+	0x8a, 0x5a, 0x08, 0xad, 0x7c, 0xcd, 0x64, 0xc4, 0xbc, 0xf7, 0xa1, 0x61, 0xda, 0xb6, 0x63, 0x1b,
+	0x59, 0x23, 0x67, 0x29, 0xf1, 0x35, 0xb7, 0xf4, 0x01, 0xcc, 0xb1, 0x99, 0x9b, 0x88, 0x95, 0xa8,
+	0x58, 0x93, 0x93, 0xb9, 0xa0, 0x76, 0x87, 0xfb, 0x82, 0x4f, 0xce, 0x5c, 0x67, 0x9a, 0x09, 0xf3,
+	0x8a, 0xfa, 0x09, 0xba, 0x27, 0xc5, 0x92, 0x60, 0xe0, 0x12, 0xbd, 0x1d, 0x6a, 0xe5, 0x06, 0xcc,
+	0xf0, 0x1a, 0x0a, 0x8f, 0x99, 0x66, 0x56, 0x4c, 0x17, 0x6c, 0xed, 0x79, 0xae, 0x0b, 0x6e, 0xc9,
+	0x5b, 0xbb, 0xd0, 0xee, 0x42, 0x4d, 0x12, 0x10, 0x82, 0x69, 0x72, 0x98, 0xe1, 0xa6, 0xd3, 0xdf,
+	0x5a, 0x00, 0x33, 0x5c, 0x00, 0x3d, 0x81, 0x86, 0xeb, 0x9f, 0x04, 0x43, 0xdf, 0x36, 0xa2, 0xa1,
+	0xe7, 0xc4, 0x7c, 0x7a, 0xd7, 0x45, 0xd4, 0x0d, 0x3d, 0x47, 0x9f, 0xe5, 0x12, 0xa4, 0x11, 0xa3,
+	0xa7, 0xd0, 0x0c, 0x86, 0x38, 0xad, 0x52, 0x1c, 0x55, 0x69, 0x08, 0x11, 0xaa, 0xa3, 0xfd, 0x14,
+	0xd0, 0x68, 0x29, 0x07, 0xdd, 0x4d, 0x8d, 0x64, 0x4e, 0x8c, 0x84, 0x0a, 0x70, 0x5f, 0xdd, 0x87,
+	0x0a, 0x2b, 0xe7, 0xb4, 0x8b, 0x99, 0x62, 0x1d, 0x13, 0xd2, 0x39, 0x53, 0x7b, 0x96, 0x45, 0xe7,
+	0x7e, 0x7a, 0x1b, 0xba, 0xf6, 0x14, 0xaa, 0xa2, 0x4d, 0xbc, 0x84, 0x5d, 0x27, 0x12, 0x5e, 0x22,
+	0xbf, 0xa5, 0xe7, 0x8a, 0x29, 0xcf, 0xfd, 0x6b, 0x01, 0x2a, 0x4c, 0xe9, 0xd7, 0xe3, 0x39, 0xb4,
+	0x06, 0xb5, 0xa1, 0x8f, 0x23, 0x52, 0xea, 0xb4, 0xe9, 0xf4, 0xaa, 0xea, 0x09, 0x01, 0xad, 0x40,
+	0x35, 0x8c, 0x1c, 0xc3, 0xf6, 0x4d, 0x4c, 0xf3, 0x77, 0x95, 0x44, 0x8f, 0xb3, 0xe3, 0x9b, 0x98,
+	0x28, 0xca, 0x43, 0x2c, 0xcd, 0xbc, 0x35, 0x3d, 0x21, 0x68, 0xff, 0xd8, 0x82, 0x69, 0xd2, 0x01,
+	0x59, 0x86, 0x48, 0xfd, 0x2b, 0xf0, 0xc5, 0x32, 0xc4, 0x5a, 0xe8, 0x23, 0x00, 0x37, 0x34, 0x2e,
+	0x9c, 0x28, 0x26, 0xbc, 0x22, 0x9d, 0xd7, 0x2d, 0x39, 0xaf, 0x8f, 0x19, 0x5d, 0xaf, 0xb9, 0x21,
+	0xff, 0x89, 0xbe, 0x4f, 0x4c, 0x09, 0x70, 0x60, 0x05, 0x5e, 0xbb, 0x94, 0x75, 0x3a, 0x27, 0xeb,
+	0x52, 0x00, 0x2d, 0xc3, 0x4c, 0x1c, 0x59, 0x86, 0xef, 0x10, 0xb3, 0x4b, 0x74, 0xf5, 0x8b, 0xac,
+	0xbe, 0x83, 0xd1, 0x87, 0x50, 0x23, 0x8c, 0x30, 0x88, 0x70, 0xdc, 0x2e, 0x53, 0xef, 0xc8, 0x18,
+	0x0f, 0x22, 0xac, 0x9b, 0xfe, 0x99, 0xa3, 0x57, 0xe3, 0xc8, 0x22, 0xad, 0x98, 0xe0, 0xd8, 0x31,
+	0xa6, 0x38, 0x15, 0x86, 0x63, 0xc7, 0x98, 0xe3, 0x10, 0x06, 0xc3, 0x99, 0x19, 0x87, 0x63, 0xc7,
+	0x98, 0xe1, 0xdc, 0x81, 0x9a, 0x6b, 0x0d, 0x42, 0x83, 0x2e, 0x62, 0x24, 0xe9, 0x96, 0xf7, 0xa6,
+	0xf4, 0x2a, 0x21, 0xd1, 0xf5, 0xe9, 0x05, 0x34, 0x25, 0xdb, 0xb0, 0x02, 0x5b, 0xe4, 0x59, 0x51,
+	0x40, 0xe8, 0x71, 0xc1, 0xae, 0x6f, 0x6f, 0x07, 0x36, 0x2d, 0x5f, 0x09, 0x5d, 0xd2, 0x46, 0xef,
+	0x43, 0x93, 0x8c, 0xca, 0x0d, 0x0d, 0x52, 0xce, 0x75, 0xed, 0xb8, 0x0d, 0xd4, 0xda, 0x7a, 0x1c,
+	0x59, 0xbd, 0xf0, 0xd0, 0xc1, 0x3d, 0x3b, 0x26, 0x42, 0xc4, 0xe4, 0x94, 0x50, 0x9d, 0x09, 0xd9,
+	0x31, 0x96, 0x42, 0xcf, 0x61, 0x85, 0x3a, 0xce, 0x1c, 0x38, 0x36, 0x1d, 0x5d, 0x5a, 0x7e, 0x96,
+	0xca, 0x2f, 0x10, 0x57, 0x12, 0x3e, 0x19, 0x5a, 0x5a, 0x91, 0x7a, 0x4a, 0xa9, 0xd8, 0x60, 0x8a,
+	0xc4, 0x77, 0x23, 0x8a, 0x3f, 0x80, 0x79, 0x6e, 0x16, 0xd5, 0x12, 0x2a, 0x73, 0x54, 0x65, 0x8e,
+	0xda, 0x46, 0xe4, 0xb9, 0xf4, 0x53, 0x98, 0xf5, 0x03, 0x6c, 0xc8, 0x48, 0x38, 0x55, 0x47, 0x42,
+	0xdd, 0x0f, 0xb0, 0x68, 0xa0, 0x75, 0x20, 0x4d, 0x43, 0x04, 0xc4, 0x19, 0x45, 0xae, 0xf9, 0x01,
+	0x3e, 0x64, 0x31, 0xb1, 0x09, 0x0d, 0xc1, 0x67, 0xdf, 0xf3, 0x7c, 0xcc, 0xf7, 0xac, 0x33, 0x1d,
+	0xf6, 0x49, 0x39, 0xaa, 0x08, 0x0f, 0x57, 0xa2, 0xee, 0xc4, 0x38, 0x85, 0x9a, 0x44, 0xc9, 0xef,
+	0x4f, 0x40, 0xdd, 0x11, 0x81, 0xf2, 0x01, 0xd3, 0x4a, 0x82, 0xe5, 0x0d, 0x0d, 0x96, 0x02, 0x95,
+	0x12, 0x61, 0x80, 0x76, 0x01, 0x65, 0xa4, 0x58, 0xcc, 0x78, 0x13, 0x63, 0xa6, 0xa0, 0xcf, 0xa5,
+	0x20, 0x08, 0x09, 0x3d, 0x02, 0x24, 0x06, 0x9e, 0xfa, 0x58, 0x03, 0x96, 0xae, 0xd8, 0x58, 0xe5,
+	0x67, 0xe2, 0xb2, 0xb9, 0x08, 0xf2, 0xa5, 0xec, 0x4e, 0x2a, 0x88, 0x5e, 0xc0, 0x1d, 0xe9, 0x70,
+	0x65, 0x3c, 0x84, 0x54, 0x6d, 0x99, 0x7f, 0x82, 0x91, 0x90, 0xe0, 0xfa, 0xe3, 0xe3, 0xe9, 0x1b,
+	0xa9, 0xbf, 0xa3, 0x0a, 0xa9, 0xa7, 0xb0, 0x18, 0x44, 0xee, 0x99, 0xeb, 0x9b, 0x1e, 0x35, 0x22,
+	0x76, 0x3c, 0xc7, 0xc2, 0x41, 0xd4, 0x8e, 0xe8, 0x12, 0x34, 0x2f, 0x98, 0x87, 0x91, 0x75, 0xc8,
+	0x59, 0x19, 0x1d, 0xd2, 0xb1, 0xd4, 0x89, 0xb3, 0x3a, 0x3b, 0x31, 0x96, 0x3a, 0xbb, 0x70, 0x37,
+	0xd3, 0x4f, 0x52, 0x06, 0x94, 0xda, 0x98, 0x6a, 0xaf, 0xa5, 0x7a, 0x94, 0xc5, 0x40, 0x25, 0x8c,
+	0x18, 0x73, 0x0e, 0x66, 0x98, 0x85, 0xe1, 0xa3, 0xce, 0xc2, 0x7c, 0x02, 0x2b, 0x12, 0x46, 0xb8,
+	0x5f, 0x02, 0x5c, 0x50, 0x80, 0x25, 0x21, 0xd0, 0xa7, 0x9e, 0x1f, 0xab, 0x9a, 0x71, 0xc0, 0xe5,
+	0x88, 0x6a, 0xda, 0x07, 0x5f, 0xb2, 0x05, 0x23, 0x5f, 0x9b, 0x1d, 0x98, 0xd8, 0x3a, 0x6f, 0x5f,
+	0x65, 0x8e, 0x92, 0xd9, 0xd2, 0xec, 0x6b, 0x22, 0xa1, 0x2f, 0xc5, 0x91, 0xa5, 0xa0, 0x13, 0x58,
+	0x66, 0x84, 0x0a, 0xf6, 0xfa, 0xed, 0xb0, 0x76, 0x8c, 0x15, 0x74, 0x92, 0x75, 0xce, 0x31, 0x0e,
+	0x39, 0xce, 0xcf, 0x32, 0x7b, 0x9c, 0xbd, 0xa3, 0xa3, 0x03, 0xa6, 0x5d, 0x23, 0x32, 0x42, 0xa1,
+	0x2a, 0x6a, 0x08, 0xed, 0x3f, 0xc8, 0xdc, 0x27, 0x90, 0xec, 0x26, 0x0b, 0xdf, 0x52, 0x08, 0xfd,
+	0x06, 0x2c, 0xe4, 0xe2, 0x88, 0x5a, 0xd1, 0xfe, 0x23, 0x96, 0xfe, 0x50, 0x26, 0x8e, 0x28, 0x0b,
+	0xed, 0xc0, 0xba, 0x4a, 0x25, 0x89, 0x83, 0xf6, 0x1f, 0x33, 0xe5, 0xdb, 0xa3, 0xca, 0x32, 0x0c,
+	0x32, 0x1d, 0xa7, 0xbe, 0x48, 0xfb, 0xe7, 0xb9, 0x8e, 0x0f, 0x23, 0x4b, 0xd5, 0x71, 0xfa, 0x23,
+	0x26, 0x1d, 0xff, 0x49, 0xae, 0xe3, 0x44, 0x39, 0xe9, 0xb8, 0x0d, 0x33, 0x64, 0xb3, 0x61, 0xb8,
+	0x76, 0xfb, 0x97, 0x3c, 0xc7, 0x93, 0x76, 0xcf, 0xde, 0xaa, 0xc0, 0x34, 0x59, 0xa2, 0xb6, 0x00,
+	0xaa, 0x62, 0xb9, 0xfa, 0xbc, 0x52, 0xfd, 0x45, 0xa1, 0xf5, 0xcb, 0x82, 0x0e, 0x5e, 0x70, 0x66,
+	0x84, 0x91, 0x73, 0xea, 0x5e, 0x69, 0x2f, 0x61, 0x5e, 0xf5, 0xb1, 0x56, 0xa1, 0x2a, 0x83, 0x90,
+	0x01, 0xcb, 0x36, 0x39, 0x6e, 0x50, 0x2b, 0xf9, 0x1e, 0x9c, 0x35, 0xb4, 0xbf, 0x2f, 0x40, 0x4d,
+	0x7e, 0x46, 0x76, 0x9c, 0xc0, 0xe7, 0x81, 0xcd, 0xb6, 0x4e, 0x35, 0x5d, 0x34, 0xd1, 0x13, 0x28,
+	0x87, 0x26, 0x3e, 0x17, 0xfb, 0xa3, 0xd5, 0x7c, 0x04, 0x3c, 0x3e, 0x30, 0xf1, 0x39, 0xfd, 0xa5,
+	0x33, 0xc1, 0xd5, 0x2f, 0xa0, 0x26, 0x69, 0x68, 0x09, 0xca, 0xce, 0x95, 0x69, 0x61, 0x66, 0xd5,
+	0xde, 0x94, 0xce, 0x9a, 0xa8, 0x0d, 0x15, 0x36, 0x22, 0xb6, 0xa5, 0x23, 0x97, 0xbd, 0xac, 0xbd,
+	0x35, 0x0b, 0x40, 0x70, 0x58, 0xdc, 0x69, 0x7f, 0x5b, 0x80, 0xd9, 0x74, 0xf8, 0xa0, 0xcf, 0xa0,
+	0x6e, 0xfa, 0x7e, 0x80, 0x69, 0xf1, 0x56, 0x6c, 0xf4, 0x3e, 0x50, 0x04, 0xda, 0xe3, 0x6e, 0x22,
+	0xc6, 0x0e, 0x68, 0x69, 0xc5, 0xd5, 0x17, 0xd0, 0xca, 0x0b, 0xbc, 0xd3, 0x51, 0xed, 0x13, 0x98,
+	0xcb, 0xa5, 0x0d, 0xba, 0x71, 0x25, 0x79, 0x88, 0xe8, 0x97, 0xd9, 0xd9, 0x8a, 0xd0, 0x68, 0xc2,
+	0x29, 0x32, 0x1a, 0xf9, 0xad, 0xbd, 0x82, 0xaa, 0x4c, 0xb8, 0x6d, 0xa8, 0xf0, 0xfa, 0x42, 0x81,
+	0x6f, 0x75, 0x78, 0x1b, 0x2d, 0xa4, 0xb7, 0xbc, 0x7b, 0x53, 0x6c, 0xd3, 0xbb, 0xd5, 0x82, 0x26,
+	0xe3, 0x1b, 0x41, 0x44, 0x83, 0x4f, 0x7b, 0x06, 0x35, 0x99, 0x20, 0x89, 0xbd, 0xa7, 0x6e, 0x14,
+	0x63, 0x6e, 0x03, 0x6b, 0x10, 0x23, 0x3c, 0x33, 0xc6, 0xc2, 0x08, 0xf2, 0x5b, 0xfb, 0xab, 0x02,
+	0xa0, 0x7c, 0x89, 0xa4, 0xb7, 0x43, 0xce, 0x64, 0x41, 0x64, 0x9d, 0x3b, 0x31, 0x8e, 0x4c, 0x1c,
+	0x44, 0x24, 0x52, 0xd9, 0xd0, 0x9b, 0x69, 0x72, 0xcf, 0x46, 0x77, 0xa1, 0x2e, 0xeb, 0x31, 0xae,
+	0xcd, 0x8f, 0xfc, 0x20, 0x48, 0x4c, 0x40, 0xd6, 0x69, 0x5c, 0x9b, 0x6e, 0x89, 0x6b, 0x3a, 0x08,
+	0x52, 0xcf, 0xfe, 0x7c, 0xba, 0x5a, 0x68, 0x15, 0xf5, 0x2a, 0xa9, 0x2f, 0xd1, 0x81, 0x5c, 0xc1,
+	0x92, 0xfa, 0x8e, 0x14, 0x3d, 0x4c, 0x1d, 0x1f, 0x56, 0xc6, 0x94, 0x77, 0xf8, 0x31, 0xe5, 0x63,
+	0xa8, 0x8a, 0x2e, 0xda, 0xe5, 0xcc, 0x3d, 0x7f, 0x5e, 0x41, 0x97, 0x82, 0xda, 0xff, 0x94, 0xa0,
+	0x95, 0x67, 0x13, 0x57, 0xc6, 0x98, 0xd4, 0x71, 0x58, 0x38, 0xb0, 0x86, 0xea, 0x20, 0x42, 0xc2,
+	0x66, 0x60, 0x5a, 0xdc, 0x05, 0xe4, 0x27, 0x19, 0xbb, 0xb8, 0x9c, 0x27, 0x39, 0x98, 0xed, 0xab,
+	0x81, 0x93, 0x48, 0xda, 0xbd, 0x0d, 0x35, 0x37, 0xbc, 0xd8, 0x24, 0xdb, 0x21, 0xb6, 0xb7, 0xae,
+	0xe9, 0x55, 0x42, 0xe8, 0x3b, 0x58, 0x30, 0x3b, 0x8c, 0x59, 0x91, 0xcc, 0x0e, 0x65, 0xde, 0x87,
+	0x32, 0x39, 0x11, 0x89, 0x9d, 0xb4, 0xd8, 0xce, 0x1d, 0xb9, 0x4e, 0xd4, 0xf3, 0x4f, 0x03, 0x9d,
+	0x71, 0xd1, 0x43, 0xa8, 0xb2, 0x0e, 0x4c, 0xdc, 0xae, 0xde, 0x2b, 0xa5, 0xce, 0xb6, 0x7d, 0x13,
+	0x53, 0xc1, 0x19, 0xda, 0x9f, 0x89, 0xb9, 0x68, 0x87, 0x8a, 0xd6, 0xc6, 0x8a, 0x76, 0x88, 0x68,
+	0x17, 0xee, 0x98, 0x9e, 0x17, 0x5c, 0x1a, 0x71, 0x18, 0x04, 0xa7, 0x8e, 0x6d, 0xf0, 0x72, 0x12,
+	0x9b, 0xba, 0x8e, 0xd8, 0x4b, 0xaf, 0x52, 0xa1, 0x43, 0x26, 0xc3, 0xea, 0x37, 0x07, 0x5c, 0x02,
+	0x7d, 0x9e, 0x9d, 0xbf, 0x75, 0xda, 0xe1, 0xc6, 0x98, 0x6f, 0xf4, 0xff, 0x3c, 0x87, 0xb7, 0x47,
+	0x23, 0x8e, 0x1f, 0x58, 0x6f, 0x1e, 0x71, 0x5a, 0x17, 0x9a, 0xe9, 0xf2, 0x69, 0x6f, 0x27, 0x1f,
+	0xf9, 0xc5, 0xb7, 0x46, 0xbe, 0x07, 0x68, 0xf4, 0xfd, 0x02, 0xba, 0x9f, 0xb2, 0x61, 0x51, 0x51,
+	0xa8, 0xe5, 0x11, 0xff, 0x51, 0x2a, 0xe2, 0x4b, 0x99, 0xb4, 0x9b, 0x16, 0x4e, 0x45, 0xfb, 0x5f,
+	0x94, 0x60, 0x36, 0xcd, 0x52, 0x95, 0x25, 0xf2, 0x11, 0x5c, 0x1c, 0x89, 0x60, 0x19, 0x87, 0xa5,
+	0x89, 0x71, 0xf8, 0x18, 0xe6, 0x9d, 0xab, 0xd0, 0xb1, 0xb0, 0x63, 0x1b, 0x34, 0x20, 0x4d, 0xdb,
+	0x8e, 0xc4, 0x8c, 0xb8, 0x25, 0x58, 0xbd, 0xf0, 0x62, 0xb3, 0x6b, 0xdb, 0xa3, 0xf2, 0x1d, 0x2e,
+	0x5f, 0x1e, 0x91, 0xef, 0x30, 0xf9, 0x1f, 0xc2, 0x9c, 0x3c, 0x82, 0x1b, 0xcc, 0xa0, 0x8a, 0xda,
+	0xa0, 0xa6, 0x94, 0x3b, 0xa2, 0x96, 0x3d, 0x83, 0xa6, 0x38, 0xaf, 0x1b, 0x13, 0x67, 0xd4, 0x2c,
+	0x3f, 0xc6, 0x33, 0xb5, 0x4d, 0x68, 0x9c, 0x06, 0xd1, 0x25, 0x29, 0xf7, 0x32, 0xad, 0xea, 0x18,
+	0x2d, 0x2e, 0xc5, 0xb4, 0x56, 0xa0, 0x7a, 0x65, 0x87, 0xc6, 0x40, 0x9c, 0x57, 0x6b, 0xfa, 0xcc,
+	0x95, 0x1d, 0xbe, 0x26, 0xd9, 0xe0, 0x37, 0xb3, 0x1f, 0x9f, 0x07, 0xe0, 0xcd, 0x3e, 0xbe, 0x16,
+	0x41, 0x55, 0xf4, 0xa8, 0xfc, 0x8c, 0x0f, 0xa1, 0xe5, 0xfa, 0x67, 0x11, 0xb9, 0xb9, 0xa0, 0x05,
+	0x1a, 0x57, 0x6e, 0x03, 0xe6, 0x38, 0xfd, 0x80, 0x93, 0xc9, 0xca, 0xef, 0xe4, 0x24, 0x79, 0x35,
+	0xce, 0xc9, 0x08, 0x6a, 0xcf, 0x61, 0x86, 0x2f, 0x0c, 0x68, 0x11, 0x2a, 0xce, 0x15, 0x39, 0x6e,
+	0x88, 0x45, 0xd2, 0xb9, 0xc2, 0xbd, 0x90, 0x90, 0x69, 0xec, 0x87, 0x62, 0xca, 0x11, 0x83, 0x43,
+	0x4d, 0x87, 0x79, 0xc5, 0x15, 0x09, 0xa9, 0x15, 0xba, 0x71, 0x60, 0x60, 0x77, 0xe0, 0xc4, 0xd8,
+	0x1c, 0x08, 0xac, 0x59, 0x37, 0x0e, 0x8e, 0x04, 0x8d, 0xd4, 0x46, 0x86, 0x21, 0x11, 0xa1, 0x90,
+	0x05, 0x9d, 0xb7, 0xb4, 0x10, 0xda, 0xe3, 0xae, 0x47, 0x6e, 0x3a, 0x81, 0x3e, 0x84, 0x0a, 0x2b,
+	0xdc, 0xb7, 0x8b, 0x19, 0xd1, 0x2c, 0xa6, 0xce, 0x85, 0xb4, 0x0d, 0x68, 0x66, 0x39, 0xc4, 0x36,
+	0x0e, 0x20, 0xca, 0xc7, 0x4c, 0xb2, 0xab, 0xb2, 0xed, 0xdd, 0xbe, 0xef, 0x15, 0xac, 0x4d, 0xba,
+	0x35, 0x79, 0x97, 0xcc, 0xf8, 0x8e, 0xc3, 0xec, 0x8d, 0xeb, 0xf9, 0xdd, 0x57, 0xc8, 0x33, 0x58,
+	0x54, 0xde, 0x7e, 0xa0, 0x3b, 0x00, 0xe1, 0xf0, 0xc4, 0x73, 0x2d, 0x23, 0x59, 0xb2, 0x6b, 0x8c,
+	0xf2, 0x85, 0x73, 0xfd, 0xce, 0x75, 0x2f, 0xed, 0x4f, 0x8b, 0xb0, 0xa4, 0xbe, 0xff, 0x24, 0x1b,
+	0x64, 0xb1, 0xdc, 0x8a, 0x0d, 0xb2, 0x68, 0xcb, 0x64, 0x4c, 0x96, 0x1a, 0x1e, 0xb1, 0x34, 0x79,
+	0x92, 0x15, 0x46, 0x26, 0x63, 0xca, 0x2c, 0x49, 0x26, 0x5d, 0x7e, 0x08, 0xaa, 0x19, 0xf3, 0xfd,
+	0x1b, 0xdb, 0xe0, 0xc8, 0x36, 0xea, 0x42, 0xc5, 0x33, 0x4f, 0x1c, 0x4f, 0xd4, 0xce, 0x1e, 0x4e,
+	0xbc, 0xa0, 0x7d, 0xfc, 0x8a, 0xca, 0xf2, 0x9b, 0x04, 0xa6, 0x48, 0x6e, 0x12, 0x52, 0xe4, 0x77,
+	0x4a, 0x6d, 0xbf, 0x3d, 0xea, 0x09, 0xfe, 0xe1, 0xfe, 0xaf, 0x9e, 0xd0, 0x5e, 0x03, 0x4a, 0x43,
+	0x7e, 0x47, 0xc7, 0xe6, 0xe1, 0xbe, 0xab, 0x75, 0xfb, 0xb0, 0xa0, 0x7a, 0x58, 0x70, 0x03, 0xc0,
+	0x4e, 0x1e, 0xb0, 0xa3, 0x06, 0xbc, 0xb1, 0x85, 0x63, 0x00, 0x77, 0xa1, 0x99, 0x7d, 0xa1, 0xa6,
+	0xb8, 0x35, 0x99, 0x0e, 0x83, 0xc0, 0xe3, 0x13, 0x74, 0x2e, 0xff, 0x26, 0x8d, 0x32, 0xb5, 0x7b,
+	0x09, 0xcc, 0x98, 0xfb, 0x90, 0x9f, 0x41, 0x55, 0x48, 0xd0, 0xf3, 0x87, 0x6b, 0xcb, 0x62, 0x3a,
+	0xf9, 0x8d, 0xd6, 0x01, 0x06, 0x66, 0xfc, 0xcd, 0xd0, 0x89, 0x4c, 0x7e, 0x32, 0xa9, 0xea, 0x29,
+	0x0a, 0x1b, 0x85, 0xcb, 0xb3, 0x95, 0x0c, 0x79, 0x97, 0xa6, 0x2b, 0x32, 0x67, 0x2f, 0xae, 0x3c,
+	0xd3, 0x67, 0x5c, 0x16, 0xf4, 0x35, 0x4a, 0xa1, 0xd9, 0xec, 0x0f, 0x0b, 0xd0, 0xc8, 0x3c, 0xb8,
+	0x41, 0xef, 0x91, 0xa7, 0xb3, 0x6e, 0x68, 0x38, 0xbe, 0x79, 0xe2, 0x39, 0xcc, 0xce, 0x2a, 0x79,
+	0x24, 0xeb, 0x86, 0xbb, 0x8c, 0x44, 0x32, 0x00, 0xc3, 0x14, 0x32, 0xcc, 0xa6, 0x59, 0x4a, 0x14,
+	0x42, 0x1b, 0xd0, 0xca, 0x08, 0x19, 0x17, 0x1d, 0x5e, 0x84, 0x6f, 0xa6, 0xe5, 0x8e, 0x3b, 0xda,
+	0x3f, 0x17, 0x60, 0x41, 0xf5, 0x60, 0x0e, 0x3d, 0x48, 0xad, 0x59, 0xcb, 0xca, 0x92, 0x08, 0x5f,
+	0x2b, 0x7f, 0x2c, 0xe7, 0x2e, 0x3b, 0xf5, 0x3e, 0x98, 0xf0, 0x0c, 0xef, 0x57, 0x3d, 0x73, 0x7f,
+	0x9c, 0x37, 0x5e, 0x5e, 0x49, 0xdf, 0xcc, 0x78, 0x6d, 0x07, 0x5a, 0x79, 0x7a, 0xf6, 0x06, 0xa2,
+	0x90, 0xbb, 0x81, 0x50, 0xde, 0xae, 0xfc, 0x53, 0x01, 0xe6, 0x72, 0x2f, 0xfa, 0x90, 0x96, 0x32,
+	0x01, 0xe5, 0x1f, 0xec, 0x71, 0xd7, 0x7d, 0x9a, 0x73, 0x9d, 0xa6, 0x7e, 0x1d, 0xf8, 0xab, 0xf6,
+	0xda, 0xb3, 0x94, 0xb5, 0xdc, 0x61, 0x37, 0xb0, 0x56, 0x7b, 0x0f, 0xea, 0x29, 0x92, 0xf2, 0x82,
+	0xee, 0x08, 0x80, 0x3d, 0xcc, 0x3b, 0xe2, 0xe7, 0x79, 0x12, 0xb9, 0x3c, 0x8a, 0xe9, 0x6f, 0x6a,
+	0x15, 0x89, 0x40, 0x1e, 0xb6, 0xac, 0x41, 0x5c, 0x2e, 0xaf, 0xf6, 0xc5, 0x6d, 0x91, 0x24, 0x68,
+	0xff, 0x51, 0x84, 0x7a, 0xea, 0xa9, 0x22, 0xfa, 0x20, 0x55, 0x3b, 0x48, 0xb2, 0x1c, 0x95, 0x48,
+	0x6e, 0x6a, 0xd1, 0xc7, 0x64, 0x2e, 0xb1, 0xe7, 0xab, 0x54, 0x9a, 0xe5, 0xc4, 0x5b, 0x72, 0xa1,
+	0x20, 0x53, 0x9e, 0x8a, 0x83, 0x1b, 0x8a, 0xdf, 0xc4, 0x8d, 0x76, 0x8c, 0xc5, 0xf1, 0xd4, 0x8e,
+	0x31, 0xd2, 0xa0, 0x41, 0x8b, 0xa7, 0x81, 0xcd, 0x0a, 0x58, 0x7c, 0x1a, 0x93, 0xdb, 0x8d, 0x7e,
+	0x60, 0xd3, 0x7a, 0x15, 0xa9, 0xd9, 0x4b, 0x19, 0x37, 0x14, 0xb7, 0x56, 0x5c, 0xa2, 0x17, 0x92,
+	0x03, 0x42, 0x6c, 0x0e, 0x1c, 0x23, 0x1e, 0x9e, 0x90, 0x9a, 0xfe, 0x0c, 0x5b, 0x45, 0x08, 0xe9,
+	0x90, 0x52, 0xc8, 0xbc, 0x27, 0x5b, 0xeb, 0x60, 0x88, 0xcf, 0x02, 0xd7, 0x3f, 0xa3, 0x57, 0x39,
+	0x55, 0xbd, 0xee, 0x9b, 0x78, 0x9f, 0x93, 0xd0, 0x7d, 0x68, 0x7a, 0x81, 0x65, 0x7a, 0x86, 0x28,
+	0x1b, 0xd0, 0xbd, 0x71, 0x55, 0x6f, 0x50, 0xaa, 0xd8, 0x4d, 0xa0, 0xa7, 0x50, 0xc7, 0xf4, 0x0b,
+	0xb0, 0x41, 0xb3, 0x97, 0xda, 0x62, 0xd0, 0xc9, 0xb7, 0xd1, 0x01, 0xcb, 0xdf, 0xda, 0x5d, 0xee,
+	0x5e, 0x1e, 0x0b, 0xdc, 0x07, 0x45, 0xe9, 0x03, 0xed, 0xbf, 0x0b, 0xb0, 0x32, 0xf6, 0xe9, 0x26,
+	0x0d, 0x84, 0xc0, 0x66, 0x9f, 0x83, 0x04, 0x42, 0x60, 0xcb, 0x63, 0x7e, 0x31, 0x39, 0xe6, 0x67,
+	0x12, 0x52, 0x29, 0xb7, 0x71, 0xd8, 0x80, 0x56, 0x68, 0x46, 0x8e, 0x8f, 0x0d, 0xdb, 0xa1, 0xa5,
+	0x42, 0x37, 0xe4, 0x7e, 0x6e, 0x32, 0xfa, 0x0e, 0x25, 0xb3, 0xed, 0xf2, 0xc0, 0xb4, 0xc8, 0x7a,
+	0xc6, 0xbc, 0x5c, 0x1e, 0x98, 0xd6, 0x71, 0x27, 0x9b, 0x4c, 0x2a, 0xb9, 0x9d, 0xc7, 0x0f, 0x00,
+	0xe5, 0xd1, 0x2f, 0x3a, 0xf4, 0x2b, 0xd4, 0xf4, 0x56, 0x16, 0xff, 0xa2, 0xa3, 0x7d, 0xa4, 0x1c,
+	0x2b, 0xf7, 0x8d, 0x62, 0xac, 0xda, 0xcf, 0x0b, 0xb0, 0x3c, 0xe6, 0x01, 0xe9, 0xc4, 0x04, 0x98,
+	0xdd, 0xd1, 0x15, 0xf3, 0x3b, 0xba, 0xc7, 0x30, 0xef, 0xfa, 0xd8, 0x89, 0x4e, 0x4d, 0x66, 0x71,
+	0xc6, 0x75, 0xb7, 0x24, 0x4b, 0x1c, 0x07, 0xb5, 0x67, 0x0a, 0x2b, 0xde, 0x9e, 0x86, 0xb5, 0x3f,
+	0x2f, 0xc0, 0xca, 0xd8, 0xa7, 0x92, 0x13, 0xed, 0xd7, 0xa0, 0x91, 0xd8, 0x4f, 0xbe, 0x08, 0x1b,
+	0x42, 0x5d, 0x0e, 0xe1, 0xb8, 0x33, 0x32, 0x88, 0xce, 0xd8, 0x41, 0xb0, 0xbc, 0xff, 0x5c, 0x69,
+	0xcc, 0x0d, 0x86, 0xf1, 0x2f, 0x05, 0x58, 0x54, 0x3e, 0x85, 0x25, 0x37, 0x30, 0xa2, 0x00, 0x6d,
+	0x79, 0xc3, 0x18, 0x3b, 0x91, 0x41, 0x32, 0xbb, 0x28, 0xde, 0xce, 0x73, 0xe6, 0x36, 0xe3, 0x6d,
+	0x13, 0x16, 0xda, 0x4c, 0x5e, 0x85, 0x3b, 0x57, 0xd8, 0x89, 0x48, 0x25, 0x9b, 0x29, 0x15, 0xf9,
+	0x5d, 0x25, 0xe3, 0xee, 0x72, 0x26, 0xd3, 0xfa, 0x11, 0xac, 0x0a, 0x2d, 0x32, 0x17, 0x4f, 0x4c,
+	0xcf, 0xf4, 0x2d, 0xd9, 0x1d, 0x3b, 0x20, 0xb6, 0xb9, 0xc4, 0xab, 0x94, 0x00, 0xd5, 0xd6, 0xbe,
+	0x86, 0x3a, 0x4f, 0x45, 0xa4, 0x44, 0x89, 0x56, 0x93, 0xc2, 0xa7, 0x18, 0xac, 0x68, 0x93, 0x28,
+	0x24, 0x32, 0xa2, 0x46, 0x29, 0xe4, 0xc9, 0x6a, 0x43, 0xe9, 0x25, 0x4a, 0x97, 0x6d, 0x32, 0x7f,
+	0x1b, 0x99, 0xa7, 0xb9, 0xca, 0xf3, 0x6f, 0x26, 0xef, 0x15, 0x15, 0x79, 0x4f, 0x3e, 0x95, 0xa9,
+	0xf1, 0x25, 0xf6, 0x0e, 0x80, 0x70, 0xa9, 0x9c, 0xb0, 0x35, 0x4e, 0xe9, 0x85, 0xe4, 0x94, 0x9c,
+	0xf1, 0x83, 0x5c, 0x1a, 0x9b, 0x69, 0x72, 0x2f, 0x24, 0xcb, 0x9f, 0x74, 0xb3, 0x1b, 0x8a, 0x3a,
+	0x5e, 0x5d, 0xd0, 0x7a, 0x61, 0x8c, 0x36, 0xa0, 0x9c, 0xbe, 0x14, 0x47, 0xd9, 0xa4, 0x4e, 0x46,
+	0xa9, 0x33, 0x01, 0xad, 0x2b, 0xc7, 0x9a, 0x9a, 0xb3, 0xef, 0x34, 0x56, 0x6d, 0x1b, 0x16, 0x95,
+	0x8f, 0x59, 0x95, 0x3b, 0xc4, 0x25, 0xa8, 0xb0, 0xd7, 0xab, 0x1c, 0x87, 0xb7, 0xb4, 0xef, 0x8f,
+	0x80, 0x24, 0xf6, 0xe4, 0x41, 0xb4, 0x47, 0xb0, 0xa0, 0x7a, 0xd7, 0x79, 0x33, 0xd9, 0xf1, 0xb8,
+	0x8f, 0x36, 0xc8, 0x73, 0x25, 0xf1, 0xd4, 0x61, 0x06, 0x4a, 0xdd, 0xfe, 0xd7, 0xad, 0x29, 0x54,
+	0x85, 0xe9, 0xde, 0xc1, 0xf1, 0x66, 0x6b, 0x9a, 0xff, 0xea, 0xb4, 0x2a, 0x8f, 0xfe, 0x8c, 0xbc,
+	0xf2, 0x12, 0x29, 0x14, 0x35, 0xa0, 0xb6, 0xdd, 0xdb, 0xd1, 0x8d, 0x5e, 0xff, 0xb3, 0xfd, 0xd6,
+	0x14, 0x9a, 0x87, 0x39, 0x7d, 0xf7, 0xf5, 0xfe, 0xd1, 0xae, 0xf1, 0xd5, 0xbe, 0xfe, 0xc5, 0xab,
+	0xfd, 0xee, 0x4e, 0xab, 0x40, 0x5e, 0x3d, 0x71, 0xe2, 0xde, 0xfe, 0xe1, 0x51, 0xab, 0x88, 0x10,
+	0x34, 0x5f, 0xed, 0x6f, 0x77, 0x5f, 0x25, 0x42, 0x25, 0xd4, 0x04, 0x60, 0x34, 0x2a, 0x33, 0x8d,
+	0x6e, 0x41, 0x83, 0x2b, 0x1d, 0x7d, 0xd9, 0xef, 0xef, 0xbe, 0x6a, 0x95, 0x51, 0x0b, 0x66, 0x99,
+	0x08, 0xa7, 0x54, 0x1e, 0x7d, 0x02, 0x90, 0xe4, 0x67, 0x62, 0x63, 0x7f, 0xbf, 0xbf, 0xdb, 0x9a,
+	0x42, 0xb3, 0x50, 0xed, 0xef, 0x1b, 0xbb, 0xfd, 0xed, 0xee, 0x41, 0xab, 0x80, 0x6a, 0x50, 0xa6,
+	0x0b, 0x75, 0xab, 0xc8, 0x86, 0xd1, 0x3b, 0x68, 0x95, 0x9e, 0xbe, 0x00, 0x60, 0xef, 0x5c, 0xe8,
+	0x3f, 0xc3, 0x3d, 0x81, 0x69, 0xfa, 0x57, 0x86, 0x4b, 0xf2, 0x2f, 0x76, 0xab, 0x82, 0x96, 0xfa,
+	0x37, 0xbb, 0x27, 0x85, 0xad, 0xe5, 0x5f, 0x7c, 0xbb, 0x5e, 0xf8, 0xb7, 0x6f, 0xd7, 0x0b, 0xff,
+	0xf9, 0xed, 0x7a, 0xe1, 0xaf, 0xff, 0x6b, 0x7d, 0xea, 0x27, 0x65, 0xfa, 0x28, 0xe0, 0xa4, 0x42,
+	0xff, 0x7c, 0xfc, 0xbf, 0x03, 0x00, 0x89, 0x71, 0x00, 0x98, 0xc4, 0x37, 0x00, 0x00,
 }
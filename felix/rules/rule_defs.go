@@ -47,6 +47,12 @@ const (
 	ChainRawUntrackedFlows     = ChainNamePrefix + "untracked-flows"
 	ChainRawBPFUntrackedPolicy = ChainNamePrefix + "untracked-policy"
 
+	// AutoBlocklistName names the hashlimit table that tracks the rate of new connections from
+	// each source and the xt_recent list of the sources that exceeded AutoBlocklistConnRate.
+	AutoBlocklistName = ChainNamePrefix + "autobl"
+	// ChainRawAutoBlocklist counts the new connections that AutoBlocklistName rate-limits.
+	ChainRawAutoBlocklist = ChainNamePrefix + "autoblocklist"
+
 	ChainFailsafeIn  = ChainNamePrefix + "failsafe-in"
 	ChainFailsafeOut = ChainNamePrefix + "failsafe-out"
 
@@ -320,6 +326,11 @@ type Config struct {
 	FailsafeInboundHostPorts  []config.ProtoPort
 	FailsafeOutboundHostPorts []config.ProtoPort
 
+	// AutoBlocklistConnRate, if non-zero, is the number of new TCP connections per second from
+	// one source to this host above which the source is recorded in the AutoBlocklistName
+	// xt_recent list, for Felix to add to the automatic blocklist.
+	AutoBlocklistConnRate int
+
 	DisableConntrackInvalid bool
 
 	NATPortRange                       numorstring.Port
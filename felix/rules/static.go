@@ -1096,13 +1096,62 @@ func (r *DefaultRuleRenderer) StaticManglePostroutingChain(ipVersion uint8) *Cha
 }
 
 func (r *DefaultRuleRenderer) StaticRawTableChains(ipVersion uint8) []*Chain {
-	return []*Chain{
+	chains := []*Chain{
 		r.failsafeInChain("raw", ipVersion),
 		r.failsafeOutChain("raw", ipVersion),
 		r.StaticRawPreroutingChain(ipVersion),
 		r.WireguardIncomingMarkChain(),
 		r.StaticRawOutputChain(0),
 	}
+	if r.AutoBlocklistConnRate > 0 && ipVersion == 4 {
+		chains = append(chains, r.autoBlocklistChain())
+	}
+	return chains
+}
+
+// autoBlocklistChain records the sources of new TCP connections that open them too quickly.
+// Connections to the inbound failsafe ports, and from Calico hosts, are never counted:
+// blocklisting their sources could cut the cluster off from its own control plane.
+func (r *DefaultRuleRenderer) autoBlocklistChain() *Chain {
+	var rules []Rule
+	for _, protoPort := range r.Config.FailsafeInboundHostPorts {
+		if protoPort.Protocol != "tcp" {
+			continue
+		}
+		match := Match().DestPorts(protoPort.Port)
+		if protoPort.Net != "" {
+			ip, _, err := cnet.ParseCIDROrIP(protoPort.Net)
+			if err != nil {
+				log.WithError(err).Error("Failed to parse CIDR in inbound failsafe rule. Skipping failsafe rule")
+				continue
+			}
+			if ip.Version() == 4 {
+				match = match.SourceNet(protoPort.Net)
+			}
+		}
+		rules = append(rules, Rule{Match: match, Action: ReturnAction{}})
+	}
+
+	rules = append(rules,
+		Rule{
+			Match:  Match().SourceIPSet(r.IPSetConfigV4.NameForMainIPSet(IPSetIDAllHostNets)),
+			Action: ReturnAction{},
+		},
+		Rule{
+			Match:  Match().SourceIPSet(r.IPSetConfigV4.NameForMainIPSet(IPSetIDThisHostIPs)),
+			Action: ReturnAction{},
+		},
+		// The rule has no action; Felix reads the list and adds its members to the blocklist.
+		Rule{
+			Match: Match().SourceRateAbove(AutoBlocklistName, r.AutoBlocklistConnRate).
+				RecordSourceInRecentList(AutoBlocklistName),
+		},
+	)
+
+	return &Chain{
+		Name:  ChainRawAutoBlocklist,
+		Rules: rules,
+	}
 }
 
 func (r *DefaultRuleRenderer) StaticBPFModeRawChains(ipVersion uint8,
@@ -1260,6 +1309,16 @@ func (r *DefaultRuleRenderer) StaticRawPreroutingChain(ipVersion uint8) *Chain {
 	rules = append(rules,
 		RPFilter(ipVersion, markFromWorkload, markFromWorkload, r.OpenStackSpecialCasesEnabled, false, r.IptablesFilterDenyAction)...)
 
+	if r.AutoBlocklistConnRate > 0 && ipVersion == 4 {
+		// Record the sources of non-workload traffic that open connections too quickly.
+		rules = append(rules, Rule{
+			Match: Match().MarkClear(markFromWorkload).
+				Protocol("tcp").
+				TCPSyn(),
+			Action: JumpAction{Target: ChainRawAutoBlocklist},
+		})
+	}
+
 	rules = append(rules,
 		// Send non-workload traffic to the untracked policy chains.
 		Rule{Match: Match().MarkClear(markFromWorkload),
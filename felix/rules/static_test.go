@@ -529,6 +529,75 @@ var _ = Describe("Static", func() {
 				}))
 			})
 
+			Describe("with automatic blocklisting enabled", func() {
+				BeforeEach(func() {
+					conf.AutoBlocklistConnRate = 20
+				})
+				AfterEach(func() {
+					conf.AutoBlocklistConnRate = 0
+				})
+
+				It("IPv4: Should record over-rate sources in the raw PREROUTING chain", func() {
+					Expect(findChain(rr.StaticRawTableChains(4), "cali-PREROUTING")).To(Equal(&Chain{
+						Name: "cali-PREROUTING",
+						Rules: []Rule{
+							{Action: ClearMarkAction{Mark: 0xf0}},
+							{Match: Match().InInterface("cali+"),
+								Action: SetMarkAction{Mark: 0x40}},
+							{Match: Match().MarkMatchesWithMask(0x40, 0x40),
+								Action: JumpAction{Target: ChainRpfSkip}},
+							{Match: Match().MarkSingleBitSet(0x40).RPFCheckFailed(false),
+								Action: denyAction},
+							{Match: Match().MarkClear(0x40).Protocol("tcp").TCPSyn(),
+								Action: JumpAction{Target: "cali-autoblocklist"}},
+							{Match: Match().MarkClear(0x40),
+								Action: JumpAction{Target: ChainDispatchFromHostEndpoint}},
+							{Match: Match().MarkSingleBitSet(0x10),
+								Action: AcceptAction{}},
+						},
+					}))
+				})
+				It("IPv4: Should not count connections to failsafe ports or from Calico hosts", func() {
+					Expect(findChain(rr.StaticRawTableChains(4), "cali-autoblocklist")).To(Equal(&Chain{
+						Name: "cali-autoblocklist",
+						Rules: []Rule{
+							{Match: Match().DestPorts(22).SourceNet("0.0.0.0/0"),
+								Action: ReturnAction{}},
+							{Match: Match().DestPorts(1022).SourceNet("0.0.0.0/0"),
+								Action: ReturnAction{}},
+							{Match: Match().SourceIPSet("cali40all-hosts-net"),
+								Action: ReturnAction{}},
+							{Match: Match().SourceIPSet("cali40this-host"),
+								Action: ReturnAction{}},
+							{Match: Match().SourceRateAbove("cali-autobl", 20).
+								RecordSourceInRecentList("cali-autobl")},
+						},
+					}))
+				})
+				Context("with UDP and IPv6 failsafe ports", func() {
+					BeforeEach(func() {
+						conf.FailsafeInboundHostPorts = []config.ProtoPort{
+							{Protocol: "udp", Port: 68},
+							{Protocol: "tcp", Port: 6443},
+							{Net: "fd00::/64", Protocol: "tcp", Port: 179},
+						}
+					})
+
+					It("IPv4: Should only exempt the TCP failsafe ports", func() {
+						rules := findChain(rr.StaticRawTableChains(4), "cali-autoblocklist").Rules
+						Expect(rules).To(HaveLen(5))
+						Expect(rules[:2]).To(Equal([]Rule{
+							{Match: Match().DestPorts(6443), Action: ReturnAction{}},
+							{Match: Match().DestPorts(179), Action: ReturnAction{}},
+						}))
+					})
+				})
+				It("IPv6: Should not record over-rate sources", func() {
+					Expect(findChain(rr.StaticRawTableChains(6), "cali-PREROUTING").Rules).To(HaveLen(6))
+					Expect(findChain(rr.StaticRawTableChains(6), "cali-autoblocklist")).To(BeNil())
+				})
+			})
+
 			It("IPv4: Should return expected mangle PREROUTING chain", func() {
 				Expect(findChain(rr.StaticMangleTableChains(4), "cali-PREROUTING")).To(Equal(&Chain{
 					Name: "cali-PREROUTING",
@@ -34,6 +34,7 @@ import (
 	"go.etcd.io/etcd/client/pkg/v3/transport"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"k8s.io/apiserver/pkg/storage/etcd3"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
@@ -46,13 +47,17 @@ import (
 
 	"github.com/projectcalico/calico/crypto/pkg/tls"
 	"github.com/projectcalico/calico/kube-controllers/pkg/config"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/autoblocklist"
 	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/controller"
 	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/flannelmigration"
 	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/namespace"
 	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/networkpolicy"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/networksetasn"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/networksetfeed"
 	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/node"
 	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/pod"
 	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/serviceaccount"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/xdpblocklist"
 	"github.com/projectcalico/calico/kube-controllers/pkg/status"
 )
 
@@ -115,7 +120,7 @@ func main() {
 	log.SetLevel(logLevel)
 
 	// Build clients to be used by the controllers.
-	k8sClientset, calicoClient, err := getClients(cfg.Kubeconfig)
+	k8sClientset, dynamicClient, calicoClient, err := getClients(cfg.Kubeconfig)
 	if err != nil {
 		log.WithError(err).Fatal("Failed to start")
 	}
@@ -198,7 +203,7 @@ func main() {
 
 		// any subsequent changes trigger a restart
 		controllerCtrl.restart = cCtrlr.ConfigChan()
-		controllerCtrl.InitControllers(ctx, runCfg, k8sClientset, calicoClient)
+		controllerCtrl.InitControllers(ctx, runCfg, k8sClientset, dynamicClient, calicoClient)
 	}
 
 	if cfg.DatastoreType == "etcdv3" {
@@ -350,27 +355,33 @@ func startCompactor(ctx context.Context, interval time.Duration) {
 }
 
 // getClients builds and returns Kubernetes and Calico clients.
-func getClients(kubeconfig string) (*kubernetes.Clientset, client.Interface, error) {
+func getClients(kubeconfig string) (*kubernetes.Clientset, dynamic.Interface, client.Interface, error) {
 	// Get Calico client
 	calicoClient, err := client.NewFromEnv()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to build Calico client: %s", err)
+		return nil, nil, nil, fmt.Errorf("failed to build Calico client: %s", err)
 	}
 
 	// Now build the Kubernetes client, we support in-cluster config and kubeconfig
 	// as means of configuring the client.
 	k8sconfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to build kubernetes client config: %s", err)
+		return nil, nil, nil, fmt.Errorf("failed to build kubernetes client config: %s", err)
 	}
 
 	// Get Kubernetes clientset
 	k8sClientset, err := kubernetes.NewForConfig(k8sconfig)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to build kubernetes client: %s", err)
+		return nil, nil, nil, fmt.Errorf("failed to build kubernetes client: %s", err)
 	}
 
-	return k8sClientset, calicoClient, nil
+	// Get a dynamic client, for watching CRDs that we don't have a clientset for.
+	dynamicClient, err := dynamic.NewForConfig(k8sconfig)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to build kubernetes dynamic client: %s", err)
+	}
+
+	return k8sClientset, dynamicClient, calicoClient, nil
 }
 
 // Returns an etcdv3 client based on the environment. The client will be configured to
@@ -447,7 +458,7 @@ type controllerControl struct {
 	informers   []cache.SharedIndexInformer
 }
 
-func (cc *controllerControl) InitControllers(ctx context.Context, cfg config.RunConfig, k8sClientset *kubernetes.Clientset, calicoClient client.Interface) {
+func (cc *controllerControl) InitControllers(ctx context.Context, cfg config.RunConfig, k8sClientset *kubernetes.Clientset, dynamicClient dynamic.Interface, calicoClient client.Interface) {
 	// Create a shared informer factory to allow cache sharing between controllers monitoring the
 	// same resource.
 	factory := informers.NewSharedInformerFactory(k8sClientset, 0)
@@ -477,6 +488,22 @@ func (cc *controllerControl) InitControllers(ctx context.Context, cfg config.Run
 		serviceAccountController := serviceaccount.NewServiceAccountController(ctx, k8sClientset, calicoClient, *cfg.Controllers.ServiceAccount)
 		cc.controllers["ServiceAccount"] = serviceAccountController
 	}
+	if cfg.Controllers.XDPBlockList != nil {
+		xdpBlockListController := xdpblocklist.NewXDPBlockListController(ctx, dynamicClient, calicoClient, *cfg.Controllers.XDPBlockList)
+		cc.controllers["XDPBlockList"] = xdpBlockListController
+	}
+	if cfg.Controllers.NetworkSetFeed != nil {
+		networkSetFeedController := networksetfeed.NewNetworkSetFeedController(ctx, calicoClient, *cfg.Controllers.NetworkSetFeed)
+		cc.controllers["NetworkSetFeed"] = networkSetFeedController
+	}
+	if cfg.Controllers.NetworkSetASN != nil {
+		networkSetASNController := networksetasn.NewNetworkSetASNController(ctx, calicoClient, *cfg.Controllers.NetworkSetASN)
+		cc.controllers["NetworkSetASN"] = networkSetASNController
+	}
+	if cfg.Controllers.AutoBlocklist != nil {
+		autoBlocklistController := autoblocklist.NewAutoBlocklistController(ctx, dynamicClient, calicoClient, *cfg.Controllers.AutoBlocklist)
+		cc.controllers["AutoBlocklist"] = autoBlocklistController
+	}
 }
 
 // registerInformers registers the given informers, if not already registered. Registered informers
@@ -40,12 +40,19 @@ type Config struct {
 	ProfileWorkers          int `default:"1" split_words:"true"`
 	PolicyWorkers           int `default:"1" split_words:"true"`
 	NodeWorkers             int `default:"1" split_words:"true"`
+	XDPBlockListWorkers     int `default:"1" split_words:"true"`
 
 	// Path to a kubeconfig file to use for accessing the k8s API.
 	Kubeconfig string `default:"" split_words:"false"`
 
 	// etcdv3 or kubernetes
 	DatastoreType string `default:"etcdv3" split_words:"true"`
+
+	// Path to the IP-to-ASN dataset used by the networksetasn controller.
+	ASNDatasetFile string `default:"" envconfig:"ASN_DATASET_FILE"`
+
+	// Hosts that the networksetfeed controller may fetch GlobalNetworkSet feeds from.
+	FeedAllowedHosts []string `default:"" envconfig:"FEED_ALLOWED_HOSTS"`
 }
 
 // Parse parses envconfig and stores in Config struct
@@ -43,6 +43,9 @@ var _ = Describe("Config", func() {
 		os.Unsetenv("WORKLOAD_ENDPOINT_WORKERS")
 		os.Unsetenv("PROFILE_WORKERS")
 		os.Unsetenv("POLICY_WORKERS")
+		os.Unsetenv("XDP_BLOCK_LIST_WORKERS")
+		os.Unsetenv("ASN_DATASET_FILE")
+		os.Unsetenv("FEED_ALLOWED_HOSTS")
 		os.Unsetenv("KUBECONFIG")
 		os.Unsetenv("DATASTORE_TYPE")
 		os.Unsetenv("HEALTH_ENABLED")
@@ -652,6 +655,157 @@ var _ = Describe("Config", func() {
 			Expect(runCfg.Controllers.WorkloadEndpoint.ReconcilerPeriod).To(Equal(time.Second * 31))
 			Expect(runCfg.Controllers.Namespace.ReconcilerPeriod).To(Equal(time.Second * 32))
 			Expect(runCfg.Controllers.ServiceAccount.ReconcilerPeriod).To(Equal(time.Second * 33))
+			Expect(runCfg.Controllers.XDPBlockList).To(BeNil())
+			close(done)
+		})
+	})
+
+	Context("with the xdpblocklist controller in ENABLED_CONTROLLERS", func() {
+
+		BeforeEach(func() {
+			unsetEnv()
+			err := os.Setenv("ENABLED_CONTROLLERS", "node,xdpblocklist")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			unsetEnv()
+		})
+
+		It("should enable it with the default reconciler period", func(done Done) {
+			cfg := new(config.Config)
+			err := cfg.Parse()
+			Expect(err).ToNot(HaveOccurred())
+			kcc := v3.NewKubeControllersConfiguration()
+			kcc.Name = "default"
+			m := &mockKCC{get: kcc}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctrl := config.NewRunConfigController(ctx, *cfg, m)
+			runCfg := <-ctrl.ConfigChan()
+			Expect(runCfg.Controllers.XDPBlockList).To(Equal(&config.GenericControllerConfig{
+				ReconcilerPeriod: time.Minute * 5,
+				NumberOfWorkers:  1,
+			}))
+			Expect(runCfg.Controllers.Policy).To(BeNil())
+			close(done)
+		})
+
+		It("should use the reconciler period from the environment", func(done Done) {
+			err := os.Setenv("RECONCILER_PERIOD", "42s")
+			Expect(err).ToNot(HaveOccurred())
+			cfg := new(config.Config)
+			err = cfg.Parse()
+			Expect(err).ToNot(HaveOccurred())
+			kcc := v3.NewKubeControllersConfiguration()
+			kcc.Name = "default"
+			m := &mockKCC{get: kcc}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctrl := config.NewRunConfigController(ctx, *cfg, m)
+			runCfg := <-ctrl.ConfigChan()
+			Expect(runCfg.Controllers.XDPBlockList.ReconcilerPeriod).To(Equal(time.Second * 42))
+			close(done)
+		})
+	})
+
+	Context("with the networksetfeed controller in ENABLED_CONTROLLERS", func() {
+
+		BeforeEach(func() {
+			unsetEnv()
+			err := os.Setenv("ENABLED_CONTROLLERS", "node,networksetfeed")
+			Expect(err).ToNot(HaveOccurred())
+			err = os.Setenv("FEED_ALLOWED_HOSTS", "feeds.example.com,10.0.0.1")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			unsetEnv()
+		})
+
+		It("should enable it with the default reconciler period and the allowed hosts", func(done Done) {
+			cfg := new(config.Config)
+			err := cfg.Parse()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.FeedAllowedHosts).To(Equal([]string{"feeds.example.com", "10.0.0.1"}))
+			kcc := v3.NewKubeControllersConfiguration()
+			kcc.Name = "default"
+			m := &mockKCC{get: kcc}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctrl := config.NewRunConfigController(ctx, *cfg, m)
+			runCfg := <-ctrl.ConfigChan()
+			Expect(runCfg.Controllers.NetworkSetFeed).To(Equal(&config.NetworkSetFeedControllerConfig{
+				ReconcilerPeriod: time.Second * 10,
+				AllowedHosts:     []string{"feeds.example.com", "10.0.0.1"},
+			}))
+			Expect(runCfg.Controllers.XDPBlockList).To(BeNil())
+			close(done)
+		})
+	})
+
+	Context("with the networksetasn controller in ENABLED_CONTROLLERS", func() {
+
+		BeforeEach(func() {
+			unsetEnv()
+			err := os.Setenv("ENABLED_CONTROLLERS", "node,networksetasn")
+			Expect(err).ToNot(HaveOccurred())
+			err = os.Setenv("ASN_DATASET_FILE", "/etc/calico/asn.txt")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			unsetEnv()
+		})
+
+		It("should enable it with the dataset file", func(done Done) {
+			cfg := new(config.Config)
+			err := cfg.Parse()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.ASNDatasetFile).To(Equal("/etc/calico/asn.txt"))
+			kcc := v3.NewKubeControllersConfiguration()
+			kcc.Name = "default"
+			m := &mockKCC{get: kcc}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctrl := config.NewRunConfigController(ctx, *cfg, m)
+			runCfg := <-ctrl.ConfigChan()
+			Expect(runCfg.Controllers.NetworkSetASN).To(Equal(&config.NetworkSetASNControllerConfig{
+				ReconcilerPeriod: time.Second * 10,
+				DatasetFile:      "/etc/calico/asn.txt",
+			}))
+			Expect(runCfg.Controllers.NetworkSetFeed).To(BeNil())
+			close(done)
+		})
+	})
+
+	Context("with the autoblocklist controller in ENABLED_CONTROLLERS", func() {
+
+		BeforeEach(func() {
+			unsetEnv()
+			err := os.Setenv("ENABLED_CONTROLLERS", "node,autoblocklist")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			unsetEnv()
+		})
+
+		It("should enable it with the default reconciler period", func(done Done) {
+			cfg := new(config.Config)
+			err := cfg.Parse()
+			Expect(err).ToNot(HaveOccurred())
+			kcc := v3.NewKubeControllersConfiguration()
+			kcc.Name = "default"
+			m := &mockKCC{get: kcc}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctrl := config.NewRunConfigController(ctx, *cfg, m)
+			runCfg := <-ctrl.ConfigChan()
+			Expect(runCfg.Controllers.AutoBlocklist).To(Equal(&config.GenericControllerConfig{
+				ReconcilerPeriod: time.Second * 10,
+			}))
+			Expect(runCfg.Controllers.XDPBlockList).To(BeNil())
 			close(done)
 		})
 	})
@@ -90,6 +90,13 @@ type ControllersConfig struct {
 	WorkloadEndpoint *GenericControllerConfig
 	ServiceAccount   *GenericControllerConfig
 	Namespace        *GenericControllerConfig
+	// XDPBlockList, NetworkSetFeed, NetworkSetASN and AutoBlocklist can only be
+	// enabled by the ENABLED_CONTROLLERS environment variable; they aren't part
+	// of the KubeControllersConfiguration API.
+	XDPBlockList   *GenericControllerConfig
+	NetworkSetFeed *NetworkSetFeedControllerConfig
+	NetworkSetASN  *NetworkSetASNControllerConfig
+	AutoBlocklist  *GenericControllerConfig
 }
 
 type GenericControllerConfig struct {
@@ -110,6 +117,20 @@ type NodeControllerConfig struct {
 	LeakGracePeriod *v1.Duration
 }
 
+type NetworkSetFeedControllerConfig struct {
+	ReconcilerPeriod time.Duration
+
+	// Hosts that feeds may be fetched from, from the FEED_ALLOWED_HOSTS environment variable.
+	AllowedHosts []string
+}
+
+type NetworkSetASNControllerConfig struct {
+	ReconcilerPeriod time.Duration
+
+	// Path to the IP-to-ASN dataset, from the ASN_DATASET_FILE environment variable.
+	DatasetFile string
+}
+
 type RunConfigController struct {
 	out chan RunConfig
 }
@@ -316,6 +337,20 @@ func mergeConfig(envVars map[string]string, envCfg Config, apiCfg v3.KubeControl
 
 	mergeHealthEnabled(envVars, &status, &rCfg, apiCfg)
 
+	if rc.NetworkSetFeed != nil {
+		if len(envCfg.FeedAllowedHosts) == 0 {
+			log.Fatal("cannot run networksetfeed controller without FEED_ALLOWED_HOSTS")
+		}
+		rc.NetworkSetFeed.AllowedHosts = envCfg.FeedAllowedHosts
+	}
+
+	if rc.NetworkSetASN != nil {
+		if envCfg.ASNDatasetFile == "" {
+			log.Fatal("cannot run networksetasn controller without ASN_DATASET_FILE")
+		}
+		rc.NetworkSetASN.DatasetFile = envCfg.ASNDatasetFile
+	}
+
 	// Merge prometheus information.
 	if apiCfg.PrometheusMetricsPort != nil {
 		rCfg.PrometheusPort = *apiCfg.PrometheusMetricsPort
@@ -357,6 +392,9 @@ func mergeConfig(envVars map[string]string, envCfg Config, apiCfg v3.KubeControl
 	if rc.Namespace != nil {
 		rc.Namespace.NumberOfWorkers = envCfg.ProfileWorkers
 	}
+	if rc.XDPBlockList != nil {
+		rc.XDPBlockList.NumberOfWorkers = envCfg.XDPBlockListWorkers
+	}
 
 	return rCfg, status
 }
@@ -498,6 +536,18 @@ func mergeReconcilerPeriod(envVars map[string]string, status *v3.KubeControllers
 			rc.Namespace.ReconcilerPeriod = d
 			sc.Namespace.ReconcilerPeriod = &v1.Duration{Duration: d}
 		}
+		if rc.XDPBlockList != nil {
+			rc.XDPBlockList.ReconcilerPeriod = d
+		}
+		if rc.NetworkSetFeed != nil {
+			rc.NetworkSetFeed.ReconcilerPeriod = d
+		}
+		if rc.NetworkSetASN != nil {
+			rc.NetworkSetASN.ReconcilerPeriod = d
+		}
+		if rc.AutoBlocklist != nil {
+			rc.AutoBlocklist.ReconcilerPeriod = d
+		}
 	}
 }
 
@@ -532,6 +582,20 @@ func mergeEnabledControllers(envVars map[string]string, status *v3.KubeControlle
 			case "serviceaccount":
 				rc.ServiceAccount = &GenericControllerConfig{}
 				sc.ServiceAccount = &v3.ServiceAccountControllerConfig{}
+			case "xdpblocklist":
+				rc.XDPBlockList = &GenericControllerConfig{ReconcilerPeriod: time.Minute * 5}
+			case "networksetfeed":
+				// The reconciler period is how often the controller looks for feeds that
+				// are due a refresh; each GlobalNetworkSet sets its own refresh interval.
+				rc.NetworkSetFeed = &NetworkSetFeedControllerConfig{ReconcilerPeriod: time.Second * 10}
+			case "networksetasn":
+				// The reconciler period is how often the controller checks the dataset
+				// file for changes.
+				rc.NetworkSetASN = &NetworkSetASNControllerConfig{ReconcilerPeriod: time.Second * 10}
+			case "autoblocklist":
+				// The reconciler period is how often the controller reads the sources
+				// that each node has exported.
+				rc.AutoBlocklist = &GenericControllerConfig{ReconcilerPeriod: time.Second * 10}
 			case "flannelmigration":
 				log.WithField(EnvEnabledControllers, v).Fatal("cannot run flannelmigration with other controllers")
 			default:
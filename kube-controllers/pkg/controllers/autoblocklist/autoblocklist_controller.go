@@ -0,0 +1,336 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package autoblocklist contains a controller that turns the sources of excessive connection
+// rates that Felix finds into GlobalNetworkSets.  Felix exports the sources that it finds in the
+// AnnotationAutoBlocklistSources annotation of its Node; while the XDPAutoBlocklist named
+// "default" exists, the controller keeps a GlobalNetworkSet per node, named
+// "auto-blocklist.<node name>", with an expiry for each source.  The sets are labelled
+// LabelAutoBlocklist=true, so that an untracked deny policy can select them, and LabelNodeScope
+// with the name of their node, so that Felix only applies each set on its own node.
+//
+// The controller owns the sets: Felix doesn't need to be able to write GlobalNetworkSets.
+package autoblocklist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	log "github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	uruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/projectcalico/calico/kube-controllers/pkg/config"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/controller"
+	libapiv1 "github.com/projectcalico/calico/libcalico-go/lib/apis/crd.projectcalico.org/v1"
+	libapiv3 "github.com/projectcalico/calico/libcalico-go/lib/apis/v3"
+	"github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+// XDPAutoBlocklistResource is the resource of the XDPAutoBlocklist CRD.
+var XDPAutoBlocklistResource = schema.GroupVersionResource{
+	Group:    "crd.projectcalico.org",
+	Version:  "v1",
+	Resource: "xdpautoblocklists",
+}
+
+const (
+	// NetworkSetNamePrefix is the prefix of the name of each node's GlobalNetworkSet; the
+	// node name is appended.
+	NetworkSetNamePrefix = "auto-blocklist."
+
+	// DefaultExpiry is how long a source stays in the blocklist if the XDPAutoBlocklist
+	// doesn't specify an Expiry.
+	DefaultExpiry = 5 * time.Minute
+
+	// MaxSourcesPerNode is the most sources that the controller takes from each node; when a
+	// node exports more, the ones it recorded longest ago are ignored.
+	MaxSourcesPerNode = 1000
+
+	// configName is the name of the XDPAutoBlocklist that enables the controller.
+	configName = "default"
+)
+
+// autoBlocklistController implements the Controller interface.  Every ReconcilerPeriod, it
+// reads the XDPAutoBlocklist and the Nodes and syncs the GlobalNetworkSets.  It needs to be able
+// to get xdpautoblocklists, list nodes, and list and write globalnetworksets.
+type autoBlocklistController struct {
+	ctx    context.Context
+	cfg    config.GenericControllerConfig
+	syncer *Syncer
+}
+
+// NewAutoBlocklistController returns a controller that syncs the automatic blocklist
+// GlobalNetworkSets from the sources that the nodes export.
+func NewAutoBlocklistController(ctx context.Context, dynamicClient dynamic.Interface, c clientv3.Interface, cfg config.GenericControllerConfig) controller.Controller {
+	return &autoBlocklistController{
+		ctx:    ctx,
+		cfg:    cfg,
+		syncer: New(dynamicClient.Resource(XDPAutoBlocklistResource), c.Nodes(), c.GlobalNetworkSets()),
+	}
+}
+
+// Run starts the controller.
+func (c *autoBlocklistController) Run(stopCh chan struct{}) {
+	defer uruntime.HandleCrash()
+
+	log.Info("Starting automatic blocklist controller")
+	wait.JitterUntil(func() {
+		if err := c.syncer.Poll(c.ctx); err != nil {
+			log.WithError(err).Warn("Failed to sync the automatic blocklist, will retry.")
+		}
+	}, c.cfg.ReconcilerPeriod, 0.1, true, stopCh)
+	log.Info("Stopping automatic blocklist controller")
+}
+
+// Syncer writes the sources that the nodes export to their GlobalNetworkSets.
+type Syncer struct {
+	config  dynamic.ResourceInterface
+	nodes   clientv3.NodeInterface
+	netSets clientv3.GlobalNetworkSetInterface
+
+	now func() time.Time
+}
+
+// New returns a Syncer that reads the XDPAutoBlocklist through the given resource interface.
+func New(config dynamic.ResourceInterface, nodes clientv3.NodeInterface, netSets clientv3.GlobalNetworkSetInterface) *Syncer {
+	return &Syncer{
+		config:  config,
+		nodes:   nodes,
+		netSets: netSets,
+		now:     time.Now,
+	}
+}
+
+// Poll brings the GlobalNetworkSets in line with the sources that the nodes export: it adds new
+// sources (or extends their expiry), removes the entries that have expired, and deletes the sets
+// of nodes that no longer exist, or that have no entries left.  If there is no XDPAutoBlocklist,
+// it deletes all the sets.  A failure to sync one set doesn't prevent the others from being
+// synced; it is retried on the next call.
+func (s *Syncer) Poll(ctx context.Context) error {
+	expiry, enabled, err := s.readConfig(ctx)
+	if err != nil {
+		return err
+	}
+	netSetList, err := s.netSets.List(ctx, options.ListOptions{})
+	if err != nil {
+		return err
+	}
+	existing := map[string]*apiv3.GlobalNetworkSet{}
+	for i := range netSetList.Items {
+		netSet := &netSetList.Items[i]
+		if strings.HasPrefix(netSet.Name, NetworkSetNamePrefix) && netSet.Labels[apiv3.LabelAutoBlocklist] == "true" {
+			existing[strings.TrimPrefix(netSet.Name, NetworkSetNamePrefix)] = netSet
+		}
+	}
+
+	numFailed := 0
+	if enabled {
+		nodeList, err := s.nodes.List(ctx, options.ListOptions{})
+		if err != nil {
+			return err
+		}
+		now := s.now()
+		for i := range nodeList.Items {
+			node := &nodeList.Items[i]
+			netSet := existing[node.Name]
+			delete(existing, node.Name)
+			if err := s.syncNode(ctx, node, netSet, expiry, now); err != nil {
+				log.WithError(err).WithField("node", node.Name).Warn(
+					"Failed to sync automatic blocklist GlobalNetworkSet.")
+				numFailed++
+			}
+		}
+	}
+
+	// Whatever is left belongs to nodes that have been deleted, or the feature is disabled.
+	for nodeName, netSet := range existing {
+		log.WithFields(log.Fields{
+			"node":       nodeName,
+			"networkSet": netSet.Name,
+		}).Info("Deleting automatic blocklist GlobalNetworkSet.")
+		if err := s.deleteNetSet(ctx, netSet.Name); err != nil {
+			log.WithError(err).WithField("networkSet", netSet.Name).Warn(
+				"Failed to delete automatic blocklist GlobalNetworkSet.")
+			numFailed++
+		}
+	}
+
+	if numFailed > 0 {
+		return fmt.Errorf("failed to sync %d automatic blocklist GlobalNetworkSet(s)", numFailed)
+	}
+	return nil
+}
+
+// readConfig returns the expiry from the XDPAutoBlocklist, and whether it exists.
+func (s *Syncer) readConfig(ctx context.Context) (time.Duration, bool, error) {
+	u, err := s.config.Get(ctx, configName, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	var autoBlocklist libapiv1.XDPAutoBlocklist
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &autoBlocklist); err != nil {
+		return 0, false, fmt.Errorf("failed to parse XDPAutoBlocklist %s: %w", configName, err)
+	}
+	expiry := DefaultExpiry
+	if autoBlocklist.Spec.Expiry != nil && autoBlocklist.Spec.Expiry.Duration > 0 {
+		expiry = autoBlocklist.Spec.Expiry.Duration
+	}
+	return expiry, true, nil
+}
+
+// syncNode creates, updates or deletes the node's GlobalNetworkSet (which is nil if it doesn't
+// exist) so that it holds the node's sources and its entries that haven't expired.
+func (s *Syncer) syncNode(ctx context.Context, node *libapiv3.Node, netSet *apiv3.GlobalNetworkSet, expiry time.Duration, now time.Time) error {
+	expiries := map[string]metav1.Time{}
+	if netSet != nil {
+		for cidr, t := range netSet.Spec.NetExpiries {
+			if t.Time.After(now) {
+				expiries[cidr] = t
+			}
+		}
+	}
+	for cidr, lastSeen := range parseSources(node) {
+		t := lastSeen.Add(expiry)
+		if t.After(now) && t.After(expiries[cidr].Time) {
+			expiries[cidr] = metav1.NewTime(t)
+		}
+	}
+
+	if len(expiries) == 0 {
+		if netSet == nil {
+			return nil
+		}
+		log.WithField("networkSet", netSet.Name).Info(
+			"All entries expired, deleting automatic blocklist GlobalNetworkSet.")
+		return s.deleteNetSet(ctx, netSet.Name)
+	}
+
+	nets := make([]string, 0, len(expiries))
+	for cidr := range expiries {
+		nets = append(nets, cidr)
+	}
+	sort.Strings(nets)
+	labels := map[string]string{
+		apiv3.LabelAutoBlocklist: "true",
+		apiv3.LabelNodeScope:     node.Name,
+	}
+
+	if netSet == nil {
+		netSet = apiv3.NewGlobalNetworkSet()
+		netSet.Name = NetworkSetNamePrefix + node.Name
+		netSet.Labels = labels
+		netSet.Spec.Nets = nets
+		netSet.Spec.NetExpiries = expiries
+		log.WithFields(log.Fields{
+			"networkSet": netSet.Name,
+			"nets":       nets,
+		}).Info("Creating automatic blocklist GlobalNetworkSet.")
+		_, err := s.netSets.Create(ctx, netSet, options.SetOptions{})
+		return err
+	}
+
+	if reflect.DeepEqual(netSet.Labels, labels) &&
+		reflect.DeepEqual(netSet.Spec.Nets, nets) &&
+		reflect.DeepEqual(netSet.Spec.NetExpiries, expiries) {
+		return nil
+	}
+	netSet.Labels = labels
+	netSet.Spec.Nets = nets
+	netSet.Spec.NetExpiries = expiries
+	log.WithFields(log.Fields{
+		"networkSet": netSet.Name,
+		"nets":       nets,
+	}).Info("Updating automatic blocklist GlobalNetworkSet.")
+	// If the set was updated since we listed it, the update fails with a conflict; we'll try
+	// again on the next poll.
+	_, err := s.netSets.Update(ctx, netSet, options.SetOptions{})
+	return err
+}
+
+func (s *Syncer) deleteNetSet(ctx context.Context, name string) error {
+	_, err := s.netSets.Delete(ctx, name, options.DeleteOptions{})
+	if _, ok := err.(cerrors.ErrorResourceDoesNotExist); ok {
+		return nil
+	}
+	return err
+}
+
+// parseSources returns the sources in the node's annotation, as CIDRs, mapped to when the node
+// last recorded them.  Bad entries are ignored and, if there are more than MaxSourcesPerNode
+// sources, only the ones recorded most recently are returned.
+func parseSources(node *libapiv3.Node) map[string]time.Time {
+	value, ok := node.Annotations[apiv3.AnnotationAutoBlocklistSources]
+	if !ok {
+		return nil
+	}
+	logCxt := log.WithField("node", node.Name)
+	var raw map[string]metav1.Time
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		logCxt.WithError(err).Warn("Ignoring bad automatic blocklist sources annotation.")
+		return nil
+	}
+
+	type source struct {
+		cidr     string
+		lastSeen time.Time
+	}
+	var sources []source
+	numBad := 0
+	for ip, lastSeen := range raw {
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			numBad++
+			continue
+		}
+		cidr := parsed.String() + "/128"
+		if parsed.To4() != nil {
+			cidr = parsed.To4().String() + "/32"
+		}
+		sources = append(sources, source{cidr: cidr, lastSeen: lastSeen.Time})
+	}
+	if numBad > 0 {
+		logCxt.WithField("numBad", numBad).Warn("Ignored bad entries in automatic blocklist sources annotation.")
+	}
+	if len(sources) > MaxSourcesPerNode {
+		logCxt.WithField("numIgnored", len(sources)-MaxSourcesPerNode).Warn(
+			"Too many automatic blocklist sources, ignoring the ones recorded longest ago.")
+		sort.Slice(sources, func(i, j int) bool {
+			return sources[i].lastSeen.After(sources[j].lastSeen)
+		})
+		sources = sources[:MaxSourcesPerNode]
+	}
+
+	result := make(map[string]time.Time, len(sources))
+	for _, src := range sources {
+		result[src.cidr] = src.lastSeen
+	}
+	return result
+}
@@ -0,0 +1,309 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoblocklist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	libapiv3 "github.com/projectcalico/calico/libcalico-go/lib/apis/v3"
+	"github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+var _ = Describe("Automatic blocklist syncer", func() {
+	var (
+		cfgClient    *mockConfigClient
+		nodeClient   *mockNodeClient
+		netSetClient *mockNetSetClient
+		s            *Syncer
+		now          time.Time
+	)
+
+	addNode := func(name string, sources map[string]time.Time) {
+		node := libapiv3.NewNode()
+		node.Name = name
+		if sources != nil {
+			raw := map[string]metav1.Time{}
+			for ip, t := range sources {
+				raw[ip] = metav1.NewTime(t)
+			}
+			value, err := json.Marshal(raw)
+			Expect(err).NotTo(HaveOccurred())
+			node.Annotations = map[string]string{apiv3.AnnotationAutoBlocklistSources: string(value)}
+		}
+		nodeClient.nodes = append(nodeClient.nodes, *node)
+	}
+
+	// expiries returns the node's set's expiries, in UTC so that they compare equal to times
+	// derived from now.
+	expiries := func(node string) map[string]time.Time {
+		netSet, ok := netSetClient.netSets[NetworkSetNamePrefix+node]
+		if !ok {
+			return nil
+		}
+		result := map[string]time.Time{}
+		for cidr, t := range netSet.Spec.NetExpiries {
+			result[cidr] = t.UTC()
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		cfgClient = &mockConfigClient{}
+		nodeClient = &mockNodeClient{}
+		netSetClient = &mockNetSetClient{netSets: map[string]*apiv3.GlobalNetworkSet{}}
+		now = time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+		s = New(cfgClient, nodeClient, netSetClient)
+		s.now = func() time.Time { return now }
+	})
+
+	It("should do nothing without an XDPAutoBlocklist", func() {
+		addNode("node1", map[string]time.Time{"10.0.0.1": now})
+		Expect(s.Poll(context.Background())).To(Succeed())
+		Expect(netSetClient.netSets).To(BeEmpty())
+	})
+
+	It("should delete the sets when there's no XDPAutoBlocklist", func() {
+		addNode("node1", nil)
+		netSetClient.add("auto-blocklist.node1", map[string]string{apiv3.LabelAutoBlocklist: "true"})
+		netSetClient.add("auto-blocklist.other", nil)
+		Expect(s.Poll(context.Background())).To(Succeed())
+		Expect(netSetClient.netSets).To(HaveLen(1))
+		Expect(netSetClient.netSets).To(HaveKey("auto-blocklist.other"))
+	})
+
+	Describe("with an XDPAutoBlocklist", func() {
+		BeforeEach(func() {
+			cfgClient.setExpiry("")
+		})
+
+		It("should create a set for each node with sources", func() {
+			addNode("node1", map[string]time.Time{
+				"10.0.0.1": now.Add(-time.Minute),
+				"10.0.0.2": now,
+			})
+			addNode("node2", nil)
+			Expect(s.Poll(context.Background())).To(Succeed())
+
+			Expect(netSetClient.netSets).To(HaveLen(1))
+			netSet := netSetClient.netSets["auto-blocklist.node1"]
+			Expect(netSet.Labels).To(Equal(map[string]string{
+				apiv3.LabelAutoBlocklist: "true",
+				apiv3.LabelNodeScope:     "node1",
+			}))
+			Expect(netSet.Spec.Nets).To(Equal([]string{"10.0.0.1/32", "10.0.0.2/32"}))
+			Expect(expiries("node1")).To(Equal(map[string]time.Time{
+				"10.0.0.1/32": now.Add(DefaultExpiry - time.Minute),
+				"10.0.0.2/32": now.Add(DefaultExpiry),
+			}))
+		})
+
+		It("should use the expiry from the XDPAutoBlocklist", func() {
+			cfgClient.setExpiry("1h")
+			addNode("node1", map[string]time.Time{"10.0.0.1": now})
+			Expect(s.Poll(context.Background())).To(Succeed())
+			Expect(expiries("node1")).To(Equal(map[string]time.Time{"10.0.0.1/32": now.Add(time.Hour)}))
+		})
+
+		It("should ignore sources that have already expired and bad entries", func() {
+			addNode("node1", map[string]time.Time{
+				"10.0.0.1": now.Add(-DefaultExpiry),
+				"garbage":  now,
+				"10.0.0.2": now,
+			})
+			Expect(s.Poll(context.Background())).To(Succeed())
+			Expect(expiries("node1")).To(Equal(map[string]time.Time{"10.0.0.2/32": now.Add(DefaultExpiry)}))
+		})
+
+		It("should only take the most recent sources from a node", func() {
+			sources := map[string]time.Time{}
+			for i := 0; i < MaxSourcesPerNode; i++ {
+				sources[fmt.Sprintf("10.0.%d.%d", i/256, i%256)] = now.Add(-time.Minute)
+			}
+			sources["10.1.0.1"] = now
+			addNode("node1", sources)
+			Expect(s.Poll(context.Background())).To(Succeed())
+			Expect(expiries("node1")).To(HaveLen(MaxSourcesPerNode))
+			Expect(expiries("node1")).To(HaveKey("10.1.0.1/32"))
+		})
+
+		It("should ignore a bad annotation", func() {
+			addNode("node1", nil)
+			nodeClient.nodes[0].Annotations = map[string]string{apiv3.AnnotationAutoBlocklistSources: "{"}
+			Expect(s.Poll(context.Background())).To(Succeed())
+			Expect(netSetClient.netSets).To(BeEmpty())
+		})
+
+		Describe("with an existing set", func() {
+			BeforeEach(func() {
+				netSet := netSetClient.add("auto-blocklist.node1", map[string]string{
+					apiv3.LabelAutoBlocklist: "true",
+					apiv3.LabelNodeScope:     "node1",
+				})
+				netSet.Spec.Nets = []string{"10.0.0.3/32", "10.0.0.4/32"}
+				netSet.Spec.NetExpiries = map[string]metav1.Time{
+					"10.0.0.3/32": metav1.NewTime(now.Add(-time.Second)),
+					"10.0.0.4/32": metav1.NewTime(now.Add(time.Hour)),
+				}
+			})
+
+			It("should add new sources, keep later expiries and remove expired entries", func() {
+				addNode("node1", map[string]time.Time{
+					"10.0.0.1": now,
+					"10.0.0.4": now,
+				})
+				Expect(s.Poll(context.Background())).To(Succeed())
+				Expect(netSetClient.numUpdates).To(Equal(1))
+				Expect(netSetClient.netSets["auto-blocklist.node1"].Spec.Nets).To(Equal([]string{"10.0.0.1/32", "10.0.0.4/32"}))
+				Expect(expiries("node1")).To(Equal(map[string]time.Time{
+					"10.0.0.1/32": now.Add(DefaultExpiry),
+					"10.0.0.4/32": now.Add(time.Hour),
+				}))
+			})
+
+			It("should not write the set if nothing has changed", func() {
+				addNode("node1", map[string]time.Time{"10.0.0.4": now})
+				Expect(s.Poll(context.Background())).To(Succeed())
+				Expect(netSetClient.numUpdates).To(Equal(1))
+
+				Expect(s.Poll(context.Background())).To(Succeed())
+				Expect(netSetClient.numUpdates).To(Equal(1))
+			})
+
+			It("should delete the set when all its entries have expired", func() {
+				addNode("node1", nil)
+				now = now.Add(time.Hour)
+				Expect(s.Poll(context.Background())).To(Succeed())
+				Expect(netSetClient.netSets).To(BeEmpty())
+			})
+
+			It("should delete the set when its node is deleted", func() {
+				addNode("node2", map[string]time.Time{"10.0.0.1": now})
+				Expect(s.Poll(context.Background())).To(Succeed())
+				Expect(netSetClient.netSets).To(HaveLen(1))
+				Expect(netSetClient.netSets).To(HaveKey("auto-blocklist.node2"))
+			})
+
+			It("should report a failure to update the set", func() {
+				netSetClient.updateErr = cerrors.ErrorResourceUpdateConflict{}
+				addNode("node1", map[string]time.Time{"10.0.0.1": now})
+				Expect(s.Poll(context.Background())).NotTo(Succeed())
+			})
+		})
+	})
+})
+
+type mockConfigClient struct {
+	dynamic.ResourceInterface
+
+	obj *unstructured.Unstructured
+}
+
+// setExpiry creates the XDPAutoBlocklist, with the given expiry if it isn't empty.
+func (c *mockConfigClient) setExpiry(expiry string) {
+	spec := map[string]interface{}{}
+	if expiry != "" {
+		spec["expiry"] = expiry
+	}
+	c.obj = &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "crd.projectcalico.org/v1",
+		"kind":       "XDPAutoBlocklist",
+		"metadata":   map[string]interface{}{"name": "default"},
+		"spec":       spec,
+	}}
+}
+
+func (c *mockConfigClient) Get(ctx context.Context, name string, opts metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if c.obj == nil || c.obj.GetName() != name {
+		return nil, kerrors.NewNotFound(XDPAutoBlocklistResource.GroupResource(), name)
+	}
+	return c.obj.DeepCopy(), nil
+}
+
+type mockNodeClient struct {
+	clientv3.NodeInterface
+
+	nodes []libapiv3.Node
+}
+
+func (c *mockNodeClient) List(ctx context.Context, opts options.ListOptions) (*libapiv3.NodeList, error) {
+	list := &libapiv3.NodeList{}
+	for _, node := range c.nodes {
+		list.Items = append(list.Items, *node.DeepCopy())
+	}
+	return list, nil
+}
+
+type mockNetSetClient struct {
+	clientv3.GlobalNetworkSetInterface
+
+	netSets    map[string]*apiv3.GlobalNetworkSet
+	numUpdates int
+	updateErr  error
+}
+
+func (c *mockNetSetClient) add(name string, labels map[string]string) *apiv3.GlobalNetworkSet {
+	netSet := apiv3.NewGlobalNetworkSet()
+	netSet.Name = name
+	netSet.Labels = labels
+	c.netSets[name] = netSet
+	return netSet
+}
+
+func (c *mockNetSetClient) List(ctx context.Context, opts options.ListOptions) (*apiv3.GlobalNetworkSetList, error) {
+	list := &apiv3.GlobalNetworkSetList{}
+	for _, netSet := range c.netSets {
+		list.Items = append(list.Items, *netSet.DeepCopy())
+	}
+	return list, nil
+}
+
+func (c *mockNetSetClient) Create(ctx context.Context, res *apiv3.GlobalNetworkSet, opts options.SetOptions) (*apiv3.GlobalNetworkSet, error) {
+	if _, ok := c.netSets[res.Name]; ok {
+		return nil, cerrors.ErrorResourceAlreadyExists{Identifier: res.Name}
+	}
+	c.netSets[res.Name] = res.DeepCopy()
+	return res, nil
+}
+
+func (c *mockNetSetClient) Update(ctx context.Context, res *apiv3.GlobalNetworkSet, opts options.SetOptions) (*apiv3.GlobalNetworkSet, error) {
+	if c.updateErr != nil {
+		return nil, c.updateErr
+	}
+	c.numUpdates++
+	c.netSets[res.Name] = res.DeepCopy()
+	return res, nil
+}
+
+func (c *mockNetSetClient) Delete(ctx context.Context, name string, opts options.DeleteOptions) (*apiv3.GlobalNetworkSet, error) {
+	netSet, ok := c.netSets[name]
+	if !ok {
+		return nil, cerrors.ErrorResourceDoesNotExist{Identifier: name}
+	}
+	delete(c.netSets, name)
+	return netSet, nil
+}
@@ -0,0 +1,263 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package networksetasn contains a controller that keeps GlobalNetworkSets that list AS numbers
+// in sync with the networks of those ASes, as given by an IP-to-ASN dataset file.  The expanded
+// CIDRs are written to the set's Nets, from where they flow into the dataplane (and into XDP, if
+// an untracked deny policy selects the set) like any other GlobalNetworkSet.  This lets operators
+// blocklist whole networks by AS.
+//
+// The dataset has one "<CIDR> <AS number>" pair per line, for example "192.0.2.0/24 64496"; the
+// AS number may have an "AS" prefix.  The file is reloaded whenever it changes.
+package networksetasn
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/api/pkg/lib/numorstring"
+	log "github.com/sirupsen/logrus"
+	uruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/projectcalico/calico/kube-controllers/pkg/config"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/controller"
+	"github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+// networkSetASNController implements the Controller interface.  Every ReconcilerPeriod, it
+// checks the dataset file for changes and updates the GlobalNetworkSets whose AS numbers expand
+// to different CIDRs.  It needs to be able to list and update globalnetworksets.
+type networkSetASNController struct {
+	ctx    context.Context
+	cfg    config.NetworkSetASNControllerConfig
+	syncer *Syncer
+}
+
+// NewNetworkSetASNController returns a controller that expands the AS numbers of
+// GlobalNetworkSets using the dataset file given by the config.
+func NewNetworkSetASNController(ctx context.Context, c clientv3.Interface, cfg config.NetworkSetASNControllerConfig) controller.Controller {
+	return &networkSetASNController{
+		ctx:    ctx,
+		cfg:    cfg,
+		syncer: New(c.GlobalNetworkSets(), cfg.DatasetFile),
+	}
+}
+
+// Run starts the controller.
+func (c *networkSetASNController) Run(stopCh chan struct{}) {
+	defer uruntime.HandleCrash()
+
+	log.WithField("dataset", c.cfg.DatasetFile).Info("Starting GlobalNetworkSet AS number controller")
+	wait.JitterUntil(func() {
+		if err := c.syncer.Poll(c.ctx); err != nil {
+			log.WithError(err).Warn("Failed to sync GlobalNetworkSet AS numbers, will retry.")
+		}
+	}, c.cfg.ReconcilerPeriod, 0.1, true, stopCh)
+	log.Info("Stopping GlobalNetworkSet AS number controller")
+}
+
+// Syncer expands the AS numbers of GlobalNetworkSets and writes the resulting CIDRs to the sets.
+type Syncer struct {
+	client      clientv3.GlobalNetworkSetInterface
+	datasetPath string
+
+	// dataset maps each AS number to its CIDRs, sorted.  It is nil until the dataset has been
+	// loaded.
+	dataset        map[numorstring.ASNumber][]string
+	datasetModTime time.Time
+}
+
+func New(client clientv3.GlobalNetworkSetInterface, datasetPath string) *Syncer {
+	return &Syncer{
+		client:      client,
+		datasetPath: datasetPath,
+	}
+}
+
+// Poll reloads the dataset if it has changed and then updates each GlobalNetworkSet that lists
+// AS numbers whose expansion differs from its Nets.  A failure to update one set doesn't prevent
+// the others from being updated; it is retried on the next call.
+func (s *Syncer) Poll(ctx context.Context) error {
+	if err := s.maybeLoadDataset(); err != nil {
+		return err
+	}
+
+	list, err := s.client.List(ctx, options.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	numFailed := 0
+	for i := range list.Items {
+		netSet := &list.Items[i]
+		if len(netSet.Spec.ASNumbers) == 0 {
+			continue
+		}
+		if err := s.syncNetSet(ctx, netSet); err != nil {
+			log.WithError(err).WithField("networkSet", netSet.Name).Warn(
+				"Failed to update GlobalNetworkSet from its AS numbers.")
+			numFailed++
+		}
+	}
+
+	if numFailed > 0 {
+		return fmt.Errorf("failed to update %d GlobalNetworkSet(s) from their AS numbers", numFailed)
+	}
+	return nil
+}
+
+func (s *Syncer) maybeLoadDataset() error {
+	info, err := os.Stat(s.datasetPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat IP-to-ASN dataset: %w", err)
+	}
+	if s.dataset != nil && info.ModTime().Equal(s.datasetModTime) {
+		return nil
+	}
+
+	f, err := os.Open(s.datasetPath)
+	if err != nil {
+		return fmt.Errorf("failed to open IP-to-ASN dataset: %w", err)
+	}
+	defer f.Close()
+	dataset, err := parseDataset(f)
+	if err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"dataset": s.datasetPath,
+		"numASes": len(dataset),
+	}).Info("Loaded IP-to-ASN dataset.")
+	s.dataset = dataset
+	s.datasetModTime = info.ModTime()
+	return nil
+}
+
+func (s *Syncer) syncNetSet(ctx context.Context, netSet *apiv3.GlobalNetworkSet) error {
+	nets := s.expand(netSet.Spec.ASNumbers)
+	if netsEqual(nets, netSet.Spec.Nets) {
+		log.WithField("networkSet", netSet.Name).Debug("AS networks unchanged.")
+		return nil
+	}
+
+	log.WithFields(log.Fields{
+		"networkSet": netSet.Name,
+		"asNumbers":  netSet.Spec.ASNumbers,
+		"numNets":    len(nets),
+	}).Info("AS networks changed, updating GlobalNetworkSet.")
+	netSet.Spec.Nets = nets
+	// Expiries only make sense for nets that are still in the set.
+	inSet := map[string]bool{}
+	for _, n := range nets {
+		inSet[n] = true
+	}
+	for cidr := range netSet.Spec.NetExpiries {
+		if !inSet[cidr] {
+			delete(netSet.Spec.NetExpiries, cidr)
+		}
+	}
+	// If the set was updated since we listed it, the update fails with a conflict; we'll try
+	// again on the next poll.
+	_, err := s.client.Update(ctx, netSet, options.SetOptions{})
+	return err
+}
+
+// expand returns the CIDRs of all the given ASes, sorted and with duplicates removed.  ASes that
+// aren't in the dataset contribute nothing.
+func (s *Syncer) expand(asNumbers []numorstring.ASNumber) []string {
+	netSet := map[string]bool{}
+	for _, asn := range asNumbers {
+		for _, n := range s.dataset[asn] {
+			netSet[n] = true
+		}
+	}
+	nets := []string{}
+	for n := range netSet {
+		nets = append(nets, n)
+	}
+	sort.Strings(nets)
+	return nets
+}
+
+func netsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDataset reads one "<CIDR> <AS number>" pair per line, ignoring blank lines, comments
+// starting with "#" and entries that don't parse.  It returns the canonical CIDRs of each AS,
+// sorted and with duplicates removed.
+func parseDataset(r io.Reader) (map[numorstring.ASNumber][]string, error) {
+	asNets := map[numorstring.ASNumber]map[string]bool{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			log.WithField("line", line).Warn("Ignoring bad entry in IP-to-ASN dataset.")
+			continue
+		}
+		_, cidr, err := cnet.ParseCIDROrIP(fields[0])
+		if err != nil {
+			log.WithField("line", line).Warn("Ignoring bad CIDR in IP-to-ASN dataset.")
+			continue
+		}
+		asn, err := numorstring.ASNumberFromString(strings.TrimPrefix(strings.ToUpper(fields[1]), "AS"))
+		if err != nil {
+			log.WithField("line", line).Warn("Ignoring bad AS number in IP-to-ASN dataset.")
+			continue
+		}
+		if asNets[asn] == nil {
+			asNets[asn] = map[string]bool{}
+		}
+		asNets[asn][cidr.String()] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read IP-to-ASN dataset: %w", err)
+	}
+
+	dataset := map[numorstring.ASNumber][]string{}
+	for asn, netSet := range asNets {
+		nets := make([]string, 0, len(netSet))
+		for n := range netSet {
+			nets = append(nets, n)
+		}
+		sort.Strings(nets)
+		dataset[asn] = nets
+	}
+	return dataset, nil
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networksetasn
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/api/pkg/lib/numorstring"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+var _ = Describe("parseDataset", func() {
+	It("should group normalised CIDRs by AS and skip comments and bad lines", func() {
+		dataset, err := parseDataset(strings.NewReader(
+			"# prefix asn\n" +
+				"10.0.0.0/24 64500\n" +
+				"\n" +
+				"  11.1.2.3/8  AS64500  # a comment\n" +
+				"10.0.0.0/24 64500\n" +
+				"192.168.0.1 as64501\n" +
+				"dead:beef::/64 1.10\n" +
+				"garbage\n" +
+				"10.1.0.0/16 notanasn\n" +
+				"10.2.0.0/16 64502 extra\n",
+		))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dataset).To(Equal(map[numorstring.ASNumber][]string{
+			64500:      {"10.0.0.0/24", "11.0.0.0/8"},
+			64501:      {"192.168.0.1/32"},
+			1<<16 + 10: {"dead:beef::/64"},
+		}))
+	})
+})
+
+var _ = Describe("Syncer", func() {
+	var (
+		client      *mockNetSetClient
+		s           *Syncer
+		tmpDir      string
+		datasetPath string
+		modTime     time.Time
+	)
+
+	writeDataset := func(lines ...string) {
+		Expect(os.WriteFile(datasetPath, []byte(strings.Join(lines, "\n")+"\n"), 0644)).To(Succeed())
+		// Make sure that every write is seen as a change, however quickly they follow each other.
+		modTime = modTime.Add(time.Second)
+		Expect(os.Chtimes(datasetPath, modTime, modTime)).To(Succeed())
+	}
+
+	addNetSet := func(name string, asNumbers ...numorstring.ASNumber) *apiv3.GlobalNetworkSet {
+		netSet := apiv3.NewGlobalNetworkSet()
+		netSet.Name = name
+		netSet.Spec.ASNumbers = asNumbers
+		client.netSets[name] = netSet
+		return netSet
+	}
+
+	BeforeEach(func() {
+		client = &mockNetSetClient{netSets: map[string]*apiv3.GlobalNetworkSet{}}
+		var err error
+		tmpDir, err = os.MkdirTemp("", "netsetasn")
+		Expect(err).NotTo(HaveOccurred())
+		datasetPath = filepath.Join(tmpDir, "ip2asn.txt")
+		modTime = time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+		writeDataset(
+			"10.0.0.0/24 64500",
+			"10.0.1.0/24 64500",
+			"10.1.0.0/16 64501",
+		)
+		s = New(client, datasetPath)
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	It("should ignore network sets without AS numbers", func() {
+		client.netSets["static"] = &apiv3.GlobalNetworkSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "static"},
+			Spec:       apiv3.GlobalNetworkSetSpec{Nets: []string{"10.0.0.1/32"}},
+		}
+		Expect(s.Poll(context.Background())).To(Succeed())
+		Expect(client.numUpdates).To(Equal(0))
+	})
+
+	It("should write the networks of all the set's ASes to the set", func() {
+		addNetSet("blocked", 64500, 64501, 64999)
+		Expect(s.Poll(context.Background())).To(Succeed())
+		Expect(client.netSets["blocked"].Spec.Nets).To(Equal([]string{"10.0.0.0/24", "10.0.1.0/24", "10.1.0.0/16"}))
+	})
+
+	It("should not update the network set if its networks are unchanged", func() {
+		addNetSet("blocked", 64501)
+		Expect(s.Poll(context.Background())).To(Succeed())
+		Expect(s.Poll(context.Background())).To(Succeed())
+		Expect(client.numUpdates).To(Equal(1))
+	})
+
+	It("should update the network set when its AS numbers change", func() {
+		addNetSet("blocked", 64501)
+		Expect(s.Poll(context.Background())).To(Succeed())
+		client.netSets["blocked"].Spec.ASNumbers = []numorstring.ASNumber{64500}
+		Expect(s.Poll(context.Background())).To(Succeed())
+		Expect(client.netSets["blocked"].Spec.Nets).To(Equal([]string{"10.0.0.0/24", "10.0.1.0/24"}))
+	})
+
+	It("should reload the dataset when it changes", func() {
+		addNetSet("blocked", 64501)
+		Expect(s.Poll(context.Background())).To(Succeed())
+		writeDataset("10.2.0.0/16 64501")
+		Expect(s.Poll(context.Background())).To(Succeed())
+		Expect(client.netSets["blocked"].Spec.Nets).To(Equal([]string{"10.2.0.0/16"}))
+	})
+
+	It("should remove expiries of nets that are no longer in the set", func() {
+		addNetSet("blocked", 64501)
+		client.netSets["blocked"].Spec.Nets = []string{"10.1.0.0/16", "10.9.0.0/16"}
+		expiry := metav1.NewTime(modTime.Add(time.Hour))
+		client.netSets["blocked"].Spec.NetExpiries = map[string]metav1.Time{
+			"10.1.0.0/16": expiry,
+			"10.9.0.0/16": expiry,
+		}
+		Expect(s.Poll(context.Background())).To(Succeed())
+		Expect(client.netSets["blocked"].Spec.NetExpiries).To(Equal(map[string]metav1.Time{
+			"10.1.0.0/16": expiry,
+		}))
+	})
+
+	It("should leave the network sets alone if the dataset is missing", func() {
+		addNetSet("blocked", 64501)
+		Expect(os.Remove(datasetPath)).To(Succeed())
+		Expect(s.Poll(context.Background())).NotTo(Succeed())
+		Expect(client.numUpdates).To(Equal(0))
+	})
+
+	It("should retry on the next poll if the update fails", func() {
+		addNetSet("blocked", 64501)
+		client.updateErr = errors.New("conflict")
+		Expect(s.Poll(context.Background())).NotTo(Succeed())
+		client.updateErr = nil
+		Expect(s.Poll(context.Background())).To(Succeed())
+		Expect(client.netSets["blocked"].Spec.Nets).To(Equal([]string{"10.1.0.0/16"}))
+	})
+})
+
+type mockNetSetClient struct {
+	clientv3.GlobalNetworkSetInterface
+
+	netSets    map[string]*apiv3.GlobalNetworkSet
+	numUpdates int
+	updateErr  error
+}
+
+func (c *mockNetSetClient) List(ctx context.Context, opts options.ListOptions) (*apiv3.GlobalNetworkSetList, error) {
+	list := &apiv3.GlobalNetworkSetList{}
+	for _, netSet := range c.netSets {
+		list.Items = append(list.Items, *netSet.DeepCopy())
+	}
+	return list, nil
+}
+
+func (c *mockNetSetClient) Update(ctx context.Context, res *apiv3.GlobalNetworkSet, opts options.SetOptions) (*apiv3.GlobalNetworkSet, error) {
+	if c.updateErr != nil {
+		return nil, c.updateErr
+	}
+	c.numUpdates++
+	c.netSets[res.Name] = res.DeepCopy()
+	return res, nil
+}
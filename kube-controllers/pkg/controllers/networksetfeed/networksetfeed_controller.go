@@ -0,0 +1,331 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package networksetfeed contains a controller that keeps GlobalNetworkSets that have a feed URL
+// in sync with the contents of the feed, for example a threat feed of addresses to blocklist.
+// The feed is fetched periodically and written to the set's Nets, from where it flows into the
+// dataplane (and into XDP, if an untracked deny policy selects the set) like any other
+// GlobalNetworkSet.
+//
+// Feeds are fetched over HTTP or HTTPS, and only from the hosts that the administrator allows
+// through the FEED_ALLOWED_HOSTS environment variable, because anyone who can write a
+// GlobalNetworkSet can set its feed URL.
+package networksetfeed
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	log "github.com/sirupsen/logrus"
+	uruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/projectcalico/calico/kube-controllers/pkg/config"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/controller"
+	"github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+const (
+	// DefaultRefresh is how often a feed is fetched if its GlobalNetworkSet doesn't specify
+	// FeedRefresh.
+	DefaultRefresh = 5 * time.Minute
+
+	// MaxFeedBytes is the largest feed that the controller reads; larger feeds fail to sync.
+	MaxFeedBytes = 16 * 1024 * 1024
+	// MaxFeedEntries is the most entries that a feed may have; feeds with more fail to sync.
+	MaxFeedEntries = 100000
+
+	fetchTimeout = 30 * time.Second
+)
+
+// networkSetFeedController implements the Controller interface.  Every ReconcilerPeriod, it
+// lists the GlobalNetworkSets and syncs the feeds that are due a refresh.  It needs to be able to
+// list and update globalnetworksets.
+type networkSetFeedController struct {
+	ctx    context.Context
+	cfg    config.NetworkSetFeedControllerConfig
+	syncer *Syncer
+}
+
+// NewNetworkSetFeedController returns a controller that syncs GlobalNetworkSets from their
+// feeds, fetching only from the hosts allowed by the config.
+func NewNetworkSetFeedController(ctx context.Context, c clientv3.Interface, cfg config.NetworkSetFeedControllerConfig) controller.Controller {
+	return &networkSetFeedController{
+		ctx:    ctx,
+		cfg:    cfg,
+		syncer: New(c.GlobalNetworkSets(), cfg.AllowedHosts),
+	}
+}
+
+// Run starts the controller.
+func (c *networkSetFeedController) Run(stopCh chan struct{}) {
+	defer uruntime.HandleCrash()
+
+	log.WithField("allowedHosts", c.cfg.AllowedHosts).Info("Starting GlobalNetworkSet feed controller")
+	wait.JitterUntil(func() {
+		if err := c.syncer.Poll(c.ctx); err != nil {
+			log.WithError(err).Warn("Failed to sync GlobalNetworkSet feeds, will retry.")
+		}
+	}, c.cfg.ReconcilerPeriod, 0.1, true, stopCh)
+	log.Info("Stopping GlobalNetworkSet feed controller")
+}
+
+// Syncer fetches the feeds of GlobalNetworkSets and writes their contents to the sets.
+type Syncer struct {
+	client       clientv3.GlobalNetworkSetInterface
+	httpClient   *http.Client
+	allowedHosts map[string]bool
+
+	// lastFetch records, for each set, the feed URL that was last fetched successfully and
+	// when.
+	lastFetch map[string]fetchRecord
+
+	now func() time.Time
+}
+
+type fetchRecord struct {
+	url  string
+	time time.Time
+}
+
+// New returns a Syncer that only fetches feeds from the given hosts.  A host matches the
+// hostname of the feed URL, without the port.
+func New(client clientv3.GlobalNetworkSetInterface, allowedHosts []string) *Syncer {
+	s := &Syncer{
+		client:       client,
+		allowedHosts: map[string]bool{},
+		lastFetch:    map[string]fetchRecord{},
+		now:          time.Now,
+	}
+	for _, h := range allowedHosts {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			s.allowedHosts[h] = true
+		}
+	}
+	s.httpClient = &http.Client{
+		Timeout: fetchTimeout,
+		// Otherwise an allowed host could redirect us anywhere.
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after %d redirects", len(via))
+			}
+			return s.checkURL(req.URL)
+		},
+	}
+	return s
+}
+
+// Poll fetches the feed of each GlobalNetworkSet that is due a refresh and updates the set if
+// the feed has changed.  A failure to sync one feed doesn't prevent the others from being
+// synced; it is retried on the next call.
+func (s *Syncer) Poll(ctx context.Context) error {
+	list, err := s.client.List(ctx, options.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	numFailed := 0
+	for i := range list.Items {
+		netSet := &list.Items[i]
+		if netSet.Spec.FeedURL == "" {
+			continue
+		}
+		seen[netSet.Name] = true
+		if !s.due(netSet) {
+			continue
+		}
+		logCxt := log.WithFields(log.Fields{
+			"networkSet": netSet.Name,
+			"feedURL":    netSet.Spec.FeedURL,
+		})
+		if err := s.syncFeed(ctx, netSet); err != nil {
+			logCxt.WithError(err).Warn("Failed to sync GlobalNetworkSet feed.")
+			numFailed++
+			continue
+		}
+		s.lastFetch[netSet.Name] = fetchRecord{url: netSet.Spec.FeedURL, time: s.now()}
+	}
+
+	for name := range s.lastFetch {
+		if !seen[name] {
+			delete(s.lastFetch, name)
+		}
+	}
+
+	if numFailed > 0 {
+		return fmt.Errorf("failed to sync %d GlobalNetworkSet feed(s)", numFailed)
+	}
+	return nil
+}
+
+// due returns true if the set's feed has never been fetched, its URL has changed, or its
+// refresh interval has passed since the last fetch.
+func (s *Syncer) due(netSet *apiv3.GlobalNetworkSet) bool {
+	last, ok := s.lastFetch[netSet.Name]
+	if !ok || last.url != netSet.Spec.FeedURL {
+		return true
+	}
+	refresh := DefaultRefresh
+	if netSet.Spec.FeedRefresh != nil && netSet.Spec.FeedRefresh.Duration > 0 {
+		refresh = netSet.Spec.FeedRefresh.Duration
+	}
+	return !s.now().Before(last.time.Add(refresh))
+}
+
+func (s *Syncer) syncFeed(ctx context.Context, netSet *apiv3.GlobalNetworkSet) error {
+	nets, numBad, err := s.fetchFeed(ctx, netSet.Spec.FeedURL)
+	if err != nil {
+		return err
+	}
+	if numBad > 0 {
+		log.WithFields(log.Fields{
+			"networkSet": netSet.Name,
+			"numBad":     numBad,
+		}).Warn("Ignored bad entries in GlobalNetworkSet feed.")
+	}
+	if len(nets) == 0 {
+		// More likely a broken feed than a deliberately empty one; don't wipe the set.
+		return fmt.Errorf("feed has no valid entries (%d bad)", numBad)
+	}
+	if netsEqual(nets, netSet.Spec.Nets) {
+		log.WithField("networkSet", netSet.Name).Debug("Feed unchanged.")
+		return nil
+	}
+
+	log.WithFields(log.Fields{
+		"networkSet": netSet.Name,
+		"numNets":    len(nets),
+	}).Info("Feed changed, updating GlobalNetworkSet.")
+	netSet.Spec.Nets = nets
+	// Expiries only make sense for nets that are still in the set.
+	inFeed := map[string]bool{}
+	for _, n := range nets {
+		inFeed[n] = true
+	}
+	for cidr := range netSet.Spec.NetExpiries {
+		if !inFeed[cidr] {
+			delete(netSet.Spec.NetExpiries, cidr)
+		}
+	}
+	// If the set was updated since we listed it, the update fails with a conflict; we'll
+	// fetch the feed again on the next poll.
+	_, err = s.client.Update(ctx, netSet, options.SetOptions{})
+	return err
+}
+
+func netsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkURL returns an error unless the URL uses HTTP or HTTPS and its host is allowed.
+func (s *Syncer) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported feed URL scheme %q", u.Scheme)
+	}
+	if !s.allowedHosts[strings.ToLower(u.Hostname())] {
+		return fmt.Errorf("feed host %q is not in FEED_ALLOWED_HOSTS", u.Hostname())
+	}
+	return nil
+}
+
+// fetchFeed fetches and parses the feed.  It returns the nets and the number of bad entries
+// that were skipped.
+func (s *Syncer) fetchFeed(ctx context.Context, feedURL string) ([]string, int, error) {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.checkURL(u); err != nil {
+		return nil, 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("unexpected status fetching feed: %s", resp.Status)
+	}
+
+	return parseFeed(resp.Body)
+}
+
+// parseFeed reads one IP address or CIDR per line, ignoring blank lines and comments starting
+// with "#", and skipping entries that don't parse.  It returns the nets in canonical CIDR form,
+// sorted and with duplicates removed, and the number of entries that it skipped.  It fails if
+// the feed is larger than MaxFeedBytes or has more than MaxFeedEntries entries.
+func parseFeed(r io.Reader) ([]string, int, error) {
+	netSet := map[string]bool{}
+	numBad := 0
+	// Read one byte more than the limit so that we can tell if the feed was truncated.
+	reader := bufio.NewReader(io.LimitReader(r, MaxFeedBytes+1))
+	numRead := 0
+	for {
+		line, err := reader.ReadString('\n')
+		numRead += len(line)
+		if numRead > MaxFeedBytes {
+			return nil, 0, fmt.Errorf("feed is larger than %d bytes", MaxFeedBytes)
+		}
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line != "" {
+			if _, cidr, perr := cnet.ParseCIDROrIP(line); perr != nil {
+				numBad++
+			} else {
+				netSet[cidr.String()] = true
+				if len(netSet) > MaxFeedEntries {
+					return nil, 0, fmt.Errorf("feed has more than %d entries", MaxFeedEntries)
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, 0, fmt.Errorf("failed to read feed: %w", err)
+		}
+	}
+
+	nets := []string{}
+	for n := range netSet {
+		nets = append(nets, n)
+	}
+	sort.Strings(nets)
+	return nets, numBad, nil
+}
@@ -0,0 +1,285 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networksetfeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+var _ = Describe("parseFeed", func() {
+	It("should normalise, sort and dedupe entries and skip comments and bad lines", func() {
+		nets, numBad, err := parseFeed(strings.NewReader(
+			"# A threat feed\n" +
+				"10.0.0.2\n" +
+				"\n" +
+				"  11.1.2.3/8  # a comment\n" +
+				"10.0.0.2/32\n" +
+				"garbage\n" +
+				"dead:beef::1",
+		))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nets).To(Equal([]string{"10.0.0.2/32", "11.0.0.0/8", "dead:beef::1/128"}))
+		Expect(numBad).To(Equal(1))
+	})
+
+	It("should return an empty list for an empty feed", func() {
+		nets, numBad, err := parseFeed(strings.NewReader(""))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nets).To(BeEmpty())
+		Expect(numBad).To(Equal(0))
+	})
+
+	It("should count a very long line as a bad entry", func() {
+		nets, numBad, err := parseFeed(strings.NewReader(
+			strings.Repeat("x", 100*1024) + "\n10.0.0.1\n",
+		))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nets).To(Equal([]string{"10.0.0.1/32"}))
+		Expect(numBad).To(Equal(1))
+	})
+
+	It("should reject a feed that is too large", func() {
+		_, _, err := parseFeed(strings.NewReader(strings.Repeat("#", MaxFeedBytes+1)))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject a feed that has too many entries", func() {
+		var feed strings.Builder
+		for i := 0; i <= MaxFeedEntries; i++ {
+			fmt.Fprintf(&feed, "10.%d.%d.%d\n", i>>16, (i>>8)&0xff, i&0xff)
+		}
+		_, _, err := parseFeed(strings.NewReader(feed.String()))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Syncer", func() {
+	var (
+		client   *mockNetSetClient
+		s        *Syncer
+		server   *httptest.Server
+		status   int
+		contents map[string]string
+		now      time.Time
+	)
+
+	addNetSet := func(name, feedURL string, refresh time.Duration) *apiv3.GlobalNetworkSet {
+		netSet := apiv3.NewGlobalNetworkSet()
+		netSet.Name = name
+		netSet.Spec.FeedURL = feedURL
+		if refresh != 0 {
+			netSet.Spec.FeedRefresh = &metav1.Duration{Duration: refresh}
+		}
+		client.netSets[name] = netSet
+		return netSet
+	}
+
+	BeforeEach(func() {
+		client = &mockNetSetClient{netSets: map[string]*apiv3.GlobalNetworkSet{}}
+		status = http.StatusOK
+		contents = map[string]string{"/feed.txt": "10.0.0.1\n10.0.0.2\n"}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, ok := contents[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(status)
+			fmt.Fprint(w, c)
+		}))
+		now = time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+		// The test server listens on 127.0.0.1.
+		s = New(client, []string{"127.0.0.1"})
+		s.now = func() time.Time { return now }
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should ignore network sets without a feed", func() {
+		client.netSets["static"] = &apiv3.GlobalNetworkSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "static"},
+			Spec:       apiv3.GlobalNetworkSetSpec{Nets: []string{"10.0.0.1/32"}},
+		}
+		Expect(s.Poll(context.Background())).To(Succeed())
+		Expect(client.numUpdates).To(Equal(0))
+	})
+
+	Describe("with a feed", func() {
+		BeforeEach(func() {
+			addNetSet("feed", server.URL+"/feed.txt", time.Minute)
+		})
+
+		It("should write the feed to the network set", func() {
+			Expect(s.Poll(context.Background())).To(Succeed())
+			Expect(client.numUpdates).To(Equal(1))
+			Expect(client.netSets["feed"].Spec.Nets).To(Equal([]string{"10.0.0.1/32", "10.0.0.2/32"}))
+		})
+
+		It("should only fetch the feed again once the refresh interval has passed", func() {
+			Expect(s.Poll(context.Background())).To(Succeed())
+			contents["/feed.txt"] = "10.0.0.3\n"
+
+			now = now.Add(59 * time.Second)
+			Expect(s.Poll(context.Background())).To(Succeed())
+			Expect(client.numUpdates).To(Equal(1))
+
+			now = now.Add(time.Second)
+			Expect(s.Poll(context.Background())).To(Succeed())
+			Expect(client.numUpdates).To(Equal(2))
+			Expect(client.netSets["feed"].Spec.Nets).To(Equal([]string{"10.0.0.3/32"}))
+		})
+
+		It("should use the default refresh interval", func() {
+			client.netSets["feed"].Spec.FeedRefresh = nil
+			Expect(s.Poll(context.Background())).To(Succeed())
+			contents["/feed.txt"] = "192.168.1.1\n"
+
+			now = now.Add(DefaultRefresh - time.Second)
+			Expect(s.Poll(context.Background())).To(Succeed())
+			Expect(client.netSets["feed"].Spec.Nets).To(Equal([]string{"10.0.0.1/32", "10.0.0.2/32"}))
+
+			now = now.Add(time.Second)
+			Expect(s.Poll(context.Background())).To(Succeed())
+			Expect(client.netSets["feed"].Spec.Nets).To(Equal([]string{"192.168.1.1/32"}))
+		})
+
+		It("should not update the network set if the feed is unchanged", func() {
+			Expect(s.Poll(context.Background())).To(Succeed())
+			now = now.Add(time.Minute)
+			Expect(s.Poll(context.Background())).To(Succeed())
+			Expect(client.numUpdates).To(Equal(1))
+		})
+
+		It("should fetch the feed straight away if the URL changes", func() {
+			Expect(s.Poll(context.Background())).To(Succeed())
+			contents["/other.txt"] = "10.0.0.9\n"
+			client.netSets["feed"].Spec.FeedURL = server.URL + "/other.txt"
+
+			Expect(s.Poll(context.Background())).To(Succeed())
+			Expect(client.netSets["feed"].Spec.Nets).To(Equal([]string{"10.0.0.9/32"}))
+		})
+
+		It("should remove expiries of nets that are no longer in the feed", func() {
+			client.netSets["feed"].Spec.Nets = []string{"10.0.0.1/32", "10.0.0.5/32"}
+			client.netSets["feed"].Spec.NetExpiries = map[string]metav1.Time{
+				"10.0.0.1/32": metav1.NewTime(now.Add(time.Hour)),
+				"10.0.0.5/32": metav1.NewTime(now.Add(time.Hour)),
+			}
+			Expect(s.Poll(context.Background())).To(Succeed())
+			Expect(client.netSets["feed"].Spec.NetExpiries).To(Equal(map[string]metav1.Time{
+				"10.0.0.1/32": metav1.NewTime(now.Add(time.Hour)),
+			}))
+		})
+
+		It("should retry on the next poll if the update fails", func() {
+			client.updateErr = errors.New("conflict")
+			Expect(s.Poll(context.Background())).NotTo(Succeed())
+			client.updateErr = nil
+			Expect(s.Poll(context.Background())).To(Succeed())
+			Expect(client.netSets["feed"].Spec.Nets).To(Equal([]string{"10.0.0.1/32", "10.0.0.2/32"}))
+		})
+
+		It("should sync other feeds if one of them fails", func() {
+			addNetSet("missing", server.URL+"/missing.txt", 0)
+			Expect(s.Poll(context.Background())).NotTo(Succeed())
+			Expect(client.netSets["feed"].Spec.Nets).To(Equal([]string{"10.0.0.1/32", "10.0.0.2/32"}))
+			Expect(client.netSets["missing"].Spec.Nets).To(BeNil())
+		})
+
+		It("should leave the network set alone if the server returns an error", func() {
+			status = http.StatusInternalServerError
+			Expect(s.Poll(context.Background())).NotTo(Succeed())
+			Expect(client.numUpdates).To(Equal(0))
+		})
+
+		It("should leave the network set alone if the feed has no valid entries", func() {
+			Expect(s.Poll(context.Background())).To(Succeed())
+			for _, c := range []string{"", "# Nothing here\n", "garbage\n"} {
+				contents["/feed.txt"] = c
+				now = now.Add(time.Minute)
+				Expect(s.Poll(context.Background())).NotTo(Succeed())
+				Expect(client.netSets["feed"].Spec.Nets).To(Equal([]string{"10.0.0.1/32", "10.0.0.2/32"}))
+			}
+		})
+	})
+
+	It("should not fetch from a host that isn't allowed", func() {
+		s = New(client, []string{"feeds.example.com"})
+		addNetSet("feed", server.URL+"/feed.txt", 0)
+		Expect(s.Poll(context.Background())).NotTo(Succeed())
+		Expect(client.numUpdates).To(Equal(0))
+	})
+
+	It("should not follow a redirect to a host that isn't allowed", func() {
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "10.0.0.7\n")
+		}))
+		defer other.Close()
+		// Reach the other server through "localhost", which isn't allowed.
+		otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+		server.Config.Handler = http.RedirectHandler(otherURL, http.StatusFound)
+		addNetSet("feed", server.URL+"/feed.txt", 0)
+
+		Expect(s.Poll(context.Background())).NotTo(Succeed())
+		Expect(client.numUpdates).To(Equal(0))
+	})
+
+	It("should not read files", func() {
+		addNetSet("feed", "file:///etc/hosts", 0)
+		Expect(s.Poll(context.Background())).NotTo(Succeed())
+		Expect(client.numUpdates).To(Equal(0))
+	})
+})
+
+type mockNetSetClient struct {
+	clientv3.GlobalNetworkSetInterface
+
+	netSets    map[string]*apiv3.GlobalNetworkSet
+	numUpdates int
+	updateErr  error
+}
+
+func (c *mockNetSetClient) List(ctx context.Context, opts options.ListOptions) (*apiv3.GlobalNetworkSetList, error) {
+	list := &apiv3.GlobalNetworkSetList{}
+	for _, netSet := range c.netSets {
+		list.Items = append(list.Items, *netSet.DeepCopy())
+	}
+	return list, nil
+}
+
+func (c *mockNetSetClient) Update(ctx context.Context, res *apiv3.GlobalNetworkSet, opts options.SetOptions) (*apiv3.GlobalNetworkSet, error) {
+	if c.updateErr != nil {
+		return nil, c.updateErr
+	}
+	c.numUpdates++
+	c.netSets[res.Name] = res.DeepCopy()
+	return res, nil
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xdpblocklist
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	rcache "github.com/projectcalico/calico/kube-controllers/pkg/cache"
+	"github.com/projectcalico/calico/kube-controllers/pkg/config"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/controller"
+	"github.com/projectcalico/calico/kube-controllers/pkg/converter"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	uruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+// XDPBlockListResource is the resource of the XDPBlockList CRD.
+var XDPBlockListResource = schema.GroupVersionResource{
+	Group:    "crd.projectcalico.org",
+	Version:  "v1",
+	Resource: "xdpblocklists",
+}
+
+// xdpBlockListController implements the Controller interface for managing XDPBlockLists and
+// syncing them to the Calico datastore as GlobalNetworkSets, so that users can manage XDP
+// blocklists with their own tooling.  It needs to be able to watch and list xdpblocklists and
+// to write globalnetworksets, which the calico-kube-controllers ClusterRole allows.
+type xdpBlockListController struct {
+	informer      cache.Controller
+	resourceCache rcache.ResourceCache
+	calicoClient  client.Interface
+	ctx           context.Context
+	cfg           config.GenericControllerConfig
+}
+
+// NewXDPBlockListController returns a controller which manages XDPBlockList objects.
+func NewXDPBlockListController(ctx context.Context, dynamicClient dynamic.Interface, c client.Interface, cfg config.GenericControllerConfig) controller.Controller {
+	xdpBlockListConverter := converter.NewXDPBlockListConverter()
+
+	// Function returns map of networkset_name:object stored by the XDP blocklist controller
+	// in the Calico datastore. Identifies controller written objects by their naming
+	// convention.
+	listFunc := func() (map[string]interface{}, error) {
+		log.Debugf("Listing GlobalNetworkSets from Calico datastore: to check for XDPBlockList")
+		filteredSets := make(map[string]interface{})
+
+		// Get all GlobalNetworkSet objects from Calico datastore.
+		setList, err := c.GlobalNetworkSets().List(ctx, options.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		// Filter out only objects that are written by this controller.
+		for _, gns := range setList.Items {
+			if strings.HasPrefix(gns.Name, converter.XDPBlockListNetworkSetPrefix) {
+				// Update the set's ObjectMeta so that it only contains what the converter
+				// writes.  There is other metadata that we might receive (like resource
+				// version) that we don't want to compare in the cache.
+				gns.ObjectMeta = metav1.ObjectMeta{Name: gns.Name, Labels: gns.Labels}
+				key := xdpBlockListConverter.GetKey(gns)
+				filteredSets[key] = gns
+			}
+		}
+		log.Debugf("Found %d XDPBlockList GlobalNetworkSets in Calico datastore", len(filteredSets))
+		return filteredSets, nil
+	}
+
+	// Create a Cache to store GlobalNetworkSets in.
+	cacheArgs := rcache.ResourceCacheArgs{
+		ListFunc:    listFunc,
+		ObjectType:  reflect.TypeOf(api.GlobalNetworkSet{}),
+		LogTypeDesc: "XDPBlockList",
+	}
+	ccache := rcache.NewResourceCache(cacheArgs)
+
+	// Create an XDPBlockList watcher.  XDPBlockList is a CRD, so watch it with the dynamic
+	// client.
+	resource := dynamicClient.Resource(XDPBlockListResource)
+	listWatcher := &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			return resource.List(ctx, opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return resource.Watch(ctx, opts)
+		},
+	}
+
+	// Bind the calico cache to kubernetes cache with the help of an informer. This way we make sure that
+	// whenever the kubernetes cache is updated, changes get reflected in the Calico cache as well.
+	_, informer := cache.NewIndexerInformer(listWatcher, &unstructured.Unstructured{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			log.Debugf("Got ADD event for XDPBlockList: %#v", obj)
+			gns, err := xdpBlockListConverter.Convert(obj)
+			if err != nil {
+				log.WithError(err).Errorf("Error while converting %#v to Calico GlobalNetworkSet.", obj)
+				return
+			}
+
+			// Add to cache.
+			k := xdpBlockListConverter.GetKey(gns)
+			ccache.Set(k, gns)
+		},
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			log.Debugf("Got UPDATE event for XDPBlockList")
+			log.Debugf("Old object: \n%#v\n", oldObj)
+			log.Debugf("New object: \n%#v\n", newObj)
+
+			// Convert the XDPBlockList into a GlobalNetworkSet.
+			gns, err := xdpBlockListConverter.Convert(newObj)
+			if err != nil {
+				log.WithError(err).Errorf("Error while converting %#v to Calico GlobalNetworkSet.", newObj)
+				return
+			}
+
+			// Update in the cache.
+			k := xdpBlockListConverter.GetKey(gns)
+			ccache.Set(k, gns)
+		},
+		DeleteFunc: func(obj interface{}) {
+			// Convert the XDPBlockList into a GlobalNetworkSet.
+			log.Debugf("Got DELETE event for XDPBlockList: %#v", obj)
+			gns, err := xdpBlockListConverter.Convert(obj)
+			if err != nil {
+				log.WithError(err).Errorf("Error converting %#v to Calico GlobalNetworkSet.", obj)
+				return
+			}
+
+			k := xdpBlockListConverter.GetKey(gns)
+			ccache.Delete(k)
+		},
+	}, cache.Indexers{})
+
+	return &xdpBlockListController{informer, ccache, c, ctx, cfg}
+}
+
+// Run starts the controller.
+func (c *xdpBlockListController) Run(stopCh chan struct{}) {
+	defer uruntime.HandleCrash()
+
+	// Let the workers stop when we are done
+	workqueue := c.resourceCache.GetQueue()
+	defer workqueue.ShutDown()
+
+	log.Info("Starting XDPBlockList/GlobalNetworkSet controller")
+
+	// Wait till k8s cache is synced
+	log.Debug("Waiting to sync with Kubernetes API (XDPBlockList)")
+	go c.informer.Run(stopCh)
+	if !cache.WaitForNamedCacheSync("xdp-blocklists", stopCh, c.informer.HasSynced) {
+		log.Info("Failed to sync resources, received signal for controller to shut down.")
+		return
+	}
+	log.Debug("Finished syncing with Kubernetes API (XDPBlockList)")
+
+	// Start Calico cache.
+	c.resourceCache.Run(c.cfg.ReconcilerPeriod.String())
+
+	// Start a number of worker threads to read from the queue.
+	for i := 0; i < c.cfg.NumberOfWorkers; i++ {
+		go c.runWorker()
+	}
+	log.Info("XDPBlockList/GlobalNetworkSet controller is now running")
+
+	<-stopCh
+	log.Info("Stopping XDPBlockList/GlobalNetworkSet controller")
+}
+
+func (c *xdpBlockListController) runWorker() {
+	for c.processNextItem() {
+	}
+}
+
+// processNextItem waits for an event on the output queue from the resource cache and syncs
+// any received keys to the datastore.
+func (c *xdpBlockListController) processNextItem() bool {
+	// Wait until there is a new item in the work queue.
+	workqueue := c.resourceCache.GetQueue()
+	key, quit := workqueue.Get()
+	if quit {
+		return false
+	}
+
+	// Sync the object to the Calico datastore.
+	err := c.syncToDatastore(key.(string))
+	c.handleErr(err, key.(string))
+
+	// Indicate that we're done processing this key, allowing for safe parallel processing such that
+	// two objects with the same key are never processed in parallel.
+	workqueue.Done(key)
+	return true
+}
+
+// syncToDatastore syncs the given update to the Calico datastore. The provided key can be used to
+// find the corresponding resource within the resource cache. If the resource for the provided key
+// exists in the cache, then the value should be written to the datastore. If it does not exist
+// in the cache, then it should be deleted from the datastore.
+func (c *xdpBlockListController) syncToDatastore(key string) error {
+	clog := log.WithField("key", key)
+
+	// Check if it exists in the controller's cache.
+	obj, exists := c.resourceCache.Get(key)
+	if !exists {
+		// The object no longer exists - delete from the datastore.
+		clog.Infof("Deleting XDPBlockList GlobalNetworkSet from Calico datastore")
+		_, name := converter.NewXDPBlockListConverter().DeleteArgsFromKey(key)
+		_, err := c.calicoClient.GlobalNetworkSets().Delete(c.ctx, name, options.DeleteOptions{})
+		if _, ok := err.(errors.ErrorResourceDoesNotExist); !ok {
+			// We hit an error other than "does not exist".
+			return err
+		}
+		return nil
+	} else {
+		// The object exists - update the datastore to reflect.
+		clog.Info("Create/Update XDPBlockList GlobalNetworkSet in Calico datastore")
+		gns := obj.(api.GlobalNetworkSet)
+
+		// Lookup to see if this object already exists in the datastore.
+		existing, err := c.calicoClient.GlobalNetworkSets().Get(c.ctx, gns.Name, options.GetOptions{})
+		if err != nil {
+			if _, ok := err.(errors.ErrorResourceDoesNotExist); !ok {
+				clog.WithError(err).Warning("Unexpected error for XDPBlockList GlobalNetworkSet from datastore")
+				return err
+			}
+
+			// Doesn't exist - create it.
+			_, err := c.calicoClient.GlobalNetworkSets().Create(c.ctx, &gns, options.SetOptions{})
+			if err != nil {
+				clog.WithError(err).Warning("Failed to create XDPBlockList GlobalNetworkSet")
+				return err
+			}
+			clog.Info("Successfully created XDPBlockList GlobalNetworkSet")
+			return nil
+		}
+
+		// The GlobalNetworkSet already exists, update it and write it back to the datastore.
+		existing.Labels = gns.Labels
+		existing.Spec = gns.Spec
+		clog.Infof("Update XDPBlockList GlobalNetworkSet in Calico datastore with resource version %s", existing.ResourceVersion)
+		_, err = c.calicoClient.GlobalNetworkSets().Update(c.ctx, existing, options.SetOptions{})
+		if err != nil {
+			clog.WithError(err).Warning("Failed to update XDPBlockList GlobalNetworkSet")
+			return err
+		}
+		clog.Infof("Successfully updated XDPBlockList GlobalNetworkSet")
+		return nil
+	}
+}
+
+// handleErr handles errors which occur while processing a key received from the resource cache.
+// For a given error, we will re-queue the key in order to retry the datastore sync up to 5 times,
+// at which point the update is dropped.
+func (c *xdpBlockListController) handleErr(err error, key string) {
+	workqueue := c.resourceCache.GetQueue()
+	if err == nil {
+		// Forget about the #AddRateLimited history of the key on every successful synchronization.
+		// This ensures that future processing of updates for this key is not delayed because of
+		// an outdated error history.
+		workqueue.Forget(key)
+		return
+	}
+
+	// This controller retries 5 times if something goes wrong. After that, it stops trying.
+	if workqueue.NumRequeues(key) < 5 {
+		// Re-enqueue the key rate limited. Based on the rate limiter on the
+		// queue and the re-enqueue history, the key will be processed later again.
+		log.WithError(err).Errorf("Error syncing GlobalNetworkSet %v: %v", key, err)
+		workqueue.AddRateLimited(key)
+		return
+	}
+	workqueue.Forget(key)
+
+	// Report to an external entity that, even after several retries, we could not successfully process this key
+	uruntime.HandleError(err)
+	log.WithError(err).Errorf("Dropping GlobalNetworkSet %q out of the queue: %v", key, err)
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter
+
+import (
+	"fmt"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	libapiv1 "github.com/projectcalico/calico/libcalico-go/lib/apis/crd.projectcalico.org/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// XDPBlockListNetworkSetPrefix is the prefix of the names of the GlobalNetworkSets that the
+// XDP blocklist controller writes, one per XDPBlockList.
+const XDPBlockListNetworkSetPrefix = "xdpblocklist."
+
+type xdpBlockListConverter struct {
+}
+
+// NewXDPBlockListConverter Constructor to convert XDPBlockList to GlobalNetworkSet
+func NewXDPBlockListConverter() Converter {
+	return &xdpBlockListConverter{}
+}
+
+func (c *xdpBlockListConverter) Convert(k8sObj interface{}) (interface{}, error) {
+	u, ok := k8sObj.(*unstructured.Unstructured)
+	if !ok {
+		tombstone, ok := k8sObj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			return nil, fmt.Errorf("couldn't get object from tombstone %+v", k8sObj)
+		}
+		u, ok = tombstone.Obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("tombstone contained object that is not an XDPBlockList %+v", k8sObj)
+		}
+	}
+	var blockList libapiv1.XDPBlockList
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &blockList); err != nil {
+		return nil, fmt.Errorf("failed to parse XDPBlockList %s: %w", u.GetName(), err)
+	}
+
+	gns := api.NewGlobalNetworkSet()
+	// Only keep the metadata that we write, so that changes to the rest of the XDPBlockList's
+	// metadata don't cause unnecessary updates.
+	gns.ObjectMeta = metav1.ObjectMeta{
+		Name:   XDPBlockListNetworkSetPrefix + blockList.Name,
+		Labels: blockList.Labels,
+	}
+	gns.Spec.Nets = blockList.Spec.Nets
+
+	return *gns, nil
+}
+
+// GetKey returns the name of the GlobalNetworkSet as its key.
+func (c *xdpBlockListConverter) GetKey(obj interface{}) string {
+	gns := obj.(api.GlobalNetworkSet)
+	return gns.Name
+}
+
+func (c *xdpBlockListConverter) DeleteArgsFromKey(key string) (string, string) {
+	// GlobalNetworkSets aren't namespaced, so just return the key, which is the name.
+	return "", key
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package converter_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/calico/kube-controllers/pkg/converter"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+var _ = Describe("XDPBlockList conversion tests", func() {
+
+	xdpConverter := converter.NewXDPBlockListConverter()
+
+	blockList := func() *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "crd.projectcalico.org/v1",
+			"kind":       "XDPBlockList",
+			"metadata": map[string]interface{}{
+				"name":            "feed",
+				"resourceVersion": "1234",
+				"labels":          map[string]interface{}{"xdpblocklist-set": "true"},
+			},
+			"spec": map[string]interface{}{
+				"nets": []interface{}{"10.0.0.1/32", "192.168.0.0/16"},
+			},
+		}}
+	}
+
+	It("should parse an XDPBlockList to a GlobalNetworkSet", func() {
+		gns, err := xdpConverter.Convert(blockList())
+		Expect(err).NotTo(HaveOccurred())
+
+		expected := api.NewGlobalNetworkSet()
+		expected.Name = "xdpblocklist.feed"
+		expected.Labels = map[string]string{"xdpblocklist-set": "true"}
+		expected.Spec.Nets = []string{"10.0.0.1/32", "192.168.0.0/16"}
+		Expect(gns).To(Equal(*expected))
+		Expect(xdpConverter.GetKey(gns)).To(Equal("xdpblocklist.feed"))
+	})
+
+	It("should parse an XDPBlockList with no nets", func() {
+		bl := blockList()
+		delete(bl.Object, "spec")
+		gns, err := xdpConverter.Convert(bl)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gns.(api.GlobalNetworkSet).Spec.Nets).To(BeEmpty())
+	})
+
+	It("should parse a tombstone", func() {
+		gns, err := xdpConverter.Convert(cache.DeletedFinalStateUnknown{Key: "feed", Obj: blockList()})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gns.(api.GlobalNetworkSet).Name).To(Equal("xdpblocklist.feed"))
+	})
+
+	It("should return an error for a tombstone of something else", func() {
+		_, err := xdpConverter.Convert(cache.DeletedFinalStateUnknown{Key: "feed", Obj: "not an XDPBlockList"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return an error for an XDPBlockList with malformed nets", func() {
+		bl := blockList()
+		bl.Object["spec"] = map[string]interface{}{"nets": "10.0.0.1/32"}
+		_, err := xdpConverter.Convert(bl)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return the name from the key", func() {
+		ns, name := xdpConverter.DeleteArgsFromKey("xdpblocklist.feed")
+		Expect(ns).To(Equal(""))
+		Expect(name).To(Equal("xdpblocklist.feed"))
+	})
+})
@@ -0,0 +1,41 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// XDPAutoBlocklist configures the automatic blocklisting of sources that Felix finds opening
+// connections faster than its XDPAutoBlocklistConnRate.  While the XDPAutoBlocklist named
+// "default" exists, the auto-blocklist controller in kube-controllers keeps a GlobalNetworkSet,
+// named "auto-blocklist.<node name>", of the sources that each node has found.  The set is
+// labelled projectcalico.org/auto-blocklist=true and scoped to its node, so an untracked deny
+// policy that selects it only drops the sources on the node that found them.
+// +kubebuilder:resource:scope=Cluster
+type XDPAutoBlocklist struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              XDPAutoBlocklistSpec `json:"spec,omitempty"`
+}
+
+// XDPAutoBlocklistSpec contains the specification for an XDPAutoBlocklist resource.
+type XDPAutoBlocklistSpec struct {
+	// How long a source stays in the blocklist after it was last found exceeding the
+	// connection rate.  Defaults to 5m.
+	Expiry *metav1.Duration `json:"expiry,omitempty"`
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// XDPBlockList is a list of IP networks to block, for blocklists that are managed by tools
+// outside Calico.  The XDP blocklist controller in kube-controllers keeps a GlobalNetworkSet,
+// named "xdpblocklist.<name>" and with the same labels, in step with each XDPBlockList, so
+// untracked policies can refer to it and Felix can program it into its XDP blocklists.
+// +kubebuilder:resource:scope=Cluster
+type XDPBlockList struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              XDPBlockListSpec `json:"spec,omitempty"`
+}
+
+// XDPBlockListSpec contains the specification for an XDPBlockList resource.
+type XDPBlockListSpec struct {
+	// The IP networks to block, as IP addresses or CIDRs.
+	Nets []string `json:"nets,omitempty" validate:"omitempty,dive,cidr"`
+}
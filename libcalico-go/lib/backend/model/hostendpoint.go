@@ -110,4 +110,5 @@ type HostEndpoint struct {
 	Labels            map[string]string `json:"labels,omitempty" validate:"omitempty,labels"`
 	ProfileIDs        []string          `json:"profile_ids,omitempty" validate:"omitempty,dive,name"`
 	Ports             []EndpointPort    `json:"ports,omitempty" validate:"dive"`
+	XDPMode           string            `json:"xdp_mode,omitempty"`
 }
@@ -21,6 +21,8 @@ import (
 
 	"reflect"
 
+	"time"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/projectcalico/calico/libcalico-go/lib/errors"
@@ -92,4 +94,11 @@ type NetworkSet struct {
 	Nets       []net.IPNet       `json:"nets,omitempty" validate:"omitempty,dive,cidr"`
 	Labels     map[string]string `json:"labels,omitempty" validate:"omitempty,labels"`
 	ProfileIDs []string          `json:"profile_ids,omitempty" validate:"omitempty,dive,name"`
+	// NetExpiries maps the string form of entries in Nets to the time at which they expire.
+	NetExpiries map[string]time.Time `json:"net_expiries,omitempty"`
+	// NetGraceUntil maps the string form of entries in Nets to the time at which their grace
+	// period ends.
+	NetGraceUntil map[string]time.Time `json:"net_grace_until,omitempty"`
+	// NetReasons maps the string form of entries in Nets to their reason codes.
+	NetReasons map[string]string `json:"net_reasons,omitempty"`
 }
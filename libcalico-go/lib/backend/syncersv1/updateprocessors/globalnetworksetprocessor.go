@@ -16,6 +16,7 @@ package updateprocessors
 
 import (
 	"errors"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -50,6 +51,9 @@ func convertGlobalNetworkSetV3ToV1(kvp *model.KVPair) (*model.KVPair, error) {
 	}
 
 	var addrs []cnet.IPNet
+	var expiries map[string]time.Time
+	var graceUntil map[string]time.Time
+	var reasons map[string]string
 	for _, cidrString := range v3res.Spec.Nets {
 		_, ipNet, err := cnet.ParseCIDROrIP(cidrString)
 		if err != nil {
@@ -61,11 +65,34 @@ func convertGlobalNetworkSetV3ToV1(kvp *model.KVPair) (*model.KVPair, error) {
 			continue
 		}
 		addrs = append(addrs, *ipNet)
+		if expiry, ok := v3res.Spec.NetExpiries[cidrString]; ok {
+			if expiries == nil {
+				expiries = map[string]time.Time{}
+			}
+			// Key on the normalised form of the CIDR so that consumers can look up
+			// the entries in Nets directly.
+			expiries[ipNet.String()] = expiry.Time
+		}
+		if until, ok := v3res.Spec.NetGraceUntil[cidrString]; ok {
+			if graceUntil == nil {
+				graceUntil = map[string]time.Time{}
+			}
+			graceUntil[ipNet.String()] = until.Time
+		}
+		if reason, ok := v3res.Spec.NetReasons[cidrString]; ok {
+			if reasons == nil {
+				reasons = map[string]string{}
+			}
+			reasons[ipNet.String()] = reason
+		}
 	}
 
 	v1value := &model.NetworkSet{
-		Labels: v3res.GetLabels(),
-		Nets:   addrs,
+		Labels:        v3res.GetLabels(),
+		Nets:          addrs,
+		NetExpiries:   expiries,
+		NetGraceUntil: graceUntil,
+		NetReasons:    reasons,
 	}
 
 	return &model.KVPair{
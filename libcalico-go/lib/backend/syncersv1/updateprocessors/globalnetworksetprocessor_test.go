@@ -0,0 +1,133 @@
+// Copyright (c) 2022 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package updateprocessors_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/syncersv1/updateprocessors"
+	"github.com/projectcalico/calico/libcalico-go/lib/net"
+)
+
+var _ = Describe("Test the GlobalNetworkSet update processor", func() {
+	name1 := "globalnetworkset-1"
+
+	v3GlobalNetworkSetKey1 := model.ResourceKey{
+		Kind: apiv3.KindGlobalNetworkSet,
+		Name: name1,
+	}
+	v1NetworkSetKey1 := model.NetworkSetKey{
+		Name: name1,
+	}
+
+	_, cidr1IPNet, _ := net.ParseCIDROrIP("1.2.3.0/24")
+	_, cidr2IPNet, _ := net.ParseCIDROrIP("1.2.3.123")
+
+	It("should convert the expiry times of entries", func() {
+		up := updateprocessors.NewGlobalNetworkSetUpdateProcessor()
+
+		expiry := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+		res := apiv3.NewGlobalNetworkSet()
+		res.Name = name1
+		res.Spec.Nets = []string{"1.2.3.0/24", "1.2.3.123"}
+		res.Spec.NetExpiries = map[string]metav1.Time{
+			"1.2.3.123": metav1.NewTime(expiry),
+		}
+
+		kvps, err := up.Process(&model.KVPair{
+			Key:      v3GlobalNetworkSetKey1,
+			Value:    res,
+			Revision: "abcde",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kvps).To(HaveLen(1))
+		Expect(kvps[0]).To(Equal(&model.KVPair{
+			Key: v1NetworkSetKey1,
+			Value: &model.NetworkSet{
+				Nets: []net.IPNet{*cidr1IPNet, *cidr2IPNet},
+				NetExpiries: map[string]time.Time{
+					"1.2.3.123/32": expiry,
+				},
+			},
+			Revision: "abcde",
+		}))
+	})
+
+	It("should convert the reasons for entries", func() {
+		up := updateprocessors.NewGlobalNetworkSetUpdateProcessor()
+
+		res := apiv3.NewGlobalNetworkSet()
+		res.Name = name1
+		res.Spec.Nets = []string{"1.2.3.0/24", "1.2.3.123"}
+		res.Spec.NetReasons = map[string]string{
+			"1.2.3.123": "threat-feed-x",
+		}
+
+		kvps, err := up.Process(&model.KVPair{
+			Key:      v3GlobalNetworkSetKey1,
+			Value:    res,
+			Revision: "abcde",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kvps).To(HaveLen(1))
+		Expect(kvps[0]).To(Equal(&model.KVPair{
+			Key: v1NetworkSetKey1,
+			Value: &model.NetworkSet{
+				Nets: []net.IPNet{*cidr1IPNet, *cidr2IPNet},
+				NetReasons: map[string]string{
+					"1.2.3.123/32": "threat-feed-x",
+				},
+			},
+			Revision: "abcde",
+		}))
+	})
+
+	It("should convert the grace periods of entries", func() {
+		up := updateprocessors.NewGlobalNetworkSetUpdateProcessor()
+
+		graceUntil := time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+		res := apiv3.NewGlobalNetworkSet()
+		res.Name = name1
+		res.Spec.Nets = []string{"1.2.3.0/24", "1.2.3.123"}
+		res.Spec.NetGraceUntil = map[string]metav1.Time{
+			"1.2.3.123": metav1.NewTime(graceUntil),
+		}
+
+		kvps, err := up.Process(&model.KVPair{
+			Key:      v3GlobalNetworkSetKey1,
+			Value:    res,
+			Revision: "abcde",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kvps).To(HaveLen(1))
+		Expect(kvps[0]).To(Equal(&model.KVPair{
+			Key: v1NetworkSetKey1,
+			Value: &model.NetworkSet{
+				Nets: []net.IPNet{*cidr1IPNet, *cidr2IPNet},
+				NetGraceUntil: map[string]time.Time{
+					"1.2.3.123/32": graceUntil,
+				},
+			},
+			Revision: "abcde",
+		}))
+	})
+})
@@ -78,6 +78,7 @@ func convertHostEndpointV2ToV1(kvp *model.KVPair) (*model.KVPair, error) {
 		Labels:            v3res.GetLabels(),
 		ProfileIDs:        v3res.Spec.Profiles,
 		Ports:             ports,
+		XDPMode:           string(v3res.Spec.XDPMode),
 	}
 
 	return &model.KVPair{
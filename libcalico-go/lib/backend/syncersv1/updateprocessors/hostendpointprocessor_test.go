@@ -89,6 +89,7 @@ var _ = Describe("Test the HostEndpoint update processor", func() {
 				Port:     uint16(8080),
 			},
 		}
+		res.Spec.XDPMode = apiv3.XDPModeGeneric
 
 		kvps, err = up.Process(&model.KVPair{
 			Key:      v3HostEndpointKey2,
@@ -111,6 +112,7 @@ var _ = Describe("Test the HostEndpoint update processor", func() {
 							Port:     uint16(8080),
 						},
 					},
+					XDPMode: "Generic",
 				},
 				Revision: "1234",
 			},
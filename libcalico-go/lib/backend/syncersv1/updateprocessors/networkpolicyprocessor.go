@@ -68,6 +68,7 @@ func convertNetworkPolicyV2ToV1Value(val interface{}) (interface{}, error) {
 		OutboundRules:  RulesAPIV2ToBackend(spec.Egress, v3res.Namespace),
 		Selector:       selector,
 		Types:          policyTypesAPIV2ToBackend(spec.Types),
+		DoNotTrack:     spec.DoNotTrack,
 		ApplyOnForward: true,
 	}
 
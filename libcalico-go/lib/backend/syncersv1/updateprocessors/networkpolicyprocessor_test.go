@@ -66,6 +66,10 @@ var _ = Describe("Test the NetworkPolicy update processor", func() {
 	allSASelector := fullNPv3("all-sa-selector", ns2, selector)
 	allSASelector.Spec.ServiceAccountSelector = "all()"
 
+	untrackedNPKey := model.ResourceKey{Kind: apiv3.KindNetworkPolicy, Name: "untracked", Namespace: ns2}
+	untrackedNP := fullNPv3("untracked", ns2, selector)
+	untrackedNP.Spec.DoNotTrack = true
+
 	Context("test processing of a valid NetworkPolicy from V3 to V1", func() {
 		up := updateprocessors.NewNetworkPolicyUpdateProcessor()
 
@@ -149,6 +153,16 @@ var _ = Describe("Test the NetworkPolicy update processor", func() {
 			Expect(kvps).To(Equal([]*model.KVPair{{Key: v1Key, Value: &policy, Revision: testRev}}))
 		})
 
+		It("should accept an untracked NetworkPolicy", func() {
+			kvps, err := up.Process(&model.KVPair{Key: untrackedNPKey, Value: untrackedNP, Revision: testRev})
+			Expect(err).NotTo(HaveOccurred())
+
+			policy := fullNPv1(ns2)
+			policy.Selector = `(mylabel == 'selectme') && projectcalico.org/namespace == 'namespace2'`
+			policy.DoNotTrack = true
+			v1Key := model.PolicyKey{Name: ns2 + "/untracked"}
+			Expect(kvps).To(Equal([]*model.KVPair{{Key: v1Key, Value: &policy, Revision: testRev}}))
+		})
 	})
 })
 
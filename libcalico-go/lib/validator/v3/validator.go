@@ -17,6 +17,7 @@ package v3
 import (
 	"fmt"
 	"net"
+	"net/url"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -87,6 +88,7 @@ var (
 	vxlanModeRegex        = regexp.MustCompile("^(Always|CrossSubnet|Never)$")
 	logLevelRegex         = regexp.MustCompile("^(Debug|Info|Warning|Error|Fatal)$")
 	bpfLogLevelRegex      = regexp.MustCompile("^(Debug|Info|Off)$")
+	xdpLogLevelRegex      = regexp.MustCompile("^(Debug|Error|Off)$")
 	bpfServiceModeRegex   = regexp.MustCompile("^(Tunnel|DSR)$")
 	datastoreType         = regexp.MustCompile("^(etcdv3|kubernetes)$")
 	routeSource           = regexp.MustCompile("^(WorkloadIPs|CalicoIPAM)$")
@@ -171,6 +173,7 @@ func init() {
 	registerFieldValidator("policyType", validatePolicyType)
 	registerFieldValidator("logLevel", validateLogLevel)
 	registerFieldValidator("bpfLogLevel", validateBPFLogLevel)
+	registerFieldValidator("xdpLogLevel", validateXDPLogLevel)
 	registerFieldValidator("bpfServiceMode", validateBPFServiceMode)
 	registerFieldValidator("dropAcceptReturn", validateFelixEtoHAction)
 	registerFieldValidator("acceptReturn", validateAcceptReturn)
@@ -423,6 +426,12 @@ func validateBPFLogLevel(fl validator.FieldLevel) bool {
 	return bpfLogLevelRegex.MatchString(s)
 }
 
+func validateXDPLogLevel(fl validator.FieldLevel) bool {
+	s := fl.Field().String()
+	log.Debugf("Validate Felix XDP log level: %s", s)
+	return xdpLogLevelRegex.MatchString(s)
+}
+
 func validateBPFServiceMode(fl validator.FieldLevel) bool {
 	s := fl.Field().String()
 	log.Debugf("Validate Felix BPF service mode: %s", s)
@@ -1519,6 +1528,37 @@ func validateGlobalNetworkSet(structLevel validator.StructLevel) {
 			)
 		}
 	}
+
+	if gns.Spec.FeedURL != "" {
+		if u, err := url.Parse(gns.Spec.FeedURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			structLevel.ReportError(
+				reflect.ValueOf(gns.Spec.FeedURL),
+				"GlobalNetworkSetSpec.FeedURL",
+				"",
+				reason("feed URL must use the http or https scheme"),
+				"",
+			)
+		}
+	}
+	if gns.Spec.FeedRefresh != nil && gns.Spec.FeedRefresh.Duration <= 0 {
+		structLevel.ReportError(
+			reflect.ValueOf(gns.Spec.FeedRefresh),
+			"GlobalNetworkSetSpec.FeedRefresh",
+			"",
+			reason("feed refresh interval must be positive"),
+			"",
+		)
+	}
+	if gns.Spec.FeedURL != "" && len(gns.Spec.ASNumbers) > 0 {
+		// Both replace Nets so they can't be used together.
+		structLevel.ReportError(
+			reflect.ValueOf(gns.Spec.ASNumbers),
+			"GlobalNetworkSetSpec.ASNumbers",
+			"",
+			reason("AS numbers can't be combined with a feed URL"),
+			"",
+		)
+	}
 }
 
 func validateGlobalNetworkPolicy(structLevel validator.StructLevel) {
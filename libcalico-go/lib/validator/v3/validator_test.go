@@ -359,6 +359,41 @@ func init() {
 			},
 			false,
 		),
+		Entry("should accept GlobalNetworkSetSpec with reasons",
+			api.GlobalNetworkSetSpec{
+				Nets:       []string{"10.0.0.1", "11.0.0.0/8"},
+				NetReasons: map[string]string{"10.0.0.1": "threat-feed-x"},
+			},
+			true,
+		),
+		Entry("should reject GlobalNetworkSetSpec with a reason for a bad CIDR",
+			api.GlobalNetworkSetSpec{
+				Nets:       []string{"10.0.0.1"},
+				NetReasons: map[string]string{"garbage": "threat-feed-x"},
+			},
+			false,
+		),
+		Entry("should reject GlobalNetworkSetSpec with a reason that's too long",
+			api.GlobalNetworkSetSpec{
+				Nets:       []string{"10.0.0.1"},
+				NetReasons: map[string]string{"10.0.0.1": "threat-feed-with-a-very-long-name"},
+			},
+			false,
+		),
+		Entry("should accept GlobalNetworkSetSpec with a grace period for an entry",
+			api.GlobalNetworkSetSpec{
+				Nets:          []string{"10.0.0.1", "11.0.0.0/8"},
+				NetGraceUntil: map[string]v1.Time{"10.0.0.1": v1.Now()},
+			},
+			true,
+		),
+		Entry("should reject GlobalNetworkSetSpec with a grace period for a bad CIDR",
+			api.GlobalNetworkSetSpec{
+				Nets:          []string{"10.0.0.1"},
+				NetGraceUntil: map[string]v1.Time{"garbage": v1.Now()},
+			},
+			false,
+		),
 		Entry("should accept GlobalNetworkSet with labels",
 			api.GlobalNetworkSet{
 				ObjectMeta: v1.ObjectMeta{
@@ -387,6 +422,72 @@ func init() {
 			},
 			false,
 		),
+		Entry("should accept GlobalNetworkSet with an HTTP feed",
+			api.GlobalNetworkSet{
+				ObjectMeta: v1.ObjectMeta{Name: "testset"},
+				Spec: api.GlobalNetworkSetSpec{
+					FeedURL:     "https://feeds.example.com/blocklist.txt",
+					FeedRefresh: &v1.Duration{Duration: time.Minute},
+				},
+			},
+			true,
+		),
+		Entry("should reject GlobalNetworkSet with a file feed",
+			api.GlobalNetworkSet{
+				ObjectMeta: v1.ObjectMeta{Name: "testset"},
+				Spec: api.GlobalNetworkSetSpec{
+					FeedURL: "file:///etc/calico/blocklist.txt",
+				},
+			},
+			false,
+		),
+		Entry("should reject GlobalNetworkSet with an unsupported feed scheme",
+			api.GlobalNetworkSet{
+				ObjectMeta: v1.ObjectMeta{Name: "testset"},
+				Spec: api.GlobalNetworkSetSpec{
+					FeedURL: "ftp://feeds.example.com/blocklist.txt",
+				},
+			},
+			false,
+		),
+		Entry("should reject GlobalNetworkSet with a feed URL that isn't a URL",
+			api.GlobalNetworkSet{
+				ObjectMeta: v1.ObjectMeta{Name: "testset"},
+				Spec: api.GlobalNetworkSetSpec{
+					FeedURL: "blocklist.txt",
+				},
+			},
+			false,
+		),
+		Entry("should reject GlobalNetworkSet with a zero feed refresh interval",
+			api.GlobalNetworkSet{
+				ObjectMeta: v1.ObjectMeta{Name: "testset"},
+				Spec: api.GlobalNetworkSetSpec{
+					FeedURL:     "https://feeds.example.com/blocklist.txt",
+					FeedRefresh: &v1.Duration{},
+				},
+			},
+			false,
+		),
+		Entry("should accept GlobalNetworkSet with AS numbers",
+			api.GlobalNetworkSet{
+				ObjectMeta: v1.ObjectMeta{Name: "testset"},
+				Spec: api.GlobalNetworkSetSpec{
+					ASNumbers: []numorstring.ASNumber{64512, 4200000000},
+				},
+			},
+			true,
+		),
+		Entry("should reject GlobalNetworkSet with both AS numbers and a feed",
+			api.GlobalNetworkSet{
+				ObjectMeta: v1.ObjectMeta{Name: "testset"},
+				Spec: api.GlobalNetworkSetSpec{
+					FeedURL:   "https://feeds.example.com/blocklist.txt",
+					ASNumbers: []numorstring.ASNumber{64512},
+				},
+			},
+			false,
+		),
 		Entry("should reject GlobalNetworkSet with bad name",
 			api.GlobalNetworkSet{
 				ObjectMeta: v1.ObjectMeta{
@@ -729,6 +830,10 @@ func init() {
 		Entry("should accept a valid BPFLogLevel value 'Info'", api.FelixConfigurationSpec{BPFLogLevel: "Info"}, true),
 		Entry("should accept a valid BPFLogLevel value 'Debug'", api.FelixConfigurationSpec{BPFLogLevel: "Debug"}, true),
 		Entry("should accept a valid BPFLogLevel value 'Off'", api.FelixConfigurationSpec{BPFLogLevel: "Off"}, true),
+		Entry("should reject an invalid XDPLogLevel value 'Info'", api.FelixConfigurationSpec{XDPLogLevel: "Info"}, false),
+		Entry("should accept a valid XDPLogLevel value 'Error'", api.FelixConfigurationSpec{XDPLogLevel: "Error"}, true),
+		Entry("should accept a valid XDPLogLevel value 'Debug'", api.FelixConfigurationSpec{XDPLogLevel: "Debug"}, true),
+		Entry("should accept a valid XDPLogLevel value 'Off'", api.FelixConfigurationSpec{XDPLogLevel: "Off"}, true),
 
 		Entry("should reject a valid BPFExternalServiceMode value 'Foo'", api.FelixConfigurationSpec{BPFExternalServiceMode: "Foo"}, false),
 		Entry("should accept a valid BPFExternalServiceMode value 'Tunnel'", api.FelixConfigurationSpec{BPFExternalServiceMode: "Tunnel"}, true),
@@ -913,6 +1018,24 @@ func init() {
 				ExpectedIPs:   []string{ipv4_1, ipv6_1},
 				Node:          "node01",
 			}, true),
+		Entry("should accept host endpoint with native XDP mode",
+			api.HostEndpointSpec{
+				InterfaceName: "eth0",
+				Node:          "node01",
+				XDPMode:       api.XDPModeNative,
+			}, true),
+		Entry("should accept host endpoint with generic XDP mode",
+			api.HostEndpointSpec{
+				InterfaceName: "eth0",
+				Node:          "node01",
+				XDPMode:       api.XDPModeGeneric,
+			}, true),
+		Entry("should reject host endpoint with an unknown XDP mode",
+			api.HostEndpointSpec{
+				InterfaceName: "eth0",
+				Node:          "node01",
+				XDPMode:       "Offload",
+			}, false),
 		Entry("should reject host endpoint with no config", api.HostEndpointSpec{}, false),
 		Entry("should reject host endpoint with blank interface an no IPs",
 			api.HostEndpointSpec{